@@ -0,0 +1,84 @@
+package gdquery
+
+import (
+	"os"
+	"strings"
+)
+
+// Annotation options
+var annotateTree = false
+var annotateNoEmoji = false
+
+// NodeMarker is a status marker shown after a node with --annotate
+type NodeMarker struct {
+	Emoji string
+	Text  string
+}
+
+// Node markers, in display order
+var (
+	markerMissingScript = NodeMarker{Emoji: "❌", Text: "[missing-script]"}
+	markerInstance      = NodeMarker{Emoji: "↪", Text: "[instance]"}
+	markerSignals       = NodeMarker{Emoji: "⚡", Text: "[signals]"}
+	markerHidden        = NodeMarker{Emoji: "👻", Text: "[hidden]"}
+)
+
+// scriptMissing reports whether the script of a node cannot be found: its
+// ext_resource does not exist or points to a file missing from the project
+func scriptMissing(node *GodotNode, scene *GodotScene) bool {
+	if node.Script == "" {
+		return false
+	}
+	script := resolveResourcePath(node.Script, scene)
+	if script == "" {
+		return true
+	}
+	if strings.HasPrefix(script, "SubResource(") || strings.HasPrefix(script, "uid://") {
+		// Built-in scripts live in the scene; uids cannot be resolved without the editor cache
+		return false
+	}
+	root := findProjectRoot(scene.File)
+	_, err := os.Stat(resToFS(root, normalizeResPath(fsToRes(root, scene.File), script)))
+	return err != nil
+}
+
+// nodeMarkers returns the markers that apply to a node
+func nodeMarkers(node *GodotNode, scene *GodotScene) []NodeMarker {
+	var markers []NodeMarker
+	if scriptMissing(node, scene) {
+		markers = append(markers, markerMissingScript)
+	}
+	if node.Instance != "" {
+		markers = append(markers, markerInstance)
+	}
+	for _, connection := range scene.Connections {
+		if connectionNodePath(scene, connection.From) == node.Path {
+			markers = append(markers, markerSignals)
+			break
+		}
+	}
+	if node.Properties["visible"] == "false" {
+		markers = append(markers, markerHidden)
+	}
+	return markers
+}
+
+// markerAnnotation formats the markers of a node for the tree, as emoji or
+// as text with --no-emoji
+func markerAnnotation(node *GodotNode, scene *GodotScene) string {
+	if !annotateTree {
+		return ""
+	}
+	var labels []string
+	for _, marker := range nodeMarkers(node, scene) {
+		if annotateNoEmoji {
+			labels = append(labels, marker.Text)
+		} else {
+			labels = append(labels, marker.Emoji)
+		}
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	return " " + strings.Join(labels, " ")
+}
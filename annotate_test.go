@@ -0,0 +1,61 @@
+package gdquery
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnnotateTree(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Script" path="res://main.gd" id="1_a"]
+[ext_resource type="Script" path="res://gone.gd" id="2_b"]
+[ext_resource type="PackedScene" path="res://enemy.tscn" id="3_c"]
+
+[node name="Main" type="Node2D"]
+script = ExtResource("1_a")
+
+[node name="Broken" type="Node" parent="."]
+script = ExtResource("2_b")
+
+[node name="Enemy" parent="." instance=ExtResource("3_c")]
+visible = false
+
+[node name="Button" type="Button" parent="."]
+
+[connection signal="pressed" from="Button" to="." method="_on_pressed"]
+`,
+		"main.gd": "extends Node2D\n",
+	})
+
+	scene, err := ParseTscnFile(filepath.Join(root, "main.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	defer func() { annotateTree, annotateNoEmoji = false, false }()
+	annotateTree = true
+	annotateNoEmoji = true
+	output := captureOutput(func(out io.Writer) {
+		printSceneTree(out, scene.RootNode, scene)
+	})
+
+	for _, expected := range []string{
+		"Main (Node2D) [Script: res://main.gd]\n",
+		"Broken (Node) [Script: res://gone.gd] [missing-script]\n",
+		"Enemy () [instance] [hidden]\n",
+		"Button (Button) [signals]\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, output)
+		}
+	}
+
+	annotateNoEmoji = false
+	if got := markerAnnotation(scene.AllNodes[2], scene); got != " ↪ 👻" {
+		t.Errorf("Unexpected emoji markers: %q", got)
+	}
+}
@@ -0,0 +1,182 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Assert command options
+var assertExists []string
+var assertTypes []string
+var assertProps []string
+var assertQuiet = false
+
+// AssertionResult is the outcome of one structural assertion on a scene
+type AssertionResult struct {
+	Assertion string `json:"assertion"` // as given, e.g. "--type Player:CharacterBody2D"
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message,omitempty"` // why the assertion failed
+}
+
+// findAssertedNode returns the node at path: "." for the root, a path from
+// the scene root ("HUD/Score") or a full path starting with the root name
+func findAssertedNode(scene *GodotScene, path string) *GodotNode {
+	root := scene.RootNode
+	if root == nil {
+		return nil
+	}
+	if path == "." || path == root.Path {
+		return root
+	}
+	if node := findNodeByExactPath(root, root.Path+"/"+path); node != nil {
+		return node
+	}
+	return findNodeByExactPath(root, path)
+}
+
+// assertPropertyValue reports whether a property value matches the expected
+// one: as written, as a number or vector with other spacing, or unquoted. An
+// unset property has its class default.
+func assertPropertyValue(node *GodotNode, key, expected string) (bool, string) {
+	value, exists := node.Properties[key]
+	if !exists {
+		value, exists = propertyDefault(nodeClass(node), key)
+	}
+	if !exists {
+		return false, fmt.Sprintf("%s is not set", key)
+	}
+	if value == expected || normalizeVariant(value) == normalizeVariant(expected) || unquoteValue(value) == expected {
+		return true, ""
+	}
+	return false, fmt.Sprintf("%s is %s", key, value)
+}
+
+// runAssertions checks the --exists, --type and --prop assertions on a scene
+func runAssertions(scene *GodotScene, exists, types, props []string) ([]*AssertionResult, error) {
+	var results []*AssertionResult
+	check := func(assertion, path string, test func(node *GodotNode) (bool, string)) {
+		result := &AssertionResult{Assertion: assertion}
+		if node := findAssertedNode(scene, path); node == nil {
+			result.Message = "node not found: " + path
+		} else {
+			result.Passed, result.Message = test(node)
+		}
+		results = append(results, result)
+	}
+
+	for _, path := range exists {
+		check("--exists "+path, path, func(node *GodotNode) (bool, string) {
+			return true, ""
+		})
+	}
+	for _, assertion := range types {
+		path, class, found := strings.Cut(assertion, ":")
+		if !found || path == "" || class == "" {
+			return nil, fmt.Errorf("invalid --type %q: expected <node path>:<type>", assertion)
+		}
+		check("--type "+assertion, path, func(node *GodotNode) (bool, string) {
+			if conformsToType(node, []string{class}) {
+				return true, ""
+			}
+			return false, "type is " + typeLabel(node)
+		})
+	}
+	for _, assertion := range props {
+		target, expected, found := strings.Cut(assertion, "=")
+		dot := strings.LastIndex(target, ".")
+		if !found || dot < 0 || dot == len(target)-1 {
+			return nil, fmt.Errorf("invalid --prop %q: expected <node path>.<property>=<value>", assertion)
+		}
+		path, key := target[:dot], target[dot+1:]
+		if path == "" {
+			// ".visible=true" asserts on the root
+			path = "."
+		}
+		check("--prop "+assertion, path, func(node *GodotNode) (bool, string) {
+			return assertPropertyValue(node, key, expected)
+		})
+	}
+	return results, nil
+}
+
+// printAssertionResults displays one line per assertion, or only the failed
+// ones when quiet
+func printAssertionResults(out io.Writer, results []*AssertionResult, quiet bool) {
+	for _, result := range results {
+		switch {
+		case !result.Passed:
+			fmt.Fprintf(out, "FAIL  %s: %s\n", result.Assertion, result.Message)
+		case !quiet:
+			fmt.Fprintf(out, "ok    %s\n", result.Assertion)
+		}
+	}
+}
+
+var assertCmd = &cobra.Command{
+	Use:   "assert <tscn file>",
+	Short: "Check structural assertions on a scene for CI",
+	Long: `Check structural invariants of a scene and print pass/fail per assertion. Exits non-zero when
+an assertion fails, to codify the structure of key scenes in game CI.
+
+Node paths are relative to the scene root ("HUD/Score", "." for the root) or start with the
+root name ("Player/Camera2D"). --type passes for the type, its base classes and script
+classes. --prop compares raw values, ignoring number formatting, and accepts strings without
+their quotes; an unset property has its class default. All flags are repeatable.`,
+	Example: `  gdq assert player.tscn --exists 'Player/Camera2D' --type 'Player:CharacterBody2D'
+  gdq assert --quiet hud.tscn --prop 'HUD/Score.text=0' --prop 'HUD.visible=true'`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		if len(assertExists)+len(assertTypes)+len(assertProps) == 0 {
+			return fmt.Errorf("no assertions: use --exists, --type or --prop")
+		}
+		file := args[0]
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s", file)
+		}
+		scene, err := parseSceneFile(file)
+		if err != nil {
+			return fmt.Errorf("parse error: %v", err)
+		}
+
+		results, err := runAssertions(scene, assertExists, assertTypes, assertProps)
+		if err != nil {
+			return err
+		}
+		if outputFormat == "json" {
+			if err := writeJSON(out, results); err != nil {
+				return err
+			}
+		} else {
+			printAssertionResults(out, results, assertQuiet)
+		}
+
+		failed := 0
+		for _, result := range results {
+			if !result.Passed {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d assertion(s) failed", failed, len(results))
+		}
+		return nil
+	},
+}
+
+func init() {
+	assertCmd.Flags().StringArrayVar(&assertExists, "exists", nil, "Node path that must exist (repeatable)")
+	assertCmd.Flags().StringArrayVar(&assertTypes, "type", nil, "<node path>:<type> the node must be of (repeatable)")
+	assertCmd.RegisterFlagCompletionFunc("type", completeAssertType)
+	assertCmd.Flags().StringArrayVar(&assertProps, "prop", nil, "<node path>.<property>=<value> the node must have (repeatable)")
+	assertCmd.Flags().BoolVar(&assertQuiet, "quiet", false, "Print only the failed assertions")
+	rootCmd.AddCommand(assertCmd)
+}
@@ -0,0 +1,93 @@
+package gdquery
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunAssertions(t *testing.T) {
+	content := `[gd_scene format=3]
+
+[node name="Player" type="CharacterBody2D"]
+
+[node name="Camera2D" type="Camera2D" parent="."]
+zoom = Vector2(2, 2)
+
+[node name="HUD" type="CanvasLayer" parent="."]
+
+[node name="Score" type="Label" parent="HUD"]
+text = "0"
+`
+	scene, err := ParseReader(strings.NewReader(content), "player.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	results, err := runAssertions(scene,
+		[]string{"Player/Camera2D", "HUD/Score", "HUD/Lives"},
+		[]string{"Player:CharacterBody2D", "Camera2D:Node2D", ".:Node3D"},
+		[]string{"HUD/Score.text=0", "Camera2D.zoom=Vector2(2.0,2.0)", "HUD/Score.visible=true", ".motion_mode=0", "Camera2D.zoom=Vector2(1, 1)", "HUD/Lives.text=1"})
+	if err != nil {
+		t.Fatalf("Assert error: %v", err)
+	}
+
+	var lines []string
+	for _, result := range results {
+		line := "ok " + result.Assertion
+		if !result.Passed {
+			line = "FAIL " + result.Assertion + ": " + result.Message
+		}
+		lines = append(lines, line)
+	}
+	expected := []string{
+		"ok --exists Player/Camera2D",
+		"ok --exists HUD/Score",
+		"FAIL --exists HUD/Lives: node not found: HUD/Lives",
+		"ok --type Player:CharacterBody2D",
+		"ok --type Camera2D:Node2D",
+		"FAIL --type .:Node3D: type is CharacterBody2D",
+		"ok --prop HUD/Score.text=0",
+		"ok --prop Camera2D.zoom=Vector2(2.0,2.0)",
+		"ok --prop HUD/Score.visible=true",
+		"ok --prop .motion_mode=0",
+		"FAIL --prop Camera2D.zoom=Vector2(1, 1): zoom is Vector2(2, 2)",
+		"FAIL --prop HUD/Lives.text=1: node not found: HUD/Lives",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected results:\n%s", strings.Join(lines, "\n"))
+	}
+
+	for _, invalid := range []string{"Score", "HUD/Score.=1", "HUD/Score.text"} {
+		if _, err := runAssertions(scene, nil, nil, []string{invalid}); err == nil {
+			t.Errorf("Expected an error for --prop %q", invalid)
+		}
+	}
+	if _, err := runAssertions(scene, nil, []string{"Player"}, nil); err == nil {
+		t.Error("Expected an error for --type without a type")
+	}
+}
+
+func TestRunAssertionsRepeatedNames(t *testing.T) {
+	// The sample repeats Control and TextureRect2 at different depths
+	scene, err := ParseFile(filepath.Join("test", "sample.tscn"), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	results, err := runAssertions(scene,
+		[]string{"Control/scrapScene/Control/Button", "Control/battleScene/Control/TextureRect2", "Control/scrapScene/Control/TextureRect2"},
+		[]string{"Control/scrapScene/Control/Button:Button"},
+		[]string{"Control/battleScene/Control/TextureRect2.offset_bottom=176"})
+	if err != nil {
+		t.Fatalf("Assertion error: %v", err)
+	}
+	var failed []string
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result.Assertion)
+		}
+	}
+	if strings.Join(failed, ",") != "--exists Control/scrapScene/Control/TextureRect2" {
+		t.Errorf("Unexpected failures: %v", failed)
+	}
+}
@@ -0,0 +1,221 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultBusLayout is the bus layout Godot loads when the project does not set one
+const defaultBusLayout = "res://default_bus_layout.tres"
+
+// busNameRe matches bus/<index>/name in an AudioBusLayout resource
+var busNameRe = regexp.MustCompile(`^bus/(\d+)/name$`)
+
+// AudioBus is a bus of the project bus layout
+type AudioBus struct {
+	Index int
+	Name  string
+	Send  string
+}
+
+// AudioPlayer is an AudioStreamPlayer(2D/3D) node found in a scene
+type AudioPlayer struct {
+	File     string
+	Node     *GodotNode
+	Stream   string
+	Bus      string
+	VolumeDB float64
+	Autoplay bool
+}
+
+// unquoteName returns the string of a String or StringName (&"...") value
+func unquoteName(value string) string {
+	return unquoteValue(strings.TrimPrefix(strings.TrimSpace(value), "&"))
+}
+
+// loadBusLayout reads the buses of the project bus layout. Master always exists,
+// even when the layout file is missing.
+func loadBusLayout(root string, project *ConfigFile) ([]*AudioBus, string, error) {
+	layout := project.GetString("audio", "buses/default_bus_layout")
+	if layout == "" {
+		// Godot 3
+		layout = project.GetString("audio", "default_bus_layout")
+	}
+	if layout == "" {
+		layout = defaultBusLayout
+	}
+
+	buses := []*AudioBus{{Index: 0, Name: "Master"}}
+	content, err := os.ReadFile(resToFS(root, layout))
+	if os.IsNotExist(err) {
+		return buses, layout, nil
+	} else if err != nil {
+		return nil, layout, err
+	}
+
+	section := splitSceneText(string(content)).resourceSection()
+	if section == nil {
+		return buses, layout, nil
+	}
+
+	byIndex := map[int]*AudioBus{0: buses[0]}
+	for _, line := range section.Lines {
+		key, value, ok := splitPropertyLine(line)
+		if !ok {
+			continue
+		}
+		matches := busNameRe.FindStringSubmatch(key)
+		if matches == nil {
+			continue
+		}
+		index, _ := strconv.Atoi(matches[1])
+		bus := byIndex[index]
+		if bus == nil {
+			bus = &AudioBus{Index: index}
+			byIndex[index] = bus
+			buses = append(buses, bus)
+		}
+		bus.Name = unquoteName(value)
+		if send, exists := section.Property(fmt.Sprintf("bus/%d/send", index)); exists {
+			bus.Send = unquoteName(send)
+		}
+	}
+
+	sort.Slice(buses, func(i, j int) bool {
+		return buses[i].Index < buses[j].Index
+	})
+	return buses, layout, nil
+}
+
+// findAudioPlayers returns the audio players of the scanned scenes
+func findAudioPlayers(results []*SceneScanResult) []*AudioPlayer {
+	var players []*AudioPlayer
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		for _, node := range result.Scene.AllNodes {
+			// Godot 3 used AudioStreamPlayer3D too, so the name prefix covers both versions
+			if !strings.HasPrefix(node.Type, "AudioStreamPlayer") {
+				continue
+			}
+			player := &AudioPlayer{
+				File:     result.File,
+				Node:     node,
+				Bus:      "Master",
+				VolumeDB: floatProperty(node, 0, "volume_db"),
+				Autoplay: node.Properties["autoplay"] == "true",
+			}
+			if bus, exists := node.Properties["bus"]; exists {
+				player.Bus = unquoteName(bus)
+			}
+			if stream, exists := node.Properties["stream"]; exists {
+				player.Stream = resolveResourcePath(stream, result.Scene)
+				if player.Stream == "" {
+					player.Stream = stream
+				}
+			}
+			players = append(players, player)
+		}
+	}
+	return players
+}
+
+// printAudioPlayers displays one row per audio player
+func printAudioPlayers(out io.Writer, players []*AudioPlayer, known map[string]bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCENE\tNODE\tTYPE\tSTREAM\tBUS\tVOLUME DB\tAUTOPLAY")
+	for _, player := range players {
+		stream := player.Stream
+		if stream == "" {
+			stream = "-"
+		}
+		bus := player.Bus
+		if !known[bus] {
+			bus += " (MISSING)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%t\n",
+			player.File, player.Node.Path, player.Node.Type, stream, bus, formatLayoutNumber(player.VolumeDB), player.Autoplay)
+	}
+	w.Flush()
+}
+
+var audioCmd = &cobra.Command{
+	Use:   "audio [project dir]",
+	Short: "List audio players with their streams and buses",
+	Long: `List all AudioStreamPlayer, AudioStreamPlayer2D and AudioStreamPlayer3D nodes with their stream,
+bus, volume_db and autoplay settings, and check the buses against the project bus layout.
+Exits non-zero when a player or bus send refers to a bus that does not exist.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		root := findProjectRoot(dir)
+		project, err := parseConfigFile(resToFS(root, "res://project.godot"))
+		if err != nil {
+			project = &ConfigFile{}
+		}
+
+		buses, layout, err := loadBusLayout(root, project)
+		if err != nil {
+			return fmt.Errorf("bus layout error: %v", err)
+		}
+		known := make(map[string]bool)
+		var names []string
+		for _, bus := range buses {
+			known[bus.Name] = true
+			names = append(names, bus.Name)
+		}
+
+		results, err := scanProject(root, dir, ParseOptions{Logger: logger})
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+		players := findAudioPlayers(results)
+
+		printAudioPlayers(out, players, known)
+		fmt.Fprintf(out, "\nBuses (%s): %s\n", layout, strings.Join(names, ", "))
+
+		var problems []string
+		for _, bus := range buses {
+			if bus.Send != "" && !known[bus.Send] {
+				problems = append(problems, fmt.Sprintf("bus %s sends to missing bus %s", bus.Name, bus.Send))
+			}
+		}
+		for _, player := range players {
+			if !known[player.Bus] {
+				problems = append(problems, fmt.Sprintf("%s:%s uses missing bus %s", player.File, player.Node.Path, player.Bus))
+			}
+		}
+		if len(problems) > 0 {
+			fmt.Fprintln(out, "\n=== Problems ===")
+			for _, problem := range problems {
+				fmt.Fprintln(out, problem)
+			}
+			return fmt.Errorf("found %d audio bus problem(s)", len(problems))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(audioCmd)
+}
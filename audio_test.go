@@ -0,0 +1,72 @@
+package gdquery
+
+import (
+	"testing"
+)
+
+func TestAudioPlayersAndBuses(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": "[audio]\n\nbuses/default_bus_layout=\"res://audio/buses.tres\"\n",
+		"audio/buses.tres": `[gd_resource type="AudioBusLayout" format=3]
+
+[resource]
+bus/1/name = &"Music"
+bus/1/volume_db = -6.0
+bus/1/send = &"Master"
+bus/2/name = &"SFX"
+bus/2/send = &"Effects"
+`,
+		"level.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="AudioStream" path="res://audio/theme.ogg" id="1_music"]
+
+[node name="Level" type="Node2D"]
+
+[node name="Music" type="AudioStreamPlayer" parent="."]
+stream = ExtResource("1_music")
+volume_db = -3.5
+autoplay = true
+bus = &"Music"
+
+[node name="Step" type="AudioStreamPlayer2D" parent="."]
+bus = &"Footsteps"
+
+[node name="Ambience" type="AudioStreamPlayer3D" parent="."]
+`,
+	})
+
+	project, err := parseConfigFile(resToFS(root, "res://project.godot"))
+	if err != nil {
+		t.Fatalf("Project error: %v", err)
+	}
+	buses, layout, err := loadBusLayout(root, project)
+	if err != nil {
+		t.Fatalf("Bus layout error: %v", err)
+	}
+	if layout != "res://audio/buses.tres" || len(buses) != 3 {
+		t.Fatalf("Unexpected buses from %s: %d", layout, len(buses))
+	}
+	if buses[0].Name != "Master" || buses[1].Name != "Music" || buses[2].Name != "SFX" || buses[2].Send != "Effects" {
+		t.Errorf("Unexpected buses: %+v %+v %+v", buses[0], buses[1], buses[2])
+	}
+
+	results, err := scanProject(root, root, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	players := findAudioPlayers(results)
+	if len(players) != 3 {
+		t.Fatalf("Expected 3 players, got %d", len(players))
+	}
+
+	music := players[0]
+	if music.Stream != "res://audio/theme.ogg" || music.Bus != "Music" || music.VolumeDB != -3.5 || !music.Autoplay {
+		t.Errorf("Unexpected music player: %+v", music)
+	}
+	if players[1].Bus != "Footsteps" {
+		t.Errorf("Expected Footsteps bus, got %s", players[1].Bus)
+	}
+	if players[2].Bus != "Master" || players[2].Stream != "" {
+		t.Errorf("Expected defaults for Ambience, got %+v", players[2])
+	}
+}
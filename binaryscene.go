@@ -0,0 +1,710 @@
+package gdquery
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Value types of binary resources (resource_format_binary.cpp)
+const (
+	variantNil                = 1
+	variantBool               = 2
+	variantInt                = 3
+	variantFloat              = 4
+	variantString             = 5
+	variantVector2            = 10
+	variantRect2              = 11
+	variantVector3            = 12
+	variantPlane              = 13
+	variantQuaternion         = 14
+	variantAABB               = 15
+	variantBasis              = 16
+	variantTransform3D        = 17
+	variantTransform2D        = 18
+	variantColor              = 20
+	variantNodePath           = 22
+	variantRID                = 23
+	variantObject             = 24
+	variantInputEvent         = 25
+	variantDictionary         = 26
+	variantArray              = 30
+	variantPackedByteArray    = 31
+	variantPackedInt32Array   = 32
+	variantPackedFloat32Array = 33
+	variantPackedStringArray  = 34
+	variantPackedVector3Array = 35
+	variantPackedColorArray   = 36
+	variantPackedVector2Array = 37
+	variantInt64              = 40
+	variantDouble             = 41
+	variantCallable           = 42
+	variantSignal             = 43
+	variantStringName         = 44
+	variantVector2i           = 45
+	variantRect2i             = 46
+	variantVector3i           = 47
+	variantPackedInt64Array   = 48
+	variantPackedFloat64Array = 49
+	variantVector4            = 50
+	variantVector4i           = 51
+	variantProjection         = 52
+	variantPackedVector4Array = 53
+)
+
+// Object references in binary resources
+const (
+	objectEmpty                 = 0
+	objectExternalResource      = 1
+	objectInternalResource      = 2
+	objectExternalResourceIndex = 3
+)
+
+// Encoding of the node and connection arrays of a PackedScene (SceneState)
+const (
+	bundledNoParent          = 0x7FFFFFFF
+	bundledTypeInstantiated  = 0x7FFFFFFF
+	bundledNameIndexBits     = 18
+	bundledNameMask          = 1<<bundledNameIndexBits - 1
+	bundledFlagIDIsPath      = 1 << 30
+	bundledFlagIsPlaceholder = 1 << 30
+	bundledFlagPropName      = 1<<30 - 1
+	bundledFlagMask          = 1<<24 - 1
+)
+
+// binaryMaxDepth bounds the nesting of arrays and dictionaries in a binary
+// resource, so that corrupt files cannot exhaust the stack
+const binaryMaxDepth = 128
+
+// binaryReader reads the values of a binary resource. The first error is kept
+// in failed and later reads return zero values.
+type binaryReader struct {
+	r        *bytes.Reader
+	order    binary.ByteOrder
+	real64   bool     // vectors and matrices hold float64 instead of float32
+	names    []string // string table of the header, used by property names
+	resource *BinaryResource
+	failed   error
+}
+
+func (b *binaryReader) read(v any) {
+	if b.failed != nil {
+		return
+	}
+	if err := binary.Read(b.r, b.order, v); err != nil {
+		b.failed = err
+	}
+}
+
+func (b *binaryReader) u16() uint16 {
+	var v uint16
+	b.read(&v)
+	return v
+}
+
+func (b *binaryReader) u32() uint32 {
+	var v uint32
+	b.read(&v)
+	return v
+}
+
+func (b *binaryReader) u64() uint64 {
+	var v uint64
+	b.read(&v)
+	return v
+}
+
+// bytes reads n raw bytes
+func (b *binaryReader) bytes(n uint32) []byte {
+	if b.failed == nil && int64(n) > int64(b.r.Len()) {
+		b.failed = fmt.Errorf("value of %d bytes past the end", n)
+	}
+	if b.failed != nil {
+		return nil
+	}
+	data := make([]byte, n)
+	b.r.Read(data)
+	return data
+}
+
+// count reads the length of an array whose elements take at least size bytes,
+// failing when they cannot fit in the rest of the data
+func (b *binaryReader) count(size int) int {
+	n := b.u32() & 0x7FFFFFFF // the top bit flags shared arrays
+	if b.failed == nil && int64(n)*int64(size) > int64(b.r.Len()) {
+		b.failed = fmt.Errorf("array of %d elements past the end", n)
+	}
+	if b.failed != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// str reads a length-prefixed, zero-terminated UTF-8 string
+func (b *binaryReader) str() string {
+	return string(bytes.TrimRight(b.bytes(b.u32()), "\x00"))
+}
+
+// name reads a string that is either an index in the string table or, with
+// the top bit set, inline
+func (b *binaryReader) name() string {
+	id := b.u32()
+	if id&0x80000000 != 0 {
+		return string(bytes.TrimRight(b.bytes(id&0x7FFFFFFF), "\x00"))
+	}
+	if b.failed == nil && int(id) >= len(b.names) {
+		b.failed = fmt.Errorf("string %d out of the string table", id)
+	}
+	if b.failed != nil {
+		return ""
+	}
+	return b.names[id]
+}
+
+// seek moves to an offset from the start of the file
+func (b *binaryReader) seek(offset uint64) {
+	if b.failed == nil && offset > uint64(b.r.Size()) {
+		b.failed = fmt.Errorf("offset %d past the end", offset)
+	}
+	if b.failed == nil {
+		b.r.Seek(int64(offset), io.SeekStart)
+	}
+}
+
+// binaryStringName, binaryNodePath and binaryDict are decoded StringName,
+// NodePath and Dictionary values; binaryLiteral is a value already written
+// in the text scene syntax, e.g. Vector2(1, 2)
+type (
+	binaryStringName string
+	binaryNodePath   string
+	binaryLiteral    string
+	binaryDict       []binaryDictEntry
+)
+
+type binaryDictEntry struct {
+	Key, Value any
+}
+
+// floats reads n float32 values, or float64 ones with wide, as text
+func (b *binaryReader) floats(n int, wide bool) string {
+	values := make([]string, n)
+	for i := range values {
+		if wide {
+			var v float64
+			b.read(&v)
+			values[i] = formatBinaryFloat(v, 64)
+		} else {
+			var v float32
+			b.read(&v)
+			values[i] = formatBinaryFloat(float64(v), 32)
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// ints reads n int32 values as text
+func (b *binaryReader) ints(n int) string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = strconv.Itoa(int(int32(b.u32())))
+	}
+	return strings.Join(values, ", ")
+}
+
+// literal formats a constructor call, e.g. Vector2(1, 2)
+func literal(name, args string) binaryLiteral {
+	return binaryLiteral(name + "(" + args + ")")
+}
+
+// variant reads a value. Strings and numbers are returned as Go values,
+// arrays as []any, PackedStringArray as []string, PackedInt32Array as
+// []int32, and the other types as a binaryLiteral.
+func (b *binaryReader) variant(depth int) any {
+	if depth > binaryMaxDepth {
+		b.failed = fmt.Errorf("values nested too deeply")
+	}
+	kind := b.u32()
+	if b.failed != nil {
+		return nil
+	}
+
+	switch kind {
+	case variantNil, variantCallable, variantSignal, variantInputEvent:
+		return nil
+	case variantBool:
+		return b.u32() != 0
+	case variantInt:
+		return int64(int32(b.u32()))
+	case variantInt64:
+		return int64(b.u64())
+	case variantFloat:
+		var v float32
+		b.read(&v)
+		return float64(v)
+	case variantDouble:
+		var v float64
+		b.read(&v)
+		return v
+	case variantString:
+		return b.str()
+	case variantStringName:
+		return binaryStringName(b.str())
+	case variantVector2:
+		return literal("Vector2", b.floats(2, b.real64))
+	case variantRect2:
+		return literal("Rect2", b.floats(4, b.real64))
+	case variantVector3:
+		return literal("Vector3", b.floats(3, b.real64))
+	case variantVector4:
+		return literal("Vector4", b.floats(4, b.real64))
+	case variantPlane:
+		return literal("Plane", b.floats(4, b.real64))
+	case variantQuaternion:
+		return literal("Quaternion", b.floats(4, b.real64))
+	case variantAABB:
+		return literal("AABB", b.floats(6, b.real64))
+	case variantBasis:
+		return literal("Basis", b.floats(9, b.real64))
+	case variantTransform3D:
+		return literal("Transform3D", b.floats(12, b.real64))
+	case variantTransform2D:
+		return literal("Transform2D", b.floats(6, b.real64))
+	case variantProjection:
+		return literal("Projection", b.floats(16, b.real64))
+	case variantColor:
+		return literal("Color", b.floats(4, false))
+	case variantVector2i:
+		return literal("Vector2i", b.ints(2))
+	case variantRect2i:
+		return literal("Rect2i", b.ints(4))
+	case variantVector3i:
+		return literal("Vector3i", b.ints(3))
+	case variantVector4i:
+		return literal("Vector4i", b.ints(4))
+	case variantRID:
+		b.u32()
+		return literal("RID", "")
+	case variantNodePath:
+		return b.nodePath()
+	case variantObject:
+		return b.object()
+	case variantDictionary:
+		var dict binaryDict
+		for i, n := 0, b.count(8); i < n && b.failed == nil; i++ {
+			key := b.variant(depth + 1)
+			dict = append(dict, binaryDictEntry{key, b.variant(depth + 1)})
+		}
+		return dict
+	case variantArray:
+		values := []any{}
+		for i, n := 0, b.count(4); i < n && b.failed == nil; i++ {
+			values = append(values, b.variant(depth+1))
+		}
+		return values
+	case variantPackedByteArray:
+		n := b.count(1)
+		data := b.bytes(uint32(n))
+		b.bytes(uint32((4 - n%4) % 4)) // padding
+		values := make([]string, len(data))
+		for i, v := range data {
+			values[i] = strconv.Itoa(int(v))
+		}
+		return literal("PackedByteArray", strings.Join(values, ", "))
+	case variantPackedInt32Array:
+		values := make([]int32, b.count(4))
+		for i := range values {
+			values[i] = int32(b.u32())
+		}
+		return values
+	case variantPackedInt64Array:
+		values := make([]string, b.count(8))
+		for i := range values {
+			values[i] = strconv.FormatInt(int64(b.u64()), 10)
+		}
+		return literal("PackedInt64Array", strings.Join(values, ", "))
+	case variantPackedFloat32Array:
+		return literal("PackedFloat32Array", b.floats(b.count(4), false))
+	case variantPackedFloat64Array:
+		return literal("PackedFloat64Array", b.floats(b.count(8), true))
+	case variantPackedStringArray:
+		values := make([]string, b.count(4))
+		for i := range values {
+			values[i] = b.str()
+		}
+		return values
+	case variantPackedVector2Array:
+		return literal("PackedVector2Array", b.floatTuples(2))
+	case variantPackedVector3Array:
+		return literal("PackedVector3Array", b.floatTuples(3))
+	case variantPackedVector4Array:
+		return literal("PackedVector4Array", b.floatTuples(4))
+	case variantPackedColorArray:
+		return literal("PackedColorArray", b.floats(b.count(16)*4, false))
+	}
+	b.failed = fmt.Errorf("unsupported value type %d", kind)
+	return nil
+}
+
+// floatTuples reads the elements of a packed vector array
+func (b *binaryReader) floatTuples(size int) string {
+	width := 4
+	if b.real64 {
+		width = 8
+	}
+	return b.floats(b.count(size*width)*size, b.real64)
+}
+
+// nodePath reads a NodePath: the node names, then the property subnames
+func (b *binaryReader) nodePath() binaryNodePath {
+	names, subnames := int(b.u16()), b.u16()
+	absolute := subnames&0x8000 != 0
+	path := make([]string, names)
+	for i := range path {
+		path[i] = b.name()
+	}
+	text := strings.Join(path, "/")
+	if absolute {
+		text = "/" + text
+	}
+	for i := 0; i < int(subnames&0x7FFF); i++ {
+		text += ":" + b.name()
+	}
+	return binaryNodePath(text)
+}
+
+// object reads a resource reference as ExtResource("id") or SubResource("id")
+func (b *binaryReader) object() any {
+	switch kind := b.u32(); kind {
+	case objectEmpty:
+		return nil
+	case objectExternalResource:
+		b.str() // type
+		return literal("Resource", strconv.Quote(b.str()))
+	case objectExternalResourceIndex:
+		index := b.u32()
+		if b.failed == nil && int(index) >= len(b.resource.ExtResources) {
+			b.failed = fmt.Errorf("ext_resource %d out of range", index)
+		}
+		return literal("ExtResource", strconv.Quote(binaryExtID(int(index))))
+	case objectInternalResource:
+		index := b.u32()
+		id := strconv.Itoa(int(index))
+		if b.resource.namedSceneIDs {
+			if b.failed == nil && int(index) >= len(b.resource.internal) {
+				b.failed = fmt.Errorf("sub_resource %d out of range", index)
+			}
+			if b.failed == nil {
+				id = b.resource.internal[index].id()
+			}
+		}
+		return literal("SubResource", strconv.Quote(id))
+	default:
+		if b.failed == nil {
+			b.failed = fmt.Errorf("unsupported object reference %d", kind)
+		}
+		return nil
+	}
+}
+
+// binaryExtID is the id given to the ext_resource at index in the decoded text scene
+func binaryExtID(index int) string {
+	return strconv.Itoa(index + 1)
+}
+
+// id returns the sub_resource id of an internal resource ("local://Shape_x" → "Shape_x")
+func (r binaryInternalResource) id() string {
+	if _, id, found := strings.Cut(r.Path, "::"); found {
+		return id
+	}
+	return strings.TrimPrefix(r.Path, "local://")
+}
+
+// formatBinaryFloat writes a float the way text scenes do: shortest form,
+// "inf" and "nan" for the special values
+func formatBinaryFloat(v float64, bits int) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	case math.IsNaN(v):
+		return "nan"
+	}
+	return strconv.FormatFloat(v, 'g', -1, bits)
+}
+
+// formatBinaryValue writes a decoded value in the text scene syntax
+func formatBinaryValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		s := formatBinaryFloat(v, 64)
+		if !strings.ContainsAny(s, ".ein") {
+			s += ".0"
+		}
+		return s
+	case string:
+		return quoteBinaryString(v)
+	case binaryStringName:
+		return "&" + quoteBinaryString(string(v))
+	case binaryNodePath:
+		return "NodePath(" + quoteBinaryString(string(v)) + ")"
+	case binaryLiteral:
+		return string(v)
+	case []string:
+		values := make([]string, len(v))
+		for i, s := range v {
+			values[i] = quoteBinaryString(s)
+		}
+		return "PackedStringArray(" + strings.Join(values, ", ") + ")"
+	case []int32:
+		values := make([]string, len(v))
+		for i, n := range v {
+			values[i] = strconv.Itoa(int(n))
+		}
+		return "PackedInt32Array(" + strings.Join(values, ", ") + ")"
+	case []any:
+		values := make([]string, len(v))
+		for i, value := range v {
+			values[i] = formatBinaryValue(value)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case binaryDict:
+		entries := make([]string, len(v))
+		for i, entry := range v {
+			entries[i] = formatBinaryValue(entry.Key) + ": " + formatBinaryValue(entry.Value)
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+	return fmt.Sprint(v)
+}
+
+// quoteBinaryString quotes a string like text scenes do: only backslashes and
+// quotes are escaped, newlines are kept
+func quoteBinaryString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// binarySceneText decodes the sub_resources and the node tree of a binary
+// PackedScene into the text scene (.tscn) it was converted from, so that it
+// can be read by the scene parser. Values are written in the Godot 4 syntax.
+func binarySceneText(data []byte, resource *BinaryResource) (string, error) {
+	if resource.Type != "PackedScene" || len(resource.internal) == 0 {
+		return "", fmt.Errorf("not a binary scene")
+	}
+	b := resource.reader(data)
+
+	format := 3
+	if strings.HasPrefix(resource.EngineVersion, "3.") {
+		format = 2
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "[gd_scene load_steps=%d format=%d]\n", len(resource.ExtResources)+len(resource.internal), format)
+	for i, ext := range resource.ExtResources {
+		fmt.Fprintf(&text, "\n[ext_resource type=%q path=%q id=%q]\n", ext.Type, ext.Path, binaryExtID(i))
+	}
+
+	// Internal resources are the sub_resources followed by the scene itself
+	var bundled binaryDict
+	for i, internal := range resource.internal {
+		b.seek(internal.Offset)
+		kind := b.str()
+		main := i == len(resource.internal)-1
+		if !main {
+			fmt.Fprintf(&text, "\n[sub_resource type=%q id=%q]\n", kind, internal.id())
+		}
+		for j, n := 0, b.count(8); j < n && b.failed == nil; j++ {
+			name, value := b.name(), b.variant(0)
+			if !main {
+				fmt.Fprintf(&text, "%s = %s\n", name, formatBinaryValue(value))
+			} else if dict, ok := value.(binaryDict); ok && name == "_bundled" {
+				bundled = dict
+			}
+		}
+	}
+	if b.failed != nil {
+		return "", b.failed
+	}
+	if bundled == nil {
+		return "", fmt.Errorf("scene without a node tree")
+	}
+
+	if err := writeBundledScene(&text, bundled); err != nil {
+		return "", err
+	}
+	return text.String(), nil
+}
+
+// bundledScene is the _bundled dictionary of a PackedScene
+type bundledScene struct {
+	names     []string
+	variants  []any
+	nodes     []int32
+	conns     []int32
+	nodePaths []any
+	version   int64
+}
+
+// writeBundledScene writes the [node] and [connection] sections of the
+// _bundled dictionary of a PackedScene
+func writeBundledScene(text *strings.Builder, dict binaryDict) error {
+	scene := bundledScene{version: 1}
+	for _, entry := range dict {
+		key, _ := entry.Key.(string)
+		switch value := entry.Value.(type) {
+		case []string:
+			if key == "names" {
+				scene.names = value
+			}
+		case []int32:
+			switch key {
+			case "nodes":
+				scene.nodes = value
+			case "conns":
+				scene.conns = value
+			}
+		case []any:
+			switch key {
+			case "variants":
+				scene.variants = value
+			case "node_paths":
+				scene.nodePaths = value
+			}
+		case int64:
+			if key == "version" {
+				scene.version = value
+			}
+		}
+	}
+
+	// next reads the int array of nodes or connections
+	var failed error
+	next := func(values []int32, i *int) int32 {
+		if *i >= len(values) {
+			if failed == nil {
+				failed = fmt.Errorf("truncated scene data")
+			}
+			return 0
+		}
+		*i++
+		return values[*i-1]
+	}
+	name := func(index int32) string {
+		if index < 0 || int(index) >= len(scene.names) {
+			if failed == nil {
+				failed = fmt.Errorf("name %d out of range", index)
+			}
+			return ""
+		}
+		return scene.names[index]
+	}
+	variant := func(index int32) string {
+		if index < 0 || int(index) >= len(scene.variants) {
+			if failed == nil {
+				failed = fmt.Errorf("value %d out of range", index)
+			}
+			return ""
+		}
+		return formatBinaryValue(scene.variants[index])
+	}
+
+	// paths are the paths of the nodes from the scene root ("." for the root)
+	var paths []string
+	path := func(id int32) string {
+		if id&bundledFlagIDIsPath != 0 {
+			index := int(id & bundledFlagMask)
+			if index < len(scene.nodePaths) {
+				if p, ok := scene.nodePaths[index].(binaryNodePath); ok {
+					return string(p)
+				}
+			}
+		} else if id >= 0 && int(id) < len(paths) {
+			return paths[id]
+		}
+		if failed == nil {
+			failed = fmt.Errorf("node %d out of range", id)
+		}
+		return ""
+	}
+
+	for i := 0; i < len(scene.nodes) && failed == nil; {
+		parent, _ := next(scene.nodes, &i), next(scene.nodes, &i) // parent, owner
+		kind, nameIndex, instance := next(scene.nodes, &i), next(scene.nodes, &i), next(scene.nodes, &i)
+
+		nodeName := name(nameIndex & bundledNameMask)
+		header := fmt.Sprintf("[node name=%q", nodeName)
+		if kind != bundledTypeInstantiated {
+			header += fmt.Sprintf(" type=%q", name(kind))
+		}
+		nodePath := "."
+		if parent >= 0 && parent != bundledNoParent {
+			parentPath := path(parent)
+			header += fmt.Sprintf(" parent=%q", parentPath)
+			nodePath = nodeName
+			if parentPath != "." {
+				nodePath = parentPath + "/" + nodeName
+			}
+		}
+		paths = append(paths, nodePath)
+		if instance >= 0 {
+			if instance&bundledFlagIsPlaceholder != 0 {
+				header += " instance_placeholder=" + variant(instance&bundledFlagMask)
+			} else {
+				header += " instance=" + variant(instance&bundledFlagMask)
+			}
+		}
+		if index := nameIndex>>bundledNameIndexBits - 1; index >= 0 {
+			header += fmt.Sprintf(" index=\"%d\"", index)
+		}
+
+		var properties []string
+		for j, n := 0, next(scene.nodes, &i); j < int(n) && failed == nil; j++ {
+			property := name(next(scene.nodes, &i) & bundledFlagPropName)
+			properties = append(properties, property+" = "+variant(next(scene.nodes, &i)))
+		}
+		var groups []string
+		for j, n := 0, next(scene.nodes, &i); j < int(n) && failed == nil; j++ {
+			groups = append(groups, quoteBinaryString(name(next(scene.nodes, &i))))
+		}
+		if len(groups) > 0 {
+			header += " groups=[" + strings.Join(groups, ", ") + "]"
+		}
+
+		fmt.Fprintf(text, "\n%s]\n", header)
+		for _, property := range properties {
+			fmt.Fprintln(text, property)
+		}
+	}
+
+	for i := 0; i < len(scene.conns) && failed == nil; {
+		from, to := path(next(scene.conns, &i)), path(next(scene.conns, &i))
+		signal, method := name(next(scene.conns, &i)), name(next(scene.conns, &i))
+		header := fmt.Sprintf("[connection signal=%q from=%q to=%q method=%q", signal, from, to, method)
+		if flags := next(scene.conns, &i); flags != 0 {
+			header += fmt.Sprintf(" flags=%d", flags)
+		}
+		var binds []string
+		for j, n := 0, next(scene.conns, &i); j < int(n) && failed == nil; j++ {
+			binds = append(binds, variant(next(scene.conns, &i)))
+		}
+		if len(binds) > 0 {
+			header += " binds=[" + strings.Join(binds, ", ") + "]"
+		}
+		if scene.version >= 3 {
+			if unbinds := next(scene.conns, &i); unbinds > 0 {
+				header += fmt.Sprintf(" unbinds=%d", unbinds)
+			}
+		}
+		fmt.Fprintf(text, "\n%s]\n", header)
+	}
+	return failed
+}
@@ -0,0 +1,606 @@
+package gdquery
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GodotClass describes a built-in class: its base class, property defaults and signals
+type GodotClass struct {
+	Name     string            `json:"name"`
+	Inherits string            `json:"inherits,omitempty"`
+	Defaults map[string]string `json:"properties,omitempty"`
+	Signals  []string          `json:"signals,omitempty"`
+}
+
+// classDB indexes the known classes by name
+var classDB = make(map[string]*GodotClass)
+
+// builtinClasses is the bundled class database (Godot 4 defaults of commonly saved
+// properties, and the signals most often connected)
+var builtinClasses = []*GodotClass{
+	{Name: "Object"},
+	{Name: "Node", Inherits: "Object", Signals: []string{"ready", "renamed", "tree_entered", "tree_exiting", "tree_exited", "child_entered_tree", "child_exiting_tree"}, Defaults: map[string]string{
+		"process_mode": "0", "process_priority": "0", "process_physics_priority": "0",
+		"editor_description": `""`, "unique_name_in_owner": "false", "auto_translate_mode": "0",
+	}},
+	{Name: "CanvasItem", Inherits: "Node", Defaults: map[string]string{
+		"visible": "true", "modulate": "Color(1, 1, 1, 1)", "self_modulate": "Color(1, 1, 1, 1)",
+		"show_behind_parent": "false", "top_level": "false", "clip_children": "0",
+		"light_mask": "1", "visibility_layer": "1", "z_index": "0", "z_as_relative": "true",
+		"y_sort_enabled": "false", "texture_filter": "0", "texture_repeat": "0",
+	}},
+	{Name: "Node2D", Inherits: "CanvasItem", Defaults: map[string]string{
+		"position": "Vector2(0, 0)", "rotation": "0.0", "scale": "Vector2(1, 1)", "skew": "0.0",
+	}},
+	{Name: "Control", Inherits: "CanvasItem", Signals: []string{"resized", "gui_input", "mouse_entered", "mouse_exited", "focus_entered", "focus_exited"}, Defaults: map[string]string{
+		"clip_contents": "false", "custom_minimum_size": "Vector2(0, 0)", "layout_direction": "0",
+		"anchor_left": "0.0", "anchor_top": "0.0", "anchor_right": "0.0", "anchor_bottom": "0.0",
+		"offset_left": "0.0", "offset_top": "0.0", "offset_right": "0.0", "offset_bottom": "0.0",
+		"grow_horizontal": "1", "grow_vertical": "1", "rotation": "0.0", "scale": "Vector2(1, 1)",
+		"pivot_offset": "Vector2(0, 0)", "size_flags_horizontal": "1", "size_flags_vertical": "1",
+		"size_flags_stretch_ratio": "1.0", "mouse_filter": "0", "mouse_default_cursor_shape": "0",
+		"focus_mode": "0", "tooltip_text": `""`, "auto_translate": "true",
+	}},
+	{Name: "Node3D", Inherits: "Node", Defaults: map[string]string{
+		"transform": "Transform3D(1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0)", "visible": "true",
+		"rotation_edit_mode": "0", "rotation_order": "2", "top_level": "false",
+	}},
+	{Name: "Viewport", Inherits: "Node"},
+	{Name: "SubViewport", Inherits: "Viewport"},
+	{Name: "Window", Inherits: "Viewport"},
+	{Name: "CanvasLayer", Inherits: "Node", Defaults: map[string]string{
+		"layer": "1", "visible": "true", "offset": "Vector2(0, 0)", "rotation": "0.0",
+		"scale": "Vector2(1, 1)", "follow_viewport_enabled": "false",
+	}},
+	{Name: "ParallaxBackground", Inherits: "CanvasLayer", Defaults: map[string]string{"layer": "-100"}},
+	{Name: "Timer", Inherits: "Node", Signals: []string{"timeout"}, Defaults: map[string]string{
+		"process_callback": "1", "wait_time": "1.0", "one_shot": "false", "autostart": "false",
+	}},
+	{Name: "HTTPRequest", Inherits: "Node", Signals: []string{"request_completed"}},
+	{Name: "AnimationMixer", Inherits: "Node", Signals: []string{"animation_started", "animation_finished"}},
+	{Name: "AnimationPlayer", Inherits: "AnimationMixer", Defaults: map[string]string{
+		"autoplay": `""`, "playback_default_blend_time": "0.0", "speed_scale": "1.0",
+	}},
+	{Name: "AnimationTree", Inherits: "AnimationMixer"},
+	{Name: "AudioStreamPlayer", Inherits: "Node", Signals: []string{"finished"}, Defaults: map[string]string{
+		"volume_db": "0.0", "pitch_scale": "1.0", "playing": "false", "autoplay": "false",
+		"stream_paused": "false", "mix_target": "0", "max_polyphony": "1", "bus": `&"Master"`,
+	}},
+	{Name: "AudioStreamPlayer2D", Inherits: "Node2D", Signals: []string{"finished"}, Defaults: map[string]string{
+		"volume_db": "0.0", "pitch_scale": "1.0", "playing": "false", "autoplay": "false",
+		"max_distance": "2000.0", "attenuation": "1.0", "max_polyphony": "1", "bus": `&"Master"`,
+		"area_mask": "1",
+	}},
+	{Name: "AudioStreamPlayer3D", Inherits: "Node3D", Signals: []string{"finished"}, Defaults: map[string]string{
+		"volume_db": "0.0", "unit_size": "10.0", "max_db": "3.0", "pitch_scale": "1.0",
+		"playing": "false", "autoplay": "false", "max_distance": "0.0", "max_polyphony": "1",
+		"bus": `&"Master"`, "area_mask": "1",
+	}},
+
+	// 2D nodes
+	{Name: "Sprite2D", Inherits: "Node2D", Defaults: map[string]string{
+		"centered": "true", "offset": "Vector2(0, 0)", "flip_h": "false", "flip_v": "false",
+		"hframes": "1", "vframes": "1", "frame": "0", "region_enabled": "false",
+	}},
+	{Name: "AnimatedSprite2D", Inherits: "Node2D", Defaults: map[string]string{
+		"animation": `&"default"`, "autoplay": `""`, "frame": "0", "speed_scale": "1.0",
+		"centered": "true", "offset": "Vector2(0, 0)", "flip_h": "false", "flip_v": "false",
+	}},
+	{Name: "Camera2D", Inherits: "Node2D", Defaults: map[string]string{
+		"offset": "Vector2(0, 0)", "anchor_mode": "1", "ignore_rotation": "true", "enabled": "true",
+		"zoom": "Vector2(1, 1)", "process_callback": "1", "position_smoothing_enabled": "false",
+		"position_smoothing_speed": "5.0",
+	}},
+	{Name: "CollisionObject2D", Inherits: "Node2D", Signals: []string{"input_event", "mouse_entered", "mouse_exited"}, Defaults: map[string]string{
+		"disable_mode": "0", "collision_layer": "1", "collision_mask": "1", "collision_priority": "1.0",
+		"input_pickable": "true",
+	}},
+	{Name: "Area2D", Inherits: "CollisionObject2D", Signals: []string{"body_entered", "body_exited", "area_entered", "area_exited"}, Defaults: map[string]string{
+		"monitoring": "true", "monitorable": "true", "priority": "0", "gravity_space_override": "0",
+		"audio_bus_override": "false", "audio_bus_name": `&"Master"`,
+	}},
+	{Name: "PhysicsBody2D", Inherits: "CollisionObject2D", Defaults: map[string]string{"input_pickable": "false"}},
+	{Name: "StaticBody2D", Inherits: "PhysicsBody2D", Defaults: map[string]string{
+		"constant_linear_velocity": "Vector2(0, 0)", "constant_angular_velocity": "0.0",
+	}},
+	{Name: "AnimatableBody2D", Inherits: "StaticBody2D", Defaults: map[string]string{"sync_to_physics": "true"}},
+	{Name: "RigidBody2D", Inherits: "PhysicsBody2D", Defaults: map[string]string{
+		"mass": "1.0", "gravity_scale": "1.0", "lock_rotation": "false", "freeze": "false",
+		"freeze_mode": "0", "continuous_cd": "0", "contact_monitor": "false", "max_contacts_reported": "0",
+		"linear_velocity": "Vector2(0, 0)", "angular_velocity": "0.0", "sleeping": "false", "can_sleep": "true",
+	}},
+	{Name: "CharacterBody2D", Inherits: "PhysicsBody2D", Defaults: map[string]string{
+		"motion_mode": "0", "up_direction": "Vector2(0, -1)", "slide_on_ceiling": "true",
+		"max_slides": "4", "wall_min_slide_angle": "0.261799", "floor_stop_on_slope": "true",
+		"floor_constant_speed": "false", "floor_block_on_wall": "true", "floor_max_angle": "0.785398",
+		"floor_snap_length": "1.0", "safe_margin": "0.08", "velocity": "Vector2(0, 0)",
+	}},
+	{Name: "CollisionShape2D", Inherits: "Node2D", Defaults: map[string]string{
+		"disabled": "false", "one_way_collision": "false", "one_way_collision_margin": "1.0",
+		"debug_color": "Color(0, 0, 0, 1)",
+	}},
+	{Name: "CollisionPolygon2D", Inherits: "Node2D", Defaults: map[string]string{
+		"build_mode": "0", "disabled": "false", "one_way_collision": "false",
+	}},
+	{Name: "RayCast2D", Inherits: "Node2D", Defaults: map[string]string{
+		"enabled": "true", "exclude_parent": "true", "target_position": "Vector2(0, 50)", "collision_mask": "1",
+	}},
+	{Name: "Marker2D", Inherits: "Node2D", Defaults: map[string]string{"gizmo_extents": "10.0"}},
+	{Name: "Path2D", Inherits: "Node2D"},
+	{Name: "PathFollow2D", Inherits: "Node2D", Defaults: map[string]string{
+		"progress": "0.0", "progress_ratio": "0.0", "h_offset": "0.0", "v_offset": "0.0",
+		"rotates": "true", "cubic_interp": "true", "loop": "true",
+	}},
+	{Name: "Line2D", Inherits: "Node2D", Defaults: map[string]string{
+		"width": "10.0", "default_color": "Color(1, 1, 1, 1)", "closed": "false", "antialiased": "false",
+	}},
+	{Name: "Polygon2D", Inherits: "Node2D", Defaults: map[string]string{
+		"color": "Color(1, 1, 1, 1)", "offset": "Vector2(0, 0)", "antialiased": "false",
+	}},
+	{Name: "Light2D", Inherits: "Node2D", Defaults: map[string]string{
+		"enabled": "true", "editor_only": "false", "color": "Color(1, 1, 1, 1)", "energy": "1.0",
+		"blend_mode": "0", "shadow_enabled": "false",
+	}},
+	{Name: "PointLight2D", Inherits: "Light2D", Defaults: map[string]string{
+		"offset": "Vector2(0, 0)", "texture_scale": "1.0", "height": "0.0",
+	}},
+	{Name: "DirectionalLight2D", Inherits: "Light2D", Defaults: map[string]string{"height": "0.0", "max_distance": "10000.0"}},
+	{Name: "LightOccluder2D", Inherits: "Node2D", Defaults: map[string]string{"sdf_collision": "true", "occluder_light_mask": "1"}},
+	{Name: "GPUParticles2D", Inherits: "Node2D", Defaults: map[string]string{
+		"emitting": "true", "amount": "8", "lifetime": "1.0", "one_shot": "false", "preprocess": "0.0",
+		"speed_scale": "1.0", "explosiveness": "0.0", "randomness": "0.0", "local_coords": "false",
+	}},
+	{Name: "CPUParticles2D", Inherits: "Node2D", Defaults: map[string]string{
+		"emitting": "true", "amount": "8", "lifetime": "1.0", "one_shot": "false", "preprocess": "0.0",
+		"speed_scale": "1.0", "explosiveness": "0.0", "randomness": "0.0", "local_coords": "false",
+	}},
+	{Name: "TileMap", Inherits: "Node2D", Defaults: map[string]string{"rendering_quadrant_size": "16", "collision_animatable": "false"}},
+	{Name: "TileMapLayer", Inherits: "Node2D", Defaults: map[string]string{"enabled": "true", "rendering_quadrant_size": "16"}},
+	{Name: "NavigationRegion2D", Inherits: "Node2D", Defaults: map[string]string{"enabled": "true", "navigation_layers": "1"}},
+	{Name: "NavigationAgent2D", Inherits: "Node", Defaults: map[string]string{
+		"path_desired_distance": "20.0", "target_desired_distance": "10.0", "radius": "10.0", "avoidance_enabled": "false",
+	}},
+	{Name: "VisibleOnScreenNotifier2D", Inherits: "Node2D", Signals: []string{"screen_entered", "screen_exited"}, Defaults: map[string]string{"rect": "Rect2(-10, -10, 20, 20)"}},
+	{Name: "VisibleOnScreenEnabler2D", Inherits: "VisibleOnScreenNotifier2D"},
+	{Name: "RemoteTransform2D", Inherits: "Node2D", Defaults: map[string]string{
+		"use_global_coordinates": "true", "update_position": "true", "update_rotation": "true", "update_scale": "true",
+	}},
+	{Name: "ParallaxLayer", Inherits: "Node2D", Defaults: map[string]string{
+		"motion_scale": "Vector2(1, 1)", "motion_offset": "Vector2(0, 0)", "motion_mirroring": "Vector2(0, 0)",
+	}},
+	{Name: "Skeleton2D", Inherits: "Node2D"},
+	{Name: "Bone2D", Inherits: "Node2D"},
+
+	// UI nodes
+	{Name: "Container", Inherits: "Control"},
+	{Name: "BoxContainer", Inherits: "Container", Defaults: map[string]string{"alignment": "0", "vertical": "false"}},
+	{Name: "HBoxContainer", Inherits: "BoxContainer"},
+	{Name: "VBoxContainer", Inherits: "BoxContainer", Defaults: map[string]string{"vertical": "true"}},
+	{Name: "FlowContainer", Inherits: "Container", Defaults: map[string]string{"alignment": "0", "vertical": "false"}},
+	{Name: "HFlowContainer", Inherits: "FlowContainer"},
+	{Name: "VFlowContainer", Inherits: "FlowContainer", Defaults: map[string]string{"vertical": "true"}},
+	{Name: "GridContainer", Inherits: "Container", Defaults: map[string]string{"columns": "1"}},
+	{Name: "MarginContainer", Inherits: "Container"},
+	{Name: "CenterContainer", Inherits: "Container", Defaults: map[string]string{"use_top_left": "false"}},
+	{Name: "PanelContainer", Inherits: "Container"},
+	{Name: "AspectRatioContainer", Inherits: "Container", Defaults: map[string]string{"ratio": "1.0", "stretch_mode": "2"}},
+	{Name: "ScrollContainer", Inherits: "Container", Defaults: map[string]string{
+		"follow_focus": "false", "horizontal_scroll_mode": "1", "vertical_scroll_mode": "1",
+		"scroll_horizontal": "0", "scroll_vertical": "0",
+	}},
+	{Name: "SplitContainer", Inherits: "Container", Defaults: map[string]string{"split_offset": "0", "collapsed": "false"}},
+	{Name: "HSplitContainer", Inherits: "SplitContainer"},
+	{Name: "VSplitContainer", Inherits: "SplitContainer"},
+	{Name: "TabContainer", Inherits: "Container", Defaults: map[string]string{"current_tab": "0", "tabs_visible": "true"}},
+	{Name: "SubViewportContainer", Inherits: "Container", Defaults: map[string]string{"stretch": "false", "stretch_shrink": "1"}},
+	{Name: "Panel", Inherits: "Control"},
+	{Name: "ColorRect", Inherits: "Control", Defaults: map[string]string{"color": "Color(1, 1, 1, 1)"}},
+	{Name: "TextureRect", Inherits: "Control", Defaults: map[string]string{
+		"expand_mode": "0", "stretch_mode": "0", "flip_h": "false", "flip_v": "false",
+	}},
+	{Name: "NinePatchRect", Inherits: "Control", Defaults: map[string]string{
+		"draw_center": "true", "region_rect": "Rect2(0, 0, 0, 0)", "patch_margin_left": "0",
+		"patch_margin_top": "0", "patch_margin_right": "0", "patch_margin_bottom": "0",
+	}},
+	{Name: "Label", Inherits: "Control", Defaults: map[string]string{
+		"text": `""`, "horizontal_alignment": "0", "vertical_alignment": "0", "autowrap_mode": "0",
+		"justification_flags": "163", "clip_text": "false", "text_overrun_behavior": "0",
+		"uppercase": "false", "lines_skipped": "0", "max_lines_visible": "-1",
+		"visible_characters": "-1", "visible_ratio": "1.0", "mouse_filter": "2",
+		"size_flags_vertical": "4",
+	}},
+	{Name: "RichTextLabel", Inherits: "Control", Defaults: map[string]string{
+		"bbcode_enabled": "false", "text": `""`, "fit_content": "false", "scroll_active": "true",
+		"scroll_following": "false", "autowrap_mode": "3", "clip_contents": "true",
+		"visible_characters": "-1", "visible_ratio": "1.0", "focus_mode": "2",
+	}},
+	{Name: "BaseButton", Inherits: "Control", Signals: []string{"pressed", "toggled", "button_down", "button_up"}, Defaults: map[string]string{
+		"disabled": "false", "toggle_mode": "false", "button_pressed": "false", "action_mode": "1",
+		"button_mask": "1", "keep_pressed_outside": "false", "focus_mode": "2",
+	}},
+	{Name: "Button", Inherits: "BaseButton", Defaults: map[string]string{
+		"text": `""`, "flat": "false", "clip_text": "false", "alignment": "1",
+		"text_overrun_behavior": "0", "autowrap_mode": "0", "icon_alignment": "0",
+		"vertical_icon_alignment": "1", "expand_icon": "false",
+	}},
+	{Name: "CheckBox", Inherits: "Button", Defaults: map[string]string{"toggle_mode": "true", "alignment": "0"}},
+	{Name: "CheckButton", Inherits: "Button", Defaults: map[string]string{"toggle_mode": "true", "alignment": "0"}},
+	{Name: "ColorPickerButton", Inherits: "Button", Defaults: map[string]string{"color": "Color(0, 0, 0, 1)", "edit_alpha": "true"}},
+	{Name: "MenuButton", Inherits: "Button", Defaults: map[string]string{"flat": "true", "focus_mode": "0"}},
+	{Name: "OptionButton", Inherits: "Button", Defaults: map[string]string{"alignment": "0", "selected": "-1"}},
+	{Name: "LinkButton", Inherits: "BaseButton", Defaults: map[string]string{"text": `""`, "underline": "0", "uri": `""`}},
+	{Name: "TextureButton", Inherits: "BaseButton", Defaults: map[string]string{
+		"ignore_texture_size": "false", "stretch_mode": "2", "flip_h": "false", "flip_v": "false",
+	}},
+	{Name: "LineEdit", Inherits: "Control", Signals: []string{"text_changed", "text_submitted"}, Defaults: map[string]string{
+		"text": `""`, "placeholder_text": `""`, "alignment": "0", "max_length": "0", "editable": "true",
+		"secret": "false", "focus_mode": "2", "mouse_default_cursor_shape": "1",
+	}},
+	{Name: "TextEdit", Inherits: "Control", Defaults: map[string]string{
+		"text": `""`, "placeholder_text": `""`, "editable": "true", "wrap_mode": "0", "focus_mode": "2",
+	}},
+	{Name: "CodeEdit", Inherits: "TextEdit"},
+	{Name: "ItemList", Inherits: "Control", Defaults: map[string]string{"select_mode": "0", "max_columns": "1", "item_count": "0"}},
+	{Name: "Tree", Inherits: "Control", Defaults: map[string]string{"columns": "1", "hide_root": "false"}},
+	{Name: "Range", Inherits: "Control", Signals: []string{"value_changed", "changed"}, Defaults: map[string]string{
+		"min_value": "0.0", "max_value": "100.0", "step": "1.0", "page": "0.0", "value": "0.0",
+		"exp_edit": "false", "rounded": "false", "allow_greater": "false", "allow_lesser": "false",
+	}},
+	{Name: "ProgressBar", Inherits: "Range", Defaults: map[string]string{"fill_mode": "0", "show_percentage": "true"}},
+	{Name: "TextureProgressBar", Inherits: "Range", Defaults: map[string]string{"fill_mode": "0", "nine_patch_stretch": "false"}},
+	{Name: "SpinBox", Inherits: "Range", Defaults: map[string]string{"alignment": "0", "editable": "true", "prefix": `""`, "suffix": `""`}},
+	{Name: "Slider", Inherits: "Range", Defaults: map[string]string{"editable": "true", "scrollable": "true", "tick_count": "0", "focus_mode": "2"}},
+	{Name: "HSlider", Inherits: "Slider"},
+	{Name: "VSlider", Inherits: "Slider"},
+	{Name: "ScrollBar", Inherits: "Range", Defaults: map[string]string{"custom_step": "-1.0", "step": "0.0"}},
+	{Name: "HScrollBar", Inherits: "ScrollBar"},
+	{Name: "VScrollBar", Inherits: "ScrollBar"},
+	{Name: "Separator", Inherits: "Control"},
+	{Name: "HSeparator", Inherits: "Separator"},
+	{Name: "VSeparator", Inherits: "Separator"},
+	{Name: "ReferenceRect", Inherits: "Control", Defaults: map[string]string{"border_color": "Color(1, 0, 0, 1)", "border_width": "1.0", "editor_only": "true"}},
+	{Name: "VideoStreamPlayer", Inherits: "Control", Defaults: map[string]string{"autoplay": "false", "volume_db": "0.0", "bus": `&"Master"`}},
+	{Name: "GraphEdit", Inherits: "Control"},
+	{Name: "GraphElement", Inherits: "Container"},
+	{Name: "GraphNode", Inherits: "GraphElement"},
+
+	// 3D nodes
+	{Name: "VisualInstance3D", Inherits: "Node3D", Defaults: map[string]string{"layers": "1", "sorting_offset": "0.0"}},
+	{Name: "GeometryInstance3D", Inherits: "VisualInstance3D", Defaults: map[string]string{
+		"cast_shadow": "1", "transparency": "0.0", "extra_cull_margin": "0.0", "lod_bias": "1.0",
+		"visibility_range_begin": "0.0", "visibility_range_end": "0.0", "gi_mode": "1",
+	}},
+	{Name: "MeshInstance3D", Inherits: "GeometryInstance3D", Defaults: map[string]string{"skeleton": `NodePath("..")`}},
+	{Name: "MultiMeshInstance3D", Inherits: "GeometryInstance3D"},
+	{Name: "CSGShape3D", Inherits: "GeometryInstance3D", Defaults: map[string]string{"operation": "0", "use_collision": "false"}},
+	{Name: "CSGPrimitive3D", Inherits: "CSGShape3D"},
+	{Name: "CSGBox3D", Inherits: "CSGPrimitive3D", Defaults: map[string]string{"size": "Vector3(1, 1, 1)"}},
+	{Name: "CSGSphere3D", Inherits: "CSGPrimitive3D", Defaults: map[string]string{"radius": "0.5"}},
+	{Name: "CSGCylinder3D", Inherits: "CSGPrimitive3D", Defaults: map[string]string{"radius": "0.5", "height": "2.0"}},
+	{Name: "CSGCombiner3D", Inherits: "CSGShape3D"},
+	{Name: "SpriteBase3D", Inherits: "GeometryInstance3D", Defaults: map[string]string{
+		"centered": "true", "offset": "Vector2(0, 0)", "flip_h": "false", "flip_v": "false",
+		"modulate": "Color(1, 1, 1, 1)", "pixel_size": "0.01", "billboard": "0",
+	}},
+	{Name: "Sprite3D", Inherits: "SpriteBase3D", Defaults: map[string]string{"hframes": "1", "vframes": "1", "frame": "0"}},
+	{Name: "AnimatedSprite3D", Inherits: "SpriteBase3D"},
+	{Name: "Label3D", Inherits: "GeometryInstance3D", Defaults: map[string]string{
+		"text": `""`, "font_size": "32", "outline_size": "12", "pixel_size": "0.005", "billboard": "0",
+		"modulate": "Color(1, 1, 1, 1)",
+	}},
+	{Name: "Camera3D", Inherits: "Node3D", Defaults: map[string]string{
+		"keep_aspect": "1", "cull_mask": "1048575", "doppler_tracking": "0", "projection": "0",
+		"current": "false", "fov": "75.0", "size": "1.0", "near": "0.05", "far": "4000.0",
+		"h_offset": "0.0", "v_offset": "0.0",
+	}},
+	{Name: "Light3D", Inherits: "VisualInstance3D", Defaults: map[string]string{
+		"light_color": "Color(1, 1, 1, 1)", "light_energy": "1.0", "light_indirect_energy": "1.0",
+		"light_negative": "false", "light_specular": "0.5", "light_bake_mode": "2", "light_cull_mask": "4294967295",
+		"shadow_enabled": "false", "editor_only": "false",
+	}},
+	{Name: "DirectionalLight3D", Inherits: "Light3D", Defaults: map[string]string{"directional_shadow_mode": "2", "sky_mode": "0"}},
+	{Name: "OmniLight3D", Inherits: "Light3D", Defaults: map[string]string{"omni_range": "5.0", "omni_attenuation": "1.0"}},
+	{Name: "SpotLight3D", Inherits: "Light3D", Defaults: map[string]string{"spot_range": "5.0", "spot_attenuation": "1.0", "spot_angle": "45.0"}},
+	{Name: "WorldEnvironment", Inherits: "Node"},
+	{Name: "CollisionObject3D", Inherits: "Node3D", Defaults: map[string]string{
+		"disable_mode": "0", "collision_layer": "1", "collision_mask": "1", "collision_priority": "1.0",
+		"input_ray_pickable": "true", "input_capture_on_drag": "false",
+	}},
+	{Name: "Area3D", Inherits: "CollisionObject3D", Signals: []string{"body_entered", "body_exited", "area_entered", "area_exited"}, Defaults: map[string]string{
+		"monitoring": "true", "monitorable": "true", "priority": "0", "gravity_space_override": "0",
+	}},
+	{Name: "PhysicsBody3D", Inherits: "CollisionObject3D"},
+	{Name: "StaticBody3D", Inherits: "PhysicsBody3D", Defaults: map[string]string{
+		"constant_linear_velocity": "Vector3(0, 0, 0)", "constant_angular_velocity": "Vector3(0, 0, 0)",
+	}},
+	{Name: "AnimatableBody3D", Inherits: "StaticBody3D", Defaults: map[string]string{"sync_to_physics": "true"}},
+	{Name: "RigidBody3D", Inherits: "PhysicsBody3D", Defaults: map[string]string{
+		"mass": "1.0", "gravity_scale": "1.0", "lock_rotation": "false", "freeze": "false",
+		"freeze_mode": "0", "continuous_cd": "false", "contact_monitor": "false", "max_contacts_reported": "0",
+		"linear_velocity": "Vector3(0, 0, 0)", "angular_velocity": "Vector3(0, 0, 0)", "sleeping": "false", "can_sleep": "true",
+	}},
+	{Name: "VehicleBody3D", Inherits: "RigidBody3D"},
+	{Name: "CharacterBody3D", Inherits: "PhysicsBody3D", Defaults: map[string]string{
+		"motion_mode": "0", "up_direction": "Vector3(0, 1, 0)", "slide_on_ceiling": "true",
+		"max_slides": "6", "floor_stop_on_slope": "true", "floor_constant_speed": "false",
+		"floor_block_on_wall": "true", "floor_max_angle": "0.785398", "floor_snap_length": "0.1",
+		"safe_margin": "0.001", "velocity": "Vector3(0, 0, 0)",
+	}},
+	{Name: "CollisionShape3D", Inherits: "Node3D", Defaults: map[string]string{"disabled": "false"}},
+	{Name: "CollisionPolygon3D", Inherits: "Node3D", Defaults: map[string]string{"depth": "1.0", "disabled": "false"}},
+	{Name: "RayCast3D", Inherits: "Node3D", Defaults: map[string]string{
+		"enabled": "true", "exclude_parent": "true", "target_position": "Vector3(0, -1, 0)", "collision_mask": "1",
+	}},
+	{Name: "Marker3D", Inherits: "Node3D", Defaults: map[string]string{"gizmo_extents": "0.25"}},
+	{Name: "Path3D", Inherits: "Node3D"},
+	{Name: "PathFollow3D", Inherits: "Node3D", Defaults: map[string]string{"progress": "0.0", "rotation_mode": "3", "loop": "true"}},
+	{Name: "Skeleton3D", Inherits: "Node3D", Defaults: map[string]string{"motion_scale": "1.0", "show_rest_only": "false"}},
+	{Name: "BoneAttachment3D", Inherits: "Node3D", Defaults: map[string]string{"bone_name": `""`, "bone_idx": "-1", "override_pose": "false"}},
+	{Name: "GPUParticles3D", Inherits: "GeometryInstance3D", Defaults: map[string]string{
+		"emitting": "true", "amount": "8", "lifetime": "1.0", "one_shot": "false", "preprocess": "0.0",
+		"speed_scale": "1.0", "explosiveness": "0.0", "randomness": "0.0", "local_coords": "false",
+	}},
+	{Name: "CPUParticles3D", Inherits: "GeometryInstance3D", Defaults: map[string]string{
+		"emitting": "true", "amount": "8", "lifetime": "1.0", "one_shot": "false", "preprocess": "0.0",
+		"speed_scale": "1.0", "explosiveness": "0.0", "randomness": "0.0", "local_coords": "false",
+	}},
+	{Name: "GridMap", Inherits: "Node3D", Defaults: map[string]string{"cell_size": "Vector3(2, 2, 2)", "collision_layer": "1", "collision_mask": "1"}},
+	{Name: "NavigationRegion3D", Inherits: "Node3D", Defaults: map[string]string{"enabled": "true", "navigation_layers": "1"}},
+	{Name: "NavigationAgent3D", Inherits: "Node", Defaults: map[string]string{
+		"path_desired_distance": "1.0", "target_desired_distance": "1.0", "radius": "0.5", "avoidance_enabled": "false",
+	}},
+	{Name: "VisibleOnScreenNotifier3D", Inherits: "VisualInstance3D"},
+	{Name: "RemoteTransform3D", Inherits: "Node3D"},
+	{Name: "ReflectionProbe", Inherits: "VisualInstance3D"},
+	{Name: "VoxelGI", Inherits: "VisualInstance3D"},
+	{Name: "LightmapGI", Inherits: "VisualInstance3D"},
+	{Name: "Decal", Inherits: "VisualInstance3D"},
+	{Name: "FogVolume", Inherits: "VisualInstance3D"},
+	{Name: "XROrigin3D", Inherits: "Node3D"},
+	{Name: "XRCamera3D", Inherits: "Camera3D"},
+	{Name: "XRNode3D", Inherits: "Node3D"},
+	{Name: "XRController3D", Inherits: "XRNode3D"},
+
+	// Common resources
+	{Name: "Resource", Inherits: "RefCounted", Defaults: map[string]string{"resource_local_to_scene": "false", "resource_name": `""`}},
+	{Name: "RefCounted", Inherits: "Object"},
+	{Name: "Script", Inherits: "Resource"},
+	{Name: "GDScript", Inherits: "Script"},
+	{Name: "CSharpScript", Inherits: "Script"},
+	{Name: "PackedScene", Inherits: "Resource"},
+	{Name: "Texture", Inherits: "Resource"},
+	{Name: "Texture2D", Inherits: "Texture"},
+	{Name: "CompressedTexture2D", Inherits: "Texture2D"},
+	{Name: "ImageTexture", Inherits: "Texture2D"},
+	{Name: "AtlasTexture", Inherits: "Texture2D"},
+	{Name: "CanvasTexture", Inherits: "Texture2D"},
+	{Name: "GradientTexture1D", Inherits: "Texture2D", Defaults: map[string]string{"width": "256", "use_hdr": "false"}},
+	{Name: "GradientTexture2D", Inherits: "Texture2D", Defaults: map[string]string{"width": "64", "height": "64"}},
+	{Name: "CurveTexture", Inherits: "Texture2D", Defaults: map[string]string{"width": "256"}},
+	{Name: "Font", Inherits: "Resource"},
+	{Name: "FontFile", Inherits: "Font"},
+	{Name: "FontVariation", Inherits: "Font"},
+	{Name: "SystemFont", Inherits: "Font"},
+	{Name: "Theme", Inherits: "Resource"},
+	{Name: "StyleBox", Inherits: "Resource"},
+	{Name: "StyleBoxFlat", Inherits: "StyleBox", Defaults: map[string]string{"bg_color": "Color(0.6, 0.6, 0.6, 1)", "draw_center": "true"}},
+	{Name: "StyleBoxTexture", Inherits: "StyleBox"},
+	{Name: "StyleBoxEmpty", Inherits: "StyleBox"},
+	{Name: "StyleBoxLine", Inherits: "StyleBox"},
+	{Name: "LabelSettings", Inherits: "Resource", Defaults: map[string]string{"font_size": "16", "font_color": "Color(1, 1, 1, 1)"}},
+	{Name: "Material", Inherits: "Resource"},
+	{Name: "ShaderMaterial", Inherits: "Material"},
+	{Name: "CanvasItemMaterial", Inherits: "Material"},
+	{Name: "BaseMaterial3D", Inherits: "Material"},
+	{Name: "StandardMaterial3D", Inherits: "BaseMaterial3D"},
+	{Name: "ORMMaterial3D", Inherits: "BaseMaterial3D"},
+	{Name: "ParticleProcessMaterial", Inherits: "Material"},
+	{Name: "Shader", Inherits: "Resource"},
+	{Name: "VisualShader", Inherits: "Shader"},
+	{Name: "Mesh", Inherits: "Resource"},
+	{Name: "ArrayMesh", Inherits: "Mesh"},
+	{Name: "PrimitiveMesh", Inherits: "Mesh"},
+	{Name: "BoxMesh", Inherits: "PrimitiveMesh"},
+	{Name: "SphereMesh", Inherits: "PrimitiveMesh"},
+	{Name: "PlaneMesh", Inherits: "PrimitiveMesh"},
+	{Name: "CapsuleMesh", Inherits: "PrimitiveMesh"},
+	{Name: "CylinderMesh", Inherits: "PrimitiveMesh"},
+	{Name: "QuadMesh", Inherits: "PlaneMesh"},
+	{Name: "MultiMesh", Inherits: "Resource"},
+	{Name: "Shape2D", Inherits: "Resource"},
+	{Name: "CircleShape2D", Inherits: "Shape2D", Defaults: map[string]string{"radius": "10.0"}},
+	{Name: "RectangleShape2D", Inherits: "Shape2D", Defaults: map[string]string{"size": "Vector2(20, 20)"}},
+	{Name: "CapsuleShape2D", Inherits: "Shape2D", Defaults: map[string]string{"radius": "10.0", "height": "30.0"}},
+	{Name: "SegmentShape2D", Inherits: "Shape2D"},
+	{Name: "ConvexPolygonShape2D", Inherits: "Shape2D"},
+	{Name: "ConcavePolygonShape2D", Inherits: "Shape2D"},
+	{Name: "WorldBoundaryShape2D", Inherits: "Shape2D"},
+	{Name: "Shape3D", Inherits: "Resource"},
+	{Name: "BoxShape3D", Inherits: "Shape3D", Defaults: map[string]string{"size": "Vector3(1, 1, 1)"}},
+	{Name: "SphereShape3D", Inherits: "Shape3D", Defaults: map[string]string{"radius": "0.5"}},
+	{Name: "CapsuleShape3D", Inherits: "Shape3D", Defaults: map[string]string{"radius": "0.5", "height": "2.0"}},
+	{Name: "CylinderShape3D", Inherits: "Shape3D", Defaults: map[string]string{"radius": "0.5", "height": "2.0"}},
+	{Name: "ConvexPolygonShape3D", Inherits: "Shape3D"},
+	{Name: "ConcavePolygonShape3D", Inherits: "Shape3D"},
+	{Name: "WorldBoundaryShape3D", Inherits: "Shape3D"},
+	{Name: "Curve", Inherits: "Resource", Defaults: map[string]string{"min_value": "0.0", "max_value": "1.0", "bake_resolution": "100"}},
+	{Name: "Curve2D", Inherits: "Resource"},
+	{Name: "Curve3D", Inherits: "Resource"},
+	{Name: "Gradient", Inherits: "Resource"},
+	{Name: "Animation", Inherits: "Resource", Defaults: map[string]string{"length": "1.0", "loop_mode": "0", "step": "0.0333333"}},
+	{Name: "AnimationLibrary", Inherits: "Resource"},
+	{Name: "SpriteFrames", Inherits: "Resource"},
+	{Name: "TileSet", Inherits: "Resource"},
+	{Name: "Environment", Inherits: "Resource"},
+	{Name: "Sky", Inherits: "Resource"},
+	{Name: "AudioStream", Inherits: "Resource"},
+	{Name: "AudioStreamWAV", Inherits: "AudioStream"},
+	{Name: "AudioStreamOggVorbis", Inherits: "AudioStream"},
+	{Name: "AudioStreamMP3", Inherits: "AudioStream"},
+	{Name: "AudioBusLayout", Inherits: "Resource"},
+	{Name: "NavigationPolygon", Inherits: "Resource"},
+	{Name: "NavigationMesh", Inherits: "Resource"},
+	{Name: "OccluderPolygon2D", Inherits: "Resource", Defaults: map[string]string{"closed": "true", "cull_mode": "0"}},
+	{Name: "Skin", Inherits: "Resource"},
+	{Name: "InputEvent", Inherits: "Resource"},
+	{Name: "InputEventKey", Inherits: "InputEvent"},
+	{Name: "InputEventMouseButton", Inherits: "InputEvent"},
+	{Name: "InputEventJoypadButton", Inherits: "InputEvent"},
+	{Name: "InputEventJoypadMotion", Inherits: "InputEvent"},
+	{Name: "Translation", Inherits: "Resource"},
+}
+
+func init() {
+	for _, class := range builtinClasses {
+		classDB[class.Name] = class
+	}
+}
+
+// lookupClass returns the class named name, or nil when unknown
+func lookupClass(name string) *GodotClass {
+	return classDB[name]
+}
+
+// classInherits reports whether class is base or derives from it
+func classInherits(class, base string) bool {
+	for seen := 0; class != "" && seen < 64; seen++ {
+		if class == base {
+			return true
+		}
+		c := lookupClass(class)
+		if c == nil {
+			return false
+		}
+		class = c.Inherits
+	}
+	return false
+}
+
+// propertyDefault returns the default value of prop for class, walking base classes
+func propertyDefault(class, prop string) (string, bool) {
+	for seen := 0; class != "" && seen < 64; seen++ {
+		c := lookupClass(class)
+		if c == nil {
+			return "", false
+		}
+		if value, exists := c.Defaults[prop]; exists {
+			return value, true
+		}
+		class = c.Inherits
+	}
+	return "", false
+}
+
+// classNames returns all known class names in sorted order
+func classNames() []string {
+	names := make([]string, 0, len(classDB))
+	for name := range classDB {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeVariant makes variant values comparable ("1.0" == "1", "1e-05" ==
+// "0.00001", spacing ignored)
+func normalizeVariant(value string) string {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	var b strings.Builder
+	end := 0
+	for _, span := range numberSpans(value) {
+		b.WriteString(value[end:span[0]])
+		number := value[span[0]:span[1]]
+		if f, err := parseVariantFloat(number); err == nil {
+			number = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		b.WriteString(number)
+		end = span[1]
+	}
+	b.WriteString(value[end:])
+	return b.String()
+}
+
+// isDefaultValue reports whether value equals the class default of prop
+func isDefaultValue(class, prop, value string) bool {
+	defaultValue, exists := propertyDefault(class, prop)
+	if !exists {
+		return false
+	}
+	return normalizeVariant(defaultValue) == normalizeVariant(value)
+}
+
+// docClass mirrors the class XML written by `godot --doctool`
+type docClass struct {
+	Name     string `xml:"name,attr"`
+	Inherits string `xml:"inherits,attr"`
+	Members  []struct {
+		Name    string `xml:"name,attr"`
+		Default string `xml:"default,attr"`
+	} `xml:"members>member"`
+	Signals []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"signals>signal"`
+}
+
+// loadClassDB merges class definitions from a JSON file (list of GodotClass)
+// or from a directory/file of `godot --doctool` class XML into the class database
+func loadClassDB(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to open class database: %v", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to open class database: %v", err)
+		}
+		var classes []*GodotClass
+		if err := json.Unmarshal(content, &classes); err != nil {
+			return fmt.Errorf("invalid class database %s: %v", path, err)
+		}
+		for _, class := range classes {
+			classDB[class.Name] = class
+		}
+		return nil
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = findProjectFiles(path, []string{".xml"})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to open class database: %v", err)
+		}
+		var doc docClass
+		if err := xml.Unmarshal(content, &doc); err != nil || doc.Name == "" {
+			logger.Warn("Skipping class file", "path", file, "error", err)
+			continue
+		}
+		class := &GodotClass{Name: doc.Name, Inherits: doc.Inherits, Defaults: make(map[string]string)}
+		for _, member := range doc.Members {
+			if member.Default != "" {
+				class.Defaults[member.Name] = member.Default
+			}
+		}
+		for _, signal := range doc.Signals {
+			class.Signals = append(class.Signals, signal.Name)
+		}
+		classDB[class.Name] = class
+	}
+
+	return nil
+}
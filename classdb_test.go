@@ -0,0 +1,60 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsDefaultValue(t *testing.T) {
+	testCases := []struct {
+		class string
+		prop  string
+		value string
+		def   bool
+	}{
+		{"Sprite2D", "position", "Vector2(0,0)", true},   // inherited from Node2D
+		{"Sprite2D", "scale", "Vector2(1.0, 1.0)", true}, // number formatting
+		{"Sprite2D", "visible", "false", false},          // inherited from CanvasItem
+		{"Label", "text", `""`, true},                    // own default
+		{"VBoxContainer", "vertical", "true", true},      // overridden in subclass
+		{"UnknownClass", "position", "Vector2(0, 0)", false},
+	}
+
+	for _, tc := range testCases {
+		if got := isDefaultValue(tc.class, tc.prop, tc.value); got != tc.def {
+			t.Errorf("isDefaultValue(%s, %s, %s) = %v, expected %v", tc.class, tc.prop, tc.value, got, tc.def)
+		}
+	}
+
+	if !classInherits("CharacterBody2D", "CanvasItem") || classInherits("Label", "Node2D") {
+		t.Error("Class hierarchy is wrong")
+	}
+}
+
+func TestLoadClassDBFromDoctool(t *testing.T) {
+	dir := t.TempDir()
+	xmlContent := `<?xml version="1.0" encoding="UTF-8" ?>
+<class name="MyWidget" inherits="Control" version="4.2">
+	<members>
+		<member name="speed" type="float" setter="set_speed" getter="get_speed" default="2.5">
+		</member>
+	</members>
+</class>
+`
+	if err := os.WriteFile(filepath.Join(dir, "MyWidget.xml"), []byte(xmlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer delete(classDB, "MyWidget")
+
+	if err := loadClassDB(dir); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+
+	if !isDefaultValue("MyWidget", "speed", "2.5") {
+		t.Error("Default from doctool XML not loaded")
+	}
+	if !isDefaultValue("MyWidget", "visible", "true") {
+		t.Error("Defaults of built-in base class not inherited")
+	}
+}
@@ -0,0 +1,17 @@
+// Package cli runs gdq command lines in-process, for Go programs and tests
+// that embed gdq. The commands themselves live in the gdquery package, next
+// to the parser whose internals they share.
+package cli
+
+import (
+	"io"
+
+	"gdquery"
+)
+
+// Run executes gdq with args (without the program name), writing its output
+// to stdout and stderr, and returns the exit status: 0 on success, 1 when the
+// command failed. See gdquery.Run.
+func Run(args []string, stdout, stderr io.Writer) int {
+	return gdquery.Run(args, stdout, stderr)
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"--help"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Usage:") {
+		t.Errorf("Expected the usage on stdout, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := Run([]string{"missing.tscn"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit status 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "file not found: missing.tscn") {
+		t.Errorf("Expected the error on stderr, got: %q", stderr.String())
+	}
+}
@@ -0,0 +1,13 @@
+// Command gdq parses Godot scene files and queries their node trees. The
+// commands live in the gdquery package.
+package main
+
+import (
+	"os"
+
+	"gdquery/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
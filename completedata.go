@@ -0,0 +1,221 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// gdscriptSignalRe matches the signals a GDScript declares
+var gdscriptSignalRe = regexp.MustCompile(`(?m)^signal\s+(\w+)`)
+
+// gdscriptMemberVarRe matches the member variables a GDScript declares, with their
+// annotations (@export, @onready) or Godot 3 keywords (export(int), onready)
+var gdscriptMemberVarRe = regexp.MustCompile(`(?m)^(?:@?\w+(?:\([^)\n]*\))?\s+)*var\s+(\w+)`)
+
+// CompletionNode is a node of a scene as editor plugins complete it
+type CompletionNode struct {
+	Path       string   `json:"path"` // NodePath from the scene root, "." for the root
+	Name       string   `json:"name"`
+	Type       string   `json:"type,omitempty"`
+	Unique     string   `json:"unique,omitempty"` // %Name when unique_name_in_owner is set
+	Script     string   `json:"script,omitempty"`
+	Instance   string   `json:"instance,omitempty"`
+	Properties []string `json:"properties,omitempty"`
+	Signals    []string `json:"signals,omitempty"`
+}
+
+// CompletionData is the completion data of a scene
+type CompletionData struct {
+	File  string            `json:"file"` // res:// path
+	Root  string            `json:"root"`
+	Nodes []*CompletionNode `json:"nodes"`
+}
+
+// scriptMembers are the signals and member variables a script declares
+type scriptMembers struct {
+	Signals []string
+	Vars    []string
+}
+
+// readScriptMembers reads the signals and member variables of a GDScript, or
+// the signals and exported members of a C# script, nil when the script cannot be read
+func readScriptMembers(root, resPath string) *scriptMembers {
+	if hasExtension(resPath, csharpExtensions) {
+		cs := readCSharpScript(root, resPath)
+		if cs == nil {
+			return nil
+		}
+		members := &scriptMembers{Signals: cs.Signals}
+		for _, variable := range cs.Exports {
+			members.Vars = append(members.Vars, variable.Name)
+		}
+		return members
+	}
+	content, err := os.ReadFile(resToFS(root, resPath))
+	if err != nil {
+		return nil
+	}
+	members := &scriptMembers{}
+	for _, match := range gdscriptSignalRe.FindAllStringSubmatch(string(content), -1) {
+		members.Signals = append(members.Signals, match[1])
+	}
+	for _, match := range gdscriptMemberVarRe.FindAllStringSubmatch(string(content), -1) {
+		members.Vars = append(members.Vars, match[1])
+	}
+	return members
+}
+
+// classMembers returns the known properties and signals of a class and its
+// base classes. Godot 3 classes are looked up under their Godot 4 name.
+func classMembers(class string) ([]string, []string) {
+	if rename, exists := legacyTypeRenames[class]; exists {
+		class = rename.New
+	}
+	var properties, signals []string
+	for seen := 0; class != "" && seen < 64; seen++ {
+		c := lookupClass(class)
+		if c == nil {
+			break
+		}
+		for prop := range c.Defaults {
+			properties = append(properties, prop)
+		}
+		signals = append(signals, c.Signals...)
+		class = c.Inherits
+	}
+	return properties, signals
+}
+
+// sortedUnique sorts names and drops the duplicates
+func sortedUnique(names []string) []string {
+	sort.Strings(names)
+	var unique []string
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// buildCompletionData collects the node paths, types, properties and signals
+// of a scene. Properties are those set in the scene, known for the class and
+// declared by the script; signals are those of the class and the script, and
+// those connected from the node.
+func buildCompletionData(scene *GodotScene, root string) *CompletionData {
+	sceneRes := fsToRes(root, scene.File)
+	data := &CompletionData{File: sceneRes, Nodes: []*CompletionNode{}}
+	if scene.RootNode == nil {
+		return data
+	}
+	data.Root = scene.RootNode.Name
+
+	scripts := make(map[string]*scriptMembers)
+	scene.Walk(func(node *GodotNode, depth int) WalkAction {
+		item := &CompletionNode{
+			Path:     relativeNodePath(scene.RootNode, node),
+			Name:     node.Name,
+			Type:     nodeClass(node),
+			Instance: node.InstanceOf,
+		}
+		// Unique names resolve within the scene owning the node, not through instances
+		owner := sceneRes
+		if node.Origin != "" {
+			owner = node.Origin
+		}
+		if node.Properties["unique_name_in_owner"] == "true" && owner == sceneRes {
+			item.Unique = "%" + node.Name
+		}
+
+		properties, signals := classMembers(item.Type)
+		for key := range node.Properties {
+			if !strings.HasPrefix(key, "metadata/") {
+				properties = append(properties, key)
+			}
+		}
+		// Built-in scripts live in the scene; uids cannot be resolved without the editor cache
+		if script := resolveResourcePath(node.Script, scene); script != "" && !strings.HasPrefix(script, "SubResource(") && !strings.HasPrefix(script, "uid://") {
+			item.Script = normalizeResPath(owner, script)
+			members, exists := scripts[item.Script]
+			if !exists {
+				members = readScriptMembers(root, item.Script)
+				scripts[item.Script] = members
+			}
+			if members != nil {
+				properties = append(properties, members.Vars...)
+				signals = append(signals, members.Signals...)
+			}
+		}
+		for _, connection := range scene.Connections {
+			if connectionNodePath(scene, connection.From) == node.Path {
+				signals = append(signals, connection.Signal)
+			}
+		}
+		item.Properties = sortedUnique(properties)
+		item.Signals = sortedUnique(signals)
+		data.Nodes = append(data.Nodes, item)
+		return WalkContinue
+	})
+	return data
+}
+
+// printCompletionData displays the node paths of the completion data with
+// the number of properties and signals; JSON output lists them
+func printCompletionData(out io.Writer, data *CompletionData) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tUNIQUE\tTYPE\tSCRIPT\tPROPERTIES\tSIGNALS")
+	for _, node := range data.Nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n", node.Path, node.Unique, node.Type, node.Script, len(node.Properties), len(node.Signals))
+	}
+	w.Flush()
+}
+
+var completeDataCmd = &cobra.Command{
+	Use:   "complete-data <tscn file>",
+	Short: "Dump node paths, types, properties and signals for editor completion",
+	Long: `Dump what an editor plugin needs to complete get_node() and NodePath strings in the scripts of
+a scene: the path of every node from the scene root, its unique %Name, type, script and
+instanced scene, the properties it can set and the signals it can emit.
+
+Properties come from the scene, the class database (--class-db) and the member variables of
+the attached GDScript; signals from the class database, the script's signal declarations and
+the connections of the scene. Use -o json for the plugin format, and --expand-instances to
+include the nodes of instanced scenes.`,
+	Example: `  gdq complete-data -o json player.tscn
+  gdq complete-data -o json --class-db docs/classes --expand-instances level.tscn`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		file := args[0]
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s", file)
+		}
+		scene, err := parseSceneFile(file)
+		if err != nil {
+			return fmt.Errorf("parse error: %v", err)
+		}
+
+		data := buildCompletionData(scene, findProjectRoot(file))
+		if outputFormat == "json" {
+			return writeJSON(out, data)
+		}
+		printCompletionData(out, data)
+		return nil
+	},
+}
+
+func init() {
+	completeDataCmd.Flags().BoolVar(&expandInstances, "expand-instances", false, "Include the nodes of instanced scenes")
+	rootCmd.AddCommand(completeDataCmd)
+}
@@ -0,0 +1,110 @@
+package gdquery
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompletionData(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"player.gd": `extends CharacterBody2D
+
+signal died
+signal health_changed(value)
+
+@export var speed := 100.0
+@onready var sprite = $Sprite
+var health = 3
+
+func _ready():
+	var local = 1
+`,
+		"weapon.tscn": `[gd_scene format=3]
+
+[node name="Weapon" type="Node2D"]
+
+[node name="Muzzle" type="Marker2D" parent="."]
+unique_name_in_owner = true
+`,
+		"player.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_s"]
+[ext_resource type="PackedScene" path="res://weapon.tscn" id="2_w"]
+
+[node name="Player" type="CharacterBody2D"]
+script = ExtResource("1_s")
+
+[node name="Sprite" type="Sprite2D" parent="."]
+unique_name_in_owner = true
+metadata/_edit_lock_ = true
+
+[node name="Cooldown" type="Timer" parent="Sprite"]
+
+[node name="Weapon" parent="." instance=ExtResource("2_w")]
+
+[connection signal="frame_changed" from="Sprite" to="." method="_on_frame_changed"]
+`,
+	})
+
+	defer func() { expandInstances = false }()
+	expandInstances = true
+	scene, err := parseSceneFile(filepath.Join(root, "player.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	data := buildCompletionData(scene, root)
+	if data.File != "res://player.tscn" || data.Root != "Player" {
+		t.Errorf("Unexpected scene: %s %s", data.File, data.Root)
+	}
+
+	has := func(names []string, name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	nodes := make(map[string]*CompletionNode)
+	var paths []string
+	for _, node := range data.Nodes {
+		nodes[node.Path] = node
+		paths = append(paths, node.Path)
+	}
+	if strings.Join(paths, " ") != ". Sprite Sprite/Cooldown Weapon Weapon/Muzzle" {
+		t.Fatalf("Unexpected paths: %v", paths)
+	}
+
+	player := nodes["."]
+	if player.Script != "res://player.gd" {
+		t.Errorf("Unexpected script: %q", player.Script)
+	}
+	for _, prop := range []string{"speed", "sprite", "health", "velocity", "visible", "script"} {
+		if !has(player.Properties, prop) {
+			t.Errorf("Expected property %s in %v", prop, player.Properties)
+		}
+	}
+	if has(player.Properties, "local") {
+		t.Error("Function variables are not properties")
+	}
+	for _, signal := range []string{"died", "health_changed", "ready"} {
+		if !has(player.Signals, signal) {
+			t.Errorf("Expected signal %s in %v", signal, player.Signals)
+		}
+	}
+
+	sprite := nodes["Sprite"]
+	if sprite.Unique != "%Sprite" || !has(sprite.Signals, "frame_changed") || has(sprite.Properties, "metadata/_edit_lock_") {
+		t.Errorf("Unexpected Sprite: %+v", sprite)
+	}
+	if !has(nodes["Sprite/Cooldown"].Signals, "timeout") {
+		t.Errorf("Expected the Timer timeout signal: %v", nodes["Sprite/Cooldown"].Signals)
+	}
+	if weapon := nodes["Weapon"]; weapon.Type != "Node2D" || weapon.Instance != "res://weapon.tscn" {
+		t.Errorf("Unexpected Weapon: %+v", weapon)
+	}
+	if muzzle := nodes["Weapon/Muzzle"]; muzzle.Unique != "" {
+		t.Errorf("Unique names of instanced nodes are not reachable: %q", muzzle.Unique)
+	}
+}
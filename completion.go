@@ -0,0 +1,51 @@
+package gdquery
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// classCompletions returns the class names starting with prefix, each
+// preceded by lead (the part of the flag value before the class name). Classes
+// of a --class-db given on the command line are offered too.
+func classCompletions(lead, prefix string) []string {
+	if classDBPath != "" {
+		// Completion skips the pre-run hooks that load it
+		loadClassDB(classDBPath)
+	}
+	var completions []string
+	for _, name := range classNames() {
+		if strings.HasPrefix(name, prefix) {
+			completions = append(completions, lead+name)
+		}
+	}
+	return completions
+}
+
+// completeClassName completes a flag whose value is a class name
+func completeClassName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return classCompletions("", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeQueryType completes the class name of a type= or type!= term being
+// typed at the end of a node query expression
+func completeQueryType(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	start := strings.LastIndexAny(toComplete, ", ") + 1
+	for _, key := range []string{"type=", "type!="} {
+		if strings.HasPrefix(toComplete[start:], key) {
+			lead := toComplete[:start+len(key)]
+			return classCompletions(lead, toComplete[len(lead):]), cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAssertType completes the class name of a <node path>:<type> assertion
+func completeAssertType(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	i := strings.LastIndex(toComplete, ":")
+	if i < 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return classCompletions(toComplete[:i+1], toComplete[i+1:]), cobra.ShellCompDirectiveNoFileComp
+}
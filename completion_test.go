@@ -0,0 +1,35 @@
+package gdquery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestClassNameCompletion(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--type", "CharacterB"}, "CharacterBody2D CharacterBody3D"},
+		{[]string{"props", "--type", "Area"}, "Area2D Area3D"},
+		{[]string{"set", "--query", "name=Title*,type=Label"}, "name=Title*,type=Label name=Title*,type=Label3D name=Title*,type=LabelSettings"},
+		{[]string{"matrix", "--query", "type!=Area"}, "type!=Area2D type!=Area3D"},
+		{[]string{"matrix", "--query", "name=Are"}, ""},
+		{[]string{"assert", "--type", "HUD/Score:Area"}, "HUD/Score:Area2D HUD/Score:Area3D"},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := Run(append([]string{"__complete"}, test.args...), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: exit status %d: %s", test.args, code, stderr.String())
+		}
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		// The last line is the completion directive
+		if got := strings.Join(lines[:len(lines)-1], " "); got != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.args, test.expected, got)
+		}
+		if lines[len(lines)-1] != ":4" {
+			t.Errorf("%v: expected no file completion, got %s", test.args, lines[len(lines)-1])
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ConfigSection represents a [section] of a Godot ConfigFile (project.godot, *.cfg, *.import)
+type ConfigSection struct {
+	Name   string
+	Keys   []string
+	Values map[string]string
+}
+
+// ConfigFile represents a parsed Godot ConfigFile
+type ConfigFile struct {
+	Sections []*ConfigSection
+}
+
+// Section returns the section with the given name, or nil
+func (c *ConfigFile) Section(name string) *ConfigSection {
+	for _, section := range c.Sections {
+		if section.Name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// Get returns the raw value of key in section
+func (c *ConfigFile) Get(section, key string) (string, bool) {
+	s := c.Section(section)
+	if s == nil {
+		return "", false
+	}
+	value, exists := s.Values[key]
+	return value, exists
+}
+
+// GetString returns the unquoted string value of key in section
+func (c *ConfigFile) GetString(section, key string) string {
+	value, _ := c.Get(section, key)
+	return unquoteValue(value)
+}
+
+// parseConfigFile parses a Godot ConfigFile from disk
+func parseConfigFile(path string) (*ConfigFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	return parseConfigText(string(content)), nil
+}
+
+// toolConfigFile is the gdq configuration looked up in the project root, for
+// settings that are not lint rules ([plugins], [editor])
+const toolConfigFile = "gdq.cfg"
+
+// loadToolConfig reads the gdq configuration of the project at root. Projects
+// configured before gdq.cfg existed keep these settings in gdqlint.cfg, which
+// is read when there is no gdq.cfg. A missing configuration is not an error.
+func loadToolConfig(root string) (*ConfigFile, error) {
+	path := filepath.Join(root, toolConfigFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return loadLintConfig(root, "")
+	}
+	return parseConfigFile(path)
+}
+
+// parseConfigText parses ConfigFile text.
+// Values spanning several lines (arrays, dictionaries, strings) are joined.
+func parseConfigText(content string) *ConfigFile {
+	config := &ConfigFile{}
+	current := &ConfigSection{Values: make(map[string]string)}
+	config.Sections = append(config.Sections, current)
+
+	var pendingKey string
+	var pendingValue strings.Builder
+
+	for _, rawLine := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if pendingKey != "" {
+			pendingValue.WriteString("\n" + rawLine)
+			if valueComplete(pendingValue.String()) {
+				current.set(pendingKey, pendingValue.String())
+				pendingKey = ""
+				pendingValue.Reset()
+			}
+			continue
+		}
+
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = &ConfigSection{
+				Name:   strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"),
+				Values: make(map[string]string),
+			}
+			config.Sections = append(config.Sections, current)
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		if !valueComplete(value) {
+			pendingKey = key
+			pendingValue.WriteString(value)
+			continue
+		}
+		current.set(key, value)
+	}
+
+	if pendingKey != "" {
+		current.set(pendingKey, pendingValue.String())
+	}
+
+	// Drop the implicit section when the file starts with a section header
+	if len(config.Sections[0].Keys) == 0 {
+		config.Sections = config.Sections[1:]
+	}
+
+	return config
+}
+
+// set stores a key, remembering the declaration order
+func (s *ConfigSection) set(key, value string) {
+	if _, exists := s.Values[key]; !exists {
+		s.Keys = append(s.Keys, key)
+	}
+	s.Values[key] = value
+}
+
+// valueComplete reports whether a variant value has balanced quotes and brackets
+func valueComplete(value string) bool {
+	depth, inString := scanValueNesting(value, 0, false)
+	return !inString && depth <= 0
+}
+
+// scanValueNesting continues the bracket depth and string state of a variant
+// value over text, so values spanning many lines are checked line by line
+func scanValueNesting(text string, depth int, inString bool) (int, bool) {
+	escaped := false
+
+	for _, r := range text {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch r {
+		case '"':
+			inString = true
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+	}
+
+	return depth, inString
+}
+
+// unquoteValue strips the quotes of a string variant and resolves escapes
+func unquoteValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || !strings.HasPrefix(value, "\"") || !strings.HasSuffix(value, "\"") {
+		return value
+	}
+	value = value[1 : len(value)-1]
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	// One pass, so that an escaped backslash is not read as part of the next escape
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(value[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// stringListRe matches the quoted elements of PackedStringArray(...) or [...] values
+var stringListRe = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+
+// parseStringList extracts the strings of a PackedStringArray/PoolStringArray/Array value
+func parseStringList(value string) []string {
+	var list []string
+	for _, matches := range stringListRe.FindAllStringSubmatch(value, -1) {
+		list = append(list, unquoteValue("\""+matches[1]+"\""))
+	}
+	return list
+}
@@ -0,0 +1,264 @@
+package gdquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gdquery/query"
+	"github.com/spf13/cobra"
+)
+
+// ConformSpec is the expected structure of a category of scenes, read from a YAML file
+type ConformSpec struct {
+	Files string    `json:"files"` // wildcard on the scene file names, all scenes when empty
+	Root  *NodeSpec `json:"root"`
+}
+
+// NodeSpec is the contract of one node. Children are required unless optional;
+// other children are allowed unless allow_extra_children is false.
+type NodeSpec struct {
+	Name               string      `json:"name"` // wildcard, required for children
+	Type               StringList  `json:"type"` // allowed classes, subclasses and script classes included
+	Script             string      `json:"script"`
+	Optional           bool        `json:"optional"`
+	AllowExtraChildren *bool       `json:"allow_extra_children"`
+	Children           []*NodeSpec `json:"children"`
+}
+
+// StringList is a list of strings written as a single string or a sequence
+type StringList []string
+
+// UnmarshalJSON accepts a string or a list of strings
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or a list of strings")
+	}
+	*l = list
+	return nil
+}
+
+// loadConformSpec reads a spec file. The YAML is decoded through JSON so that
+// misspelled keys are reported.
+func loadConformSpec(path string) (*ConformSpec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseYAML(string(content))
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &ConformSpec{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(spec); err != nil {
+		return nil, err
+	}
+	if spec.Root == nil {
+		return nil, fmt.Errorf("missing root")
+	}
+	if err := spec.Root.validate("root"); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// validate checks that every child spec names its node
+func (s *NodeSpec) validate(path string) error {
+	for i, child := range s.Children {
+		if child == nil || child.Name == "" {
+			return fmt.Errorf("%s: child %d has no name", path, i+1)
+		}
+		if err := child.validate(path + "/" + child.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conformsToType reports whether a node is of one of the classes, using the
+// root type of the instanced scene for instanced nodes without a type
+func conformsToType(node *GodotNode, classes []string) bool {
+	for _, class := range classes {
+		if isOfType(node, class) || (node.Type == "" && node.InstanceType != "" && classInherits(node.InstanceType, class)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNodeSpec reports the differences between a node and its spec
+func checkNodeSpec(scene *GodotScene, file string, node *GodotNode, spec *NodeSpec) []LintFinding {
+	var findings []LintFinding
+	report := func(format string, args ...any) {
+		findings = append(findings, LintFinding{
+			Rule:     "conform",
+			Severity: severityError,
+			File:     file,
+			Line:     node.Span.StartLine,
+			Node:     node.Path,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if len(spec.Type) > 0 && !conformsToType(node, spec.Type) {
+		report("type %s, expected %s", typeLabel(node), strings.Join(spec.Type, " or "))
+	}
+
+	if spec.Script != "" {
+		script := resolveResourcePath(node.Script, scene)
+		if script != "" && !strings.HasPrefix(script, "SubResource(") && !strings.HasPrefix(script, "uid://") {
+			script = normalizeResPath(file, script)
+		}
+		switch {
+		case script == "":
+			report("missing script, expected %s", spec.Script)
+		case !query.Wildcard(spec.Script, script) && !matchesScriptClass(node, spec.Script):
+			report("script %s, expected %s", script, spec.Script)
+		}
+	}
+
+	matched := make(map[*GodotNode]bool)
+	for _, childSpec := range spec.Children {
+		var child *GodotNode
+		for _, candidate := range node.Children {
+			if !matched[candidate] && query.Wildcard(childSpec.Name, candidate.OriginalName) {
+				child = candidate
+				break
+			}
+		}
+		if child == nil {
+			if !childSpec.Optional {
+				report("missing required child %s", childSpec.Name)
+			}
+			continue
+		}
+		matched[child] = true
+		findings = append(findings, checkNodeSpec(scene, file, child, childSpec)...)
+	}
+
+	if spec.AllowExtraChildren != nil && !*spec.AllowExtraChildren {
+		for _, child := range node.Children {
+			if !matched[child] {
+				report("unexpected child %s", child.OriginalName)
+			}
+		}
+	}
+	return findings
+}
+
+// checkConformance reports how a scene differs from the spec
+func checkConformance(scene *GodotScene, file string, spec *ConformSpec) []LintFinding {
+	if scene.RootNode == nil {
+		return []LintFinding{{Rule: "conform", Severity: severityError, File: file, Message: "scene has no root node"}}
+	}
+	var findings []LintFinding
+	if spec.Root.Name != "" && !query.Wildcard(spec.Root.Name, scene.RootNode.OriginalName) {
+		findings = append(findings, LintFinding{
+			Rule:     "conform",
+			Severity: severityError,
+			File:     file,
+			Line:     scene.RootNode.Span.StartLine,
+			Node:     scene.RootNode.Path,
+			Message:  fmt.Sprintf("root named %s, expected %s", scene.RootNode.OriginalName, spec.Root.Name),
+		})
+	}
+	return append(findings, checkNodeSpec(scene, file, scene.RootNode, spec.Root)...)
+}
+
+var conformCmd = &cobra.Command{
+	Use:   "conform <spec> <dir>",
+	Short: "Check that the scenes under a directory match a structure spec",
+	Long: `Verify every scene under dir against a YAML spec describing the expected structure of
+that category of scenes: the allowed root types, required scripts and required (or optional)
+children, nested to any depth. Types include subclasses and script classes; names and scripts
+accept * and ? wildcards. Exits non-zero when a scene does not conform.
+
+  files: "level_*.tscn"        # optional, scene file names to check
+  root:
+    type: Node2D
+    script: res://levels/level.gd
+    children:
+      - name: Spawn
+        type: Marker2D
+      - name: Enemies
+      - name: Music
+        type: [AudioStreamPlayer, AudioStreamPlayer2D]
+        optional: true
+      - name: HUD
+        allow_extra_children: false
+        children:
+          - name: Score`,
+	Example:      `  gdq conform specs/level.yaml levels/`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		spec, err := loadConformSpec(args[0])
+		if err != nil {
+			return fmt.Errorf("spec error: %s: %v", args[0], err)
+		}
+		dir := args[1]
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		root := findProjectRoot(dir)
+		files, err := findProjectFiles(dir, nodeSceneExtensions)
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+
+		checked, failed, broken := 0, 0, 0
+		progress := newProgress("Checking", len(files))
+		for _, file := range files {
+			progress.Step()
+			if spec.Files != "" && !query.Wildcard(spec.Files, filepath.Base(file)) {
+				continue
+			}
+			checked++
+			scene, err := parseSceneFile(file)
+			if err != nil {
+				progress.Clear()
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", file, err)
+				broken++
+				continue
+			}
+			findings := checkConformance(scene, fsToRes(root, file), spec)
+			if len(findings) > 0 {
+				failed++
+				progress.Clear()
+				printLintFindings(out, findings)
+			}
+		}
+		progress.Clear()
+
+		fmt.Fprintf(out, "\n%d of %d scene(s) conform to %s\n", checked-failed-broken, checked, args[0])
+		if broken > 0 {
+			return fmt.Errorf("%d scene(s) could not be checked", broken)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d scene(s) do not conform", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(conformCmd)
+}
@@ -0,0 +1,121 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConformSpec(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"levels/level.gd": "class_name Level\nextends Node2D\n",
+		"levels/level_1.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://levels/level.gd" id="1_l"]
+
+[node name="Level1" type="Node2D"]
+script = ExtResource("1_l")
+
+[node name="Spawn" type="Marker2D" parent="."]
+
+[node name="Enemies" type="Node2D" parent="."]
+
+[node name="HUD" type="CanvasLayer" parent="."]
+
+[node name="Score" type="Label" parent="HUD"]
+`,
+		"levels/level_2.tscn": `[gd_scene format=3]
+
+[node name="Level2" type="Node3D"]
+
+[node name="Spawn" type="Node2D" parent="."]
+
+[node name="HUD" type="CanvasLayer" parent="."]
+
+[node name="Debug" type="Label" parent="HUD"]
+`,
+		"levels/piece.tscn": `[gd_scene format=3]
+
+[node name="Piece" type="Node"]
+`,
+		"specs/level.yaml": `files: "level_*.tscn"
+root:
+  type: Node2D
+  script: Level
+  children:
+    - name: Spawn
+      type: Marker2D
+    - name: Enemies
+    - name: Music
+      optional: true
+    - name: HUD
+      allow_extra_children: false
+      children:
+        - name: Score
+          type: [Label, RichTextLabel]
+`,
+	})
+
+	spec, err := loadConformSpec(filepath.Join(root, "specs", "level.yaml"))
+	if err != nil {
+		t.Fatalf("Spec error: %v", err)
+	}
+
+	var got []string
+	for _, name := range []string{"level_1.tscn", "level_2.tscn"} {
+		scene, err := parseSceneFile(filepath.Join(root, "levels", name))
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		for _, finding := range checkConformance(scene, "res://levels/"+name, spec) {
+			got = append(got, fmt.Sprintf("%s:%d:%s: %s", finding.File, finding.Line, finding.Node, finding.Message))
+		}
+	}
+	expected := []string{
+		"res://levels/level_2.tscn:3:Level2: type Node3D, expected Node2D",
+		"res://levels/level_2.tscn:3:Level2: missing script, expected Level",
+		"res://levels/level_2.tscn:5:Level2/Spawn: type Node2D, expected Marker2D",
+		"res://levels/level_2.tscn:3:Level2: missing required child Enemies",
+		"res://levels/level_2.tscn:7:Level2/HUD: missing required child Score",
+		"res://levels/level_2.tscn:7:Level2/HUD: unexpected child Debug",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	// Misspelled keys and children without a name are rejected
+	for _, invalid := range []string{"root:\n  typ: Node2D\n", "root:\n  children:\n    - type: Node\n", "files: x\n"} {
+		path := filepath.Join(root, "specs", "invalid.yaml")
+		os.WriteFile(path, []byte(invalid), 0644)
+		if _, err := loadConformSpec(path); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestConformCommandUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Unreadable files are readable by root")
+	}
+	root := writeProjectFiles(t, map[string]string{
+		"levels/level_1.tscn": "[gd_scene format=3]\n\n[node name=\"Level1\" type=\"Node2D\"]\n",
+		"levels/level_2.tscn": "[gd_scene format=3]\n\n[node name=\"Level2\" type=\"Node2D\"]\n",
+		"specs/level.yaml":    "root:\n  type: Node2D\n",
+	})
+	locked := filepath.Join(root, "levels", "level_2.tscn")
+	os.Chmod(locked, 0)
+
+	// An unreadable scene is reported on stderr, out of the report, and fails the command
+	var stdout, stderr strings.Builder
+	if code := Run([]string{"conform", filepath.Join(root, "specs", "level.yaml"), filepath.Join(root, "levels")}, &stdout, &stderr); code == 0 {
+		t.Error("Expected a failure for the unreadable scene")
+	}
+	if !strings.Contains(stderr.String(), "Error: "+locked) || strings.Contains(stdout.String(), "Error") {
+		t.Errorf("Expected the error on stderr:\nstdout: %s\nstderr: %s", stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "1 of 2 scene(s) conform") {
+		t.Errorf("Unexpected report:\n%s", stdout.String())
+	}
+}
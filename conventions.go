@@ -0,0 +1,190 @@
+package gdquery
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// nameCasePatterns maps the naming conventions accepted by the case options to their patterns
+var nameCasePatterns = map[string]*regexp.Regexp{
+	"PascalCase": regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`),
+	"camelCase":  regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`),
+	"snake_case": regexp.MustCompile(`^[a-z0-9]+(?:_[a-z0-9]+)*$`),
+	"kebab-case": regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`),
+}
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "node-name-case",
+		Description: "Node names that do not follow the naming convention (option case, default PascalCase)",
+		Check:       checkNodeNameCase,
+	})
+	registerLintRule(&LintRule{
+		Name:        "scene-file-case",
+		Description: "Scene file names that do not follow the naming convention (option case, default snake_case)",
+		Check:       checkSceneFileCase,
+	})
+	registerLintRule(&LintRule{
+		Name:        "root-type",
+		Description: "Scenes whose root node does not have the type required for their directory (options: <dir>=\"<Class>\")",
+		Check:       checkRootType,
+	})
+	registerLintRule(&LintRule{
+		Name:        "scenes-per-dir",
+		Description: "Directories holding more scenes than allowed (option max)",
+		Check:       checkScenesPerDir,
+	})
+}
+
+// caseOption returns the pattern of the naming convention configured for a rule
+func caseOption(ctx *LintContext, rule, def string) (string, *regexp.Regexp) {
+	name := def
+	if value, exists := ctx.option(rule, "case"); exists {
+		name = unquoteValue(value)
+	}
+	pattern, known := nameCasePatterns[name]
+	if !known {
+		logger.Warn("Unknown naming convention", "rule", rule, "case", name)
+		return name, nil
+	}
+	return name, pattern
+}
+
+// checkNodeNameCase reports nodes whose name does not follow the configured convention
+func checkNodeNameCase(ctx *LintContext) []LintFinding {
+	caseName, pattern := caseOption(ctx, "node-name-case", "PascalCase")
+	if pattern == nil {
+		return nil
+	}
+
+	var findings []LintFinding
+	for _, result := range ctx.Scenes() {
+		if result.Err != nil {
+			continue
+		}
+		for _, node := range result.Scene.AllNodes {
+			if !pattern.MatchString(node.OriginalName) {
+				findings = append(findings, LintFinding{
+					File:    result.File,
+					Node:    node.Path,
+					Message: fmt.Sprintf("node name %q is not %s", node.OriginalName, caseName),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// checkSceneFileCase reports scene files whose name does not follow the configured convention
+func checkSceneFileCase(ctx *LintContext) []LintFinding {
+	caseName, pattern := caseOption(ctx, "scene-file-case", "snake_case")
+	if pattern == nil {
+		return nil
+	}
+
+	var findings []LintFinding
+	for _, result := range ctx.Scenes() {
+		base := path.Base(result.File)
+		name := strings.TrimSuffix(base, path.Ext(base))
+		if !pattern.MatchString(name) {
+			findings = append(findings, LintFinding{
+				File:    result.File,
+				Message: fmt.Sprintf("file name %q is not %s", base, caseName),
+			})
+		}
+	}
+	return findings
+}
+
+// rootTypeRequirements returns the required root class per res:// directory,
+// longest directory first so the most specific requirement wins
+func rootTypeRequirements(ctx *LintContext) [][2]string {
+	section := ctx.Config.Section("root-type")
+	if section == nil {
+		return nil
+	}
+
+	var requirements [][2]string
+	for _, key := range section.Keys {
+		if key == "enabled" || key == "severity" {
+			continue
+		}
+		dir := strings.TrimSuffix(normalizeResPath("res://", key), "/")
+		requirements = append(requirements, [2]string{dir, unquoteValue(section.Values[key])})
+	}
+	sort.SliceStable(requirements, func(i, j int) bool {
+		return len(requirements[i][0]) > len(requirements[j][0])
+	})
+	return requirements
+}
+
+// checkRootType reports scenes whose root type does not derive from the class
+// required for their directory
+func checkRootType(ctx *LintContext) []LintFinding {
+	if ctx.Config == nil {
+		return nil
+	}
+	requirements := rootTypeRequirements(ctx)
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	var findings []LintFinding
+	for _, result := range ctx.Scenes() {
+		if result.Err != nil || result.Scene.RootNode == nil {
+			continue
+		}
+		root := result.Scene.RootNode
+
+		for _, requirement := range requirements {
+			dir, class := requirement[0], requirement[1]
+			if !strings.HasPrefix(result.File, dir+"/") {
+				continue
+			}
+			// Inherited scenes take their root type from the base scene
+			if root.Type != "" && !classInherits(root.Type, class) {
+				findings = append(findings, LintFinding{
+					Severity: severityError,
+					File:     result.File,
+					Node:     root.Path,
+					Message:  fmt.Sprintf("root node type %s is not a %s (required for %s/)", root.Type, class, dir),
+				})
+			}
+			break
+		}
+	}
+	return findings
+}
+
+// checkScenesPerDir reports directories holding more scenes than the configured maximum
+func checkScenesPerDir(ctx *LintContext) []LintFinding {
+	value, exists := ctx.option("scenes-per-dir", "max")
+	if !exists {
+		return nil
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		logger.Warn("Invalid scenes-per-dir max", "value", value)
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, result := range ctx.Scenes() {
+		counts[resDir(result.File)]++
+	}
+
+	var findings []LintFinding
+	for dir, count := range counts {
+		if count > limit {
+			findings = append(findings, LintFinding{
+				File:    dir,
+				Message: fmt.Sprintf("%d scenes in directory (max %d)", count, limit),
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,156 @@
+package gdquery
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// csharpExtensions are the extensions of C# scripts (Godot .NET / Mono projects)
+var csharpExtensions = []string{".cs"}
+
+// CSharpScript is what the C# scanner reads from a Godot C# script
+type CSharpScript struct {
+	Class   string         // the class named after the file, as Godot requires, else the first class
+	Base    string         // the class it derives from, without the Godot. namespace
+	Global  bool           // [GlobalClass]: usable by name like a GDScript class_name
+	Exports []*ExportedVar // [Export] fields and properties
+	Signals []string       // [Signal] delegates, without the EventHandler suffix
+}
+
+// C# declarations. The scanner is not a C# parser: it reads the declarations
+// Godot's source generators look for, after comments are removed.
+var (
+	csharpClassRe  = regexp.MustCompile(`\bclass\s+(\w+)(?:\s*<[^>{]*>)?\s*(?::\s*([\w.]+))?`)
+	csharpGlobalRe = regexp.MustCompile(`\[\s*GlobalClass\s*\]`)
+	csharpSignalRe = regexp.MustCompile(`\[\s*Signal\s*\]\s*(?:\[[^\]]*\]\s*)*(?:\w+\s+)*delegate\s+[\w.<>\[\]?]+\s+(\w+)\s*\(`)
+	// csharpExportRe matches [Export] and [Export(hint, "hint string")], and
+	// the attributes following it up to the declaration
+	csharpExportRe    = regexp.MustCompile(`\[\s*Export\s*(?:\([^)]*\))?\s*\]\s*(?:\[[^\]]*\]\s*)*`)
+	csharpModifierRe  = regexp.MustCompile(`^(?:(?:public|private|protected|internal|static|readonly|new|override|virtual|required|partial)\s+)*`)
+	csharpMemberNameR = regexp.MustCompile(`^(.*\S)\s+(\w+)$`)
+)
+
+// stripCSharpComments removes // and /* */ comments, keeping strings, verbatim
+// strings and character literals intact
+func stripCSharpComments(source string) string {
+	var b strings.Builder
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case c == '/' && i+1 < len(source) && source[i+1] == '/':
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+			if i < len(source) {
+				b.WriteByte('\n')
+			}
+		case c == '/' && i+1 < len(source) && source[i+1] == '*':
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+			b.WriteByte(' ')
+		case c == '"' || c == '\'':
+			// Verbatim strings escape quotes by doubling them
+			verbatim := c == '"' && i > 0 && source[i-1] == '@'
+			start := i
+			for i++; i < len(source); i++ {
+				if !verbatim && source[i] == '\\' {
+					i++
+				} else if source[i] == c {
+					if verbatim && i+1 < len(source) && source[i+1] == '"' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteString(source[start:min(i+1, len(source))])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// parseCSharpExport reads the declaration following an [Export] attribute:
+// a field (Type name = value;) or an auto-property (Type Name { get; set; } = value;)
+func parseCSharpExport(declaration string) *ExportedVar {
+	end := strings.IndexAny(declaration, ";{=")
+	if end < 0 {
+		return nil
+	}
+	head := csharpModifierRe.ReplaceAllString(strings.TrimSpace(declaration[:end]), "")
+	matches := csharpMemberNameR.FindStringSubmatch(head)
+	if matches == nil {
+		return nil
+	}
+	variable := &ExportedVar{Name: matches[2], Type: strings.TrimSpace(matches[1])}
+
+	rest := declaration[end:]
+	if strings.HasPrefix(rest, "{") {
+		// Property: the initializer follows the accessors
+		close := strings.Index(rest, "}")
+		if close < 0 {
+			return variable
+		}
+		rest = strings.TrimSpace(rest[close+1:])
+	}
+	if strings.HasPrefix(rest, "=") && !strings.HasPrefix(rest, "=>") {
+		value, _, _ := strings.Cut(rest[1:], ";")
+		variable.Default = strings.Join(strings.Fields(value), " ")
+	}
+	return variable
+}
+
+// parseCSharpScript scans a C# script named fileName for its class, base
+// class, exported members and signals
+func parseCSharpScript(source, fileName string) *CSharpScript {
+	source = stripCSharpComments(source)
+	script := &CSharpScript{Global: csharpGlobalRe.MatchString(source)}
+
+	want := strings.TrimSuffix(path.Base(fileName), ".cs")
+	for _, matches := range csharpClassRe.FindAllStringSubmatch(source, -1) {
+		if script.Class == "" || matches[1] == want {
+			script.Class = matches[1]
+			script.Base = strings.TrimPrefix(matches[2], "Godot.")
+		}
+		if matches[1] == want {
+			break
+		}
+	}
+
+	for _, loc := range csharpExportRe.FindAllStringIndex(source, -1) {
+		if variable := parseCSharpExport(source[loc[1]:]); variable != nil {
+			script.Exports = append(script.Exports, variable)
+		}
+	}
+	for _, matches := range csharpSignalRe.FindAllStringSubmatch(source, -1) {
+		script.Signals = append(script.Signals, strings.TrimSuffix(matches[1], "EventHandler"))
+	}
+	return script
+}
+
+// csharpScripts caches the scanned C# scripts by file path
+var csharpScripts = make(map[string]*CSharpScript)
+var csharpScriptsMu sync.Mutex
+
+// readCSharpScript scans the C# script at resPath once, nil when it cannot be read
+func readCSharpScript(root, resPath string) *CSharpScript {
+	file := resToFS(root, resPath)
+	csharpScriptsMu.Lock()
+	defer csharpScriptsMu.Unlock()
+	if script, cached := csharpScripts[file]; cached {
+		return script
+	}
+	var script *CSharpScript
+	if content, err := os.ReadFile(file); err == nil {
+		script = parseCSharpScript(string(content), file)
+	}
+	csharpScripts[file] = script
+	return script
+}
@@ -0,0 +1,118 @@
+package gdquery
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const csharpPlayerScript = `using Godot;
+using System;
+
+// class NotThis : Node {}
+namespace Game;
+
+/* [Export] public int Commented = 1; */
+[GlobalClass]
+public partial class Player : Godot.CharacterBody2D
+{
+	[Signal]
+	public delegate void HealthChangedEventHandler(int health);
+
+	[Signal] public delegate void DiedEventHandler();
+
+	[Export]
+	public float Speed { get; set; } = 200.0f;
+
+	[Export(PropertyHint.Range, "0,10")] private int _lives = 3;
+
+	[Export]
+	[ExportGroup("Look")]
+	public string Title = "A // not a comment";
+
+	[Export] public NodePath Target { get; set; }
+
+	[ExportGroup("Other")]
+	public int NotExported = 1;
+
+	private class Helper : Node {}
+}
+`
+
+func TestParseCSharpScript(t *testing.T) {
+	script := parseCSharpScript(csharpPlayerScript, "res://chars/Player.cs")
+	if script.Class != "Player" || script.Base != "CharacterBody2D" || !script.Global {
+		t.Errorf("Unexpected class: %+v", *script)
+	}
+
+	expected := []ExportedVar{
+		{Name: "Speed", Type: "float", Default: "200.0f"},
+		{Name: "_lives", Type: "int", Default: "3"},
+		{Name: "Title", Type: "string", Default: `"A // not a comment"`},
+		{Name: "Target", Type: "NodePath"},
+	}
+	if len(script.Exports) != len(expected) {
+		t.Fatalf("Expected %d exports, got %d", len(expected), len(script.Exports))
+	}
+	for i, variable := range script.Exports {
+		if *variable != expected[i] {
+			t.Errorf("Unexpected export %d: %+v", i, *variable)
+		}
+	}
+	if got := strings.Join(script.Signals, " "); got != "HealthChanged Died" {
+		t.Errorf("Unexpected signals: %s", got)
+	}
+
+	// The class named after the file wins over the classes declared before it
+	helper := parseCSharpScript("class Data {}\npublic partial class Hud : Control {}\n", "Hud.cs")
+	if helper.Class != "Hud" || helper.Base != "Control" || helper.Global {
+		t.Errorf("Unexpected class: %+v", *helper)
+	}
+}
+
+func TestCSharpScriptProject(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"chars/Player.cs": csharpPlayerScript,
+		"chars/Boss.cs":   "public partial class Boss : Player {}\n",
+		"level.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://chars/Player.cs" id="1_p"]
+[ext_resource type="Script" path="res://chars/Boss.cs" id="2_b"]
+
+[node name="Level" type="Node2D"]
+
+[node name="Player" type="CharacterBody2D" parent="."]
+script = ExtResource("1_p")
+
+[node name="Boss" type="Button" parent="."]
+script = ExtResource("2_b")
+`,
+	})
+
+	scene, err := ParseTscnFile(filepath.Join(root, "level.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	resolveScriptClasses(scene)
+	var labels []string
+	for _, node := range scene.AllNodes {
+		labels = append(labels, node.Name+" ("+typeLabel(node)+")")
+	}
+	expected := "Level (Node2D), Player (Player: CharacterBody2D), Boss (Boss: Button)"
+	if got := strings.Join(labels, ", "); got != expected {
+		t.Errorf("Unexpected types: %s", got)
+	}
+	if nodes := findNodesOfType(scene.RootNode, "Player"); len(nodes) != 2 {
+		t.Errorf("Expected Player and Boss (extends the global class Player) for --type Player, got %d nodes", len(nodes))
+	}
+
+	findings := lintProjectDir(t, "script-node-type", root)
+	if len(findings) != 1 || findings[0].Message != "res://chars/Boss.cs extends CharacterBody2D but is attached to a Button node" {
+		t.Errorf("Unexpected findings: %+v", findings)
+	}
+
+	members := readScriptMembers(root, "res://chars/Player.cs")
+	if members == nil || strings.Join(members.Vars, " ") != "Speed _lives Title Target" || len(members.Signals) != 2 {
+		t.Errorf("Unexpected members: %+v", members)
+	}
+}
@@ -0,0 +1,356 @@
+package gdquery
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Curve command options
+var curveCSV = ""
+var curveSamples = 0
+
+// mainResourceID names the [resource] section of a .tres file in curve output
+const mainResourceID = "resource"
+
+// curveResourceTypes are the resource types the curves command summarizes
+var curveResourceTypes = map[string]bool{
+	"Curve": true, "Gradient": true, "CurveTexture": true,
+	"GradientTexture": true, "GradientTexture1D": true, "GradientTexture2D": true,
+}
+
+// gradientInterpolationModes are the names of Gradient.interpolation_mode values
+var gradientInterpolationModes = []string{"linear", "constant", "cubic"}
+
+// gradientFills are the names of GradientTexture2D.fill values
+var gradientFills = []string{"linear", "radial", "square"}
+
+// sectionTypeRe and sectionIDRe match the type and id attributes of a section header
+var sectionTypeRe = regexp.MustCompile(`\btype="([^"]*)"`)
+var sectionIDRe = regexp.MustCompile(`\bid=(?:"([^"]*)"|(\d+))`)
+
+// subResourceRefRe matches SubResource("id") and Godot 3 SubResource( 1 ) values
+var subResourceRefRe = regexp.MustCompile(`^SubResource\(\s*(?:"([^"]*)"|(\d+))\s*\)$`)
+
+// CurvePoint is a point of a Curve with its tangents
+type CurvePoint struct {
+	X, Y         float64
+	LeftTangent  float64
+	RightTangent float64
+}
+
+// Curve is a decoded Curve resource
+type Curve struct {
+	Points               []CurvePoint
+	MinDomain, MaxDomain float64
+	MinValue, MaxValue   float64
+}
+
+// GradientStop is a color stop of a Gradient
+type GradientStop struct {
+	Offset     float64
+	R, G, B, A float64
+}
+
+// Gradient is a decoded Gradient resource
+type Gradient struct {
+	Stops         []GradientStop
+	Interpolation string
+}
+
+// CurveResource is a Curve, Gradient or texture made from one, found in a scene or resource file
+type CurveResource struct {
+	ID      string
+	Type    string
+	Section *sceneSection
+}
+
+// findCurveResources returns the curve and gradient resources of a scene or resource file,
+// the [resource] section of a .tres file included under the id "resource"
+func findCurveResources(text *sceneText) []*CurveResource {
+	mainType := ""
+	var resources []*CurveResource
+	for _, section := range text.Sections {
+		resourceType := ""
+		if matches := sectionTypeRe.FindStringSubmatch(section.Header); matches != nil {
+			resourceType = matches[1]
+		}
+
+		switch {
+		case strings.HasPrefix(section.Header, "[gd_resource"):
+			mainType = resourceType
+		case strings.HasPrefix(section.Header, "[resource]"):
+			if curveResourceTypes[mainType] {
+				resources = append(resources, &CurveResource{ID: mainResourceID, Type: mainType, Section: section})
+			}
+		case strings.HasPrefix(section.Header, "[sub_resource"):
+			matches := sectionIDRe.FindStringSubmatch(section.Header)
+			if matches != nil && curveResourceTypes[resourceType] {
+				resources = append(resources, &CurveResource{ID: matches[1] + matches[2], Type: resourceType, Section: section})
+			}
+		}
+	}
+	return resources
+}
+
+// sectionFloat returns a number property of a section, or def when it is not set
+func sectionFloat(section *sceneSection, key string, def float64) float64 {
+	if value, exists := section.Property(key); exists {
+		if f, err := parseVariantFloat(value); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// decodeCurve decodes the _data of a Curve: per point a Vector2 position,
+// the left and right tangents and the left and right tangent modes
+func decodeCurve(section *sceneSection) *Curve {
+	curve := &Curve{
+		MinDomain: sectionFloat(section, "min_domain", 0),
+		MaxDomain: sectionFloat(section, "max_domain", 1),
+		MinValue:  sectionFloat(section, "min_value", 0),
+		MaxValue:  sectionFloat(section, "max_value", 1),
+	}
+
+	data, _ := section.Property("_data")
+	numbers := parseNumberList(strings.ReplaceAll(data, "Vector2", ""))
+	for i := 0; i+6 <= len(numbers); i += 6 {
+		curve.Points = append(curve.Points, CurvePoint{
+			X: numbers[i], Y: numbers[i+1], LeftTangent: numbers[i+2], RightTangent: numbers[i+3],
+		})
+	}
+	return curve
+}
+
+// Sample returns the value of the curve at x, interpolated like Curve.sample()
+// with a cubic Bezier built from the point tangents
+func (c *Curve) Sample(x float64) float64 {
+	if len(c.Points) == 0 {
+		return 0
+	}
+	if x <= c.Points[0].X {
+		return c.Points[0].Y
+	}
+	last := c.Points[len(c.Points)-1]
+	if x >= last.X {
+		return last.Y
+	}
+
+	i := 0
+	for i+1 < len(c.Points)-1 && c.Points[i+1].X <= x {
+		i++
+	}
+	a, b := c.Points[i], c.Points[i+1]
+	d := b.X - a.X
+	if d <= 1e-6 {
+		return b.Y
+	}
+	t := (x - a.X) / d
+	d /= 3
+	yac := a.Y + d*a.RightTangent
+	ybc := b.Y - d*b.LeftTangent
+
+	u := 1 - t
+	return u*u*u*a.Y + 3*u*u*t*yac + 3*u*t*t*ybc + t*t*t*b.Y
+}
+
+// decodeGradient decodes the offsets and colors of a Gradient; a Gradient
+// without them is Godot's default black to white
+func decodeGradient(section *sceneSection) *Gradient {
+	gradient := &Gradient{Interpolation: "linear"}
+	offsets := []float64{0, 1}
+	colors := []float64{0, 0, 0, 1, 1, 1, 1, 1}
+	if value, exists := section.Property("offsets"); exists {
+		offsets = parseNumberList(value)
+	}
+	if value, exists := section.Property("colors"); exists {
+		colors = parseNumberList(value)
+	}
+	for i, offset := range offsets {
+		if (i+1)*4 > len(colors) {
+			break
+		}
+		c := colors[i*4 : i*4+4]
+		gradient.Stops = append(gradient.Stops, GradientStop{Offset: offset, R: c[0], G: c[1], B: c[2], A: c[3]})
+	}
+
+	mode := int(sectionFloat(section, "interpolation_mode", 0))
+	if mode >= 0 && mode < len(gradientInterpolationModes) {
+		gradient.Interpolation = gradientInterpolationModes[mode]
+	}
+	return gradient
+}
+
+// formatColor formats a color as #rrggbb, or #rrggbbaa when it is not opaque
+func formatColor(r, g, b, a float64) string {
+	channel := func(f float64) int {
+		return int(math.Round(math.Max(0, math.Min(1, f)) * 255))
+	}
+	if a >= 1 {
+		return fmt.Sprintf("#%02x%02x%02x", channel(r), channel(g), channel(b))
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", channel(r), channel(g), channel(b), channel(a))
+}
+
+// resourceRefName returns the id of a SubResource value, or the raw value for other references
+func resourceRefName(value string) string {
+	value = strings.TrimSpace(value)
+	if matches := subResourceRefRe.FindStringSubmatch(value); matches != nil {
+		return matches[1] + matches[2]
+	}
+	return value
+}
+
+// summarize describes a curve resource in one line followed by its points or stops
+func (r *CurveResource) summarize() []string {
+	switch r.Type {
+	case "Curve":
+		curve := decodeCurve(r.Section)
+		lines := []string{fmt.Sprintf("%d point(s), domain %s..%s, range %s..%s", len(curve.Points),
+			formatLayoutNumber(curve.MinDomain), formatLayoutNumber(curve.MaxDomain),
+			formatLayoutNumber(curve.MinValue), formatLayoutNumber(curve.MaxValue))}
+		var points []string
+		for _, point := range curve.Points {
+			points = append(points, fmt.Sprintf("(%s, %s)", formatLayoutNumber(point.X), formatLayoutNumber(point.Y)))
+		}
+		if len(points) > 0 {
+			lines = append(lines, strings.Join(points, " "))
+		}
+		return lines
+
+	case "Gradient":
+		gradient := decodeGradient(r.Section)
+		lines := []string{fmt.Sprintf("%d stop(s), %s", len(gradient.Stops), gradient.Interpolation)}
+		var stops []string
+		for _, stop := range gradient.Stops {
+			stops = append(stops, fmt.Sprintf("%s %s", formatLayoutNumber(stop.Offset), formatColor(stop.R, stop.G, stop.B, stop.A)))
+		}
+		if len(stops) > 0 {
+			lines = append(lines, strings.Join(stops, ", "))
+		}
+		return lines
+
+	case "CurveTexture":
+		curve, _ := r.Section.Property("curve")
+		return []string{fmt.Sprintf("width %s, curve %s",
+			formatLayoutNumber(sectionFloat(r.Section, "width", 256)), resourceRefName(curve))}
+
+	case "GradientTexture2D":
+		gradient, _ := r.Section.Property("gradient")
+		fill := gradientFills[0]
+		if mode := int(sectionFloat(r.Section, "fill", 0)); mode >= 0 && mode < len(gradientFills) {
+			fill = gradientFills[mode]
+		}
+		return []string{fmt.Sprintf("%sx%s %s, gradient %s",
+			formatLayoutNumber(sectionFloat(r.Section, "width", 64)), formatLayoutNumber(sectionFloat(r.Section, "height", 64)),
+			fill, resourceRefName(gradient))}
+
+	default: // GradientTexture (Godot 3) and GradientTexture1D
+		gradient, _ := r.Section.Property("gradient")
+		defaultWidth := 256.0
+		if r.Type == "GradientTexture" {
+			defaultWidth = 2048
+		}
+		return []string{fmt.Sprintf("width %s, gradient %s",
+			formatLayoutNumber(sectionFloat(r.Section, "width", defaultWidth)), resourceRefName(gradient))}
+	}
+}
+
+// printCurveResources displays the summaries of the curve resources of a file
+func printCurveResources(out io.Writer, resources []*CurveResource) {
+	if len(resources) == 0 {
+		fmt.Fprintln(out, "No curves or gradients")
+		return
+	}
+	for _, resource := range resources {
+		lines := resource.summarize()
+		fmt.Fprintf(out, "%s %s: %s\n", resource.Type, resource.ID, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+}
+
+// writeCurveCSV writes the points of a curve (x, y and tangents), or samples
+// evenly spaced values over its domain when samples is at least 2
+func writeCurveCSV(out io.Writer, curve *Curve, samples int) error {
+	w := csv.NewWriter(out)
+	if samples >= 2 {
+		w.Write([]string{"x", "y"})
+		start, end := curve.MinDomain, curve.MaxDomain
+		for i := 0; i < samples; i++ {
+			x := start + (end-start)*float64(i)/float64(samples-1)
+			w.Write([]string{formatLayoutNumber(x), formatLayoutNumber(curve.Sample(x))})
+		}
+	} else {
+		w.Write([]string{"x", "y", "left_tangent", "right_tangent"})
+		for _, point := range curve.Points {
+			w.Write([]string{formatLayoutNumber(point.X), formatLayoutNumber(point.Y),
+				formatLayoutNumber(point.LeftTangent), formatLayoutNumber(point.RightTangent)})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+var curvesCmd = &cobra.Command{
+	Use:   "curves <scene or resource file> [more files...]",
+	Short: "Summarize embedded Curve and Gradient resources",
+	Long: `Decode the Curve, Gradient, CurveTexture and GradientTexture resources of scenes and .tres files
+and print their point count, domain and range, color stops and texture sizes.
+With --csv, the points of one curve are written as CSV for plotting; the main resource
+of a .tres file has the id "resource".`,
+	Example: `  gdq curves effects/explosion.tscn
+  gdq curves --csv Curve_3xk2p --samples 100 effects/explosion.tscn > falloff.csv`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if curveCSV != "" && len(args) != 1 {
+			return fmt.Errorf("--csv takes exactly one file")
+		}
+
+		for i, file := range args {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("read error: %v", err)
+			}
+			resources := findCurveResources(splitSceneText(string(content)))
+
+			if curveCSV != "" {
+				for _, resource := range resources {
+					if resource.ID != curveCSV {
+						continue
+					}
+					if resource.Type != "Curve" {
+						return fmt.Errorf("%s is a %s, not a Curve", curveCSV, resource.Type)
+					}
+					return writeCurveCSV(out, decodeCurve(resource.Section), curveSamples)
+				}
+				return fmt.Errorf("curve not found: %s", curveCSV)
+			}
+
+			if len(args) > 1 {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				fmt.Fprintf(out, "=== %s ===\n", file)
+			}
+			printCurveResources(out, resources)
+		}
+		return nil
+	},
+}
+
+func init() {
+	curvesCmd.Flags().StringVar(&curveCSV, "csv", "", "Write the points of the Curve with this id as CSV")
+	curvesCmd.Flags().IntVar(&curveSamples, "samples", 0, "With --csv, sample the curve at this many evenly spaced points instead")
+	rootCmd.AddCommand(curvesCmd)
+}
@@ -0,0 +1,109 @@
+package gdquery
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const curvesScene = `[gd_scene load_steps=4 format=3]
+
+[sub_resource type="Curve" id="Curve_fade"]
+max_value = 2.0
+_data = [Vector2(0, 0), 0.0, 2.0, 0, 0, Vector2(0.5, 1), 0.0, 0.0, 0, 0, Vector2(1, 2), 0.0, 0.0, 0, 0]
+point_count = 3
+
+[sub_resource type="Gradient" id="Gradient_fire"]
+offsets = PackedFloat32Array(0, 0.5, 1)
+colors = PackedColorArray(1, 0, 0, 1, 1, 0.5, 0, 1, 1, 1, 1, 0)
+
+[sub_resource type="GradientTexture2D" id="GradientTexture2D_glow"]
+gradient = SubResource("Gradient_fire")
+fill = 1
+width = 128
+
+[node name="Effect" type="Node2D"]
+`
+
+func TestCurveResources(t *testing.T) {
+	resources := findCurveResources(splitSceneText(curvesScene))
+	if len(resources) != 3 {
+		t.Fatalf("Expected 3 curve resources, got %d", len(resources))
+	}
+
+	summaries := make(map[string]string)
+	for _, resource := range resources {
+		summaries[resource.ID] = strings.Join(resource.summarize(), "\n")
+	}
+	expected := map[string]string{
+		"Curve_fade":             "3 point(s), domain 0..1, range 0..2\n(0, 0) (0.5, 1) (1, 2)",
+		"Gradient_fire":          "3 stop(s), linear\n0 #ff0000, 0.5 #ff8000, 1 #ffffff00",
+		"GradientTexture2D_glow": "128x64 radial, gradient Gradient_fire",
+	}
+	for id, want := range expected {
+		if summaries[id] != want {
+			t.Errorf("%s: expected %q, got %q", id, want, summaries[id])
+		}
+	}
+
+	curve := decodeCurve(resources[0].Section)
+	if got := curve.Sample(0.75); math.Abs(got-1.5) > 1e-9 {
+		t.Errorf("Expected a linear segment to sample 1.5 at 0.75, got %v", got)
+	}
+	if got := curve.Sample(0.25); got <= 0.5 {
+		t.Errorf("Expected the right tangent of the first point to lift the curve above 0.5 at 0.25, got %v", got)
+	}
+	if curve.Sample(-1) != 0 || curve.Sample(2) != 2 {
+		t.Errorf("Expected samples outside the points to clamp to the end points")
+	}
+
+	var b strings.Builder
+	if err := writeCurveCSV(&b, curve, 0); err != nil {
+		t.Fatalf("CSV error: %v", err)
+	}
+	if want := "x,y,left_tangent,right_tangent\n0,0,0,2\n0.5,1,0,0\n1,2,0,0\n"; b.String() != want {
+		t.Errorf("Expected CSV %q, got %q", want, b.String())
+	}
+
+	b.Reset()
+	if err := writeCurveCSV(&b, curve, 3); err != nil {
+		t.Fatalf("CSV error: %v", err)
+	}
+	if want := "x,y\n0,0\n0.5,1\n1,2\n"; b.String() != want {
+		t.Errorf("Expected sampled CSV %q, got %q", want, b.String())
+	}
+}
+
+func TestCurveResourceFiles(t *testing.T) {
+	// The main resource of a .tres file, and Godot 3 defaults and syntax
+	text := splitSceneText(`[gd_resource type="Gradient" format=2]
+
+[resource]
+`)
+	resources := findCurveResources(text)
+	if len(resources) != 1 || resources[0].ID != mainResourceID {
+		t.Fatalf("Expected the main Gradient resource, got %+v", resources)
+	}
+	if got := resources[0].summarize(); got[1] != "0 #000000, 1 #ffffff" {
+		t.Errorf("Expected the default black to white gradient, got %q", got[1])
+	}
+
+	text = splitSceneText(`[gd_scene load_steps=2 format=2]
+
+[sub_resource type="Curve" id=1]
+_data = [ Vector2( 0, 1 ), 0.0, 0.0, 0, 0, Vector2( 1, 0 ), 0.0, 0.0, 0, 0 ]
+
+[sub_resource type="CurveTexture" id=2]
+curve = SubResource( 1 )
+`)
+	resources = findCurveResources(text)
+	if len(resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %d", len(resources))
+	}
+	if got := resources[0].summarize(); got[1] != "(0, 1) (1, 0)" {
+		t.Errorf("Unexpected Godot 3 curve points: %q", got[1])
+	}
+	if got := resources[1].summarize(); got[0] != "width 256, curve 1" {
+		t.Errorf("Unexpected CurveTexture summary: %q", got[0])
+	}
+}
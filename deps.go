@@ -0,0 +1,495 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Dependency command options
+var depsCyclesOnly = false
+var depsList = false
+
+// scriptExtensions lists script files scanned for preload/load calls
+var scriptExtensions = []string{".gd"}
+
+// scriptLoadRe matches preload("res://...") and load("res://...") calls in GDScript
+var scriptLoadRe = regexp.MustCompile(`\b(preload|load)\(\s*"([^"]+)"\s*\)`)
+
+// DependencyEdge represents a reference from one project file to another
+type DependencyEdge struct {
+	From string
+	To   string
+	Kind string // ext_resource, instance, preload or load
+}
+
+// DependencyGraph holds the res:// dependencies between project files
+type DependencyGraph struct {
+	Root  string
+	Files []string
+	Edges map[string][]DependencyEdge
+	// UIDs maps the uid:// of scenes to their res:// path
+	UIDs map[string]string
+	// Imports describes the imported 3D scenes (.glb, .obj, ...) files depend on
+	Imports map[string]*ImportedAsset
+}
+
+// addEdge records a dependency, ignoring duplicates
+func (g *DependencyGraph) addEdge(from, to, kind string) {
+	for _, edge := range g.Edges[from] {
+		if edge.To == to && edge.Kind == kind {
+			return
+		}
+	}
+	g.Edges[from] = append(g.Edges[from], DependencyEdge{From: from, To: to, Kind: kind})
+}
+
+// buildDependencyGraph scans all scenes, resources and scripts under root
+func buildDependencyGraph(root string) (*DependencyGraph, error) {
+	graph := &DependencyGraph{
+		Root:  root,
+		Edges: make(map[string][]DependencyEdge),
+		UIDs:  make(map[string]string),
+	}
+
+	exts := append(append([]string{}, sceneExtensions...), scriptExtensions...)
+	files, err := findProjectFiles(root, exts)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := newProgress("Scanning", len(files))
+	defer progress.Clear()
+	for _, file := range files {
+		progress.Step()
+		resPath := fsToRes(root, file)
+		graph.Files = append(graph.Files, resPath)
+
+		if hasExtension(file, scriptExtensions) {
+			if err := addScriptDependencies(graph, file, resPath); err != nil {
+				logger.Warn("Skipping script", "path", file, "error", err)
+			}
+			continue
+		}
+
+		// Dependencies come from the ext_resources and instances only
+		scene, err := ParseFile(file, ParseOptions{SkipProperties: true, Logger: logger})
+		if err != nil {
+			logger.Warn("Skipping file", "path", file, "error", err)
+			continue
+		}
+		if scene.UID != "" {
+			graph.UIDs[scene.UID] = resPath
+		}
+		addSceneDependencies(graph, scene, resPath)
+	}
+	graph.resolveUIDs()
+	graph.addImportedAssets()
+
+	for from := range graph.Edges {
+		sort.Slice(graph.Edges[from], func(i, j int) bool {
+			return graph.Edges[from][i].To < graph.Edges[from][j].To
+		})
+	}
+
+	return graph, nil
+}
+
+// addSceneDependencies adds the ext_resources of a parsed scene to the graph,
+// in file order (the order Godot loads them). ext_resources without a path
+// depend on their uid://, see resolveUIDs.
+func addSceneDependencies(graph *DependencyGraph, scene *GodotScene, resPath string) {
+	instanced := make(map[string]bool)
+	for _, node := range scene.AllNodes {
+		if matches := resourceRefRe.FindStringSubmatch(node.Instance); matches != nil {
+			instanced[matches[2]] = true
+		}
+	}
+
+	resources := make([]*GodotResource, 0, len(scene.ExtResources))
+	for _, resource := range scene.ExtResources {
+		resources = append(resources, resource)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Span.StartLine < resources[j].Span.StartLine
+	})
+	for _, resource := range resources {
+		id := resource.ID
+		target := resource.Path
+		if target == "" {
+			target = resource.UID
+		}
+		if target == "" {
+			continue
+		}
+		kind := "ext_resource"
+		if instanced[id] {
+			kind = "instance"
+		}
+		graph.addEdge(resPath, normalizeResPath(resPath, target), kind)
+	}
+}
+
+// resolveUIDs replaces uid:// dependencies by the res:// path of the scene
+// declaring the uid in its header, once every scene has been scanned
+func (g *DependencyGraph) resolveUIDs() {
+	for from, edges := range g.Edges {
+		g.Edges[from] = nil
+		for _, edge := range edges {
+			to := edge.To
+			if path, exists := g.UIDs[to]; exists {
+				to = path
+			}
+			g.addEdge(from, to, edge.Kind)
+		}
+	}
+}
+
+// addScriptDependencies adds preload/load targets of a script to the graph
+func addScriptDependencies(graph *DependencyGraph, file, resPath string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		// Ignore commented out code
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, matches := range scriptLoadRe.FindAllStringSubmatch(line, -1) {
+			graph.addEdge(resPath, normalizeResPath(resPath, matches[2]), matches[1])
+		}
+	}
+
+	return nil
+}
+
+// findDependencyCycles returns one cycle path (first element repeated at the end)
+// for every strongly connected component that contains a cycle
+func findDependencyCycles(graph *DependencyGraph) [][]string {
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var strongConnect func(v string)
+	strongConnect = func(v string) {
+		indices[v] = index
+		lowlink[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, edge := range graph.Edges[v] {
+			if _, visited := indices[edge.To]; !visited {
+				strongConnect(edge.To)
+				lowlink[v] = min(lowlink[v], lowlink[edge.To])
+			} else if onStack[edge.To] {
+				lowlink[v] = min(lowlink[v], indices[edge.To])
+			}
+		}
+
+		if lowlink[v] == indices[v] {
+			var component []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, file := range graph.Files {
+		if _, visited := indices[file]; !visited {
+			strongConnect(file)
+		}
+	}
+
+	var cycles [][]string
+	for _, component := range components {
+		sort.Strings(component)
+		start := component[0]
+		if len(component) == 1 && !graph.hasEdge(start, start) {
+			continue
+		}
+		members := make(map[string]bool)
+		for _, file := range component {
+			members[file] = true
+		}
+		if cycle := shortestCycle(graph, start, members); cycle != nil {
+			cycles = append(cycles, cycle)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+
+	return cycles
+}
+
+// hasEdge reports whether from depends directly on to
+func (g *DependencyGraph) hasEdge(from, to string) bool {
+	for _, edge := range g.Edges[from] {
+		if edge.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// shortestCycle finds the shortest path from start back to itself within members
+func shortestCycle(graph *DependencyGraph, start string, members map[string]bool) []string {
+	prev := make(map[string]string)
+	queue := []string{start}
+	visited := map[string]bool{}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range graph.Edges[current] {
+			if !members[edge.To] {
+				continue
+			}
+			if edge.To == start {
+				cycle := []string{start}
+				for node := current; node != start; node = prev[node] {
+					cycle = append([]string{node}, cycle...)
+				}
+				return append([]string{start}, cycle...)
+			}
+			if !visited[edge.To] {
+				visited[edge.To] = true
+				prev[edge.To] = current
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	return nil
+}
+
+// printDependencyGraph displays the dependencies of every scanned file
+func printDependencyGraph(out io.Writer, graph *DependencyGraph) {
+	for _, file := range graph.Files {
+		edges := graph.Edges[file]
+		if len(edges) == 0 {
+			continue
+		}
+		fmt.Fprintln(out, file)
+		for _, edge := range edges {
+			if asset := graph.Imports[edge.To]; asset != nil {
+				fmt.Fprintf(out, "  -> %s (%s; %s)\n", edge.To, edge.Kind, asset)
+			} else {
+				fmt.Fprintf(out, "  -> %s (%s)\n", edge.To, edge.Kind)
+			}
+		}
+	}
+}
+
+// printDependencyCycles displays the detected cycles
+func printDependencyCycles(out io.Writer, cycles [][]string) {
+	fmt.Fprintln(out, "=== Dependency Cycles ===")
+	for _, cycle := range cycles {
+		fmt.Fprintln(out, strings.Join(cycle, " -> "))
+	}
+}
+
+// ExtDependency is an ext_resource of a scene with its usage within the scene
+type ExtDependency struct {
+	Resource *GodotResource
+	ResPath  string
+	RefCount int
+	Missing  bool
+	Import   *ImportedAsset // imported 3D scenes only
+}
+
+// listExtDependencies returns the ext_resources of a scene file with reference
+// counts and whether the referenced file exists in the project
+func listExtDependencies(file string) ([]*ExtDependency, error) {
+	scene, err := ParseFile(file, ParseOptions{Logger: logger})
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Count ExtResource("id") references anywhere in the file
+	refCounts := make(map[string]int)
+	refRe := regexp.MustCompile(`ExtResource\(\s*"?([^")\s]*)"?\s*\)`)
+	for _, matches := range refRe.FindAllStringSubmatch(string(content), -1) {
+		refCounts[matches[1]]++
+	}
+
+	root := findProjectRoot(file)
+	fromRes := fsToRes(root, file)
+
+	var deps []*ExtDependency
+	for id, resource := range scene.ExtResources {
+		dep := &ExtDependency{Resource: resource, RefCount: refCounts[id]}
+		if resource.Path != "" {
+			dep.ResPath = normalizeResPath(fromRes, resource.Path)
+			if _, err := os.Stat(resToFS(root, dep.ResPath)); err != nil {
+				dep.Missing = true
+			}
+			if hasExtension(dep.ResPath, importedSceneExtensions) {
+				dep.Import = readImportedAsset(root, dep.ResPath)
+			}
+		} else {
+			dep.ResPath = resource.UID
+		}
+		deps = append(deps, dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Resource.Type != deps[j].Resource.Type {
+			return deps[i].Resource.Type < deps[j].Resource.Type
+		}
+		return deps[i].ResPath < deps[j].ResPath
+	})
+
+	return deps, nil
+}
+
+// printExtDependencies displays ext_resources grouped by type
+func printExtDependencies(out io.Writer, deps []*ExtDependency) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	currentType := ""
+	for i, dep := range deps {
+		if i == 0 || dep.Resource.Type != currentType {
+			currentType = dep.Resource.Type
+			count := 0
+			for _, d := range deps {
+				if d.Resource.Type == currentType {
+					count++
+				}
+			}
+			fmt.Fprintf(w, "%s (%d)\n", currentType, count)
+		}
+
+		marker := ""
+		if dep.Missing {
+			marker = "MISSING"
+		}
+		if dep.Import != nil {
+			if dep.Import.NotImported {
+				marker = strings.TrimSpace(marker + " NOT IMPORTED")
+			} else {
+				marker = strings.TrimSpace(marker + " " + dep.Import.Importer + " importer")
+			}
+		}
+		fmt.Fprintf(w, "  %s\t%s\trefs: %d\t%s\n", dep.Resource.ID, dep.ResPath, dep.RefCount, marker)
+	}
+	w.Flush()
+}
+
+var depsCmd = &cobra.Command{
+	Use:   "deps [project dir | --list tscn files...]",
+	Short: "Display the project dependency graph",
+	Long: `Scan scenes, resources and scripts and display the res:// dependency graph. Exits non-zero when dependency cycles are found.
+
+With -o dot or -o graphml, write the graph in Graphviz DOT or GraphML (Gephi, yEd) format;
+the cycles are then reported on stderr.
+
+With --list, display the ext_resources of the given scenes grouped by type, with reference
+counts and a MISSING marker for files that do not exist. Imported 3D scenes (.gltf, .glb,
+.fbx, .blend, .dae, .obj) show the importer of their .import file, or NOT IMPORTED without one.`,
+	Args:         cobra.ArbitraryArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if depsList {
+			if len(args) == 0 {
+				return fmt.Errorf("--list requires at least one scene file")
+			}
+			for i, file := range args {
+				if _, err := os.Stat(file); os.IsNotExist(err) {
+					return fmt.Errorf("file not found: %s", file)
+				}
+				deps, err := listExtDependencies(file)
+				if err != nil {
+					return fmt.Errorf("parse error: %v", err)
+				}
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				fmt.Fprintf(out, "=== %s ===\n", file)
+				printExtDependencies(out, deps)
+			}
+			return nil
+		}
+
+		if err := validateOutputFormat("text", "dot", "graphml"); err != nil {
+			return err
+		}
+
+		if len(args) > 1 {
+			return fmt.Errorf("expected a single project directory")
+		}
+
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		graph, err := buildDependencyGraph(findProjectRoot(dir))
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+
+		cycles := findDependencyCycles(graph)
+		if outputFormat != "text" {
+			if err := writeExportGraphs(out, dependencyExportGraph(graph)); err != nil {
+				return err
+			}
+			// The cycles go to stderr to keep the graph output valid
+			if len(cycles) > 0 {
+				printDependencyCycles(cmd.ErrOrStderr(), cycles)
+				return fmt.Errorf("found %d dependency cycle(s)", len(cycles))
+			}
+			return nil
+		}
+
+		if !depsCyclesOnly {
+			printDependencyGraph(out, graph)
+		}
+
+		if len(cycles) > 0 {
+			if !depsCyclesOnly {
+				fmt.Fprintln(out)
+			}
+			printDependencyCycles(out, cycles)
+			return fmt.Errorf("found %d dependency cycle(s)", len(cycles))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	depsCmd.Flags().BoolVar(&depsCyclesOnly, "cycles", false, "Only report dependency cycles")
+	depsCmd.Flags().BoolVar(&depsList, "list", false, "List the ext_resources of the given scenes grouped by type")
+	rootCmd.AddCommand(depsCmd)
+}
@@ -0,0 +1,315 @@
+package gdquery
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProjectFiles creates a temporary Godot project with the given files
+func writeProjectFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	if _, exists := files["project.godot"]; !exists {
+		files["project.godot"] = "config_version=5\n"
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	return root
+}
+
+func TestDependencyCycles(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://player/player.tscn" id="1_a"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Player" parent="." instance=ExtResource("1_a")]
+`,
+		"player/player.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://player/player.gd" id="1_b"]
+
+[node name="Player" type="CharacterBody2D"]
+script = ExtResource("1_b")
+`,
+		"player/player.gd": `extends CharacterBody2D
+
+const Main = preload("res://main.tscn")
+# var unused = load("res://unused.tscn")
+`,
+		"hud.tscn": `[gd_scene format=2]
+
+[ext_resource path="player/player.tscn" type="PackedScene" id=1]
+
+[node name="HUD" type="Control"]
+
+[node name="Player" parent="." instance=ExtResource( 1 )]
+`,
+	})
+
+	graph, err := buildDependencyGraph(root)
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+
+	if !graph.hasEdge("res://main.tscn", "res://player/player.tscn") {
+		t.Error("main.tscn should depend on player.tscn")
+	}
+	if !graph.hasEdge("res://hud.tscn", "res://player/player.tscn") {
+		t.Error("hud.tscn should resolve its relative Godot 3 path")
+	}
+	if graph.hasEdge("res://player/player.gd", "res://unused.tscn") {
+		t.Error("Commented out load() should be ignored")
+	}
+	if edges := graph.Edges["res://main.tscn"]; len(edges) != 1 || edges[0].Kind != "instance" {
+		t.Errorf("Expected one instance edge from main.tscn, got: %v", edges)
+	}
+
+	cycles := findDependencyCycles(graph)
+	if len(cycles) != 1 {
+		t.Fatalf("Expected 1 cycle, got: %d (%v)", len(cycles), cycles)
+	}
+
+	expected := "res://main.tscn -> res://player/player.tscn -> res://player/player.gd -> res://main.tscn"
+	if got := strings.Join(cycles[0], " -> "); got != expected {
+		t.Errorf("Cycle path is wrong (expected: %s, got: %s)", expected, got)
+	}
+
+	// Graph formats keep stdout valid and still exit non-zero on cycles
+	for _, format := range []string{"dot", "graphml"} {
+		var stdout, stderr strings.Builder
+		if code := Run([]string{"deps", "-o", format, root}, &stdout, &stderr); code == 0 {
+			t.Errorf("%s: expected a failure for the dependency cycle", format)
+		}
+		if !strings.Contains(stderr.String(), expected) || strings.Contains(stdout.String(), expected) {
+			t.Errorf("%s: expected the cycle on stderr:\nstdout: %s\nstderr: %s", format, stdout.String(), stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "res://player/player.tscn") {
+			t.Errorf("%s: expected the graph on stdout:\n%s", format, stdout.String())
+		}
+	}
+}
+
+func TestEscnDependencies(t *testing.T) {
+	// Blender exporter output: numeric IDs, paths relative to the .escn file,
+	// and type before name in node headers
+	root := writeProjectFiles(t, map[string]string{
+		"models/tree.escn": `[gd_scene load_steps=2 format=2]
+
+[ext_resource id=1 path="textures/bark.png" type="Texture"]
+
+[sub_resource id=1 type="SpatialMaterial"]
+albedo_texture = ExtResource(1)
+
+[node type="Spatial" name="Scene"]
+
+[node type="MeshInstance" name="Trunk" parent="."]
+transform = Transform(1.0, 0.0, 0.0, 0.0, 1.0, 0.0, 0.0, 0.0, 1.0, 0.0, 0.0, 0.0)
+`,
+		"models/textures/bark.png": "",
+		"level.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://models/tree.escn" id="1_t"]
+
+[node name="Level" type="Node3D"]
+
+[node name="Tree" parent="." instance=ExtResource("1_t")]
+`,
+	})
+
+	scene, err := ParseTscnFile(filepath.Join(root, "models", "tree.escn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if scene.RootNode == nil || scene.RootNode.OriginalName != "Scene" || len(scene.RootNode.Children) != 1 {
+		t.Fatalf("escn tree parsed incorrectly")
+	}
+	if scene.ExtResources["1"] == nil || scene.SubResources["1"] == nil {
+		t.Errorf("Numeric resource IDs not parsed: %v %v", scene.ExtResources, scene.SubResources)
+	}
+
+	graph, err := buildDependencyGraph(root)
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	if !graph.hasEdge("res://level.tscn", "res://models/tree.escn") {
+		t.Error("level.tscn should depend on tree.escn")
+	}
+	if !graph.hasEdge("res://models/tree.escn", "res://models/textures/bark.png") {
+		t.Error("tree.escn should resolve its relative texture path")
+	}
+}
+
+func TestListExtDependencies(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Script" path="res://main.gd" id="1_s"]
+[ext_resource type="Texture2D" path="res://icon.png" id="2_t"]
+[ext_resource type="Texture2D" path="res://gone.png" id="3_t"]
+
+[sub_resource type="AtlasTexture" id="AtlasTexture_a"]
+atlas = ExtResource("2_t")
+
+[node name="Main" type="Node2D"]
+script = ExtResource("1_s")
+
+[node name="Icon" type="Sprite2D" parent="."]
+texture = ExtResource("2_t")
+`,
+		"main.gd":  "extends Node2D\n",
+		"icon.png": "",
+	})
+
+	deps, err := listExtDependencies(filepath.Join(root, "main.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	if len(deps) != 3 {
+		t.Fatalf("Expected 3 dependencies, got: %d", len(deps))
+	}
+
+	// Sorted by type, then path
+	expected := []struct {
+		path    string
+		refs    int
+		missing bool
+	}{
+		{"res://main.gd", 1, false},
+		{"res://gone.png", 0, true},
+		{"res://icon.png", 2, false},
+	}
+	for i, e := range expected {
+		if deps[i].ResPath != e.path || deps[i].RefCount != e.refs || deps[i].Missing != e.missing {
+			t.Errorf("Dependency %d is wrong (expected: %+v, got: %s refs=%d missing=%v)",
+				i, e, deps[i].ResPath, deps[i].RefCount, deps[i].Missing)
+		}
+	}
+}
+
+func TestSceneUIDDependencies(t *testing.T) {
+	// Godot 4.3+ headers carry the scene uid; an ext_resource may reference only the uid
+	root := writeProjectFiles(t, map[string]string{
+		"actors/player.tscn": "[gd_scene format=3 uid=\"uid://bplayer\"]\n\n[node name=\"Player\" type=\"CharacterBody2D\"]\n",
+		"main.tscn": `[gd_scene load_steps=2 format=3 uid="uid://bmain"]
+
+[ext_resource type="PackedScene" uid="uid://bplayer" id="1_p"]
+[ext_resource type="Texture2D" uid="uid://bunknown" id="2_t"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Player" parent="." instance=ExtResource("1_p")]
+`,
+	})
+
+	scene, err := ParseTscnFile(filepath.Join(root, "main.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if scene.UID != "uid://bmain" || sceneToJSON(scene, nil).UID != "uid://bmain" {
+		t.Errorf("Unexpected scene uid: %q", scene.UID)
+	}
+	if output := captureOutput(func(out io.Writer) { printSceneStats(out, scene) }); !strings.Contains(output, "UID: uid://bmain\n") {
+		t.Errorf("Expected the uid in the stats:\n%s", output)
+	}
+
+	graph, err := buildDependencyGraph(root)
+	if err != nil {
+		t.Fatalf("Graph error: %v", err)
+	}
+	var edges []string
+	for _, edge := range graph.Edges["res://main.tscn"] {
+		edges = append(edges, edge.Kind+" "+edge.To)
+	}
+	if got := strings.Join(edges, ", "); got != "instance res://actors/player.tscn, ext_resource uid://bunknown" {
+		t.Errorf("Unexpected edges: %s", got)
+	}
+}
+
+func TestImportedSceneDependencies(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"level.tscn": `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="PackedScene" path="res://models/tree.glb" id="1_t"]
+[ext_resource type="PackedScene" path="res://models/rock.gltf" id="2_r"]
+[ext_resource type="Mesh" path="res://models/gone.obj" id="3_g"]
+
+[node name="Level" type="Node3D"]
+
+[node name="Tree" parent="." instance=ExtResource("1_t")]
+
+[node name="Rock" parent="." instance=ExtResource("2_r")]
+
+[node name="Gone" type="MeshInstance3D" parent="."]
+mesh = ExtResource("3_g")
+`,
+		"models/tree.glb": "glTF",
+		"models/tree.glb.import": `[remap]
+
+importer="scene"
+importer_version=1
+type="PackedScene"
+path="res://.godot/imported/tree.glb-0123.scn"
+
+[deps]
+
+source_file="res://models/tree.glb"
+`,
+		"models/rock.gltf": "{}",
+	})
+
+	graph, err := buildDependencyGraph(root)
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	tree := graph.Imports["res://models/tree.glb"]
+	if tree == nil || tree.Importer != "scene" || tree.Type != "PackedScene" || tree.Imported != "res://.godot/imported/tree.glb-0123.scn" || tree.SourceMissing {
+		t.Errorf("Unexpected import of tree.glb: %+v", tree)
+	}
+	if rock := graph.Imports["res://models/rock.gltf"]; rock == nil || !rock.NotImported || rock.SourceMissing {
+		t.Errorf("rock.gltf should not be imported yet: %+v", rock)
+	}
+	if gone := graph.Imports["res://models/gone.obj"]; gone == nil || !gone.SourceMissing {
+		t.Errorf("gone.obj should be missing: %+v", gone)
+	}
+
+	var dot strings.Builder
+	if err := writeDOT(&dot, dependencyExportGraph(graph)); err != nil {
+		t.Fatalf("Export error: %v", err)
+	}
+	if !strings.Contains(dot.String(), `importer="scene"`) {
+		t.Errorf("Expected the importer in the DOT export:\n%s", dot.String())
+	}
+
+	deps, err := listExtDependencies(filepath.Join(root, "level.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	for _, dep := range deps {
+		if dep.Import == nil {
+			t.Errorf("%s should be listed as an imported scene", dep.ResPath)
+		}
+	}
+
+	findings := lintProjectDir(t, "imported-scene-source", root)
+	if len(findings) != 2 || findings[0].Severity != severityError || !strings.Contains(findings[0].Message, "gone.obj") ||
+		findings[1].Severity != severityWarning || !strings.Contains(findings[1].Message, "rock.gltf") {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+}
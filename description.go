@@ -0,0 +1,33 @@
+package gdquery
+
+import (
+	"regexp"
+	"strings"
+)
+
+// godot3DescriptionRe matches the editor description Godot 3 stores in the __meta__ dictionary
+var godot3DescriptionRe = regexp.MustCompile(`"_editor_description_"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+
+// nodeDescription returns the editor description of a node: editor_description
+// in Godot 4, __meta__["_editor_description_"] in Godot 3
+func nodeDescription(node *GodotNode) string {
+	if value, exists := node.Properties["editor_description"]; exists {
+		return unquoteValue(value)
+	}
+	if matches := godot3DescriptionRe.FindStringSubmatch(node.Properties["__meta__"]); matches != nil {
+		return unquoteValue("\"" + matches[1] + "\"")
+	}
+	return ""
+}
+
+// descriptionAnnotation formats the first line of a node description for the tree
+func descriptionAnnotation(node *GodotNode) string {
+	description := strings.TrimSpace(nodeDescription(node))
+	if description == "" {
+		return ""
+	}
+	if first, _, multiline := strings.Cut(description, "\n"); multiline {
+		description = strings.TrimSpace(first) + " ..."
+	}
+	return "  # " + description
+}
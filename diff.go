@@ -0,0 +1,251 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// Scene change operations
+const (
+	opAddNode          = "add-node"
+	opRemoveNode       = "remove-node"
+	opSetType          = "set-type"
+	opSetProperty      = "set-property"
+	opRemoveProperty   = "remove-property"
+	opAddConnection    = "add-connection"
+	opRemoveConnection = "remove-connection"
+)
+
+// SceneChange is one change between two versions of a scene. Nodes are
+// identified by their path below the scene root ("." for the root), so that
+// renaming the root is not a change. Applying the changes in order turns the
+// old scene into the new one: a removed node takes its descendants and their
+// connections with it, and an added node is followed by the properties set on it.
+type SceneChange struct {
+	Op         string            `json:"op"`
+	Path       string            `json:"path,omitempty"`
+	Type       string            `json:"type,omitempty"`     // type of an added node
+	Instance   string            `json:"instance,omitempty"` // instanced scene of an added node
+	Property   string            `json:"property,omitempty"`
+	Old        *string           `json:"old,omitempty"` // raw values; a type for set-type
+	New        *string           `json:"new,omitempty"`
+	Connection *ConnectionChange `json:"connection,omitempty"`
+}
+
+// ConnectionChange is the connection added or removed by a SceneChange
+type ConnectionChange struct {
+	Signal string `json:"signal"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Method string `json:"method"`
+	Flags  int    `json:"flags,omitempty"`
+	Binds  string `json:"binds,omitempty"`
+}
+
+// newConnectionChange copies a connection of a scene
+func newConnectionChange(c *GodotConnection) *ConnectionChange {
+	return &ConnectionChange{Signal: c.Signal, From: c.From, To: c.To, Method: c.Method, Flags: c.Flags, Binds: c.Binds}
+}
+
+// diffNodes indexes the nodes of a scene by the path their section header
+// gives them, the identity SceneEditor and patch use. The first node of a
+// path wins, so that second roots do not replace the root.
+func diffNodes(scene *GodotScene) map[string]*GodotNode {
+	nodes := make(map[string]*GodotNode)
+	for _, node := range scene.AllNodes {
+		if path := headerNodePath(node); nodes[path] == nil {
+			nodes[path] = node
+		}
+	}
+	return nodes
+}
+
+// nodePropertyValues returns the properties of a node as written in the file
+// when the scene was parsed with KeepRawLines, and as parsed otherwise
+func nodePropertyValues(node *GodotNode) map[string]string {
+	if len(node.RawLines) == 0 {
+		return node.Properties
+	}
+	_, values := (&sceneSection{Lines: node.RawLines[1:]}).PropertyValues()
+	return values
+}
+
+// connectionKey identifies a connection by its signal, nodes and method
+func connectionKey(c *GodotConnection) string {
+	return c.Signal + "\x00" + c.From + "\x00" + c.To + "\x00" + c.Method
+}
+
+// diffScenes lists the changes from the old to the new version of a scene:
+// removed nodes (the topmost of each removed subtree), then the added and
+// changed nodes in the tree order of the new scene, then the connections.
+// Values are raw when the scenes are parsed with KeepRawLines.
+func diffScenes(oldScene, newScene *GodotScene) []*SceneChange {
+	var changes []*SceneChange
+	if oldScene.RootNode == nil || newScene.RootNode == nil {
+		return changes
+	}
+	oldNodes, newNodes := diffNodes(oldScene), diffNodes(newScene)
+
+	var removed []string
+	oldScene.Walk(func(node *GodotNode, depth int) WalkAction {
+		path := headerNodePath(node)
+		if _, exists := newNodes[path]; exists {
+			return WalkContinue
+		}
+		changes = append(changes, &SceneChange{Op: opRemoveNode, Path: path})
+		removed = append(removed, path)
+		return WalkSkipChildren
+	})
+	isRemoved := func(path string) bool {
+		for _, removedPath := range removed {
+			if inSubtree(path, removedPath) {
+				return true
+			}
+		}
+		return false
+	}
+
+	newScene.Walk(func(node *GodotNode, depth int) WalkAction {
+		path := headerNodePath(node)
+		oldNode, exists := oldNodes[path]
+		if !exists {
+			changes = append(changes, &SceneChange{Op: opAddNode, Path: path, Type: node.Type, Instance: resolveResourcePath(node.Instance, newScene)})
+		} else if oldNode.Type != node.Type {
+			oldType, newType := oldNode.Type, node.Type
+			changes = append(changes, &SceneChange{Op: opSetType, Path: path, Old: &oldType, New: &newType})
+		}
+
+		oldValues, newValues := map[string]string{}, nodePropertyValues(node)
+		if exists {
+			oldValues = nodePropertyValues(oldNode)
+		}
+		keys := make([]string, 0, len(newValues)+len(oldValues))
+		for key := range newValues {
+			keys = append(keys, key)
+		}
+		for key := range oldValues {
+			if _, exists := newValues[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			oldValue, hadValue := oldValues[key]
+			newValue, hasValue := newValues[key]
+			switch {
+			case !hasValue:
+				changes = append(changes, &SceneChange{Op: opRemoveProperty, Path: path, Property: key, Old: &oldValue})
+			case !hadValue:
+				changes = append(changes, &SceneChange{Op: opSetProperty, Path: path, Property: key, New: &newValue})
+			case oldValue != newValue:
+				changes = append(changes, &SceneChange{Op: opSetProperty, Path: path, Property: key, Old: &oldValue, New: &newValue})
+			}
+		}
+		return WalkContinue
+	})
+
+	oldConnections := make(map[string]bool)
+	for _, connection := range oldScene.Connections {
+		oldConnections[connectionKey(connection)] = true
+	}
+	newConnections := make(map[string]bool)
+	for _, connection := range newScene.Connections {
+		newConnections[connectionKey(connection)] = true
+	}
+	for _, connection := range oldScene.Connections {
+		if !newConnections[connectionKey(connection)] && !isRemoved(connection.From) && !isRemoved(connection.To) {
+			changes = append(changes, &SceneChange{Op: opRemoveConnection, Connection: newConnectionChange(connection)})
+		}
+	}
+	for _, connection := range newScene.Connections {
+		if !oldConnections[connectionKey(connection)] {
+			changes = append(changes, &SceneChange{Op: opAddConnection, Connection: newConnectionChange(connection)})
+		}
+	}
+	return changes
+}
+
+// String formats a change as a line of text output
+func (c *SceneChange) String() string {
+	switch c.Op {
+	case opAddNode:
+		nodeType := c.Type
+		if nodeType == "" {
+			nodeType = "instance of " + c.Instance
+		}
+		return fmt.Sprintf("+ %s (%s)", c.Path, nodeType)
+	case opRemoveNode:
+		return fmt.Sprintf("- %s", c.Path)
+	case opSetType:
+		return fmt.Sprintf("~ %s: type %s -> %s", c.Path, *c.Old, *c.New)
+	case opSetProperty:
+		if c.Old == nil {
+			return fmt.Sprintf("+ %s: %s = %s", c.Path, c.Property, *c.New)
+		}
+		return fmt.Sprintf("~ %s: %s = %s -> %s", c.Path, c.Property, *c.Old, *c.New)
+	case opRemoveProperty:
+		return fmt.Sprintf("- %s: %s = %s", c.Path, c.Property, *c.Old)
+	case opAddConnection:
+		return fmt.Sprintf("+ connection %s", c.Connection)
+	case opRemoveConnection:
+		return fmt.Sprintf("- connection %s", c.Connection)
+	}
+	return c.Op
+}
+
+// String formats a connection as "signal: from -> to (method)"
+func (c *ConnectionChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s (%s)", c.Signal, c.From, c.To, c.Method)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old tscn file> <new tscn file>",
+	Short: "List the node, property and connection changes between two versions of a scene",
+	Long: `Compare two versions of a scene by node path below the root and list the nodes added and
+removed, the types and properties changed, and the connections added and removed. Values are
+shown as written in the files, so the JSON changes can be applied with gdq patch.
+
+With -o json, print the changes as a list of operations that turn the old scene into the new
+one when applied in order: add-node, remove-node, set-type, set-property, remove-property,
+add-connection and remove-connection, with the node path, property and old/new values.`,
+	Example: `  gdq diff old/player.tscn player.tscn
+  git show HEAD~1:player.tscn > /tmp/old.tscn && gdq diff -o json /tmp/old.tscn player.tscn`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		var scenes []*GodotScene
+		for _, file := range args {
+			if _, err := os.Stat(file); os.IsNotExist(err) {
+				return fmt.Errorf("file not found: %s", file)
+			}
+			scene, err := ParseFile(file, ParseOptions{KeepRawLines: true, Logger: logger})
+			if err != nil {
+				return fmt.Errorf("parse error: %s: %v", file, err)
+			}
+			scenes = append(scenes, scene)
+		}
+
+		changes := diffScenes(scenes[0], scenes[1])
+		if outputFormat == "json" {
+			return writeJSON(out, changes)
+		}
+		if len(changes) == 0 {
+			fmt.Fprintln(out, "No changes")
+		}
+		for _, change := range changes {
+			fmt.Fprintln(out, change)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,131 @@
+package gdquery
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffScenes(t *testing.T) {
+	oldContent := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_s"]
+
+[node name="Player" type="CharacterBody2D"]
+script = ExtResource("1_s")
+speed = 100
+visible = false
+
+[node name="Old" type="Node2D" parent="."]
+
+[node name="Child" type="Node2D" parent="Old"]
+
+[node name="Sprite" type="Sprite2D" parent="."]
+
+[connection signal="timeout" from="Old" to="." method="_on_timeout"]
+`
+	newContent := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_s"]
+
+[node name="Hero" type="CharacterBody2D"]
+script = ExtResource("1_s")
+speed = 200
+health = 3
+
+[node name="Sprite" type="AnimatedSprite2D" parent="."]
+
+[node name="Label" type="Label" parent="Sprite"]
+text = "Hi"
+
+[connection signal="ready" from="." to="." method="_on_ready"]
+`
+	oldScene, err := ParseReader(strings.NewReader(oldContent), "old.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	newScene, err := ParseReader(strings.NewReader(newContent), "new.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var lines []string
+	for _, change := range diffScenes(oldScene, newScene) {
+		lines = append(lines, change.String())
+	}
+	expected := []string{
+		"- Old",
+		"+ .: health = 3",
+		"~ .: speed = 100 -> 200",
+		"- .: visible = false",
+		"~ Sprite: type Sprite2D -> AnimatedSprite2D",
+		"+ Sprite/Label (Label)",
+		`+ Sprite/Label: text = "Hi"`,
+		"+ connection ready: . -> . (_on_ready)",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected changes:\n%s", strings.Join(lines, "\n"))
+	}
+
+	data, err := json.Marshal(diffScenes(oldScene, newScene)[1:3])
+	if err != nil {
+		t.Fatalf("JSON error: %v", err)
+	}
+	if string(data) != `[{"op":"set-property","path":".","property":"health","new":"3"},{"op":"set-property","path":".","property":"speed","old":"100","new":"200"}]` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	if changes := diffScenes(oldScene, oldScene); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %d", len(changes))
+	}
+}
+
+func TestDiffSceneRepeatedNames(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("test", "sample.tscn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := string(content)
+	parse := func(content string) *GodotScene {
+		scene, err := ParseReader(strings.NewReader(content), "sample.tscn", ParseOptions{KeepRawLines: true})
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		return scene
+	}
+
+	if changes := diffScenes(parse(original), parse(original)); len(changes) != 0 {
+		t.Errorf("Expected no changes against itself, got: %v", changes)
+	}
+
+	// Changes below nodes whose names repeat at other depths round-trip through patch
+	modified := strings.Replace(original, `[node name="Button" type="Button" parent="Control/scrapScene/Control"]`,
+		`[node name="Button" type="Button" parent="Control/scrapScene/Control"]`+"\ntext = \"Scrap\"", 1)
+	modified = strings.Replace(modified, `[node name="TextureRect2" type="TextureRect" parent="Control/battleScene/Control"]`,
+		`[node name="TextureRect2" type="Control" parent="Control/battleScene/Control"]`, 1)
+	changes := diffScenes(parse(original), parse(modified))
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.String())
+	}
+	expected := []string{
+		`+ Control/scrapScene/Control/Button: text = "Scrap"`,
+		`~ Control/battleScene/Control/TextureRect2: type TextureRect -> Control`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Unexpected changes:\n%s", strings.Join(got, "\n"))
+	}
+
+	editor, err := NewSceneEditor(original)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if err := applySceneChanges(editor, changes, false); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if remaining := diffScenes(parse(editor.String()), parse(modified)); len(remaining) != 0 {
+		t.Errorf("Unexpected changes after patching: %v", remaining)
+	}
+}
@@ -0,0 +1,333 @@
+package gdquery
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ExportedVar is an exported variable of a GDScript
+type ExportedVar struct {
+	Name    string
+	Type    string
+	Default string
+}
+
+// Exported variable declarations. Godot 4 annotations may stand on the line
+// before the var; Godot 3 uses export(Type, hints) var.
+var (
+	gdscriptExportAnnotationRe = regexp.MustCompile(`^(?:@export\w*(?:\([^)]*\))?\s*)+`)
+	gdscriptExport3Re          = regexp.MustCompile(`^export(?:\(\s*([^,)]*)[^)]*\))?\s+`)
+	gdscriptVarRe              = regexp.MustCompile(`^(?:onready\s+)?var\s+(\w+)\s*(?::\s*([^=]*?))?\s*(?::?=\s*(.*))?$`)
+)
+
+// stripScriptComment removes a trailing # comment outside of strings
+func stripScriptComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseExportedVars returns the exported variables declared at the top level of a GDScript
+func parseExportedVars(source string) []*ExportedVar {
+	var vars []*ExportedVar
+	pending := false
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != strings.TrimLeft(line, " \t") {
+			// Indented: inside a function or property block
+			continue
+		}
+		line = strings.TrimSpace(stripScriptComment(line))
+		if line == "" {
+			continue
+		}
+
+		exported := pending
+		hintType := ""
+		if matches := gdscriptExportAnnotationRe.FindString(line); matches != "" {
+			exported = true
+			line = strings.TrimSpace(line[len(matches):])
+			if line == "" {
+				pending = true
+				continue
+			}
+		} else if matches := gdscriptExport3Re.FindStringSubmatch(line); matches != nil {
+			exported = true
+			hintType = strings.TrimSpace(matches[1])
+			line = line[len(matches[0]):]
+		}
+		pending = false
+		if !exported {
+			continue
+		}
+
+		matches := gdscriptVarRe.FindStringSubmatch(strings.TrimSuffix(line, ":"))
+		if matches == nil {
+			continue
+		}
+		variable := &ExportedVar{Name: matches[1], Type: strings.TrimSpace(matches[2]), Default: strings.TrimSpace(matches[3])}
+		if variable.Type == "" {
+			variable.Type = hintType
+		}
+		// Godot 3 setget and Godot 4 inline property blocks follow the default
+		if i := strings.Index(variable.Default, " setget "); i >= 0 {
+			variable.Default = strings.TrimSpace(variable.Default[:i])
+		}
+		vars = append(vars, variable)
+	}
+	return vars
+}
+
+// markdownCell escapes a value for a Markdown table cell
+func markdownCell(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(value, "|", `\|`) + "`"
+}
+
+// writeDocTree writes a node and its children as a nested Markdown list
+func writeDocTree(b *strings.Builder, node *GodotNode, scene *GodotScene, depth int) {
+	fmt.Fprintf(b, "%s- **%s** (%s)", strings.Repeat("  ", depth), node.OriginalName, typeLabel(node))
+	if node.Instance != "" {
+		if path := resolveResourcePath(node.Instance, scene); path != "" {
+			fmt.Fprintf(b, " instance of `%s`", path)
+		}
+	}
+	if node.Script != "" {
+		if path := resolveResourcePath(node.Script, scene); path != "" {
+			fmt.Fprintf(b, " script `%s`", path)
+		}
+	}
+	b.WriteString("\n")
+	for _, child := range node.Children {
+		writeDocTree(b, child, scene, depth+1)
+	}
+}
+
+// docSignalRows returns the rows of the signal table of a scene: the signals
+// declared by the scripts of its nodes, in tree order, with their connections
+// (a row without target when a signal is not connected), then the connections
+// of the other signals
+func docSignalRows(root, resPath string, scene *GodotScene) []string {
+	var rows []string
+	row := func(signal, script, from string, connection *GodotConnection) {
+		to, method := "", ""
+		if connection != nil {
+			to, method = markdownCell(connectionNodePath(scene, connection.To)), connection.Method
+		}
+		rows = append(rows, fmt.Sprintf("| %s | %s | %s | %s | %s |\n", signal, markdownCell(script), markdownCell(from), to, method))
+	}
+
+	listed := make(map[*GodotConnection]bool)
+	for _, node := range scene.AllNodes {
+		script := resolveResourcePath(node.Script, scene)
+		if script == "" || strings.HasPrefix(script, "SubResource(") || strings.HasPrefix(script, "uid://") {
+			continue
+		}
+		members := readScriptMembers(root, normalizeResPath(resPath, script))
+		if members == nil {
+			continue
+		}
+		for _, signal := range members.Signals {
+			connected := false
+			for _, connection := range scene.Connections {
+				if connection.Signal == signal && connectionNodePath(scene, connection.From) == node.Path {
+					row(signal, script, node.Path, connection)
+					listed[connection] = true
+					connected = true
+				}
+			}
+			if !connected {
+				row(signal, script, node.Path, nil)
+			}
+		}
+	}
+
+	for _, connection := range scene.Connections {
+		if !listed[connection] {
+			row(connection.Signal, "", connectionNodePath(scene, connection.From), connection)
+		}
+	}
+	return rows
+}
+
+// generateSceneDoc returns the Markdown documentation of a scene: its tree
+// outline, scripts with their exported variables, signals and dependencies
+func generateSceneDoc(root, file string) (string, error) {
+	scene, err := parseSceneFile(file)
+	if err != nil {
+		return "", err
+	}
+	deps, err := listExtDependencies(file)
+	if err != nil {
+		return "", err
+	}
+	resPath := fsToRes(root, file)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n`%s`\n", filepath.Base(file), resPath)
+
+	b.WriteString("\n## Tree\n\n")
+	if scene.RootNode != nil {
+		writeDocTree(&b, scene.RootNode, scene, 0)
+	}
+
+	// Scripts in tree order, with the nodes using them
+	var scripts []string
+	users := make(map[string][]string)
+	for _, node := range scene.AllNodes {
+		script := resolveResourcePath(node.Script, scene)
+		if script == "" || strings.HasPrefix(script, "SubResource(") {
+			continue
+		}
+		if users[script] == nil {
+			scripts = append(scripts, script)
+		}
+		users[script] = append(users[script], node.Path)
+	}
+	if len(scripts) > 0 {
+		b.WriteString("\n## Scripts\n")
+		for _, script := range scripts {
+			fmt.Fprintf(&b, "\n### %s\n\nUsed by: %s\n", script, strings.Join(users[script], ", "))
+			if strings.HasPrefix(script, "uid://") {
+				continue
+			}
+			source, err := os.ReadFile(resToFS(root, normalizeResPath(resPath, script)))
+			if err != nil {
+				b.WriteString("\nScript not found.\n")
+				continue
+			}
+			vars := parseExportedVars(string(source))
+			if hasExtension(script, csharpExtensions) {
+				vars = parseCSharpScript(string(source), script).Exports
+			}
+			if len(vars) == 0 {
+				continue
+			}
+			b.WriteString("\n| Variable | Type | Default |\n|---|---|---|\n")
+			for _, variable := range vars {
+				fmt.Fprintf(&b, "| %s | %s | %s |\n", variable.Name, markdownCell(variable.Type), markdownCell(variable.Default))
+			}
+		}
+	}
+
+	if rows := docSignalRows(root, resPath, scene); len(rows) > 0 {
+		b.WriteString("\n## Signals\n\n| Signal | Declared by | From | To | Method |\n|---|---|---|---|---|\n")
+		for _, row := range rows {
+			b.WriteString(row)
+		}
+	}
+
+	if len(deps) > 0 {
+		b.WriteString("\n## Dependencies\n\n")
+		for _, dep := range deps {
+			fmt.Fprintf(&b, "- `%s` (%s)", dep.ResPath, dep.Resource.Type)
+			if dep.Missing {
+				b.WriteString(" **missing**")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// generateDocs writes one Markdown file per scene under dir to outDir, mirroring
+// the directory layout, and an index.md linking them. It returns the number of
+// scenes documented.
+func generateDocs(dir, outDir string) (int, error) {
+	root := findProjectRoot(dir)
+	files, err := findProjectFiles(dir, []string{".tscn", ".escn"})
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(files)
+
+	var index strings.Builder
+	index.WriteString("# Scenes\n\n")
+	count := 0
+	progress := newProgress("Documenting", len(files))
+	defer progress.Clear()
+	for _, file := range files {
+		progress.Step()
+		doc, err := generateSceneDoc(root, file)
+		if err != nil {
+			logger.Warn("Skipping scene", "path", file, "error", err)
+			continue
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return count, err
+		}
+		rel = strings.TrimSuffix(rel, filepath.Ext(rel)) + ".md"
+		target := filepath.Join(outDir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return count, err
+		}
+		if err := os.WriteFile(target, []byte(doc), 0644); err != nil {
+			return count, err
+		}
+		fmt.Fprintf(&index, "- [%s](%s)\n", fsToRes(root, file), filepath.ToSlash(rel))
+		count++
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return count, err
+	}
+	return count, os.WriteFile(filepath.Join(outDir, "index.md"), []byte(index.String()), 0644)
+}
+
+var docCmd = &cobra.Command{
+	Use:   "doc <dir> <output dir>",
+	Short: "Generate Markdown documentation for the scenes of a project",
+	Long: `Write one Markdown file per scene under dir: the tree outline, the attached scripts
+with their exported variables, the signals the scripts declare and the signal connections,
+and the dependencies. The files mirror the directory layout of the scenes, with an index.md
+linking them, and can be committed as living architecture docs.
+
+The output directory is the second argument, created when missing; -o is the output format
+flag shared by every command and does not apply here.`,
+	Example:      `  gdq doc . docs/scenes`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if len(args) < 2 {
+			if cmd.Flags().Changed("output") {
+				return fmt.Errorf("the output directory is the second argument (gdq doc %s %s); -o selects the output format", args[0], outputFormat)
+			}
+			return fmt.Errorf("missing output directory (gdq doc %s docs/scenes)", args[0])
+		}
+		if _, err := os.Stat(args[0]); err != nil {
+			return fmt.Errorf("directory not found: %s", args[0])
+		}
+		count, err := generateDocs(args[0], args[1])
+		if err != nil {
+			return fmt.Errorf("doc error: %v", err)
+		}
+		fmt.Fprintf(out, "Documented %d scene(s) in %s\n", count, args[1])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(docCmd)
+}
@@ -0,0 +1,107 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseExportedVars(t *testing.T) {
+	vars := parseExportedVars(`extends Node2D
+
+@export var speed := 200.0 # pixels per second
+@export_range(0, 10) var lives: int = 3
+@export
+var title: String = "A # not a comment"
+@export var target: NodePath:
+	set(value):
+		target = value
+var internal = 1
+export(int, 0, 5) var old_style = 2 setget set_old
+export var plain = "x"
+
+func _ready():
+	@export var not_top_level = 1
+`)
+
+	expected := []ExportedVar{
+		{Name: "speed", Default: "200.0"},
+		{Name: "lives", Type: "int", Default: "3"},
+		{Name: "title", Type: "String", Default: `"A # not a comment"`},
+		{Name: "target", Type: "NodePath"},
+		{Name: "old_style", Type: "int", Default: "2"},
+		{Name: "plain", Default: `"x"`},
+	}
+	if len(vars) != len(expected) {
+		t.Fatalf("Expected %d exported vars, got %d", len(expected), len(vars))
+	}
+	for i, variable := range vars {
+		if *variable != expected[i] {
+			t.Errorf("Unexpected var %d: %+v", i, *variable)
+		}
+	}
+}
+
+func TestGenerateDocs(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"player.gd": "extends CharacterBody2D\n\nsignal hit(amount)\nsignal died\n\n@export var speed := 200.0\n",
+		"levels/level.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_a"]
+[ext_resource type="Texture2D" path="res://missing.png" id="2_b"]
+
+[node name="Level" type="Node2D"]
+
+[node name="Player" type="CharacterBody2D" parent="."]
+script = ExtResource("1_a")
+
+[node name="Sprite" type="Sprite2D" parent="Player"]
+texture = ExtResource("2_b")
+
+[connection signal="tree_entered" from="." to="Player" method="_on_level_entered"]
+[connection signal="hit" from="Player" to="." method="_on_player_hit"]
+`,
+	})
+	out := filepath.Join(t.TempDir(), "docs")
+
+	count, err := generateDocs(root, out)
+	if err != nil || count != 1 {
+		t.Fatalf("Expected 1 scene documented, got %d (%v)", count, err)
+	}
+
+	doc, err := os.ReadFile(filepath.Join(out, "levels", "level.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# level.tscn\n\n`res://levels/level.tscn`\n" +
+		"\n## Tree\n\n" +
+		"- **Level** (Node2D)\n" +
+		"  - **Player** (CharacterBody2D) script `res://player.gd`\n" +
+		"    - **Sprite** (Sprite2D)\n" +
+		"\n## Scripts\n" +
+		"\n### res://player.gd\n\nUsed by: Level/Player\n" +
+		"\n| Variable | Type | Default |\n|---|---|---|\n" +
+		"| speed |  | `200.0` |\n" +
+		"\n## Signals\n\n| Signal | Declared by | From | To | Method |\n|---|---|---|---|---|\n" +
+		"| hit | `res://player.gd` | `Level/Player` | `Level` | _on_player_hit |\n" +
+		"| died | `res://player.gd` | `Level/Player` |  |  |\n" +
+		"| tree_entered |  | `Level` | `Level/Player` | _on_level_entered |\n" +
+		"\n## Dependencies\n\n" +
+		"- `res://player.gd` (Script)\n" +
+		"- `res://missing.png` (Texture2D) **missing**\n"
+	if string(doc) != expected {
+		t.Errorf("Unexpected doc:\n%s", doc)
+	}
+
+	index, err := os.ReadFile(filepath.Join(out, "index.md"))
+	if err != nil || !strings.Contains(string(index), "- [res://levels/level.tscn](levels/level.md)\n") {
+		t.Errorf("Unexpected index:\n%s", index)
+	}
+
+	// -o is the output format: the directory must be passed as the second argument
+	var stdout, stderr strings.Builder
+	if code := Run([]string{"doc", root, "-o", out}, &stdout, &stderr); code == 0 || !strings.Contains(stderr.String(), "second argument") {
+		t.Errorf("Expected an error pointing to the output directory argument, got: %s", stderr.String())
+	}
+}
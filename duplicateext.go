@@ -0,0 +1,210 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "duplicate-ext-resource",
+		Description: "ext_resources declared twice with the same id, or for the same path with different ids, as left by bad merges",
+		Check:       checkDuplicateExtResources,
+		Fix:         fixDuplicateExtResources,
+	})
+}
+
+// extResourceDeclaration is an [ext_resource] section of a text scene or resource
+type extResourceDeclaration struct {
+	ID      string
+	Target  string // res:// path, or uid when the declaration has no path
+	Line    int
+	Section *sceneSection
+}
+
+// ExtResourceDuplicate is an ext_resource declaration repeating an earlier one:
+// the same id, or the same path with another id. Only declarations that can
+// be dropped with their references rewritten to the first are fixable.
+type ExtResourceDuplicate struct {
+	Declaration *extResourceDeclaration
+	First       *extResourceDeclaration
+	Fixable     bool
+}
+
+// findDuplicateExtResources lists the ext_resource declarations of content
+// that repeat an earlier id or path. fileRes resolves Godot 3 relative paths.
+func findDuplicateExtResources(text *sceneText, fileRes string) []*ExtResourceDuplicate {
+	var declarations []*extResourceDeclaration
+	line := len(text.Preamble)
+	for _, section := range text.Sections {
+		line++
+		if strings.HasPrefix(section.Header, "[ext_resource") {
+			declaration := &extResourceDeclaration{Line: line, Section: section}
+			if matches := sectionIDRe.FindStringSubmatch(section.Header); matches != nil {
+				declaration.ID = matches[1] + matches[2]
+			}
+			if path, exists := headerAttr(section.Header, "path"); exists {
+				declaration.Target = normalizeResPath(fileRes, path)
+			} else {
+				declaration.Target, _ = headerAttr(section.Header, "uid")
+			}
+			if declaration.ID != "" {
+				declarations = append(declarations, declaration)
+			}
+		}
+		line += len(section.Lines)
+	}
+
+	// An id declared for two targets is ambiguous: its references are left alone
+	conflicting := make(map[string]bool)
+	byID := make(map[string]*extResourceDeclaration)
+	for _, declaration := range declarations {
+		if first := byID[declaration.ID]; first != nil && first.Target != declaration.Target {
+			conflicting[declaration.ID] = true
+		} else if first == nil {
+			byID[declaration.ID] = declaration
+		}
+	}
+
+	var duplicates []*ExtResourceDuplicate
+	byID = make(map[string]*extResourceDeclaration)
+	byTarget := make(map[string]*extResourceDeclaration)
+	for _, declaration := range declarations {
+		if first := byID[declaration.ID]; first != nil {
+			duplicates = append(duplicates, &ExtResourceDuplicate{Declaration: declaration, First: first, Fixable: first.Target == declaration.Target})
+			continue
+		}
+		byID[declaration.ID] = declaration
+		if first := byTarget[declaration.Target]; first != nil && declaration.Target != "" {
+			duplicates = append(duplicates, &ExtResourceDuplicate{Declaration: declaration, First: first, Fixable: !conflicting[declaration.ID] && !conflicting[first.ID]})
+			continue
+		}
+		byTarget[declaration.Target] = declaration
+	}
+	return duplicates
+}
+
+// message describes the duplicate for lint output
+func (d *ExtResourceDuplicate) message() string {
+	var message string
+	switch {
+	case d.Declaration.ID != d.First.ID:
+		message = fmt.Sprintf("ext_resource %s declared again as id %s (first as id %s at line %d)", d.Declaration.Target, d.Declaration.ID, d.First.ID, d.First.Line)
+	case d.Declaration.Target == d.First.Target:
+		message = fmt.Sprintf("ext_resource id %s declared twice for %s (first at line %d)", d.Declaration.ID, d.Declaration.Target, d.First.Line)
+	default:
+		return fmt.Sprintf("ext_resource id %s declared for %s and %s (line %d); references are ambiguous", d.Declaration.ID, d.First.Target, d.Declaration.Target, d.First.Line)
+	}
+	return message + "; fixable with --fix"
+}
+
+// checkDuplicateExtResources reports repeated ext_resource declarations in the
+// scenes and resources of the linted directory
+func checkDuplicateExtResources(ctx *LintContext) []LintFinding {
+	files, err := findProjectFiles(ctx.Dir, sceneExtensions)
+	if err != nil {
+		logger.Warn("Scene scan failed", "error", err)
+		return nil
+	}
+
+	var findings []LintFinding
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			logger.Warn("Skipping file", "path", file, "error", err)
+			continue
+		}
+		fileRes := fsToRes(ctx.Root, file)
+		for _, duplicate := range findDuplicateExtResources(splitSceneText(string(content)), fileRes) {
+			findings = append(findings, LintFinding{
+				Severity: severityError,
+				File:     fileRes,
+				Line:     duplicate.Declaration.Line,
+				Message:  duplicate.message(),
+			})
+		}
+	}
+	return findings
+}
+
+// dedupeExtResources drops the fixable duplicate declarations of a text scene
+// and points their references to the first declaration. It returns the
+// number of dropped declarations.
+func dedupeExtResources(text *sceneText, fileRes string) int {
+	dropped := make(map[*sceneSection]bool)
+	replacements := make(map[string]string)
+	for _, duplicate := range findDuplicateExtResources(text, fileRes) {
+		if duplicate.Fixable {
+			dropped[duplicate.Declaration.Section] = true
+			replacements[duplicate.Declaration.ID] = duplicate.First.ID
+		}
+	}
+	if len(dropped) == 0 {
+		return 0
+	}
+
+	rewrite := func(line string) string {
+		matches := resourceRefRe.FindAllStringSubmatchIndex(line, -1)
+		for i := len(matches) - 1; i >= 0; i-- {
+			match := matches[i]
+			if line[match[2]:match[3]] != "Ext" {
+				continue
+			}
+			if id, exists := replacements[line[match[4]:match[5]]]; exists {
+				line = line[:match[4]] + id + line[match[5]:]
+			}
+		}
+		return line
+	}
+
+	var sections []*sceneSection
+	for _, section := range text.Sections {
+		if dropped[section] {
+			// Keep the blank lines separating the declarations from the next section
+			if len(sections) > 0 {
+				previous := sections[len(sections)-1]
+				previous.Lines = append(previous.Lines, section.Lines...)
+			}
+			continue
+		}
+		section.Header = rewrite(section.Header)
+		for i, line := range section.Lines {
+			section.Lines[i] = rewrite(line)
+		}
+		sections = append(sections, section)
+	}
+	text.Sections = sections
+	updateLoadSteps(text.Sections)
+	return len(dropped)
+}
+
+// fixDuplicateExtResources rewrites the files of the linted directory with
+// fixable duplicate ext_resource declarations
+func fixDuplicateExtResources(ctx *LintContext) (int, error) {
+	files, err := findProjectFiles(ctx.Dir, sceneExtensions)
+	if err != nil {
+		return 0, err
+	}
+
+	fixed := 0
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fixed, err
+		}
+		text := splitSceneText(string(content))
+		if dedupeExtResources(text, fsToRes(ctx.Root, file)) == 0 {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return fixed, err
+		}
+		if err := os.WriteFile(file, []byte(text.String()), info.Mode()); err != nil {
+			return fixed, err
+		}
+		fixed++
+	}
+	return fixed, nil
+}
@@ -0,0 +1,255 @@
+package gdquery
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Duplicates command options
+var duplicatesMinBytes = 0
+
+// SubResourceCopy is one occurrence of a sub_resource
+type SubResourceCopy struct {
+	File string // res:// path
+	ID   string
+	Line int
+	Size int // bytes of the section
+}
+
+// DuplicateGroup is a set of sub_resources with identical type and content
+type DuplicateGroup struct {
+	Type   string
+	Size   int // bytes of the first copy; copies differ only by the length of their id
+	Copies []SubResourceCopy
+}
+
+// fileCount returns the number of distinct files holding a copy
+func (g *DuplicateGroup) fileCount() int {
+	files := make(map[string]bool)
+	for _, c := range g.Copies {
+		files[c.File] = true
+	}
+	return len(files)
+}
+
+// subResourceContent identifies a sub_resource by type and properties, ignoring
+// its id and formatting. Blank lines do not count.
+func subResourceContent(resourceType string, section *sceneSection) string {
+	var lines []string
+	for _, line := range section.Lines {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return resourceType + "\n" + strings.Join(lines, "\n")
+}
+
+// sectionSize returns the serialized size of a section including its header
+func sectionSize(section *sceneSection) int {
+	size := len(section.Header) + 1
+	for _, line := range section.Lines {
+		if strings.TrimSpace(line) != "" {
+			size += len(line) + 1
+		}
+	}
+	return size
+}
+
+// findDuplicateSubResources hashes the sub_resources of files and returns the
+// groups of identical copies within a single file and the content repeated across files
+func findDuplicateSubResources(root string, files []string, minBytes int) (within, across []*DuplicateGroup, err error) {
+	groups := make(map[[sha256.Size]byte]*DuplicateGroup)
+	var order [][sha256.Size]byte
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		text := splitSceneText(string(content))
+
+		line := len(text.Preamble) + 1
+		for _, section := range text.Sections {
+			sectionLine := line
+			line += 1 + len(section.Lines)
+			if !strings.HasPrefix(section.Header, "[sub_resource") {
+				continue
+			}
+
+			resourceType, id := "", ""
+			if matches := sectionTypeRe.FindStringSubmatch(section.Header); matches != nil {
+				resourceType = matches[1]
+			}
+			if matches := sectionIDRe.FindStringSubmatch(section.Header); matches != nil {
+				id = matches[1] + matches[2]
+			}
+			size := sectionSize(section)
+			if size < minBytes {
+				continue
+			}
+
+			hash := sha256.Sum256([]byte(subResourceContent(resourceType, section)))
+			group, exists := groups[hash]
+			if !exists {
+				group = &DuplicateGroup{Type: resourceType, Size: size}
+				groups[hash] = group
+				order = append(order, hash)
+			}
+			group.Copies = append(group.Copies, SubResourceCopy{File: fsToRes(root, file), ID: id, Line: sectionLine, Size: size})
+		}
+	}
+
+	for _, hash := range order {
+		group := groups[hash]
+		if len(group.Copies) < 2 {
+			continue
+		}
+
+		byFile := make(map[string][]SubResourceCopy)
+		var fileOrder []string
+		for _, c := range group.Copies {
+			if _, exists := byFile[c.File]; !exists {
+				fileOrder = append(fileOrder, c.File)
+			}
+			byFile[c.File] = append(byFile[c.File], c)
+		}
+		for _, file := range fileOrder {
+			if copies := byFile[file]; len(copies) > 1 {
+				within = append(within, &DuplicateGroup{Type: group.Type, Size: copies[0].Size, Copies: copies})
+			}
+		}
+		if len(fileOrder) > 1 {
+			across = append(across, group)
+		}
+	}
+
+	// Largest savings first
+	sort.SliceStable(within, func(i, j int) bool {
+		return within[i].withinSavings() > within[j].withinSavings()
+	})
+	sort.SliceStable(across, func(i, j int) bool {
+		return across[i].acrossSavings() > across[j].acrossSavings()
+	})
+	return within, across, nil
+}
+
+// withinSavings estimates the bytes saved by keeping one copy in the file
+func (g *DuplicateGroup) withinSavings() int {
+	return (len(g.Copies) - 1) * g.Size
+}
+
+// acrossSavings estimates the bytes saved by moving the content to one shared
+// .tres file, once duplicates within each file are merged
+func (g *DuplicateGroup) acrossSavings() int {
+	return (g.fileCount() - 1) * g.Size
+}
+
+// printDuplicateSubResources displays the duplicate groups and the estimated savings
+func printDuplicateSubResources(out io.Writer, within, across []*DuplicateGroup) {
+	total := 0
+
+	fmt.Fprintln(out, "=== Duplicates Within Scenes ===")
+	if len(within) == 0 {
+		fmt.Fprintln(out, "None")
+	}
+	for _, group := range within {
+		var ids []string
+		for _, c := range group.Copies {
+			ids = append(ids, fmt.Sprintf("%s:%d", c.ID, c.Line))
+		}
+		fmt.Fprintf(out, "%s: %s x%d (%s each, saves %s): %s\n", group.Copies[0].File, group.Type, len(group.Copies),
+			formatSize(group.Size), formatSize(group.withinSavings()), strings.Join(ids, ", "))
+		total += group.withinSavings()
+	}
+
+	fmt.Fprintln(out, "\n=== Shared Across Scenes (candidates for .tres files) ===")
+	if len(across) == 0 {
+		fmt.Fprintln(out, "None")
+	}
+	for _, group := range across {
+		fmt.Fprintf(out, "%s in %d files (%s each, saves %s):\n", group.Type, group.fileCount(),
+			formatSize(group.Size), formatSize(group.acrossSavings()))
+		for _, c := range group.Copies {
+			fmt.Fprintf(out, "  %s:%d %s\n", c.File, c.Line, c.ID)
+		}
+		total += group.acrossSavings()
+	}
+
+	fmt.Fprintf(out, "\nEstimated savings: %s\n", formatSize(total))
+}
+
+var duplicatesCmd = &cobra.Command{
+	Use:   "duplicates [project dir]",
+	Short: "Find identical sub_resources within and across scenes",
+	Long: `Hash the content of every sub_resource (type and properties, ignoring the id) and report
+copies repeated within a scene, which could be a single sub_resource, and content repeated
+across scenes and resources, which could be a shared .tres file, with the estimated bytes saved.
+
+With --scenes, report near-identical scenes instead: scenes with the same tree (types, names
+and instances of the nodes, the root name aside) whose properties are at least --similarity
+alike, which usually are copies that could be one scene with exported parameters.`,
+	Example: `  gdq duplicates path/to/project
+  gdq duplicates --scenes --similarity 0.9 path/to/project`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		root := findProjectRoot(dir)
+		if duplicatesScenes {
+			if duplicatesSimilarity < 0 || duplicatesSimilarity > 1 {
+				return fmt.Errorf("--similarity must be between 0 and 1")
+			}
+			results, err := scanProject(root, dir, ParseOptions{Logger: logger})
+			if err != nil {
+				return fmt.Errorf("scan error: %v", err)
+			}
+			var fingerprints []*SceneFingerprint
+			for _, result := range results {
+				if result.Err != nil {
+					logger.Warn("Skipping file", "path", result.File, "error", result.Err)
+					continue
+				}
+				if result.Scene.RootNode != nil {
+					fingerprints = append(fingerprints, fingerprintScene(result.File, result.Scene))
+				}
+			}
+			printDuplicateScenes(out, findDuplicateScenes(fingerprints, duplicatesSimilarity, duplicatesMinNodes))
+			return nil
+		}
+
+		files, err := findProjectFiles(dir, sceneExtensions)
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+
+		within, across, err := findDuplicateSubResources(root, files, duplicatesMinBytes)
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+		printDuplicateSubResources(out, within, across)
+		return nil
+	},
+}
+
+func init() {
+	duplicatesCmd.Flags().IntVar(&duplicatesMinBytes, "min-bytes", 0, "Ignore sub_resources smaller than this many bytes")
+	duplicatesCmd.Flags().BoolVar(&duplicatesScenes, "scenes", false, "Report near-identical scenes instead of sub_resources")
+	duplicatesCmd.Flags().Float64Var(&duplicatesSimilarity, "similarity", 0.8, "With --scenes, share of identical properties (0 to 1) for scenes with the same tree to be reported")
+	duplicatesCmd.Flags().IntVar(&duplicatesMinNodes, "min-nodes", 3, "With --scenes, ignore scenes with fewer nodes")
+	rootCmd.AddCommand(duplicatesCmd)
+}
@@ -0,0 +1,136 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateSubResources(t *testing.T) {
+	style := "bg_color = Color(0.2, 0.2, 0.2, 1)\ncorner_radius_top_left = 4\n"
+	root := writeProjectFiles(t, map[string]string{
+		"ui/menu.tscn": `[gd_scene load_steps=4 format=3]
+
+[sub_resource type="StyleBoxFlat" id="StyleBoxFlat_a"]
+` + style + `
+[sub_resource type="StyleBoxFlat" id="StyleBoxFlat_b"]
+` + style + `
+[sub_resource type="StyleBoxFlat" id="StyleBoxFlat_c"]
+bg_color = Color(1, 0, 0, 1)
+
+[node name="Menu" type="Control"]
+`,
+		"ui/hud.tscn": `[gd_scene load_steps=2 format=3]
+
+[sub_resource type="StyleBoxFlat" id="StyleBoxFlat_hud"]
+` + style + `
+[sub_resource type="StyleBoxEmpty" id="StyleBoxEmpty_1"]
+
+[node name="HUD" type="Control"]
+`,
+	})
+	files, err := findProjectFiles(root, sceneExtensions)
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+
+	within, across, err := findDuplicateSubResources(root, files, 0)
+	if err != nil {
+		t.Fatalf("Duplicate error: %v", err)
+	}
+
+	if len(within) != 1 || within[0].Copies[0].File != "res://ui/menu.tscn" || len(within[0].Copies) != 2 {
+		t.Fatalf("Expected the two identical StyleBoxes of the menu, got %+v", within)
+	}
+	if c := within[0].Copies[1]; c.ID != "StyleBoxFlat_b" || c.Line != 7 {
+		t.Errorf("Unexpected second copy: %+v", c)
+	}
+	size := len(`[sub_resource type="StyleBoxFlat" id="StyleBoxFlat_a"]`) + 1 + len(style)
+	if within[0].Size != size || within[0].withinSavings() != size {
+		t.Errorf("Expected %d bytes saved, got %d (size %d)", size, within[0].withinSavings(), within[0].Size)
+	}
+
+	if len(across) != 1 || across[0].fileCount() != 2 || len(across[0].Copies) != 3 {
+		t.Fatalf("Expected the StyleBox shared by both scenes, got %+v", across)
+	}
+	// The first copy (in hud.tscn) has a longer id
+	if across[0].acrossSavings() != size+2 {
+		t.Errorf("Expected %d bytes saved by sharing, got %d", size+2, across[0].acrossSavings())
+	}
+
+	output := captureOutput(func(out io.Writer) { printDuplicateSubResources(out, within, across) })
+	if !strings.Contains(output, fmt.Sprintf("Estimated savings: %d bytes", 2*size+2)) {
+		t.Errorf("Unexpected report:\n%s", output)
+	}
+
+	// Small sub_resources can be ignored
+	within, across, _ = findDuplicateSubResources(root, files, size+3)
+	if len(within) != 0 || len(across) != 0 {
+		t.Errorf("Expected no duplicates above %d bytes", size+3)
+	}
+}
+
+func TestDuplicateScenes(t *testing.T) {
+	enemy := func(name, texture, speed string) string {
+		return `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://enemies/enemy.gd" id="` + name + `_s"]
+[ext_resource type="Texture2D" path="` + texture + `" id="` + name + `_t"]
+
+[node name="` + name + `" type="CharacterBody2D"]
+script = ExtResource("` + name + `_s")
+speed = ` + speed + `
+health = 3
+
+[node name="Sprite" type="Sprite2D" parent="."]
+texture = ExtResource("` + name + `_t")
+scale = Vector2(2, 2)
+
+[node name="Shape" type="CollisionShape2D" parent="."]
+disabled = false
+`
+	}
+	root := writeProjectFiles(t, map[string]string{
+		// Same tree and properties, resources declared under other ids
+		"enemies/bat.tscn":  enemy("Bat", "res://enemies/bat.png", "100"),
+		"enemies/bat2.tscn": enemy("BatCopy", "res://enemies/bat.png", "100"),
+		// Same tree, one property of six differs
+		"enemies/rat.tscn": enemy("Rat", "res://enemies/bat.png", "50"),
+		// Same tree, mostly different properties
+		"enemies/slime.tscn": enemy("Slime", "res://enemies/slime.png", "20"),
+		"tiny/a.tscn":        "[gd_scene format=3]\n\n[node name=\"A\" type=\"Node\"]\n",
+		"tiny/b.tscn":        "[gd_scene format=3]\n\n[node name=\"B\" type=\"Node\"]\n",
+	})
+	results, err := scanProject(root, root, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	var fingerprints []*SceneFingerprint
+	for _, result := range results {
+		fingerprints = append(fingerprints, fingerprintScene(result.File, result.Scene))
+	}
+
+	duplicates := findDuplicateScenes(fingerprints, 1, 3)
+	if len(duplicates) != 1 || strings.Join(duplicates[0].Files, " ") != "res://enemies/bat.tscn res://enemies/bat2.tscn" {
+		t.Fatalf("Expected the identical copies, got %+v", duplicates)
+	}
+
+	duplicates = findDuplicateScenes(fingerprints, 0.7, 3)
+	if len(duplicates) != 1 || len(duplicates[0].Files) != 3 || duplicates[0].NodeCount != 3 {
+		t.Fatalf("Expected the bat copies and the rat, got %+v", duplicates)
+	}
+	// 5 of the 7 different property entries of bat and rat are shared
+	if similarity := duplicates[0].Similarity; similarity < 0.71 || similarity > 0.72 {
+		t.Errorf("Unexpected similarity %.2f", similarity)
+	}
+
+	if duplicates = findDuplicateScenes(fingerprints, 0, 1); len(duplicates) != 2 {
+		t.Errorf("Expected the tiny scenes with a lower --min-nodes, got %d groups", len(duplicates))
+	}
+
+	output := captureOutput(func(out io.Writer) { printDuplicateScenes(out, findDuplicateScenes(fingerprints, 0.7, 3)) })
+	if !strings.Contains(output, "3 scenes with the same 3-node tree (properties 71% identical):\n  res://enemies/bat.tscn\n") {
+		t.Errorf("Unexpected report:\n%s", output)
+	}
+}
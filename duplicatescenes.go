@@ -0,0 +1,179 @@
+package gdquery
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Duplicate scene options
+var duplicatesScenes = false
+var duplicatesSimilarity = 0.8
+var duplicatesMinNodes = 3
+
+// SceneFingerprint is the normalized structure and properties of a scene
+type SceneFingerprint struct {
+	File       string // res:// path
+	NodeCount  int
+	Structure  [sha256.Size]byte // hash of the tree: depth, type, name and instance of every node
+	Properties map[string]bool   // "node path\x00key = value" entries, resources by path
+}
+
+// DuplicateScenes is a set of scenes with the same tree and similar properties
+type DuplicateScenes struct {
+	Files      []string
+	NodeCount  int
+	Similarity float64 // lowest property similarity between two of the scenes
+}
+
+// normalizedValue replaces the resource references of a property value by what
+// they point to, so that scenes declaring the same resources under other ids match
+func normalizedValue(value string, scene *GodotScene) string {
+	return resourceRefRe.ReplaceAllStringFunc(value, func(ref string) string {
+		if path := resolveResourcePath(ref, scene); path != "" {
+			return path
+		}
+		return ref
+	})
+}
+
+// fingerprintScene normalizes a scene for comparison. The name of the root is
+// left out, as copies of a scene are usually renamed after their file.
+func fingerprintScene(file string, scene *GodotScene) *SceneFingerprint {
+	fingerprint := &SceneFingerprint{File: file, Properties: make(map[string]bool)}
+	var structure strings.Builder
+	rootPath := scene.RootNode.Path
+	scene.Walk(func(node *GodotNode, depth int) WalkAction {
+		fingerprint.NodeCount++
+		path := "."
+		name := ""
+		if node != scene.RootNode {
+			path = strings.TrimPrefix(node.Path, rootPath+"/")
+			name = node.Name
+		}
+		fmt.Fprintf(&structure, "%d\x00%s\x00%s\x00%s\n", depth, node.Type, name, normalizedValue(node.Instance, scene))
+
+		if node.Script != "" {
+			fingerprint.Properties[path+"\x00script = "+normalizedValue(node.Script, scene)] = true
+		}
+		for key, value := range node.Properties {
+			if key == "script" || strings.HasPrefix(key, "metadata/_edit_") {
+				continue
+			}
+			fingerprint.Properties[path+"\x00"+key+" = "+normalizedValue(value, scene)] = true
+		}
+		return WalkContinue
+	})
+	fingerprint.Structure = sha256.Sum256([]byte(structure.String()))
+	return fingerprint
+}
+
+// propertySimilarity returns the share of the property entries of two scenes
+// that are identical, 1 when neither sets any property
+func propertySimilarity(a, b *SceneFingerprint) float64 {
+	common := 0
+	for entry := range a.Properties {
+		if b.Properties[entry] {
+			common++
+		}
+	}
+	total := len(a.Properties) + len(b.Properties) - common
+	if total == 0 {
+		return 1
+	}
+	return float64(common) / float64(total)
+}
+
+// findDuplicateScenes groups the scenes with the same tree whose properties
+// are at least similarity alike. Scenes with fewer than minNodes nodes are ignored.
+func findDuplicateScenes(fingerprints []*SceneFingerprint, similarity float64, minNodes int) []*DuplicateScenes {
+	byStructure := make(map[[sha256.Size]byte][]*SceneFingerprint)
+	var order [][sha256.Size]byte
+	for _, fingerprint := range fingerprints {
+		if fingerprint.NodeCount < minNodes {
+			continue
+		}
+		if _, exists := byStructure[fingerprint.Structure]; !exists {
+			order = append(order, fingerprint.Structure)
+		}
+		byStructure[fingerprint.Structure] = append(byStructure[fingerprint.Structure], fingerprint)
+	}
+
+	var duplicates []*DuplicateScenes
+	for _, hash := range order {
+		candidates := byStructure[hash]
+		if len(candidates) < 2 {
+			continue
+		}
+
+		// Join scenes that are similar enough, transitively
+		group := make([]int, len(candidates))
+		for i := range group {
+			group[i] = i
+		}
+		var find func(i int) int
+		find = func(i int) int {
+			if group[i] != i {
+				group[i] = find(group[i])
+			}
+			return group[i]
+		}
+		for i := range candidates {
+			for j := i + 1; j < len(candidates); j++ {
+				if propertySimilarity(candidates[i], candidates[j]) >= similarity {
+					group[find(j)] = find(i)
+				}
+			}
+		}
+
+		members := make(map[int][]*SceneFingerprint)
+		var roots []int
+		for i, candidate := range candidates {
+			root := find(i)
+			if _, exists := members[root]; !exists {
+				roots = append(roots, root)
+			}
+			members[root] = append(members[root], candidate)
+		}
+		for _, root := range roots {
+			scenes := members[root]
+			if len(scenes) < 2 {
+				continue
+			}
+			duplicate := &DuplicateScenes{NodeCount: scenes[0].NodeCount, Similarity: 1}
+			for i, scene := range scenes {
+				duplicate.Files = append(duplicate.Files, scene.File)
+				for _, other := range scenes[i+1:] {
+					duplicate.Similarity = min(duplicate.Similarity, propertySimilarity(scene, other))
+				}
+			}
+			duplicates = append(duplicates, duplicate)
+		}
+	}
+
+	// Largest copies first
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].NodeCount*len(duplicates[i].Files) > duplicates[j].NodeCount*len(duplicates[j].Files)
+	})
+	return duplicates
+}
+
+// printDuplicateScenes displays the groups of near-identical scenes
+func printDuplicateScenes(out io.Writer, duplicates []*DuplicateScenes) {
+	fmt.Fprintln(out, "=== Duplicate Scenes ===")
+	if len(duplicates) == 0 {
+		fmt.Fprintln(out, "None")
+	}
+	for i, duplicate := range duplicates {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%d scenes with the same %d-node tree (properties %.0f%% identical):\n",
+			len(duplicate.Files), duplicate.NodeCount, duplicate.Similarity*100)
+		for _, file := range duplicate.Files {
+			fmt.Fprintf(out, "  %s\n", file)
+		}
+	}
+}
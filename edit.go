@@ -0,0 +1,465 @@
+package gdquery
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SceneEditor performs structural edits on a text scene for Go tools doing
+// scene surgery. It edits the sections of the file, so the parts it does not
+// touch keep their formatting, and keeps the parent paths, connections, editable
+// paths and NodePath values of the nodes consistent. Node paths are relative
+// to the scene root, "." being the root itself.
+type SceneEditor struct {
+	text *sceneText
+}
+
+// NewSceneEditor returns an editor for text scene content
+func NewSceneEditor(content string) (*SceneEditor, error) {
+	if _, err := ParseReader(strings.NewReader(content), "", ParseOptions{}); err != nil {
+		return nil, err
+	}
+	return &SceneEditor{text: splitSceneText(content)}, nil
+}
+
+// OpenSceneEditor returns an editor for a scene file
+func OpenSceneEditor(file string) (*SceneEditor, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewSceneEditor(string(content))
+}
+
+// String serializes the edited scene, with the load_steps of the header
+// updated and the sections separated as Godot writes them
+func (e *SceneEditor) String() string {
+	updateLoadSteps(e.text.Sections)
+	return strings.Join(append(append([]string{}, e.text.Preamble...), formatSceneSections(e.text.Sections)), "\n")
+}
+
+// Scene parses the edited scene
+func (e *SceneEditor) Scene() (*GodotScene, error) {
+	return ParseReader(strings.NewReader(e.String()), "", ParseOptions{})
+}
+
+// Save writes the edited scene to file, keeping the mode of an existing file
+func (e *SceneEditor) Save(file string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode()
+	}
+	return os.WriteFile(file, []byte(e.String()), mode)
+}
+
+// nodeSection returns the section of the node at path, or nil
+func (e *SceneEditor) nodeSection(path string) *sceneSection {
+	for _, section := range e.text.nodeSections() {
+		if sceneNodePath(section.Header) == path {
+			return section
+		}
+	}
+	return nil
+}
+
+// inSubtree reports whether the node path is path or one of its descendants
+func inSubtree(nodePath, path string) bool {
+	return path == "." || nodePath == path || strings.HasPrefix(nodePath, path+"/")
+}
+
+// childNodePath returns the path of the child name of the node at parent
+func childNodePath(parent, name string) string {
+	if parent == "." {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// subtreeEnd returns the index of the section after the last node section of
+// the subtree at path, where children of the node are appended
+func (e *SceneEditor) subtreeEnd(path string) int {
+	end := 0
+	for i, section := range e.text.Sections {
+		if strings.HasPrefix(section.Header, "[node") && inSubtree(sceneNodePath(section.Header), path) {
+			end = i + 1
+		}
+	}
+	return end
+}
+
+// checkNewChild reports an invalid name or a sibling with the same name under parent
+func (e *SceneEditor) checkNewChild(parent, name string) error {
+	if name == "" || strings.ContainsAny(name, invalidNodeNameChars) {
+		return fmt.Errorf("invalid node name %q", name)
+	}
+	if e.nodeSection(parent) == nil {
+		return fmt.Errorf("node not found: %s", parent)
+	}
+	if e.nodeSection(childNodePath(parent, name)) != nil {
+		return fmt.Errorf("%s already has a child named %s", parent, name)
+	}
+	return nil
+}
+
+// AddChild appends a node of type nodeType named name to the children of parent
+func (e *SceneEditor) AddChild(parent, name, nodeType string) error {
+	if err := e.checkNewChild(parent, name); err != nil {
+		return err
+	}
+	section := &sceneSection{Header: fmt.Sprintf(`[node name=%q type=%q parent=%q]`, name, nodeType, parent)}
+	at := e.subtreeEnd(parent)
+	e.text.Sections = append(e.text.Sections[:at], append([]*sceneSection{section}, e.text.Sections[at:]...)...)
+	return nil
+}
+
+// SetProperty sets the raw value of a property of the node at path
+func (e *SceneEditor) SetProperty(path, key, value string) error {
+	section := e.nodeSection(path)
+	if section == nil {
+		return fmt.Errorf("node not found: %s", path)
+	}
+	section.SetProperty(key, value)
+	return nil
+}
+
+// RemoveProperty deletes a property of the node at path, reporting whether it was set
+func (e *SceneEditor) RemoveProperty(path, key string) (bool, error) {
+	section := e.nodeSection(path)
+	if section == nil {
+		return false, fmt.Errorf("node not found: %s", path)
+	}
+	return section.RemoveProperty(key), nil
+}
+
+// Property returns the raw value of a property of the node at path
+func (e *SceneEditor) Property(path, key string) (string, bool) {
+	section := e.nodeSection(path)
+	if section == nil {
+		return "", false
+	}
+	return section.Property(key)
+}
+
+// SetType changes the type of the node at path; an empty type removes it
+func (e *SceneEditor) SetType(path, nodeType string) error {
+	section := e.nodeSection(path)
+	if section == nil {
+		return fmt.Errorf("node not found: %s", path)
+	}
+	if _, exists := headerAttr(section.Header, "type"); !exists && nodeType != "" {
+		// Godot writes the type right after the name
+		if name := headerAttrRe("name").FindStringIndex(section.Header); name != nil {
+			section.Header = section.Header[:name[1]] + ` type=""` + section.Header[name[1]:]
+		}
+	}
+	section.Header = setHeaderAttr(section.Header, "type", nodeType)
+	return nil
+}
+
+// AddInstance appends an instance of the scene at the res:// path resPath named
+// name to the children of parent, declaring the scene as an ext_resource when
+// it is not one yet
+func (e *SceneEditor) AddInstance(parent, name, resPath string) error {
+	if err := e.checkNewChild(parent, name); err != nil {
+		return err
+	}
+	ref := e.extResourceRef("PackedScene", resPath)
+	section := &sceneSection{Header: fmt.Sprintf(`[node name=%q parent=%q instance=%s]`, name, parent, ref)}
+	at := e.subtreeEnd(parent)
+	e.text.Sections = append(e.text.Sections[:at], append([]*sceneSection{section}, e.text.Sections[at:]...)...)
+	return nil
+}
+
+// extResourceRef returns the ExtResource() reference of the resource at
+// resPath, adding an ext_resource after the last one when it is not declared
+func (e *SceneEditor) extResourceRef(resourceType, resPath string) string {
+	insertAt, count, maxID := 0, 0, 0
+	for i, section := range e.text.Sections {
+		if !strings.HasPrefix(section.Header, "[ext_resource") {
+			if i == 0 {
+				insertAt = 1
+			}
+			continue
+		}
+		id, _ := headerAttr(section.Header, "id")
+		if path, _ := headerAttr(section.Header, "path"); path == resPath {
+			return extResourceRefString(id, e.format())
+		}
+		insertAt, count = i+1, count+1
+		if n, err := strconv.Atoi(id); err == nil && n > maxID {
+			maxID = n
+		}
+	}
+
+	// Godot 4 ids are "<n>_<random>"; Godot 3 ids are numbers
+	var header, id string
+	if e.format() >= 3 {
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(resPath)))
+		id = fmt.Sprintf("%d_%s", count+1, hash[:5])
+		header = fmt.Sprintf(`[ext_resource type=%q path=%q id=%q]`, resourceType, resPath, id)
+	} else {
+		id = strconv.Itoa(maxID + 1)
+		header = fmt.Sprintf(`[ext_resource path=%q type=%q id=%s]`, resPath, resourceType, id)
+	}
+	e.text.Sections = append(e.text.Sections[:insertAt], append([]*sceneSection{{Header: header}}, e.text.Sections[insertAt:]...)...)
+	return extResourceRefString(id, e.format())
+}
+
+// extResourceRefString formats a reference to an ext_resource id as written in the format
+func extResourceRefString(id string, format int) string {
+	if format >= 3 {
+		return fmt.Sprintf("ExtResource(%q)", id)
+	}
+	return fmt.Sprintf("ExtResource( %s )", id)
+}
+
+// format returns the format= of the [gd_scene] header, 3 when it is missing
+func (e *SceneEditor) format() int {
+	if len(e.text.Sections) > 0 {
+		if value, exists := headerAttr(e.text.Sections[0].Header, "format"); exists {
+			if format, err := strconv.Atoi(value); err == nil {
+				return format
+			}
+		}
+	}
+	return 3
+}
+
+// Connect appends a signal connection. binds is the raw bound arguments array, or empty.
+func (e *SceneEditor) Connect(signal, from, to, method string, flags int, binds string) error {
+	for _, path := range []string{from, to} {
+		if e.nodeSection(path) == nil {
+			return fmt.Errorf("node not found: %s", path)
+		}
+	}
+	if e.connectionIndex(signal, from, to, method) >= 0 {
+		return fmt.Errorf("%s is already connected from %s to %s (%s)", signal, from, to, method)
+	}
+	header := fmt.Sprintf(`[connection signal=%q from=%q to=%q method=%q`, signal, from, to, method)
+	if flags != 0 {
+		header += fmt.Sprintf(" flags=%d", flags)
+	}
+	if binds != "" {
+		header += " binds=" + binds
+	}
+	e.text.Sections = append(e.text.Sections, &sceneSection{Header: header + "]"})
+	return nil
+}
+
+// Disconnect removes a signal connection
+func (e *SceneEditor) Disconnect(signal, from, to, method string) error {
+	i := e.connectionIndex(signal, from, to, method)
+	if i < 0 {
+		return fmt.Errorf("%s is not connected from %s to %s (%s)", signal, from, to, method)
+	}
+	e.text.Sections = append(e.text.Sections[:i], e.text.Sections[i+1:]...)
+	return nil
+}
+
+// connectionIndex returns the index of the section of a connection, or -1
+func (e *SceneEditor) connectionIndex(signal, from, to, method string) int {
+	for i, section := range e.text.Sections {
+		if !strings.HasPrefix(section.Header, "[connection") {
+			continue
+		}
+		connection := parseConnection(section.Header)
+		if connection.Signal == signal && connection.From == from && connection.To == to && connection.Method == method {
+			return i
+		}
+	}
+	return -1
+}
+
+// Remove deletes the node at path with its descendants, the connections and
+// editable paths involving them, and the resources only they used
+func (e *SceneEditor) Remove(path string) error {
+	if path == "." {
+		return fmt.Errorf("cannot remove the root node")
+	}
+	if e.nodeSection(path) == nil {
+		return fmt.Errorf("node not found: %s", path)
+	}
+
+	var removed, kept []*sceneSection
+	for _, section := range e.text.Sections {
+		drop := false
+		switch {
+		case strings.HasPrefix(section.Header, "[node"):
+			drop = inSubtree(sceneNodePath(section.Header), path)
+		case strings.HasPrefix(section.Header, "[connection"):
+			from, _ := headerAttr(section.Header, "from")
+			to, _ := headerAttr(section.Header, "to")
+			drop = inSubtree(from, path) || inSubtree(to, path)
+		case strings.HasPrefix(section.Header, "[editable"):
+			editable, _ := headerAttr(section.Header, "path")
+			drop = inSubtree(editable, path)
+		}
+		if drop {
+			removed = append(removed, section)
+		} else {
+			kept = append(kept, section)
+		}
+	}
+
+	// Drop the resources the removed sections used that nothing else uses
+	removedRefs := make(map[string]bool)
+	collectResourceRefs(e.text, removed, removedRefs)
+	var referencing []*sceneSection
+	for _, section := range kept {
+		if _, isResource := resourceSectionRef(section); !isResource {
+			referencing = append(referencing, section)
+		}
+	}
+	keptRefs := make(map[string]bool)
+	collectResourceRefs(e.text, referencing, keptRefs)
+
+	sections := kept[:0]
+	for _, section := range kept {
+		if ref, isResource := resourceSectionRef(section); isResource && removedRefs[ref] && !keptRefs[ref] {
+			continue
+		}
+		sections = append(sections, section)
+	}
+	e.text.Sections = sections
+	return nil
+}
+
+// Reparent moves the node at path with its descendants under newParent, as
+// its last child. Parent and owner paths, connections, editable paths and the
+// NodePath values of all nodes are rewritten to the new paths.
+func (e *SceneEditor) Reparent(path, newParent string) error {
+	if path == "." {
+		return fmt.Errorf("cannot reparent the root node")
+	}
+	section := e.nodeSection(path)
+	if section == nil {
+		return fmt.Errorf("node not found: %s", path)
+	}
+	if inSubtree(newParent, path) {
+		return fmt.Errorf("cannot move %s under itself", path)
+	}
+	name, _ := headerAttr(section.Header, "name")
+	if err := e.checkNewChild(newParent, name); err != nil {
+		return err
+	}
+	newPath := childNodePath(newParent, name)
+	move := func(nodePath string) string {
+		if !inSubtree(nodePath, path) {
+			return nodePath
+		}
+		return newPath + strings.TrimPrefix(nodePath, path)
+	}
+
+	// NodePath values are relative to their node: resolve them with the old
+	// paths and make them relative again with the new ones
+	for _, node := range e.text.nodeSections() {
+		oldNode := sceneNodePath(node.Header)
+		newNode := move(oldNode)
+		for i, line := range node.Lines {
+			node.Lines[i] = nodePathValueRe.ReplaceAllStringFunc(line, func(value string) string {
+				nodePath := nodePathValueRe.FindStringSubmatch(value)[1]
+				rewritten := rebaseRelativeNodePath(nodePath, oldNode, newNode, move)
+				return `NodePath("` + rewritten + `")`
+			})
+		}
+	}
+
+	var moved, rest []*sceneSection
+	for _, s := range e.text.Sections {
+		switch {
+		case strings.HasPrefix(s.Header, "[node"):
+			nodePath := sceneNodePath(s.Header)
+			if s == section {
+				s.Header = setHeaderAttr(setHeaderAttr(s.Header, "parent", newParent), "index", "")
+			} else if parent, exists := headerAttr(s.Header, "parent"); exists {
+				s.Header = setHeaderAttr(s.Header, "parent", move(parent))
+			}
+			if owner, exists := headerAttr(s.Header, "owner"); exists {
+				s.Header = setHeaderAttr(s.Header, "owner", move(owner))
+			}
+			if inSubtree(nodePath, path) {
+				moved = append(moved, s)
+				continue
+			}
+		case strings.HasPrefix(s.Header, "[connection"):
+			for _, key := range []string{"from", "to"} {
+				if value, exists := headerAttr(s.Header, key); exists {
+					s.Header = setHeaderAttr(s.Header, key, move(value))
+				}
+			}
+		case strings.HasPrefix(s.Header, "[editable"):
+			if value, exists := headerAttr(s.Header, "path"); exists {
+				s.Header = setHeaderAttr(s.Header, "path", move(value))
+			}
+		}
+		rest = append(rest, s)
+	}
+
+	e.text.Sections = rest
+	at := e.subtreeEnd(newParent)
+	e.text.Sections = append(rest[:at], append(moved, rest[at:]...)...)
+	return nil
+}
+
+// rebaseRelativeNodePath rewrites a NodePath written on the node at oldNode
+// for the node now at newNode, its target following move. Absolute paths,
+// unique names and paths leaving the scene are kept as written.
+func rebaseRelativeNodePath(nodePath, oldNode, newNode string, move func(string) string) string {
+	nodePart, subname := nodePath, ""
+	if i := strings.Index(nodePath, ":"); i >= 0 {
+		nodePart, subname = nodePath[:i], nodePath[i:]
+	}
+	if nodePart == "" || strings.HasPrefix(nodePart, "/") || strings.HasPrefix(nodePart, "%") {
+		return nodePath
+	}
+
+	// Resolve the target relative to the scene root
+	target := nodePathSegments(oldNode)
+	for _, segment := range strings.Split(nodePart, "/") {
+		switch segment {
+		case "", ".":
+		case "..":
+			if len(target) == 0 {
+				return nodePath
+			}
+			target = target[:len(target)-1]
+		default:
+			target = append(target, segment)
+		}
+	}
+	targetPath := "."
+	if len(target) > 0 {
+		targetPath = strings.Join(target, "/")
+	}
+	newTarget := move(targetPath)
+	if newTarget == targetPath && newNode == oldNode {
+		return nodePath
+	}
+
+	// Make it relative to the new position of the node
+	from, to := nodePathSegments(newNode), nodePathSegments(newTarget)
+	common := 0
+	for common < len(from) && common < len(to) && from[common] == to[common] {
+		common++
+	}
+	var segments []string
+	for range from[common:] {
+		segments = append(segments, "..")
+	}
+	segments = append(segments, to[common:]...)
+	if len(segments) == 0 {
+		return "." + subname
+	}
+	return strings.Join(segments, "/") + subname
+}
+
+// nodePathSegments splits a node path relative to the scene root into names, none for the root
+func nodePathSegments(path string) []string {
+	if path == "." || path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
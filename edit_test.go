@@ -0,0 +1,136 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const editTestScene = `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Script" path="res://hud.gd" id="1_a"]
+[ext_resource type="Texture2D" path="res://icon.png" id="2_b"]
+
+[sub_resource type="AtlasTexture" id="AtlasTexture_1"]
+atlas = ExtResource("2_b")
+
+[node name="Main" type="Node2D"]
+
+[node name="HUD" type="CanvasLayer" parent="."]
+script = ExtResource("1_a")
+score_path = NodePath("Score")
+
+[node name="Score" type="Label" parent="HUD"]
+text = "0"
+
+[node name="Icon" type="TextureRect" parent="HUD/Score"]
+texture = SubResource("AtlasTexture_1")
+target = NodePath("../../../Player")
+
+[node name="Player" type="Node2D" parent="."]
+hud = NodePath("../HUD/Score:text")
+
+[connection signal="changed" from="HUD/Score" to="HUD" method="_on_score_changed"]
+[connection signal="died" from="Player" to="HUD" method="_on_player_died"]
+`
+
+func TestSceneEditorReparent(t *testing.T) {
+	editor, err := NewSceneEditor(editTestScene)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if err := editor.Reparent("HUD/Score", "Player"); err != nil {
+		t.Fatalf("Reparent error: %v", err)
+	}
+
+	content := editor.String()
+	for _, expected := range []string{
+		`score_path = NodePath("../Player/Score")`,
+		"[node name=\"Player\" type=\"Node2D\" parent=\".\"]\nhud = NodePath(\"Score:text\")\n\n[node name=\"Score\" type=\"Label\" parent=\"Player\"]",
+		`[node name="Icon" type="TextureRect" parent="Player/Score"]`,
+		`target = NodePath("../..")`,
+		`[connection signal="changed" from="Player/Score" to="HUD" method="_on_score_changed"]`,
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %s in:\n%s", expected, content)
+		}
+	}
+
+	scene, err := editor.Scene()
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if node := findNodeByPath(scene, "Main/Player/Score/Icon"); node == nil || node.ParentNode().Name != "Score" {
+		t.Errorf("Icon not moved with Score")
+	}
+
+	for _, tt := range []struct{ path, parent string }{
+		{".", "HUD"},
+		{"Player", "Player/Score"},
+		{"Missing", "."},
+		{"HUD", "Missing"},
+	} {
+		if err := editor.Reparent(tt.path, tt.parent); err == nil {
+			t.Errorf("Expected an error moving %s under %s", tt.path, tt.parent)
+		}
+	}
+	if err := editor.AddChild(".", "Score", "Node"); err != nil {
+		t.Fatalf("AddChild error: %v", err)
+	}
+	if err := editor.Reparent("Score", "Player"); err == nil {
+		t.Error("Expected a name collision error")
+	}
+}
+
+func TestSceneEditorAddRemove(t *testing.T) {
+	editor, err := NewSceneEditor(editTestScene)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if err := editor.AddChild("HUD", "Lives", "Label"); err != nil {
+		t.Fatalf("AddChild error: %v", err)
+	}
+	if err := editor.SetProperty("HUD/Lives", "text", `"3"`); err != nil {
+		t.Fatalf("SetProperty error: %v", err)
+	}
+	for _, name := range []string{"Lives", "", "a/b"} {
+		if err := editor.AddChild("HUD", name, "Label"); err == nil {
+			t.Errorf("Expected an error adding %q", name)
+		}
+	}
+	if !strings.Contains(editor.String(), "[node name=\"Lives\" type=\"Label\" parent=\"HUD\"]\ntext = \"3\"\n\n[node name=\"Player\"") {
+		t.Errorf("Lives not added after the HUD subtree:\n%s", editor.String())
+	}
+
+	if err := editor.Remove("HUD/Score"); err != nil {
+		t.Fatalf("Remove error: %v", err)
+	}
+	content := editor.String()
+	for _, unexpected := range []string{`name="Score"`, `name="Icon"`, "AtlasTexture", "icon.png", `signal="changed"`} {
+		if strings.Contains(content, unexpected) {
+			t.Errorf("Unexpected %s in:\n%s", unexpected, content)
+		}
+	}
+	if !strings.HasPrefix(content, "[gd_scene load_steps=2 format=3]") || !strings.Contains(content, `signal="died"`) {
+		t.Errorf("Unexpected content:\n%s", content)
+	}
+	if err := editor.Remove("."); err == nil {
+		t.Error("Expected an error removing the root")
+	}
+
+	file := filepath.Join(t.TempDir(), "main.tscn")
+	if err := editor.Save(file); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	saved, err := OpenSceneEditor(file)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	if saved.String() != content {
+		t.Errorf("Saved scene differs:\n%s", saved.String())
+	}
+	if written, _ := os.ReadFile(file); string(written) != content {
+		t.Error("Unexpected saved content")
+	}
+}
@@ -0,0 +1,226 @@
+package gdquery
+
+import (
+	"os"
+	"strings"
+)
+
+// Expand option
+var expandInstances = false
+
+// instanceResourceSeparator joins the res:// path of an instanced scene and the
+// id of one of its resources, naming the resource in the expanding scene
+const instanceResourceSeparator = "::"
+
+// expandSceneInstances replaces every instanced scene of scene by its nodes,
+// giving the effective tree the scene has at runtime. The root of an instanced
+// scene merges into the instancing node (its type and properties, overridden
+// by the instancing scene), its children are added before the children given
+// by the instancing scene, and override sections of the instancing scene merge
+// into the nodes they name. Each node's Origin is the res:// path of the scene
+// file defining it. The resources of instanced scenes are added to the scene
+// under "<res path>::<id>" ids, so the references of their nodes still resolve.
+func expandSceneInstances(scene *GodotScene, root string) {
+	visiting := map[string]bool{resToFS(root, fsToRes(root, scene.File)): true}
+	expandInstancesOf(scene, root, visiting, newInstanceTypeCache())
+}
+
+// expandInstancesOf expands the instances of scene, visiting holding the scene
+// files of the chain of instances being expanded
+func expandInstancesOf(scene *GodotScene, root string, visiting map[string]bool, cache *instanceTypeCache) {
+	if scene.RootNode == nil {
+		return
+	}
+	sceneRes := fsToRes(root, scene.File)
+	for _, node := range scene.AllNodes {
+		node.Origin = sceneRes
+	}
+
+	expanded := false
+	for _, node := range scene.AllNodes {
+		file := instancedScenePath(node, scene, root)
+		if file == "" || visiting[file] || len(visiting) >= maxInstanceDepth {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		instanced, err := ParseFile(file, sceneParseOptions())
+		if err != nil || instanced.RootNode == nil {
+			logger.Debug("Instanced scene not expanded", "path", file, "error", err)
+			continue
+		}
+		resolveInstanceTypes(instanced, root, cache, logger)
+		visiting[file] = true
+		expandInstancesOf(instanced, root, visiting, cache)
+		delete(visiting, file)
+
+		graftInstance(scene, node, instanced, fsToRes(root, file))
+		expanded = true
+	}
+	if !expanded {
+		return
+	}
+	updateNodePaths(scene.RootNode)
+
+	// Nodes of the scene under a node of an instance (parent="Player/Sprite")
+	// could not be placed before the instance was expanded
+	for _, node := range scene.AllNodes {
+		if node.Parent == "" || node.Parent == "." || node.parent == nil {
+			continue
+		}
+		expected := scene.RootNode.Path + "/" + node.Parent
+		if node.parent.Path == expected {
+			continue
+		}
+		target := findNodeByExactPath(scene.RootNode, expected)
+		if target == nil || target == node || node.IsAncestorOf(target) {
+			continue
+		}
+		detachChild(node)
+		attachChild(target, node)
+		updateNodePaths(scene.RootNode)
+	}
+
+	scene.AllNodes = scene.AllNodes[:0]
+	scene.Walk(func(n *GodotNode, depth int) WalkAction {
+		scene.AllNodes = append(scene.AllNodes, n)
+		return WalkContinue
+	})
+}
+
+// graftInstance merges the expanded instanced scene into the instancing node
+func graftInstance(scene *GodotScene, node *GodotNode, instanced *GodotScene, instancedRes string) {
+	// Import the resources of the instanced scene under prefixed ids
+	prefix := instancedRes + instanceResourceSeparator
+	for id, resource := range instanced.ExtResources {
+		imported := *resource
+		imported.ID = prefix + id
+		if imported.Path != "" {
+			imported.Path = normalizeResPath(instancedRes, imported.Path)
+		}
+		scene.ExtResources[imported.ID] = &imported
+	}
+	for id, resource := range instanced.SubResources {
+		imported := *resource
+		imported.ID = prefix + id
+		scene.SubResources[imported.ID] = &imported
+	}
+	rewrite := func(value string) string {
+		return resourceRefRe.ReplaceAllStringFunc(value, func(ref string) string {
+			matches := resourceRefRe.FindStringSubmatch(ref)
+			return matches[1] + `Resource("` + prefix + matches[2] + `")`
+		})
+	}
+	instanced.Walk(func(n *GodotNode, depth int) WalkAction {
+		n.Script = rewrite(n.Script)
+		n.Instance = rewrite(n.Instance)
+		for key, value := range n.Properties {
+			n.Properties[key] = rewrite(value)
+		}
+		return WalkContinue
+	})
+
+	// The instanced root merges into the instancing node
+	instancedRoot := instanced.RootNode
+	if node.InstanceOf == "" {
+		node.InstanceOf = resolveResourcePath(node.Instance, scene)
+	}
+	if node.Type == "" {
+		node.Type = instancedRoot.Type
+	}
+	if node.Script == "" {
+		node.Script = instancedRoot.Script
+	}
+	node.Properties = mergeProperties(instancedRoot.Properties, node.Properties)
+
+	own := node.Children
+	node.Children = nil
+	for _, child := range instancedRoot.Children {
+		child.parent = node
+		node.Children = append(node.Children, child)
+	}
+	for _, child := range own {
+		attachChild(node, child)
+	}
+}
+
+// mergeProperties returns the base properties overridden by overrides
+func mergeProperties(base, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
+
+// attachChild adds child to the children of parent. A child without a type or
+// instance named like an existing child is an override section: its properties
+// and children merge into the existing child.
+func attachChild(parent, child *GodotNode) {
+	if child.Type == "" && child.Instance == "" {
+		for _, existing := range parent.Children {
+			if existing.OriginalName != child.OriginalName || existing == child {
+				continue
+			}
+			existing.Properties = mergeProperties(existing.Properties, child.Properties)
+			if child.Script != "" {
+				existing.Script = child.Script
+			}
+			for _, grandchild := range child.Children {
+				attachChild(existing, grandchild)
+			}
+			return
+		}
+	}
+	child.parent = parent
+	parent.Children = insertChild(parent.Children, child)
+}
+
+// detachChild removes a node from the children of its parent
+func detachChild(node *GodotNode) {
+	parent := node.parent
+	if parent == nil {
+		return
+	}
+	for i, child := range parent.Children {
+		if child == node {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+			break
+		}
+	}
+	node.parent = nil
+}
+
+// updateNodePaths sets the path of every node below root from the tree
+func updateNodePaths(root *GodotNode) {
+	root.Walk(func(n *GodotNode, depth int) WalkAction {
+		if n.parent == nil || n == root {
+			if n.Path == "" {
+				n.Path = n.Name
+			}
+		} else {
+			n.Path = n.parent.Path + "/" + n.Name
+		}
+		return WalkContinue
+	})
+}
+
+// findNodeByExactPath returns the node below root with the given full path, or nil
+func findNodeByExactPath(root *GodotNode, path string) *GodotNode {
+	var found *GodotNode
+	root.Walk(func(n *GodotNode, depth int) WalkAction {
+		switch {
+		case n.Path == path:
+			found = n
+			return WalkStop
+		case !strings.HasPrefix(path, n.Path+"/"):
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+	return found
+}
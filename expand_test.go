@@ -0,0 +1,103 @@
+package gdquery
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandInstances(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": "",
+		"chars/player.tscn": `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Script" path="player.gd" id="1_p"]
+[ext_resource type="PackedScene" path="res://chars/weapon.tscn" id="2_w"]
+[ext_resource type="Texture2D" path="res://chars/player.png" id="3_t"]
+
+[node name="Player" type="CharacterBody2D"]
+script = ExtResource("1_p")
+speed = 100
+
+[node name="Sprite" type="Sprite2D" parent="."]
+texture = ExtResource("3_t")
+
+[node name="Weapon" parent="." instance=ExtResource("2_w")]
+`,
+		"chars/weapon.tscn": `[gd_scene format=3]
+
+[node name="Weapon" type="Node2D"]
+
+[node name="Muzzle" type="Marker2D" parent="."]
+`,
+		"cycle.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://cycle.tscn" id="1_c"]
+
+[node name="Cycle" type="Node"]
+
+[node name="Again" parent="." instance=ExtResource("1_c")]
+`,
+		"level.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://chars/player.tscn" id="1_x"]
+
+[node name="Level" type="Node2D"]
+
+[node name="Hero" parent="." instance=ExtResource("1_x")]
+speed = 200
+
+[node name="Sprite" parent="Hero"]
+modulate = Color(1, 0, 0, 1)
+
+[node name="Flash" type="Sprite2D" parent="Hero/Weapon/Muzzle"]
+
+[node name="Label" type="Label" parent="Hero"]
+`,
+	})
+
+	expandInstances = true
+	defer func() { expandInstances = false }()
+
+	scene, err := parseSceneFile(filepath.Join(root, "level.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	var got []string
+	for _, node := range scene.AllNodes {
+		got = append(got, fmt.Sprintf("%s (%s) %s", node.Path, node.Type, node.Origin))
+	}
+	expected := []string{
+		"Level (Node2D) res://level.tscn",
+		"Level/Hero (CharacterBody2D) res://level.tscn",
+		"Level/Hero/Sprite (Sprite2D) res://chars/player.tscn",
+		"Level/Hero/Weapon (Node2D) res://chars/player.tscn",
+		"Level/Hero/Weapon/Muzzle (Marker2D) res://chars/weapon.tscn",
+		"Level/Hero/Weapon/Muzzle/Flash (Sprite2D) res://level.tscn",
+		"Level/Hero/Label (Label) res://level.tscn",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Unexpected expanded tree:\n%s", strings.Join(got, "\n"))
+	}
+
+	hero, sprite := scene.AllNodes[1], scene.AllNodes[2]
+	if hero.Properties["speed"] != "200" || resolveResourcePath(hero.Script, scene) != "res://chars/player.gd" {
+		t.Errorf("Unexpected instance root: %v, script %s", hero.Properties, hero.Script)
+	}
+	if sprite.Properties["modulate"] != "Color(1, 0, 0, 1)" || resolveResourcePath(sprite.Properties["texture"], scene) != "res://chars/player.png" {
+		t.Errorf("Unexpected merged override: %v", sprite.Properties)
+	}
+	if json := nodeToJSON(sprite); json.Origin != "res://chars/player.tscn" {
+		t.Errorf("Expected the origin in JSON, got %q", json.Origin)
+	}
+
+	// Instance cycles stop at the scene being expanded
+	scene, err = parseSceneFile(filepath.Join(root, "cycle.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(scene.AllNodes) != 2 {
+		t.Errorf("Expected the cycle not to be expanded, got %d nodes", len(scene.AllNodes))
+	}
+}
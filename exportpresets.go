@@ -0,0 +1,233 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gdquery/query"
+	"github.com/spf13/cobra"
+)
+
+// ExportPreset represents a [preset.N] section of export_presets.cfg
+type ExportPreset struct {
+	Index          int
+	Name           string
+	Platform       string
+	ExportPath     string
+	ExportFilter   string
+	IncludeFilters []string
+	ExcludeFilters []string
+	ExportFiles    []string
+}
+
+// ExportConflict is an excluded file referenced by a file that is exported
+type ExportConflict struct {
+	Preset     *ExportPreset
+	From       string
+	Referenced string
+	Reason     string
+}
+
+// presetSectionRe matches [preset.N] section names (not [preset.N.options])
+var presetSectionRe = regexp.MustCompile(`^preset\.(\d+)$`)
+
+// parseExportPresets parses export_presets.cfg
+func parseExportPresets(path string) ([]*ExportPreset, error) {
+	config, err := parseConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var presets []*ExportPreset
+	for _, section := range config.Sections {
+		matches := presetSectionRe.FindStringSubmatch(section.Name)
+		if matches == nil {
+			continue
+		}
+
+		preset := &ExportPreset{
+			Name:           config.GetString(section.Name, "name"),
+			Platform:       config.GetString(section.Name, "platform"),
+			ExportPath:     config.GetString(section.Name, "export_path"),
+			ExportFilter:   config.GetString(section.Name, "export_filter"),
+			IncludeFilters: splitFilterList(config.GetString(section.Name, "include_filter")),
+			ExcludeFilters: splitFilterList(config.GetString(section.Name, "exclude_filter")),
+		}
+		preset.Index, _ = strconv.Atoi(matches[1])
+		if files, exists := section.Values["export_files"]; exists {
+			preset.ExportFiles = parseStringList(files)
+		}
+		presets = append(presets, preset)
+	}
+
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Index < presets[j].Index
+	})
+
+	return presets, nil
+}
+
+// splitFilterList splits a comma-separated include/exclude filter
+func splitFilterList(filter string) []string {
+	var filters []string
+	for _, f := range strings.Split(filter, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			filters = append(filters, f)
+		}
+	}
+	return filters
+}
+
+// matchExportFilter returns the first filter matching resPath, the way the
+// export dialog does: against the full path or the file name only
+func matchExportFilter(filters []string, resPath string) string {
+	rel := strings.TrimPrefix(resPath, "res://")
+	name := filepath.Base(rel)
+
+	for _, filter := range filters {
+		pattern := strings.TrimPrefix(filter, "res://")
+		if query.Wildcard(pattern, rel) || query.Wildcard(pattern, name) {
+			return filter
+		}
+	}
+	return ""
+}
+
+// exclusionReason explains why a file is left out of the preset, or returns ""
+func (p *ExportPreset) exclusionReason(resPath string) string {
+	if filter := matchExportFilter(p.ExcludeFilters, resPath); filter != "" {
+		return fmt.Sprintf("exclude_filter %q", filter)
+	}
+	if p.ExportFilter == "exclude" {
+		for _, file := range p.ExportFiles {
+			if file == resPath {
+				return "excluded in export_files"
+			}
+		}
+	}
+	return ""
+}
+
+// exportRoots returns the files a preset exports before dependencies are added
+func (p *ExportPreset) exportRoots(graph *DependencyGraph) []string {
+	switch p.ExportFilter {
+	case "scenes", "resources":
+		return p.ExportFiles
+	default:
+		// all_resources and exclude export every resource of the project
+		return graph.Files
+	}
+}
+
+// checkExportPreset finds excluded files that exported files still reference
+func checkExportPreset(preset *ExportPreset, graph *DependencyGraph) []ExportConflict {
+	var conflicts []ExportConflict
+	visited := make(map[string]bool)
+	var queue []string
+
+	for _, file := range preset.exportRoots(graph) {
+		if preset.exclusionReason(file) == "" && !visited[file] {
+			visited[file] = true
+			queue = append(queue, file)
+		}
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range graph.Edges[current] {
+			if !strings.HasPrefix(edge.To, "res://") {
+				continue
+			}
+			if reason := preset.exclusionReason(edge.To); reason != "" {
+				conflicts = append(conflicts, ExportConflict{
+					Preset:     preset,
+					From:       current,
+					Referenced: edge.To,
+					Reason:     reason,
+				})
+				continue
+			}
+			if !visited[edge.To] {
+				visited[edge.To] = true
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// printExportPreset displays the settings of a preset
+func printExportPreset(out io.Writer, preset *ExportPreset) {
+	fmt.Fprintf(out, "=== Preset %d: %s (%s) ===\n", preset.Index, preset.Name, preset.Platform)
+	fmt.Fprintf(out, "Export Path: %s\n", preset.ExportPath)
+	fmt.Fprintf(out, "Export Filter: %s\n", preset.ExportFilter)
+	fmt.Fprintf(out, "Include Filter: %s\n", strings.Join(preset.IncludeFilters, ", "))
+	fmt.Fprintf(out, "Exclude Filter: %s\n", strings.Join(preset.ExcludeFilters, ", "))
+	if len(preset.ExportFiles) > 0 {
+		fmt.Fprintf(out, "Export Files: %d\n", len(preset.ExportFiles))
+		for _, file := range preset.ExportFiles {
+			fmt.Fprintf(out, "  %s\n", file)
+		}
+	}
+	fmt.Fprintln(out)
+}
+
+var exportPresetsCmd = &cobra.Command{
+	Use:          "export-presets [project dir]",
+	Short:        "List export presets and check their filters",
+	Long:         `Parse export_presets.cfg, list presets with their include/exclude filters, and check that files left out of an export are not referenced by exported scenes.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		root := findProjectRoot(dir)
+		presetsFile := filepath.Join(root, "export_presets.cfg")
+		if _, err := os.Stat(presetsFile); os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s", presetsFile)
+		}
+
+		presets, err := parseExportPresets(presetsFile)
+		if err != nil {
+			return fmt.Errorf("parse error: %v", err)
+		}
+
+		graph, err := buildDependencyGraph(root)
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+
+		var conflicts []ExportConflict
+		for _, preset := range presets {
+			printExportPreset(out, preset)
+			conflicts = append(conflicts, checkExportPreset(preset, graph)...)
+		}
+
+		if len(conflicts) > 0 {
+			fmt.Fprintln(out, "=== Excluded but Referenced ===")
+			for _, conflict := range conflicts {
+				fmt.Fprintf(out, "[%s] %s -> %s (%s)\n", conflict.Preset.Name, conflict.From, conflict.Referenced, conflict.Reason)
+			}
+			return fmt.Errorf("found %d excluded file reference(s)", len(conflicts))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportPresetsCmd)
+}
@@ -0,0 +1,105 @@
+package gdquery
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const testExportPresets = `[preset.0]
+
+name="Linux"
+platform="Linux/X11"
+runnable=true
+export_filter="all_resources"
+include_filter="*.json"
+exclude_filter="debug/*, *.md"
+export_path="build/game.x86_64"
+
+[preset.0.options]
+
+binary_format/embed_pck=false
+
+[preset.1]
+
+name="Demo"
+platform="Web"
+export_filter="scenes"
+include_filter=""
+exclude_filter=""
+export_files=PackedStringArray("res://main.tscn",
+"res://menu.tscn")
+export_path="build/index.html"
+`
+
+func TestExportPresets(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"export_presets.cfg": testExportPresets,
+		"main.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://debug/overlay.tscn" id="1_a"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Overlay" parent="." instance=ExtResource("1_a")]
+`,
+		"debug/overlay.tscn": `[gd_scene format=3]
+
+[node name="Overlay" type="Control"]
+`,
+		"menu.tscn": `[gd_scene format=3]
+
+[node name="Menu" type="Control"]
+`,
+	})
+
+	presets, err := parseExportPresets(filepath.Join(root, "export_presets.cfg"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	if len(presets) != 2 {
+		t.Fatalf("Expected 2 presets, got: %d", len(presets))
+	}
+
+	if presets[0].Name != "Linux" || len(presets[0].ExcludeFilters) != 2 {
+		t.Errorf("Preset 0 parsed incorrectly: %+v", presets[0])
+	}
+
+	if len(presets[1].ExportFiles) != 2 || presets[1].ExportFiles[1] != "res://menu.tscn" {
+		t.Errorf("Multiline export_files parsed incorrectly: %v", presets[1].ExportFiles)
+	}
+
+	graph, err := buildDependencyGraph(root)
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+
+	conflicts := checkExportPreset(presets[0], graph)
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got: %d", len(conflicts))
+	}
+	if conflicts[0].From != "res://main.tscn" || conflicts[0].Referenced != "res://debug/overlay.tscn" {
+		t.Errorf("Unexpected conflict: %+v", conflicts[0])
+	}
+
+	if conflicts := checkExportPreset(presets[1], graph); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts for preset without exclude filter, got: %v", conflicts)
+	}
+}
+
+func TestUnquoteValue(t *testing.T) {
+	tests := map[string]string{
+		`"C:\\new"`:           `C:\new`,
+		`"line\nbreak"`:       "line\nbreak",
+		`"say \"hi\""`:        `say "hi"`,
+		`"trailing \\"`:       `trailing \`,
+		`"tab\there\\\\n"`:    "tab\there\\\\n",
+		`"unknown \q"`:        `unknown \q`,
+		`PackedStringArray()`: `PackedStringArray()`,
+	}
+	for value, expected := range tests {
+		if got := unquoteValue(value); got != expected {
+			t.Errorf("unquoteValue(%s) = %q, expected %q", value, got, expected)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// rootStringRe matches string values holding an absolute node path. NodePath
+// arguments and strings inside strings (built-in script sources) are skipped.
+var rootStringRe = regexp.MustCompile(`(?:^|[^\\(])"(/root/[^"\\]*)"`)
+
+// Kinds of external path references
+const (
+	externalAbsolute = "absolute"      // NodePath("/root/...")
+	externalOutside  = "outside-scene" // relative NodePath climbing above the scene root
+	externalString   = "string"        // "/root/..." string, usually passed to get_node()
+)
+
+// ExternalPathRef is a property referring to a node outside of its scene
+type ExternalPathRef struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Owner    string `json:"owner"` // node path, or sub_resource id
+	Property string `json:"property"`
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+}
+
+// message describes the reference and why it is a portability risk
+func (r *ExternalPathRef) message() string {
+	switch r.Kind {
+	case externalOutside:
+		return fmt.Sprintf("%s %s: NodePath %s leaves the scene and breaks when it is instanced elsewhere", r.Owner, r.Property, r.Path)
+	case externalString:
+		return fmt.Sprintf("%s %s: string %s is an absolute node path tied to the running tree", r.Owner, r.Property, r.Path)
+	}
+	return fmt.Sprintf("%s %s: absolute NodePath %s only resolves when the scene sits at that place in the running tree", r.Owner, r.Property, r.Path)
+}
+
+// nodePathLeavesScene reports whether a relative NodePath written on a node at
+// depth (0 for the scene root) climbs above the scene root
+func nodePathLeavesScene(path string, depth int) bool {
+	path, _, _ = strings.Cut(path, ":")
+	for _, segment := range strings.Split(path, "/") {
+		switch {
+		case segment == "" || segment == ".":
+		case segment == "..":
+			depth--
+			if depth < 0 {
+				return true
+			}
+		case strings.HasPrefix(segment, "%"):
+			return false
+		default:
+			depth++
+		}
+	}
+	return false
+}
+
+// findExternalPaths lists the properties of a scene or resource file that
+// refer to nodes outside of it: absolute NodePaths and /root/ strings in
+// nodes and sub_resources, and NodePaths of nodes climbing above the root.
+// Paths of sub_resources such as animation tracks are relative to a node
+// chosen at runtime and are only checked for absolute paths.
+func findExternalPaths(resPath, content string) []*ExternalPathRef {
+	text := splitSceneText(content)
+	line := len(text.Preamble)
+	rootName := ""
+	var refs []*ExternalPathRef
+	for _, section := range text.Sections {
+		line++
+		owner, depth, isNode := "", 0, false
+		switch strings.Fields(section.Header)[0] {
+		case "[node":
+			isNode = true
+			name, _ := headerAttr(section.Header, "name")
+			parent, hasParent := headerAttr(section.Header, "parent")
+			switch {
+			case !hasParent:
+				rootName, owner = name, name
+			case parent == ".":
+				owner, depth = rootName+"/"+name, 1
+			default:
+				owner, depth = rootName+"/"+parent+"/"+name, len(strings.Split(parent, "/"))+1
+			}
+		case "[sub_resource":
+			id, _ := headerAttr(section.Header, "id")
+			owner = "sub_resource " + id
+		case "[resource":
+			owner = "resource"
+		default:
+			line += len(section.Lines)
+			continue
+		}
+
+		key := ""
+		var pending strings.Builder
+		for _, sectionLine := range section.Lines {
+			line++
+			if pending.Len() > 0 {
+				pending.WriteString("\n" + sectionLine)
+			} else if k, value, ok := splitPropertyLine(sectionLine); ok && k != "" {
+				key = k
+				pending.WriteString(value)
+			}
+			if pending.Len() > 0 && valueComplete(pending.String()) {
+				pending.Reset()
+			}
+			if key == "" {
+				continue
+			}
+
+			for _, matches := range nodePathValueRe.FindAllStringSubmatch(sectionLine, -1) {
+				kind := ""
+				if strings.HasPrefix(matches[1], "/") {
+					kind = externalAbsolute
+				} else if isNode && nodePathLeavesScene(matches[1], depth) {
+					kind = externalOutside
+				}
+				if kind != "" {
+					refs = append(refs, &ExternalPathRef{File: resPath, Line: line, Owner: owner, Property: key, Path: matches[1], Kind: kind})
+				}
+			}
+			for _, matches := range rootStringRe.FindAllStringSubmatch(sectionLine, -1) {
+				refs = append(refs, &ExternalPathRef{File: resPath, Line: line, Owner: owner, Property: key, Path: matches[1], Kind: externalString})
+			}
+		}
+	}
+	return refs
+}
+
+// findProjectExternalPaths scans the scenes and resources under dir
+func findProjectExternalPaths(root, dir string) ([]*ExternalPathRef, error) {
+	files, err := findProjectFiles(dir, sceneExtensions)
+	if err != nil {
+		return nil, err
+	}
+	var refs []*ExternalPathRef
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			logger.Warn("Skipping file", "path", file, "error", err)
+			continue
+		}
+		refs = append(refs, findExternalPaths(fsToRes(root, file), string(content))...)
+	}
+	return refs, nil
+}
+
+// checkExternalPaths reports properties referring to nodes outside of their scene
+func checkExternalPaths(ctx *LintContext) []LintFinding {
+	refs, err := findProjectExternalPaths(ctx.Root, ctx.Dir)
+	if err != nil {
+		logger.Warn("Scene scan failed", "error", err)
+		return nil
+	}
+	var findings []LintFinding
+	for _, ref := range refs {
+		findings = append(findings, LintFinding{File: ref.File, Line: ref.Line, Message: ref.message()})
+	}
+	return findings
+}
+
+// printExternalPaths displays the references grouped by file
+func printExternalPaths(out io.Writer, refs []*ExternalPathRef) {
+	files := 0
+	for i, ref := range refs {
+		if i == 0 || refs[i-1].File != ref.File {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintln(out, ref.File)
+			files++
+		}
+		fmt.Fprintf(out, "  %d: %s %s = %s (%s)\n", ref.Line, ref.Owner, ref.Property, ref.Path, ref.Kind)
+	}
+	if len(refs) > 0 {
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "%d external path reference(s) in %d file(s)\n", len(refs), files)
+}
+
+var externalPathsCmd = &cobra.Command{
+	Use:   "external-paths [project dir]",
+	Short: "List properties referring to nodes outside of their scene",
+	Long: `List the properties of scenes and resources that refer to nodes outside of their scene, a
+portability risk since they break when the scene is instanced elsewhere:
+
+  absolute       NodePath("/root/...") values, in nodes and sub_resources (animation tracks)
+  outside-scene  relative NodePaths of nodes that climb above the scene root ("../../HUD")
+  string         "/root/..." strings, usually exported for get_node()
+
+The external-node-path lint rule reports the same references.`,
+	Example: `  gdq external-paths path/to/project
+  gdq external-paths -o json levels/`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		refs, err := findProjectExternalPaths(findProjectRoot(dir), dir)
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+
+		if outputFormat == "json" {
+			if refs == nil {
+				refs = []*ExternalPathRef{}
+			}
+			return writeJSON(out, refs)
+		}
+		printExternalPaths(out, refs)
+		return nil
+	},
+}
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "external-node-path",
+		Description: "Absolute NodePaths, /root/ strings and NodePaths leaving the scene, which break when the scene is instanced elsewhere",
+		Check:       checkExternalPaths,
+	})
+	rootCmd.AddCommand(externalPathsCmd)
+}
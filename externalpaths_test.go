@@ -0,0 +1,43 @@
+package gdquery
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const externalPathsScene = `[gd_scene load_steps=2 format=3]
+
+[sub_resource type="Animation" id="Animation_fade"]
+tracks/0/path = NodePath("/root/Game/HUD:modulate")
+tracks/1/path = NodePath("Sprite:modulate")
+
+[node name="Enemy" type="CharacterBody2D"]
+target = NodePath("../Player")
+hud_path = "/root/Game/HUD"
+
+[node name="Sprite" type="Sprite2D" parent="."]
+
+[node name="Aim" type="RayCast2D" parent="Sprite"]
+follow = NodePath("../..")
+camera = NodePath("/root/Game/Camera2D")
+exclude = [NodePath("../../../Wall"), NodePath("%Hitbox")]
+label = "not /root/ a path"
+`
+
+func TestFindExternalPaths(t *testing.T) {
+	var got []string
+	for _, ref := range findExternalPaths("res://enemy.tscn", externalPathsScene) {
+		got = append(got, fmt.Sprintf("%d %s %s %s %s", ref.Line, ref.Owner, ref.Property, ref.Path, ref.Kind))
+	}
+	expected := []string{
+		"4 sub_resource Animation_fade tracks/0/path /root/Game/HUD:modulate absolute",
+		"8 Enemy target ../Player outside-scene",
+		"9 Enemy hud_path /root/Game/HUD string",
+		"15 Enemy/Sprite/Aim camera /root/Game/Camera2D absolute",
+		"16 Enemy/Sprite/Aim exclude ../../../Wall outside-scene",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected references:\n%s", strings.Join(got, "\n"))
+	}
+}
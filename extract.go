@@ -0,0 +1,385 @@
+package gdquery
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Extract command options
+var extractReplace = false
+
+// resourceRefRe matches ExtResource("id") and SubResource("id") references (Godot 3: ExtResource( 1 ))
+var resourceRefRe = regexp.MustCompile(`\b(Ext|Sub)Resource\(\s*"?([^")\s]*)"?\s*\)`)
+
+// headerAttrRe matches a key="value" or key=value attribute of a section header
+func headerAttrRe(key string) *regexp.Regexp {
+	return regexp.MustCompile(`\s\b` + key + `=("[^"]*"|[^\s\]]+)`)
+}
+
+// setHeaderAttr replaces the value of an attribute of a section header, or removes it when value is empty
+func setHeaderAttr(header, key, value string) string {
+	re := headerAttrRe(key)
+	if value == "" {
+		return re.ReplaceAllString(header, "")
+	}
+	return re.ReplaceAllLiteralString(header, " "+key+"="+strconv.Quote(value))
+}
+
+// headerAttr returns the unquoted value of an attribute of a section header
+func headerAttr(header, key string) (string, bool) {
+	matches := headerAttrRe(key).FindStringSubmatch(header)
+	if matches == nil {
+		return "", false
+	}
+	return strings.Trim(matches[1], `"`), true
+}
+
+// rebaseNodePath converts a node path relative to the scene root into a path
+// relative to the node at base, reporting false for paths outside of it
+func rebaseNodePath(path, base string) (string, bool) {
+	switch {
+	case path == base:
+		return ".", true
+	case strings.HasPrefix(path, base+"/"):
+		return strings.TrimPrefix(path, base+"/"), true
+	}
+	return "", false
+}
+
+// sceneNodePath returns the path of a node section relative to the scene root, as used by parent= and connections
+func sceneNodePath(header string) string {
+	name, _ := headerAttr(header, "name")
+	parent, _ := headerAttr(header, "parent")
+	switch parent {
+	case "":
+		return "."
+	case ".":
+		return name
+	}
+	return parent + "/" + name
+}
+
+// headerNodePath returns the path of a parsed node as its section header
+// gives it, like sceneNodePath, whatever tree the parser built
+func headerNodePath(node *GodotNode) string {
+	switch node.Parent {
+	case "":
+		return "."
+	case ".":
+		return node.OriginalName
+	}
+	return node.Parent + "/" + node.OriginalName
+}
+
+// collectResourceRefs adds the resources referenced by sections, and by the
+// sub_resources they reference, to refs ("Ext id" / "Sub id")
+func collectResourceRefs(text *sceneText, sections []*sceneSection, refs map[string]bool) {
+	pending := append([]*sceneSection{}, sections...)
+	for len(pending) > 0 {
+		section := pending[0]
+		pending = pending[1:]
+		content := section.Header + "\n" + strings.Join(section.Lines, "\n")
+		for _, matches := range resourceRefRe.FindAllStringSubmatch(content, -1) {
+			ref := matches[1] + " " + matches[2]
+			if refs[ref] {
+				continue
+			}
+			refs[ref] = true
+			if matches[1] == "Sub" {
+				if sub := text.subResourceSection(matches[2]); sub != nil {
+					pending = append(pending, sub)
+				}
+			}
+		}
+	}
+}
+
+// resourceSectionRef returns the "Ext id" / "Sub id" reference key of a resource section
+func resourceSectionRef(section *sceneSection) (string, bool) {
+	kind := "Ext"
+	if strings.HasPrefix(section.Header, "[sub_resource") {
+		kind = "Sub"
+	} else if !strings.HasPrefix(section.Header, "[ext_resource") {
+		return "", false
+	}
+	matches := sectionIDRe.FindStringSubmatch(section.Header)
+	if matches == nil {
+		return "", false
+	}
+	return kind + " " + matches[1] + matches[2], true
+}
+
+// ExtractResult describes a subtree extracted into its own scene
+type ExtractResult struct {
+	Root        string // path of the extracted node relative to the original scene root
+	Nodes       int
+	Resources   int
+	Connections int
+	Scene       string // content of the new scene
+	Original    string // content of the original scene with the subtree replaced by an instance, when replacing
+}
+
+// extractSubtree moves the subtree at nodePath of the scene file into a new
+// scene saved as newRes, carrying the ext_resources and sub_resources it uses
+// and the connections between its nodes. With replace, the original gets an
+// instance of the new scene in place of the subtree.
+func extractSubtree(file, nodePath, newRes string, replace bool) (*ExtractResult, error) {
+	scene, err := ParseFile(file, ParseOptions{Logger: logger})
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	text := splitSceneText(string(content))
+	nodeSections := text.nodeSections()
+	if len(nodeSections) != len(scene.AllNodes) {
+		return nil, fmt.Errorf("node sections do not match parsed nodes (%d != %d)", len(nodeSections), len(scene.AllNodes))
+	}
+
+	// The path is matched exactly against the node paths of the file, relative to the root
+	base := strings.Trim(nodePath, "/")
+	if base == "" || base == "." {
+		return nil, fmt.Errorf("cannot extract the root node")
+	}
+	moved := make(map[*sceneSection]bool)
+	var targetSection *sceneSection
+	for _, section := range nodeSections {
+		path := sceneNodePath(section.Header)
+		if path == base {
+			targetSection = section
+		}
+		if path != "." && inSubtree(path, base) {
+			moved[section] = true
+		}
+	}
+	if targetSection == nil {
+		return nil, fmt.Errorf("node not found: %s", nodePath)
+	}
+	result := &ExtractResult{Root: base, Nodes: len(moved)}
+
+	// Nodes become relative to the extracted node; connections and editable
+	// instances follow when all their nodes are in the subtree
+	var subtree []*sceneSection
+	var nodes, connections []*sceneSection
+	for _, section := range text.Sections {
+		header := section.Header
+		switch {
+		case moved[section]:
+			if section == targetSection {
+				header = setHeaderAttr(setHeaderAttr(header, "parent", ""), "index", "")
+			} else {
+				parent, _ := headerAttr(header, "parent")
+				rebased, _ := rebaseNodePath(parent, base)
+				header = setHeaderAttr(header, "parent", rebased)
+			}
+			nodes = append(nodes, &sceneSection{Header: header, Lines: section.Lines})
+		case strings.HasPrefix(header, "[connection"):
+			from, _ := headerAttr(header, "from")
+			to, _ := headerAttr(header, "to")
+			newFrom, fromInside := rebaseNodePath(from, base)
+			newTo, toInside := rebaseNodePath(to, base)
+			if !fromInside || !toInside {
+				continue
+			}
+			header = setHeaderAttr(setHeaderAttr(header, "from", newFrom), "to", newTo)
+			connections = append(connections, &sceneSection{Header: header, Lines: section.Lines})
+			result.Connections++
+		case strings.HasPrefix(header, "[editable"):
+			path, _ := headerAttr(header, "path")
+			rebased, inside := rebaseNodePath(path, base)
+			if !inside || rebased == "." {
+				continue
+			}
+			connections = append(connections, &sceneSection{Header: setHeaderAttr(header, "path", rebased), Lines: section.Lines})
+		default:
+			continue
+		}
+		moved[section] = true
+		subtree = append(subtree, section)
+	}
+
+	refs := make(map[string]bool)
+	collectResourceRefs(text, subtree, refs)
+
+	root := findProjectRoot(file)
+	fileRes := fsToRes(root, file)
+	var resources []*sceneSection
+	for _, section := range text.Sections {
+		ref, ok := resourceSectionRef(section)
+		if !ok || !refs[ref] {
+			continue
+		}
+		header := section.Header
+		// Godot 3 paths may be relative to the original scene
+		if path, exists := headerAttr(header, "path"); exists {
+			header = setHeaderAttr(header, "path", normalizeResPath(fileRes, path))
+		}
+		resources = append(resources, &sceneSection{Header: header, Lines: section.Lines})
+	}
+	result.Resources = len(resources)
+
+	sceneHeader := "[gd_scene"
+	if loadStepsRe.MatchString(text.Sections[0].Header) {
+		sceneHeader += " load_steps=0"
+	}
+	if scene.Format > 0 {
+		sceneHeader += fmt.Sprintf(" format=%d", scene.Format)
+	}
+	sceneHeader += "]"
+	sections := append([]*sceneSection{{Header: sceneHeader}}, resources...)
+	sections = append(append(sections, nodes...), connections...)
+	updateLoadSteps(sections)
+	result.Scene = formatSceneSections(sections)
+
+	if replace {
+		result.Original = replaceWithInstance(text, moved, targetSection, newRes, scene.Format)
+	}
+	return result, nil
+}
+
+// replaceWithInstance removes the moved sections from the original scene and
+// instances the new scene in place of the extracted node. Resources only used
+// by the moved sections are removed.
+func replaceWithInstance(text *sceneText, moved map[*sceneSection]bool, targetSection *sceneSection, newRes string, format int) string {
+	instanceSection := &sceneSection{}
+	var kept []*sceneSection
+	for _, section := range text.Sections {
+		switch {
+		case section == targetSection:
+			kept = append(kept, instanceSection)
+		case !moved[section]:
+			kept = append(kept, section)
+		}
+	}
+
+	// Drop the resources the moved sections used that nothing else uses
+	movedRefs := make(map[string]bool)
+	var movedSections []*sceneSection
+	for section := range moved {
+		movedSections = append(movedSections, section)
+	}
+	collectResourceRefs(text, movedSections, movedRefs)
+	keptRefs := make(map[string]bool)
+	var referencing []*sceneSection
+	for _, section := range kept {
+		if _, isResource := resourceSectionRef(section); !isResource {
+			referencing = append(referencing, section)
+		}
+	}
+	collectResourceRefs(text, referencing, keptRefs)
+
+	var sections []*sceneSection
+	insertAt, extCount, maxID := 1, 0, 0
+	for _, section := range kept {
+		ref, isResource := resourceSectionRef(section)
+		if isResource && movedRefs[ref] && !keptRefs[ref] {
+			continue
+		}
+		sections = append(sections, section)
+		if isResource && strings.HasPrefix(ref, "Ext ") {
+			insertAt = len(sections)
+			extCount++
+			if id, err := strconv.Atoi(strings.TrimPrefix(ref, "Ext ")); err == nil && id > maxID {
+				maxID = id
+			}
+		}
+	}
+
+	// Godot 4 ids are "<n>_<random>"; Godot 3 ids are numbers
+	var extResource, instance string
+	if format >= 3 {
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(newRes)))
+		id := fmt.Sprintf("%d_%s", extCount+1, hash[:5])
+		extResource = fmt.Sprintf(`[ext_resource type="PackedScene" path=%q id=%q]`, newRes, id)
+		instance = fmt.Sprintf(`ExtResource(%q)`, id)
+	} else {
+		extResource = fmt.Sprintf(`[ext_resource path=%q type="PackedScene" id=%d]`, newRes, maxID+1)
+		instance = fmt.Sprintf(`ExtResource( %d )`, maxID+1)
+	}
+	sections = append(sections[:insertAt], append([]*sceneSection{{Header: extResource}}, sections[insertAt:]...)...)
+
+	node := parseNodeHeader(targetSection.Header)
+	instanceSection.Header = fmt.Sprintf(`[node name=%q parent=%q`, node.Name, node.Parent)
+	if index, exists := headerAttr(targetSection.Header, "index"); exists {
+		instanceSection.Header += fmt.Sprintf(` index=%q`, index)
+	}
+	instanceSection.Header += " instance=" + instance + "]"
+
+	updateLoadSteps(sections)
+	return strings.Join(append(text.Preamble, formatSceneSections(sections)), "\n")
+}
+
+var extractCmd = &cobra.Command{
+	Use:   "extract [--replace] <tscn file> <node path> <new tscn file>",
+	Short: "Save a node and its children as a new scene",
+	Long: `Save the subtree of a node as a new scene ("Save Branch as Scene" in the editor), with the
+ext_resources and sub_resources it uses and the signal connections between its nodes. The node
+path is relative to the scene root, as in parent= attributes, and must match exactly.
+With --replace, the subtree is replaced in the original scene by an instance of the new scene,
+and resources only the subtree used are removed. Connections between the subtree and the rest
+of the scene stay in the original: node paths below the instance are unchanged.`,
+	Example:      `  gdq extract --replace levels/level_1.tscn HUD/Score ui/score.tscn`,
+	Args:         cobra.ExactArgs(3),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		file, nodePath, newFile := args[0], args[1], args[2]
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s", file)
+		}
+		if _, err := os.Stat(newFile); err == nil {
+			return fmt.Errorf("destination already exists: %s", newFile)
+		}
+
+		root := findProjectRoot(file)
+		absNew, err := filepath.Abs(newFile)
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(root, absNew); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("new scene must be inside the project: %s", newFile)
+		}
+		newRes := fsToRes(root, absNew)
+
+		result, err := extractSubtree(file, nodePath, newRes, extractReplace)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(absNew), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(absNew, []byte(result.Scene), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Extracted %s to %s: %d node(s), %d resource(s), %d connection(s)\n",
+			result.Root, newRes, result.Nodes, result.Resources, result.Connections)
+
+		if extractReplace {
+			info, err := os.Stat(file)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(file, []byte(result.Original), info.Mode()); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Replaced %s in %s with an instance of %s\n", result.Root, file, newRes)
+		}
+		return nil
+	},
+}
+
+func init() {
+	extractCmd.Flags().BoolVar(&extractReplace, "replace", false, "Replace the subtree in the original scene with an instance of the new scene")
+	rootCmd.AddCommand(extractCmd)
+}
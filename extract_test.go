@@ -0,0 +1,169 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const extractScene = `[gd_scene load_steps=5 format=3 uid="uid://level"]
+
+[ext_resource type="Script" path="res://level.gd" id="1_a"]
+[ext_resource type="Texture2D" path="res://icon.png" id="2_b"]
+[ext_resource type="FontFile" path="res://font.ttf" id="3_c"]
+
+[sub_resource type="LabelSettings" id="LabelSettings_x"]
+font = ExtResource("3_c")
+
+[node name="Level" type="Node2D"]
+script = ExtResource("1_a")
+
+[node name="HUD" type="CanvasLayer" parent="."]
+
+[node name="Score" type="Label" parent="HUD"]
+label_settings = SubResource("LabelSettings_x")
+
+[node name="Icon" type="TextureRect" parent="HUD/Score"]
+texture = ExtResource("2_b")
+
+[node name="Player" type="Sprite2D" parent="."]
+texture = ExtResource("2_b")
+
+[connection signal="resized" from="HUD/Score" to="HUD/Score/Icon" method="_on_resized"]
+[connection signal="hit" from="Player" to="HUD/Score" method="_on_hit"]
+`
+
+func TestExtractSubtree(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{"levels/level.tscn": extractScene})
+	file := filepath.Join(root, "levels", "level.tscn")
+
+	result, err := extractSubtree(file, "HUD/Score", "res://ui/score.tscn", true)
+	if err != nil {
+		t.Fatalf("Extract error: %v", err)
+	}
+
+	expectedScene := `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Texture2D" path="res://icon.png" id="2_b"]
+[ext_resource type="FontFile" path="res://font.ttf" id="3_c"]
+
+[sub_resource type="LabelSettings" id="LabelSettings_x"]
+font = ExtResource("3_c")
+
+[node name="Score" type="Label"]
+label_settings = SubResource("LabelSettings_x")
+
+[node name="Icon" type="TextureRect" parent="."]
+texture = ExtResource("2_b")
+
+[connection signal="resized" from="." to="Icon" method="_on_resized"]
+`
+	if result.Scene != expectedScene {
+		t.Errorf("Unexpected new scene:\n%s", result.Scene)
+	}
+	if result.Nodes != 2 || result.Resources != 3 || result.Connections != 1 {
+		t.Errorf("Unexpected counts: %+v", result)
+	}
+
+	// The font is only used by the subtree; the texture is still used by Player
+	if strings.Contains(result.Original, "font.ttf") || strings.Contains(result.Original, "LabelSettings") {
+		t.Errorf("Expected unused resources to be removed:\n%s", result.Original)
+	}
+	for _, expected := range []string{
+		"[gd_scene load_steps=4 format=3 uid=\"uid://level\"]\n",
+		"[ext_resource type=\"Texture2D\" path=\"res://icon.png\" id=\"2_b\"]\n[ext_resource type=\"PackedScene\" path=\"res://ui/score.tscn\" id=\"3_",
+		"[node name=\"Score\" parent=\"HUD\" instance=ExtResource(\"3_",
+		"[connection signal=\"hit\" from=\"Player\" to=\"HUD/Score\" method=\"_on_hit\"]\n",
+	} {
+		if !strings.Contains(result.Original, expected) {
+			t.Errorf("Expected %q in the original:\n%s", expected, result.Original)
+		}
+	}
+	if strings.Contains(result.Original, "Icon") || strings.Contains(result.Original, "_on_resized") {
+		t.Errorf("Expected the subtree to be removed from the original:\n%s", result.Original)
+	}
+
+	// The replaced scene still parses into the same top-level tree
+	if err := os.WriteFile(file, []byte(result.Original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scene, err := ParseTscnFile(file)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	score := findNodeByPath(scene, "Level/HUD/Score")
+	if len(scene.AllNodes) != 4 || score == nil || score.Instance == "" {
+		t.Errorf("Unexpected replaced scene: %d nodes", len(scene.AllNodes))
+	}
+
+	if _, err := extractSubtree(file, "Level", "res://x.tscn", false); err == nil {
+		t.Error("Expected an error extracting the root node")
+	}
+}
+
+func TestExtractSubtreeGodot3(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{"main.tscn": `[gd_scene load_steps=2 format=2]
+
+[ext_resource path="icon.png" type="Texture" id=1]
+
+[node name="Main" type="Node2D"]
+
+[node name="Box" type="Node2D" parent="."]
+
+[node name="Sprite" type="Sprite" parent="Box"]
+texture = ExtResource( 1 )
+`})
+
+	result, err := extractSubtree(filepath.Join(root, "main.tscn"), "Box", "res://box.tscn", true)
+	if err != nil {
+		t.Fatalf("Extract error: %v", err)
+	}
+	if !strings.Contains(result.Scene, `[ext_resource path="res://icon.png" type="Texture" id=1]`) {
+		t.Errorf("Expected the relative path to become absolute:\n%s", result.Scene)
+	}
+	expectedOriginal := `[gd_scene load_steps=2 format=2]
+
+[ext_resource path="res://box.tscn" type="PackedScene" id=1]
+
+[node name="Main" type="Node2D"]
+
+[node name="Box" parent="." instance=ExtResource( 1 )]
+`
+	if result.Original != expectedOriginal {
+		t.Errorf("Unexpected original:\n%s", result.Original)
+	}
+}
+
+func TestExtractSubtreeRepeatedNames(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("test", "sample.tscn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := writeProjectFiles(t, map[string]string{"e.tscn": string(content)})
+	file := filepath.Join(root, "e.tscn")
+
+	// Control/scrapScene/Control repeats the name of the root's child Control
+	result, err := extractSubtree(file, "Control/scrapScene", "res://sub/scrap.tscn", true)
+	if err != nil {
+		t.Fatalf("Extract error: %v", err)
+	}
+	for _, header := range []string{
+		`[node name="scrapinfp" type="TextureRect" parent="Control"]`,
+		`[node name="Button" type="Button" parent="Control"]`,
+		`[node name="TextureRect" type="TextureRect" parent="Control"]`,
+		`[node name="Button" type="Button" parent="centerItem/ItemList/vbox/Node5"]`,
+	} {
+		if !strings.Contains(result.Scene, header+"\n") {
+			t.Errorf("Expected %s in the new scene", header)
+		}
+	}
+	if strings.Contains(result.Original, `parent="Control/scrapScene/`) {
+		t.Errorf("Expected no node left below the instance:\n%s", result.Original)
+	}
+
+	// Paths are matched exactly, not by suffix
+	if _, err := extractSubtree(file, "scrapScene", "res://sub/scrap.tscn", false); err == nil {
+		t.Error("Expected an error for a path that is not relative to the root")
+	}
+}
@@ -0,0 +1,325 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Fmt command options
+var fmtCheck = false
+
+// FormatIssue is a difference between a text scene and the way Godot writes it.
+// Malformed lines cannot be fixed and keep the file from being rewritten.
+type FormatIssue struct {
+	Line      int
+	Message   string
+	Malformed bool
+}
+
+// formatSectionHeader rewrites a section header as Godot does: the tag and the
+// key=value attributes separated by single spaces, without spaces inside the brackets
+func formatSectionHeader(header string) (string, error) {
+	inner := strings.TrimSpace(header)
+	if !strings.HasPrefix(inner, "[") || !strings.HasSuffix(inner, "]") {
+		return "", fmt.Errorf("missing closing bracket")
+	}
+	inner = strings.TrimSpace(inner[1 : len(inner)-1])
+
+	end := strings.IndexAny(inner, " \t")
+	if end < 0 {
+		end = len(inner)
+	}
+	tag := inner[:end]
+	if tag == "" || strings.ContainsAny(tag, "=\"[]") {
+		return "", fmt.Errorf("missing section name")
+	}
+	parts := []string{tag}
+
+	rest := strings.TrimLeft(inner[end:], " \t")
+	for rest != "" {
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return "", fmt.Errorf("expected key=value, found %q", rest)
+		}
+		key := strings.TrimRight(rest[:eq], " \t")
+		if strings.ContainsAny(key, " \t\"[]") {
+			return "", fmt.Errorf("expected key=value, found %q", rest)
+		}
+		rest = strings.TrimLeft(rest[eq+1:], " \t")
+
+		// The value ends at the first blank outside of strings and brackets
+		size, depth, inString := 0, 0, false
+		for size < len(rest) && (depth > 0 || inString || (rest[size] != ' ' && rest[size] != '\t')) {
+			depth, inString = scanValueNesting(rest[size:size+1], depth, inString)
+			if rest[size] == '\\' && inString && size+1 < len(rest) {
+				size++
+			}
+			size++
+		}
+		if size == 0 {
+			return "", fmt.Errorf("missing value of %s", key)
+		}
+		if depth != 0 || inString {
+			return "", fmt.Errorf("unterminated value of %s", key)
+		}
+		parts = append(parts, key+"="+rest[:size])
+		rest = strings.TrimLeft(rest[size:], " \t")
+	}
+	return "[" + strings.Join(parts, " ") + "]", nil
+}
+
+// formatSceneText rewrites text scene content in Godot's canonical style: LF line
+// endings, canonical section headers, "key = value" properties without indentation
+// or trailing whitespace, blank lines between sections as formatSceneSections writes
+// them, and a final newline. Multiline values are kept as they are, apart from
+// trailing whitespace outside of strings.
+func formatSceneText(content string) (string, []FormatIssue) {
+	var issues []FormatIssue
+	report := func(line int, format string, args ...any) {
+		issues = append(issues, FormatIssue{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if i := strings.Index(content, "\r\n"); i >= 0 {
+		report(strings.Count(content[:i], "\n")+1, "CRLF line endings")
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+
+	lines := strings.Split(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		report(len(lines), "missing newline at end of file")
+	}
+
+	var preamble []string
+	var sections []*sceneSection
+	var current *sceneSection
+	previousKind := ""
+	blanks, firstBlank := 0, 0
+	depth, inString := 0, false
+
+	for i, line := range lines {
+		number := i + 1
+
+		// Continuation lines of a multiline value
+		if depth > 0 || inString {
+			if !inString {
+				if trimmed := strings.TrimRight(line, " \t"); trimmed != line {
+					report(number, "trailing whitespace")
+					line = trimmed
+				}
+			}
+			depth, inString = scanValueNesting(line, depth, inString)
+			if current == nil {
+				preamble = append(preamble, line)
+			} else {
+				current.Lines = append(current.Lines, line)
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			if blanks == 0 {
+				firstBlank = number
+			}
+			blanks++
+			continue
+		}
+		if indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]; indent != "" {
+			if strings.Contains(indent, "\t") {
+				report(number, "indented with tabs")
+			} else {
+				report(number, "indented with spaces")
+			}
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			header, err := formatSectionHeader(trimmed)
+			if err != nil {
+				issues = append(issues, FormatIssue{Line: number, Message: "malformed section header: " + err.Error(), Malformed: true})
+				header = trimmed
+			} else if header != trimmed {
+				report(number, "section header %s written as %s", trimmed, header)
+			}
+
+			kind := strings.Fields(header)[0]
+			expected := 0
+			if (current != nil || len(preamble) > 0) && (kind != previousKind || !groupedSectionKinds[kind]) {
+				expected = 1
+			}
+			if blanks != expected {
+				report(number, "%d blank line(s) before section, expected %d", blanks, expected)
+			}
+			current = &sceneSection{Header: header}
+			sections = append(sections, current)
+			previousKind = kind
+			blanks = 0
+			continue
+		}
+
+		if blanks > 0 {
+			report(firstBlank, "blank line inside section")
+			blanks = 0
+		}
+
+		formatted := strings.TrimLeft(line, " \t")
+		if eq := strings.IndexByte(formatted, '='); eq > 0 && !strings.HasPrefix(formatted, "\"") {
+			key := strings.TrimRight(formatted[:eq], " \t")
+			value := strings.TrimLeft(formatted[eq+1:], " \t")
+			formatted = key + " = " + value
+			if formatted != strings.TrimLeft(line, " \t") {
+				report(number, "property %s not written as \"key = value\"", key)
+			}
+			depth, inString = scanValueNesting(value, 0, false)
+		}
+		if !inString {
+			if trimmed := strings.TrimRight(formatted, " \t"); trimmed != formatted {
+				report(number, "trailing whitespace")
+				formatted = trimmed
+			}
+		}
+
+		if current == nil {
+			preamble = append(preamble, formatted)
+		} else {
+			current.Lines = append(current.Lines, formatted)
+		}
+	}
+	if blanks > 0 {
+		report(firstBlank, "blank line(s) at end of file")
+	}
+
+	var formatted strings.Builder
+	for _, line := range preamble {
+		formatted.WriteString(line + "\n")
+	}
+	if len(preamble) > 0 && len(sections) > 0 {
+		formatted.WriteString("\n")
+	}
+	formatted.WriteString(formatSceneSections(sections))
+	return formatted.String(), issues
+}
+
+// formatFile reports the formatting issues of a text scene or resource and
+// rewrites it when write is true and none of its lines is malformed
+func formatFile(file string, write bool) ([]FormatIssue, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	formatted, issues := formatSceneText(string(content))
+	if !write || formatted == string(content) {
+		return issues, nil
+	}
+	for _, issue := range issues {
+		if issue.Malformed {
+			return issues, nil
+		}
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	return issues, os.WriteFile(file, []byte(formatted), info.Mode())
+}
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [--check] <file or dir> [more files or dirs...]",
+	Short: "Rewrite scenes and resources in Godot's canonical text format",
+	Long: `Rewrite .tscn, .escn and .tres files the way Godot serializes them: LF line endings,
+section headers as [tag key=value ...], properties as "key = value" without indentation or
+trailing whitespace, one blank line between sections (none within runs of ext_resources,
+connections and editable paths) and a final newline. Multiline values are left as they are.
+
+With --check nothing is written: every deviation is reported as file:line and the command
+exits non-zero when a file needs formatting, e.g. to catch hand edits in CI. Files with a
+malformed section header are reported and never rewritten.`,
+	Example: `  gdq fmt --check .
+  gdq fmt scenes/player.tscn`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		var files []string
+		for _, arg := range args {
+			info, err := os.Stat(arg)
+			if err != nil {
+				return fmt.Errorf("file not found: %s", arg)
+			}
+			if !info.IsDir() {
+				files = append(files, arg)
+				continue
+			}
+			matches, err := findProjectFiles(arg, sceneExtensions)
+			if err != nil {
+				return fmt.Errorf("scan error: %v", err)
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no scenes found")
+		}
+
+		unformatted, malformed, failed := 0, 0, 0
+		progress := newProgress("Formatting", len(files))
+		for _, file := range files {
+			progress.Step()
+			issues, err := formatFile(file, !fmtCheck)
+			if err != nil {
+				progress.Clear()
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", file, err)
+				failed++
+				continue
+			}
+			if len(issues) == 0 {
+				continue
+			}
+
+			progress.Clear()
+			unformatted++
+			broken := false
+			for _, issue := range issues {
+				switch {
+				case issue.Malformed:
+					broken = true
+					fmt.Fprintf(out, "%s:%d: %s\n", file, issue.Line, issue.Message)
+				case fmtCheck:
+					fmt.Fprintf(out, "%s:%d: %s\n", file, issue.Line, issue.Message)
+				}
+			}
+			if broken {
+				malformed++
+				fmt.Fprintf(out, "%s: not formatted (malformed)\n", file)
+			} else if !fmtCheck {
+				fmt.Fprintf(out, "%s: %d fix(es)\n", file, len(issues))
+			}
+		}
+		progress.Clear()
+
+		if fmtCheck {
+			fmt.Fprintf(out, "\n%d of %d file(s) need formatting\n", unformatted, len(files))
+			if failed > 0 {
+				return fmt.Errorf("%d file(s) could not be checked", failed)
+			}
+			if unformatted > 0 {
+				return fmt.Errorf("%d file(s) not formatted", unformatted)
+			}
+			return nil
+		}
+		fmt.Fprintf(out, "\nFormatted %d of %d file(s)\n", unformatted-malformed, len(files))
+		if malformed+failed > 0 {
+			return fmt.Errorf("%d file(s) could not be formatted", malformed+failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "Report the formatting issues without writing files, exit non-zero when any is found")
+	rootCmd.AddCommand(fmtCmd)
+}
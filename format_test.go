@@ -0,0 +1,183 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatSectionHeader(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+		err      bool
+	}{
+		{`[node name="Root" type="Node2D"]`, `[node name="Root" type="Node2D"]`, false},
+		{`[ node  name = "Root"	type="Node2D" ]`, `[node name="Root" type="Node2D"]`, false},
+		{`[node name="A B" parent="." groups=["a b", "c"]]`, `[node name="A B" parent="." groups=["a b", "c"]]`, false},
+		{`[node name="Q\" x" parent="."]`, `[node name="Q\" x" parent="."]`, false},
+		{`[gd_scene load_steps=2 format=3]`, `[gd_scene load_steps=2 format=3]`, false},
+		{`[resource]`, `[resource]`, false},
+		{`[node name="Root"`, "", true},
+		{`[node name]`, "", true},
+		{`[node name="Root]`, "", true},
+		{`[node instance=ExtResource("1"]`, "", true},
+	}
+	for _, tt := range tests {
+		formatted, err := formatSectionHeader(tt.header)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", tt.header, formatted)
+			}
+			continue
+		}
+		if err != nil || formatted != tt.expected {
+			t.Errorf("%s: expected %s, got %s (%v)", tt.header, tt.expected, formatted, err)
+		}
+	}
+}
+
+func TestFormatSceneText(t *testing.T) {
+	canonical := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://a.gd" id="1"]
+[ext_resource type="Texture2D" path="res://a.png" id="2"]
+
+[node name="Root" type="Node2D"]
+script = ExtResource("1")
+text = "two  
+lines"
+data = {
+"a": 1
+}
+
+[node name="B" parent="."]
+
+[connection signal="a" from="." to="." method="_a"]
+[connection signal="b" from="." to="." method="_b"]
+`
+	formatted, issues := formatSceneText(canonical)
+	if formatted != canonical || len(issues) != 0 {
+		t.Fatalf("Canonical scene reformatted: %v\n%s", issues, formatted)
+	}
+
+	messy := strings.ReplaceAll(`[gd_scene load_steps=2 format=3]
+[ ext_resource type="Script"  path="res://a.gd" id="1" ]
+
+[ext_resource type="Texture2D" path="res://a.png" id="2"]
+
+[node name="Root" type="Node2D"]
+	script=ExtResource("1")   
+text = "two  
+lines"
+data = {  
+"a": 1
+}
+
+[node name="B" parent="."]
+[connection signal="a" from="." to="." method="_a"]
+
+[connection signal="b" from="." to="." method="_b"]
+
+`, "\n", "\r\n")
+	formatted, issues = formatSceneText(messy)
+	if formatted != canonical {
+		t.Errorf("Unexpected formatting:\n%s", formatted)
+	}
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Message)
+		if issue.Malformed {
+			t.Errorf("Unexpected malformed issue: %v", issue)
+		}
+	}
+	expected := []string{
+		"CRLF line endings",
+		`section header [ ext_resource type="Script"  path="res://a.gd" id="1" ] written as [ext_resource type="Script" path="res://a.gd" id="1"]`,
+		"0 blank line(s) before section, expected 1",
+		"1 blank line(s) before section, expected 0",
+		"indented with tabs",
+		`property script not written as "key = value"`,
+		"trailing whitespace",
+		"trailing whitespace",
+		"0 blank line(s) before section, expected 1",
+		"1 blank line(s) before section, expected 0",
+		"blank line(s) at end of file",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected issues:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+	if issues[1].Line != 2 || issues[4].Line != 7 {
+		t.Errorf("Unexpected issue lines: %v", issues)
+	}
+
+	// A multiline value before the first section stays in the preamble
+	preamble := "a = [\n1\n]\n\n[gd_scene format=3]\n"
+	formatted, issues = formatSceneText("a = [\n1\n]\n[gd_scene format=3]\n")
+	if formatted != preamble || len(issues) != 1 {
+		t.Errorf("Unexpected preamble formatting: %v\n%s", issues, formatted)
+	}
+}
+
+func TestFormatFileMalformed(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"broken.tscn": "[gd_scene format=3]\n[node name=\"Root\" type=\"Node\"\n",
+		"messy.tres":  "[gd_resource type=\"Resource\" format=3]\n[resource]\nvalue=1\n",
+	})
+
+	broken := filepath.Join(root, "broken.tscn")
+	issues, err := formatFile(broken, true)
+	if err != nil {
+		t.Fatalf("Format error: %v", err)
+	}
+	malformed := false
+	for _, issue := range issues {
+		malformed = malformed || issue.Malformed && issue.Line == 2
+	}
+	if !malformed {
+		t.Errorf("Expected a malformed header on line 2, got: %v", issues)
+	}
+	if content, _ := os.ReadFile(broken); string(content) != "[gd_scene format=3]\n[node name=\"Root\" type=\"Node\"\n" {
+		t.Errorf("Malformed file was rewritten:\n%s", content)
+	}
+
+	messy := filepath.Join(root, "messy.tres")
+	if _, err := formatFile(messy, false); err != nil {
+		t.Fatalf("Format error: %v", err)
+	}
+	if content, _ := os.ReadFile(messy); !strings.Contains(string(content), "value=1") {
+		t.Error("Check mode rewrote the file")
+	}
+	if _, err := formatFile(messy, true); err != nil {
+		t.Fatalf("Format error: %v", err)
+	}
+	content, _ := os.ReadFile(messy)
+	if string(content) != "[gd_resource type=\"Resource\" format=3]\n\n[resource]\nvalue = 1\n" {
+		t.Errorf("Unexpected formatting:\n%s", content)
+	}
+}
+
+func TestFormatCommandUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Unreadable files are readable by root")
+	}
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn":   "[gd_scene format=3]\n",
+		"locked.tscn": "[gd_scene format=3]\n",
+	})
+	locked := filepath.Join(root, "locked.tscn")
+	os.Chmod(locked, 0)
+
+	// An unreadable file is reported on stderr and fails both modes
+	for _, args := range [][]string{{"fmt", "--check", root}, {"fmt", root}} {
+		var stdout, stderr strings.Builder
+		if code := Run(args, &stdout, &stderr); code == 0 {
+			t.Errorf("%v: expected a failure for the unreadable file", args)
+		}
+		if !strings.Contains(stderr.String(), "Error: "+locked) || strings.Contains(stdout.String(), "Error") {
+			t.Errorf("%v: expected the error on stderr:\nstdout: %s\nstderr: %s", args, stdout.String(), stderr.String())
+		}
+	}
+}
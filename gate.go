@@ -0,0 +1,285 @@
+package gdquery
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Gate command options
+var gateBase = ""
+var gateMaxNodes = "10%"
+var gateMaxBytes = "10%"
+var gateMaxDeps = "5"
+
+// SceneSize holds the size metrics the gate compares
+type SceneSize struct {
+	Nodes        int
+	Resources    int // ext_resources and sub_resources
+	Bytes        int
+	Dependencies int
+}
+
+// measureScene computes the size metrics of scene content
+func measureScene(content []byte, name string) (SceneSize, error) {
+	scene, err := ParseReader(bytes.NewReader(content), name, ParseOptions{SkipProperties: true, Logger: logger})
+	if err != nil {
+		return SceneSize{}, err
+	}
+	paths := make(map[string]bool)
+	for _, resource := range scene.ExtResources {
+		if resource.Path != "" {
+			paths[resource.Path] = true
+		}
+	}
+	return SceneSize{
+		Nodes:        len(scene.AllNodes),
+		Resources:    len(scene.ExtResources) + len(scene.SubResources),
+		Bytes:        len(content),
+		Dependencies: len(paths),
+	}, nil
+}
+
+// GrowthLimit is the allowed growth of a metric, absolute ("50") or relative to the base ("10%")
+type GrowthLimit struct {
+	Value   float64
+	Percent bool
+}
+
+// parseGrowthLimit parses a limit; an empty string disables the check and returns nil
+func parseGrowthLimit(s string) (*GrowthLimit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	limit := &GrowthLimit{Percent: strings.HasSuffix(s, "%")}
+	value, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("invalid growth limit: %s (expected e.g. 50 or 10%%)", s)
+	}
+	limit.Value = value
+	return limit, nil
+}
+
+// exceeded reports whether growing from base to head goes over the limit.
+// Relative limits do not apply to new scenes (base 0).
+func (l *GrowthLimit) exceeded(base, head int) bool {
+	delta := head - base
+	if l == nil || delta <= 0 {
+		return false
+	}
+	if l.Percent {
+		return base > 0 && float64(delta)*100/float64(base) > l.Value
+	}
+	return float64(delta) > l.Value
+}
+
+// SceneDelta is the change of a scene against the base revision
+type SceneDelta struct {
+	File     string // res:// path
+	Base     SceneSize
+	Head     SceneSize
+	New      bool
+	Removed  bool
+	Exceeded []string // metrics over their limit
+}
+
+// gitOutput runs git in dir and returns its standard output
+func gitOutput(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// changedScenes returns the scenes under root (relative paths) that differ from
+// the base revision, including new untracked scenes
+func changedScenes(root, base string) ([]string, error) {
+	files, err := changedFiles(root, base)
+	if err != nil {
+		return nil, err
+	}
+	var scenes []string
+	for _, file := range files {
+		if hasExtension(file, nodeSceneExtensions) {
+			scenes = append(scenes, file)
+		}
+	}
+	return scenes, nil
+}
+
+// changedFiles returns the files under root (relative paths) that differ from
+// the base revision, including new untracked files
+func changedFiles(root, base string) ([]string, error) {
+	diff, err := gitOutput(root, "diff", "--name-only", "--relative", base, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput(root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(diff)+string(untracked), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		files = append(files, line)
+	}
+	return files, nil
+}
+
+// compareWithBase measures the changed scenes under root against the base revision
+func compareWithBase(root, base string, limits map[string]*GrowthLimit) ([]*SceneDelta, error) {
+	files, err := changedScenes(root, base)
+	if err != nil {
+		return nil, err
+	}
+
+	var deltas []*SceneDelta
+	for _, file := range files {
+		delta := &SceneDelta{File: "res://" + filepath.ToSlash(file)}
+
+		// "./" makes the path relative to the working directory instead of the repository root
+		if content, err := gitOutput(root, "show", base+":./"+filepath.ToSlash(file)); err == nil {
+			if delta.Base, err = measureScene(content, file); err != nil {
+				return nil, fmt.Errorf("%s at %s: %v", file, base, err)
+			}
+		} else {
+			delta.New = true
+		}
+
+		content, err := os.ReadFile(filepath.Join(root, file))
+		if os.IsNotExist(err) {
+			delta.Removed = true
+			deltas = append(deltas, delta)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if delta.Head, err = measureScene(content, file); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		if limits["nodes"].exceeded(delta.Base.Nodes, delta.Head.Nodes) {
+			delta.Exceeded = append(delta.Exceeded, "nodes")
+		}
+		if limits["bytes"].exceeded(delta.Base.Bytes, delta.Head.Bytes) {
+			delta.Exceeded = append(delta.Exceeded, "bytes")
+		}
+		if limits["deps"].exceeded(delta.Base.Dependencies, delta.Head.Dependencies) {
+			delta.Exceeded = append(delta.Exceeded, "deps")
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas, nil
+}
+
+// formatDelta formats a metric change as "base -> head (+delta)"
+func formatDelta(base, head int) string {
+	return fmt.Sprintf("%d -> %d (%+d)", base, head, head-base)
+}
+
+// printSceneDeltas displays one row per changed scene
+func printSceneDeltas(out io.Writer, deltas []*SceneDelta) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCENE\tNODES\tBYTES\tDEPS\tSTATUS")
+	for _, delta := range deltas {
+		status := "ok"
+		switch {
+		case delta.Removed:
+			status = "removed"
+		case len(delta.Exceeded) > 0:
+			status = "FAIL: " + strings.Join(delta.Exceeded, ", ")
+		case delta.New:
+			status = "new"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", delta.File,
+			formatDelta(delta.Base.Nodes, delta.Head.Nodes),
+			formatDelta(delta.Base.Bytes, delta.Head.Bytes),
+			formatDelta(delta.Base.Dependencies, delta.Head.Dependencies), status)
+	}
+	w.Flush()
+}
+
+var gateCmd = &cobra.Command{
+	Use:   "gate --base <git ref> [project dir]",
+	Short: "Fail when changed scenes grow too much against a git revision",
+	Long: `Compare the node count, file size and dependency count of every scene changed since the base
+git revision (committed, uncommitted and untracked changes) and print a per-scene delta table.
+Exits non-zero when a scene grows more than allowed. Limits are absolute ("50") or relative to
+the base ("10%"); an empty limit disables the check. Relative limits do not apply to new scenes.`,
+	Example:      `  gdq gate --base origin/main --max-nodes 20% --max-deps 3`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if gateBase == "" {
+			return fmt.Errorf("--base is required")
+		}
+
+		limits := make(map[string]*GrowthLimit)
+		for name, value := range map[string]string{"nodes": gateMaxNodes, "bytes": gateMaxBytes, "deps": gateMaxDeps} {
+			limit, err := parseGrowthLimit(value)
+			if err != nil {
+				return err
+			}
+			limits[name] = limit
+		}
+
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		deltas, err := compareWithBase(findProjectRoot(dir), gateBase, limits)
+		if err != nil {
+			return fmt.Errorf("gate error: %v", err)
+		}
+		if len(deltas) == 0 {
+			fmt.Fprintf(out, "No scenes changed since %s\n", gateBase)
+			return nil
+		}
+		printSceneDeltas(out, deltas)
+
+		failed := 0
+		for _, delta := range deltas {
+			if len(delta.Exceeded) > 0 {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d scene(s) grew more than allowed", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	gateCmd.Flags().StringVar(&gateBase, "base", "", "Git revision to compare against (e.g. origin/main)")
+	gateCmd.Flags().StringVar(&gateMaxNodes, "max-nodes", "10%", "Allowed node count growth per scene")
+	gateCmd.Flags().StringVar(&gateMaxBytes, "max-bytes", "10%", "Allowed file size growth per scene")
+	gateCmd.Flags().StringVar(&gateMaxDeps, "max-deps", "5", "Allowed ext_resource dependency growth per scene")
+	rootCmd.AddCommand(gateCmd)
+}
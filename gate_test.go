@@ -0,0 +1,101 @@
+package gdquery
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGrowthLimit(t *testing.T) {
+	tests := []struct {
+		limit      string
+		base, head int
+		exceeded   bool
+	}{
+		{"10%", 100, 110, false},
+		{"10%", 100, 111, true},
+		{"10%", 0, 500, false}, // relative limits do not apply to new scenes
+		{"5", 0, 6, true},
+		{"5", 10, 15, false},
+		{"5", 10, 2, false},
+		{"", 1, 1000, false},
+	}
+	for _, tt := range tests {
+		limit, err := parseGrowthLimit(tt.limit)
+		if err != nil {
+			t.Fatalf("Limit error: %v", err)
+		}
+		if got := limit.exceeded(tt.base, tt.head); got != tt.exceeded {
+			t.Errorf("%q: %d -> %d: expected exceeded=%t", tt.limit, tt.base, tt.head, tt.exceeded)
+		}
+	}
+	if _, err := parseGrowthLimit("ten"); err == nil {
+		t.Errorf("Expected an error for an invalid limit")
+	}
+}
+
+func TestGateCompareWithBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	small := "[gd_scene format=3]\n\n[node name=\"Main\" type=\"Node2D\"]\n"
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": "config_version=5\n",
+		"main.tscn":     small,
+		"stable.tscn":   small,
+		"old/gone.tscn": small,
+		"scripts/a.gd":  "extends Node\n",
+	})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+
+	grown := small + `[ext_resource type="Texture2D" path="res://a.png" id="1"]
+
+[node name="A" type="Sprite2D" parent="."]
+
+[node name="B" type="Sprite2D" parent="."]
+`
+	files := map[string]string{"main.tscn": grown, "levels/new.tscn": small, "scripts/a.gd": "extends Node2D\n"}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Write error: %v", err)
+		}
+	}
+	os.Remove(filepath.Join(root, "old", "gone.tscn"))
+
+	limits := map[string]*GrowthLimit{"nodes": {Value: 50, Percent: true}, "deps": {Value: 1}}
+	deltas, err := compareWithBase(root, "HEAD", limits)
+	if err != nil {
+		t.Fatalf("Gate error: %v", err)
+	}
+
+	var got []string
+	for _, delta := range deltas {
+		got = append(got, delta.File+" "+formatDelta(delta.Base.Nodes, delta.Head.Nodes)+" "+strings.Join(delta.Exceeded, ","))
+	}
+	expected := []string{
+		"res://main.tscn 1 -> 3 (+2) nodes",
+		"res://old/gone.tscn 1 -> 0 (-1) ",
+		"res://levels/new.tscn 0 -> 1 (+1) ",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Unexpected deltas:\n%s", strings.Join(got, "\n"))
+	}
+	if !deltas[1].Removed || !deltas[2].New || deltas[0].Head.Dependencies != 1 {
+		t.Errorf("Unexpected delta details: %+v %+v %+v", deltas[0], deltas[1], deltas[2])
+	}
+}
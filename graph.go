@@ -0,0 +1,210 @@
+package gdquery
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportGraph is a directed graph written in DOT or GraphML format
+type ExportGraph struct {
+	Name  string
+	Nodes []*ExportGraphNode
+	Edges []*ExportGraphEdge
+}
+
+// ExportGraphNode is a vertex of an ExportGraph
+type ExportGraphNode struct {
+	ID    string
+	Label string
+	Attrs map[string]string
+}
+
+// ExportGraphEdge is an edge of an ExportGraph
+type ExportGraphEdge struct {
+	From  string
+	To    string
+	Attrs map[string]string
+}
+
+// sceneTreeGraph converts the node tree of a scene into a graph with parent -> child edges
+func sceneTreeGraph(name string, scene *GodotScene) *ExportGraph {
+	graph := &ExportGraph{Name: name}
+	for _, node := range scene.AllNodes {
+		attrs := map[string]string{"type": node.Type, "path": node.Path}
+		if node.Script != "" {
+			attrs["script"] = resolveResourcePath(node.Script, scene)
+		}
+		if node.Instance != "" {
+			attrs["instance"] = resolveResourcePath(node.Instance, scene)
+		}
+		graph.Nodes = append(graph.Nodes, &ExportGraphNode{ID: node.Path, Label: node.OriginalName, Attrs: attrs})
+		for _, child := range node.Children {
+			graph.Edges = append(graph.Edges, &ExportGraphEdge{From: node.Path, To: child.Path})
+		}
+	}
+	return graph
+}
+
+// dependencyExportGraph converts a dependency graph; referenced files outside
+// the scanned files (e.g. textures) become nodes too
+func dependencyExportGraph(deps *DependencyGraph) *ExportGraph {
+	graph := &ExportGraph{Name: "dependencies"}
+	seen := make(map[string]bool)
+	addNode := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			node := &ExportGraphNode{ID: file, Label: file, Attrs: map[string]string{}}
+			if asset := deps.Imports[file]; asset != nil && asset.Importer != "" {
+				node.Attrs["importer"] = asset.Importer
+			}
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+
+	for _, file := range deps.Files {
+		addNode(file)
+	}
+	for _, file := range deps.Files {
+		for _, edge := range deps.Edges[file] {
+			addNode(edge.To)
+			graph.Edges = append(graph.Edges, &ExportGraphEdge{From: edge.From, To: edge.To, Attrs: map[string]string{"kind": edge.Kind}})
+		}
+	}
+	return graph
+}
+
+// dotQuote quotes a DOT identifier or attribute value
+func dotQuote(s string) string {
+	return strconv.Quote(s)
+}
+
+// dotAttrs formats attributes as [key="value", ...] in key order
+func dotAttrs(label string, attrs map[string]string) string {
+	var parts []string
+	if label != "" {
+		parts = append(parts, "label="+dotQuote(label))
+	}
+	for _, key := range sortedKeys(attrs) {
+		if attrs[key] != "" {
+			parts = append(parts, key+"="+dotQuote(attrs[key]))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeDOT writes graphs in Graphviz DOT format, one digraph per graph
+func writeDOT(w io.Writer, graphs ...*ExportGraph) error {
+	for _, graph := range graphs {
+		fmt.Fprintf(w, "digraph %s {\n", dotQuote(graph.Name))
+		for _, node := range graph.Nodes {
+			fmt.Fprintf(w, "  %s%s;\n", dotQuote(node.ID), dotAttrs(node.Label, node.Attrs))
+		}
+		for _, edge := range graph.Edges {
+			fmt.Fprintf(w, "  %s -> %s%s;\n", dotQuote(edge.From), dotQuote(edge.To), dotAttrs("", edge.Attrs))
+		}
+		if _, err := fmt.Fprintln(w, "}"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xmlEscape escapes text for XML attributes and content
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// writeGraphML writes graphs in GraphML format (Gephi, yEd), one <graph> per graph
+func writeGraphML(w io.Writer, graphs ...*ExportGraph) error {
+	// Declare every attribute used by nodes and edges
+	nodeKeys := map[string]string{"label": ""}
+	edgeKeys := map[string]string{}
+	for _, graph := range graphs {
+		for _, node := range graph.Nodes {
+			for key := range node.Attrs {
+				nodeKeys[key] = ""
+			}
+		}
+		for _, edge := range graph.Edges {
+			for key := range edge.Attrs {
+				edgeKeys[key] = ""
+			}
+		}
+	}
+
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(w, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	for _, key := range sortedKeys(nodeKeys) {
+		fmt.Fprintf(w, "  <key id=\"n_%s\" for=\"node\" attr.name=\"%s\" attr.type=\"string\"/>\n", xmlEscape(key), xmlEscape(key))
+	}
+	for _, key := range sortedKeys(edgeKeys) {
+		fmt.Fprintf(w, "  <key id=\"e_%s\" for=\"edge\" attr.name=\"%s\" attr.type=\"string\"/>\n", xmlEscape(key), xmlEscape(key))
+	}
+
+	edgeCount := 0
+	for _, graph := range graphs {
+		// Node ids must be unique in the whole document
+		nodeID := func(id string) string {
+			if len(graphs) > 1 {
+				id = graph.Name + ":" + id
+			}
+			return xmlEscape(id)
+		}
+
+		fmt.Fprintf(w, "  <graph id=\"%s\" edgedefault=\"directed\">\n", xmlEscape(graph.Name))
+		for _, node := range graph.Nodes {
+			fmt.Fprintf(w, "    <node id=\"%s\">\n", nodeID(node.ID))
+			fmt.Fprintf(w, "      <data key=\"n_label\">%s</data>\n", xmlEscape(node.Label))
+			for _, key := range sortedKeys(node.Attrs) {
+				if node.Attrs[key] != "" {
+					fmt.Fprintf(w, "      <data key=\"n_%s\">%s</data>\n", xmlEscape(key), xmlEscape(node.Attrs[key]))
+				}
+			}
+			fmt.Fprintln(w, "    </node>")
+		}
+		for _, edge := range graph.Edges {
+			edgeCount++
+			fmt.Fprintf(w, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\"", edgeCount, nodeID(edge.From), nodeID(edge.To))
+			if len(edge.Attrs) == 0 {
+				fmt.Fprintln(w, "/>")
+				continue
+			}
+			fmt.Fprintln(w, ">")
+			for _, key := range sortedKeys(edge.Attrs) {
+				fmt.Fprintf(w, "      <data key=\"e_%s\">%s</data>\n", xmlEscape(key), xmlEscape(edge.Attrs[key]))
+			}
+			fmt.Fprintln(w, "    </edge>")
+		}
+		fmt.Fprintln(w, "  </graph>")
+	}
+
+	_, err := fmt.Fprintln(w, "</graphml>")
+	return err
+}
+
+// writeExportGraphs writes graphs in the current graph output format (dot or graphml)
+func writeExportGraphs(w io.Writer, graphs ...*ExportGraph) error {
+	if outputFormat == "graphml" {
+		return writeGraphML(w, graphs...)
+	}
+	return writeDOT(w, graphs...)
+}
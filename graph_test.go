@@ -0,0 +1,55 @@
+package gdquery
+
+import (
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGraphExport(t *testing.T) {
+	tempFile := "test_graph.tscn"
+	if err := os.WriteFile(tempFile, []byte(testTscnContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	graph := sceneTreeGraph(tempFile, scene)
+	if len(graph.Nodes) != 5 || len(graph.Edges) != 4 {
+		t.Fatalf("Expected 5 nodes and 4 edges, got %d and %d", len(graph.Nodes), len(graph.Edges))
+	}
+
+	var dot strings.Builder
+	if err := writeDOT(&dot, graph); err != nil {
+		t.Fatalf("DOT error: %v", err)
+	}
+	if !strings.Contains(dot.String(), `"Root/Child1" -> "Root/Child1/GrandChild";`) {
+		t.Errorf("Missing DOT edge:\n%s", dot.String())
+	}
+
+	// Two graphs in one document must still produce well-formed XML with unique ids
+	var graphml strings.Builder
+	if err := writeGraphML(&graphml, graph, sceneTreeGraph("other.tscn", scene)); err != nil {
+		t.Fatalf("GraphML error: %v", err)
+	}
+	var document struct {
+		Graphs []struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(graphml.String()), &document); err != nil {
+		t.Fatalf("Invalid GraphML: %v\n%s", err, graphml.String())
+	}
+	if len(document.Graphs) != 2 || document.Graphs[1].Nodes[0].ID != "other.tscn:Root" || document.Graphs[1].Edges[0].Source != "other.tscn:Root" {
+		t.Errorf("Unexpected GraphML structure: %+v", document)
+	}
+}
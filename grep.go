@@ -0,0 +1,138 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Grep command options
+var grepMeta = false
+var grepIgnoreCase = false
+
+// GrepMatch is a node field matching the grep pattern
+type GrepMatch struct {
+	Node  *GodotNode
+	Field string // "name", "type", "description" or a property name
+	Value string
+}
+
+// isMetaProperty reports whether a property holds node metadata:
+// metadata/* in Godot 4, __meta__ in Godot 3
+func isMetaProperty(prop string) bool {
+	return strings.HasPrefix(prop, "metadata/") || prop == "__meta__"
+}
+
+// grepScene returns the node fields of a scene matching re. With meta only
+// editor descriptions and metadata are searched; otherwise names, types and
+// all property values are.
+func grepScene(scene *GodotScene, re *regexp.Regexp, meta bool) []GrepMatch {
+	var matches []GrepMatch
+	for _, node := range scene.AllNodes {
+		if description := nodeDescription(node); description != "" && re.MatchString(description) {
+			matches = append(matches, GrepMatch{Node: node, Field: "description", Value: description})
+		}
+		if !meta {
+			if re.MatchString(node.OriginalName) {
+				matches = append(matches, GrepMatch{Node: node, Field: "name", Value: node.OriginalName})
+			}
+			if re.MatchString(typeLabel(node)) {
+				matches = append(matches, GrepMatch{Node: node, Field: "type", Value: typeLabel(node)})
+			}
+		}
+		for _, prop := range sortedKeys(node.Properties) {
+			if prop == "editor_description" || (meta && !isMetaProperty(prop)) {
+				continue
+			}
+			if value := node.Properties[prop]; re.MatchString(value) {
+				matches = append(matches, GrepMatch{Node: node, Field: prop, Value: value})
+			}
+		}
+	}
+	return matches
+}
+
+// grepFiles expands directories in args to the scenes they contain
+func grepFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("file not found: %s", arg)
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		scenes, err := findProjectFiles(arg, nodeSceneExtensions)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %v", err)
+		}
+		files = append(files, scenes...)
+	}
+	return files, nil
+}
+
+var grepCmd = &cobra.Command{
+	Use:   "grep [flags] <pattern> <tscn files or dirs...>",
+	Short: "Search node names, types, properties and editor descriptions",
+	Long: `Search the nodes of scenes for a regular expression and print "file:line:node: field: value"
+for every match. With --meta only editor descriptions (editor_description) and node metadata
+are searched, which teams use as in-scene documentation. Exits non-zero when nothing matches.`,
+	Example:      `  gdq grep --meta -i 'todo|fixme' scenes/`,
+	Args:         cobra.MinimumNArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		pattern := args[0]
+		if grepIgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+
+		files, err := grepFiles(args[1:])
+		if err != nil {
+			return err
+		}
+
+		found := 0
+		progress := newProgress("Searching", len(files))
+		defer progress.Clear()
+		for _, file := range files {
+			progress.Step()
+			scene, err := parseSceneFile(file)
+			if err != nil {
+				progress.Clear()
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", file, err)
+				continue
+			}
+			matches := grepScene(scene, re, grepMeta)
+			if len(matches) > 0 {
+				progress.Clear()
+			}
+			for _, match := range matches {
+				value := strings.ReplaceAll(match.Value, "\n", "\\n")
+				fmt.Fprintf(out, "%s:%d:%s: %s: %s\n", file, match.Node.Span.StartLine, match.Node.Path, match.Field, value)
+				found++
+			}
+		}
+
+		if found == 0 {
+			return fmt.Errorf("no matches")
+		}
+		return nil
+	},
+}
+
+func init() {
+	grepCmd.Flags().BoolVar(&grepMeta, "meta", false, "Search only editor descriptions and node metadata")
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "Match case-insensitively")
+	grepCmd.Flags().BoolVar(&useIndex, "use-index", false, "Read unchanged scenes from the project index built by gdq index")
+	rootCmd.AddCommand(grepCmd)
+}
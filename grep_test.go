@@ -0,0 +1,87 @@
+package gdquery
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const describedScene = `[gd_scene format=3]
+
+[node name="Level" type="Node2D"]
+editor_description = "Spawns enemies.
+TODO: tune wave timing"
+
+[node name="Door" type="Area2D" parent="."]
+editor_description = "Opens when all enemies are dead"
+metadata/owner = "todo: level design"
+
+[node name="Todo" type="Label" parent="."]
+text = "TODO list"
+`
+
+func TestGrepDescriptions(t *testing.T) {
+	tempFile := "test_grep.tscn"
+	if err := os.WriteFile(tempFile, []byte(describedScene), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	if got := descriptionAnnotation(scene.RootNode); got != "  # Spawns enemies. ..." {
+		t.Errorf("Unexpected multiline annotation: %q", got)
+	}
+	if got := descriptionAnnotation(scene.AllNodes[1]); got != "  # Opens when all enemies are dead" {
+		t.Errorf("Unexpected annotation: %q", got)
+	}
+
+	format := func(matches []GrepMatch) string {
+		var lines []string
+		for _, match := range matches {
+			lines = append(lines, match.Node.Path+" "+match.Field)
+		}
+		return strings.Join(lines, ", ")
+	}
+	re := regexp.MustCompile("(?i)todo")
+	if got := format(grepScene(scene, re, true)); got != "Level description, Level/Door metadata/owner" {
+		t.Errorf("Unexpected --meta matches: %s", got)
+	}
+	if got := format(grepScene(scene, re, false)); got != "Level description, Level/Door metadata/owner, Level/Todo name, Level/Todo text" {
+		t.Errorf("Unexpected matches: %s", got)
+	}
+
+	// Godot 3 keeps the description in a multi-line __meta__ dictionary
+	godot3File := "test_grep_godot3.tscn"
+	godot3Scene := `[gd_scene format=2]
+
+[node name="Level" type="Node2D"]
+__meta__ = {
+"_edit_lock_": true,
+"_editor_description_": "Old \"style\" note",
+"points": [
+1, 2
+]
+}
+position = Vector2( 1, 2 )
+`
+	if err := os.WriteFile(godot3File, []byte(godot3Scene), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(godot3File)
+
+	scene, err = ParseTscnFile(godot3File)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(scene.AllNodes) != 1 || scene.RootNode.Properties["position"] != "Vector2( 1, 2 )" {
+		t.Fatalf("Multi-line __meta__ broke parsing: %d nodes, %v", len(scene.AllNodes), scene.RootNode.Properties)
+	}
+	if got := nodeDescription(scene.RootNode); got != `Old "style" note` {
+		t.Errorf("Unexpected Godot 3 description: %q", got)
+	}
+}
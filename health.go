@@ -0,0 +1,281 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Health command options
+var healthMaxNodes = 1000
+var healthMaxKB = 1024
+var healthMaxDepth = 16
+var healthMinScore = 0
+var healthConfigPath = ""
+
+// Weights of the health checks in the score, out of 100
+const (
+	healthWeightValidate = 30
+	healthWeightLint     = 25
+	healthWeightUnused   = 15
+	healthWeightBudgets  = 15
+	healthWeightCycles   = 15
+)
+
+// healthCyclePenalty is the number of points each dependency cycle costs
+const healthCyclePenalty = 25
+
+// projectResRefRe matches the res:// paths referenced by project.godot
+var projectResRefRe = regexp.MustCompile(`res://[^"\s]+`)
+
+// HealthBudget holds the per-scene limits of the budgets check
+type HealthBudget struct {
+	MaxNodes int
+	MaxKB    int
+	MaxDepth int
+}
+
+// HealthCheck is one line of the health breakdown
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Score   int    `json:"score"` // 0 to 100
+	Weight  int    `json:"weight"`
+	Issues  int    `json:"issues"`
+	Details string `json:"details"`
+}
+
+// HealthReport is the weighted project health score with its breakdown
+type HealthReport struct {
+	Score  int            `json:"score"`
+	Checks []*HealthCheck `json:"checks"`
+}
+
+// ratioScore scores the share of good items out of total, 100 when there are none
+func ratioScore(bad float64, total int) int {
+	if total == 0 {
+		return 100
+	}
+	return int(math.Round(100 * math.Max(0, 1-bad/float64(total))))
+}
+
+// unusedResources returns the scenes and resources of the checked directory
+// that no file references, nor project.godot (main scene, autoloads, themes)
+func unusedResources(ctx *LintContext) ([]string, int) {
+	graph := ctx.Graph()
+	referenced := make(map[string]bool)
+	for from, edges := range graph.Edges {
+		for _, edge := range edges {
+			if edge.To != from {
+				referenced[edge.To] = true
+			}
+		}
+	}
+	if content, err := os.ReadFile(resToFS(ctx.Root, "res://project.godot")); err == nil {
+		for _, path := range projectResRefRe.FindAllString(string(content), -1) {
+			referenced[path] = true
+		}
+	}
+
+	var unused []string
+	total := 0
+	for _, file := range graph.Files {
+		if !ctx.inDir(file) || !hasExtension(file, sceneExtensions) {
+			continue
+		}
+		total++
+		if !referenced[file] {
+			unused = append(unused, file)
+		}
+	}
+	return unused, total
+}
+
+// computeHealth runs the checks of the health score over the project: scenes
+// that fail strict parsing, lint findings, unused scenes, resources and
+// scripts, scenes over budget and dependency cycles
+func computeHealth(ctx *LintContext, budget HealthBudget) (*HealthReport, error) {
+	report := &HealthReport{}
+
+	// Validate: the scenes Godot would reject or that need guessing to load
+	strict, err := scanProject(ctx.Root, ctx.Dir, ParseOptions{Strict: true, SkipProperties: true, Logger: logger})
+	if err != nil {
+		return nil, err
+	}
+	invalid := 0
+	for _, result := range strict {
+		if result.Err != nil {
+			invalid++
+		}
+	}
+	report.Checks = append(report.Checks, &HealthCheck{
+		Name:    "validate",
+		Score:   ratioScore(float64(invalid), len(strict)),
+		Weight:  healthWeightValidate,
+		Issues:  invalid,
+		Details: fmt.Sprintf("%d of %d scene(s) fail strict parsing", invalid, len(strict)),
+	})
+
+	// Lint: the enabled rules except unused-script, counted as unused below.
+	// A warning weighs a quarter of an error; one error per scene scores 0.
+	var rules []*LintRule
+	for _, rule := range lintRules {
+		if rule.Name != "unused-script" && ctx.enabled(rule.Name) {
+			rules = append(rules, rule)
+		}
+	}
+	errors, warnings := 0, 0
+	for _, finding := range runLint(ctx, rules) {
+		if finding.Severity == severityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	report.Checks = append(report.Checks, &HealthCheck{
+		Name:    "lint",
+		Score:   ratioScore(float64(errors)+float64(warnings)/4, max(len(strict), 1)),
+		Weight:  healthWeightLint,
+		Issues:  errors + warnings,
+		Details: fmt.Sprintf("%d error(s), %d warning(s)", errors, warnings),
+	})
+
+	// Unused: scenes and resources nothing references, and unused scripts
+	unused, total := unusedResources(ctx)
+	scripts := 0
+	for _, file := range ctx.Graph().Files {
+		if ctx.inDir(file) && hasExtension(file, scriptExtensions) {
+			scripts++
+		}
+	}
+	unusedScripts := len(checkUnusedScripts(ctx))
+	report.Checks = append(report.Checks, &HealthCheck{
+		Name:    "unused",
+		Score:   ratioScore(float64(len(unused)+unusedScripts), total+scripts),
+		Weight:  healthWeightUnused,
+		Issues:  len(unused) + unusedScripts,
+		Details: fmt.Sprintf("%d of %d scene(s)/resource(s) and %d of %d script(s) unreferenced", len(unused), total, unusedScripts, scripts),
+	})
+
+	// Budgets: node count, file size and tree depth of every scene
+	over := 0
+	for _, result := range ctx.Scenes() {
+		if result.Err != nil {
+			continue
+		}
+		size := int64(0)
+		if info, err := os.Stat(resToFS(ctx.Root, result.File)); err == nil {
+			size = info.Size()
+		}
+		if (budget.MaxNodes > 0 && result.Metrics.NodeCount > budget.MaxNodes) ||
+			(budget.MaxKB > 0 && size > int64(budget.MaxKB)*1024) ||
+			(budget.MaxDepth > 0 && result.Metrics.MaxDepth > budget.MaxDepth) {
+			over++
+		}
+	}
+	report.Checks = append(report.Checks, &HealthCheck{
+		Name:    "budgets",
+		Score:   ratioScore(float64(over), len(ctx.Scenes())),
+		Weight:  healthWeightBudgets,
+		Issues:  over,
+		Details: fmt.Sprintf("%d scene(s) over %d nodes, %d KB or depth %d", over, budget.MaxNodes, budget.MaxKB, budget.MaxDepth),
+	})
+
+	// Cycles: each dependency cycle costs a quarter of the check
+	cycles := len(findDependencyCycles(ctx.Graph()))
+	report.Checks = append(report.Checks, &HealthCheck{
+		Name:    "cycles",
+		Score:   max(0, 100-healthCyclePenalty*cycles),
+		Weight:  healthWeightCycles,
+		Issues:  cycles,
+		Details: fmt.Sprintf("%d dependency cycle(s)", cycles),
+	})
+
+	weighted, weights := 0, 0
+	for _, check := range report.Checks {
+		weighted += check.Score * check.Weight
+		weights += check.Weight
+	}
+	report.Score = int(math.Round(float64(weighted) / float64(weights)))
+	return report, nil
+}
+
+// printHealthReport displays the breakdown table and the score
+func printHealthReport(out io.Writer, report *HealthReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSCORE\tWEIGHT\tDETAILS")
+	for _, check := range report.Checks {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", check.Name, check.Score, check.Weight, check.Details)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\nHealth score: %d/100\n", report.Score)
+}
+
+var healthCmd = &cobra.Command{
+	Use:   "health [project dir]",
+	Short: "Score the health of a project from validation, lint, unused files, budgets and cycles",
+	Long: `Combine several checks into a single weighted score out of 100 with a breakdown table, to track
+a project sprint over sprint:
+
+  validate (30)  scenes that fail strict parsing (missing parents, second roots, unterminated values)
+  lint     (25)  findings of the enabled lint rules, a warning counting as a quarter of an error
+  unused   (15)  scenes and resources nothing references, and unused scripts
+  budgets  (15)  scenes over --max-nodes, --max-kb or --max-depth
+  cycles   (15)  dependency cycles, 25 points each
+
+Lint rules are configured as for gdq lint (gdqlint.cfg or --config). With --min-score, the
+command exits non-zero when the score is lower.`,
+	Example: `  gdq health path/to/project
+  gdq health --min-score 80 --max-nodes 500 -o json .`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		root := findProjectRoot(dir)
+		config, err := loadLintConfig(root, healthConfigPath)
+		if err != nil {
+			return fmt.Errorf("lint config error: %v", err)
+		}
+		ctx := &LintContext{Root: root, Dir: dir, Config: config}
+		report, err := computeHealth(ctx, HealthBudget{MaxNodes: healthMaxNodes, MaxKB: healthMaxKB, MaxDepth: healthMaxDepth})
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+
+		if outputFormat == "json" {
+			if err := writeJSON(out, report); err != nil {
+				return err
+			}
+		} else {
+			printHealthReport(out, report)
+		}
+		if report.Score < healthMinScore {
+			return fmt.Errorf("health score %d is below %d", report.Score, healthMinScore)
+		}
+		return nil
+	},
+}
+
+func init() {
+	healthCmd.Flags().IntVar(&healthMaxNodes, "max-nodes", 1000, "Node count budget per scene (0 for none)")
+	healthCmd.Flags().IntVar(&healthMaxKB, "max-kb", 1024, "File size budget per scene in KB (0 for none)")
+	healthCmd.Flags().IntVar(&healthMaxDepth, "max-depth", 16, "Tree depth budget per scene (0 for none)")
+	healthCmd.Flags().IntVar(&healthMinScore, "min-score", 0, "Exit non-zero when the score is lower")
+	healthCmd.Flags().StringVar(&healthConfigPath, "config", "", "Lint configuration file (default: gdqlint.cfg in the project root)")
+	rootCmd.AddCommand(healthCmd)
+}
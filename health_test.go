@@ -0,0 +1,62 @@
+package gdquery
+
+import (
+	"testing"
+)
+
+func TestComputeHealth(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": `config_version=5
+
+[application]
+
+run/main_scene="res://main.tscn"
+`,
+		"main.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://player.tscn" id="1_a"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Player" parent="." instance=ExtResource("1_a")]
+`,
+		"player.tscn": `[gd_scene format=3]
+
+[node name="Player" type="CharacterBody2D"]
+
+[node name="Sprite" type="Sprite2D" parent="."]
+`,
+		"orphan.tscn": `[gd_scene format=3]
+
+[node name="Orphan" type="Node"]
+
+[node name="Child" type="Node" parent="Missing"]
+`,
+	})
+
+	ctx := &LintContext{Root: root, Dir: root}
+	report, err := computeHealth(ctx, HealthBudget{MaxNodes: 1, MaxKB: 1024, MaxDepth: 16})
+	if err != nil {
+		t.Fatalf("Health error: %v", err)
+	}
+
+	checks := make(map[string]*HealthCheck)
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	if check := checks["validate"]; check == nil || check.Issues != 1 || check.Score != 67 {
+		t.Errorf("Expected orphan.tscn to fail strict parsing, got: %+v", check)
+	}
+	if check := checks["unused"]; check == nil || check.Issues != 1 {
+		t.Errorf("Expected orphan.tscn to be the only unused scene, got: %+v", check)
+	}
+	if check := checks["budgets"]; check == nil || check.Issues != 3 {
+		t.Errorf("Expected every scene over 1 node, got: %+v", check)
+	}
+	if check := checks["cycles"]; check == nil || check.Score != 100 {
+		t.Errorf("Expected no cycles, got: %+v", check)
+	}
+	if report.Score <= 0 || report.Score >= 100 {
+		t.Errorf("Expected a partial score, got: %d", report.Score)
+	}
+}
@@ -0,0 +1,150 @@
+package gdquery
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// historyBarWidth is the width of the node count bar of the largest revision
+const historyBarWidth = 30
+
+// SceneRevision is the size of a scene at one commit
+type SceneRevision struct {
+	Commit  string
+	Date    string // author date, YYYY-MM-DD
+	Subject string
+	Path    string // path of the scene at the commit, relative to the repository root
+	Size    SceneSize
+}
+
+// sceneRevisions returns the commits that changed file, oldest first, following
+// renames. Merge commits and commits deleting the scene are left out.
+func sceneRevisions(file string) ([]*SceneRevision, error) {
+	dir, name := filepath.Split(file)
+	if dir == "" {
+		dir = "."
+	}
+	log, err := gitOutput(dir, "log", "--follow", "--date=short", "--format=%x1e%H%x1f%ad%x1f%s", "--name-only", "--", name)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []*SceneRevision
+	for _, record := range strings.Split(string(log), "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.SplitN(lines[0], "\x1f", 3)
+		if len(fields) != 3 || len(lines) < 2 {
+			continue
+		}
+		path := strings.TrimSpace(lines[len(lines)-1])
+		content, err := gitOutput(dir, "show", fields[0]+":"+path)
+		if err != nil {
+			continue
+		}
+		size, err := measureScene(content, path)
+		if err != nil {
+			logger.Warn("Skipping revision", "commit", fields[0], "error", err)
+			continue
+		}
+		revisions = append(revisions, &SceneRevision{Commit: fields[0], Date: fields[1], Subject: fields[2], Path: path, Size: size})
+	}
+
+	// git log lists the newest commit first
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+	return revisions, nil
+}
+
+// formatGrowth formats a metric with its change from the previous revision
+func formatGrowth(previous, current int, first bool) string {
+	if first || previous == current {
+		return strconv.Itoa(current)
+	}
+	return fmt.Sprintf("%d (%+d)", current, current-previous)
+}
+
+// printSceneHistory displays one row per revision with a bar of the node count
+func printSceneHistory(out io.Writer, revisions []*SceneRevision) {
+	maxNodes := 1
+	for _, revision := range revisions {
+		maxNodes = max(maxNodes, revision.Size.Nodes)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tCOMMIT\tNODES\tRESOURCES\tBYTES\t\tSUBJECT")
+	for i, revision := range revisions {
+		var previous SceneSize
+		if i > 0 {
+			previous = revisions[i-1].Size
+		}
+		subject := revision.Subject
+		if runes := []rune(subject); len(runes) > 50 {
+			subject = string(runes[:47]) + "..."
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", revision.Date, revision.Commit[:min(8, len(revision.Commit))],
+			formatGrowth(previous.Nodes, revision.Size.Nodes, i == 0),
+			formatGrowth(previous.Resources, revision.Size.Resources, i == 0),
+			formatGrowth(previous.Bytes, revision.Size.Bytes, i == 0),
+			strings.Repeat("#", max(1, revision.Size.Nodes*historyBarWidth/maxNodes)), subject)
+	}
+	w.Flush()
+}
+
+// writeSceneHistoryCSV writes the revisions as CSV, one row per commit
+func writeSceneHistoryCSV(out io.Writer, revisions []*SceneRevision) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"date", "commit", "path", "nodes", "resources", "bytes", "subject"})
+	for _, revision := range revisions {
+		w.Write([]string{revision.Date, revision.Commit, revision.Path,
+			strconv.Itoa(revision.Size.Nodes), strconv.Itoa(revision.Size.Resources),
+			strconv.Itoa(revision.Size.Bytes), revision.Subject})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history <tscn file>",
+	Short: "Show the node count, resource count and size of a scene over its git history",
+	Long: `Walk the git history of a scene, following renames, and print its node count, resource
+count (ext_resources and sub_resources) and file size at every commit that changed it, oldest
+first, with the change from the previous commit and a bar of the node count, to find when a
+scene blew up and which commit did it. -o csv writes the same rows as CSV for spreadsheets and
+plotting tools. Uncommitted changes are not included.`,
+	Example: `  gdq history levels/level_1.tscn
+  gdq history -o csv levels/level_1.tscn > level_1.csv`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "csv"); err != nil {
+			return err
+		}
+
+		revisions, err := sceneRevisions(args[0])
+		if err != nil {
+			return fmt.Errorf("history error: %v", err)
+		}
+		if len(revisions) == 0 {
+			return fmt.Errorf("no committed revisions of %s", args[0])
+		}
+
+		if outputFormat == "csv" {
+			return writeSceneHistoryCSV(out, revisions)
+		}
+		printSceneHistory(out, revisions)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,68 @@
+package gdquery
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestSceneRevisions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	small := "[gd_scene format=3]\n\n[node name=\"Main\" type=\"Node2D\"]\n"
+	root := writeProjectFiles(t, map[string]string{"main.tscn": small, "other.tscn": small})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "add scenes")
+
+	grown := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Texture2D" path="res://a.png" id="1"]
+
+[sub_resource type="CircleShape2D" id="2"]
+
+[node name="Main" type="Node2D"]
+
+[node name="A" type="Sprite2D" parent="."]
+
+[node name="B" type="Sprite2D" parent="."]
+`
+	if err := os.WriteFile(filepath.Join(root, "main.tscn"), []byte(grown), 0644); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	git("commit", "-q", "-am", "grow main")
+	git("mv", "main.tscn", "level.tscn")
+	git("commit", "-q", "-m", "rename main")
+	// Changes to other scenes are not part of the history
+	os.WriteFile(filepath.Join(root, "other.tscn"), []byte(grown), 0644)
+	git("commit", "-q", "-am", "grow other")
+
+	revisions, err := sceneRevisions(filepath.Join(root, "level.tscn"))
+	if err != nil {
+		t.Fatalf("History error: %v", err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("Expected 3 revisions, got %d", len(revisions))
+	}
+	first, grownRev, renamed := revisions[0], revisions[1], revisions[2]
+	if first.Subject != "add scenes" || first.Path != "main.tscn" || first.Size.Nodes != 1 || first.Size.Resources != 0 {
+		t.Errorf("Unexpected first revision: %+v", first)
+	}
+	if grownRev.Subject != "grow main" || grownRev.Size.Nodes != 3 || grownRev.Size.Resources != 2 || grownRev.Size.Bytes != len(grown) {
+		t.Errorf("Unexpected grown revision: %+v", grownRev)
+	}
+	if renamed.Subject != "rename main" || renamed.Path != "level.tscn" || renamed.Size.Nodes != 3 {
+		t.Errorf("Unexpected renamed revision: %+v", renamed)
+	}
+}
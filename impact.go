@@ -0,0 +1,179 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Impact command options
+var impactBase = ""
+var impactProject = "."
+var impactList = false
+
+// ImpactedScene is a scene affected by a change, directly or through its dependencies
+type ImpactedScene struct {
+	Scene string `json:"scene"`
+	// Via is the dependency chain from the scene to a changed file, empty
+	// when the scene itself changed
+	Via []string `json:"via"`
+}
+
+// changedResPath converts a changed file to its res:// path: .import and .uid
+// files stand for the file they describe
+func changedResPath(root, file string) string {
+	resPath := file
+	if !strings.HasPrefix(file, "res://") {
+		resPath = fsToRes(root, file)
+	}
+	resPath = strings.TrimSuffix(resPath, ".import")
+	return strings.TrimSuffix(resPath, ".uid")
+}
+
+// findImpactedScenes returns the scenes that changed or depend, through any
+// number of scenes, resources and scripts, on a changed file, sorted by path.
+// Each scene comes with the shortest dependency chain to a changed file.
+func findImpactedScenes(graph *DependencyGraph, changed []string) []*ImpactedScene {
+	dependents := make(map[string][]string)
+	exists := make(map[string]bool)
+	for _, file := range graph.Files {
+		exists[file] = true
+		for _, edge := range graph.Edges[file] {
+			dependents[edge.To] = append(dependents[edge.To], file)
+		}
+	}
+
+	// via holds the dependency through which each affected file was reached,
+	// "" for the changed files
+	via := make(map[string]string)
+	var queue []string
+	for _, file := range changed {
+		if _, seen := via[file]; !seen {
+			via[file] = ""
+			queue = append(queue, file)
+		}
+	}
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[file] {
+			if _, seen := via[dependent]; !seen {
+				via[dependent] = file
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	var scenes []*ImpactedScene
+	for file := range via {
+		// Deleted scenes are not affected, only the scenes using them
+		if !exists[file] || !hasExtension(file, nodeSceneExtensions) {
+			continue
+		}
+		scene := &ImpactedScene{Scene: file, Via: []string{}}
+		for next := via[file]; next != ""; next = via[next] {
+			scene.Via = append(scene.Via, next)
+		}
+		scenes = append(scenes, scene)
+	}
+	sort.Slice(scenes, func(i, j int) bool {
+		return scenes[i].Scene < scenes[j].Scene
+	})
+	return scenes
+}
+
+// printImpactedScenes displays the affected scenes with the dependency chain
+// that makes them affected
+func printImpactedScenes(out io.Writer, scenes []*ImpactedScene, changed int) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, scene := range scenes {
+		reason := "changed"
+		if len(scene.Via) > 0 {
+			reason = "-> " + strings.Join(scene.Via, " -> ")
+		}
+		fmt.Fprintf(w, "%s\t%s\n", scene.Scene, reason)
+	}
+	w.Flush()
+	if len(scenes) > 0 {
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "%d scene(s) affected by %d changed file(s)\n", len(scenes), changed)
+}
+
+var impactCmd = &cobra.Command{
+	Use:   "impact [changed files...]",
+	Short: "List the scenes affected by changed assets, scripts, resources and scenes",
+	Long: `Given changed files, list every scene that changed or depends on one of them, directly or through
+other scenes, resources and scripts (ext_resources, instances, preload and load calls), with the
+dependency chain that makes it affected. Use it to select the scenes to test and focus QA.
+
+Files are paths relative to the working directory or res:// paths; .import and .uid files stand
+for the file they describe. With --base, the files changed since a git revision (committed,
+uncommitted and untracked) are used. --list prints only the scene paths, one per line.`,
+	Example: `  gdq impact chars/player.gd textures/hero.png
+  gdq impact --base origin/main --list
+  git diff --name-only HEAD~1 | xargs gdq impact -o json`,
+	Args:         cobra.ArbitraryArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		if len(args) == 0 && impactBase == "" {
+			return fmt.Errorf("expected changed files or --base")
+		}
+		if _, err := os.Stat(impactProject); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", impactProject)
+		}
+
+		root := findProjectRoot(impactProject)
+		var changed []string
+		for _, file := range args {
+			changed = append(changed, changedResPath(root, file))
+		}
+		if impactBase != "" {
+			files, err := changedFiles(root, impactBase)
+			if err != nil {
+				return fmt.Errorf("git error: %v", err)
+			}
+			for _, file := range files {
+				changed = append(changed, changedResPath(root, filepath.Join(root, file)))
+			}
+		}
+
+		graph, err := buildDependencyGraph(root)
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+		scenes := findImpactedScenes(graph, changed)
+
+		switch {
+		case outputFormat == "json":
+			if scenes == nil {
+				scenes = []*ImpactedScene{}
+			}
+			return writeJSON(out, scenes)
+		case impactList:
+			for _, scene := range scenes {
+				fmt.Fprintln(out, scene.Scene)
+			}
+		default:
+			printImpactedScenes(out, scenes, len(changed))
+		}
+		return nil
+	},
+}
+
+func init() {
+	impactCmd.Flags().StringVar(&impactBase, "base", "", "Also use the files changed since this git revision")
+	impactCmd.Flags().StringVar(&impactProject, "project", ".", "Project directory")
+	impactCmd.Flags().BoolVar(&impactList, "list", false, "Print only the paths of the affected scenes")
+	rootCmd.AddCommand(impactCmd)
+}
@@ -0,0 +1,59 @@
+package gdquery
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFindImpactedScenes(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"chars/player.gd": `extends CharacterBody2D
+`,
+		"chars/player.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://chars/player.gd" id="1_s"]
+[ext_resource type="Texture2D" path="res://textures/hero.png" id="2_t"]
+
+[node name="Player" type="CharacterBody2D"]
+script = ExtResource("1_s")
+`,
+		"levels/level_1.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://chars/player.tscn" id="1_p"]
+
+[node name="Level" type="Node2D"]
+
+[node name="Player" parent="." instance=ExtResource("1_p")]
+`,
+		"ui/hud.tscn": `[gd_scene format=3]
+
+[node name="HUD" type="CanvasLayer"]
+`,
+	})
+
+	graph, err := buildDependencyGraph(root)
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+
+	format := func(scenes []*ImpactedScene) string {
+		var lines []string
+		for _, scene := range scenes {
+			lines = append(lines, fmt.Sprintf("%s %v", scene.Scene, scene.Via))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	scenes := findImpactedScenes(graph, []string{changedResPath(root, root+"/textures/hero.png.import")})
+	expected := "res://chars/player.tscn [res://textures/hero.png]\n" +
+		"res://levels/level_1.tscn [res://chars/player.tscn res://textures/hero.png]"
+	if got := format(scenes); got != expected {
+		t.Errorf("Unexpected scenes for hero.png:\n%s", got)
+	}
+
+	scenes = findImpactedScenes(graph, []string{"res://ui/hud.tscn", "res://chars/deleted.tscn"})
+	if got := format(scenes); got != "res://ui/hud.tscn []" {
+		t.Errorf("Expected only the changed hud.tscn, got:\n%s", got)
+	}
+}
@@ -0,0 +1,108 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+)
+
+// importedSceneExtensions lists the 3D formats Godot imports as scenes (or as a
+// Mesh for .obj), referenced by ext_resources through their source file
+var importedSceneExtensions = []string{".gltf", ".glb", ".fbx", ".blend", ".dae", ".obj"}
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "imported-scene-source",
+		Description: "ext_resources of imported 3D scenes (.gltf, .glb, .fbx, .blend, .dae, .obj) whose source file or .import file is missing",
+		Check:       checkImportedSceneSources,
+	})
+}
+
+// ImportedAsset is a source asset Godot imports, as described by its .import file
+type ImportedAsset struct {
+	Source        string `json:"source"`             // res:// path of the source asset
+	Importer      string `json:"importer,omitempty"` // scene, wavefront_obj, ...
+	Type          string `json:"type,omitempty"`     // type of the imported resource
+	Imported      string `json:"imported,omitempty"` // res:// path of the file Godot loads
+	SourceMissing bool   `json:"source_missing,omitempty"`
+	NotImported   bool   `json:"not_imported,omitempty"` // no .import file next to the source
+}
+
+// readImportedAsset describes the source asset at resPath from its .import file
+func readImportedAsset(root, resPath string) *ImportedAsset {
+	asset := &ImportedAsset{Source: resPath}
+	if _, err := os.Stat(resToFS(root, resPath)); err != nil {
+		asset.SourceMissing = true
+	}
+	config, err := parseConfigFile(resToFS(root, resPath) + ".import")
+	if err != nil {
+		asset.NotImported = true
+		return asset
+	}
+	asset.Importer = config.GetString("remap", "importer")
+	asset.Type = config.GetString("remap", "type")
+	asset.Imported = importedFile(root, resPath)
+	return asset
+}
+
+// String describes the import for dependency listings
+func (a *ImportedAsset) String() string {
+	var description string
+	switch {
+	case a.NotImported:
+		description = "not imported"
+	case a.Type != "":
+		description = fmt.Sprintf("%s importer, %s", a.Importer, a.Type)
+	default:
+		description = a.Importer + " importer"
+	}
+	if a.SourceMissing {
+		description += ", source missing"
+	}
+	return description
+}
+
+// addImportedAssets describes the imported scene assets the files of the graph
+// depend on, once every file has been scanned
+func (g *DependencyGraph) addImportedAssets() {
+	g.Imports = make(map[string]*ImportedAsset)
+	for _, edges := range g.Edges {
+		for _, edge := range edges {
+			if _, exists := g.Imports[edge.To]; !exists && hasExtension(edge.To, importedSceneExtensions) {
+				g.Imports[edge.To] = readImportedAsset(g.Root, edge.To)
+			}
+		}
+	}
+}
+
+// checkImportedSceneSources reports imported 3D scenes referenced by the files
+// of the linted directory whose source is missing (error) or that Godot has
+// not imported yet (warning)
+func checkImportedSceneSources(ctx *LintContext) []LintFinding {
+	graph := ctx.Graph()
+
+	var findings []LintFinding
+	for _, file := range graph.Files {
+		if !ctx.inDir(file) {
+			continue
+		}
+		for _, edge := range graph.Edges[file] {
+			asset := graph.Imports[edge.To]
+			switch {
+			case asset == nil:
+			case asset.SourceMissing:
+				findings = append(findings, LintFinding{
+					Severity: severityError,
+					File:     file,
+					Message:  fmt.Sprintf("imported scene %s does not exist", edge.To),
+				})
+			case asset.NotImported:
+				findings = append(findings, LintFinding{
+					Severity: severityWarning,
+					File:     file,
+					Message:  fmt.Sprintf("%s has no .import file; open the project in the editor to import it", edge.To),
+				})
+			}
+		}
+	}
+	return findings
+}
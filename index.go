@@ -0,0 +1,318 @@
+package gdquery
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// indexDir is the location of the project index relative to the project root,
+// one file per scene so that a query reads only the scenes it needs. Hidden
+// directories are ignored by Godot and by project scans.
+const indexDir = ".gdq/index"
+
+// indexVersion is bumped when the entry layout changes; older entries are rebuilt
+const indexVersion = 7
+
+// Index options
+var useIndex = false
+
+// IndexedScene is a parsed scene together with the file state it was parsed
+// from. Scenes are indexed with the default parse options.
+type IndexedScene struct {
+	Version      int                       `json:"version"`
+	Path         string                    `json:"path"` // res:// path of the scene
+	ModTime      int64                     `json:"mod_time"`
+	Size         int64                     `json:"size"`
+	UID          string                    `json:"uid,omitempty"`
+	GodotVersion GodotVersion              `json:"godot_version"`
+	LoadSteps    int                       `json:"load_steps"`
+	Format       int                       `json:"format"`
+	Resources    []string                  `json:"resources,omitempty"`
+	Nodes        []*IndexedNode            `json:"nodes"`
+	ExtResources map[string]*GodotResource `json:"ext_resources,omitempty"`
+	SubResources map[string]*GodotResource `json:"sub_resources,omitempty"`
+	Connections  []*GodotConnection        `json:"connections,omitempty"`
+}
+
+// IndexedNode is a node section as written in the scene; the tree is rebuilt on load
+type IndexedNode struct {
+	Name       string            `json:"name"`
+	Type       string            `json:"type,omitempty"`
+	Parent     string            `json:"parent,omitempty"`
+	Owner      string            `json:"owner,omitempty"`
+	Index      *int              `json:"index,omitempty"` // nil when absent
+	Script     string            `json:"script,omitempty"`
+	Instance   string            `json:"instance,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Span       SourceSpan        `json:"span"`
+}
+
+// indexDirPath returns the index directory of the project at root
+func indexDirPath(root string) string {
+	return filepath.Join(root, filepath.FromSlash(indexDir))
+}
+
+// indexEntryFile returns the file holding the index entry of a scene, named
+// after a hash of its res:// path
+func indexEntryFile(root, resPath string) string {
+	sum := sha1.Sum([]byte(resPath))
+	return filepath.Join(indexDirPath(root), hex.EncodeToString(sum[:])+".json")
+}
+
+// readIndexEntry reads the index entry of a scene, nil when the scene is not
+// indexed or was indexed by another index version
+func readIndexEntry(root, resPath string) (*IndexedScene, error) {
+	content, err := os.ReadFile(indexEntryFile(root, resPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var entry IndexedScene
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, fmt.Errorf("%s: %v", indexEntryFile(root, resPath), err)
+	}
+	if entry.Version != indexVersion || entry.Path != resPath {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// writeIndexEntry writes the index entry of a scene of the project at root
+func writeIndexEntry(root string, entry *IndexedScene) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(indexEntryFile(root, entry.Path), content, 0644)
+}
+
+// fresh reports whether the indexed scene still matches the file on disk
+func (s *IndexedScene) fresh(info os.FileInfo) bool {
+	return s.ModTime == info.ModTime().UnixNano() && s.Size == info.Size()
+}
+
+// indexScene converts a parsed scene into an index entry
+func indexScene(scene *GodotScene, resPath string, info os.FileInfo) *IndexedScene {
+	entry := &IndexedScene{
+		Version:      indexVersion,
+		Path:         resPath,
+		ModTime:      info.ModTime().UnixNano(),
+		Size:         info.Size(),
+		UID:          scene.UID,
+		GodotVersion: scene.Version,
+		LoadSteps:    scene.LoadSteps,
+		Format:       scene.Format,
+		Resources:    scene.Resources,
+		Nodes:        make([]*IndexedNode, 0, len(scene.AllNodes)),
+		ExtResources: scene.ExtResources,
+		SubResources: scene.SubResources,
+		Connections:  scene.Connections,
+	}
+	for _, node := range scene.AllNodes {
+		var index *int
+		if node.Index >= 0 {
+			index = &node.Index
+		}
+		entry.Nodes = append(entry.Nodes, &IndexedNode{
+			Name:       node.OriginalName,
+			Type:       node.Type,
+			Parent:     node.Parent,
+			Owner:      node.Owner,
+			Index:      index,
+			Script:     node.Script,
+			Instance:   node.Instance,
+			Properties: node.Properties,
+			Span:       node.Span,
+		})
+	}
+	return entry
+}
+
+// scene rebuilds the scene of an index entry; with forest, nodes whose parent
+// does not exist are kept as detached trees like ParseOptions.Forest does
+func (s *IndexedScene) scene(file string, forest bool) *GodotScene {
+	scene := &GodotScene{
+		File:         file,
+		UID:          s.UID,
+		Version:      s.GodotVersion,
+		LoadSteps:    s.LoadSteps,
+		Format:       s.Format,
+		AllNodes:     make([]*GodotNode, 0, len(s.Nodes)),
+		Resources:    s.Resources,
+		Extensions:   make([]string, 0),
+		ExtResources: s.ExtResources,
+		SubResources: s.SubResources,
+		Connections:  s.Connections,
+	}
+	if scene.ExtResources == nil {
+		scene.ExtResources = make(map[string]*GodotResource)
+	}
+	if scene.SubResources == nil {
+		scene.SubResources = make(map[string]*GodotResource)
+	}
+	for _, entry := range s.Nodes {
+		node := &GodotNode{
+			Name:       entry.Name,
+			Type:       entry.Type,
+			Parent:     entry.Parent,
+			Owner:      entry.Owner,
+			Index:      -1,
+			Script:     entry.Script,
+			Instance:   entry.Instance,
+			Properties: entry.Properties,
+			Children:   make([]*GodotNode, 0),
+			Span:       entry.Span,
+		}
+		if entry.Index != nil {
+			node.Index = *entry.Index
+		}
+		if node.Properties == nil {
+			node.Properties = make(map[string]string)
+		}
+		scene.AllNodes = append(scene.AllNodes, node)
+	}
+	buildSceneTree(scene, forest, logger)
+	return scene
+}
+
+// updateProjectIndex parses the scenes under root that changed since they were
+// indexed and drops the entries of scenes that no longer exist. It returns the
+// number of indexed scenes and the number of scenes parsed.
+func updateProjectIndex(root string) (int, int, error) {
+	files, err := findProjectFiles(root, nodeSceneExtensions)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := os.MkdirAll(indexDirPath(root), 0755); err != nil {
+		return 0, 0, err
+	}
+	// Older versions kept the whole index in a single file
+	os.Remove(filepath.Join(filepath.Dir(indexDirPath(root)), "index.json"))
+
+	indexed, updated := 0, 0
+	keep := make(map[string]bool)
+	progress := newProgress("Indexing", len(files))
+	defer progress.Clear()
+	for _, file := range files {
+		progress.Step()
+		resPath := fsToRes(root, file)
+		info, err := os.Stat(file)
+		if err != nil {
+			return indexed, updated, err
+		}
+		if entry, err := readIndexEntry(root, resPath); err == nil && entry != nil && entry.fresh(info) {
+			keep[indexEntryFile(root, resPath)] = true
+			indexed++
+			continue
+		}
+
+		scene, err := ParseFile(file, ParseOptions{Logger: logger})
+		if err != nil {
+			logger.Warn("Skipping file", "path", file, "error", err)
+			continue
+		}
+		if err := writeIndexEntry(root, indexScene(scene, resPath, info)); err != nil {
+			return indexed, updated, err
+		}
+		keep[indexEntryFile(root, resPath)] = true
+		indexed++
+		updated++
+	}
+
+	entries, err := os.ReadDir(indexDirPath(root))
+	if err != nil {
+		return indexed, updated, err
+	}
+	for _, entry := range entries {
+		if file := filepath.Join(indexDirPath(root), entry.Name()); !keep[file] {
+			os.Remove(file)
+		}
+	}
+	return indexed, updated, nil
+}
+
+// parseSceneFile parses a scene for display. With --use-index the scene is
+// taken from the project index when the file has not changed since indexing;
+// with --expand-instances instanced scenes are replaced by their nodes.
+func parseSceneFile(file string) (*GodotScene, error) {
+	scene, err := loadSceneFile(file)
+	if err != nil {
+		return nil, err
+	}
+	root := findProjectRoot(scene.File)
+	resolveInstanceTypes(scene, root, newInstanceTypeCache(), logger)
+	if expandInstances {
+		expandSceneInstances(scene, root)
+	}
+	resolveScriptClasses(scene)
+	return scene, nil
+}
+
+// loadSceneFile parses a scene or reads it from the index with --use-index.
+// The index holds scenes parsed with the default options: --structure-only
+// and --low-memory parse the file.
+func loadSceneFile(file string) (*GodotScene, error) {
+	opts := sceneParseOptions()
+	if !useIndex || opts.SkipProperties || opts.MaxValueSize > 0 {
+		return ParseFile(file, opts)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	root := findProjectRoot(file)
+	entry, err := readIndexEntry(root, fsToRes(root, file))
+	if err != nil {
+		logger.Warn("Ignoring unreadable index entry", "path", file, "error", err)
+	} else if entry != nil && entry.fresh(info) {
+		logger.Debug("Using index", "path", file)
+		return entry.scene(file, opts.Forest), nil
+	}
+
+	logger.Info("Scene not indexed or changed, parsing", "path", file)
+	return ParseFile(file, opts)
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index [project dir]",
+	Short: "Build the project index used by --use-index",
+	Long: `Parse every scene of a project and store its nodes, types, scripts, properties and resources
+in ` + indexDir + ` under the project root, one file per scene. Only scenes changed since the last
+run are parsed again. Queries and grep run with --use-index read the entries of the unchanged
+scenes they need instead of parsing them.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		root := findProjectRoot(dir)
+		indexed, updated, err := updateProjectIndex(root)
+		if err != nil {
+			return fmt.Errorf("failed to update index: %v", err)
+		}
+
+		fmt.Fprintf(out, "Indexed %d scenes (%d updated) in %s\n", indexed, updated, indexDirPath(root))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	rootCmd.Flags().BoolVar(&useIndex, "use-index", false, "Read unchanged scenes from the project index built by gdq index")
+}
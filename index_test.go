@@ -0,0 +1,147 @@
+package gdquery
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProjectIndex(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://main.gd" id="1_s"]
+
+[node name="Main" type="Node2D"]
+script = ExtResource("1_s")
+
+[node name="Player" type="CharacterBody2D" parent="."]
+position = Vector2(10, 20)
+`,
+		"level/level.tscn": `[gd_scene format=3]
+
+[node name="Level" type="Node"]
+`,
+	})
+
+	if indexed, updated, err := updateProjectIndex(root); err != nil || indexed != 2 || updated != 2 {
+		t.Fatalf("Expected 2 updated scenes, got %d of %d (%v)", updated, indexed, err)
+	}
+
+	// Unchanged scenes are not parsed again, the entries of removed scenes are dropped
+	levelEntry := indexEntryFile(root, "res://level/level.tscn")
+	if err := os.Remove(filepath.Join(root, "level", "level.tscn")); err != nil {
+		t.Fatal(err)
+	}
+	if indexed, updated, err := updateProjectIndex(root); err != nil || indexed != 1 || updated != 0 {
+		t.Fatalf("Expected 0 updated of 1 scene, got %d of %d (%v)", updated, indexed, err)
+	}
+	if _, err := os.Stat(levelEntry); !os.IsNotExist(err) {
+		t.Errorf("Expected the entry of the removed scene to be deleted")
+	}
+
+	entry, err := readIndexEntry(root, "res://main.tscn")
+	if err != nil || entry == nil {
+		t.Fatalf("Expected an index entry, got %v", err)
+	}
+	scene := entry.scene("main.tscn", false)
+	player := findNodeByPath(scene, "Player")
+	if player == nil || player.Path != "Main/Player" || player.Properties["position"] != "Vector2(10, 20)" {
+		t.Fatalf("Unexpected indexed node: %+v", player)
+	}
+	if resolveResourcePath(scene.RootNode.Script, scene) != "res://main.gd" {
+		t.Errorf("Unexpected script: %s", scene.RootNode.Script)
+	}
+
+	// A changed file is parsed instead of read from the stale entry
+	useIndex = true
+	defer func() { useIndex = false }()
+	file := filepath.Join(root, "main.tscn")
+	if err := os.WriteFile(file, []byte("[gd_scene format=3]\n\n[node name=\"Changed\" type=\"Node\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(file, future, future); err != nil {
+		t.Fatal(err)
+	}
+	scene, err = parseSceneFile(file)
+	if err != nil || scene.RootNode.Name != "Changed" {
+		t.Errorf("Expected the changed scene to be parsed, got %+v (%v)", scene.RootNode, err)
+	}
+}
+
+// tamperIndexEntry renames the root node of the indexed scene at resPath, so
+// that tests can tell the index from a parse
+func tamperIndexEntry(t *testing.T, root, resPath string) {
+	t.Helper()
+	entry, err := readIndexEntry(root, resPath)
+	if err != nil || entry == nil {
+		t.Fatalf("Expected an index entry for %s, got %v", resPath, err)
+	}
+	entry.Nodes[0].Name = "Indexed"
+	if err := writeIndexEntry(root, entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUseIndex(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn":  "[gd_scene format=3]\n\n[node name=\"Main\" type=\"Node\"]\n",
+		"other.tscn": "[gd_scene format=3]\n\n[node name=\"Other\" type=\"Node\"]\n",
+	})
+	if _, _, err := updateProjectIndex(root); err != nil {
+		t.Fatal(err)
+	}
+	tamperIndexEntry(t, root, "res://main.tscn")
+	// Only the entries of the requested scenes are read
+	if err := os.WriteFile(indexEntryFile(root, "res://other.tscn"), []byte("{corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "main.tscn")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"--use-index", file}, &stdout, &stderr); code != 0 || !strings.HasPrefix(stdout.String(), "Indexed (Node)") {
+		t.Errorf("Expected the scene read from the index, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	if code := Run([]string{"grep", "--use-index", "Indexed", file}, &stdout, &stderr); code != 0 || !strings.Contains(stdout.String(), ":Indexed: name: Indexed") {
+		t.Errorf("Expected grep to read the index, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+
+	// The index holds full scenes: other parse options parse the file
+	for _, flag := range []string{"--structure-only", "--low-memory"} {
+		stdout.Reset()
+		if code := Run([]string{"--use-index", flag, file}, &stdout, &stderr); code != 0 || !strings.HasPrefix(stdout.String(), "Main (Node)") {
+			t.Errorf("%s: expected the scene to be parsed, got %d: %s%s", flag, code, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestUseIndexForest(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"forest.tscn": `[gd_scene format=3]
+
+[node name="Root" type="Node"]
+
+[node name="Lost" type="Node" parent="Missing"]
+`,
+	})
+	if _, _, err := updateProjectIndex(root); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "forest.tscn")
+
+	for _, args := range [][]string{{"--forest", file}, {"--forest", "--use-index", file}} {
+		var stdout, stderr bytes.Buffer
+		if code := Run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: exit status %d: %s", args, code, stderr.String())
+		}
+		if got := stdout.String(); !strings.HasPrefix(got, "Root (Node)\n\n") || !strings.Contains(got, "Detached tree (parent Missing not found):\nLost (Node)") {
+			t.Errorf("%v: expected Lost as a detached tree, got:\n%s", args, got)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// inputActionCallRe matches Input/InputEvent calls taking a single action name
+var inputActionCallRe = regexp.MustCompile(`\b(is_action(?:_pressed|_just_pressed|_just_released|_released)?|get_action_strength|get_action_raw_strength|action_press|action_release)\(\s*&?"([^"]+)"`)
+
+// inputAxisCallRe matches Input.get_axis/get_vector calls taking several action names
+var inputAxisCallRe = regexp.MustCompile(`\b(get_axis|get_vector)\(([^)]*)\)`)
+
+// InputActionUse is a reference to an input action in a script
+type InputActionUse struct {
+	Action string
+	File   string // res:// path of the script
+	Line   int
+}
+
+// projectInputActions returns the input actions defined in project.godot
+func projectInputActions(project *ConfigFile) []string {
+	section := project.Section("input")
+	if section == nil {
+		return nil
+	}
+	return append([]string{}, section.Keys...)
+}
+
+// isBuiltinInputAction reports whether Godot defines the action by default
+func isBuiltinInputAction(action string) bool {
+	return strings.HasPrefix(action, "ui_")
+}
+
+// findInputActionUses scans a script for input action names passed to Input calls
+func findInputActionUses(file, resPath string) ([]InputActionUse, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var uses []InputActionUse
+	for i, line := range strings.Split(string(content), "\n") {
+		// Ignore commented out code
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, matches := range inputActionCallRe.FindAllStringSubmatch(line, -1) {
+			uses = append(uses, InputActionUse{Action: matches[2], File: resPath, Line: i + 1})
+		}
+		for _, matches := range inputAxisCallRe.FindAllStringSubmatch(line, -1) {
+			for _, action := range parseStringList(matches[2]) {
+				uses = append(uses, InputActionUse{Action: action, File: resPath, Line: i + 1})
+			}
+		}
+	}
+	return uses, nil
+}
+
+// usedScripts returns the scripts reachable from the scenes and autoloads of the project
+func usedScripts(ctx *LintContext) []string {
+	graph := ctx.Graph()
+
+	var queue []string
+	for _, file := range graph.Files {
+		if hasExtension(file, nodeSceneExtensions) {
+			queue = append(queue, file)
+		}
+	}
+	if section := ctx.Project().Section("autoload"); section != nil {
+		for _, key := range section.Keys {
+			queue = append(queue, strings.TrimPrefix(unquoteValue(section.Values[key]), "*"))
+		}
+	}
+
+	visited := make(map[string]bool)
+	var scripts []string
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		if visited[file] {
+			continue
+		}
+		visited[file] = true
+		if hasExtension(file, scriptExtensions) {
+			scripts = append(scripts, file)
+		}
+		for _, edge := range graph.Edges[file] {
+			queue = append(queue, edge.To)
+		}
+	}
+
+	sort.Strings(scripts)
+	return scripts
+}
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "input-actions",
+		Description: "Input actions used in scripts but not defined in project.godot, and defined actions never used",
+		Check:       checkInputActions,
+	})
+}
+
+// checkInputActions cross-checks the input actions used by scripts against project.godot
+func checkInputActions(ctx *LintContext) []LintFinding {
+	defined := make(map[string]bool)
+	for _, action := range projectInputActions(ctx.Project()) {
+		defined[action] = true
+	}
+
+	var findings []LintFinding
+	used := make(map[string]bool)
+	for _, script := range usedScripts(ctx) {
+		uses, err := findInputActionUses(resToFS(ctx.Root, script), script)
+		if err != nil {
+			logger.Warn("Skipping script", "path", script, "error", err)
+			continue
+		}
+		for _, use := range uses {
+			used[use.Action] = true
+			if defined[use.Action] || isBuiltinInputAction(use.Action) || !ctx.inDir(use.File) {
+				continue
+			}
+			findings = append(findings, LintFinding{
+				Severity: severityError,
+				File:     use.File,
+				Line:     use.Line,
+				Message:  fmt.Sprintf("input action %q is not defined in project.godot", use.Action),
+			})
+		}
+	}
+
+	for _, action := range projectInputActions(ctx.Project()) {
+		if !used[action] {
+			findings = append(findings, LintFinding{
+				File:    "res://project.godot",
+				Message: fmt.Sprintf("input action %q is never used by a script", action),
+			})
+		}
+	}
+
+	return findings
+}
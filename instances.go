@@ -0,0 +1,83 @@
+package gdquery
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxInstanceDepth bounds the chain of inherited scenes followed to find a root type
+const maxInstanceDepth = 16
+
+// instancedRootType returns the root type of the scene at file, following
+// inherited scenes whose root is itself an instance. visiting holds the
+// files of the chain being followed, guarding against instance cycles.
+func instancedRootType(file string, cache *instanceTypeCache, visiting map[string]bool, log *slog.Logger) string {
+	if rootType, exists := cache.get(file); exists {
+		return rootType
+	}
+	if visiting[file] || len(visiting) >= maxInstanceDepth {
+		return ""
+	}
+	visiting[file] = true
+
+	scene, err := ParseFile(file, ParseOptions{SkipProperties: true, Logger: log})
+	if err != nil || scene.RootNode == nil {
+		log.Debug("Instanced scene not resolved", "path", file, "error", err)
+		return ""
+	}
+	rootType := scene.RootNode.Type
+	if rootType == "" {
+		if path := instancedScenePath(scene.RootNode, scene, findProjectRoot(file)); path != "" {
+			rootType = instancedRootType(path, cache, visiting, log)
+		}
+	}
+	cache.set(file, rootType)
+	return rootType
+}
+
+// instancedScenePath returns the file instanced by a node, or "" when the
+// node is not an instance or the reference cannot be resolved
+func instancedScenePath(node *GodotNode, scene *GodotScene, root string) string {
+	if node.Instance == "" {
+		return ""
+	}
+	path := resolveResourcePath(node.Instance, scene)
+	if path == "" || strings.HasPrefix(path, "uid://") {
+		return ""
+	}
+	return resToFS(root, normalizeResPath(fsToRes(root, scene.File), path))
+}
+
+// resolveInstanceTypes fills in InstanceOf for instanced nodes without a type
+// (inherited scene roots and instanced children) and, when the instanced scene
+// is in the project at root, InstanceType with the type of its root. Scenes
+// that cannot be read are logged to log.
+func resolveInstanceTypes(scene *GodotScene, root string, cache *instanceTypeCache, log *slog.Logger) {
+	for _, node := range scene.AllNodes {
+		if node.Instance == "" || node.Type != "" {
+			continue
+		}
+		ref := resolveResourcePath(node.Instance, scene)
+		if ref == "" {
+			continue
+		}
+		node.InstanceOf = ref
+		if path := instancedScenePath(node, scene, root); path != "" {
+			if _, err := os.Stat(path); err == nil {
+				node.InstanceType = instancedRootType(path, cache, make(map[string]bool), log)
+			}
+		}
+	}
+}
+
+// instanceLabel describes an instanced node without a type: "instance of
+// player.tscn", prefixed with the resolved root type when known
+func instanceLabel(node *GodotNode) string {
+	label := "instance of " + filepath.Base(node.InstanceOf)
+	if node.InstanceType != "" {
+		label = node.InstanceType + ", " + label
+	}
+	return label
+}
@@ -0,0 +1,49 @@
+package gdquery
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveInstanceTypes(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"player.tscn": "[gd_scene format=3]\n\n[node name=\"Player\" type=\"CharacterBody2D\"]\n",
+		// Inherited scene of an inherited scene
+		"hero.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://player.tscn" id="1_a"]
+
+[node name="Hero" instance=ExtResource("1_a")]
+`,
+		"levels/boss.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="PackedScene" path="res://hero.tscn" id="1_a"]
+[ext_resource type="PackedScene" path="res://missing.tscn" id="2_b"]
+
+[node name="Boss" instance=ExtResource("1_a")]
+
+[node name="Minion" parent="." instance=ExtResource("2_b")]
+
+[node name="Hitbox" type="Area2D" parent="."]
+`,
+	})
+
+	scene, err := parseSceneFile(filepath.Join(root, "levels", "boss.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	expected := map[string]string{
+		"Boss":        "CharacterBody2D, instance of hero.tscn",
+		"Boss/Minion": "instance of missing.tscn",
+		"Boss/Hitbox": "Area2D",
+	}
+	for _, node := range scene.AllNodes {
+		if label := typeLabel(node); label != expected[node.Path] {
+			t.Errorf("%s: unexpected type label %q", node.Path, label)
+		}
+	}
+	if scene.RootNode.Type != "" || scene.RootNode.InstanceOf != "res://hero.tscn" {
+		t.Errorf("Unexpected root: type %q, instance of %q", scene.RootNode.Type, scene.RootNode.InstanceOf)
+	}
+}
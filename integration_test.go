@@ -1,8 +1,9 @@
-package main
+package gdquery
 
 import (
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -205,3 +206,46 @@ func TestParsingPerformance(t *testing.T) {
 		})
 	}
 }
+
+// Check that the numbers of every math value in the demo projects parse
+func TestDemoProjectNumbers(t *testing.T) {
+	if !checkSubmoduleInitialized(t) {
+		return
+	}
+
+	tscnFiles, err := findTscnFiles("test/godot-demo-projects")
+	if err != nil {
+		t.Fatalf("tscn file search error: %v", err)
+	}
+	if len(tscnFiles) == 0 {
+		t.Skip("No tscn files found")
+	}
+
+	// Number of components of each math type
+	components := map[string]int{
+		"Vector2": 2, "Vector2i": 2, "Vector3": 3, "Vector3i": 3, "Vector4": 4, "Color": 4,
+		"Rect2": 4, "Rect2i": 4, "Quaternion": 4, "Transform2D": 6, "Basis": 9, "Transform3D": 12, "Transform": 12,
+	}
+	mathValueRe := regexp.MustCompile(`^(\w+)\(([^()"]*)\)$`)
+
+	checked := 0
+	for _, file := range tscnFiles {
+		scene, err := ParseTscnFile(file)
+		if err != nil {
+			continue
+		}
+		for _, node := range scene.AllNodes {
+			for prop, value := range node.Properties {
+				matches := mathValueRe.FindStringSubmatch(value)
+				if matches == nil || components[matches[1]] == 0 {
+					continue
+				}
+				checked++
+				if numbers := parseNumberList(value); len(numbers) != components[matches[1]] {
+					t.Errorf("%s: %s.%s = %s: parsed %d number(s), expected %d", file, node.Path, prop, value, len(numbers), components[matches[1]])
+				}
+			}
+		}
+	}
+	t.Logf("Checked %d math values", checked)
+}
@@ -0,0 +1,163 @@
+package gdquery
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Text size estimates, relative to the font size. Real widths depend on the
+// font; these match Godot's default font closely enough to spot risky labels.
+const (
+	glyphWidthFactor     = 0.6 // average width of a Latin glyph
+	wideGlyphWidthFactor = 1.0 // CJK and other full-width glyphs
+	lineHeightFactor     = 1.4
+)
+
+// bbcodeTagRe matches [tags] of RichTextLabel text
+var bbcodeTagRe = regexp.MustCompile(`\[/?[a-zA-Z_]+[^\]]*\]`)
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "label-overflow",
+		Description: "Label/RichTextLabel text that likely does not fit the node size for its font size and autowrap settings",
+		Check:       checkLabelOverflow,
+	})
+}
+
+// estimateTextWidth estimates the width in pixels of a single line of text
+func estimateTextWidth(line string, fontSize int) float64 {
+	width := 0.0
+	for _, r := range line {
+		if isWideRune(r) {
+			width += wideGlyphWidthFactor
+		} else {
+			width += glyphWidthFactor
+		}
+	}
+	return width * float64(fontSize)
+}
+
+// isWideRune reports whether r is usually rendered full-width
+func isWideRune(r rune) bool {
+	return utf8.RuneLen(r) >= 3 && (unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) || (r >= 0xFF00 && r <= 0xFFEF))
+}
+
+// labelFitsRect estimates the text size of a label and compares it with the
+// available size. It returns a description of the problem, or "" when the text fits.
+func labelFitsRect(text string, fontSize int, autowrap bool, width, height float64) string {
+	lines := strings.Split(text, "\n")
+	lineHeight := float64(fontSize) * lineHeightFactor
+
+	if !autowrap {
+		widest := 0.0
+		for _, line := range lines {
+			widest = max(widest, estimateTextWidth(line, fontSize))
+		}
+		if width > 0 && widest > width {
+			return fmt.Sprintf("text needs ~%.0fpx at font size %d but the label is %.0fpx wide", widest, fontSize, width)
+		}
+		return ""
+	}
+
+	if width <= 0 {
+		return ""
+	}
+	wrapped := 0
+	for _, line := range lines {
+		wrapped += max(1, int(math.Ceil(estimateTextWidth(line, fontSize)/width)))
+	}
+	needed := float64(wrapped) * lineHeight
+	if height > 0 && needed > height {
+		return fmt.Sprintf("text wraps to ~%d lines (~%.0fpx) at font size %d but the label is %.0fpx tall", wrapped, needed, fontSize, height)
+	}
+	return ""
+}
+
+// checkLabelOverflow estimates the text size of every Label and RichTextLabel
+// and reports labels whose text does not fit their rect
+func checkLabelOverflow(ctx *LintContext) []LintFinding {
+	var findings []LintFinding
+
+	viewport, err := parseViewportSize(layoutViewport)
+	if err != nil {
+		viewport = layoutRect{W: 1152, H: 648}
+	}
+	project := ctx.Project()
+	if w, ok := intValue(project.GetString("display", "window/size/viewport_width")); ok {
+		viewport.W = float64(w)
+	}
+	if h, ok := intValue(project.GetString("display", "window/size/viewport_height")); ok {
+		viewport.H = float64(h)
+	}
+
+	themes := newThemeResolver(ctx.Root, project)
+	for _, result := range ctx.Scenes() {
+		if result.Err != nil || result.Scene.RootNode == nil {
+			continue
+		}
+		scene := result.Scene
+
+		for _, layout := range computeControlLayouts(scene.RootNode, viewport) {
+			node := layout.Node
+			item := ""
+			switch {
+			case classInherits(node.Type, "RichTextLabel"):
+				item = "normal_font_size"
+			case classInherits(node.Type, "Label"):
+				item = "font_size"
+			default:
+				continue
+			}
+
+			text := unquoteValue(node.Properties["text"])
+			if item == "normal_font_size" && node.Properties["bbcode_enabled"] == "true" {
+				text = bbcodeTagRe.ReplaceAllString(text, "")
+			}
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+
+			autowrap := floatProperty(node, 0, "autowrap_mode") != 0
+			if scene.Version.Major != 4 {
+				// Godot 3 used autowrap = true
+				autowrap = autowrap || node.Properties["autowrap"] == "true"
+			}
+			if item == "normal_font_size" {
+				// RichTextLabel wraps by default
+				autowrap = floatProperty(node, 3, "autowrap_mode") != 0
+			}
+
+			width, height := layout.Rect.W, layout.Rect.H
+			if layout.Managed {
+				// Containers size children to at least their minimum size,
+				// so only an explicit minimum size bounds the text
+				width, height = 0, 0
+				if minSize, exists := node.Properties["custom_minimum_size"]; exists {
+					if values := parseNumberList(minSize); len(values) == 2 {
+						width, height = values[0], values[1]
+					}
+				}
+			}
+
+			problem := labelFitsRect(text, themes.FontSize(scene, node, item), autowrap, width, height)
+			if problem == "" {
+				continue
+			}
+			if node.Properties["clip_text"] == "true" || floatProperty(node, 0, "text_overrun_behavior") != 0 {
+				problem += " (text is clipped)"
+			}
+			findings = append(findings, LintFinding{
+				File:    result.File,
+				Node:    node.Path,
+				Message: problem,
+			})
+		}
+	}
+
+	return findings
+}
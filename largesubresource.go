@@ -0,0 +1,81 @@
+package gdquery
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxSubResourceKB is the size above which an embedded sub_resource is reported
+const defaultMaxSubResourceKB = 256
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "large-sub-resource",
+		Description: "Embedded sub_resources larger than max_kb (default 256), which should be saved as separate .tres/.res files",
+		Check:       checkLargeSubResources,
+	})
+}
+
+// formatSize formats a byte count in bytes, KB or MB
+func formatSize(bytes int) string {
+	switch {
+	case bytes >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+	case bytes >= 1024:
+		return fmt.Sprintf("%d KB", bytes/1024)
+	}
+	return fmt.Sprintf("%d bytes", bytes)
+}
+
+// checkLargeSubResources reports sub_resources of scenes and resources whose
+// serialized section exceeds the configured size. Embedded images, meshes and
+// tile data make every change rewrite megabytes of text in version control.
+func checkLargeSubResources(ctx *LintContext) []LintFinding {
+	limit := defaultMaxSubResourceKB
+	if value, exists := ctx.option("large-sub-resource", "max_kb"); exists {
+		kb, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			logger.Warn("Invalid large-sub-resource max_kb", "value", value)
+		} else {
+			limit = kb
+		}
+	}
+
+	files, err := findProjectFiles(ctx.Dir, sceneExtensions)
+	if err != nil {
+		logger.Warn("Scene scan failed", "error", err)
+		return nil
+	}
+
+	var findings []LintFinding
+	for _, file := range files {
+		// Sub-resource spans do not need the node properties
+		scene, err := ParseFile(file, ParseOptions{SkipProperties: true, Logger: logger})
+		if err != nil {
+			logger.Warn("Skipping file", "path", file, "error", err)
+			continue
+		}
+
+		var large []*GodotResource
+		for _, resource := range scene.SubResources {
+			if resource.Span.Size() > limit*1024 {
+				large = append(large, resource)
+			}
+		}
+		sort.Slice(large, func(i, j int) bool {
+			return large[i].Span.StartLine < large[j].Span.StartLine
+		})
+
+		for _, resource := range large {
+			findings = append(findings, LintFinding{
+				File: fsToRes(ctx.Root, file),
+				Line: resource.Span.StartLine,
+				Message: fmt.Sprintf("sub_resource %s (%s) embeds %s (max %d KB); save it as a separate .tres/.res file",
+					resource.ID, resource.Type, formatSize(resource.Span.Size()), limit),
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,89 @@
+package gdquery
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"strings"
+)
+
+// lowMemoryValueSize is the size in bytes over which --low-memory summarizes property values
+const lowMemoryValueSize = 16 * 1024
+
+// largeValuePreviewSize is the number of bytes of a summarized value kept as a preview
+const largeValuePreviewSize = 64
+
+// largeValue is the summary of a property value too large to retain: its
+// length, FNV-1a hash and first bytes
+type largeValue struct {
+	size    int
+	hash    hash.Hash64
+	preview strings.Builder
+}
+
+func newLargeValue() *largeValue {
+	return &largeValue{hash: fnv.New64a()}
+}
+
+// write adds the next part of the value
+func (v *largeValue) write(s string) {
+	v.size += len(s)
+	v.hash.Write([]byte(s))
+	if rest := largeValuePreviewSize - v.preview.Len(); rest > 0 {
+		v.preview.WriteString(s[:min(rest, len(s))])
+	}
+}
+
+// String returns the placeholder stored in place of the value, e.g.
+// <large value: 52428800 bytes, fnv64a 9b3c5e0f1a2d4c6e, PackedByteArray(137, 80...>
+func (v *largeValue) String() string {
+	preview := strings.ToValidUTF8(strings.ReplaceAll(v.preview.String(), "\n", " "), "")
+	return fmt.Sprintf("<large value: %d bytes, fnv64a %016x, %s...>", v.size, v.hash.Sum64(), preview)
+}
+
+// summarizeLargeValue returns the placeholder of a value over limit bytes, or
+// the value itself; limit 0 keeps every value
+func summarizeLargeValue(value string, limit int) string {
+	if limit <= 0 || len(value) <= limit {
+		return value
+	}
+	v := newLargeValue()
+	v.write(value)
+	return v.String()
+}
+
+// valueBuffer accumulates a property value spanning several lines. Once it
+// grows over limit bytes (0 for no limit) only a largeValue summary is kept.
+type valueBuffer struct {
+	limit int
+	text  strings.Builder
+	large *largeValue
+}
+
+// WriteString appends the next part of the value
+func (b *valueBuffer) WriteString(s string) {
+	if b.large != nil {
+		b.large.write(s)
+		return
+	}
+	b.text.WriteString(s)
+	if b.limit > 0 && b.text.Len() > b.limit {
+		b.large = newLargeValue()
+		b.large.write(b.text.String())
+		b.text = strings.Builder{}
+	}
+}
+
+// String returns the value, or its summary when it went over the limit
+func (b *valueBuffer) String() string {
+	if b.large != nil {
+		return b.large.String()
+	}
+	return b.text.String()
+}
+
+// Reset empties the buffer for the next value
+func (b *valueBuffer) Reset() {
+	b.text.Reset()
+	b.large = nil
+}
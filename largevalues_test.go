@@ -0,0 +1,54 @@
+package gdquery
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"testing"
+)
+
+func TestMaxValueSize(t *testing.T) {
+	data := "PackedByteArray(" + strings.Repeat("255, ", 100) + "0)"
+	content := `[gd_scene format=3]
+
+[node name="Root" type="Node3D"]
+position = Vector3(1, 2, 3)
+data = ` + data + `
+block = [
+` + strings.Repeat("1, 2, 3,\n", 20) + `]
+text = "` + strings.Repeat("line of text\n", 20) + `end"
+`
+
+	scene, err := ParseReader(strings.NewReader(content), "huge.tscn", ParseOptions{MaxValueSize: 100})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	properties := scene.RootNode.Properties
+	if properties["position"] != "Vector3(1, 2, 3)" {
+		t.Errorf("Expected small values to be kept: %q", properties["position"])
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(data))
+	expected := fmt.Sprintf("<large value: %d bytes, fnv64a %016x, %s...>", len(data), hash.Sum64(), data[:largeValuePreviewSize])
+	if properties["data"] != expected {
+		t.Errorf("Unexpected summary:\n%s\nexpected:\n%s", properties["data"], expected)
+	}
+	for _, key := range []string{"block", "text"} {
+		if value := properties[key]; !strings.HasPrefix(value, "<large value: ") || len(value) > 200 {
+			t.Errorf("Expected %s to be summarized: %q", key, value)
+		}
+	}
+
+	// Without a limit the values are kept as written
+	full, err := ParseReader(strings.NewReader(content), "huge.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if full.RootNode.Properties["data"] != data {
+		t.Errorf("Expected the full value without MaxValueSize")
+	}
+	if got := summarizeLargeValue(full.RootNode.Properties["block"], 100); got != properties["block"] {
+		t.Errorf("Streamed summary %q differs from %q", properties["block"], got)
+	}
+}
@@ -0,0 +1,167 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// editorSection is the section of gdq.cfg holding the Godot executable:
+// godot = "/path/to/godot"
+const editorSection = "editor"
+
+// godotBinaryEnv names the environment variable overriding the Godot executable
+const godotBinaryEnv = "GODOT_BIN"
+
+// godotBinaryNames are looked up on the PATH when no executable is configured
+var godotBinaryNames = []string{"godot4", "godot", "godot3"}
+
+// godotBinary finds the Godot executable: GODOT_BIN, then godot in the [editor]
+// section of the configuration of the project, then godot4, godot or godot3 on
+// the PATH. A configured path relative to the project root is only run with
+// GDQ_PROJECT_PLUGINS=1, like plugins; absolute paths and names on the PATH are.
+func godotBinary(root string) (string, error) {
+	if path := os.Getenv(godotBinaryEnv); path != "" {
+		return path, nil
+	}
+	config, err := loadToolConfig(root)
+	if err != nil {
+		return "", fmt.Errorf("config error: %v", err)
+	}
+	if value, exists := config.Get(editorSection, "godot"); exists {
+		path := unquoteValue(value)
+		if filepath.IsAbs(path) || !strings.ContainsAny(path, `/\`) {
+			return path, nil
+		}
+		if os.Getenv(projectPluginsEnv) != "1" {
+			return "", fmt.Errorf("godot=%q in %s is relative to the project: set %s=1 to run it, or use an absolute path",
+				path, toolConfigFile, projectPluginsEnv)
+		}
+		return filepath.Join(root, path), nil
+	}
+	for _, name := range godotBinaryNames {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("Godot not found: set %s or godot in the [%s] section of %s", godotBinaryEnv, editorSection, toolConfigFile)
+}
+
+// godotArgs returns the arguments starting Godot on the project at root with
+// the scene file, in the editor when edit is true and running it otherwise
+func godotArgs(root, file string, edit bool) []string {
+	args := []string{"--path", root}
+	if edit {
+		args = append(args, "--editor")
+	}
+	return append(args, fsToRes(root, file))
+}
+
+// godotSceneCommand checks the scene file and builds the Godot command for it
+func godotSceneCommand(file string, edit bool) (*exec.Cmd, error) {
+	if !hasExtension(file, nodeSceneExtensions) {
+		return nil, fmt.Errorf("not a scene: %s", file)
+	}
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("file not found: %s", file)
+	}
+	root, err := filepath.Abs(findProjectRoot(file))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(root, "project.godot")); err != nil {
+		return nil, fmt.Errorf("no project.godot found above %s", file)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := godotBinary(root)
+	if err != nil {
+		return nil, err
+	}
+	command := exec.Command(binary, godotArgs(root, abs, edit)...)
+	command.Dir = root
+	logger.Debug("Starting Godot", "binary", binary, "args", command.Args[1:])
+	return command, nil
+}
+
+var openCmd = &cobra.Command{
+	Use:   "open <tscn file> [node path]",
+	Short: "Open a scene in the Godot editor",
+	Long: `Start the Godot editor on the project containing the scene, with the scene open. The
+editor runs in the background. Godot is found from the GODOT_BIN environment variable, then
+from godot in the [editor] section of gdq.cfg in the project root, then as godot4, godot
+or godot3 on the PATH. A configured godot relative to the project root is only run with
+GDQ_PROJECT_PLUGINS=1.
+
+Godot has no command line option to select a node, so a node path is checked against the
+scene and printed to find in the Scene dock.`,
+	Example: `  gdq open levels/level_1.tscn
+  gdq open levels/level_1.tscn HUD/Score
+  GODOT_BIN=~/bin/Godot_v4.3 gdq open ui/menu.tscn`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		command, err := godotSceneCommand(args[0], true)
+		if err != nil {
+			return err
+		}
+		nodePath := ""
+		if len(args) > 1 {
+			scene, err := ParseFile(args[0], ParseOptions{Logger: logger})
+			if err != nil {
+				return fmt.Errorf("parse error: %v", err)
+			}
+			node := findNodeByPath(scene, args[1])
+			if node == nil {
+				return fmt.Errorf("node not found: %s", args[1])
+			}
+			nodePath = node.Path
+		}
+
+		if err := command.Start(); err != nil {
+			return fmt.Errorf("cannot start Godot: %v", err)
+		}
+		fmt.Fprintf(out, "Opened %s in the Godot editor\n", args[0])
+		if nodePath != "" {
+			fmt.Fprintf(out, "Select %s in the Scene dock\n", nodePath)
+		}
+		return command.Process.Release()
+	},
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <tscn file>",
+	Short: "Play a single scene with Godot",
+	Long: `Run the scene with Godot, as "Run Current Scene" in the editor, and wait for it to exit. The
+output of the game is shown in the terminal. Godot is found as for the open command.`,
+	Example:      `  gdq run levels/level_1.tscn`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		command, err := godotSceneCommand(args[0], false)
+		if err != nil {
+			return err
+		}
+		command.Stdin = cmd.InOrStdin()
+		command.Stdout = out
+		command.Stderr = cmd.ErrOrStderr()
+		if err := command.Run(); err != nil {
+			return fmt.Errorf("godot failed: %v", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,62 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGodotBinary(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": "",
+		"gdq.cfg":       "[editor]\ngodot=\"tools/godot\"\n",
+		"gdqlint.cfg":   "[editor]\ngodot=\"tools/old-godot\"\n",
+		"levels/a.tscn": "[gd_scene format=3]\n\n[node name=\"A\" type=\"Node\"]\n",
+		"levels/a.tres": "[gd_resource type=\"Resource\" format=3]\n\n[resource]\n",
+	})
+
+	t.Setenv(godotBinaryEnv, "")
+	t.Setenv(projectPluginsEnv, "")
+	// Executables of the project are only run when enabled
+	if binary, err := godotBinary(root); err == nil {
+		t.Errorf("Expected an error for a binary relative to the project, got %s", binary)
+	}
+	t.Setenv(projectPluginsEnv, "1")
+	binary, err := godotBinary(root)
+	if err != nil || binary != filepath.Join(root, "tools", "godot") {
+		t.Errorf("Expected the configured binary, got %s (%v)", binary, err)
+	}
+
+	// gdqlint.cfg is read when there is no gdq.cfg
+	if err := os.Remove(filepath.Join(root, "gdq.cfg")); err != nil {
+		t.Fatal(err)
+	}
+	if binary, _ := godotBinary(root); binary != filepath.Join(root, "tools", "old-godot") {
+		t.Errorf("Expected the binary configured in gdqlint.cfg, got %s", binary)
+	}
+
+	t.Setenv(godotBinaryEnv, "/opt/godot/godot")
+	if binary, _ := godotBinary(root); binary != "/opt/godot/godot" {
+		t.Errorf("Expected %s to override the configuration, got %s", godotBinaryEnv, binary)
+	}
+
+	command, err := godotSceneCommand(filepath.Join(root, "levels", "a.tscn"), true)
+	if err != nil {
+		t.Fatalf("Command error: %v", err)
+	}
+	if args := strings.Join(command.Args, " "); args != "/opt/godot/godot --path "+root+" --editor res://levels/a.tscn" {
+		t.Errorf("Unexpected command: %s", args)
+	}
+	command, _ = godotSceneCommand(filepath.Join(root, "levels", "a.tscn"), false)
+	if args := strings.Join(command.Args[1:], " "); args != "--path "+root+" res://levels/a.tscn" {
+		t.Errorf("Unexpected command: %s", args)
+	}
+
+	if _, err := godotSceneCommand(filepath.Join(root, "levels", "a.tres"), true); err == nil {
+		t.Error("Expected an error for a resource")
+	}
+	if _, err := godotSceneCommand(filepath.Join(root, "levels", "missing.tscn"), true); err == nil {
+		t.Error("Expected an error for a missing scene")
+	}
+}
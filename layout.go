@@ -0,0 +1,224 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Layout view options
+var showLayout = false
+var layoutViewport = "1152x648"
+
+// layoutRect is an estimated rectangle in viewport coordinates
+type layoutRect struct {
+	X, Y, W, H float64
+}
+
+// ControlLayout holds the layout settings and estimated rect of a Control node
+type ControlLayout struct {
+	Node       *GodotNode
+	Anchors    [4]float64 // left, top, right, bottom
+	Offsets    [4]float64 // left, top, right, bottom
+	SizeFlagsH int
+	SizeFlagsV int
+	Rect       layoutRect
+	Managed    bool // positioned by a parent Container
+	Warnings   []string
+}
+
+// layoutSides lists the side suffixes of anchor_*/offset_* properties
+var layoutSides = [4]string{"left", "top", "right", "bottom"}
+
+// parseViewportSize parses a "WIDTHxHEIGHT" string
+func parseViewportSize(size string) (layoutRect, error) {
+	parts := strings.SplitN(strings.ToLower(size), "x", 2)
+	if len(parts) != 2 {
+		return layoutRect{}, fmt.Errorf("invalid viewport size: %s (expected WIDTHxHEIGHT)", size)
+	}
+	w, errW := strconv.ParseFloat(parts[0], 64)
+	h, errH := strconv.ParseFloat(parts[1], 64)
+	if errW != nil || errH != nil {
+		return layoutRect{}, fmt.Errorf("invalid viewport size: %s (expected WIDTHxHEIGHT)", size)
+	}
+	return layoutRect{W: w, H: h}, nil
+}
+
+// floatProperty returns a numeric property, trying each name in order
+func floatProperty(node *GodotNode, def float64, names ...string) float64 {
+	for _, name := range names {
+		if value, exists := node.Properties[name]; exists {
+			if f, err := parseVariantFloat(value); err == nil {
+				return f
+			}
+		}
+	}
+	return def
+}
+
+// parseNumberList extracts the numbers of a constructor value like Vector2(10, 20)
+func parseNumberList(value string) []float64 {
+	if i := strings.Index(value, "("); i >= 0 {
+		value = value[i+1:]
+	}
+	var numbers []float64
+	for _, span := range numberSpans(value) {
+		if f, err := parseVariantFloat(value[span[0]:span[1]]); err == nil {
+			numbers = append(numbers, f)
+		}
+	}
+	return numbers
+}
+
+// isControlNode reports whether the node type is a Control
+func isControlNode(node *GodotNode) bool {
+	return classInherits(node.Type, "Control")
+}
+
+// computeControlLayouts estimates the rect of every Control under node
+func computeControlLayouts(node *GodotNode, viewport layoutRect) []*ControlLayout {
+	var layouts []*ControlLayout
+
+	var walk func(node *GodotNode, parentRect layoutRect, parentType string)
+	walk = func(node *GodotNode, parentRect layoutRect, parentType string) {
+		rect := parentRect
+		if isControlNode(node) {
+			layout := computeControlLayout(node, parentRect, parentType)
+			layouts = append(layouts, layout)
+			rect = layout.Rect
+		} else {
+			// Controls below non-Control nodes are laid out against the viewport
+			rect = viewport
+		}
+		for _, child := range node.Children {
+			walk(child, rect, node.Type)
+		}
+	}
+	walk(node, viewport, "")
+
+	return layouts
+}
+
+// computeControlLayout estimates the rect of a single Control inside parentRect
+func computeControlLayout(node *GodotNode, parentRect layoutRect, parentType string) *ControlLayout {
+	layout := &ControlLayout{Node: node}
+
+	for i, side := range layoutSides {
+		layout.Anchors[i] = floatProperty(node, 0, "anchor_"+side)
+		// Godot 3 used margin_* for offsets
+		layout.Offsets[i] = floatProperty(node, 0, "offset_"+side, "margin_"+side)
+	}
+	layout.SizeFlagsH = int(floatProperty(node, 1, "size_flags_horizontal"))
+	layout.SizeFlagsV = int(floatProperty(node, 1, "size_flags_vertical"))
+
+	if classInherits(parentType, "Container") {
+		// Containers override anchors and offsets of their children
+		layout.Managed = true
+		layout.Rect = parentRect
+		return layout
+	}
+
+	left := parentRect.X + layout.Anchors[0]*parentRect.W + layout.Offsets[0]
+	top := parentRect.Y + layout.Anchors[1]*parentRect.H + layout.Offsets[1]
+	right := parentRect.X + layout.Anchors[2]*parentRect.W + layout.Offsets[2]
+	bottom := parentRect.Y + layout.Anchors[3]*parentRect.H + layout.Offsets[3]
+	layout.Rect = layoutRect{X: left, Y: top, W: right - left, H: bottom - top}
+
+	for i, anchor := range layout.Anchors {
+		if anchor < 0 || anchor > 1 {
+			layout.Warnings = append(layout.Warnings, fmt.Sprintf("anchor_%s %s is outside the parent (0..1)", layoutSides[i], formatLayoutNumber(anchor)))
+		}
+	}
+	if layout.Anchors[0] > layout.Anchors[2] {
+		layout.Warnings = append(layout.Warnings, "anchor_left is greater than anchor_right")
+	}
+	if layout.Anchors[1] > layout.Anchors[3] {
+		layout.Warnings = append(layout.Warnings, "anchor_top is greater than anchor_bottom")
+	}
+	if layout.Rect.W < 0 || layout.Rect.H < 0 {
+		layout.Warnings = append(layout.Warnings, fmt.Sprintf("negative size %sx%s", formatLayoutNumber(layout.Rect.W), formatLayoutNumber(layout.Rect.H)))
+	}
+
+	return layout
+}
+
+// formatLayoutNumber formats a float without unnecessary decimals
+func formatLayoutNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatSizeFlags formats Control size flags (SIZE_FILL, SIZE_EXPAND, SIZE_SHRINK_*)
+func formatSizeFlags(flags int) string {
+	var names []string
+	switch {
+	case flags&8 != 0:
+		names = append(names, "shrink_end")
+	case flags&4 != 0:
+		names = append(names, "shrink_center")
+	case flags&1 == 0:
+		names = append(names, "shrink_begin")
+	}
+	if flags&2 != 0 {
+		names = append(names, "expand")
+	}
+	if flags&1 != 0 {
+		names = append(names, "fill")
+	}
+	return strings.Join(names, "|")
+}
+
+// joinLayoutNumbers formats a side quadruple
+func joinLayoutNumbers(values [4]float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatLayoutNumber(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printControlLayout displays the layout table and warnings for Controls under node
+func printControlLayout(out io.Writer, node *GodotNode) error {
+	viewport, err := parseViewportSize(layoutViewport)
+	if err != nil {
+		return err
+	}
+
+	layouts := computeControlLayouts(node, viewport)
+	if len(layouts) == 0 {
+		fmt.Fprintln(out, "No Control nodes found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tTYPE\tANCHORS (L, T, R, B)\tOFFSETS (L, T, R, B)\tSIZE FLAGS (H / V)\tRECT (X, Y, W x H)")
+	for _, layout := range layouts {
+		rect := fmt.Sprintf("%s, %s, %s x %s",
+			formatLayoutNumber(layout.Rect.X), formatLayoutNumber(layout.Rect.Y),
+			formatLayoutNumber(layout.Rect.W), formatLayoutNumber(layout.Rect.H))
+		if layout.Managed {
+			rect = "(container)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s / %s\t%s\n",
+			layout.Node.Path, layout.Node.Type,
+			joinLayoutNumbers(layout.Anchors), joinLayoutNumbers(layout.Offsets),
+			formatSizeFlags(layout.SizeFlagsH), formatSizeFlags(layout.SizeFlagsV), rect)
+	}
+	w.Flush()
+
+	warningCount := 0
+	for _, layout := range layouts {
+		warningCount += len(layout.Warnings)
+	}
+	if warningCount > 0 {
+		fmt.Fprintln(out, "\n=== Layout Warnings ===")
+		for _, layout := range layouts {
+			for _, warning := range layout.Warnings {
+				fmt.Fprintf(out, "%s: %s\n", layout.Node.Path, warning)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package gdquery
+
+import (
+	"os"
+	"testing"
+)
+
+func TestControlLayout(t *testing.T) {
+	content := `[gd_scene format=3]
+
+[node name="UI" type="Control"]
+anchor_right = 1.0
+anchor_bottom = 1.0
+
+[node name="Panel" type="Panel" parent="."]
+anchor_left = 0.5
+anchor_right = 0.5
+offset_left = -100.0
+offset_top = 20.0
+offset_right = 100.0
+offset_bottom = 120.0
+
+[node name="Broken" type="Label" parent="Panel"]
+anchor_right = 1.5
+offset_right = -400.0
+
+[node name="Box" type="VBoxContainer" parent="Panel"]
+
+[node name="Item" type="Button" parent="Panel/Box"]
+offset_right = 9999.0
+`
+
+	tempFile := "test_layout.tscn"
+	if err := os.WriteFile(tempFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	layouts := computeControlLayouts(scene.RootNode, layoutRect{W: 1000, H: 500})
+	if len(layouts) != 5 {
+		t.Fatalf("Expected 5 Control layouts, got: %d", len(layouts))
+	}
+
+	byName := make(map[string]*ControlLayout)
+	for _, layout := range layouts {
+		byName[layout.Node.OriginalName] = layout
+	}
+
+	if rect := byName["UI"].Rect; rect != (layoutRect{W: 1000, H: 500}) {
+		t.Errorf("UI rect is wrong: %+v", rect)
+	}
+	if rect := byName["Panel"].Rect; rect != (layoutRect{X: 400, Y: 20, W: 200, H: 100}) {
+		t.Errorf("Panel rect is wrong: %+v", rect)
+	}
+
+	// anchor 1.5 is outside the parent and the resulting width is negative
+	if warnings := byName["Broken"].Warnings; len(warnings) != 2 {
+		t.Errorf("Expected 2 warnings for Broken, got: %v", warnings)
+	}
+	if !byName["Item"].Managed || len(byName["Item"].Warnings) != 0 {
+		t.Errorf("Container children should be managed without warnings: %+v", byName["Item"])
+	}
+}
@@ -0,0 +1,76 @@
+package gdquery
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// lineReader reads lines of any length, unlike bufio.Scanner whose tokens are
+// limited by its buffer. Embedded meshes and tilemaps can store PackedByteArray
+// values of tens of megabytes on a single line.
+type lineReader struct {
+	reader *bufio.Reader
+	line   string
+	size   int // bytes consumed by the last line, including its line ending
+	err    error
+	// maxSize fails the read on longer lines; 0 for no limit
+	maxSize int
+}
+
+// newLineReader returns a lineReader reading from r
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{reader: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Scan advances to the next line, returning false at the end of the input or on error
+func (r *lineReader) Scan() bool {
+	if r.err != nil {
+		return false
+	}
+
+	// Accumulate the chunks of a long line until the newline
+	var b strings.Builder
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		b.Write(chunk)
+		if r.maxSize > 0 && b.Len() > r.maxSize {
+			r.err = fmt.Errorf("line longer than %d bytes", r.maxSize)
+			return false
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			r.err = err
+			if b.Len() == 0 {
+				return false
+			}
+		}
+		break
+	}
+	raw := b.String()
+
+	r.size = len(raw)
+	r.line = strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+	return true
+}
+
+// Text returns the last line read, without its line ending
+func (r *lineReader) Text() string {
+	return r.line
+}
+
+// Size returns the number of bytes of the last line, including its line ending
+func (r *lineReader) Size() int {
+	return r.size
+}
+
+// Err returns the first error other than io.EOF
+func (r *lineReader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
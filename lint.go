@@ -0,0 +1,368 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Lint command options
+var lintRuleNames []string
+var lintListRules = false
+var lintConfigPath = ""
+var lintFix = false
+
+// lintConfigFile is the lint configuration looked up in the project root
+const lintConfigFile = "gdqlint.cfg"
+
+// Lint severities
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// LintFinding is a problem reported by a lint rule
+type LintFinding struct {
+	Rule     string
+	Severity string
+	File     string // res:// path of the offending file
+	Line     int    // 1-based line in File, if known
+	Node     string // node path, if the finding is about a node
+	Message  string
+}
+
+// LintContext gives lint rules access to the project being linted
+type LintContext struct {
+	Root    string
+	Dir     string
+	Config  *ConfigFile // rule options, one [section] per rule
+	scenes  []*SceneScanResult
+	graph   *DependencyGraph
+	project *ConfigFile
+	version *GodotVersion
+}
+
+// Scenes returns the parsed scenes under the linted directory
+func (c *LintContext) Scenes() []*SceneScanResult {
+	if c.scenes == nil {
+		c.scenes, _ = scanProject(c.Root, c.Dir, ParseOptions{Logger: logger})
+	}
+	return c.scenes
+}
+
+// Graph returns the project dependency graph
+func (c *LintContext) Graph() *DependencyGraph {
+	if c.graph == nil {
+		graph, err := buildDependencyGraph(c.Root)
+		if err != nil {
+			logger.Warn("Dependency scan failed", "error", err)
+			graph = &DependencyGraph{Root: c.Root, Edges: make(map[string][]DependencyEdge)}
+		}
+		c.graph = graph
+	}
+	return c.graph
+}
+
+// Project returns the parsed project.godot, or an empty config when it is missing
+func (c *LintContext) Project() *ConfigFile {
+	if c.project == nil {
+		project, err := parseConfigFile(filepath.Join(c.Root, "project.godot"))
+		if err != nil {
+			logger.Debug("No project settings", "root", c.Root, "error", err)
+			project = &ConfigFile{}
+		}
+		c.project = project
+	}
+	return c.project
+}
+
+// GodotVersion returns the Godot version of the project, from project.godot or,
+// when it gives no hint, from the first scene that does
+func (c *LintContext) GodotVersion() GodotVersion {
+	if c.version == nil {
+		version := detectProjectVersion(c.Project())
+		if !version.Known() {
+			for _, result := range c.Scenes() {
+				if result.Err == nil && result.Scene.Version.Known() {
+					version = result.Scene.Version
+					break
+				}
+			}
+		}
+		c.version = &version
+	}
+	return *c.version
+}
+
+// reset drops the scenes, graph and version read so far, after fixes rewrote files
+func (c *LintContext) reset() {
+	c.scenes, c.graph, c.version = nil, nil, nil
+}
+
+// option returns the raw value of a rule option from the lint configuration
+func (c *LintContext) option(rule, key string) (string, bool) {
+	if c.Config == nil {
+		return "", false
+	}
+	return c.Config.Get(rule, key)
+}
+
+// enabled reports whether a rule is enabled in the lint configuration
+func (c *LintContext) enabled(rule string) bool {
+	value, exists := c.option(rule, "enabled")
+	return !exists || value != "false"
+}
+
+// loadLintConfig reads the lint configuration from path, or from the project
+// root when path is empty. A missing default configuration is not an error.
+func loadLintConfig(root, path string) (*ConfigFile, error) {
+	if path == "" {
+		path = filepath.Join(root, lintConfigFile)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return &ConfigFile{}, nil
+		}
+	}
+	return parseConfigFile(path)
+}
+
+// inDir reports whether a res:// path lies under the linted directory
+func (c *LintContext) inDir(resPath string) bool {
+	dirRes := fsToRes(c.Root, c.Dir)
+	return dirRes == "res://" || resPath == dirRes || strings.HasPrefix(resPath, dirRes+"/")
+}
+
+// LintRule is a named check over a project
+type LintRule struct {
+	Name        string
+	Description string
+	Check       func(ctx *LintContext) []LintFinding
+	// Fix rewrites the files with problems the rule can repair and returns
+	// the number of fixed files; nil when the rule has no fix
+	Fix func(ctx *LintContext) (int, error)
+	// Versions lists the Godot major versions the rule applies to; empty for all
+	Versions []int
+}
+
+// appliesTo reports whether the rule applies to projects of the given version.
+// Rules run when the version is unknown.
+func (r *LintRule) appliesTo(version GodotVersion) bool {
+	if len(r.Versions) == 0 || !version.Known() {
+		return true
+	}
+	for _, major := range r.Versions {
+		if major == version.Major {
+			return true
+		}
+	}
+	return false
+}
+
+// versionNote describes the versions the rule applies to and whether it is
+// fixable, for --list-rules
+func (r *LintRule) versionNote() string {
+	var notes []string
+	if len(r.Versions) > 0 {
+		var majors []string
+		for _, major := range r.Versions {
+			majors = append(majors, fmt.Sprintf("Godot %d", major))
+		}
+		notes = append(notes, strings.Join(majors, ", ")+" only")
+	}
+	if r.Fix != nil {
+		notes = append(notes, "fixable")
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(notes, ", ") + ")"
+}
+
+// lintRules holds all registered rules
+var lintRules []*LintRule
+
+// registerLintRule adds a rule to the linter
+func registerLintRule(rule *LintRule) {
+	lintRules = append(lintRules, rule)
+}
+
+// findLintRule returns the rule with the given name, or nil
+func findLintRule(name string) *LintRule {
+	for _, rule := range lintRules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// selectLintRules returns the given rules, or all enabled rules when empty,
+// that apply to the Godot version of the project
+func selectLintRules(ctx *LintContext, rules []*LintRule) []*LintRule {
+	if len(rules) == 0 {
+		for _, rule := range lintRules {
+			if ctx.enabled(rule.Name) {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	var selected []*LintRule
+	for _, rule := range rules {
+		if !rule.appliesTo(ctx.GodotVersion()) {
+			logger.Info("Skipping lint rule for this Godot version", "rule", rule.Name, "version", ctx.GodotVersion().String())
+			continue
+		}
+		selected = append(selected, rule)
+	}
+	return selected
+}
+
+// fixLint runs the fixes of the given rules (all enabled rules when empty) and
+// prints the number of files each one fixed
+func fixLint(out io.Writer, ctx *LintContext, rules []*LintRule) error {
+	for _, rule := range selectLintRules(ctx, rules) {
+		if rule.Fix == nil {
+			continue
+		}
+		logger.Debug("Fixing lint rule", "rule", rule.Name)
+		fixed, err := rule.Fix(ctx)
+		if err != nil {
+			return fmt.Errorf("%s fix failed: %v", rule.Name, err)
+		}
+		if fixed > 0 {
+			fmt.Fprintf(out, "Fixed %d file(s) [%s]\n", fixed, rule.Name)
+			ctx.reset()
+		}
+	}
+	return nil
+}
+
+// runLint runs the given rules (all enabled rules when empty) and returns sorted findings
+func runLint(ctx *LintContext, rules []*LintRule) []LintFinding {
+	var findings []LintFinding
+	for _, rule := range selectLintRules(ctx, rules) {
+		logger.Debug("Running lint rule", "rule", rule.Name)
+		severity, overridden := ctx.option(rule.Name, "severity")
+		for _, finding := range rule.Check(ctx) {
+			finding.Rule = rule.Name
+			if overridden {
+				finding.Severity = unquoteValue(severity)
+			}
+			if finding.Severity == "" {
+				finding.Severity = severityWarning
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Node < findings[j].Node
+	})
+
+	return findings
+}
+
+// printLintFindings displays findings as "file[:line][:node]: severity: message [rule]"
+func printLintFindings(out io.Writer, findings []LintFinding) {
+	for _, finding := range findings {
+		location := finding.File
+		if finding.Line > 0 {
+			location += fmt.Sprintf(":%d", finding.Line)
+		}
+		if finding.Node != "" {
+			location += ":" + finding.Node
+		}
+		fmt.Fprintf(out, "%s: %s: %s [%s]\n", location, finding.Severity, finding.Message, finding.Rule)
+	}
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [project dir]",
+	Short: "Check a project for common problems",
+	Long: `Run lint rules over the scenes, resources and scripts of a project. Exits non-zero when a finding with error severity is reported.
+
+Rules are configured in gdqlint.cfg in the project root (or the file given with --config),
+with one [section] per rule. Set enabled=false in a section to disable the rule and
+severity="error" or severity="warning" to change the severity of its findings.
+
+With --fix, the rules that can repair their problems (marked fixable in --list-rules) rewrite
+the files first; the remaining problems are then reported.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if lintListRules {
+			for _, rule := range lintRules {
+				fmt.Fprintf(out, "%s: %s%s\n", rule.Name, rule.Description, rule.versionNote())
+			}
+			return nil
+		}
+
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		var rules []*LintRule
+		for _, name := range lintRuleNames {
+			rule := findLintRule(name)
+			if rule == nil {
+				return fmt.Errorf("unknown lint rule: %s", name)
+			}
+			rules = append(rules, rule)
+		}
+
+		root := findProjectRoot(dir)
+		config, err := loadLintConfig(root, lintConfigPath)
+		if err != nil {
+			return fmt.Errorf("lint config error: %v", err)
+		}
+
+		ctx := &LintContext{Root: root, Dir: dir, Config: config}
+		if lintFix {
+			if err := fixLint(out, ctx, rules); err != nil {
+				return err
+			}
+		}
+		findings := runLint(ctx, rules)
+		printLintFindings(out, findings)
+
+		errorCount := 0
+		for _, finding := range findings {
+			if finding.Severity == severityError {
+				errorCount++
+			}
+		}
+		if len(findings) > 0 {
+			fmt.Fprintf(out, "\n%d problem(s) (%d error(s), %d warning(s))\n", len(findings), errorCount, len(findings)-errorCount)
+		}
+		if errorCount > 0 {
+			return fmt.Errorf("lint failed with %d error(s)", errorCount)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringArrayVar(&lintRuleNames, "rule", nil, "Run only the given rule (repeatable)")
+	lintCmd.Flags().BoolVar(&lintListRules, "list-rules", false, "List the available lint rules")
+	lintCmd.Flags().BoolVar(&lintFix, "fix", false, "Repair the problems of fixable rules before reporting")
+	lintCmd.Flags().StringVar(&lintConfigPath, "config", "", "Lint configuration file (default: gdqlint.cfg in the project root)")
+	rootCmd.AddCommand(lintCmd)
+}
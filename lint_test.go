@@ -0,0 +1,714 @@
+package gdquery
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lintProjectDir runs a single lint rule over an existing project directory
+func lintProjectDir(t *testing.T, rule string, root string) []LintFinding {
+	t.Helper()
+
+	lintRule := findLintRule(rule)
+	if lintRule == nil {
+		t.Fatalf("Lint rule not registered: %s", rule)
+	}
+	return runLint(&LintContext{Root: root, Dir: root}, []*LintRule{lintRule})
+}
+
+func TestResPathCaseRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Texture2D" path="res://Assets/Icon.png" id="1_a"]
+[ext_resource type="Texture2D" path="res://assets/icon.png" id="2_b"]
+[ext_resource type="Texture2D" path="res://linked/logo.png" id="3_c"]
+
+[node name="Main" type="Node2D"]
+`,
+		"player.gd":           "extends Node\nvar scene = preload(\"res://MAIN.tscn\")\n",
+		"assets/icon.png":     "",
+		"shared/art/logo.png": "",
+	})
+
+	// Symlinked directories are resolved like regular ones
+	if err := os.Symlink(filepath.Join(root, "shared", "art"), filepath.Join(root, "linked")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	findings := lintProjectDir(t, "res-path-case", root)
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got: %v", findings)
+	}
+
+	if findings[0].File != "res://main.tscn" || !strings.Contains(findings[0].Message, "res://assets/icon.png") {
+		t.Errorf("Unexpected finding: %+v", findings[0])
+	}
+	if findings[1].File != "res://player.gd" || findings[1].Severity != severityError {
+		t.Errorf("Unexpected finding: %+v", findings[1])
+	}
+}
+
+func TestLabelOverflowRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": "[gui]\n\ntheme/custom=\"res://project_theme.tres\"\n",
+		"project_theme.tres": `[gd_resource type="Theme" format=3]
+
+[resource]
+default_font_size = 10
+`,
+		"ui/theme.tres": `[gd_resource type="Theme" format=3]
+
+[resource]
+Label/font_sizes/font_size = 32
+`,
+		"ui/menu.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Theme" path="res://ui/theme.tres" id="1_theme"]
+
+[sub_resource type="LabelSettings" id="LabelSettings_1"]
+font_size = 40
+
+[node name="Menu" type="Control"]
+theme = ExtResource("1_theme")
+
+[node name="Themed" type="Label" parent="."]
+offset_right = 100.0
+offset_bottom = 30.0
+text = "Start Game"
+
+[node name="Small" type="Label" parent="."]
+offset_right = 100.0
+offset_bottom = 30.0
+theme_override_font_sizes/font_size = 8
+text = "Start Game"
+
+[node name="Settings" type="Label" parent="."]
+offset_right = 150.0
+offset_bottom = 50.0
+label_settings = SubResource("LabelSettings_1")
+text = "Options"
+
+[node name="Wrapped" type="Label" parent="."]
+offset_right = 100.0
+offset_bottom = 20.0
+autowrap_mode = 3
+clip_text = true
+text = "A very long description that wraps"
+
+[node name="Box" type="VBoxContainer" parent="."]
+
+[node name="Managed" type="Label" parent="Box"]
+text = "A very long text inside a container"
+`,
+		"hud.tscn": `[gd_scene format=3]
+
+[node name="Hud" type="Control"]
+
+[node name="Score" type="Label" parent="."]
+offset_right = 50.0
+offset_bottom = 20.0
+text = "Score: 1000"
+`,
+	})
+
+	findings := lintProjectDir(t, "label-overflow", root)
+
+	var nodes []string
+	for _, finding := range findings {
+		nodes = append(nodes, finding.File+":"+finding.Node)
+	}
+	expected := []string{
+		"res://hud.tscn:Hud/Score",         // project theme default_font_size 10: 66px > 50px
+		"res://ui/menu.tscn:Menu/Settings", // LabelSettings font size 40
+		"res://ui/menu.tscn:Menu/Themed",   // ancestor theme font size 32
+		"res://ui/menu.tscn:Menu/Wrapped",
+	}
+	if strings.Join(nodes, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected findings for %v, got %v", expected, findings)
+	}
+
+	if !strings.Contains(findings[3].Message, "clipped") || !strings.Contains(findings[3].Message, "lines") {
+		t.Errorf("Unexpected message for wrapped label: %s", findings[3].Message)
+	}
+}
+
+func TestConventionRules(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"gdqlint.cfg": `[node-name-case]
+case="PascalCase"
+
+[scene-file-case]
+severity="error"
+
+[root-type]
+ui="Control"
+ui/world="Node2D"
+
+[scenes-per-dir]
+max=2
+`,
+		"ui/main_menu.tscn":   "[gd_scene format=3]\n\n[node name=\"MainMenu\" type=\"PanelContainer\"]\n\n[node name=\"start_button\" type=\"Button\" parent=\".\"]\n",
+		"ui/HUD.tscn":         "[gd_scene format=3]\n\n[node name=\"Hud\" type=\"Node2D\"]\n",
+		"ui/pause.tscn":       "[gd_scene format=3]\n\n[node name=\"Pause\" type=\"Control\"]\n",
+		"ui/world/map.tscn":   "[gd_scene format=3]\n\n[node name=\"Map\" type=\"TileMap\"]\n",
+		"levels/level_1.tscn": "[gd_scene format=3]\n\n[node name=\"Level1\" type=\"Node3D\"]\n",
+	})
+
+	config, err := loadLintConfig(root, "")
+	if err != nil {
+		t.Fatalf("Config error: %v", err)
+	}
+	ctx := &LintContext{Root: root, Dir: root, Config: config}
+
+	var rules []*LintRule
+	for _, name := range []string{"node-name-case", "scene-file-case", "root-type", "scenes-per-dir"} {
+		rules = append(rules, findLintRule(name))
+	}
+
+	var got []string
+	for _, finding := range runLint(ctx, rules) {
+		got = append(got, finding.Rule+" "+finding.Severity+" "+finding.File+":"+finding.Node)
+	}
+	expected := []string{
+		"scenes-per-dir warning res://ui:",
+		"scene-file-case error res://ui/HUD.tscn:",
+		"root-type error res://ui/HUD.tscn:Hud",
+		"node-name-case warning res://ui/main_menu.tscn:MainMenu/start_button",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	// Disabled rules are skipped when running all rules
+	ctx.Config = parseConfigText("[node-name-case]\nenabled=false\n")
+	for _, finding := range runLint(ctx, nil) {
+		if finding.Rule == "node-name-case" {
+			t.Errorf("Disabled rule reported: %+v", finding)
+		}
+	}
+}
+
+func TestPhysicsLayers(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": `[layer_names]
+
+2d_physics/layer_1="player"
+2d_physics/layer_3="world"
+3d_physics/layer_2="props"
+`,
+		"level.tscn": `[gd_scene format=3]
+
+[node name="Level" type="Node2D"]
+
+[node name="Player" type="CharacterBody2D" parent="."]
+collision_layer = 5
+collision_mask = 0
+
+[node name="Ghost" type="Area3D" parent="."]
+collision_layer = 0
+collision_mask = 6
+`,
+	})
+
+	scene, err := ParseTscnFile(filepath.Join(root, "level.tscn"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	player, ghost := scene.AllNodes[1], scene.AllNodes[2]
+	if got := formatPhysicsLayers(player, player.Properties["collision_layer"], scene); got != "[player, world] (5)" {
+		t.Errorf("Unexpected 2D layers: %s", got)
+	}
+	if got := formatPhysicsLayers(ghost, ghost.Properties["collision_mask"], scene); got != "[props, 3] (6)" {
+		t.Errorf("Unexpected 3D layers: %s", got)
+	}
+
+	findings := lintProjectDir(t, "physics-layer-zero", root)
+	if len(findings) != 2 || findings[0].Node != "Level/Ghost" || findings[1].Node != "Level/Player" {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+}
+
+func TestInputActionsRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": `[autoload]
+
+Controls="*res://autoload/controls.gd"
+
+[input]
+
+jump={
+"deadzone": 0.5,
+"events": [Object(InputEventKey,"keycode":32)]
+}
+move_left={
+"deadzone": 0.5,
+"events": []
+}
+move_right={
+"deadzone": 0.5,
+"events": []
+}
+pause={
+"deadzone": 0.5,
+"events": []
+}
+`,
+		"player.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_s"]
+
+[node name="Player" type="CharacterBody2D"]
+script = ExtResource("1_s")
+`,
+		"player.gd": `extends CharacterBody2D
+
+func _physics_process(delta):
+	var direction = Input.get_axis("move_left", "move_right")
+	if Input.is_action_just_pressed(&"jump"):
+		pass
+	# if Input.is_action_pressed("dash"):
+	if Input.is_action_pressed("crouch"):
+		pass
+`,
+		"autoload/controls.gd": "extends Node\n\nfunc _input(event):\n\tif event.is_action_pressed(\"ui_cancel\") or event.is_action_pressed(\"menu\"):\n\t\tpass\n",
+		"unused.gd":            "extends Node\n\nfunc _ready():\n\tInput.is_action_pressed(\"pause\")\n",
+	})
+
+	var got []string
+	for _, finding := range lintProjectDir(t, "input-actions", root) {
+		got = append(got, fmt.Sprintf("%s:%d %s", finding.File, finding.Line, finding.Message))
+	}
+	expected := []string{
+		`res://autoload/controls.gd:4 input action "menu" is not defined in project.godot`,
+		`res://player.gd:8 input action "crouch" is not defined in project.godot`,
+		`res://project.godot:0 input action "pause" is never used by a script`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestUnusedScriptRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": "[autoload]\n\nGame=\"*res://autoload/game.gd\"\n",
+		"main.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://main.gd" id="1_s"]
+
+[node name="Main" type="Node"]
+script = ExtResource("1_s")
+`,
+		"main.gd":                "extends Node\n\nconst Util = preload(\"res://util.gd\")\n",
+		"util.gd":                "extends RefCounted\n",
+		"autoload/game.gd":       "extends Node\n",
+		"weapon.gd":              "class_name Weapon\nextends Resource\n",
+		"old/enemy.gd":           "extends Node2D\n\nconst Self = preload(\"res://old/enemy.gd\")\n",
+		"addons/tool/plugin.cfg": "[plugin]\n\nname=\"Tool\"\nscript=\"plugin.gd\"\n",
+		"addons/tool/plugin.gd":  "@tool\nextends EditorPlugin\n",
+		"csharp/Player.cs":       "using Godot;\n\npublic partial class Player : CharacterBody2D\n{\n}\n",
+		"csharp/MathUtil.cs":     "public static class MathUtil\n{\n}\n",
+	})
+
+	var got []string
+	for _, finding := range lintProjectDir(t, "unused-script", root) {
+		got = append(got, finding.File)
+	}
+	// A script preloading itself is still unused
+	expected := []string{"res://csharp/Player.cs", "res://old/enemy.gd"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected unused scripts: %v", got)
+	}
+}
+
+func TestLargeSubResourceRule(t *testing.T) {
+	blob := strings.Repeat("255, ", 2*1024)
+	root := writeProjectFiles(t, map[string]string{
+		"gdqlint.cfg": "[large-sub-resource]\nmax_kb=8\n",
+		"level.tscn": `[gd_scene load_steps=3 format=3]
+
+[sub_resource type="Image" id="Image_big"]
+data = {
+"data": PackedByteArray(` + blob + `0),
+"format": "RGBA8"
+}
+
+[sub_resource type="RectangleShape2D" id="RectangleShape2D_small"]
+size = Vector2(10, 10)
+
+[node name="Level" type="Node2D"]
+`,
+		"tiles.tres": `[gd_resource type="TileSet" load_steps=2 format=3]
+
+[sub_resource type="Image" id="Image_tiles"]
+data = {
+"data": PackedByteArray(` + blob + blob + `0)
+}
+
+[resource]
+`,
+	})
+
+	config, err := loadLintConfig(root, "")
+	if err != nil {
+		t.Fatalf("Config error: %v", err)
+	}
+	ctx := &LintContext{Root: root, Dir: root, Config: config}
+	findings := runLint(ctx, []*LintRule{findLintRule("large-sub-resource")})
+
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s:%d %s", finding.File, finding.Line, finding.Message))
+	}
+	expected := []string{
+		"res://level.tscn:3 sub_resource Image_big (Image) embeds 10 KB (max 8 KB); save it as a separate .tres/.res file",
+		"res://tiles.tres:3 sub_resource Image_tiles (Image) embeds 20 KB (max 8 KB); save it as a separate .tres/.res file",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	// The default limit is far above these resources
+	if findings := lintProjectDir(t, "large-sub-resource", root); len(findings) != 0 {
+		t.Errorf("Expected no findings with the default limit, got %d", len(findings))
+	}
+}
+
+func TestEmptyPolygonRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"level.tscn": polygonScene,
+		"nav.tres":   "[gd_resource type=\"NavigationPolygon\" format=3]\n\n[resource]\n",
+	})
+
+	ctx := &LintContext{Root: root, Dir: root, Config: &ConfigFile{}}
+	findings := runLint(ctx, []*LintRule{findLintRule("empty-polygon")})
+
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s:%d %s", finding.File, finding.Line, finding.Message))
+	}
+	expected := []string{
+		"res://level.tscn:8 NavigationPolygon NavigationPolygon_unbaked has outlines but no polygons (never baked)",
+		"res://nav.tres:3 NavigationPolygon resource has no polygons",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestScriptNodeTypeRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"player.gd": "extends CharacterBody2D\n",
+		"enemy.gd":  "class_name Enemy\nextends \"res://player.gd\"\n",
+		"boss.gd":   "extends Enemy\n",
+		"ui.gd":     "@tool\nextends Control\n",
+		"custom.gd": "extends MyExtensionNode\n",
+		"scenes/hud.tscn": `[gd_scene load_steps=6 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_p"]
+[ext_resource type="Script" path="res://boss.gd" id="2_b"]
+[ext_resource type="Script" path="res://ui.gd" id="3_u"]
+[ext_resource type="Script" path="res://custom.gd" id="4_c"]
+
+[node name="HUD" type="Control"]
+script = ExtResource("3_u")
+
+[node name="Player" type="Control" parent="."]
+script = ExtResource("1_p")
+
+[node name="Boss" type="Button" parent="."]
+script = ExtResource("2_b")
+
+[node name="Body" type="CharacterBody2D" parent="."]
+script = ExtResource("2_b")
+
+[node name="Custom" type="Node2D" parent="."]
+script = ExtResource("4_c")
+`,
+	})
+
+	findings := lintProjectDir(t, "script-node-type", root)
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s %s %s: %s", finding.Severity, finding.File, finding.Node, finding.Message))
+	}
+	expected := []string{
+		"warning res://scenes/hud.tscn HUD/Boss: res://boss.gd extends CharacterBody2D but is attached to a Button node",
+		"warning res://scenes/hud.tscn HUD/Player: res://player.gd extends CharacterBody2D but is attached to a Control node",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestDisabledProcessingRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"mover.gd": "extends Node2D\n\nfunc _physics_process(delta):\n\tpass\n",
+		"label.gd": "extends Label\n\nfunc _ready():\n\tpass\n",
+		"scenes/level.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://mover.gd" id="1_m"]
+[ext_resource type="Script" path="res://label.gd" id="2_l"]
+
+[node name="Level" type="Node2D"]
+
+[node name="Frozen" type="Node2D" parent="."]
+process_mode = 4
+
+[node name="Mover" type="Node2D" parent="Frozen"]
+script = ExtResource("1_m")
+
+[node name="Label" type="Label" parent="Frozen"]
+script = ExtResource("2_l")
+
+[node name="Spawn" type="Timer" parent="Frozen"]
+autostart = true
+
+[node name="Anim" type="AnimationPlayer" parent="Frozen"]
+autoplay = "idle"
+
+[node name="Awake" type="Node" parent="Frozen"]
+process_mode = 3
+
+[node name="Tick" type="Timer" parent="Frozen/Awake"]
+autostart = true
+`,
+	})
+
+	findings := lintProjectDir(t, "disabled-processing", root)
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s %s:%d %s: %s", finding.Severity, finding.File, finding.Line, finding.Node, finding.Message))
+	}
+	expected := []string{
+		"warning res://scenes/level.tscn:11 Level/Frozen/Mover: script res://mover.gd defines _physics_process but process_mode is disabled by Level/Frozen",
+		"warning res://scenes/level.tscn:17 Level/Frozen/Spawn: Timer with autostart but process_mode is disabled by Level/Frozen",
+		"warning res://scenes/level.tscn:20 Level/Frozen/Anim: AnimationPlayer with autoplay but process_mode is disabled by Level/Frozen",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestDuplicateExtResourceRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": `[gd_scene load_steps=6 format=3]
+
+[ext_resource type="Texture2D" path="res://icon.png" id="1_a"]
+[ext_resource type="Script" path="res://main.gd" id="2_b"]
+[ext_resource type="Texture2D" path="res://icon.png" id="3_c"]
+[ext_resource type="Script" path="res://main.gd" id="2_b"]
+[ext_resource type="Texture2D" path="res://logo.png" id="4_d"]
+
+[node name="Main" type="Node2D"]
+script = ExtResource("2_b")
+
+[node name="Icon" type="Sprite2D" parent="."]
+texture = ExtResource("3_c")
+
+[node name="Logo" type="Sprite2D" parent="."]
+texture = ExtResource("4_d")
+`,
+		"old.tscn": `[gd_scene load_steps=3 format=2]
+
+[ext_resource path="res://a.png" type="Texture" id=1]
+[ext_resource path="res://b.png" type="Texture" id=1]
+
+[node name="Old" type="Sprite"]
+texture = ExtResource( 1 )
+`,
+	})
+
+	findings := lintProjectDir(t, "duplicate-ext-resource", root)
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s %s:%d: %s", finding.Severity, finding.File, finding.Line, finding.Message))
+	}
+	expected := []string{
+		"error res://main.tscn:5: ext_resource res://icon.png declared again as id 3_c (first as id 1_a at line 3); fixable with --fix",
+		"error res://main.tscn:6: ext_resource id 2_b declared twice for res://main.gd (first at line 4); fixable with --fix",
+		"error res://old.tscn:4: ext_resource id 1 declared for res://a.png and res://b.png (line 3); references are ambiguous",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	fixed, err := fixDuplicateExtResources(&LintContext{Root: root, Dir: root})
+	if err != nil || fixed != 1 {
+		t.Fatalf("Expected 1 fixed file, got %d (%v)", fixed, err)
+	}
+	content, _ := os.ReadFile(filepath.Join(root, "main.tscn"))
+	expectedContent := `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Texture2D" path="res://icon.png" id="1_a"]
+[ext_resource type="Script" path="res://main.gd" id="2_b"]
+[ext_resource type="Texture2D" path="res://logo.png" id="4_d"]
+
+[node name="Main" type="Node2D"]
+script = ExtResource("2_b")
+
+[node name="Icon" type="Sprite2D" parent="."]
+texture = ExtResource("1_a")
+
+[node name="Logo" type="Sprite2D" parent="."]
+texture = ExtResource("4_d")
+`
+	if string(content) != expectedContent {
+		t.Errorf("Unexpected fixed content:\n%s", content)
+	}
+	if findings := lintProjectDir(t, "duplicate-ext-resource", root); len(findings) != 1 {
+		t.Errorf("Expected only the ambiguous id left, got %v", findings)
+	}
+}
+
+func TestLintFixRereadsScenes(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Texture2D" path="res://icon.png" id="1_a"]
+[ext_resource type="Texture2D" path="res://icon.png" id="2_b"]
+
+[node name="Main" type="Sprite2D"]
+texture = ExtResource("2_b")
+`,
+	})
+
+	// Rules running after a fix see the fixed files, not the scenes read before it
+	ctx := &LintContext{Root: root, Dir: root}
+	if scenes := ctx.Scenes(); len(scenes) != 1 || scenes[0].Scene.RootNode.Properties["texture"] != `ExtResource("2_b")` {
+		t.Fatalf("Unexpected scenes before the fix: %+v", scenes)
+	}
+	ctx.Graph()
+	if err := fixLint(io.Discard, ctx, []*LintRule{findLintRule("duplicate-ext-resource")}); err != nil {
+		t.Fatalf("Fix error: %v", err)
+	}
+	if ctx.graph != nil {
+		t.Error("Expected the dependency graph to be dropped")
+	}
+	scenes := ctx.Scenes()
+	if len(scenes) != 1 || scenes[0].Scene.RootNode.Properties["texture"] != `ExtResource("1_a")` || len(scenes[0].Scene.ExtResources) != 1 {
+		t.Errorf("Expected the fixed scene, got: %+v", scenes[0].Scene)
+	}
+}
+
+func TestStaleUIDRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"player.tscn": `[gd_scene format=3 uid="uid://player1"]
+
+[node name="Player" type="Node2D"]
+`,
+		"player.gd":     "extends Node2D\n",
+		"player.gd.uid": "uid://script1\n",
+		"art/coin.png":  "",
+		"art/coin.png.import": `[remap]
+
+importer="texture"
+type="CompressedTexture2D"
+uid="uid://coin1"
+`,
+		"art/old.png": "",
+		"main.tscn": `[gd_scene load_steps=6 format=3]
+
+[ext_resource type="PackedScene" uid="uid://player1" path="res://actors/player.tscn" id="1_p"]
+[ext_resource type="Script" uid="uid://script1" path="res://player.gd" id="2_s"]
+[ext_resource type="Texture2D" uid="uid://gone" path="res://art/coin.png" id="3_c"]
+[ext_resource type="Texture2D" uid="uid://gone2" path="res://art/old.png" id="4_o"]
+[ext_resource type="Texture2D" uid="uid://gone3" path="res://art/missing.png" id="5_m"]
+
+[node name="Main" type="Node2D"]
+`,
+	})
+
+	findings := lintProjectDir(t, "stale-uid", root)
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s %s:%d: %s", finding.Severity, finding.File, finding.Line, finding.Message))
+	}
+	expected := []string{
+		"warning res://main.tscn:3: ext_resource uid://player1 points to res://player.tscn, not to its path res://actors/player.tscn; fixable with --fix (path updated)",
+		"warning res://main.tscn:5: ext_resource uid://gone does not point to a valid resource, res://art/coin.png has uid://coin1; fixable with --fix (uid updated)",
+		"warning res://main.tscn:6: ext_resource uid://gone2 does not point to a valid resource and res://art/old.png has no uid; fixable with --fix (uid removed)",
+		"error res://main.tscn:7: ext_resource uid://gone3 does not point to a valid resource and res://art/missing.png does not exist",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	fixed, err := fixStaleUIDs(&LintContext{Root: root, Dir: root})
+	if err != nil || fixed != 1 {
+		t.Fatalf("Expected 1 fixed file, got %d (%v)", fixed, err)
+	}
+	content, _ := os.ReadFile(filepath.Join(root, "main.tscn"))
+	for _, header := range []string{
+		`[ext_resource type="PackedScene" uid="uid://player1" path="res://player.tscn" id="1_p"]`,
+		`[ext_resource type="Texture2D" uid="uid://coin1" path="res://art/coin.png" id="3_c"]`,
+		`[ext_resource type="Texture2D" path="res://art/old.png" id="4_o"]`,
+	} {
+		if !strings.Contains(string(content), header+"\n") {
+			t.Errorf("Expected %s in fixed content:\n%s", header, content)
+		}
+	}
+	if findings := lintProjectDir(t, "stale-uid", root); len(findings) != 1 {
+		t.Errorf("Expected only the missing resource left, got %v", findings)
+	}
+}
+
+func TestReadUIDCache(t *testing.T) {
+	var cache []byte
+	cache = binary.LittleEndian.AppendUint32(cache, 2)
+	for id, path := range map[int64]string{0: "res://a.tscn", 34*34 + 33: "res://b.png"} {
+		cache = binary.LittleEndian.AppendUint64(cache, uint64(id))
+		cache = binary.LittleEndian.AppendUint32(cache, uint32(len(path)))
+		cache = append(cache, path...)
+	}
+	file := filepath.Join(t.TempDir(), "uid_cache.bin")
+	if err := os.WriteFile(file, cache, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readUIDCache(file)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if entries["uid://a"] != "res://a.tscn" || entries["uid://ba8"] != "res://b.png" || len(entries) != 2 {
+		t.Errorf("Unexpected entries: %v", entries)
+	}
+}
+
+func TestMissingAttachmentBoneRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"player.tscn": skeletonScene,
+	})
+
+	findings := lintProjectDir(t, "missing-attachment-bone", root)
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s %s:%d: %s", finding.Severity, finding.File, finding.Line, finding.Message))
+	}
+	expected := []string{
+		"error res://player.tscn:32: BoneAttachment3D Player/Skeleton3D/SwordSocket follows bone Hand.R, which Skeleton3D Player/Skeleton3D does not have",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestExternalNodePathRule(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"enemy.tscn": externalPathsScene,
+	})
+
+	findings := lintProjectDir(t, "external-node-path", root)
+	if len(findings) != 5 {
+		t.Fatalf("Expected 5 findings, got: %v", findings)
+	}
+	expected := "warning res://enemy.tscn:8: Enemy target: NodePath ../Player leaves the scene and breaks when it is instanced elsewhere"
+	if got := fmt.Sprintf("%s %s:%d: %s", findings[1].Severity, findings[1].File, findings[1].Line, findings[1].Message); got != expected {
+		t.Errorf("Unexpected finding: %s", got)
+	}
+}
@@ -0,0 +1,188 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// LoadedResource is a file loaded along with a scene
+type LoadedResource struct {
+	Path     string `json:"path"`               // res:// path
+	Kind     string `json:"kind"`               // scene, instance, ext_resource or preload
+	Size     int64  `json:"size"`               // bytes loaded: the imported file when there is one
+	Imported string `json:"imported,omitempty"` // res:// path of the imported file
+	Missing  bool   `json:"missing,omitempty"`
+}
+
+// LoadCost is the estimated load cost of a scene
+type LoadCost struct {
+	Scene     string            `json:"scene"`
+	Total     int64             `json:"total_bytes"`
+	Resources []*LoadedResource `json:"resources"`
+}
+
+// importedFile returns the res:// path of the file Godot loads in place of an
+// imported asset, from the [remap] section of its .import file. When the asset
+// has one variant per texture format (path.s3tc, path.etc2), the largest is used.
+func importedFile(root, resPath string) string {
+	config, err := parseConfigFile(resToFS(root, resPath) + ".import")
+	if err != nil {
+		return ""
+	}
+	remap := config.Section("remap")
+	if remap == nil {
+		return ""
+	}
+	if path, exists := remap.Values["path"]; exists {
+		return unquoteValue(path)
+	}
+
+	largest, largestSize := "", int64(-1)
+	for _, key := range remap.Keys {
+		if !strings.HasPrefix(key, "path.") {
+			continue
+		}
+		path := unquoteValue(remap.Values[key])
+		if info, err := os.Stat(resToFS(root, path)); err == nil && info.Size() > largestSize {
+			largest, largestSize = path, info.Size()
+		}
+	}
+	return largest
+}
+
+// loadDependencies returns the files loaded together with a scene, resource or
+// script. Scripts only contribute their preloads: load() calls run later.
+func loadDependencies(root, resPath string) []DependencyEdge {
+	graph := &DependencyGraph{Root: root, Edges: make(map[string][]DependencyEdge)}
+	file := resToFS(root, resPath)
+
+	switch {
+	case hasExtension(file, scriptExtensions):
+		if err := addScriptDependencies(graph, file, resPath); err != nil {
+			logger.Warn("Skipping script", "path", file, "error", err)
+		}
+	case hasExtension(file, sceneExtensions):
+		scene, err := ParseFile(file, ParseOptions{SkipProperties: true, Logger: logger})
+		if err != nil {
+			logger.Warn("Skipping file", "path", file, "error", err)
+			return nil
+		}
+		addSceneDependencies(graph, scene, resPath)
+	}
+
+	var edges []DependencyEdge
+	for _, edge := range graph.Edges[resPath] {
+		if edge.Kind != "load" && !strings.HasPrefix(edge.To, "uid://") {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// computeLoadCost walks the resources a scene loads transitively and sums their sizes.
+// Every file is counted once, however many scenes reference it.
+func computeLoadCost(file string) (*LoadCost, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("file not found: %s", file)
+	}
+
+	root := findProjectRoot(file)
+	start := fsToRes(root, file)
+	cost := &LoadCost{Scene: start}
+
+	seen := map[string]bool{start: true}
+	queue := []DependencyEdge{{To: start, Kind: "scene"}}
+	for len(queue) > 0 {
+		edge := queue[0]
+		queue = queue[1:]
+
+		resource := &LoadedResource{Path: edge.To, Kind: edge.Kind}
+		cost.Resources = append(cost.Resources, resource)
+
+		info, err := os.Stat(resToFS(root, edge.To))
+		if err != nil {
+			resource.Missing = true
+			continue
+		}
+		resource.Size = info.Size()
+		if imported := importedFile(root, edge.To); imported != "" {
+			if info, err := os.Stat(resToFS(root, imported)); err == nil {
+				resource.Imported = imported
+				resource.Size = info.Size()
+			}
+		}
+		cost.Total += resource.Size
+
+		for _, dep := range loadDependencies(root, edge.To) {
+			if !seen[dep.To] {
+				seen[dep.To] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	// Largest first
+	sort.SliceStable(cost.Resources, func(i, j int) bool {
+		return cost.Resources[i].Size > cost.Resources[j].Size
+	})
+	return cost, nil
+}
+
+// printLoadCost displays the loaded resources and the total
+func printLoadCost(out io.Writer, cost *LoadCost) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SIZE\tKIND\tRESOURCE")
+	for _, resource := range cost.Resources {
+		size := formatSize(int(resource.Size))
+		if resource.Missing {
+			size = "MISSING"
+		}
+		path := resource.Path
+		if resource.Imported != "" {
+			path += " (imported: " + filepath.Base(resource.Imported) + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", size, resource.Kind, path)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\nTotal: %s in %d file(s)\n", formatSize(int(cost.Total)), len(cost.Resources))
+}
+
+var loadCostCmd = &cobra.Command{
+	Use:   "load-cost <tscn file>",
+	Short: "Estimate the bytes loaded with a scene",
+	Long: `Transitively resolve every resource a scene loads (instanced scenes, scripts and their
+preloads, textures, audio and other ext_resources) and sum their file sizes, approximating
+the cost of loading the scene. Imported assets count the size of the imported file Godot
+actually loads (.godot/imported or .import). load() calls in scripts run later and are not counted.`,
+	Example:      `  gdq load-cost levels/world_1.tscn`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+
+		cost, err := computeLoadCost(args[0])
+		if err != nil {
+			return err
+		}
+
+		if outputFormat == "json" {
+			return writeJSON(out, cost)
+		}
+		printLoadCost(out, cost)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loadCostCmd)
+}
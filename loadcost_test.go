@@ -0,0 +1,160 @@
+package gdquery
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadCost(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="PackedScene" path="res://enemy.tscn" id="1_a"]
+[ext_resource type="Texture2D" path="res://art/bg.png" id="2_b"]
+[ext_resource type="Texture2D" path="res://art/gone.png" id="3_c"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Bg" type="Sprite2D" parent="."]
+texture = ExtResource("2_b")
+
+[node name="Enemy" parent="." instance=ExtResource("1_a")]
+`,
+		"enemy.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://enemy.gd" id="1_a"]
+[ext_resource type="Texture2D" path="res://art/bg.png" id="2_b"]
+
+[node name="Enemy" type="Sprite2D"]
+texture = ExtResource("2_b")
+script = ExtResource("1_a")
+`,
+		"enemy.gd": `extends Sprite2D
+
+const Bullet = preload("res://bullet.tscn")
+var later = load("res://boss.tscn")
+`,
+		"bullet.tscn": "[gd_scene format=3]\n\n[node name=\"Bullet\" type=\"Area2D\"]\n",
+		"boss.tscn":   "[gd_scene format=3]\n\n[node name=\"Boss\" type=\"Node2D\"]\n",
+		"art/bg.png":  "png",
+		"art/bg.png.import": `[remap]
+
+importer="texture"
+type="CompressedTexture2D"
+path="res://.godot/imported/bg.png-abc.ctex"
+`,
+		".godot/imported/bg.png-abc.ctex": strings.Repeat("x", 5000),
+	})
+
+	cost, err := computeLoadCost(filepath.Join(root, "main.tscn"))
+	if err != nil {
+		t.Fatalf("Load cost error: %v", err)
+	}
+
+	resources := make(map[string]*LoadedResource)
+	for _, resource := range cost.Resources {
+		resources[resource.Path] = resource
+	}
+
+	// bg.png is loaded by both scenes but counted once; boss.tscn is only load()ed
+	if len(cost.Resources) != 6 {
+		t.Errorf("Expected 6 resources, got %d: %v", len(cost.Resources), resources)
+	}
+	if _, exists := resources["res://boss.tscn"]; exists {
+		t.Error("load() targets should not be counted")
+	}
+	if resources["res://bullet.tscn"] == nil || resources["res://bullet.tscn"].Kind != "preload" {
+		t.Error("Expected the preloaded bullet scene")
+	}
+	if resources["res://enemy.tscn"] == nil || resources["res://enemy.tscn"].Kind != "instance" {
+		t.Error("Expected the instanced enemy scene")
+	}
+
+	bg := resources["res://art/bg.png"]
+	if bg == nil || bg.Size != 5000 || bg.Imported != "res://.godot/imported/bg.png-abc.ctex" {
+		t.Errorf("Expected the imported texture size, got %+v", bg)
+	}
+	if cost.Resources[0] != bg {
+		t.Errorf("Expected the largest resource first, got %s", cost.Resources[0].Path)
+	}
+	if gone := resources["res://art/gone.png"]; gone == nil || !gone.Missing {
+		t.Error("Expected the missing texture to be reported")
+	}
+
+	var total int64
+	for _, resource := range cost.Resources {
+		total += resource.Size
+	}
+	if cost.Total != total || cost.Total <= 5000 {
+		t.Errorf("Unexpected total: %d", cost.Total)
+	}
+}
+
+func TestLoadOrder(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Texture2D" path="res://art/bg.png" id="3_c"]
+[ext_resource type="PackedScene" path="res://enemy.tscn" id="1_a"]
+[ext_resource type="Texture2D" path="res://art/gone.png" id="2_b"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Enemy" parent="." instance=ExtResource("1_a")]
+`,
+		"enemy.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://enemy.gd" id="1_a"]
+[ext_resource type="Texture2D" path="res://art/bg.png" id="2_b"]
+
+[node name="Enemy" type="Sprite2D"]
+script = ExtResource("1_a")
+`,
+		"enemy.gd": `extends Sprite2D
+
+const Bullet = preload("res://bullet.tscn")
+var later = load("res://boss.tscn")
+`,
+		"bullet.tscn": "[gd_scene format=3]\n\n[node name=\"Bullet\" type=\"Area2D\"]\n",
+		"boss.tscn":   "[gd_scene format=3]\n\n[node name=\"Boss\" type=\"Node2D\"]\n",
+		"art/bg.png":  "png",
+	})
+
+	order, err := computeLoadOrder(filepath.Join(root, "main.tscn"))
+	if err != nil {
+		t.Fatalf("Load order error: %v", err)
+	}
+
+	var steps []string
+	for _, step := range order.Steps {
+		steps = append(steps, fmt.Sprintf("%d %s %s", step.Depth, step.Kind, step.Path))
+	}
+	expected := []string{
+		"0 scene res://main.tscn",
+		"1 ext_resource res://art/bg.png",
+		"1 instance res://enemy.tscn",
+		"2 ext_resource res://enemy.gd",
+		"3 preload res://bullet.tscn",
+		"1 ext_resource res://art/gone.png",
+	}
+	if strings.Join(steps, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected load order:\n%s", strings.Join(steps, "\n"))
+	}
+	if !order.Steps[5].Missing || order.Steps[1].Missing {
+		t.Error("Expected only gone.png to be missing")
+	}
+
+	if order.MaxDepth != 3 || len(order.DeepestChains) != 1 {
+		t.Fatalf("Unexpected deepest chains: %d %v", order.MaxDepth, order.DeepestChains)
+	}
+	if chain := strings.Join(order.DeepestChains[0], " -> "); chain != "res://main.tscn -> res://enemy.tscn -> res://enemy.gd -> res://bullet.tscn" {
+		t.Errorf("Unexpected deepest chain: %s", chain)
+	}
+	for _, step := range order.Steps {
+		if step.Deepest != (step.Path != "res://art/bg.png" && step.Path != "res://art/gone.png") {
+			t.Errorf("Unexpected deepest flag on %s", step.Path)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// LoadStep is a file Godot loads while loading a scene
+type LoadStep struct {
+	Order   int    `json:"order"` // 1 for the scene itself
+	Path    string `json:"path"`  // res:// path
+	Kind    string `json:"kind"`  // scene, instance, ext_resource or preload
+	Depth   int    `json:"depth"` // number of loads between the scene and the file
+	Parent  string `json:"parent,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+	Deepest bool   `json:"deepest,omitempty"` // on one of the deepest chains
+}
+
+// LoadOrder is the order in which Godot loads the files of a scene
+type LoadOrder struct {
+	Scene    string      `json:"scene"`
+	Steps    []*LoadStep `json:"steps"`
+	MaxDepth int         `json:"max_depth"`
+	// DeepestChains lists the chains of loads from the scene to the deepest files
+	DeepestChains [][]string `json:"deepest_chains"`
+}
+
+// computeLoadOrder simulates the loading of a scene: every file starts loading
+// when the first file referencing it does, depth-first through ext_resources
+// (in file order), instanced scenes and script preloads. A file already loaded
+// comes from the resource cache and is listed once.
+func computeLoadOrder(file string) (*LoadOrder, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("file not found: %s", file)
+	}
+
+	root := findProjectRoot(file)
+	start := fsToRes(root, file)
+	order := &LoadOrder{Scene: start, DeepestChains: [][]string{}}
+
+	parents := make(map[*LoadStep]*LoadStep)
+	seen := map[string]bool{start: true}
+	var visit func(edge DependencyEdge, depth int, parent *LoadStep)
+	visit = func(edge DependencyEdge, depth int, parent *LoadStep) {
+		step := &LoadStep{Order: len(order.Steps) + 1, Path: edge.To, Kind: edge.Kind, Depth: depth}
+		if parent != nil {
+			step.Parent = parent.Path
+			parents[step] = parent
+		}
+		order.Steps = append(order.Steps, step)
+		order.MaxDepth = max(order.MaxDepth, depth)
+
+		if _, err := os.Stat(resToFS(root, edge.To)); err != nil {
+			step.Missing = true
+			return
+		}
+		for _, dep := range loadDependencies(root, edge.To) {
+			if !seen[dep.To] {
+				seen[dep.To] = true
+				visit(dep, depth+1, step)
+			}
+		}
+	}
+	visit(DependencyEdge{To: start, Kind: "scene"}, 0, nil)
+
+	if order.MaxDepth == 0 {
+		return order, nil
+	}
+	for _, step := range order.Steps {
+		if step.Depth != order.MaxDepth {
+			continue
+		}
+		chain := make([]string, step.Depth+1)
+		for s := step; s != nil; s = parents[s] {
+			s.Deepest = true
+			chain[s.Depth] = s.Path
+		}
+		order.DeepestChains = append(order.DeepestChains, chain)
+	}
+	return order, nil
+}
+
+// printLoadOrder displays the loaded files indented by depth, marking those on
+// the deepest chains with *, then the deepest chains
+func printLoadOrder(out io.Writer, order *LoadOrder) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ORDER\tDEPTH\tKIND\tRESOURCE")
+	for _, step := range order.Steps {
+		marker := " "
+		if step.Deepest {
+			marker = "*"
+		}
+		path := step.Path
+		if step.Missing {
+			path += " (MISSING)"
+		}
+		fmt.Fprintf(w, "%d\t%s%d\t%s\t%s%s\n", step.Order, marker, step.Depth, step.Kind, strings.Repeat("  ", step.Depth), path)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\n%d file(s) loaded, deepest chain: %d level(s)\n", len(order.Steps), order.MaxDepth)
+	for _, chain := range order.DeepestChains {
+		fmt.Fprintf(out, "  %s\n", strings.Join(chain, " -> "))
+	}
+}
+
+var loadOrderCmd = &cobra.Command{
+	Use:   "load-order <tscn file>",
+	Short: "List the files a scene loads, in the order Godot loads them",
+	Long: `Simulate the loading of a scene: list every resource, instanced scene and preloaded script
+in the order Godot starts loading them, depth-first through the ext_resources of each scene
+(in file order), the instanced scenes and the preload() calls of scripts. Files already loaded
+come from the resource cache and are listed once.
+
+The depth of a file is the number of nested loads leading to it. The deepest chains are marked
+with * and printed at the end: they are the first thing to inspect when a scene stutters on
+instantiation. load() calls in scripts run later and are not listed.`,
+	Example: `  gdq load-order levels/world_1.tscn
+  gdq load-order -o json levels/world_1.tscn`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+
+		order, err := computeLoadOrder(args[0])
+		if err != nil {
+			return err
+		}
+
+		if outputFormat == "json" {
+			return writeJSON(out, order)
+		}
+		printLoadOrder(out, order)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loadOrderCmd)
+}
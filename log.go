@@ -0,0 +1,54 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Logging options
+var debugMode = false
+var logLevel = "warn"
+var logFormat = "text"
+
+// logger writes leveled logs to stderr so they never mix with stdout output
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+// discardLogger drops every record, for parses without a logger
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+
+// setupLogger configures the logger from the logging options, writing to w (stderr)
+func setupLogger(w io.Writer) error {
+	var level slog.Level
+	switch strings.ToLower(logLevel) {
+	case "debug":
+		level = slog.LevelDebug
+	case "info":
+		level = slog.LevelInfo
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		return fmt.Errorf("invalid log level: %s (expected debug, info or warn)", logLevel)
+	}
+
+	// -d is a shortcut for --log-level debug
+	if debugMode {
+		level = slog.LevelDebug
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(logFormat) {
+	case "text":
+		logger = slog.New(slog.NewTextHandler(w, options))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(w, options))
+	default:
+		return fmt.Errorf("invalid log format: %s (expected text or json)", logFormat)
+	}
+
+	return nil
+}
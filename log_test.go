@@ -0,0 +1,94 @@
+package gdquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withLogOptions sets the logging options for a test, restoring them and the logger after it
+func withLogOptions(t *testing.T, level, format string, debug bool) {
+	t.Helper()
+	savedLogger, savedLevel, savedFormat, savedDebug := logger, logLevel, logFormat, debugMode
+	t.Cleanup(func() {
+		logger, logLevel, logFormat, debugMode = savedLogger, savedLevel, savedFormat, savedDebug
+	})
+	logLevel, logFormat, debugMode = level, format, debug
+}
+
+func TestSetupLoggerValidation(t *testing.T) {
+	tests := []struct {
+		level, format string
+		err           string
+	}{
+		{"verbose", "text", "invalid log level: verbose"},
+		{"warn", "xml", "invalid log format: xml"},
+		{"WARNING", "JSON", ""},
+		{"error", "text", ""},
+	}
+	for _, test := range tests {
+		withLogOptions(t, test.level, test.format, false)
+		err := setupLogger(&bytes.Buffer{})
+		if test.err == "" && err != nil {
+			t.Errorf("%s/%s: unexpected error: %v", test.level, test.format, err)
+		}
+		if test.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.err)) {
+			t.Errorf("%s/%s: expected %q, got %v", test.level, test.format, test.err, err)
+		}
+	}
+}
+
+func TestSetupLoggerLevels(t *testing.T) {
+	var stderr bytes.Buffer
+	withLogOptions(t, "warn", "text", false)
+	if err := setupLogger(&stderr); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if got := stderr.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "level=WARN msg=shown") {
+		t.Errorf("Expected only the warning, got:\n%s", got)
+	}
+
+	// -d overrides --log-level
+	stderr.Reset()
+	withLogOptions(t, "error", "text", true)
+	if err := setupLogger(&stderr); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("details")
+	if !strings.Contains(stderr.String(), "level=DEBUG msg=details") {
+		t.Errorf("Expected -d to enable debug logs, got:\n%s", stderr.String())
+	}
+}
+
+func TestSetupLoggerJSON(t *testing.T) {
+	var stderr bytes.Buffer
+	withLogOptions(t, "info", "json", false)
+	if err := setupLogger(&stderr); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("Skipping file", "path", "a.tscn")
+
+	var record map[string]any
+	if err := json.Unmarshal(stderr.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON record, got %q: %v", stderr.String(), err)
+	}
+	if record["level"] != slog.LevelInfo.String() || record["msg"] != "Skipping file" || record["path"] != "a.tscn" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+
+	// Logs go to stderr, never mixed with the output
+	file := filepath.Join(writeProjectFiles(t, map[string]string{"main.tscn": testTscnContent}), "main.tscn")
+	var stdout bytes.Buffer
+	stderr.Reset()
+	if code := Run([]string{"--log-format", "json", "--log-level", "debug", file}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Exit status %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), `"level"`) || !strings.HasPrefix(stderr.String(), `{"time":`) {
+		t.Errorf("Expected JSON logs on stderr only, got stdout:\n%s\nstderr:\n%s", stdout.String(), stderr.String())
+	}
+}
@@ -1,8 +1,10 @@
-package main
+package gdquery
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"regexp"
 	"strconv"
@@ -11,13 +13,37 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// Debug mode flag
-var debugMode = false
-
 // Display options
 var showSummary = false
 var nodePath = ""
+var queryFirst = false
+var queryExpectOne = false
+var showSubtreeStats = false
 var verbose = false
+var onlyOverrides = false
+var classDBPath = ""
+var structureOnly = false
+var lowMemory = false
+var forestMode = false
+var treeStyle = "indent"
+
+// TreeStyle holds the connectors drawn in front of tree lines
+type TreeStyle struct {
+	Branch string // before a child that has later siblings
+	Last   string // before the last child
+	Pipe   string // below a node whose later siblings are still to come
+	Space  string // below the last child
+	// Prefixes of property lines, below a node with and without children
+	PropPipe  string
+	PropSpace string
+}
+
+// treeStyles are the styles accepted by --tree-style
+var treeStyles = map[string]*TreeStyle{
+	"indent":  {Branch: "  ", Last: "  ", Pipe: "  ", Space: "  ", PropPipe: "  ", PropSpace: "  "},
+	"unicode": {Branch: "├── ", Last: "└── ", Pipe: "│   ", Space: "    ", PropPipe: "│ ", PropSpace: "  "},
+	"ascii":   {Branch: "|-- ", Last: "`-- ", Pipe: "|   ", Space: "    ", PropPipe: "| ", PropSpace: "  "},
+}
 
 // GodotNode represents a node in the Godot scene
 type GodotNode struct {
@@ -25,11 +51,22 @@ type GodotNode struct {
 	OriginalName string
 	Type         string
 	Parent       string
-	Index        int
+	Owner        string // explicit owner= path written by some external tools, usually absent
+	Index        int    // index="N": position among the children of the parent, -1 when absent
 	Path         string
 	Script       string
+	ScriptClass  string // class_name of the script, see resolveScriptClasses
+	Instance     string
+	InstanceOf   string   // instanced scene of a node without a type, see resolveInstanceTypes
+	InstanceType string   // root type of InstanceOf, when it could be resolved
+	Origin       string   // res:// path of the scene file defining the node, with --expand-instances
+	RawLines     []string // lines of the node section as written, with ParseOptions.KeepRawLines
 	Properties   map[string]string
 	Children     []*GodotNode
+	Span         SourceSpan
+	parent       *GodotNode // set by buildSceneTree, see ParentNode
+	// scriptClassChain is ScriptClass and the classes it extends, nearest first
+	scriptClassChain []string
 }
 
 // GodotResource represents a resource in the Godot scene
@@ -38,31 +75,114 @@ type GodotResource struct {
 	Type string
 	Path string
 	UID  string
+	Span SourceSpan
+}
+
+// SourceSpan is the location of a section in the scene file.
+// Lines are 1-based and inclusive, bytes are 0-based offsets with EndByte exclusive.
+type SourceSpan struct {
+	StartLine int
+	EndLine   int
+	StartByte int
+	EndByte   int
+}
+
+// Size returns the number of bytes covered by the span
+func (s SourceSpan) Size() int {
+	return s.EndByte - s.StartByte
+}
+
+// GodotConnection represents a [connection] section: signal of node From connected to Method of node To.
+// From and To are node paths relative to the scene root ("." is the root).
+type GodotConnection struct {
+	Signal string
+	From   string
+	To     string
+	Method string
+	Flags  int
+	Binds  string // raw bound arguments, e.g. "[1, \"a\"]"
+	Span   SourceSpan
 }
 
 // GodotScene represents the entire Godot scene
 type GodotScene struct {
-	Version       string
-	LoadSteps     int
-	Format        int
-	RootNode      *GodotNode
+	File      string
+	UID       string       // uid="uid://..." of the [gd_scene] header (Godot 4)
+	Version   GodotVersion // Godot version that wrote the scene, inferred
+	LoadSteps int
+	Format    int
+	RootNode  *GodotNode
+	// DetachedRoots holds, with ParseOptions.Forest, the roots of the trees
+	// whose parent does not exist, in file order
+	DetachedRoots []*GodotNode
 	AllNodes      []*GodotNode
 	Resources     []string
 	Extensions    []string
 	ExtResources  map[string]*GodotResource
 	SubResources  map[string]*GodotResource
+	Connections   []*GodotConnection
 }
 
-// debugLog prints debug messages when debug mode is enabled
-func debugLog(msg string, args ...interface{}) {
-	if debugMode {
-		fmt.Printf("[DEBUG] "+msg+"\n", args...)
-	}
+// ParseOptions holds the parser tunables. The parser reads no global state,
+// so scenes can be parsed concurrently with different options.
+type ParseOptions struct {
+	// SkipProperties ignores node property bodies, keeping only the hierarchy,
+	// resources and the script of each node. Properties stays empty.
+	SkipProperties bool
+	// MaxLineSize fails the parse on lines longer than this many bytes; 0 for no limit
+	MaxLineSize int
+	// MaxValueSize replaces node property values longer than this many bytes by
+	// a "<large value: ...>" summary of their length, hash and first bytes
+	// instead of retaining them; 0 keeps every value
+	MaxValueSize int
+	// KeepRawLines keeps the lines of each node section as written in RawLines
+	KeepRawLines bool
+	// Strict fails the parse on content Godot would reject instead of recovering:
+	// nodes whose parent does not exist, second roots and unterminated values
+	Strict bool
+	// Forest keeps nodes whose parent does not exist, and second roots, as the
+	// roots of separate trees in DetachedRoots instead of attaching them under
+	// the root, and logs a warning for each
+	Forest bool
+	// FollowInstances reads instanced scenes to resolve the type of instanced
+	// nodes without one (InstanceOf, InstanceType)
+	FollowInstances bool
+	// ProjectRoot is the directory holding project.godot, used to resolve res://
+	// paths; found from the scene file when empty
+	ProjectRoot string
+	// Logger receives the debug logs of the parse and the warnings about
+	// recovered content; nil discards them
+	Logger *slog.Logger
 }
 
 // ParseTscnFile parses a Godot .tscn file
 func ParseTscnFile(filepath string) (*GodotScene, error) {
-	debugLog("Opening file: %s", filepath)
+	return ParseFile(filepath, ParseOptions{})
+}
+
+// sceneParseOptions returns the parse options selected by the display flags
+func sceneParseOptions() ParseOptions {
+	opts := ParseOptions{SkipProperties: structureOnly, Forest: forestMode, Logger: logger}
+	if lowMemory {
+		opts.MaxValueSize = lowMemoryValueSize
+	}
+	return opts
+}
+
+// ParseFile parses a scene file with the given options
+func ParseFile(filepath string, opts ParseOptions) (*GodotScene, error) {
+	return NewParser(opts).ParseFile(filepath)
+}
+
+// ParseReader parses scene content read from r, e.g. a file from another
+// git revision. name is recorded as the scene file.
+func ParseReader(r io.Reader, name string, opts ParseOptions) (*GodotScene, error) {
+	return NewParser(opts).ParseReader(r, name)
+}
+
+// ParseFile parses a scene file
+func (p *Parser) ParseFile(filepath string) (*GodotScene, error) {
+	p.log.Debug("Opening file", "path", filepath)
 
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -70,7 +190,14 @@ func ParseTscnFile(filepath string) (*GodotScene, error) {
 	}
 	defer file.Close()
 
+	return p.ParseReader(file, filepath)
+}
+
+// ParseReader parses scene content read from r. name is recorded as the scene file.
+func (p *Parser) ParseReader(r io.Reader, name string) (*GodotScene, error) {
+	opts, log := p.opts, p.log
 	scene := &GodotScene{
+		File:         name,
 		AllNodes:     make([]*GodotNode, 0),
 		Resources:    make([]string, 0),
 		Extensions:   make([]string, 0),
@@ -78,32 +205,76 @@ func ParseTscnFile(filepath string) (*GodotScene, error) {
 		SubResources: make(map[string]*GodotResource),
 	}
 
-	scanner := bufio.NewScanner(file)
-	// Increase buffer size to handle large files (up to 10MB)
-	const maxCapacity = 10 * 1024 * 1024 // 10MB
-	buf := make([]byte, maxCapacity)
-	scanner.Buffer(buf, maxCapacity)
+	// Lines can be arbitrarily long (embedded PackedByteArray data)
+	scanner := newLineReader(r)
+	scanner.maxSize = opts.MaxLineSize
+	// Byte length of the current line (including its line ending) for spans
+	lineBytes := 0
 
 	var currentNode *GodotNode
 	var inNode bool
 	var multilineProperty string
-	var multilineValue strings.Builder
+	multilineValue := valueBuffer{limit: opts.MaxValueSize}
 	var inMultiline bool
+	// Dictionaries and arrays spanning several lines
+	var inBlock, blockInString bool
+	var blockDepth int
 	lineNum := 0
+	offset := 0
+	// Node whose section lines are kept with KeepRawLines
+	var rawNode *GodotNode
+
+	// The span of the current section ends at its last non-empty line
+	var openSpan *SourceSpan
+	lastContentLine, lastContentEnd := 0, 0
+	closeSpan := func() {
+		if openSpan != nil {
+			openSpan.EndLine = lastContentLine
+			openSpan.EndByte = lastContentEnd
+			openSpan = nil
+		}
+	}
+	startSpan := func(span *SourceSpan) {
+		*span = SourceSpan{StartLine: lineNum, EndLine: lineNum, StartByte: offset - lineBytes, EndByte: offset}
+		openSpan = span
+	}
 
+	// Per-line logs are skipped unless enabled: their arguments would be
+	// evaluated for every line of the file
+	debugEnabled := log.Enabled(context.Background(), slog.LevelDebug)
 	for scanner.Scan() {
 		lineNum++
+		lineBytes = scanner.Size()
+		offset += lineBytes
 		line := strings.TrimSpace(scanner.Text())
 		originalLine := scanner.Text()
 
-		debugLog("Line %d: %s", lineNum, originalLine)
+		if debugEnabled {
+			log.Debug("Line", "line", lineNum, "text", originalLine)
+		}
+
+		if !inMultiline && !inBlock && strings.HasPrefix(line, "[") {
+			closeSpan()
+			rawNode = nil
+		}
+		if rawNode != nil {
+			rawNode.RawLines = append(rawNode.RawLines, originalLine)
+		}
+		if inMultiline || inBlock || line != "" {
+			lastContentLine, lastContentEnd = lineNum, offset
+		}
 
 		// Handle multiline properties
 		if inMultiline {
-			if strings.HasSuffix(line, "\"") {
+			// The string ends at its first unescaped quote
+			if _, inString := scanValueNesting(line, 0, true); !inString {
 				// End of multiline
+				if opts.SkipProperties {
+					inMultiline = false
+					continue
+				}
 				multilineValue.WriteString(strings.TrimSuffix(line, "\""))
-				if currentNode != nil {
+				if currentNode != nil && multilineProperty != "" {
 					currentNode.Properties[multilineProperty] = multilineValue.String()
 					if multilineProperty == "script" {
 						currentNode.Script = multilineValue.String()
@@ -115,11 +286,29 @@ func ParseTscnFile(filepath string) (*GodotScene, error) {
 				continue
 			} else {
 				// Continue multiline
-				multilineValue.WriteString(line + "\n")
+				if !opts.SkipProperties {
+					multilineValue.WriteString(line + "\n")
+				}
 				continue
 			}
 		}
 
+		if inBlock {
+			blockDepth, blockInString = scanValueNesting(line, blockDepth, blockInString)
+			if !opts.SkipProperties {
+				multilineValue.WriteString("\n" + line)
+			}
+			if blockDepth <= 0 && !blockInString {
+				if currentNode != nil && multilineProperty != "" {
+					currentNode.Properties[multilineProperty] = multilineValue.String()
+				}
+				inBlock = false
+				multilineProperty = ""
+				multilineValue.Reset()
+			}
+			continue
+		}
+
 		// Skip empty lines and comments
 		if line == "" || strings.HasPrefix(line, ";") {
 			continue
@@ -127,7 +316,7 @@ func ParseTscnFile(filepath string) (*GodotScene, error) {
 
 		// Parse header information
 		if strings.HasPrefix(line, "[gd_scene") {
-			debugLog("Parsing header: %s", line)
+			log.Debug("Parsing header", "line", line)
 			parseHeader(line, scene)
 			inNode = false
 			continue
@@ -135,69 +324,156 @@ func ParseTscnFile(filepath string) (*GodotScene, error) {
 
 		// Parse resource information
 		if strings.HasPrefix(line, "[ext_resource") || strings.HasPrefix(line, "[sub_resource") {
-			debugLog("Parsing resource: %s", line)
-			parseResource(line, scene)
+			log.Debug("Parsing resource", "line", line)
+			if resource := parseResource(line, scene); resource != nil {
+				log.Debug("Added resource", "id", resource.ID, "uid", resource.UID, "type", resource.Type, "path", resource.Path)
+				startSpan(&resource.Span)
+			}
 			inNode = false
 			continue
 		}
 
 		// Node start
 		if strings.HasPrefix(line, "[node") {
-			debugLog("Node start: %s", line)
+			log.Debug("Node start", "line", line)
 			if currentNode != nil {
-				debugLog("Adding previous node: %s (%s)", currentNode.Name, currentNode.Type)
+				log.Debug("Adding previous node", "name", currentNode.Name, "type", currentNode.Type)
 				scene.AllNodes = append(scene.AllNodes, currentNode)
 			}
 			currentNode = parseNodeHeader(line)
 			if currentNode != nil {
-				debugLog("Created new node: %s (%s) parent=%s", currentNode.Name, currentNode.Type, currentNode.Parent)
+				startSpan(&currentNode.Span)
+				if opts.KeepRawLines {
+					rawNode = currentNode
+					rawNode.RawLines = []string{originalLine}
+				}
+				log.Debug("Created new node", "name", currentNode.Name, "type", currentNode.Type, "parent", currentNode.Parent)
 			}
 			inNode = true
 			continue
 		}
 
-		// Other sections (connections, etc.)
+		// Signal connections
+		if strings.HasPrefix(line, "[connection") {
+			log.Debug("Parsing connection", "line", line)
+			connection := parseConnection(line)
+			scene.Connections = append(scene.Connections, connection)
+			startSpan(&connection.Span)
+			inNode = false
+			continue
+		}
+
+		// Other sections (editable paths, etc.)
 		if strings.HasPrefix(line, "[") {
-			debugLog("Other section: %s", line)
+			log.Debug("Other section", "line", line)
 			inNode = false
 			continue
 		}
 
-		// Properties within a node
-		if inNode && currentNode != nil {
-			debugLog("Parsing property: %s", line)
-			// Check for multiline start
-			if strings.Contains(line, "=") {
-				parts := strings.SplitN(line, "=", 2)
-				if len(parts) == 2 {
-					key := strings.TrimSpace(parts[0])
-					value := strings.TrimSpace(parts[1])
-
-					if strings.HasPrefix(value, "\"") && !strings.HasSuffix(value, "\"") {
-						// Multiline start
-						inMultiline = true
-						multilineProperty = key
-						multilineValue.WriteString(strings.TrimPrefix(value, "\"") + "\n")
-						continue
-					}
+		// Values spanning several lines are consumed in every section, so that
+		// their lines are not taken for section headers or properties
+		key, value, isProperty := splitPropertyLine(line)
+		keep := inNode && currentNode != nil && !opts.SkipProperties
+		if isProperty {
+			depth, inString := scanValueNesting(value, 0, false)
+			if strings.HasPrefix(value, "\"") && inString {
+				// Multiline string; the body is still consumed when skipping properties
+				inMultiline = true
+				if keep {
+					multilineProperty = key
+					multilineValue.WriteString(strings.TrimPrefix(value, "\"") + "\n")
 				}
+				continue
+			}
+			if depth > 0 || inString {
+				// Arrays, dictionaries and constructor calls with brackets spanning
+				// lines, e.g. Array[int]([ or PackedStringArray( followed by elements
+				inBlock, blockDepth, blockInString = true, depth, inString
+				if keep {
+					multilineProperty = key
+					multilineValue.WriteString(value)
+				}
+				continue
+			}
+		}
+
+		// Properties within a node
+		if inNode && currentNode != nil && isProperty {
+			if debugEnabled {
+				log.Debug("Parsing property", "line", line)
+			}
+			if opts.SkipProperties && key != "script" {
+				continue
 			}
 			parseNodeProperty(line, currentNode)
+			if opts.MaxValueSize > 0 {
+				currentNode.Properties[key] = summarizeLargeValue(currentNode.Properties[key], opts.MaxValueSize)
+			}
 		}
 	}
 
+	closeSpan()
+
 	// Add the last node
 	if currentNode != nil {
-		debugLog("Adding last node: %s (%s)", currentNode.Name, currentNode.Type)
+		log.Debug("Adding last node", "name", currentNode.Name, "type", currentNode.Type)
 		scene.AllNodes = append(scene.AllNodes, currentNode)
 	}
 
-	debugLog("Parsing complete. Total nodes: %d", len(scene.AllNodes))
+	log.Debug("Parsing complete", "nodes", len(scene.AllNodes))
+	if err := scanner.Err(); err != nil {
+		return scene, fmt.Errorf("line %d: %v", lineNum+1, err)
+	}
+
+	// Trailing blank lines separate sections; they are not part of the node
+	for _, node := range scene.AllNodes {
+		for len(node.RawLines) > 1 && strings.TrimSpace(node.RawLines[len(node.RawLines)-1]) == "" {
+			node.RawLines = node.RawLines[:len(node.RawLines)-1]
+		}
+	}
 
 	// Build scene tree
-	buildSceneTree(scene)
+	buildSceneTree(scene, opts.Forest, log)
+	scene.Version = detectSceneVersion(scene)
+
+	if opts.Strict {
+		if inMultiline || inBlock {
+			return scene, fmt.Errorf("unterminated property value at the end of the file")
+		}
+		if err := validateSceneTree(scene); err != nil {
+			return scene, err
+		}
+	}
+	if opts.FollowInstances {
+		root := opts.ProjectRoot
+		if root == "" {
+			root = findProjectRoot(name)
+		}
+		resolveInstanceTypes(scene, root, p.instanceTypes, p.log)
+	}
 
-	return scene, scanner.Err()
+	return scene, nil
+}
+
+// validateSceneTree reports the first node whose parent path does not lead
+// to an earlier node, which buildSceneTree recovers from by guessing
+func validateSceneTree(scene *GodotScene) error {
+	for i, node := range scene.AllNodes {
+		if i == 0 || (node.Parent == "" && node.Owner != "") {
+			continue
+		}
+		if node.Parent == "" {
+			return fmt.Errorf("node %s: second root node (no parent)", node.Name)
+		}
+		expected := scene.RootNode.Path
+		if node.Parent != "." {
+			expected += "/" + node.Parent
+		}
+		if node.parent == nil || node.parent.Path != expected {
+			return fmt.Errorf("node %s: parent %s not found", node.Name, node.Parent)
+		}
+	}
+	return nil
 }
 
 // parseHeader parses the scene header
@@ -212,21 +488,27 @@ func parseHeader(line string, scene *GodotScene) {
 	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
 		scene.Format, _ = strconv.Atoi(matches[1])
 	}
+
+	re = regexp.MustCompile(`\buid="([^"]*)"`)
+	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+		scene.UID = matches[1]
+	}
 }
 
-// parseResource parses resource information
-func parseResource(line string, scene *GodotScene) {
+// parseResource parses resource information and returns the parsed resource, if any
+func parseResource(line string, scene *GodotScene) *GodotResource {
 	scene.Resources = append(scene.Resources, line)
 
 	if strings.HasPrefix(line, "[ext_resource") {
-		parseExtResource(line, scene)
+		return parseExtResource(line, scene)
 	} else if strings.HasPrefix(line, "[sub_resource") {
-		parseSubResource(line, scene)
+		return parseSubResource(line, scene)
 	}
+	return nil
 }
 
 // parseExtResource parses external resources
-func parseExtResource(line string, scene *GodotScene) {
+func parseExtResource(line string, scene *GodotScene) *GodotResource {
 	resource := &GodotResource{}
 
 	// Extract type="Script"
@@ -242,9 +524,10 @@ func parseExtResource(line string, scene *GodotScene) {
 	}
 
 	// Extract id="1_abc123" (this is the actual ID used in references)
-	idRe := regexp.MustCompile(`\bid="([^"]*)"`)
-	if matches := idRe.FindStringSubmatch(line); len(matches) > 1 {
-		resource.ID = matches[1]
+	// Godot 3 scenes use unquoted numeric IDs (id=1)
+	idRe := regexp.MustCompile(`\bid=(?:"([^"]*)"|(\d+))`)
+	if matches := idRe.FindStringSubmatch(line); len(matches) > 2 {
+		resource.ID = matches[1] + matches[2]
 	}
 
 	// Extract uid="uid://..."
@@ -256,16 +539,17 @@ func parseExtResource(line string, scene *GodotScene) {
 	// Save if ID exists (ID is the actual reference key)
 	if resource.ID != "" {
 		scene.ExtResources[resource.ID] = resource
-		debugLog("Added ExtResource: %s (%s) -> %s", resource.ID, resource.Type, resource.Path)
 	} else if resource.UID != "" {
 		// Use UID if no ID
 		scene.ExtResources[resource.UID] = resource
-		debugLog("Added ExtResource: %s (%s) -> %s", resource.UID, resource.Type, resource.Path)
+	} else {
+		return nil
 	}
+	return resource
 }
 
 // parseSubResource parses sub-resources
-func parseSubResource(line string, scene *GodotScene) {
+func parseSubResource(line string, scene *GodotScene) *GodotResource {
 	resource := &GodotResource{}
 
 	// Extract type="CanvasTexture"
@@ -275,20 +559,22 @@ func parseSubResource(line string, scene *GodotScene) {
 	}
 
 	// Extract id="CanvasTexture_38dae"
-	idRe := regexp.MustCompile(`id="([^"]*)"`)
-	if matches := idRe.FindStringSubmatch(line); len(matches) > 1 {
-		resource.ID = matches[1]
+	idRe := regexp.MustCompile(`\bid=(?:"([^"]*)"|(\d+))`)
+	if matches := idRe.FindStringSubmatch(line); len(matches) > 2 {
+		resource.ID = matches[1] + matches[2]
 	}
 
 	if resource.ID != "" {
 		scene.SubResources[resource.ID] = resource
-		debugLog("Added SubResource: %s (%s)", resource.ID, resource.Type)
+		return resource
 	}
+	return nil
 }
 
 // parseNodeHeader parses a node header line
 func parseNodeHeader(line string) *GodotNode {
 	node := &GodotNode{
+		Index:      -1,
 		Properties: make(map[string]string),
 		Children:   make([]*GodotNode, 0),
 	}
@@ -309,6 +595,17 @@ func parseNodeHeader(line string) *GodotNode {
 		node.Parent = matches[1]
 	}
 
+	re = regexp.MustCompile(`\bowner="([^"]*)"`)
+	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+		node.Owner = matches[1]
+	}
+
+	// instance=ExtResource("1_abc123") (Godot 3: ExtResource( 1 ))
+	re = regexp.MustCompile(`instance=(ExtResource\([^)]*\))`)
+	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+		node.Instance = matches[1]
+	}
+
 	re = regexp.MustCompile(`index="(\d+)"`)
 	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
 		node.Index, _ = strconv.Atoi(matches[1])
@@ -317,6 +614,57 @@ func parseNodeHeader(line string) *GodotNode {
 	return node
 }
 
+// parseConnection parses a connection header line
+func parseConnection(line string) *GodotConnection {
+	connection := &GodotConnection{}
+
+	// [connection signal="pressed" from="Button" to="." method="_on_pressed" flags=3 binds=[1]]
+	re := regexp.MustCompile(`signal="([^"]*)"`)
+	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+		connection.Signal = matches[1]
+	}
+
+	re = regexp.MustCompile(`from="([^"]*)"`)
+	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+		connection.From = matches[1]
+	}
+
+	re = regexp.MustCompile(`\bto="([^"]*)"`)
+	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+		connection.To = matches[1]
+	}
+
+	re = regexp.MustCompile(`method="([^"]*)"`)
+	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+		connection.Method = matches[1]
+	}
+
+	re = regexp.MustCompile(`flags=(\d+)`)
+	if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+		connection.Flags, _ = strconv.Atoi(matches[1])
+	}
+
+	// Godot 3 writes binds= [ 1 ] with spaces; the array may be nested
+	re = regexp.MustCompile(`\bbinds=\s*\[`)
+	if loc := re.FindStringIndex(line); loc != nil {
+		start := loc[1] - 1
+		depth := 0
+		for i := start; i < len(line); i++ {
+			if line[i] == '[' {
+				depth++
+			} else if line[i] == ']' {
+				depth--
+				if depth == 0 {
+					connection.Binds = line[start : i+1]
+					break
+				}
+			}
+		}
+	}
+
+	return connection
+}
+
 // parseNodeProperty parses a node property line
 func parseNodeProperty(line string, node *GodotNode) {
 	// script = ExtResource("1_abc123")
@@ -330,7 +678,7 @@ func parseNodeProperty(line string, node *GodotNode) {
 			if strings.HasPrefix(value, "\"") && !strings.HasSuffix(value, "\"") {
 				// Start of multiline
 				value = strings.TrimPrefix(value, "\"")
-			} else if strings.HasSuffix(value, "\"") && !strings.HasPrefix(value, "\"") {
+			} else if strings.HasSuffix(value, "\"") && !strings.HasPrefix(value, "\"") && !isQuotedName(value) {
 				// End of multiline
 				value = strings.TrimSuffix(value, "\"")
 			}
@@ -348,29 +696,52 @@ func parseNodeProperty(line string, node *GodotNode) {
 	}
 }
 
-// buildSceneTree builds the scene tree structure
-func buildSceneTree(scene *GodotScene) {
-	debugLog("Building scene tree")
+// isQuotedName reports whether value is a StringName (&"...") or NodePath (^"...") literal
+func isQuotedName(value string) bool {
+	return strings.HasPrefix(value, "&\"") || strings.HasPrefix(value, "^\"")
+}
+
+// buildSceneTree builds the scene tree structure. Parents are looked up by
+// their path relative to the root, as parent= attributes are written. Nodes
+// whose parent is not found are attached under the root, or kept as detached
+// roots with forest. Logs go to log.
+func buildSceneTree(scene *GodotScene, forest bool, log *slog.Logger) {
+	log.Debug("Building scene tree")
 
+	// Processed nodes by their path relative to the root ("." for the root,
+	// "A/B" below it); detached roots by the path the file gives them
 	pathMap := make(map[string]*GodotNode)
+	relativePath := make(map[*GodotNode]string)
+	addPath := func(node *GodotNode, path string) {
+		relativePath[node] = path
+		pathMap[path] = node
+	}
 
 	// Build parent-child relationships sequentially (maintaining context)
 	for i, node := range scene.AllNodes {
 		// Save original name
 		node.OriginalName = node.Name
 
-		debugLog("Processing node: %s (parent: %s)", node.Name, node.Parent)
+		log.Debug("Processing node", "name", node.Name, "parent", node.Parent)
 
 		// Determine parent node
 		var parentNode *GodotNode
-		if node.Parent == "" || node.Parent == "." {
+		if node.Parent == "" && node.Owner != "" && scene.RootNode != nil {
+			// No parent but an explicit owner: the node hangs under its owner
+			// instead of being a second root
+			if node.Owner == "." {
+				parentNode = scene.RootNode
+			} else {
+				parentNode = findParentInProcessedNodes(node.Owner, pathMap, scene.AllNodes[:i], forest, log)
+			}
+		} else if node.Parent == "" || node.Parent == "." {
 			// Root node or direct child of root
 			if scene.RootNode == nil && node.Parent == "" {
 				// Set first node as root
 				scene.RootNode = node
 				node.Path = node.Name
-				pathMap[node.Path] = node
-				debugLog("Root node set: %s", node.Name)
+				addPath(node, ".")
+				log.Debug("Root node set", "name", node.Name)
 				continue
 			} else if node.Parent == "." && scene.RootNode != nil {
 				// Direct child of root
@@ -378,50 +749,86 @@ func buildSceneTree(scene *GodotScene) {
 			}
 		} else {
 			// Search for parent node (among already processed nodes)
-			parentNode = findParentInProcessedNodes(node.Parent, pathMap, scene.AllNodes[:i])
+			parentNode = findParentInProcessedNodes(node.Parent, pathMap, scene.AllNodes[:i], forest, log)
 		}
 
 		// If parent node found
 		if parentNode != nil {
-			debugLog("Parent node found: %s -> %s", node.Name, parentNode.OriginalName)
-			parentNode.Children = append(parentNode.Children, node)
+			log.Debug("Parent node found", "name", node.Name, "parent", parentNode.OriginalName)
+			parentNode.Children = insertChild(parentNode.Children, node)
+			node.parent = parentNode
 			node.Path = parentNode.Path + "/" + node.Name
+			if parentNode == scene.RootNode {
+				addPath(node, node.Name)
+			} else {
+				addPath(node, relativePath[parentNode]+"/"+node.Name)
+			}
+		} else if forest && scene.RootNode != nil {
+			// Keep the node where the file puts it, as the root of a separate tree
+			log.Warn("Parent not found, keeping a detached tree", "file", scene.File, "node", node.Name, "parent", node.Parent)
+			node.Path = node.Name
+			if node.Parent != "" && node.Parent != "." {
+				node.Path = node.Parent + "/" + node.Name
+			}
+			scene.DetachedRoots = append(scene.DetachedRoots, node)
+			addPath(node, node.Path)
 		} else {
 			// If parent not found, treat as child of root
-			debugLog("Parent not found, treating as child of root: %s", node.Name)
+			log.Debug("Parent not found, treating as child of root", "name", node.Name)
 			if scene.RootNode != nil {
 				scene.RootNode.Children = append(scene.RootNode.Children, node)
+				node.parent = scene.RootNode
 				node.Path = scene.RootNode.Path + "/" + node.Name
+				addPath(node, node.Name)
 			} else {
 				// If root node not set, set this node as root
 				scene.RootNode = node
 				node.Path = node.Name
+				addPath(node, ".")
 			}
 		}
 
-		pathMap[node.Path] = node
-		debugLog("Path set: %s -> %s", node.Name, node.Path)
+		log.Debug("Path set", "name", node.Name, "path", node.Path)
 	}
 
+	log.Debug("Scene tree construction complete")
+}
 
-	debugLog("Scene tree construction complete")
+// insertChild adds a node to the children of its parent. Like the Godot loader,
+// a node with an index is moved to that position, which counts the children
+// added before it; the children of an instanced scene are not known, so the
+// position is clamped to the known children.
+func insertChild(children []*GodotNode, node *GodotNode) []*GodotNode {
+	if node.Index < 0 || node.Index >= len(children) {
+		return append(children, node)
+	}
+	children = append(children, nil)
+	copy(children[node.Index+1:], children[node.Index:])
+	children[node.Index] = node
+	return children
 }
 
-// findParentInProcessedNodes searches for parent node among processed nodes
-func findParentInProcessedNodes(parentPath string, pathMap map[string]*GodotNode, processedNodes []*GodotNode) *GodotNode {
-	debugLog("Searching for parent in processed nodes: %s", parentPath)
+// findParentInProcessedNodes searches for parent node among processed nodes,
+// by its path relative to the root. Without forest, a parent that is not found
+// is looked up by name and by path suffix to recover from broken files; with
+// forest the node is left to become a detached tree.
+func findParentInProcessedNodes(parentPath string, pathMap map[string]*GodotNode, processedNodes []*GodotNode, forest bool, log *slog.Logger) *GodotNode {
+	log.Debug("Searching for parent in processed nodes", "parent", parentPath)
 
 	// Search by complete path
 	if parentNode, exists := pathMap[parentPath]; exists {
-		debugLog("Complete path match: %s", parentPath)
+		log.Debug("Complete path match", "parent", parentPath)
 		return parentNode
 	}
+	if forest {
+		return nil
+	}
 
 	// Search by simple name (first found in processed nodes)
 	// Prioritize first found according to processing order
 	for _, node := range processedNodes {
 		if node.OriginalName == parentPath {
-			debugLog("Name match (sequential): %s -> %s", parentPath, node.Path)
+			log.Debug("Name match (sequential)", "parent", parentPath, "path", node.Path)
 			return node
 		}
 	}
@@ -434,7 +841,7 @@ func findParentInProcessedNodes(parentPath string, pathMap map[string]*GodotNode
 		// Prioritize first found according to processing order
 		for _, node := range processedNodes {
 			if node.OriginalName == parentName {
-				debugLog("Name match: %s -> %s", parentName, node.Path)
+				log.Debug("Name match", "parent", parentName, "path", node.Path)
 				return node
 			}
 		}
@@ -443,7 +850,7 @@ func findParentInProcessedNodes(parentPath string, pathMap map[string]*GodotNode
 	// Search based on path suffix (last resort)
 	for path, node := range pathMap {
 		if strings.HasSuffix(path, "/"+parentPath) {
-			debugLog("Suffix match: %s -> %s", parentPath, path)
+			log.Debug("Suffix match", "parent", parentPath, "path", path)
 			return node
 		}
 	}
@@ -451,94 +858,58 @@ func findParentInProcessedNodes(parentPath string, pathMap map[string]*GodotNode
 	return nil
 }
 
-// findParentNode is a helper function to search for parent node
-func findParentNode(parentPath string, pathMap, nodeMap map[string]*GodotNode, currentNodePath string) *GodotNode {
-	debugLog("Searching for parent node: %s (current: %s)", parentPath, currentNodePath)
-
-	// Search by complete path (highest priority)
-	if parentNode, exists := pathMap[parentPath]; exists {
-		debugLog("Complete path match: %s", parentPath)
-		return parentNode
-	}
-
-	// For complex paths
-	if strings.Contains(parentPath, "/") {
-		// Perform more specific path matching
-		for path, node := range pathMap {
-			if strings.HasSuffix(path, parentPath) {
-				debugLog("Partial path match: %s -> %s", parentPath, path)
-				return node
-			}
-		}
-
-		// Match paths stepwise
-		parts := strings.Split(parentPath, "/")
-		for i := len(parts) - 1; i >= 0; i-- {
-			testPath := strings.Join(parts[i:], "/")
-			if parentNode, exists := pathMap[testPath]; exists {
-				debugLog("Stepwise path match: %s -> %s", parentPath, testPath)
-				return parentNode
-			}
-		}
-
-		// Search by last element only (last resort)
-		parentName := parts[len(parts)-1]
-		debugLog("Simplify complex path: %s -> %s", parentPath, parentName)
-
-		// If multiple nodes match by name, choose the hierarchically closest one
-		var bestMatch *GodotNode
-		for path, node := range pathMap {
-			if strings.HasSuffix(path, "/"+parentName) || node.OriginalName == parentName {
-				if bestMatch == nil {
-					bestMatch = node
-				} else {
-					// Prioritize shorter path (higher hierarchy)
-					if len(node.Path) < len(bestMatch.Path) {
-						bestMatch = node
-					}
-				}
-			}
-		}
-
-		if bestMatch != nil {
-			debugLog("Optimal match selected: %s -> %s", parentName, bestMatch.Path)
-			return bestMatch
-		}
-	}
-
-	// Search by simple name
-	if parentNode, exists := nodeMap[parentPath]; exists {
-		debugLog("Name match: %s", parentPath)
-		return parentNode
+// findNodeByPath searches for node by path (from entire scene) and returns
+// the first match, see findNodesByPath
+func findNodeByPath(scene *GodotScene, path string) *GodotNode {
+	if nodes := findNodesByPath(scene, path); len(nodes) > 0 {
+		return nodes[0]
 	}
-
 	return nil
 }
 
-// findNodeByPath searches for node by path (from entire scene)
-func findNodeByPath(scene *GodotScene, path string) *GodotNode {
-	for _, node := range scene.AllNodes {
+// findNodesByPath returns the nodes matching path in tree order: exact paths
+// or names, or else paths ending with path, or else paths containing it.
+// Several nodes match when names repeat under different parents.
+func findNodesByPath(scene *GodotScene, path string) []*GodotNode {
+	matchers := []func(node *GodotNode) bool{
 		// Exact match
-		if node.Path == path || node.OriginalName == path {
-			return node
-		}
+		func(node *GodotNode) bool { return node.Path == path || node.OriginalName == path },
+		// Partial match (suffix)
+		func(node *GodotNode) bool { return strings.HasSuffix(node.Path, "/"+path) },
+		// Contained in path (more flexible search)
+		func(node *GodotNode) bool { return strings.Contains(node.Path, path) },
 	}
-
-	// Partial match (suffix)
-	for _, node := range scene.AllNodes {
-		if strings.HasSuffix(node.Path, "/"+path) {
-			return node
+	for _, match := range matchers {
+		var nodes []*GodotNode
+		for _, node := range scene.AllNodes {
+			if match(node) {
+				nodes = append(nodes, node)
+			}
+		}
+		if len(nodes) > 0 {
+			return nodes
 		}
 	}
+	return nil
+}
 
-	// Contained in path (more flexible search)
-	for _, node := range scene.AllNodes {
-		if strings.Contains(node.Path, path) {
-			return node
+// queryNodes returns the nodes matching --query: all of them, the first with
+// --first, or an error when several match with --expect-one
+func queryNodes(scene *GodotScene) ([]*GodotNode, error) {
+	nodes := findNodesByPath(scene, nodePath)
+	switch {
+	case len(nodes) == 0:
+		return nil, fmt.Errorf("node not found: %s", nodePath)
+	case queryFirst:
+		return nodes[:1], nil
+	case queryExpectOne && len(nodes) > 1:
+		paths := make([]string, len(nodes))
+		for i, node := range nodes {
+			paths[i] = node.Path
 		}
+		return nil, fmt.Errorf("query %s matches %d nodes: %s", nodePath, len(nodes), strings.Join(paths, ", "))
 	}
-
-	return nil
+	return nodes, nil
 }
 
 // getPathToNode gets the path from root to node
@@ -573,106 +944,148 @@ func getPathToNode(scene *GodotScene, targetNode *GodotNode) []*GodotNode {
 }
 
 // printNodeWithPath displays path and subtree of specified node
-func printNodeWithPath(scene *GodotScene, targetNode *GodotNode) {
-
+func printNodeWithPath(out io.Writer, scene *GodotScene, targetNode *GodotNode) {
 
 	// Display subtree under target node
-	printSceneTree(targetNode, 0, scene)
+	printSceneTree(out, targetNode, scene)
+}
+
+// printSceneTree displays the scene tree in the selected --tree-style
+func printSceneTree(out io.Writer, node *GodotNode, scene *GodotScene) {
+	printTreeNode(out, node, scene, "", "", treeStyles[treeStyle])
 }
 
-// printSceneTree displays the scene tree
-func printSceneTree(node *GodotNode, indent int, scene *GodotScene) {
+// printTreeNode displays node after linePrefix (the connector to its parent) and
+// its properties and children after childPrefix (the connectors of its ancestors)
+func printTreeNode(out io.Writer, node *GodotNode, scene *GodotScene, linePrefix, childPrefix string, style *TreeStyle) {
 	if node == nil {
 		return
 	}
 
-	indentStr := strings.Repeat("  ", indent)
-
-	fmt.Printf("%s%s (%s)", indentStr, node.OriginalName, node.Type)
+	fmt.Fprintf(out, "%s%s (%s)", linePrefix, node.OriginalName, typeLabel(node))
 
 	if node.Script != "" {
 		scriptPath := resolveResourcePath(node.Script, scene)
 		if scriptPath != "" {
-			fmt.Printf(" [Script: %s]", scriptPath)
+			fmt.Fprintf(out, " [Script: %s]", scriptPath)
 		} else {
-			fmt.Printf(" [Script: %s]", node.Script)
+			fmt.Fprintf(out, " [Script: %s]", node.Script)
 		}
 	}
+	if verbose && node.Origin != "" {
+		fmt.Fprintf(out, " [From: %s]", node.Origin)
+	}
+	fmt.Fprint(out, markerAnnotation(node, scene))
+	fmt.Fprint(out, descriptionAnnotation(node))
 
-	fmt.Println()
+	fmt.Fprintln(out)
 
-	// Display properties
+	// Display properties, continuing the connector line when children follow
 	if len(node.Properties) > 0 {
-		if verbose {
+		propPrefix := childPrefix + style.PropSpace
+		if len(node.Children) > 0 {
+			propPrefix = childPrefix + style.PropPipe
+		}
+		if verbose || onlyOverrides {
 			// Verbose mode: display all properties
-			showAllProperties(node, indent+1, scene)
+			showAllProperties(out, node, propPrefix, scene)
 		} else {
 			// Normal mode: display important properties only
-			showImportantProperties(node, indent+1, scene)
+			showImportantProperties(out, node, propPrefix, scene)
 		}
 	}
 
 	// Display child nodes recursively
-	for _, child := range node.Children {
-		printSceneTree(child, indent+1, scene)
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			printTreeNode(out, child, scene, childPrefix+style.Last, childPrefix+style.Space, style)
+		} else {
+			printTreeNode(out, child, scene, childPrefix+style.Branch, childPrefix+style.Pipe, style)
+		}
 	}
 }
 
 // showImportantProperties displays important properties
-func showImportantProperties(node *GodotNode, indent int, scene *GodotScene) {
-	indentStr := strings.Repeat("  ", indent)
-	importantProps := []string{"position", "scale", "rotation", "size", "text", "texture", "visible"}
+func showImportantProperties(out io.Writer, node *GodotNode, indentStr string, scene *GodotScene) {
+	importantProps := []string{"position", "scale", "rotation", "size", "text", "texture", "visible", "collision_layer", "collision_mask"}
 
 	for _, prop := range importantProps {
 		if value, exists := node.Properties[prop]; exists {
+			// Hide values that are the same as the class default
+			if isDefaultValue(node.Type, prop, value) {
+				continue
+			}
+			if isPhysicsLayerProperty(prop) {
+				// Decode layer bits into layer names
+				if layers := formatPhysicsLayers(node, value, scene); layers != "" {
+					value = layers
+				}
+			}
 			if prop == "texture" {
 				// Resolve texture resource
 				texturePath := resolveResourcePath(value, scene)
 				if texturePath != "" {
-					fmt.Printf("%s  %s: %s\n", indentStr, prop, texturePath)
+					fmt.Fprintf(out, "%s  %s: %s\n", indentStr, prop, texturePath)
 				} else {
-					fmt.Printf("%s  %s: %s\n", indentStr, prop, value)
+					fmt.Fprintf(out, "%s  %s: %s\n", indentStr, prop, value)
 				}
 			} else {
-				fmt.Printf("%s  %s: %s\n", indentStr, prop, value)
+				fmt.Fprintf(out, "%s  %s: %s\n", indentStr, prop, prettyValue(prop, value, truecolorTerminal(out)))
 			}
 		}
 	}
 }
 
 // showAllProperties displays all properties (for verbose mode)
-func showAllProperties(node *GodotNode, indent int, scene *GodotScene) {
+func showAllProperties(out io.Writer, node *GodotNode, indentStr string, scene *GodotScene) {
 	if len(node.Properties) == 0 {
 		return
 	}
 
-	indentStr := strings.Repeat("  ", indent)
+	// Summarize the bones of skeletons instead of dumping their transforms
+	collapseBones := skeletonClasses[node.Type] && !rawValues
+	if collapseBones {
+		if bones := skeletonBones(node); len(bones) > 0 {
+			fmt.Fprintf(out, "%s  bones: %s\n", indentStr, describeBones(bones))
+		}
+	}
 
 	for prop, value := range node.Properties {
+		// Only show what is actually customized
+		if onlyOverrides && (isDefaultValue(node.Type, prop, value) || strings.HasPrefix(prop, "metadata/_edit_")) {
+			continue
+		}
+		if collapseBones && bonePropertyRe.MatchString(prop) {
+			continue
+		}
+
 		// Resolve resource references
 		if strings.Contains(value, "ExtResource") || strings.Contains(value, "SubResource") {
 			resolvedPath := resolveResourcePath(value, scene)
 			if resolvedPath != "" {
-				fmt.Printf("%s  %s: %s\n", indentStr, prop, resolvedPath)
+				fmt.Fprintf(out, "%s  %s: %s\n", indentStr, prop, resolvedPath)
 				continue
 			}
 		}
 
-		// Truncate values that are too long
-		displayValue := value
-		maxLen := 100
-		if len(value) > maxLen {
-			displayValue = value[:maxLen] + "..."
+		// Decode layer bits into layer names
+		if isPhysicsLayerProperty(prop) {
+			if layers := formatPhysicsLayers(node, value, scene); layers != "" {
+				fmt.Fprintf(out, "%s  %s: %s\n", indentStr, prop, layers)
+				continue
+			}
 		}
 
-		fmt.Printf("%s  %s: %s\n", indentStr, prop, displayValue)
+		// Truncate or wrap values that do not fit on the line
+		prefix := fmt.Sprintf("%s  %s: ", indentStr, prop)
+		fmt.Fprintf(out, "%s%s\n", prefix, formatPropertyValue(out, prefix, indentStr, prettyValue(prop, value, truecolorTerminal(out))))
 	}
 }
 
 // resolveResourcePath resolves resource references to actual paths
 func resolveResourcePath(resourceRef string, scene *GodotScene) string {
-	// Parse ExtResource("1_abc123") format
-	extResourceRe := regexp.MustCompile(`ExtResource\("([^"]*)"\)`)
+	// Parse ExtResource("1_abc123") format (Godot 3: ExtResource( 1 ))
+	extResourceRe := regexp.MustCompile(`ExtResource\(\s*"?([^")\s]*)"?\s*\)`)
 	if matches := extResourceRe.FindStringSubmatch(resourceRef); len(matches) > 1 {
 		resourceID := matches[1]
 		if resource, exists := scene.ExtResources[resourceID]; exists {
@@ -680,8 +1093,8 @@ func resolveResourcePath(resourceRef string, scene *GodotScene) string {
 		}
 	}
 
-	// Parse SubResource("SubResource_123") format
-	subResourceRe := regexp.MustCompile(`SubResource\("([^"]*)"\)`)
+	// Parse SubResource("SubResource_123") format (Godot 3: SubResource( 1 ))
+	subResourceRe := regexp.MustCompile(`SubResource\(\s*"?([^")\s]*)"?\s*\)`)
 	if matches := subResourceRe.FindStringSubmatch(resourceRef); len(matches) > 1 {
 		resourceID := matches[1]
 		if resource, exists := scene.SubResources[resourceID]; exists {
@@ -693,12 +1106,18 @@ func resolveResourcePath(resourceRef string, scene *GodotScene) string {
 }
 
 // printSceneStats displays scene statistics
-func printSceneStats(scene *GodotScene) {
-	fmt.Println("=== Scene Statistics ===")
-	fmt.Printf("Format Version: %d\n", scene.Format)
-	fmt.Printf("Load Steps: %d\n", scene.LoadSteps)
-	fmt.Printf("Total Nodes: %d\n", len(scene.AllNodes))
-	fmt.Printf("Resources: %d\n", len(scene.Resources))
+func printSceneStats(out io.Writer, scene *GodotScene) {
+	fmt.Fprintln(out, "=== Scene Statistics ===")
+	fmt.Fprintf(out, "Format Version: %d\n", scene.Format)
+	if scene.UID != "" {
+		fmt.Fprintf(out, "UID: %s\n", scene.UID)
+	}
+	if scene.Version.Known() {
+		fmt.Fprintf(out, "Godot Version: %s\n", scene.Version)
+	}
+	fmt.Fprintf(out, "Load Steps: %d\n", scene.LoadSteps)
+	fmt.Fprintf(out, "Total Nodes: %d\n", len(scene.AllNodes))
+	fmt.Fprintf(out, "Resources: %d\n", len(scene.Resources))
 
 	// Count by node type
 	typeCount := make(map[string]int)
@@ -711,30 +1130,33 @@ func printSceneStats(scene *GodotScene) {
 		}
 	}
 
-	fmt.Printf("Nodes with Scripts: %d\n", scriptCount)
+	fmt.Fprintf(out, "Nodes with Scripts: %d\n", scriptCount)
+
+	// Tree shape metrics
+	printTreeMetrics(out, computeTreeMetrics(scene.RootNode))
 
 	// Resource statistics
-	fmt.Printf("ExtResources: %d\n", len(scene.ExtResources))
-	fmt.Printf("SubResources: %d\n", len(scene.SubResources))
+	fmt.Fprintf(out, "ExtResources: %d\n", len(scene.ExtResources))
+	fmt.Fprintf(out, "SubResources: %d\n", len(scene.SubResources))
 
-	fmt.Println("\nBy Node Type:")
+	fmt.Fprintln(out, "\nBy Node Type:")
 	for nodeType, count := range typeCount {
-		fmt.Printf("  %s: %d\n", nodeType, count)
+		fmt.Fprintf(out, "  %s: %d\n", nodeType, count)
 	}
 
 	// Count by ExtResource type
 	if len(scene.ExtResources) > 0 {
-		fmt.Println("\nBy ExtResource Type:")
+		fmt.Fprintln(out, "\nBy ExtResource Type:")
 		extTypeCount := make(map[string]int)
 		for _, resource := range scene.ExtResources {
 			extTypeCount[resource.Type]++
 		}
 		for extType, count := range extTypeCount {
-			fmt.Printf("  %s: %d\n", extType, count)
+			fmt.Fprintf(out, "  %s: %d\n", extType, count)
 		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(out)
 }
 
 var rootCmd = &cobra.Command{
@@ -742,7 +1164,50 @@ var rootCmd = &cobra.Command{
 	Short: "Godot scene file parser",
 	Long:  `Parse Godot .tscn files and display the scene tree structure.`,
 	Args:  cobra.MinimumNArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogger(cmd.ErrOrStderr()); err != nil {
+			return err
+		}
+		progressOutput = cmd.ErrOrStderr()
+		if err := redirectOutput(cmd); err != nil {
+			return err
+		}
+
+		// Load an additional class database (property defaults and signals)
+		if classDBPath != "" {
+			return loadClassDB(classDBPath)
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json", "jsonl", "sexpr", "scenetree", "dot", "graphml", "mermaid-signals"); err != nil {
+			return err
+		}
+		if _, exists := treeStyles[treeStyle]; !exists {
+			return fmt.Errorf("invalid tree style: %s (expected unicode, ascii, indent)", treeStyle)
+		}
+		if structureOnly && (verbose || onlyOverrides || showLayout || showEffectiveVisibility || showHiddenOnly || showZOrder || showRuntime || showTransforms) {
+			return fmt.Errorf("--structure-only cannot be combined with options that display properties")
+		}
+		if showSubtreeStats && nodePath == "" {
+			return fmt.Errorf("--stat requires --query")
+		}
+		switch outputFormat {
+		case "json":
+			return printScenesJSON(out, args)
+		case "jsonl":
+			return printNodesJSONLines(out, args)
+		case "sexpr":
+			return printScenesSexpr(out, args)
+		case "scenetree":
+			return printScenesSceneTree(out, args)
+		case "dot", "graphml":
+			return printSceneGraphs(out, args)
+		case "mermaid-signals":
+			return printSceneSignals(out, args)
+		}
+
 		// Process first file
 		tscnFile := args[0]
 
@@ -752,32 +1217,13 @@ var rootCmd = &cobra.Command{
 		}
 
 		// Parse tscn file
-		scene, err := ParseTscnFile(tscnFile)
+		scene, err := parseSceneFile(tscnFile)
 		if err != nil {
 			return fmt.Errorf("parse error: %v", err)
 		}
 
-		// If node path is specified
-		if nodePath != "" {
-			targetNode := findNodeByPath(scene, nodePath)
-			if targetNode == nil {
-				return fmt.Errorf("node not found: %s", nodePath)
-			}
-
-			printNodeWithPath(scene, targetNode)
-			return nil
-		}
-
-		// Display summary (optional)
-		if showSummary {
-			printSceneStats(scene)
-		}
-
-		// Display scene tree
-		if scene.RootNode != nil {
-			printSceneTree(scene.RootNode, 0, scene)
-		} else {
-			fmt.Println("Root node not found")
+		if err := displayScene(out, scene); err != nil {
+			return err
 		}
 
 		// Support multiple files
@@ -785,57 +1231,285 @@ var rootCmd = &cobra.Command{
 			for _, file := range args[1:] {
 				// Check file existence
 				if _, err := os.Stat(file); os.IsNotExist(err) {
-					fmt.Printf("\nError: file not found: %s\n", file)
+					fmt.Fprintf(out, "\nError: file not found: %s\n", file)
 					continue
 				}
 
-				fmt.Printf("\n" + strings.Repeat("=", 50) + "\n")
-				fmt.Printf("File: %s\n\n", file)
+				fmt.Fprintf(out, "\n"+strings.Repeat("=", 50)+"\n")
+				fmt.Fprintf(out, "File: %s\n\n", file)
 
-				scene, err := ParseTscnFile(file)
+				scene, err := parseSceneFile(file)
 				if err != nil {
-					fmt.Printf("Error: %v\n", err)
+					fmt.Fprintf(out, "Error: %v\n", err)
 					continue
 				}
 
-				// If node path is specified
-				if nodePath != "" {
-					targetNode := findNodeByPath(scene, nodePath)
-					if targetNode == nil {
-						fmt.Printf("Error: node not found: %s\n", nodePath)
-						continue
-					}
-
-					printNodeWithPath(scene, targetNode)
-					continue
+				if err := displayScene(out, scene); err != nil {
+					fmt.Fprintf(out, "Error: %v\n", err)
 				}
+			}
+		}
 
-				// Display summary (optional)
-				if showSummary {
-					printSceneStats(scene)
-				}
+		return nil
+	},
+}
+
+// displayQueriedNode displays a node matched by --query according to the display options
+func displayQueriedNode(out io.Writer, scene *GodotScene, targetNode *GodotNode) error {
+	if showLayout {
+		return printControlLayout(out, targetNode)
+	}
+	if showEffectiveVisibility || showHiddenOnly {
+		printEffectiveVisibility(out, scene, targetNode, showHiddenOnly)
+		return nil
+	}
+	if showZOrder {
+		printZOrder(out, scene, targetNode)
+		return nil
+	}
+	if showTransforms {
+		printTransforms(out, scene, targetNode)
+		return nil
+	}
+	if showRuntime {
+		printRuntime(out, scene, targetNode)
+		return nil
+	}
+	if relativeTo != "" {
+		return printRelativePaths(out, scene, targetNode)
+	}
+	if typeFilter != "" {
+		printNodesOfType(out, scene, targetNode)
+		return nil
+	}
 
-				if scene.RootNode != nil {
-					printSceneTree(scene.RootNode, 0, scene)
+	printNodeWithPath(out, scene, targetNode)
+	if showSubtreeStats {
+		fmt.Fprintln(out)
+		printSubtreeStats(out, computeSubtreeStats(scene, targetNode))
+	}
+	return nil
+}
+
+// displayScene displays a parsed scene according to the display options
+func displayScene(out io.Writer, scene *GodotScene) error {
+	// If node path is specified
+	if nodePath != "" {
+		targetNodes, err := queryNodes(scene)
+		if err != nil {
+			return err
+		}
+		for i, targetNode := range targetNodes {
+			if len(targetNodes) > 1 {
+				if i > 0 {
+					fmt.Fprintln(out)
 				}
+				fmt.Fprintf(out, "Match %d of %d: %s\n", i+1, len(targetNodes), targetNode.Path)
+			}
+			if err := displayQueriedNode(out, scene, targetNode); err != nil {
+				return err
 			}
 		}
+		return nil
+	}
+
+	// Display summary (optional)
+	if showSummary {
+		printSceneStats(out, scene)
+	}
 
+	// Display Control layout instead of the tree
+	if showLayout && scene.RootNode != nil {
+		return printControlLayout(out, scene.RootNode)
+	}
+
+	// Display effective visibility instead of the tree
+	if (showEffectiveVisibility || showHiddenOnly) && scene.RootNode != nil {
+		printEffectiveVisibility(out, scene, scene.RootNode, showHiddenOnly)
 		return nil
-	},
+	}
+
+	// Display CanvasItems in draw order instead of the tree
+	if showZOrder && scene.RootNode != nil {
+		printZOrder(out, scene, scene.RootNode)
+		return nil
+	}
+
+	// Display Node3D transforms instead of the tree
+	if showTransforms && scene.RootNode != nil {
+		printTransforms(out, scene, scene.RootNode)
+		return nil
+	}
+
+	// Display process modes and runtime settings instead of the tree
+	if showRuntime && scene.RootNode != nil {
+		printRuntime(out, scene, scene.RootNode)
+		return nil
+	}
+
+	// Display node paths relative to a node instead of the tree
+	if relativeTo != "" && scene.RootNode != nil {
+		return printRelativePaths(out, scene, scene.RootNode)
+	}
+
+	// Display the nodes of a type instead of the tree
+	if typeFilter != "" && scene.RootNode != nil {
+		printNodesOfType(out, scene, scene.RootNode)
+		return nil
+	}
+
+	// Display scene tree
+	if scene.RootNode != nil {
+		printSceneTree(out, scene.RootNode, scene)
+	} else {
+		fmt.Fprintln(out, "Root node not found")
+	}
+	for _, root := range scene.DetachedRoots {
+		if root.Parent == "" {
+			fmt.Fprintln(out, "\nDetached tree (second root):")
+		} else {
+			fmt.Fprintf(out, "\nDetached tree (parent %s not found):\n", root.Parent)
+		}
+		printSceneTree(out, root, scene)
+	}
+
+	return nil
 }
 
-func init() {
-	rootCmd.Flags().BoolVarP(&debugMode, "debug", "d", false, "Enable debug mode")
-	rootCmd.Flags().BoolVarP(&showSummary, "summary", "s", false, "Display statistics summary")
-	rootCmd.Flags().StringVarP(&nodePath, "query", "q", "", "Search for a specific node path (e.g., \"Player/Sprite\")")
-	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Display all properties in detail")
+// printScenesJSON parses the given files and writes them as JSON: a single
+// object for one file, an array for several. Per-file errors are reported in
+// the "error" field.
+func printScenesJSON(out io.Writer, files []string) error {
+	results := make([]*SceneJSON, 0, len(files))
+	for _, file := range files {
+		scene, err := parseSceneFile(file)
+		if err != nil {
+			results = append(results, &SceneJSON{File: file, Error: err.Error()})
+			continue
+		}
+
+		var nodes []*GodotNode
+		if nodePath != "" {
+			if nodes, err = queryNodes(scene); err != nil {
+				results = append(results, &SceneJSON{File: file, Error: err.Error()})
+				continue
+			}
+		}
+		var stats *SubtreeStats
+		if showSubtreeStats && len(nodes) == 1 {
+			stats = computeSubtreeStats(scene, nodes[0])
+		}
+		if typeFilter != "" {
+			nodes = findQueriedNodesOfType(scene, nodes)
+		}
+		result := sceneToJSON(scene, nodes)
+		result.Stats = stats
+		if relativeTo != "" {
+			if err := setRelativePaths(scene, result.Nodes); err != nil {
+				results = append(results, &SceneJSON{File: file, Error: err.Error()})
+				continue
+			}
+		}
+		results = append(results, result)
+	}
+
+	if len(results) == 1 {
+		if results[0].Error != "" {
+			return fmt.Errorf("%s: %s", results[0].File, results[0].Error)
+		}
+		return writeJSON(out, results[0])
+	}
+	return writeJSON(out, results)
+}
+
+// printNodesJSONLines writes every node (or the queried node) of the given
+// files as one JSON object per line, as each file is parsed
+func printNodesJSONLines(out io.Writer, files []string) error {
+	for _, file := range files {
+		scene, err := parseSceneFile(file)
+		if err != nil {
+			logger.Warn("Skipping file", "path", file, "error", err)
+			continue
+		}
+
+		nodes := scene.AllNodes
+		if nodePath != "" {
+			if nodes, err = queryNodes(scene); err != nil {
+				logger.Warn("Skipping file", "path", file, "error", err)
+				continue
+			}
+		}
+		if typeFilter != "" {
+			nodes = findQueriedNodesOfType(scene, nodes)
+		}
+
+		var base *GodotNode
+		if relativeTo != "" {
+			if base, err = findRelativeBase(scene); err != nil {
+				logger.Warn("Skipping file", "path", file, "error", err)
+				continue
+			}
+		}
+
+		for _, node := range nodes {
+			line := &NodeLineJSON{File: file, NodeJSON: nodeToJSON(node)}
+			if base != nil {
+				line.RelativePath = relativeNodePath(base, node)
+			}
+			if err := writeJSONLine(out, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
-// Main function
-func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+// printSceneGraphs writes the node trees of the given files as DOT or GraphML graphs
+func printSceneGraphs(out io.Writer, files []string) error {
+	var graphs []*ExportGraph
+	for _, file := range files {
+		scene, err := parseSceneFile(file)
+		if err != nil {
+			return fmt.Errorf("parse error: %v", err)
+		}
+		graphs = append(graphs, sceneTreeGraph(file, scene))
 	}
+	return writeExportGraphs(out, graphs...)
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&debugMode, "debug", "d", false, "Enable debug logging (same as --log-level debug)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "warn", "Log level: debug, info, warn")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Log format: text, json")
+	rootCmd.Flags().BoolVarP(&showSummary, "summary", "s", false, "Display statistics summary")
+	rootCmd.Flags().StringVarP(&nodePath, "query", "q", "", "Search for a specific node path (e.g., \"Player/Sprite\"); every matching node is shown")
+	rootCmd.Flags().BoolVar(&queryFirst, "first", false, "With --query, show only the first matching node")
+	rootCmd.Flags().BoolVar(&queryExpectOne, "expect-one", false, "With --query, fail when several nodes match")
+	rootCmd.Flags().BoolVar(&showSubtreeStats, "stat", false, "With --query, display statistics of the subtree (node types, scripts, depth)")
+	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Display all properties in detail")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text, json, jsonl, sexpr, scenetree, dot, graphml, mermaid-signals (json includes line/byte spans of every section)")
+	rootCmd.PersistentFlags().BoolVar(&noProgress, "no-progress", false, "Do not show a progress bar on stderr while scanning directories")
+	rootCmd.PersistentFlags().StringVar(&outputFile, "out", "", "Write the output to this file instead of stdout")
+	rootCmd.Flags().BoolVar(&showLayout, "layout", false, "Display anchors, offsets, size flags and estimated rects of Control nodes")
+	rootCmd.Flags().StringVar(&layoutViewport, "viewport", "1152x648", "Viewport size used to estimate Control rects (WIDTHxHEIGHT)")
+	rootCmd.Flags().BoolVar(&showEffectiveVisibility, "effective-visibility", false, "Display the effective visibility of each node (own and ancestors' visible, modulate alpha)")
+	rootCmd.Flags().BoolVar(&showRuntime, "runtime", false, "Display the effective process mode and the process/physics settings of each node")
+	rootCmd.Flags().BoolVar(&showTransforms, "transforms", false, "Display the position, rotation (degrees) and scale of Node3D nodes, decomposed from their Transform3D")
+	rootCmd.Flags().BoolVar(&showZOrder, "z-order", false, "List CanvasItem nodes in their effective draw order (canvas layer, z_index, y-sort, tree order)")
+	rootCmd.Flags().BoolVar(&showHiddenOnly, "hidden", false, "List only the nodes hidden at load (implies --effective-visibility)")
+	rootCmd.Flags().StringVar(&treeStyle, "tree-style", "indent", "Tree connectors: unicode (├──/└──), ascii (|--/`--) or indent")
+	rootCmd.Flags().StringVar(&relativeTo, "relative-to", "", "Print node paths relative to this node, as get_node() expects them in its script")
+	rootCmd.Flags().StringVar(&typeFilter, "type", "", "List only nodes of this type, including subclasses and script classes (class_name)")
+	rootCmd.RegisterFlagCompletionFunc("type", completeClassName)
+	rootCmd.Flags().BoolVar(&expandInstances, "expand-instances", false, "Replace instanced scenes by their nodes (the runtime tree), with the file defining each node in verbose and JSON output")
+	rootCmd.Flags().BoolVar(&structureOnly, "structure-only", false, "Skip node properties and parse only the hierarchy (faster on huge scenes)")
+	rootCmd.Flags().BoolVar(&lowMemory, "low-memory", false, "Keep only the length, hash and start of property values over 16 KiB (for scenes with huge embedded data)")
+	rootCmd.Flags().BoolVar(&forestMode, "forest", false, "Show nodes whose parent does not exist as separate trees instead of attaching them under the root")
+	rootCmd.Flags().BoolVar(&annotateTree, "annotate", false, "Mark nodes in the tree: missing script (❌), instanced scene (↪), connected signals (⚡), hidden (👻)")
+	rootCmd.Flags().BoolVar(&rawValues, "raw", false, "Show property values as stored, without converting rotations to degrees, colors to hex and grouping large numbers")
+	rootCmd.Flags().BoolVar(&annotateNoEmoji, "no-emoji", false, "With --annotate, use text markers ([missing-script], [instance], [signals], [hidden])")
+	rootCmd.Flags().BoolVar(&noTruncate, "no-truncate", false, "In verbose mode, show long property values whole instead of cutting them to the terminal width")
+	rootCmd.Flags().BoolVar(&wrapValues, "wrap", false, "In verbose mode, wrap long property values onto aligned lines instead of cutting them")
+	rootCmd.Flags().BoolVar(&onlyOverrides, "only-overrides", false, "Display only properties that differ from the class defaults")
+	rootCmd.PersistentFlags().StringVar(&classDBPath, "class-db", "", "Load class defaults from a JSON file or godot --doctool XML directory")
 }
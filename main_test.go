@@ -1,7 +1,12 @@
-package main
+package gdquery
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -150,4 +155,484 @@ Ceylon"
 	if text != expected {
 		t.Errorf("Multiline text not parsed correctly (expected: %q, got: %q)", expected, text)
 	}
-}
\ No newline at end of file
+}
+
+func TestMultilineValues(t *testing.T) {
+	content := `[gd_scene load_steps=2 format=3]
+
+[sub_resource type="Resource" id="Resource_1"]
+grid = [[1, 2],
+[3, 4]]
+
+[node name="Root" type="Node2D"]
+ids = Array[int]([
+1,
+2
+])
+names = PackedStringArray(
+"a (",
+"b ]"
+)
+say = "He said \"hi\"
+and \"bye\""
+
+[node name="Child" type="Node" parent="."]
+data = {
+"list": [
+[1],
+[2]
+]
+}
+`
+	scene, err := ParseReader(strings.NewReader(content), "values.tscn", ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(scene.AllNodes) != 2 || scene.AllNodes[1].Path != "Root/Child" {
+		t.Fatalf("Unexpected nodes: %d", len(scene.AllNodes))
+	}
+	if span := scene.SubResources["Resource_1"].Span; span.EndLine != 5 {
+		t.Errorf("Expected the sub_resource to end at line 5, got %d", span.EndLine)
+	}
+
+	root := scene.AllNodes[0].Properties
+	expected := map[string]string{
+		"ids":   "Array[int]([\n1,\n2\n])",
+		"names": "PackedStringArray(\n\"a (\",\n\"b ]\"\n)",
+		"say":   "He said \\\"hi\\\"\nand \\\"bye\\\"",
+	}
+	for key, value := range expected {
+		if root[key] != value {
+			t.Errorf("%s: expected %q, got %q", key, value, root[key])
+		}
+	}
+	if data := scene.AllNodes[1].Properties["data"]; data != "{\n\"list\": [\n[1],\n[2]\n]\n}" {
+		t.Errorf("Unexpected data: %q", data)
+	}
+}
+
+func TestSectionSpans(t *testing.T) {
+	// CRLF line endings must be counted in byte offsets
+	content := strings.ReplaceAll(`[gd_scene load_steps=2 format=3]
+
+[sub_resource type="RectangleShape2D" id="Shape_1"]
+size = Vector2(4, 4)
+
+[node name="Root" type="Node2D"]
+
+[node name="Label" type="Label" parent="."]
+text = "a
+b"
+
+[connection signal="ready" from="." to="." method="_on_ready"]
+`, "\n", "\r\n")
+
+	tempFile := "test_spans.tscn"
+	if err := os.WriteFile(tempFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	sections := map[string]SourceSpan{
+		"Shape_1": scene.SubResources["Shape_1"].Span,
+		"Root":    scene.AllNodes[0].Span,
+		"Label":   scene.AllNodes[1].Span,
+	}
+	expected := map[string][2]int{
+		"Shape_1": {3, 4},
+		"Root":    {6, 6},
+		"Label":   {8, 10},
+	}
+	for name, lines := range expected {
+		span := sections[name]
+		if span.StartLine != lines[0] || span.EndLine != lines[1] {
+			t.Errorf("%s: expected lines %d-%d, got %d-%d", name, lines[0], lines[1], span.StartLine, span.EndLine)
+		}
+		text := content[span.StartByte:span.EndByte]
+		if !strings.HasPrefix(text, "[") || !strings.HasSuffix(text, "\r\n") || strings.HasSuffix(text, "\r\n\r\n") {
+			t.Errorf("%s: span does not cover the section exactly: %q", name, text)
+		}
+	}
+}
+
+func TestSkipProperties(t *testing.T) {
+	// The "[node" line inside the multiline text must not start a section
+	content := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://root.gd" id="1_s"]
+
+[node name="Root" type="Node2D"]
+script = ExtResource("1_s")
+position = Vector2(1, 2)
+
+[node name="Label" type="Label" parent="."]
+text = "first
+[node name=\"Fake\" type=\"Node\" parent=\".\"]
+last"
+
+[node name="Child" type="Node2D" parent="Label"]
+`
+
+	tempFile := "test_structure_only.tscn"
+	if err := os.WriteFile(tempFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseFile(tempFile, ParseOptions{SkipProperties: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var paths []string
+	for _, node := range scene.AllNodes {
+		paths = append(paths, node.Path)
+		if node.Path != "Root" && len(node.Properties) != 0 {
+			t.Errorf("%s: expected no properties, got %v", node.Path, node.Properties)
+		}
+	}
+	if got := strings.Join(paths, ","); got != "Root,Root/Label,Root/Label/Child" {
+		t.Errorf("Unexpected nodes: %s", got)
+	}
+	if scene.RootNode.Script != `ExtResource("1_s")` || len(scene.RootNode.Properties) != 1 {
+		t.Errorf("Expected only the script to be kept, got %v", scene.RootNode.Properties)
+	}
+	if scene.AllNodes[1].Span.EndLine != 12 {
+		t.Errorf("Expected the Label span to end at line 12, got %d", scene.AllNodes[1].Span.EndLine)
+	}
+}
+
+func TestHugeLine(t *testing.T) {
+	// bufio.Scanner used to fail with "token too long" on lines over 10MB
+	blob := strings.Repeat("255, ", 3*1024*1024)
+	content := "[gd_scene load_steps=1 format=3]\n\n[node name=\"Root\" type=\"Node2D\"]\n" +
+		"data = PackedByteArray(" + blob + "0)\n\n[node name=\"Child\" type=\"Node2D\" parent=\".\"]"
+
+	tempFile := "test_huge_line.tscn"
+	if err := os.WriteFile(tempFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(scene.AllNodes) != 2 {
+		t.Fatalf("Expected 2 nodes, got %d", len(scene.AllNodes))
+	}
+	if got := len(scene.RootNode.Properties["data"]); got != len(blob)+len("PackedByteArray(0)") {
+		t.Errorf("Unexpected data length %d", got)
+	}
+	// The last line has no line ending
+	if span := scene.AllNodes[1].Span; span.EndByte != len(content) || span.StartLine != 6 {
+		t.Errorf("Unexpected span of the last node: %+v", span)
+	}
+}
+
+func TestOwnerAttribute(t *testing.T) {
+	// Written by an external tool: explicit owners, one node with an owner but no parent
+	content := `[gd_scene format=3]
+
+[node name="Root" type="Node2D"]
+
+[node name="Body" type="Node2D" parent="." owner="."]
+
+[node name="Arm" type="Node2D" owner="Body"]
+
+[node name="Hand" type="Node2D" parent="Body/Arm" owner="."]
+`
+	scene, err := ParseReader(strings.NewReader(content), "owners.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var paths []string
+	for _, node := range scene.AllNodes {
+		paths = append(paths, node.Path+"@"+node.Owner)
+	}
+	if got := strings.Join(paths, ","); got != "Root@,Root/Body@.,Root/Body/Arm@Body,Root/Body/Arm/Hand@." {
+		t.Errorf("Unexpected nodes: %s", got)
+	}
+	if owner := nodeToJSON(scene.AllNodes[2]).Owner; owner != "Body" {
+		t.Errorf("Expected the owner in JSON, got %q", owner)
+	}
+}
+
+func TestChildIndex(t *testing.T) {
+	// Nodes added to an inherited scene keep the position they were moved to
+	content := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://base.tscn" id="1_b"]
+
+[node name="Main" instance=ExtResource("1_b")]
+
+[node name="A" type="Node" parent="."]
+
+[node name="B" type="Node" parent="."]
+
+[node name="C" type="Node" parent="." index="0"]
+
+[node name="D" type="Node" parent="." index="2"]
+
+[node name="E" type="Node" parent="." index="9"]
+
+[node name="F" type="Node" parent="C" index="0"]
+`
+	childNames := func(scene *GodotScene) string {
+		var names []string
+		for _, child := range scene.RootNode.Children {
+			names = append(names, child.OriginalName)
+		}
+		return strings.Join(names, ",")
+	}
+
+	scene, err := ParseReader(strings.NewReader(content), "inherited.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if got := childNames(scene); got != "C,A,D,B,E" {
+		t.Errorf("Unexpected child order: %s", got)
+	}
+	if scene.AllNodes[1].Index != -1 || scene.AllNodes[3].Index != 0 {
+		t.Errorf("Unexpected indexes: %d, %d", scene.AllNodes[1].Index, scene.AllNodes[3].Index)
+	}
+	if len(scene.AllNodes[3].Children) != 1 || scene.AllNodes[6].Path != "Main/C/F" {
+		t.Errorf("Unexpected children of C: %v", scene.AllNodes[3].Children)
+	}
+
+	// The project index keeps index="0" apart from a missing index
+	file := filepath.Join(t.TempDir(), "inherited.tscn")
+	os.WriteFile(file, []byte(content), 0644)
+	info, _ := os.Stat(file)
+	data, _ := json.Marshal(indexScene(scene, "res://inherited.tscn", info))
+	entry := &IndexedScene{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		t.Fatalf("Index error: %v", err)
+	}
+	rebuilt := entry.scene(file, false)
+	if got := childNames(rebuilt); got != "C,A,D,B,E" {
+		t.Errorf("Unexpected child order from the index: %s", got)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	content := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://player.tscn" id="1_p"]
+
+[node name="Root" type="Node2D"]
+position = Vector2(1, 2)
+
+[node name="Player" parent="." instance=ExtResource("1_p")]
+
+[node name="Lost" type="Node" parent="Missing"]
+`
+	parse := func(opts ParseOptions) (*GodotScene, error) {
+		return ParseReader(strings.NewReader(content), "main.tscn", opts)
+	}
+
+	scene, err := parse(ParseOptions{KeepRawLines: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if got := strings.Join(scene.RootNode.RawLines, "|"); got != `[node name="Root" type="Node2D"]|position = Vector2(1, 2)` {
+		t.Errorf("Unexpected raw lines: %s", got)
+	}
+	if scene.AllNodes[1].InstanceType != "" {
+		t.Errorf("Expected instances not to be followed by default")
+	}
+
+	if _, err := parse(ParseOptions{MaxLineSize: 20}); err == nil || !strings.Contains(err.Error(), "line 1: line longer than 20 bytes") {
+		t.Errorf("Expected a line size error, got %v", err)
+	}
+
+	// Lenient parsing attaches Lost to the root; strict parsing rejects it
+	if _, err := parse(ParseOptions{Strict: true}); err == nil || err.Error() != "node Lost: parent Missing not found" {
+		t.Errorf("Expected a strict parent error, got %v", err)
+	}
+
+	// Forest parsing keeps Lost, and the nodes under it, as a detached tree
+	forest, err := ParseReader(strings.NewReader(content+"\n[node name=\"Kid\" type=\"Label\" parent=\"Missing/Lost\"]\n\n[node name=\"Second\" type=\"Node\"]\n"), "main.tscn", ParseOptions{Forest: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	var detached []string
+	for _, root := range forest.DetachedRoots {
+		detached = append(detached, root.Path)
+	}
+	if strings.Join(detached, ",") != "Missing/Lost,Second" || len(forest.RootNode.Children) != 1 {
+		t.Errorf("Unexpected detached roots: %v", detached)
+	}
+	if lost := forest.DetachedRoots[0]; len(lost.Children) != 1 || lost.Children[0].Path != "Missing/Lost/Kid" || lost.ParentNode() != nil {
+		t.Errorf("Unexpected detached tree: %+v", lost.Children)
+	}
+
+	for _, broken := range []string{
+		"[gd_scene format=3]\n\n[node name=\"A\" type=\"Node\"]\n\n[node name=\"B\" type=\"Node\"]\n",
+		"[gd_scene format=3]\n\n[node name=\"A\" type=\"Label\"]\ntext = \"never closed\n",
+	} {
+		if _, err := ParseReader(strings.NewReader(broken), "broken.tscn", ParseOptions{Strict: true}); err == nil {
+			t.Errorf("Expected a strict error for:\n%s", broken)
+		}
+	}
+
+	root := t.TempDir()
+	player := "[gd_scene format=3]\n\n[node name=\"Player\" type=\"CharacterBody2D\"]\n"
+	if err := os.WriteFile(filepath.Join(root, "player.tscn"), []byte(player), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scene, err = parse(ParseOptions{FollowInstances: true, ProjectRoot: root})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if got := scene.AllNodes[1].InstanceType; got != "CharacterBody2D" {
+		t.Errorf("Expected the instanced root type, got %q", got)
+	}
+}
+
+func TestNestedParentPaths(t *testing.T) {
+	// The sample repeats names at different depths (Control, Control/battleScene/Control)
+	scene, err := ParseFile(filepath.Join("test", "sample.tscn"), ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	for _, node := range scene.AllNodes[1:] {
+		expected := scene.RootNode.Path + "/" + node.Name
+		if node.Parent != "." {
+			expected = scene.RootNode.Path + "/" + node.Parent + "/" + node.Name
+		}
+		if node.Path != expected {
+			t.Errorf("Node %s attached as %s, expected %s", node.Name, node.Path, expected)
+		}
+	}
+
+	content := `[gd_scene format=3]
+
+[node name="Root" type="Node"]
+
+[node name="Data" type="Node" parent="."]
+
+[node name="A" type="Node" parent="."]
+
+[node name="Data" type="Node" parent="A"]
+
+[node name="Deep" type="Node" parent="A/Data"]
+
+[node name="Lost" type="Node" parent="Nope/Data"]
+`
+	scene, err = ParseReader(strings.NewReader(content), "main.tscn", ParseOptions{Forest: true})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if deep := scene.AllNodes[4]; deep.Path != "Root/A/Data/Deep" {
+		t.Errorf("Expected Deep under A/Data, got %s", deep.Path)
+	}
+	// Under --forest a missing nested parent is not matched by name
+	if len(scene.DetachedRoots) != 1 || scene.DetachedRoots[0].Path != "Nope/Data/Lost" {
+		t.Errorf("Expected Lost as a detached tree, got %v", scene.DetachedRoots)
+	}
+}
+
+// captureOutput returns what fn writes to out
+func captureOutput(fn func(out io.Writer)) string {
+	var out strings.Builder
+	fn(&out)
+	return out.String()
+}
+
+func TestTreeStyles(t *testing.T) {
+	tempFile := "test_tree_style.tscn"
+	if err := os.WriteFile(tempFile, []byte(testTscnContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	tests := map[string]string{
+		"indent": `Root (Node2D)
+  Child1 (Control)
+    GrandChild (Button)
+        text: "Test Button"
+      DeepChild (Label)
+          text: "Deep Level"
+  Child2 (Control)
+`,
+		"unicode": `Root (Node2D)
+├── Child1 (Control)
+│   └── GrandChild (Button)
+│       │   text: "Test Button"
+│       └── DeepChild (Label)
+│               text: "Deep Level"
+└── Child2 (Control)
+`,
+		"ascii": `Root (Node2D)
+|-- Child1 (Control)
+|   ` + "`" + `-- GrandChild (Button)
+|       |   text: "Test Button"
+|       ` + "`" + `-- DeepChild (Label)
+|               text: "Deep Level"
+` + "`" + `-- Child2 (Control)
+`,
+	}
+	defer func() { treeStyle = "indent" }()
+	for style, expected := range tests {
+		treeStyle = style
+		if got := captureOutput(func(out io.Writer) { printSceneTree(out, scene.RootNode, scene) }); got != expected {
+			t.Errorf("Unexpected %s tree:\n%s", style, got)
+		}
+	}
+}
+
+func TestQueryMultipleMatches(t *testing.T) {
+	content := `[gd_scene format=3]
+
+[node name="Main" type="Node2D"]
+
+[node name="Enemy" type="Node2D" parent="."]
+
+[node name="Sprite" type="Sprite2D" parent="Enemy"]
+
+[node name="Boss" type="Node2D" parent="."]
+
+[node name="Sprite" type="Sprite2D" parent="Boss"]
+`
+	file := filepath.Join(t.TempDir(), "main.tscn")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-q", "Sprite", file}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	expected := "Match 1 of 2: Main/Enemy/Sprite\nSprite (Sprite2D)\n\nMatch 2 of 2: Main/Boss/Sprite\nSprite (Sprite2D)\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected both matches, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := Run([]string{"-q", "Sprite", "--first", file}, &stdout, &stderr); code != 0 || stdout.String() != "Sprite (Sprite2D)\n" {
+		t.Errorf("Expected only the first match, got %d:\n%s", code, stdout.String())
+	}
+
+	stderr.Reset()
+	if code := Run([]string{"-q", "Sprite", "--expect-one", file}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit status 1 with --expect-one, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "query Sprite matches 2 nodes: Main/Enemy/Sprite, Main/Boss/Sprite") {
+		t.Errorf("Unexpected error: %s", stderr.String())
+	}
+	if code := Run([]string{"-q", "Enemy/Sprite", "--expect-one", file}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected a single match for Enemy/Sprite, got %d", code)
+	}
+}
@@ -0,0 +1,178 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gdquery/query"
+	"github.com/spf13/cobra"
+)
+
+// Matrix command options
+var matrixQuery = ""
+var matrixProps []string
+var matrixDiffOnly = false
+
+// Matrix cell markers for nodes missing from a file and properties left unset
+const (
+	matrixMissing = "-"
+	matrixUnset   = "(unset)"
+)
+
+// MatrixRow is a property of a node compared across scene variants. Nodes are
+// identified by their path below the scene root, so variants with differently
+// named roots still line up.
+type MatrixRow struct {
+	Node     string
+	Property string
+	Values   []string // one per file
+}
+
+// differs reports whether the values are not all the same
+func (r *MatrixRow) differs() bool {
+	for _, value := range r.Values[1:] {
+		if value != r.Values[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// MatrixRowJSON is the JSON form of a MatrixRow
+type MatrixRowJSON struct {
+	Node     string            `json:"node"`
+	Property string            `json:"property"`
+	Values   map[string]string `json:"values"`
+}
+
+// buildPropertyMatrix compares props of the nodes selected by matcher (all
+// nodes when nil) across scenes, with one row per node and property in order
+// of first appearance
+func buildPropertyMatrix(scenes []*GodotScene, matcher query.Matcher, props []string) []*MatrixRow {
+	var rows []*MatrixRow
+	rowIndex := make(map[string]*MatrixRow)
+	for i, scene := range scenes {
+		if scene.RootNode == nil {
+			continue
+		}
+		for _, node := range scene.AllNodes {
+			if matcher != nil && !matcher.Match(QueryNode(node, scene)) {
+				continue
+			}
+			path := relativeNodePath(scene.RootNode, node)
+			for _, prop := range props {
+				key := path + "\x00" + prop
+				row, exists := rowIndex[key]
+				if !exists {
+					row = &MatrixRow{Node: path, Property: prop, Values: make([]string, len(scenes))}
+					for j := range row.Values {
+						row.Values[j] = matrixMissing
+					}
+					rowIndex[key] = row
+					rows = append(rows, row)
+				}
+				row.Values[i] = matrixUnset
+				if value, exists := node.Properties[prop]; exists {
+					row.Values[i] = value
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// printPropertyMatrix displays the rows as a table with one column per file
+func printPropertyMatrix(out io.Writer, files []string, rows []*MatrixRow, props []string) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := []string{"NODE"}
+	if len(props) > 1 {
+		header = append(header, "PROPERTY")
+	}
+	fmt.Fprintln(w, strings.Join(append(header, files...), "\t"))
+	for _, row := range rows {
+		cells := []string{row.Node}
+		if len(props) > 1 {
+			cells = append(cells, row.Property)
+		}
+		fmt.Fprintln(w, strings.Join(append(cells, row.Values...), "\t"))
+	}
+	w.Flush()
+}
+
+var matrixCmd = &cobra.Command{
+	Use:   "matrix --prop <property> <tscn file> <tscn file> [tscn files...]",
+	Short: "Compare node properties across scene variants",
+	Long: `Print a table with one row per node path and one column per file, showing how properties
+differ between scene variants (e.g. level_easy/normal/hard). Node paths are relative to
+the scene root. "-" marks a node missing from a file and "(unset)" a property left at its default.`,
+	Example:      `  gdq matrix --query 'type=Label' --prop text level_easy.tscn level_normal.tscn level_hard.tscn`,
+	Args:         cobra.MinimumNArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		if len(matrixProps) == 0 {
+			return fmt.Errorf("--prop is required")
+		}
+
+		var matcher query.Matcher
+		if matrixQuery != "" {
+			nodeQuery, err := query.Compile(matrixQuery)
+			if err != nil {
+				return err
+			}
+			matcher = nodeQuery
+		}
+
+		scenes := make([]*GodotScene, 0, len(args))
+		for _, file := range args {
+			if _, err := os.Stat(file); os.IsNotExist(err) {
+				return fmt.Errorf("file not found: %s", file)
+			}
+			scene, err := parseSceneFile(file)
+			if err != nil {
+				return fmt.Errorf("parse error: %s: %v", file, err)
+			}
+			scenes = append(scenes, scene)
+		}
+
+		rows := buildPropertyMatrix(scenes, matcher, matrixProps)
+		if matrixDiffOnly {
+			var differing []*MatrixRow
+			for _, row := range rows {
+				if row.differs() {
+					differing = append(differing, row)
+				}
+			}
+			rows = differing
+		}
+
+		if outputFormat == "json" {
+			list := make([]*MatrixRowJSON, 0, len(rows))
+			for _, row := range rows {
+				values := make(map[string]string)
+				for i, file := range args {
+					values[file] = row.Values[i]
+				}
+				list = append(list, &MatrixRowJSON{Node: row.Node, Property: row.Property, Values: values})
+			}
+			return writeJSON(out, list)
+		}
+
+		printPropertyMatrix(out, args, rows, matrixProps)
+		return nil
+	},
+}
+
+func init() {
+	matrixCmd.Flags().StringVar(&matrixQuery, "query", "", "Compare only nodes matching this query (e.g. 'type=Label')")
+	matrixCmd.RegisterFlagCompletionFunc("query", completeQueryType)
+	matrixCmd.Flags().StringSliceVar(&matrixProps, "prop", nil, "Property to compare (repeatable)")
+	matrixCmd.Flags().BoolVar(&matrixDiffOnly, "diff-only", false, "Show only rows whose values differ between files")
+	rootCmd.AddCommand(matrixCmd)
+}
@@ -0,0 +1,71 @@
+package gdquery
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gdquery/query"
+)
+
+func TestPropertyMatrix(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"level_easy.tscn": `[gd_scene format=3]
+
+[node name="LevelEasy" type="Node2D"]
+
+[node name="Title" type="Label" parent="."]
+text = "Easy"
+
+[node name="Hint" type="Label" parent="."]
+text = "Press jump"
+`,
+		"level_hard.tscn": `[gd_scene format=3]
+
+[node name="LevelHard" type="Node2D"]
+
+[node name="Title" type="Label" parent="."]
+text = "Hard"
+visible = false
+
+[node name="Timer" type="Label" parent="."]
+`,
+	})
+
+	var scenes []*GodotScene
+	for _, name := range []string{"level_easy.tscn", "level_hard.tscn"} {
+		scene, err := ParseTscnFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		scenes = append(scenes, scene)
+	}
+
+	nodeQuery, err := query.Compile("type=Label")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rows := buildPropertyMatrix(scenes, nodeQuery, []string{"text", "visible"})
+
+	var got []string
+	for _, row := range rows {
+		got = append(got, row.Node+" "+row.Property+": "+strings.Join(row.Values, " | "))
+	}
+	expected := []string{
+		`Title text: "Easy" | "Hard"`,
+		`Title visible: (unset) | false`,
+		`Hint text: "Press jump" | -`,
+		`Hint visible: (unset) | -`,
+		`Timer text: - | (unset)`,
+		`Timer visible: - | (unset)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected matrix:\n%s", strings.Join(got, "\n"))
+	}
+
+	// Roots with different names line up
+	rows = buildPropertyMatrix(scenes, nil, []string{"name"})
+	if len(rows) != 4 || rows[0].Node != "." || rows[0].differs() {
+		t.Errorf("Expected the roots to share the row \".\", got %+v", rows[0])
+	}
+}
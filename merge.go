@@ -0,0 +1,451 @@
+package gdquery
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Merge command options
+var mergeInteractive = false
+
+// mergeSection is a section of a scene being merged, with its properties by key.
+// Sections and properties are matched by identity, not by line.
+type mergeSection struct {
+	Key     string
+	Header  string
+	Keys    []string          // property keys in order, including removed ones
+	Values  map[string]string // raw values of the present properties
+	removed bool
+}
+
+// newMergeSection collects the properties of a section
+func newMergeSection(key string, section *sceneSection) *mergeSection {
+	s := &mergeSection{Key: key, Header: section.Header, Values: make(map[string]string)}
+	for i := 0; i < len(section.Lines); i++ {
+		k, value, ok := splitPropertyLine(section.Lines[i])
+		if !ok {
+			continue
+		}
+		end := i + 1
+		for !valueComplete(value) && end < len(section.Lines) {
+			value += "\n" + section.Lines[end]
+			end++
+		}
+		s.Keys = append(s.Keys, k)
+		s.Values[k] = value
+		i = end - 1
+	}
+	return s
+}
+
+// value returns the raw value of a property, or nil when it is not set
+func (s *mergeSection) value(key string) *string {
+	if s == nil {
+		return nil
+	}
+	if value, exists := s.Values[key]; exists {
+		return &value
+	}
+	return nil
+}
+
+// String formats the section as scene text
+func (s *mergeSection) String() string {
+	lines := []string{s.Header}
+	for _, key := range s.Keys {
+		if value, exists := s.Values[key]; exists {
+			lines = append(lines, key+" = "+value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sectionKey identifies a section across versions of a scene: resources by id,
+// nodes by path from the root ("." for the root) and connections by their header
+func sectionKey(header string) string {
+	switch {
+	case strings.HasPrefix(header, "[gd_scene"), strings.HasPrefix(header, "[gd_resource"):
+		return "file header"
+	case strings.HasPrefix(header, "[ext_resource"), strings.HasPrefix(header, "[sub_resource"):
+		kind := strings.Trim(strings.Fields(header)[0], "[]")
+		if matches := sectionIDRe.FindStringSubmatch(header); matches != nil {
+			return kind + " " + matches[1] + matches[2]
+		}
+	case strings.HasPrefix(header, "[node"):
+		node := parseNodeHeader(header)
+		switch node.Parent {
+		case "":
+			return "node ."
+		case ".":
+			return "node " + node.Name
+		}
+		return "node " + node.Parent + "/" + node.Name
+	}
+	return header
+}
+
+// indexSections splits scene content into merge sections. Repeated keys get a
+// "#n" suffix so that every section stays addressable.
+func indexSections(content string) ([]string, []*mergeSection, map[string]*mergeSection) {
+	text := splitSceneText(content)
+	var sections []*mergeSection
+	byKey := make(map[string]*mergeSection)
+	for _, section := range text.Sections {
+		key := sectionKey(section.Header)
+		for n := 2; byKey[key] != nil; n++ {
+			key = sectionKey(section.Header) + "#" + strconv.Itoa(n)
+		}
+		s := newMergeSection(key, section)
+		sections = append(sections, s)
+		byKey[key] = s
+	}
+	return text.Preamble, sections, byKey
+}
+
+// MergeConflict is a change both sides made differently. Values are nil when
+// the property or section is absent on that side.
+type MergeConflict struct {
+	Section  string
+	Property string // empty for the header or the whole section
+	Base     *string
+	Ours     *string
+	Theirs   *string
+	apply    func(value *string)
+}
+
+// editable reports whether the conflict can be resolved with a typed value
+func (c *MergeConflict) editable() bool {
+	return c.Property != "" || c.Ours != nil && c.Theirs != nil
+}
+
+// Resolve sets the merged value; nil removes the property or section
+func (c *MergeConflict) Resolve(value *string) {
+	c.apply(value)
+}
+
+// SceneMerge is the result of a three-way merge of a scene
+type SceneMerge struct {
+	Preamble  []string
+	Sections  []*mergeSection
+	Conflicts []*MergeConflict
+}
+
+// String formats the merged scene, updating load_steps to the merged resources
+func (m *SceneMerge) String() string {
+	var sections []*sceneSection
+	for _, s := range m.Sections {
+		if !s.removed {
+			lines := strings.Split(s.String(), "\n")
+			sections = append(sections, &sceneSection{Header: lines[0], Lines: lines[1:]})
+		}
+	}
+	updateLoadSteps(sections)
+
+	content := formatSceneSections(sections)
+	if preamble := strings.Join(m.Preamble, "\n"); strings.TrimSpace(preamble) != "" {
+		return preamble + "\n" + content
+	}
+	return content
+}
+
+// equalValues compares optional values
+func equalValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// mergeValue merges a value changed on either side, returning ours and true
+// when both sides changed it differently
+func mergeValue(base, ours, theirs *string) (*string, bool) {
+	switch {
+	case equalValues(ours, theirs), equalValues(base, theirs):
+		return ours, false
+	case equalValues(base, ours):
+		return theirs, false
+	}
+	return ours, true
+}
+
+// sectionText returns the text of an optional section
+func sectionText(s *mergeSection) *string {
+	if s == nil {
+		return nil
+	}
+	text := s.String()
+	return &text
+}
+
+// mergeProperties merges the header and properties of a section present in
+// ours and theirs (base is nil when both sides added it)
+func (m *SceneMerge) mergeProperties(base, ours, theirs *mergeSection) *mergeSection {
+	merged := &mergeSection{Key: ours.Key, Header: ours.Header, Values: make(map[string]string)}
+
+	// load_steps is recomputed when writing
+	header := func(s *mergeSection) *string {
+		if s == nil {
+			return nil
+		}
+		h := loadStepsRe.ReplaceAllString(s.Header, " load_steps=0")
+		return &h
+	}
+	baseHeader, oursHeader, theirsHeader := header(base), header(ours), header(theirs)
+	if value, conflict := mergeValue(baseHeader, oursHeader, theirsHeader); conflict {
+		m.Conflicts = append(m.Conflicts, &MergeConflict{
+			Section: merged.Key, Base: baseHeader, Ours: &ours.Header, Theirs: &theirs.Header,
+			apply: func(value *string) {
+				if value != nil {
+					merged.Header = *value
+				}
+			},
+		})
+	} else if value == theirsHeader {
+		merged.Header = theirs.Header
+	}
+
+	keys := append([]string{}, ours.Keys...)
+	for _, key := range theirs.Keys {
+		if _, exists := ours.Values[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		key := key
+		merged.Keys = append(merged.Keys, key)
+		value, conflict := mergeValue(base.value(key), ours.value(key), theirs.value(key))
+		if value != nil {
+			merged.Values[key] = *value
+		}
+		if conflict {
+			m.Conflicts = append(m.Conflicts, &MergeConflict{
+				Section: merged.Key, Property: key,
+				Base: base.value(key), Ours: ours.value(key), Theirs: theirs.value(key),
+				apply: func(value *string) {
+					if value == nil {
+						delete(merged.Values, key)
+					} else {
+						merged.Values[key] = *value
+					}
+				},
+			})
+		}
+	}
+	return merged
+}
+
+// sectionConflict records a section modified on one side and deleted on the other
+func (m *SceneMerge) sectionConflict(section *mergeSection, base, ours, theirs *mergeSection) {
+	m.Conflicts = append(m.Conflicts, &MergeConflict{
+		Section: section.Key, Base: sectionText(base), Ours: sectionText(ours), Theirs: sectionText(theirs),
+		apply: func(value *string) {
+			section.removed = value == nil
+		},
+	})
+}
+
+// mergeScenes merges the changes from base to ours and from base to theirs.
+// Sections and properties changed on one side only are taken from that side;
+// changes made differently on both sides are returned as conflicts, resolved
+// to ours until Resolve is called.
+func mergeScenes(base, ours, theirs string) *SceneMerge {
+	_, _, baseByKey := indexSections(base)
+	preamble, oursSections, _ := indexSections(ours)
+	_, theirsSections, theirsByKey := indexSections(theirs)
+
+	m := &SceneMerge{Preamble: preamble}
+	for _, o := range oursSections {
+		b, t := baseByKey[o.Key], theirsByKey[o.Key]
+		switch {
+		case b == nil && t == nil:
+			m.Sections = append(m.Sections, o)
+		case t == nil:
+			// Deleted in theirs
+			if o.String() != b.String() {
+				m.Sections = append(m.Sections, o)
+				m.sectionConflict(o, b, o, nil)
+			}
+		default:
+			m.Sections = append(m.Sections, m.mergeProperties(b, o, t))
+		}
+	}
+
+	// Sections only in theirs go after the section preceding them in theirs
+	position := make(map[string]int)
+	for i, s := range m.Sections {
+		position[s.Key] = i
+	}
+	insertAt := 0
+	for _, t := range theirsSections {
+		if _, exists := position[t.Key]; exists {
+			insertAt = position[t.Key] + 1
+			continue
+		}
+		b := baseByKey[t.Key]
+		if b != nil && b.String() == t.String() {
+			// Deleted in ours
+			continue
+		}
+
+		m.Sections = append(m.Sections[:insertAt], append([]*mergeSection{t}, m.Sections[insertAt:]...)...)
+		for i := insertAt; i < len(m.Sections); i++ {
+			position[m.Sections[i].Key] = i
+		}
+		insertAt++
+
+		if b != nil {
+			// Modified in theirs, deleted in ours: keep ours until resolved
+			t.removed = true
+			m.sectionConflict(t, b, nil, t)
+		}
+	}
+	return m
+}
+
+// describeValue formats an optional conflict value on one line
+func describeValue(value *string) string {
+	if value == nil {
+		return "(absent)"
+	}
+	if strings.Contains(*value, "\n") {
+		return strings.ReplaceAll(*value, "\n", "\n          ")
+	}
+	return *value
+}
+
+// resolveConflicts asks how to resolve every conflict: ours, theirs or a typed value
+func resolveConflicts(conflicts []*MergeConflict, in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	readLine := func() (string, error) {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("merge aborted: %v", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	for i, conflict := range conflicts {
+		what := conflict.Section
+		if conflict.Property != "" {
+			what += ": " + conflict.Property
+		}
+		fmt.Fprintf(out, "\nConflict %d/%d: %s\n", i+1, len(conflicts), what)
+		fmt.Fprintf(out, "  base:   %s\n", describeValue(conflict.Base))
+		fmt.Fprintf(out, "  ours:   %s\n", describeValue(conflict.Ours))
+		fmt.Fprintf(out, "  theirs: %s\n", describeValue(conflict.Theirs))
+
+		prompt := "[o]urs, [t]heirs"
+		if conflict.editable() {
+			prompt += ", [e]dit"
+		}
+		for resolved := false; !resolved; {
+			fmt.Fprintf(out, "%s? ", prompt)
+			answer, err := readLine()
+			if err != nil {
+				return err
+			}
+
+			resolved = true
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "o", "ours":
+				conflict.Resolve(conflict.Ours)
+			case "t", "theirs":
+				conflict.Resolve(conflict.Theirs)
+			case "e", "edit":
+				if !conflict.editable() {
+					resolved = false
+					continue
+				}
+				if conflict.Property != "" {
+					fmt.Fprint(out, "value (empty removes the property): ")
+				} else {
+					fmt.Fprint(out, "header: ")
+				}
+				value, err := readLine()
+				if err != nil {
+					return err
+				}
+				if value = strings.TrimSpace(value); value == "" && conflict.Property != "" {
+					conflict.Resolve(nil)
+				} else if value != "" {
+					conflict.Resolve(&value)
+				} else {
+					resolved = false
+				}
+			default:
+				resolved = false
+			}
+		}
+	}
+	return nil
+}
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [--interactive] <base> <ours> <theirs>",
+	Short: "Three-way merge of a scene, resolving conflicts by node and property",
+	Long: `Merge the changes from base to theirs into ours, matching resources by id, nodes by path and
+properties by name instead of by line, and write the result to ours. Changes made on one side
+are taken as is; changes made differently on both sides are conflicts.
+
+Without --interactive, conflicts are listed and nothing is written (exit code 1). With
+--interactive, every conflicting property or section is shown with its base, ours and theirs
+values to pick ours, theirs or type a new value, and the merged scene is written at the end.
+The argument order matches a git merge driver (%O %A %B).`,
+	Example: `  gdq merge --interactive base.tscn level.tscn theirs.tscn
+
+  # .git/config
+  [merge "gdq"]
+      driver = gdq merge %O %A %B`,
+	Args:         cobra.ExactArgs(3),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		var contents []string
+		for _, file := range args {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("file not found: %s", file)
+			}
+			contents = append(contents, string(content))
+		}
+
+		merge := mergeScenes(contents[0], contents[1], contents[2])
+		if len(merge.Conflicts) > 0 {
+			if !mergeInteractive {
+				for _, conflict := range merge.Conflicts {
+					what := conflict.Section
+					if conflict.Property != "" {
+						what += ": " + conflict.Property
+					}
+					fmt.Fprintf(out, "CONFLICT %s\n", what)
+				}
+				return fmt.Errorf("%d conflict(s); rerun with --interactive to resolve them", len(merge.Conflicts))
+			}
+			if err := resolveConflicts(merge.Conflicts, cmd.InOrStdin(), out); err != nil {
+				return err
+			}
+		}
+
+		info, err := os.Stat(args[1])
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(args[1], []byte(merge.String()), info.Mode()); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Merged into %s (%d conflict(s) resolved)\n", args[1], len(merge.Conflicts))
+		return nil
+	},
+}
+
+func init() {
+	mergeCmd.Flags().BoolVarP(&mergeInteractive, "interactive", "i", false, "Resolve conflicts one by one with a prompt")
+	rootCmd.AddCommand(mergeCmd)
+}
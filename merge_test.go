@@ -0,0 +1,130 @@
+package gdquery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const mergeBase = `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_a"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Player" type="CharacterBody2D" parent="."]
+script = ExtResource("1_a")
+position = Vector2(0, 0)
+speed = 100
+
+[node name="Enemy" type="Node2D" parent="."]
+`
+
+// Ours moves the player, changes its speed and adds a HUD
+const mergeOurs = `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_a"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Player" type="CharacterBody2D" parent="."]
+script = ExtResource("1_a")
+position = Vector2(10, 0)
+speed = 150
+
+[node name="Enemy" type="Node2D" parent="."]
+
+[node name="HUD" type="CanvasLayer" parent="."]
+`
+
+// Theirs changes the speed differently, adds a texture and deletes the enemy
+const mergeTheirs = `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_a"]
+[ext_resource type="Texture2D" path="res://icon.png" id="2_b"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Player" type="CharacterBody2D" parent="."]
+script = ExtResource("1_a")
+position = Vector2(0, 0)
+speed = 200
+
+[node name="Sprite" type="Sprite2D" parent="Player"]
+texture = ExtResource("2_b")
+`
+
+func TestMergeScenes(t *testing.T) {
+	merge := mergeScenes(mergeBase, mergeOurs, mergeTheirs)
+
+	if len(merge.Conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %d", len(merge.Conflicts))
+	}
+	conflict := merge.Conflicts[0]
+	if conflict.Section != "node Player" || conflict.Property != "speed" || *conflict.Ours != "150" || *conflict.Theirs != "200" {
+		t.Errorf("Unexpected conflict: %+v", conflict)
+	}
+
+	// Pick theirs
+	var out bytes.Buffer
+	if err := resolveConflicts(merge.Conflicts, strings.NewReader("x\nt\n"), &out); err != nil {
+		t.Fatalf("Resolve error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Conflict 1/1: node Player: speed") {
+		t.Errorf("Expected the conflict to be shown, got:\n%s", out.String())
+	}
+
+	expected := `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_a"]
+[ext_resource type="Texture2D" path="res://icon.png" id="2_b"]
+
+[node name="Main" type="Node2D"]
+
+[node name="Player" type="CharacterBody2D" parent="."]
+script = ExtResource("1_a")
+position = Vector2(10, 0)
+speed = 200
+
+[node name="Sprite" type="Sprite2D" parent="Player"]
+texture = ExtResource("2_b")
+
+[node name="HUD" type="CanvasLayer" parent="."]
+`
+	if got := merge.String(); got != expected {
+		t.Errorf("Unexpected merge:\n%s", got)
+	}
+
+	// Edit the value, or remove the property with an empty value
+	merge = mergeScenes(mergeBase, mergeOurs, mergeTheirs)
+	if err := resolveConflicts(merge.Conflicts, strings.NewReader("e\n175\n"), &out); err != nil {
+		t.Fatalf("Resolve error: %v", err)
+	}
+	if !strings.Contains(merge.String(), "speed = 175\n") {
+		t.Errorf("Expected the edited value, got:\n%s", merge.String())
+	}
+	merge = mergeScenes(mergeBase, mergeOurs, mergeTheirs)
+	if err := resolveConflicts(merge.Conflicts, strings.NewReader("e\n\n"), &out); err != nil {
+		t.Fatalf("Resolve error: %v", err)
+	}
+	if strings.Contains(merge.String(), "speed") {
+		t.Errorf("Expected the property to be removed, got:\n%s", merge.String())
+	}
+
+	// A node modified in ours and deleted in theirs
+	modified := strings.Replace(mergeOurs, `[node name="Enemy" type="Node2D" parent="."]`, "[node name=\"Enemy\" type=\"Node2D\" parent=\".\"]\nvisible = false", 1)
+	merge = mergeScenes(mergeBase, modified, mergeTheirs)
+	if len(merge.Conflicts) != 2 || merge.Conflicts[1].Section != "node Enemy" || merge.Conflicts[1].Theirs != nil {
+		t.Fatalf("Expected a delete conflict on Enemy, got %+v", merge.Conflicts)
+	}
+	if err := resolveConflicts(merge.Conflicts, strings.NewReader("o\nt\n"), &out); err != nil {
+		t.Fatalf("Resolve error: %v", err)
+	}
+	if strings.Contains(merge.String(), "Enemy") {
+		t.Errorf("Expected the deletion to be taken, got:\n%s", merge.String())
+	}
+
+	if err := resolveConflicts(mergeScenes(mergeBase, mergeOurs, mergeTheirs).Conflicts, strings.NewReader(""), &out); err == nil {
+		t.Error("Expected an error when input ends before all conflicts are resolved")
+	}
+}
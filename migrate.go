@@ -0,0 +1,280 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Migration check options
+var migrateFix = false
+
+// LegacyRename is a Godot 3 name and its Godot 4 replacement. Simple renames
+// keep the value format and can be rewritten automatically.
+type LegacyRename struct {
+	New    string
+	Simple bool
+	Note   string // what else has to change for renames that are not simple
+}
+
+// legacyTypeRenames maps Godot 3 node types to their Godot 4 names
+var legacyTypeRenames = map[string]LegacyRename{
+	"Spatial":                   {New: "Node3D", Simple: true},
+	"KinematicBody":             {New: "CharacterBody3D", Note: "move_and_slide() takes no arguments; the velocity property replaces them"},
+	"KinematicBody2D":           {New: "CharacterBody2D", Note: "move_and_slide() takes no arguments; the velocity property replaces them"},
+	"RigidBody":                 {New: "RigidBody3D", Simple: true},
+	"StaticBody":                {New: "StaticBody3D", Simple: true},
+	"Area":                      {New: "Area3D", Simple: true},
+	"CollisionShape":            {New: "CollisionShape3D", Simple: true},
+	"CollisionPolygon":          {New: "CollisionPolygon3D", Simple: true},
+	"RayCast":                   {New: "RayCast3D", Simple: true},
+	"MeshInstance":              {New: "MeshInstance3D", Simple: true},
+	"Camera":                    {New: "Camera3D", Simple: true},
+	"Listener":                  {New: "AudioListener3D", Simple: true},
+	"DirectionalLight":          {New: "DirectionalLight3D", Simple: true},
+	"OmniLight":                 {New: "OmniLight3D", Simple: true},
+	"SpotLight":                 {New: "SpotLight3D", Simple: true},
+	"Path":                      {New: "Path3D", Simple: true},
+	"PathFollow":                {New: "PathFollow3D", Simple: true},
+	"Position2D":                {New: "Marker2D", Simple: true},
+	"Position3D":                {New: "Marker3D", Simple: true},
+	"RemoteTransform":           {New: "RemoteTransform3D", Simple: true},
+	"Skeleton":                  {New: "Skeleton3D", Simple: true},
+	"BoneAttachment":            {New: "BoneAttachment3D", Simple: true},
+	"SoftBody":                  {New: "SoftBody3D", Simple: true},
+	"VehicleBody":               {New: "VehicleBody3D", Simple: true},
+	"VehicleWheel":              {New: "VehicleWheel3D", Simple: true},
+	"Sprite":                    {New: "Sprite2D", Simple: true},
+	"AnimatedSprite":            {New: "AnimatedSprite2D", Note: "frames became sprite_frames and playing became autoplay"},
+	"Particles":                 {New: "GPUParticles3D", Note: "process materials must be converted"},
+	"Particles2D":               {New: "GPUParticles2D", Note: "process materials must be converted"},
+	"CPUParticles":              {New: "CPUParticles3D", Simple: true},
+	"Light2D":                   {New: "PointLight2D", Note: "texture_scale and mode changed"},
+	"TextureProgress":           {New: "TextureProgressBar", Simple: true},
+	"ToolButton":                {New: "Button", Note: "set flat = true"},
+	"Viewport":                  {New: "SubViewport", Note: "size and render settings changed"},
+	"ViewportContainer":         {New: "SubViewportContainer", Simple: true},
+	"VisibilityNotifier":        {New: "VisibleOnScreenNotifier3D", Simple: true},
+	"VisibilityNotifier2D":      {New: "VisibleOnScreenNotifier2D", Simple: true},
+	"VisibilityEnabler":         {New: "VisibleOnScreenEnabler3D", Simple: true},
+	"VisibilityEnabler2D":       {New: "VisibleOnScreenEnabler2D", Simple: true},
+	"NavigationMeshInstance":    {New: "NavigationRegion3D", Simple: true},
+	"NavigationPolygonInstance": {New: "NavigationRegion2D", Simple: true},
+	"GIProbe":                   {New: "VoxelGI", Note: "probe data must be baked again"},
+	"BakedLightmap":             {New: "LightmapGI", Note: "lightmaps must be baked again"},
+	"ARVROrigin":                {New: "XROrigin3D", Simple: true},
+	"ARVRCamera":                {New: "XRCamera3D", Simple: true},
+	"YSort":                     {New: "Node2D", Note: "set y_sort_enabled = true"},
+	"Navigation":                {Note: "removed: use NavigationServer3D and NavigationRegion3D"},
+	"Navigation2D":              {Note: "removed: use NavigationServer2D and NavigationRegion2D"},
+	"Tween":                     {Note: "the Tween node was removed: use create_tween() in scripts"},
+}
+
+// legacyPropertyRenames maps Godot 3 node property names to their Godot 4 names.
+// Keys ending in "/" rename every property with that prefix.
+var legacyPropertyRenames = map[string]LegacyRename{
+	"rect_position":     {New: "position", Simple: true},
+	"rect_size":         {New: "size", Simple: true},
+	"rect_min_size":     {New: "custom_minimum_size", Simple: true},
+	"rect_scale":        {New: "scale", Simple: true},
+	"rect_pivot_offset": {New: "pivot_offset", Simple: true},
+	"rect_clip_content": {New: "clip_contents", Simple: true},
+	"rect_rotation":     {New: "rotation", Note: "degrees became radians"},
+	"margin_left":       {New: "offset_left", Simple: true},
+	"margin_top":        {New: "offset_top", Simple: true},
+	"margin_right":      {New: "offset_right", Simple: true},
+	"margin_bottom":     {New: "offset_bottom", Simple: true},
+	"hint_tooltip":      {New: "tooltip_text", Simple: true},
+	"percent_visible":   {New: "visible_ratio", Simple: true},
+	"translation":       {New: "position", Simple: true},
+	"custom_fonts/":     {New: "theme_override_fonts/", Simple: true},
+	"custom_colors/":    {New: "theme_override_colors/", Simple: true},
+	"custom_constants/": {New: "theme_override_constants/", Simple: true},
+	"custom_styles/":    {New: "theme_override_styles/", Simple: true},
+	"align":             {New: "horizontal_alignment", Note: "Label/Button alignment enums were renamed"},
+	"valign":            {New: "vertical_alignment", Note: "Label alignment enums were renamed"},
+	"autowrap":          {New: "autowrap_mode", Note: "true became 3 (AUTOWRAP_WORD_SMART)"},
+	"pause_mode":        {New: "process_mode", Note: "the mode values changed"},
+}
+
+// LegacyUse is a Godot 3 name found in a scene
+type LegacyUse struct {
+	Node   *GodotNode
+	Kind   string // "type" or "property"
+	Old    string
+	Rename LegacyRename
+}
+
+// legacyPropertyRename returns the rename of a property, with prefix renames applied
+func legacyPropertyRename(prop string) (LegacyRename, bool) {
+	if rename, exists := legacyPropertyRenames[prop]; exists {
+		return rename, true
+	}
+	for prefix, rename := range legacyPropertyRenames {
+		if strings.HasSuffix(prefix, "/") && strings.HasPrefix(prop, prefix) {
+			rename.New += strings.TrimPrefix(prop, prefix)
+			return rename, true
+		}
+	}
+	return LegacyRename{}, false
+}
+
+// findLegacyUses returns the Godot 3 types and properties used by the nodes of a scene
+func findLegacyUses(scene *GodotScene) []LegacyUse {
+	var uses []LegacyUse
+	for _, node := range scene.AllNodes {
+		if rename, exists := legacyTypeRenames[node.Type]; exists {
+			uses = append(uses, LegacyUse{Node: node, Kind: "type", Old: node.Type, Rename: rename})
+		}
+		for _, prop := range sortedKeys(node.Properties) {
+			if rename, exists := legacyPropertyRename(prop); exists {
+				uses = append(uses, LegacyUse{Node: node, Kind: "property", Old: prop, Rename: rename})
+			}
+		}
+	}
+	return uses
+}
+
+// describe formats a legacy use for display
+func (u LegacyUse) describe() string {
+	s := fmt.Sprintf("%s %s", u.Kind, u.Old)
+	if u.Rename.New != "" {
+		s += " -> " + u.Rename.New
+	}
+	if u.Rename.Note != "" {
+		s += " (" + u.Rename.Note + ")"
+	}
+	return s
+}
+
+// fixLegacyUses rewrites the simple renames of a scene file and returns the uses left
+func fixLegacyUses(file string, scene *GodotScene, uses []LegacyUse) ([]LegacyUse, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	text := splitSceneText(string(content))
+	sections := text.nodeSections()
+	if len(sections) != len(scene.AllNodes) {
+		return nil, fmt.Errorf("node sections do not match parsed nodes (%d != %d)", len(sections), len(scene.AllNodes))
+	}
+	sectionOf := make(map[*GodotNode]*sceneSection)
+	for i, node := range scene.AllNodes {
+		sectionOf[node] = sections[i]
+	}
+
+	var remaining []LegacyUse
+	fixed := 0
+	for _, use := range uses {
+		section := sectionOf[use.Node]
+		if !use.Rename.Simple {
+			remaining = append(remaining, use)
+			continue
+		}
+		switch use.Kind {
+		case "type":
+			typeRe := regexp.MustCompile(`\btype="` + regexp.QuoteMeta(use.Old) + `"`)
+			section.Header = typeRe.ReplaceAllLiteralString(section.Header, `type="`+use.Rename.New+`"`)
+		case "property":
+			if _, exists := section.Property(use.Rename.New); exists {
+				// Both names are set; leave the decision to the user
+				remaining = append(remaining, use)
+				continue
+			}
+			section.RenameProperty(use.Old, use.Rename.New)
+		}
+		fixed++
+	}
+
+	if fixed > 0 {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(file, []byte(text.String()), info.Mode()); err != nil {
+			return nil, err
+		}
+	}
+	return remaining, nil
+}
+
+var migrateCheckCmd = &cobra.Command{
+	Use:   "migrate-check [project dir]",
+	Short: "Report Godot 3 node types and properties left in scenes",
+	Long: `Check every scene for node types and properties that were renamed or removed in Godot 4.
+With --fix, renames that keep the value format are rewritten in place; the others are
+reported with what has to change. Exits non-zero while legacy names remain.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		root := findProjectRoot(dir)
+		project, err := parseConfigFile(resToFS(root, "res://project.godot"))
+		if err != nil {
+			project = &ConfigFile{}
+		}
+		if version := detectProjectVersion(project); version.Known() && version.Major < 4 {
+			fmt.Fprintf(out, "Note: project.godot is still Godot %s\n\n", version)
+		}
+
+		results, err := scanProject(root, dir, ParseOptions{Logger: logger})
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+
+		remaining, failed := 0, 0
+		for _, result := range results {
+			if result.Err != nil {
+				continue
+			}
+			uses := findLegacyUses(result.Scene)
+			if len(uses) == 0 {
+				continue
+			}
+
+			if migrateFix {
+				left, err := fixLegacyUses(resToFS(root, result.File), result.Scene, uses)
+				if err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", result.File, err)
+					failed++
+				} else {
+					if fixed := len(uses) - len(left); fixed > 0 {
+						fmt.Fprintf(out, "%s: fixed %d rename(s)\n", result.File, fixed)
+					}
+					uses = left
+				}
+			}
+
+			for _, use := range uses {
+				fmt.Fprintf(out, "%s:%d:%s: %s\n", result.File, use.Node.Span.StartLine, use.Node.Path, use.describe())
+			}
+			remaining += len(uses)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d file(s) could not be migrated", failed)
+		}
+		if remaining > 0 {
+			return fmt.Errorf("found %d legacy name(s)", remaining)
+		}
+		return nil
+	},
+}
+
+func init() {
+	migrateCheckCmd.Flags().BoolVar(&migrateFix, "fix", false, "Rewrite renames that keep the value format")
+	rootCmd.AddCommand(migrateCheckCmd)
+}
@@ -0,0 +1,92 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateCheck(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"menu.tscn": `[gd_scene load_steps=1 format=2]
+
+[node name="Menu" type="Control"]
+rect_min_size = Vector2( 200, 100 )
+margin_right = 40.0
+custom_colors/font_color = Color( 1, 0, 0, 1 )
+
+[node name="Play" type="ToolButton" parent="."]
+hint_tooltip = "Start"
+rect_rotation = 45.0
+
+[node name="World" type="Spatial" parent="."]
+translation = Vector3( 1, 2, 3 )
+`,
+	})
+
+	file := filepath.Join(root, "menu.tscn")
+	scene, err := ParseTscnFile(file)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var got []string
+	for _, use := range findLegacyUses(scene) {
+		got = append(got, use.Node.Path+": "+use.describe())
+	}
+	expected := []string{
+		"Menu: property custom_colors/font_color -> theme_override_colors/font_color",
+		"Menu: property margin_right -> offset_right",
+		"Menu: property rect_min_size -> custom_minimum_size",
+		"Menu/Play: type ToolButton -> Button (set flat = true)",
+		"Menu/Play: property hint_tooltip -> tooltip_text",
+		"Menu/Play: property rect_rotation -> rotation (degrees became radians)",
+		"Menu/World: type Spatial -> Node3D",
+		"Menu/World: property translation -> position",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Unexpected legacy uses:\n%s", strings.Join(got, "\n"))
+	}
+
+	remaining, err := fixLegacyUses(file, scene, findLegacyUses(scene))
+	if err != nil {
+		t.Fatalf("Fix error: %v", err)
+	}
+	if len(remaining) != 2 || remaining[0].Old != "ToolButton" || remaining[1].Old != "rect_rotation" {
+		t.Errorf("Expected the ToolButton and rect_rotation to remain, got %+v", remaining)
+	}
+
+	content, _ := os.ReadFile(file)
+	for _, line := range []string{
+		"custom_minimum_size = Vector2( 200, 100 )",
+		"theme_override_colors/font_color = Color( 1, 0, 0, 1 )",
+		`[node name="World" type="Node3D" parent="."]`,
+		"position = Vector3( 1, 2, 3 )",
+		`[node name="Play" type="ToolButton" parent="."]`,
+		"rect_rotation = 45.0",
+	} {
+		if !strings.Contains(string(content), line) {
+			t.Errorf("Expected %q in the fixed scene:\n%s", line, content)
+		}
+	}
+}
+
+func TestMigrateCheckReadOnly(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Read-only files are writable by root")
+	}
+	root := writeProjectFiles(t, map[string]string{
+		"world.tscn": "[gd_scene format=2]\n\n[node name=\"World\" type=\"Spatial\"]\ntranslation = Vector3( 1, 2, 3 )\n",
+	})
+	os.Chmod(filepath.Join(root, "world.tscn"), 0444)
+
+	// A scene that cannot be rewritten is reported on stderr and fails the command
+	var stdout, stderr strings.Builder
+	if code := Run([]string{"migrate-check", "--fix", root}, &stdout, &stderr); code == 0 {
+		t.Error("Expected a failure for the read-only scene")
+	}
+	if !strings.Contains(stderr.String(), "Error: res://world.tscn") || !strings.Contains(stderr.String(), "could not be migrated") || strings.Contains(stdout.String(), "Error") {
+		t.Errorf("Expected the error on stderr:\nstdout: %s\nstderr: %s", stdout.String(), stderr.String())
+	}
+}
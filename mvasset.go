@@ -0,0 +1,239 @@
+package gdquery
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Move asset command options
+var mvassetMove = false
+var mvassetDryRun = false
+
+// extResourcePathRe matches the path attribute of an [ext_resource] header
+var extResourcePathRe = regexp.MustCompile(`\bpath="([^"]*)"`)
+
+// assetSidecarExtensions are the files Godot keeps next to an asset and that move with it
+var assetSidecarExtensions = []string{".import", ".uid"}
+
+// rewriteExtResourcePaths points the ext_resources of file referencing oldRes to newRes
+// and returns the number of rewritten references. The uid is kept: it identifies
+// the asset, not its path. The file is only written when write is true.
+func rewriteExtResourcePaths(file, fileRes, oldRes, newRes string, write bool) (int, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+
+	text := splitSceneText(string(content))
+	rewritten := 0
+	for _, section := range text.Sections {
+		if !strings.HasPrefix(section.Header, "[ext_resource") {
+			continue
+		}
+		loc := extResourcePathRe.FindStringSubmatchIndex(section.Header)
+		if loc == nil {
+			continue
+		}
+		// Godot 3 and .escn scenes may use paths relative to the scene
+		if normalizeResPath(fileRes, section.Header[loc[2]:loc[3]]) != oldRes {
+			continue
+		}
+		section.Header = section.Header[:loc[2]] + newRes + section.Header[loc[3]:]
+		rewritten++
+	}
+
+	if write && rewritten > 0 {
+		info, err := os.Stat(file)
+		if err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(file, []byte(text.String()), info.Mode()); err != nil {
+			return 0, err
+		}
+	}
+
+	return rewritten, nil
+}
+
+// importedDirs are where Godot 4 and Godot 3 keep the imported data of assets
+var importedDirs = []string{".godot/imported", ".import"}
+
+// importSourceFileRe matches the source_file line of an .import file
+var importSourceFileRe = regexp.MustCompile(`(?m)^source_file="[^"]*"`)
+
+// importedStem returns the name under which Godot stores the imported data of
+// an asset: its file name and the MD5 of its res:// path
+func importedStem(resPath string) string {
+	sum := md5.Sum([]byte(resPath))
+	return path.Base(resPath) + "-" + hex.EncodeToString(sum[:])
+}
+
+// moveImportFile updates the .import file of an asset moved from oldRes to
+// newRes: source_file names the new path, and path= and dest_files= the
+// imported files, which are renamed in the imported data directory so that
+// Godot does not import the asset again
+func moveImportFile(root, importFile, oldRes, newRes string) error {
+	info, err := os.Stat(importFile)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(importFile)
+	if err != nil {
+		return err
+	}
+	oldStem, newStem := importedStem(oldRes), importedStem(newRes)
+	text := importSourceFileRe.ReplaceAllLiteralString(string(content), `source_file="`+newRes+`"`)
+	text = strings.ReplaceAll(text, "/"+oldStem+".", "/"+newStem+".")
+	if err := os.WriteFile(importFile, []byte(text), info.Mode()); err != nil {
+		return err
+	}
+
+	for _, dir := range importedDirs {
+		dir = filepath.Join(root, filepath.FromSlash(dir))
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			// Imported files, e.g. icon.png-<md5>.ctex, and the .md5 file of the import
+			if name := entry.Name(); strings.HasPrefix(name, oldStem+".") {
+				if err := os.Rename(filepath.Join(dir, name), filepath.Join(dir, newStem+strings.TrimPrefix(name, oldStem))); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// moveAsset renames the asset under root together with its sidecar files,
+// updating the paths in its .import file
+func moveAsset(root, oldRes, newRes string) error {
+	oldPath, newPath := resToFS(root, oldRes), resToFS(root, newRes)
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("destination already exists: %s", newRes)
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return err
+	}
+
+	for _, ext := range assetSidecarExtensions {
+		if _, err := os.Stat(oldPath + ext); err != nil {
+			continue
+		}
+		if err := os.Rename(oldPath+ext, newPath+ext); err != nil {
+			return err
+		}
+		if ext == ".import" {
+			if err := moveImportFile(root, newPath+ext, oldRes, newRes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// toResPath accepts res:// paths and paths relative to the project root
+func toResPath(path string) string {
+	if strings.HasPrefix(path, "res://") {
+		return path
+	}
+	return "res://" + strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+}
+
+var mvassetCmd = &cobra.Command{
+	Use:   "mvasset <old res path> <new res path> [project dir]",
+	Short: "Rewrite ext_resource paths after moving an asset",
+	Long: `Update every ext_resource referencing an asset across all scenes and resources of a project,
+so that moving the asset outside the Godot editor does not break the referencing scenes.
+Resource uids are kept. With --move the asset and its .import/.uid files are moved too: the
+.import file is pointed at the new path and the imported data in .godot/imported is renamed
+after it, so that Godot does not import the asset again.`,
+	Example:      `  gdq mvasset --move res://icon.png res://art/icon.png path/to/project`,
+	Args:         cobra.RangeArgs(2, 3),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		oldRes, newRes := toResPath(args[0]), toResPath(args[1])
+		dir := "."
+		if len(args) > 2 {
+			dir = args[2]
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+		root := findProjectRoot(dir)
+
+		if mvassetMove {
+			if _, err := os.Stat(resToFS(root, oldRes)); os.IsNotExist(err) {
+				return fmt.Errorf("file not found: %s", oldRes)
+			}
+			if _, err := os.Stat(resToFS(root, newRes)); err == nil {
+				return fmt.Errorf("destination already exists: %s", newRes)
+			}
+		}
+
+		files, err := findProjectFiles(root, sceneExtensions)
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+
+		touchedFiles := 0
+		references := 0
+		failed := 0
+		for _, file := range files {
+			count, err := rewriteExtResourcePaths(file, fsToRes(root, file), oldRes, newRes, !mvassetDryRun)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", file, err)
+				failed++
+				continue
+			}
+			if count == 0 {
+				continue
+			}
+			touchedFiles++
+			references += count
+			fmt.Fprintf(out, "%s: %d reference(s)\n", fsToRes(root, file), count)
+		}
+
+		// The asset stays in place while scenes still reference it by its old path
+		if mvassetMove && !mvassetDryRun && failed == 0 {
+			if err := moveAsset(root, oldRes, newRes); err != nil {
+				return fmt.Errorf("move error: %v", err)
+			}
+			fmt.Fprintf(out, "Moved %s -> %s\n", oldRes, newRes)
+		}
+
+		if mvassetDryRun {
+			fmt.Fprintf(out, "\nWould update %d reference(s) in %d file(s) (dry run)\n", references, touchedFiles)
+		} else {
+			fmt.Fprintf(out, "\nUpdated %d reference(s) in %d file(s)\n", references, touchedFiles)
+		}
+
+		if failed > 0 && mvassetMove && !mvassetDryRun {
+			return fmt.Errorf("%d file(s) could not be updated, %s was not moved", failed, oldRes)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d file(s) could not be updated", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	mvassetCmd.Flags().BoolVar(&mvassetMove, "move", false, "Also move the asset and its .import/.uid files")
+	mvassetCmd.Flags().BoolVar(&mvassetDryRun, "dry-run", false, "Show the changes without writing files")
+	rootCmd.AddCommand(mvassetCmd)
+}
@@ -0,0 +1,128 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveAsset(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"icon.png":        "png",
+		"icon.png.import": "[remap]\n\nuid=\"uid://icon\"\n",
+		"main.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Texture2D" uid="uid://icon" path="res://icon.png" id="1_i"]
+[ext_resource type="Texture2D" path="res://icon.png.bak" id="2_b"]
+
+[node name="Main" type="Sprite2D"]
+texture = ExtResource("1_i")
+`,
+		"old/level.escn": `[gd_scene load_steps=2 format=2]
+
+[ext_resource path="../icon.png" type="Texture" id=1]
+
+[node name="Level" type="Spatial"]
+`,
+	})
+
+	count, err := rewriteExtResourcePaths(filepath.Join(root, "main.tscn"), "res://main.tscn", "res://icon.png", "res://art/icon.png", true)
+	if err != nil || count != 1 {
+		t.Fatalf("Expected 1 rewritten reference, got %d (%v)", count, err)
+	}
+	content, _ := os.ReadFile(filepath.Join(root, "main.tscn"))
+	if !strings.Contains(string(content), `[ext_resource type="Texture2D" uid="uid://icon" path="res://art/icon.png" id="1_i"]`) ||
+		!strings.Contains(string(content), `path="res://icon.png.bak"`) {
+		t.Errorf("Unexpected rewritten scene:\n%s", content)
+	}
+
+	// Relative paths are resolved against the referencing scene
+	count, err = rewriteExtResourcePaths(filepath.Join(root, "old", "level.escn"), "res://old/level.escn", "res://icon.png", "res://art/icon.png", false)
+	if err != nil || count != 1 {
+		t.Errorf("Expected 1 relative reference, got %d (%v)", count, err)
+	}
+
+	if err := moveAsset(root, "res://icon.png", "res://art/icon.png"); err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
+	for _, name := range []string{"art/icon.png", "art/icon.png.import"} {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			t.Errorf("Expected %s to exist: %v", name, err)
+		}
+	}
+	if err := moveAsset(root, "res://main.tscn", "res://art/icon.png"); err == nil {
+		t.Error("Expected an error when the destination exists")
+	}
+
+	// A scene that cannot be rewritten is reported on stderr, fails the
+	// command and keeps the asset in place
+	if os.Geteuid() == 0 {
+		t.Skip("Read-only files are writable by root")
+	}
+	locked := filepath.Join(root, "locked.tscn")
+	os.WriteFile(locked, []byte(`[ext_resource type="Texture2D" path="res://art/icon.png" id="1"]`+"\n"), 0444)
+	var stdout, stderr strings.Builder
+	if code := Run([]string{"mvasset", "--move", "res://art/icon.png", "res://icon.png", root}, &stdout, &stderr); code == 0 {
+		t.Error("Expected a failure for the read-only scene")
+	}
+	if !strings.Contains(stderr.String(), "Error: "+locked) || strings.Contains(stdout.String(), "Error") {
+		t.Errorf("Expected the error on stderr:\nstdout: %s\nstderr: %s", stdout.String(), stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, "art", "icon.png")); err != nil {
+		t.Errorf("Expected the asset to stay in place: %v", err)
+	}
+}
+
+func TestMoveAssetImportFile(t *testing.T) {
+	// Godot names imported data after the file name and the MD5 of its res:// path
+	oldStem := "icon.png-487276ed1e3a0c39cad0279d744ee560"
+	if got := importedStem("res://icon.png"); got != oldStem {
+		t.Fatalf("Unexpected imported stem: %s", got)
+	}
+	newStem := importedStem("res://art/icon.png")
+
+	root := writeProjectFiles(t, map[string]string{
+		"icon.png": "png",
+		"icon.png.import": `[remap]
+
+importer="texture"
+type="CompressedTexture2D"
+uid="uid://icon"
+path="res://.godot/imported/` + oldStem + `.ctex"
+
+[deps]
+
+source_file="res://icon.png"
+dest_files=["res://.godot/imported/` + oldStem + `.ctex"]
+`,
+		".godot/imported/" + oldStem + ".ctex": "ctex",
+		".godot/imported/" + oldStem + ".md5":  "source_md5=\"x\"\n",
+	})
+
+	if err := moveAsset(root, "res://icon.png", "res://art/icon.png"); err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(root, "art", "icon.png.import"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`uid="uid://icon"`,
+		`path="res://.godot/imported/` + newStem + `.ctex"`,
+		`source_file="res://art/icon.png"`,
+		`dest_files=["res://.godot/imported/` + newStem + `.ctex"]`,
+	} {
+		if !strings.Contains(string(content), line+"\n") {
+			t.Errorf("Expected %s in the moved .import file:\n%s", line, content)
+		}
+	}
+	if strings.Contains(string(content), oldStem) {
+		t.Errorf("Expected no reference to the old imported data:\n%s", content)
+	}
+	for _, ext := range []string{".ctex", ".md5"} {
+		if _, err := os.Stat(filepath.Join(root, ".godot", "imported", newStem+ext)); err != nil {
+			t.Errorf("Expected the imported %s file to be renamed: %v", ext, err)
+		}
+	}
+}
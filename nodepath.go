@@ -0,0 +1,77 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Node path options
+var relativeTo = ""
+
+// relativeNodePath returns the NodePath leading from node from to node to,
+// as get_node() expects it in a script attached to from
+func relativeNodePath(from, to *GodotNode) string {
+	fromParts := strings.Split(from.Path, "/")
+	toParts := strings.Split(to.Path, "/")
+
+	common := 0
+	for common < len(fromParts) && common < len(toParts) && fromParts[common] == toParts[common] {
+		common++
+	}
+
+	var parts []string
+	for range fromParts[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, toParts[common:]...)
+	if len(parts) == 0 {
+		return "."
+	}
+	return strings.Join(parts, "/")
+}
+
+// findRelativeBase returns the node given by --relative-to
+func findRelativeBase(scene *GodotScene) (*GodotNode, error) {
+	base := findNodeByPath(scene, relativeTo)
+	if base == nil {
+		return nil, fmt.Errorf("node not found: %s", relativeTo)
+	}
+	return base, nil
+}
+
+// setRelativePaths fills the relative_path of JSON nodes from their scene nodes
+func setRelativePaths(scene *GodotScene, nodes []*NodeJSON) error {
+	base, err := findRelativeBase(scene)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		for _, sceneNode := range scene.AllNodes {
+			if sceneNode.Path == node.Path {
+				node.RelativePath = relativeNodePath(base, sceneNode)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// printRelativePaths displays target and its descendants with their NodePath
+// from the --relative-to node and the matching get_node() call
+func printRelativePaths(out io.Writer, scene *GodotScene, target *GodotNode) error {
+	base, err := findRelativeBase(scene)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Relative to %s (%s):\n", base.Path, base.Type)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	target.Walk(func(node *GodotNode, depth int) WalkAction {
+		path := relativeNodePath(base, node)
+		fmt.Fprintf(w, "%s\t%s\tget_node(%q)\n", path, node.Type, path)
+		return WalkContinue
+	})
+	return w.Flush()
+}
@@ -0,0 +1,45 @@
+package gdquery
+
+import "testing"
+
+func TestRelativeNodePath(t *testing.T) {
+	scene := &GodotScene{}
+	for _, node := range []*GodotNode{
+		{Name: "Main"},
+		{Name: "Player", Parent: "."},
+		{Name: "Sprite", Parent: "Player"},
+		{Name: "HUD", Parent: "."},
+		{Name: "Score", Parent: "HUD"},
+	} {
+		node.Properties = map[string]string{}
+		scene.AllNodes = append(scene.AllNodes, node)
+	}
+	buildSceneTree(scene, false, discardLogger)
+
+	tests := []struct {
+		from, to, expected string
+	}{
+		{"Main/Player", "Main/HUD/Score", "../HUD/Score"},
+		{"Main/Player/Sprite", "Main/HUD", "../../HUD"},
+		{"Main", "Main/Player/Sprite", "Player/Sprite"},
+		{"Main/Player/Sprite", "Main", "../.."},
+		{"Main/HUD", "Main/HUD", "."},
+	}
+	for _, test := range tests {
+		from, to := findNodeByPath(scene, test.from), findNodeByPath(scene, test.to)
+		if got := relativeNodePath(from, to); got != test.expected {
+			t.Errorf("%s -> %s: expected %s, got %s", test.from, test.to, test.expected, got)
+		}
+	}
+
+	// JSON nodes get their path from the --relative-to node
+	nodes := []*NodeJSON{{Path: "Main/HUD/Score"}, {Path: "Main/Player"}}
+	relativeTo = "HUD"
+	defer func() { relativeTo = "" }()
+	if err := setRelativePaths(scene, nodes); err != nil {
+		t.Fatalf("Relative path error: %v", err)
+	}
+	if nodes[0].RelativePath != "Score" || nodes[1].RelativePath != "../Player" {
+		t.Errorf("Unexpected relative paths: %s, %s", nodes[0].RelativePath, nodes[1].RelativePath)
+	}
+}
@@ -0,0 +1,342 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Normalize command options
+var normalizeCheck = false
+
+// sectionKindOrder is the order in which Godot writes the kinds of sections
+var sectionKindOrder = map[string]int{
+	"[gd_scene":     0,
+	"[gd_resource":  0,
+	"[ext_resource": 1,
+	"[sub_resource": 2,
+	"[node":         3,
+	"[resource]":    3,
+	"[connection":   4,
+	"[editable":     5,
+}
+
+// sectionKindRank returns the position of a section kind in the file, unknown
+// kinds going last
+func sectionKindRank(section *sceneSection) int {
+	if rank, exists := sectionKindOrder[strings.Fields(section.Header)[0]]; exists {
+		return rank
+	}
+	return len(sectionKindOrder)
+}
+
+// extResourceIDRe splits an ext_resource id into its sequence number and the rest
+var extResourceIDRe = regexp.MustCompile(`^(\d+)(.*)$`)
+
+// extResourceIDLess orders ext_resource ids by their sequence number ("2_a"
+// before "10_b"), then as strings
+func extResourceIDLess(a, b string) bool {
+	ma, mb := extResourceIDRe.FindStringSubmatch(a), extResourceIDRe.FindStringSubmatch(b)
+	if ma != nil && mb != nil {
+		na, _ := strconv.Atoi(ma[1])
+		nb, _ := strconv.Atoi(mb[1])
+		if na != nb {
+			return na < nb
+		}
+		return ma[2] < mb[2]
+	}
+	if (ma != nil) != (mb != nil) {
+		return ma != nil
+	}
+	return a < b
+}
+
+// classPropertyDepth returns how far from Object the class declaring a
+// property is in the hierarchy of class, or -1 when the property is unknown
+func classPropertyDepth(class, key string) int {
+	var chain []*GodotClass
+	for seen := 0; class != "" && seen < 64; seen++ {
+		c := lookupClass(class)
+		if c == nil {
+			break
+		}
+		chain = append(chain, c)
+		class = c.Inherits
+	}
+	for i, c := range chain {
+		if _, exists := c.Defaults[key]; exists {
+			return len(chain) - 1 - i
+		}
+	}
+	return -1
+}
+
+// sectionProperty is a property of a section with its continuation lines
+type sectionProperty struct {
+	Key   string
+	Lines []string
+}
+
+// orderSectionProperties reorders the properties of a section as Godot writes
+// them: the properties of the class, base classes first, then the script and
+// the script variables, then metadata. Properties the class database does not
+// know keep their place after the known one before them.
+func orderSectionProperties(section *sceneSection, class string) {
+	lines := section.Lines
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var leading []string
+	var properties []*sectionProperty
+	for i := 0; i < len(lines); i++ {
+		key, value, ok := splitPropertyLine(lines[i])
+		if !ok || key == "" {
+			if len(properties) == 0 {
+				leading = append(leading, lines[i])
+			} else {
+				last := properties[len(properties)-1]
+				last.Lines = append(last.Lines, lines[i])
+			}
+			continue
+		}
+		property := &sectionProperty{Key: key, Lines: []string{lines[i]}}
+		for !valueComplete(value) && i+1 < len(lines) {
+			i++
+			value += "\n" + lines[i]
+			property.Lines = append(property.Lines, lines[i])
+		}
+		properties = append(properties, property)
+	}
+
+	// Rank by group (class, script, script variables, metadata) and depth
+	type rank struct{ group, depth int }
+	ranks := make(map[*sectionProperty]rank)
+	afterScript := false
+	depth := 0
+	for _, property := range properties {
+		switch {
+		case strings.HasPrefix(property.Key, "metadata/") || property.Key == "__meta__":
+			ranks[property] = rank{group: 3}
+		case property.Key == "script":
+			ranks[property] = rank{group: 1}
+			afterScript = true
+		default:
+			if d := classPropertyDepth(class, property.Key); d >= 0 {
+				depth = d
+				ranks[property] = rank{group: 0, depth: d}
+			} else if afterScript {
+				ranks[property] = rank{group: 2}
+			} else {
+				ranks[property] = rank{group: 0, depth: depth}
+			}
+		}
+	}
+	sort.SliceStable(properties, func(i, j int) bool {
+		a, b := ranks[properties[i]], ranks[properties[j]]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		return a.depth < b.depth
+	})
+
+	ordered := leading
+	for _, property := range properties {
+		ordered = append(ordered, property.Lines...)
+	}
+	section.Lines = ordered
+}
+
+// normalizeSceneText rewrites a text scene or resource in the order Godot saves
+// it: ext_resources sorted by id, sub_resources before the nodes, connections
+// and editable paths last, and properties in class order, formatted as gdq fmt
+// does. It returns the normalized content and what changed.
+func normalizeSceneText(content string) (string, []string, error) {
+	formatted, issues := formatSceneText(content)
+	for _, issue := range issues {
+		if issue.Malformed {
+			return "", nil, fmt.Errorf("line %d: %s", issue.Line, issue.Message)
+		}
+	}
+	var changes []string
+	if len(issues) > 0 {
+		changes = append(changes, fmt.Sprintf("%d formatting fix(es)", len(issues)))
+	}
+
+	text := splitSceneText(formatted)
+	sections := append([]*sceneSection{}, text.Sections...)
+	sort.SliceStable(sections, func(i, j int) bool {
+		ri, rj := sectionKindRank(sections[i]), sectionKindRank(sections[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if ri == sectionKindOrder["[ext_resource"] {
+			idI, _ := headerAttr(sections[i].Header, "id")
+			idJ, _ := headerAttr(sections[j].Header, "id")
+			return extResourceIDLess(idI, idJ)
+		}
+		return false
+	})
+
+	movedKinds, sortedExt := false, false
+	var extBefore, extAfter []*sceneSection
+	for i, section := range sections {
+		if sectionKindRank(section) != sectionKindRank(text.Sections[i]) {
+			movedKinds = true
+		}
+		if sectionKindRank(section) == sectionKindOrder["[ext_resource"] {
+			extAfter = append(extAfter, section)
+		}
+		if sectionKindRank(text.Sections[i]) == sectionKindOrder["[ext_resource"] {
+			extBefore = append(extBefore, text.Sections[i])
+		}
+	}
+	for i := range extAfter {
+		if extAfter[i] != extBefore[i] {
+			sortedExt = true
+		}
+	}
+	if movedKinds {
+		changes = append(changes, "sections moved into Godot's order")
+	}
+	if sortedExt {
+		changes = append(changes, "ext_resources sorted by id")
+	}
+
+	for _, section := range sections {
+		kind := strings.Fields(section.Header)[0]
+		if kind != "[node" && kind != "[sub_resource" && kind != "[resource]" {
+			continue
+		}
+		class, _ := headerAttr(section.Header, "type")
+		if kind == "[resource]" {
+			if header := text.Sections[0]; strings.HasPrefix(header.Header, "[gd_resource") {
+				class, _ = headerAttr(header.Header, "type")
+			}
+		}
+		before := strings.Join(section.Lines, "\n")
+		orderSectionProperties(section, class)
+		if strings.TrimRight(before, "\n") != strings.Join(section.Lines, "\n") {
+			name, exists := headerAttr(section.Header, "name")
+			if !exists {
+				name, _ = headerAttr(section.Header, "id")
+			}
+			changes = append(changes, fmt.Sprintf("properties of %s %s reordered", strings.TrimPrefix(kind, "["), name))
+		}
+	}
+
+	text.Sections = sections
+	for len(text.Preamble) > 0 && strings.TrimSpace(text.Preamble[len(text.Preamble)-1]) == "" {
+		text.Preamble = text.Preamble[:len(text.Preamble)-1]
+	}
+	var normalized strings.Builder
+	for _, line := range text.Preamble {
+		normalized.WriteString(line + "\n")
+	}
+	if len(text.Preamble) > 0 && len(sections) > 0 {
+		normalized.WriteString("\n")
+	}
+	normalized.WriteString(formatSceneSections(sections))
+	return normalized.String(), changes, nil
+}
+
+var normalizeCmd = &cobra.Command{
+	Use:   "normalize [--check] <file or dir> [more files or dirs...]",
+	Short: "Rewrite scenes in the order the Godot editor saves them",
+	Long: `Rewrite tool-generated .tscn, .escn and .tres files the way the Godot editor saves them, so the
+first save in the editor does not churn the file: ext_resources sorted by id, sub_resources
+before the nodes, connections and editable paths after them, and the properties of each node
+and sub_resource in class order (base class properties first, then the script, the script
+variables and metadata). Properties unknown to the class database (see --class-db) keep their
+place. The file is also formatted as gdq fmt does.
+
+With --check nothing is written: the files that are not normalized are listed with what would
+change, and the command exits non-zero.`,
+	Example: `  gdq normalize generated/level_1.tscn
+  gdq normalize --check generated/`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		var files []string
+		for _, arg := range args {
+			info, err := os.Stat(arg)
+			if err != nil {
+				return fmt.Errorf("file not found: %s", arg)
+			}
+			if !info.IsDir() {
+				files = append(files, arg)
+				continue
+			}
+			matches, err := findProjectFiles(arg, sceneExtensions)
+			if err != nil {
+				return fmt.Errorf("scan error: %v", err)
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no scenes found")
+		}
+
+		changed, written, failed := 0, 0, 0
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", file, err)
+				failed++
+				continue
+			}
+			normalized, changes, err := normalizeSceneText(string(content))
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", file, err)
+				failed++
+				continue
+			}
+			if normalized == string(content) {
+				continue
+			}
+
+			changed++
+			fmt.Fprintf(out, "%s: %s\n", file, strings.Join(changes, ", "))
+			if normalizeCheck {
+				continue
+			}
+			info, err := os.Stat(file)
+			if err == nil {
+				err = os.WriteFile(file, []byte(normalized), info.Mode())
+			}
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", file, err)
+				failed++
+				continue
+			}
+			written++
+		}
+
+		if normalizeCheck {
+			fmt.Fprintf(out, "\n%d of %d file(s) need normalizing\n", changed, len(files))
+			if failed > 0 {
+				return fmt.Errorf("%d file(s) could not be checked", failed)
+			}
+			if changed > 0 {
+				return fmt.Errorf("%d file(s) not normalized", changed)
+			}
+			return nil
+		}
+		fmt.Fprintf(out, "\nNormalized %d of %d file(s)\n", written, len(files))
+		if failed > 0 {
+			return fmt.Errorf("%d file(s) could not be normalized", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	normalizeCmd.Flags().BoolVar(&normalizeCheck, "check", false, "List the files that are not normalized without writing them, exit non-zero when any is found")
+	rootCmd.AddCommand(normalizeCmd)
+}
@@ -0,0 +1,98 @@
+package gdquery
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSceneText(t *testing.T) {
+	content := `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Texture2D" path="res://b.png" id="10_b"]
+[ext_resource type="Script" path="res://p.gd" id="2_a"]
+
+[node name="Player" type="CharacterBody2D"]
+script = ExtResource("2_a")
+metadata/_edit_group_ = true
+speed = 3
+items = [
+1, 2
+]
+position = Vector2(1, 2)
+velocity = Vector2(0, 1)
+
+[sub_resource type="RectangleShape2D" id="RectangleShape2D_x"]
+size = Vector2(4, 4)
+
+[connection signal="ready" from="." to="." method="_on_ready"]
+
+[node name="Sprite" type="Sprite2D" parent="."]
+visible = false
+position = Vector2(1, 1)
+modulate = Color(1, 0, 0, 1)
+`
+	expected := `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Script" path="res://p.gd" id="2_a"]
+[ext_resource type="Texture2D" path="res://b.png" id="10_b"]
+
+[sub_resource type="RectangleShape2D" id="RectangleShape2D_x"]
+size = Vector2(4, 4)
+
+[node name="Player" type="CharacterBody2D"]
+position = Vector2(1, 2)
+velocity = Vector2(0, 1)
+script = ExtResource("2_a")
+speed = 3
+items = [
+1, 2
+]
+metadata/_edit_group_ = true
+
+[node name="Sprite" type="Sprite2D" parent="."]
+visible = false
+modulate = Color(1, 0, 0, 1)
+position = Vector2(1, 1)
+
+[connection signal="ready" from="." to="." method="_on_ready"]
+`
+	normalized, changes, err := normalizeSceneText(content)
+	if err != nil {
+		t.Fatalf("Normalize error: %v", err)
+	}
+	if normalized != expected {
+		t.Errorf("Unexpected normalized scene:\n%s", normalized)
+	}
+	if got := strings.Join(changes, ", "); got != "sections moved into Godot's order, ext_resources sorted by id, properties of node Player reordered, properties of node Sprite reordered" {
+		t.Errorf("Unexpected changes: %s", got)
+	}
+
+	again, changes, err := normalizeSceneText(normalized)
+	if err != nil || again != normalized || len(changes) != 0 {
+		t.Errorf("Expected a normalized scene to stay the same, got %v:\n%s", changes, again)
+	}
+
+	if _, _, err := normalizeSceneText("[gd_scene format=3]\n\n[node name=\"A\" type=\"Node\"\n"); err == nil {
+		t.Error("Expected an error for a malformed header")
+	}
+}
+
+func TestNormalizeCommandFailures(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn":   "[gd_scene format=3]\n\n[node name=\"Main\" type=\"Node\"]\n",
+		"broken.tscn": "[gd_scene format=3]\n\n[node name=\"A\" type=\"Node\"\n",
+	})
+	broken := filepath.Join(root, "broken.tscn")
+
+	// A file that cannot be normalized is reported on stderr and fails both modes
+	for _, args := range [][]string{{"normalize", "--check", root}, {"normalize", root}} {
+		var stdout, stderr strings.Builder
+		if code := Run(args, &stdout, &stderr); code == 0 {
+			t.Errorf("%v: expected a failure for the malformed scene", args)
+		}
+		if !strings.Contains(stderr.String(), "Error: "+broken) || strings.Contains(stdout.String(), "Error") {
+			t.Errorf("%v: expected the error on stderr:\nstdout: %s\nstderr: %s", args, stdout.String(), stderr.String())
+		}
+	}
+}
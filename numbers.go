@@ -0,0 +1,103 @@
+package gdquery
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// isIdentByte reports whether c can be part of an identifier
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isDigit reports whether c is a decimal digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// numberSpans returns the [start, end) offsets of the numeric literals of a
+// variant value: integers and decimals with a sign, leading or trailing digits
+// and an exponent (-1.5e-05, .5, 3., 2E+20), long precision values, and the
+// inf, inf_neg and nan constants Godot writes for non-finite floats. Digits of
+// identifiers (Vector2, Color8) and the contents of strings are not numbers.
+// Parsing never depends on the locale: the decimal separator is always a dot.
+func numberSpans(value string) [][2]int {
+	var spans [][2]int
+	// signStart returns start, or the offset of the sign before it
+	signStart := func(start int) int {
+		if start > 0 && (value[start-1] == '-' || value[start-1] == '+') &&
+			(start == 1 || !isIdentByte(value[start-2]) && value[start-2] != '.') {
+			return start - 1
+		}
+		return start
+	}
+
+	for i := 0; i < len(value); {
+		c := value[i]
+		switch {
+		case c == '"':
+			// Skip strings, honoring escaped quotes
+			for i++; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		case isIdentByte(c) && !isDigit(c):
+			start := i
+			for i < len(value) && isIdentByte(value[i]) {
+				i++
+			}
+			switch value[start:i] {
+			case "inf", "inf_neg", "nan":
+				spans = append(spans, [2]int{signStart(start), i})
+			}
+		case isDigit(c) || c == '.' && i+1 < len(value) && isDigit(value[i+1]):
+			start := i
+			for i < len(value) && isDigit(value[i]) {
+				i++
+			}
+			if i < len(value) && value[i] == '.' {
+				for i++; i < len(value) && isDigit(value[i]); i++ {
+				}
+			}
+			if i < len(value) && (value[i] == 'e' || value[i] == 'E') {
+				j := i + 1
+				if j < len(value) && (value[j] == '-' || value[j] == '+') {
+					j++
+				}
+				if j < len(value) && isDigit(value[j]) {
+					for i = j; i < len(value) && isDigit(value[i]); i++ {
+					}
+				}
+			}
+			spans = append(spans, [2]int{signStart(start), i})
+		default:
+			i++
+		}
+	}
+	return spans
+}
+
+// parseVariantFloat parses a number as Godot writes it, including inf,
+// inf_neg and nan, ignoring surrounding spaces
+func parseVariantFloat(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	switch strings.TrimPrefix(value, "+") {
+	case "inf_neg", "-inf":
+		return math.Inf(-1), nil
+	case "-inf_neg":
+		return math.Inf(1), nil
+	case "-nan":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// isVariantNumber reports whether value is a single number
+func isVariantNumber(value string) bool {
+	value = strings.TrimSpace(value)
+	spans := numberSpans(value)
+	return len(spans) == 1 && spans[0] == [2]int{0, len(value)}
+}
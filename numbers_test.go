@@ -0,0 +1,63 @@
+package gdquery
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseNumberList(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []float64
+	}{
+		// Values written by Godot and by exporters (Blender, LDtk, Tiled importers)
+		{"Vector2(-1.5, 2)", []float64{-1.5, 2}},
+		{"Transform3D(0.999999, -1.74846e-07, 0, 1.74846e-07, 0.999999, 0, 0, 0, 1, 1.5, 2.5e+20, -3)",
+			[]float64{0.999999, -1.74846e-07, 0, 1.74846e-07, 0.999999, 0, 0, 0, 1, 1.5, 2.5e+20, -3}},
+		{"Color(0.30000001192092896, .5, 1., 1)", []float64{0.30000001192092896, 0.5, 1, 1}},
+		{"Vector3(-1.2E-5, +4, 1e3)", []float64{-1.2e-5, 4, 1000}},
+		{"PackedVector2Array(1, 2, 3, 4)", []float64{1, 2, 3, 4}},
+		{"[Vector2(1, 2), Vector2(3, 4)]", []float64{1, 2, 3, 4}},
+		{"Vector2(inf, inf_neg)", []float64{math.Inf(1), math.Inf(-1)}},
+		{"Vector2(-inf, 0)", []float64{math.Inf(-1), 0}},
+		{`NodePath("Sprite2D/Area3D")`, nil},
+		{`Rect2i(0, 0, 2048, 1024)`, []float64{0, 0, 2048, 1024}},
+	}
+	for _, test := range tests {
+		got := parseNumberList(test.value)
+		if len(got) != len(test.expected) {
+			t.Errorf("parseNumberList(%q) = %v, expected %v", test.value, got, test.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("parseNumberList(%q) = %v, expected %v", test.value, got, test.expected)
+				break
+			}
+		}
+	}
+
+	if numbers := parseNumberList("Vector2(nan, 1)"); len(numbers) != 2 || !math.IsNaN(numbers[0]) {
+		t.Errorf("Expected nan to parse, got: %v", numbers)
+	}
+}
+
+func TestNormalizeVariant(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"Vector2(1.0, 0)", "Vector2(1, 0)"},
+		{"1e-05", "0.00001"},
+		{"Color(1, 1, 1, 1.000000)", "Color(1,1,1,1)"},
+		{"Vector2(inf_neg, 0)", "Vector2(-inf, 0.0)"},
+	}
+	for _, test := range tests {
+		if normalizeVariant(test.a) != normalizeVariant(test.b) {
+			t.Errorf("Expected %q and %q to be equal: %q != %q", test.a, test.b, normalizeVariant(test.a), normalizeVariant(test.b))
+		}
+	}
+	if normalizeVariant(`"v1.0"`) == normalizeVariant(`"v1"`) {
+		t.Error("Numbers inside strings should be kept as written")
+	}
+	if got := normalizeVariant("Vector2(1.50, 2)"); got != "Vector2(1.5,2)" {
+		t.Errorf("Unexpected normalized value: %s", got)
+	}
+}
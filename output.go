@@ -0,0 +1,248 @@
+package gdquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Output options
+var outputFormat = "text"
+var outputFile = ""
+
+// openedOutputFile is the --out file standing in for stdout, or nil
+var openedOutputFile *os.File
+
+// redirectOutput sends the output of the commands to the --out file
+func redirectOutput(cmd *cobra.Command) error {
+	if outputFile == "" || openedOutputFile != nil {
+		return nil
+	}
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("output file error: %v", err)
+	}
+	openedOutputFile = file
+	cmd.Root().SetOut(file)
+	return nil
+}
+
+// closeOutput closes the --out file, reporting write errors that were deferred until close
+func closeOutput() error {
+	if openedOutputFile == nil {
+		return nil
+	}
+	err := openedOutputFile.Close()
+	openedOutputFile = nil
+	return err
+}
+
+// SpanJSON is the JSON form of a SourceSpan
+type SpanJSON struct {
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+	StartByte int `json:"start_byte"`
+	EndByte   int `json:"end_byte"`
+	Bytes     int `json:"bytes"`
+}
+
+// NodeJSON is the JSON form of a GodotNode
+type NodeJSON struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+	Path string `json:"path"`
+	// RelativePath is the NodePath from the --relative-to node
+	RelativePath string            `json:"relative_path,omitempty"`
+	Parent       string            `json:"parent,omitempty"`
+	Owner        string            `json:"owner,omitempty"`
+	Script       string            `json:"script,omitempty"`
+	ScriptClass  string            `json:"script_class,omitempty"`
+	Instance     string            `json:"instance,omitempty"`
+	InstanceOf   string            `json:"instance_of,omitempty"`
+	InstanceType string            `json:"instance_type,omitempty"`
+	Origin       string            `json:"origin,omitempty"` // scene file defining the node, with --expand-instances
+	Description  string            `json:"description,omitempty"`
+	Properties   map[string]string `json:"properties,omitempty"`
+	Span         SpanJSON          `json:"span"`
+	// SubtreeBytes is the size of the node section plus the sections of all its descendants
+	SubtreeBytes int `json:"subtree_bytes"`
+}
+
+// ResourceJSON is the JSON form of a GodotResource
+type ResourceJSON struct {
+	ID   string   `json:"id"`
+	Type string   `json:"type,omitempty"`
+	Path string   `json:"path,omitempty"`
+	UID  string   `json:"uid,omitempty"`
+	Span SpanJSON `json:"span"`
+}
+
+// SceneJSON is the JSON form of a parsed scene
+type SceneJSON struct {
+	File         string      `json:"file"`
+	UID          string      `json:"uid,omitempty"`
+	GodotVersion string      `json:"godot_version,omitempty"`
+	Format       int         `json:"format,omitempty"`
+	LoadSteps    int         `json:"load_steps,omitempty"`
+	Nodes        []*NodeJSON `json:"nodes"`
+	// DetachedRoots lists the paths of the roots of separate trees, with --forest
+	DetachedRoots []string        `json:"detached_roots,omitempty"`
+	ExtResources  []*ResourceJSON `json:"ext_resources"`
+	SubResources  []*ResourceJSON `json:"sub_resources"`
+	Stats         *SubtreeStats   `json:"stats,omitempty"` // queried subtree, with --stat
+	Error         string          `json:"error,omitempty"`
+}
+
+// NodeLineJSON is a node emitted on its own line in jsonl output
+type NodeLineJSON struct {
+	File string `json:"file"`
+	*NodeJSON
+}
+
+// ScanResultJSON is the JSON form of a SceneScanResult
+type ScanResultJSON struct {
+	File         string  `json:"file"`
+	GodotVersion string  `json:"godot_version,omitempty"`
+	Nodes        int     `json:"nodes"`
+	MaxDepth     int     `json:"max_depth"`
+	DeepestPath  string  `json:"deepest_path"`
+	AvgChildren  float64 `json:"avg_children"`
+	MaxChildren  int     `json:"max_children"`
+	WidestLevel  int     `json:"widest_level"`
+	WidestCount  int     `json:"widest_count"`
+	LongestPath  string  `json:"longest_path"`
+	Error        string  `json:"error,omitempty"`
+}
+
+// validateOutputFormat checks the --output flag against the formats a command supports
+func validateOutputFormat(formats ...string) error {
+	for _, format := range formats {
+		if outputFormat == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output format: %s (expected %s)", outputFormat, strings.Join(formats, ", "))
+}
+
+// spanToJSON converts a span to its JSON form
+func spanToJSON(span SourceSpan) SpanJSON {
+	return SpanJSON{
+		StartLine: span.StartLine,
+		EndLine:   span.EndLine,
+		StartByte: span.StartByte,
+		EndByte:   span.EndByte,
+		Bytes:     span.Size(),
+	}
+}
+
+// subtreeBytes returns the size of the sections of node and all its descendants
+func subtreeBytes(node *GodotNode) int {
+	size := node.Span.Size()
+	for _, child := range node.Children {
+		size += subtreeBytes(child)
+	}
+	return size
+}
+
+// nodeToJSON converts a node to its JSON form
+func nodeToJSON(node *GodotNode) *NodeJSON {
+	return &NodeJSON{
+		Name:         node.Name,
+		Type:         node.Type,
+		Path:         node.Path,
+		Parent:       node.Parent,
+		Owner:        node.Owner,
+		Script:       node.Script,
+		ScriptClass:  node.ScriptClass,
+		Instance:     node.Instance,
+		InstanceOf:   node.InstanceOf,
+		InstanceType: node.InstanceType,
+		Origin:       node.Origin,
+		Description:  nodeDescription(node),
+		Properties:   node.Properties,
+		Span:         spanToJSON(node.Span),
+		SubtreeBytes: subtreeBytes(node),
+	}
+}
+
+// resourcesToJSON converts resources to their JSON form in file order
+func resourcesToJSON(resources map[string]*GodotResource) []*ResourceJSON {
+	list := make([]*ResourceJSON, 0, len(resources))
+	for _, resource := range resources {
+		list = append(list, &ResourceJSON{
+			ID:   resource.ID,
+			Type: resource.Type,
+			Path: resource.Path,
+			UID:  resource.UID,
+			Span: spanToJSON(resource.Span),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Span.StartLine < list[j].Span.StartLine
+	})
+	return list
+}
+
+// sceneToJSON converts a scene to its JSON form. Only the given nodes are
+// included, or all nodes in file order when nodes is nil.
+func sceneToJSON(scene *GodotScene, nodes []*GodotNode) *SceneJSON {
+	if nodes == nil {
+		nodes = scene.AllNodes
+	}
+
+	result := &SceneJSON{
+		File:         scene.File,
+		UID:          scene.UID,
+		GodotVersion: scene.Version.String(),
+		Format:       scene.Format,
+		LoadSteps:    scene.LoadSteps,
+		Nodes:        make([]*NodeJSON, 0, len(nodes)),
+		ExtResources: resourcesToJSON(scene.ExtResources),
+		SubResources: resourcesToJSON(scene.SubResources),
+	}
+	for _, node := range nodes {
+		result.Nodes = append(result.Nodes, nodeToJSON(node))
+	}
+	for _, root := range scene.DetachedRoots {
+		result.DetachedRoots = append(result.DetachedRoots, root.Path)
+	}
+	return result
+}
+
+// scanResultToJSON converts a scan result to its JSON form
+func scanResultToJSON(result *SceneScanResult) *ScanResultJSON {
+	if result.Err != nil {
+		return &ScanResultJSON{File: result.File, Error: result.Err.Error()}
+	}
+	m := result.Metrics
+	return &ScanResultJSON{
+		File:         result.File,
+		GodotVersion: result.Scene.Version.String(),
+		Nodes:        m.NodeCount,
+		MaxDepth:     m.MaxDepth,
+		DeepestPath:  m.DeepestPath,
+		AvgChildren:  m.AvgChildren(),
+		MaxChildren:  m.MaxChildren,
+		WidestLevel:  m.WidestLevel,
+		WidestCount:  m.WidestCount,
+		LongestPath:  m.LongestPath,
+	}
+}
+
+// writeJSONLine writes a value as a single line of JSON, so streaming
+// consumers can process it as soon as it is written
+func writeJSONLine(out io.Writer, value any) error {
+	return json.NewEncoder(out).Encode(value)
+}
+
+// writeJSON writes a value as indented JSON
+func writeJSON(out io.Writer, value any) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,565 @@
+package gdquery
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gdquery/query"
+	"github.com/spf13/cobra"
+)
+
+// pckMagic starts a Godot .pck file ("GDPC"), and ends an executable with an embedded pack
+const pckMagic = 0x43504447
+
+// pckMaxPathLength bounds the length of a path in the directory of a .pck, so
+// that corrupt packs cannot request huge allocations
+const pckMaxPathLength = 4096
+
+// pckMinEntrySize is the size of a directory entry with an empty path: path
+// length, offset, size and MD5 (the flags of format 2 and later come on top)
+const pckMinEntrySize = 4 + 8 + 8 + 16
+
+// Directory and file flags of .pck format 2 and later
+const (
+	pckDirEncrypted  = 1 << 0
+	pckRelFileBase   = 1 << 1
+	pckFileEncrypted = 1 << 0
+)
+
+// PackFile is a file stored in an export package
+type PackFile struct {
+	Path   string `json:"path"` // res:// path
+	Size   int64  `json:"size"`
+	offset int64  // .pck only
+	zip    *zip.File
+}
+
+// ExportPack is a Godot export package (.pck, an executable with an embedded pack, or .zip)
+type ExportPack struct {
+	Path    string
+	Version string // Godot version that exported a .pck, e.g. "4.2.1"
+	Files   []*PackFile
+	byPath  map[string]*PackFile
+	file    *os.File
+	zip     *zip.ReadCloser
+}
+
+// openExportPack reads the file directory of an export package
+func openExportPack(path string) (*ExportPack, error) {
+	pack := &ExportPack{Path: path, byPath: make(map[string]*PackFile)}
+
+	if hasExtension(path, []string{".zip"}) {
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		pack.zip = reader
+		for _, f := range reader.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			pack.add(&PackFile{Path: f.Name, Size: int64(f.UncompressedSize64), zip: f})
+		}
+		return pack, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	pack.file = f
+	if err := pack.readPckDirectory(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return pack, nil
+}
+
+// add registers a file under its res:// path
+func (p *ExportPack) add(f *PackFile) {
+	f.Path = "res://" + strings.TrimPrefix(strings.TrimPrefix(f.Path, "res://"), "/")
+	p.Files = append(p.Files, f)
+	p.byPath[f.Path] = f
+}
+
+// readPckDirectory reads the header and file directory of a .pck (format 1 for
+// Godot 3, 2 and 3 for Godot 4), standalone or embedded at the end of an executable
+func (p *ExportPack) readPckDirectory() error {
+	info, err := p.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	var start int64
+	var magic uint32
+	if err := binary.Read(io.NewSectionReader(p.file, 0, 4), binary.LittleEndian, &magic); err != nil || magic != pckMagic {
+		// Embedded packs end with their size and the magic
+		var trailer struct {
+			Size  uint64
+			Magic uint32
+		}
+		if info.Size() < 12 {
+			return fmt.Errorf("not a Godot pack")
+		}
+		if err := binary.Read(io.NewSectionReader(p.file, info.Size()-12, 12), binary.LittleEndian, &trailer); err != nil || trailer.Magic != pckMagic {
+			return fmt.Errorf("not a Godot pack")
+		}
+		start = info.Size() - 12 - int64(trailer.Size)
+	}
+
+	r := io.NewSectionReader(p.file, start, info.Size()-start)
+	read := func(v any) error {
+		return binary.Read(r, binary.LittleEndian, v)
+	}
+
+	var header struct {
+		Magic, Format, Major, Minor, Patch uint32
+	}
+	if err := read(&header); err != nil || header.Magic != pckMagic {
+		return fmt.Errorf("not a Godot pack")
+	}
+	if header.Format < 1 || header.Format > 3 {
+		return fmt.Errorf("unsupported pack format %d", header.Format)
+	}
+	p.Version = fmt.Sprintf("%d.%d.%d", header.Major, header.Minor, header.Patch)
+
+	var flags uint32
+	var fileBase, dirOffset uint64
+	if header.Format >= 2 {
+		if err := read(&flags); err != nil {
+			return err
+		}
+		if err := read(&fileBase); err != nil {
+			return err
+		}
+		if flags&pckDirEncrypted != 0 {
+			return fmt.Errorf("encrypted packs are not supported")
+		}
+		if flags&pckRelFileBase != 0 {
+			fileBase += uint64(start)
+		}
+	}
+	if header.Format >= 3 {
+		// The directory moved to the end of the pack
+		if err := read(&dirOffset); err != nil {
+			return err
+		}
+	}
+	if _, err := r.Seek(16*4, io.SeekCurrent); err != nil {
+		return err
+	}
+	if header.Format >= 3 {
+		if _, err := r.Seek(int64(dirOffset), io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	// remaining returns the number of bytes left after the read position
+	remaining := func() int64 {
+		position, _ := r.Seek(0, io.SeekCurrent)
+		return r.Size() - position
+	}
+
+	var count uint32
+	if err := read(&count); err != nil {
+		return err
+	}
+	if int64(count) > remaining()/pckMinEntrySize {
+		return fmt.Errorf("corrupt pack: %d files do not fit in the directory", count)
+	}
+	for i := uint32(0); i < count; i++ {
+		var length uint32
+		if err := read(&length); err != nil {
+			return err
+		}
+		if length > pckMaxPathLength || int64(length) > remaining() {
+			return fmt.Errorf("corrupt pack: path of %d bytes in file entry %d", length, i)
+		}
+		name := make([]byte, length)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return err
+		}
+		var entry struct {
+			Offset, Size uint64
+			MD5          [16]byte
+		}
+		if err := read(&entry); err != nil {
+			return err
+		}
+		var fileFlags uint32
+		if header.Format >= 2 {
+			if err := read(&fileFlags); err != nil {
+				return err
+			}
+		}
+		if fileFlags&pckFileEncrypted != 0 {
+			continue
+		}
+
+		offset := int64(entry.Offset) + start
+		if header.Format >= 2 {
+			offset = int64(entry.Offset + fileBase)
+		}
+		// Paths are padded with zeros to a multiple of 4 bytes
+		path := string(bytes.TrimRight(name, "\x00"))
+		if entry.Size > uint64(info.Size()) || offset < 0 || offset > info.Size()-int64(entry.Size) {
+			return fmt.Errorf("corrupt pack: %s lies outside of the file", path)
+		}
+		p.add(&PackFile{Path: path, Size: int64(entry.Size), offset: offset})
+	}
+	return nil
+}
+
+// Close releases the package file
+func (p *ExportPack) Close() error {
+	if p.zip != nil {
+		return p.zip.Close()
+	}
+	return p.file.Close()
+}
+
+// ReadFile returns the content of a file of the package
+func (p *ExportPack) ReadFile(resPath string) ([]byte, error) {
+	f, exists := p.byPath[resPath]
+	if !exists {
+		return nil, fmt.Errorf("not in pack: %s", resPath)
+	}
+	if f.zip != nil {
+		r, err := f.zip.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+	content := make([]byte, f.Size)
+	if _, err := p.file.ReadAt(content, f.offset); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// remapTarget returns the file Godot loads for resPath: exports convert text
+// scenes to binary and import assets, leaving a .remap (or .import) file in place
+// of the original. Returns resPath when it is not remapped.
+func (p *ExportPack) remapTarget(resPath string) string {
+	for _, ext := range []string{".remap", ".import"} {
+		content, err := p.ReadFile(resPath + ext)
+		if err != nil {
+			continue
+		}
+		if path, exists := parseConfigText(string(content)).Get("remap", "path"); exists {
+			return unquoteValue(path)
+		}
+	}
+	return resPath
+}
+
+// BinaryResource is the header of a binary resource (.scn, .res)
+type BinaryResource struct {
+	Type          string              `json:"type"`
+	EngineVersion string              `json:"engine_version"` // major.minor of the Godot that saved it
+	Format        uint32              `json:"format"`
+	ExtResources  []BinaryExtResource `json:"ext_resources"`
+	SubResources  int                 `json:"sub_resources"`
+	internal      []binaryInternalResource
+	names         []string
+	bigEndian     bool
+	real64        bool
+	namedSceneIDs bool
+}
+
+// BinaryExtResource is a dependency listed in a binary resource header
+type BinaryExtResource struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// binaryInternalResource is a sub_resource, or the main resource, of a binary resource
+type binaryInternalResource struct {
+	Path   string // "local://<id>"
+	Offset uint64
+}
+
+// Binary resource flags (Godot 4)
+const (
+	binaryFlagNamedSceneIDs = 1 << 0
+	binaryFlagUIDs          = 1 << 1
+	binaryFlagScriptClass   = 1 << 3
+)
+
+// reader returns a reader of the values of the resource
+func (res *BinaryResource) reader(data []byte) *binaryReader {
+	b := &binaryReader{r: bytes.NewReader(data), order: binary.LittleEndian, real64: res.real64, names: res.names, resource: res}
+	if res.bigEndian {
+		b.order = binary.BigEndian
+	}
+	return b
+}
+
+// parseBinaryResource reads the header of a binary resource: its type, the
+// ext_resources it depends on and where its sub_resources are stored. See
+// binarySceneText for the node tree of binary scenes.
+func parseBinaryResource(data []byte) (*BinaryResource, error) {
+	if bytes.HasPrefix(data, []byte("RSCC")) {
+		return nil, fmt.Errorf("compressed binary resources are not supported")
+	}
+	if !bytes.HasPrefix(data, []byte("RSRC")) {
+		return nil, fmt.Errorf("not a binary resource")
+	}
+
+	resource := &BinaryResource{}
+	b := resource.reader(data)
+	b.seek(4)
+	if bigEndian := b.u32(); bigEndian != 0 {
+		resource.bigEndian = true
+		b.order = binary.BigEndian
+	}
+	resource.real64 = b.u32() != 0
+	b.real64 = resource.real64
+	major, minor := b.u32(), b.u32()
+	resource.EngineVersion = fmt.Sprintf("%d.%d", major, minor)
+	resource.Format = b.u32()
+	resource.Type = b.str()
+	b.u64() // import metadata offset
+
+	// Godot 4 stores flags, the uid and the script class before 11 reserved
+	// fields; Godot 3 has 14 reserved fields in the same space
+	uids := false
+	reserved := 14
+	if major >= 4 {
+		flags := b.u32()
+		b.u64()
+		uids = flags&binaryFlagUIDs != 0
+		resource.namedSceneIDs = flags&binaryFlagNamedSceneIDs != 0
+		if flags&binaryFlagScriptClass != 0 {
+			b.str()
+		}
+		reserved = 11
+	}
+	for i := 0; i < reserved; i++ {
+		b.u32()
+	}
+
+	for i, count := 0, b.count(4); i < count && b.failed == nil; i++ {
+		resource.names = append(resource.names, b.str()) // property names
+	}
+	b.names = resource.names
+	for i, count := 0, b.count(8); i < count && b.failed == nil; i++ {
+		ext := BinaryExtResource{Type: b.str(), Path: b.str()}
+		if uids {
+			b.u64()
+		}
+		resource.ExtResources = append(resource.ExtResources, ext)
+	}
+	// Internal resources are the sub_resources followed by the main resource
+	for i, count := 0, b.count(12); i < count && b.failed == nil; i++ {
+		resource.internal = append(resource.internal, binaryInternalResource{Path: b.str(), Offset: b.u64()})
+	}
+	if len(resource.internal) > 0 {
+		resource.SubResources = len(resource.internal) - 1
+	}
+
+	if b.failed != nil {
+		return nil, fmt.Errorf("truncated binary resource: %v", b.failed)
+	}
+	return resource, nil
+}
+
+// printPackFiles lists the files of a package, showing remapped resources as "original -> target"
+func printPackFiles(out io.Writer, pack *ExportPack) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SIZE\tPATH")
+	var total int64
+	for _, f := range pack.Files {
+		total += f.Size
+		path := f.Path
+		if original, remapped := strings.CutSuffix(path, ".remap"); remapped {
+			path = original + " -> " + pack.remapTarget(original)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", formatSize(int(f.Size)), path)
+	}
+	w.Flush()
+
+	version := ""
+	if pack.Version != "" {
+		version = ", exported by Godot " + pack.Version
+	}
+	fmt.Fprintf(out, "\n%d file(s), %s%s\n", len(pack.Files), formatSize(int(total)), version)
+}
+
+// packScenePaths returns the scenes and resources of a package matching the
+// patterns, by their original path (remapped files are listed under the name
+// they are loaded by)
+func packScenePaths(pack *ExportPack, patterns []string) []string {
+	var paths []string
+	for _, f := range pack.Files {
+		path := strings.TrimSuffix(f.Path, ".remap")
+		if !hasExtension(path, append([]string{".scn", ".res"}, sceneExtensions...)) {
+			continue
+		}
+		for _, pattern := range patterns {
+			if query.Wildcard(toResPath(pattern), path) {
+				paths = append(paths, path)
+				break
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// PackSceneJSON is a scene of a package in JSON output: the parsed scene, with the header of binary files
+type PackSceneJSON struct {
+	Path   string          `json:"path"`
+	Loaded string          `json:"loaded"` // the file Godot loads, after remapping
+	Scene  *SceneJSON      `json:"scene,omitempty"`
+	Binary *BinaryResource `json:"binary,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	scene  *GodotScene
+}
+
+// loadPackScene reads a scene of a package. Binary scenes are decoded back to
+// text and parsed like text scenes; other binary resources only have their header read.
+func loadPackScene(pack *ExportPack, path string) *PackSceneJSON {
+	result := &PackSceneJSON{Path: path, Loaded: pack.remapTarget(path)}
+	content, err := pack.ReadFile(result.Loaded)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if bytes.HasPrefix(content, []byte("RSRC")) || bytes.HasPrefix(content, []byte("RSCC")) {
+		if result.Binary, err = parseBinaryResource(content); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if result.Binary.Type != "PackedScene" {
+			return result
+		}
+		text, err := binarySceneText(content, result.Binary)
+		if err != nil {
+			result.Error = "node tree not decoded: " + err.Error()
+			return result
+		}
+		content = []byte(text)
+	}
+
+	scene, err := ParseReader(bytes.NewReader(content), result.Loaded, sceneParseOptions())
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.scene = scene
+	result.Scene = sceneToJSON(scene, nil)
+	return result
+}
+
+// printPackScene displays a scene of a package: its tree, and the type and
+// dependencies of binary resources that are not scenes. Read errors are left
+// to the caller.
+func printPackScene(out io.Writer, result *PackSceneJSON) error {
+	fmt.Fprintf(out, "=== %s ===\n", result.Path)
+	if result.Loaded != result.Path {
+		fmt.Fprintf(out, "Remapped to %s\n", result.Loaded)
+	}
+	if binary := result.Binary; binary != nil {
+		fmt.Fprintf(out, "Binary %s (format %d, Godot %s), %d sub-resource(s)\n",
+			binary.Type, binary.Format, binary.EngineVersion, binary.SubResources)
+		if result.scene == nil {
+			for _, ext := range binary.ExtResources {
+				fmt.Fprintf(out, "  %s %s\n", ext.Type, ext.Path)
+			}
+		}
+	}
+	if result.scene == nil {
+		return nil
+	}
+	return displayScene(out, result.scene)
+}
+
+var packCmd = &cobra.Command{
+	Use:   "pack <pck or zip file> [res:// paths...]",
+	Short: "List and read the scenes of an exported .pck or .zip",
+	Long: `Open a Godot export package (.pck, an executable with an embedded pack, or .zip) to audit
+what shipped. Without paths, list the files of the package; remapped files are shown with the
+file they are loaded from. With paths (wildcards allowed), display the matching scenes and
+resources: scenes are parsed as usual (-q, -v and the other display flags apply), including
+the scenes converted to binary on export (.scn), whose node tree is decoded. Other binary
+resources (.res) show their type and dependencies. Compressed binary resources and encrypted
+packs are not supported. Files that cannot be read are reported on stderr (in the error field
+with -o json) and make the command exit non-zero.`,
+	Example: `  gdq pack build/game.pck
+  gdq pack build/game.pck 'res://levels/*.tscn'`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+
+		pack, err := openExportPack(args[0])
+		if err != nil {
+			return fmt.Errorf("pack error: %v", err)
+		}
+		defer pack.Close()
+
+		if len(args) == 1 {
+			if outputFormat == "json" {
+				return writeJSON(out, pack.Files)
+			}
+			printPackFiles(out, pack)
+			return nil
+		}
+
+		paths := packScenePaths(pack, args[1:])
+		if len(paths) == 0 {
+			return fmt.Errorf("no scenes matched in %s", args[0])
+		}
+
+		var results []*PackSceneJSON
+		failed := 0
+		for _, path := range paths {
+			result := loadPackScene(pack, path)
+			results = append(results, result)
+			if result.Error != "" {
+				failed++
+			}
+		}
+
+		if outputFormat == "json" {
+			if err := writeJSON(out, results); err != nil {
+				return err
+			}
+		} else {
+			for i, result := range results {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				if err := printPackScene(out, result); err != nil {
+					return err
+				}
+				if result.Error != "" {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %s\n", result.Path, result.Error)
+				}
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d file(s) could not be read", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packCmd)
+}
@@ -0,0 +1,310 @@
+package gdquery
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePck builds a .pck of the given format holding files, in directory order
+func writePck(t *testing.T, format uint32, names []string, files map[string]string) string {
+	t.Helper()
+
+	var directory, data bytes.Buffer
+	le := binary.LittleEndian
+	headerSize := 5*4 + 16*4
+	if format >= 2 {
+		headerSize += 4 + 8
+	}
+	directorySize := 4
+	for _, name := range names {
+		padded := (len(name) + 3) / 4 * 4
+		directorySize += 4 + padded + 8 + 8 + 16
+		if format >= 2 {
+			directorySize += 4
+		}
+	}
+
+	binary.Write(&directory, le, uint32(len(names)))
+	for _, name := range names {
+		padded := make([]byte, (len(name)+3)/4*4)
+		copy(padded, name)
+		binary.Write(&directory, le, uint32(len(padded)))
+		directory.Write(padded)
+		offset := uint64(data.Len())
+		if format == 1 {
+			offset += uint64(headerSize + directorySize)
+		}
+		binary.Write(&directory, le, offset)
+		binary.Write(&directory, le, uint64(len(files[name])))
+		directory.Write(make([]byte, 16))
+		if format >= 2 {
+			binary.Write(&directory, le, uint32(0))
+		}
+		data.WriteString(files[name])
+	}
+
+	var pck bytes.Buffer
+	binary.Write(&pck, le, []uint32{pckMagic, format, 4, 2, 1})
+	if format >= 2 {
+		binary.Write(&pck, le, uint32(0))
+		binary.Write(&pck, le, uint64(headerSize+directorySize))
+	}
+	pck.Write(make([]byte, 16*4))
+	pck.Write(directory.Bytes())
+	pck.Write(data.Bytes())
+
+	path := filepath.Join(t.TempDir(), "game.pck")
+	if err := os.WriteFile(path, pck.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	return path
+}
+
+// binaryScene builds a Godot 4 binary PackedScene: a CharacterBody2D with a
+// script, a CollisionShape2D using a sub_resource, an instanced enemy and a connection
+func binaryScene() string {
+	var b, body bytes.Buffer
+	le := binary.LittleEndian
+	str := func(w *bytes.Buffer, s string) {
+		binary.Write(w, le, uint32(len(s)+1))
+		w.WriteString(s + "\x00")
+	}
+	b.WriteString("RSRC")
+	binary.Write(&b, le, []uint32{0, 0, 4, 2, 5})
+	str(&b, "PackedScene")
+	binary.Write(&b, le, uint64(0))
+	binary.Write(&b, le, uint32(binaryFlagNamedSceneIDs|binaryFlagUIDs))
+	binary.Write(&b, le, uint64(1234))
+	b.Write(make([]byte, 11*4))
+	binary.Write(&b, le, uint32(2))
+	str(&b, "size")
+	str(&b, "_bundled")
+	binary.Write(&b, le, uint32(2))
+	str(&b, "Script")
+	str(&b, "res://player.gd")
+	binary.Write(&b, le, uint64(99))
+	str(&b, "PackedScene")
+	str(&b, "res://enemy.tscn")
+	binary.Write(&b, le, uint64(100))
+
+	// The bodies follow the list of internal resources and their offsets
+	internal := []string{"local://RectangleShape2D_x", "local://PackedScene_y"}
+	start := b.Len() + 4
+	for _, path := range internal {
+		start += 4 + len(path) + 1 + 8
+	}
+	var offsets []uint64
+
+	offsets = append(offsets, uint64(start+body.Len()))
+	str(&body, "RectangleShape2D")
+	binary.Write(&body, le, []uint32{1, 0, variantVector2})
+	binary.Write(&body, le, []float32{10, 20.5})
+
+	ints := func(values ...int32) {
+		binary.Write(&body, le, []uint32{variantPackedInt32Array, uint32(len(values))})
+		binary.Write(&body, le, values)
+	}
+	key := func(s string) {
+		binary.Write(&body, le, uint32(variantString))
+		str(&body, s)
+	}
+	offsets = append(offsets, uint64(start+body.Len()))
+	str(&body, "PackedScene")
+	binary.Write(&body, le, []uint32{1, 1, variantDictionary, 5})
+	key("names")
+	names := []string{"Player", "CharacterBody2D", "script", "Shape", "CollisionShape2D", "shape",
+		"Enemy", "body_entered", "_on_body_entered", "enemies"}
+	binary.Write(&body, le, []uint32{variantPackedStringArray, uint32(len(names))})
+	for _, name := range names {
+		str(&body, name)
+	}
+	key("variants")
+	binary.Write(&body, le, []uint32{variantArray, 3,
+		variantObject, objectExternalResourceIndex, 0,
+		variantObject, objectInternalResource, 0,
+		variantObject, objectExternalResourceIndex, 1})
+	key("nodes")
+	ints(-1, -1, 1, 0, -1, 1, 2, 0, 0,
+		0, 0, 4, 3, -1, 1, 5, 1, 1, 9,
+		0, 0, bundledTypeInstantiated, 6|2<<bundledNameIndexBits, 2, 0, 0)
+	key("conns")
+	ints(2, 0, 7, 8, 0, 0, 0)
+	key("version")
+	binary.Write(&body, le, []uint32{variantInt, 3})
+
+	binary.Write(&b, le, uint32(len(internal)))
+	for i, path := range internal {
+		str(&b, path)
+		binary.Write(&b, le, offsets[i])
+	}
+	b.Write(body.Bytes())
+	return b.String()
+}
+
+const packTextScene = `[gd_scene format=3]
+
+[node name="Menu" type="Control"]
+
+[node name="Start" type="Button" parent="."]
+text = "Start"
+`
+
+func TestExportPack(t *testing.T) {
+	files := map[string]string{
+		"res://menu.tscn":       packTextScene,
+		"res://main.tscn.remap": "[remap]\n\npath=\"res://.godot/exported/133200997/export-abc-main.scn\"\n",
+		"res://.godot/exported/133200997/export-abc-main.scn": binaryScene(),
+	}
+	names := []string{"res://menu.tscn", "res://main.tscn.remap", "res://.godot/exported/133200997/export-abc-main.scn"}
+
+	for _, format := range []uint32{1, 2} {
+		pack, err := openExportPack(writePck(t, format, names, files))
+		if err != nil {
+			t.Fatalf("Format %d: open error: %v", format, err)
+		}
+		defer pack.Close()
+
+		if pack.Version != "4.2.1" || len(pack.Files) != 3 {
+			t.Errorf("Format %d: unexpected pack: %s, %d files", format, pack.Version, len(pack.Files))
+		}
+
+		paths := packScenePaths(pack, []string{"res://*.tscn"})
+		if len(paths) != 2 || paths[0] != "res://main.tscn" || paths[1] != "res://menu.tscn" {
+			t.Fatalf("Format %d: unexpected scenes: %v", format, paths)
+		}
+
+		menu := loadPackScene(pack, "res://menu.tscn")
+		if menu.Error != "" || menu.scene == nil || len(menu.scene.AllNodes) != 2 {
+			t.Errorf("Format %d: expected the text scene to be parsed: %+v", format, menu)
+		}
+
+		main := loadPackScene(pack, "res://main.tscn")
+		if main.Loaded != "res://.godot/exported/133200997/export-abc-main.scn" {
+			t.Errorf("Format %d: expected the remap to be resolved, got %s", format, main.Loaded)
+		}
+		if main.Error != "" || main.Binary == nil {
+			t.Fatalf("Format %d: expected a binary header: %+v", format, main)
+		}
+		header := main.Binary
+		if header.Type != "PackedScene" || header.EngineVersion != "4.2" || header.SubResources != 1 ||
+			len(header.ExtResources) != 2 || header.ExtResources[0].Path != "res://player.gd" {
+			t.Errorf("Format %d: unexpected binary header: %+v", format, header)
+		}
+
+		scene := main.scene
+		if scene == nil || len(scene.AllNodes) != 3 {
+			t.Fatalf("Format %d: expected the node tree to be decoded: %+v", format, main.Scene)
+		}
+		player, shape, enemy := scene.AllNodes[0], scene.AllNodes[1], scene.AllNodes[2]
+		if scene.RootNode != player || player.Type != "CharacterBody2D" || scene.ExtResources["1"].Path != "res://player.gd" ||
+			player.Script != `ExtResource("1")` {
+			t.Errorf("Format %d: unexpected root: %+v", format, player)
+		}
+		if shape.Path != "Player/Shape" || shape.Type != "CollisionShape2D" ||
+			shape.Properties["shape"] != `SubResource("RectangleShape2D_x")` ||
+			scene.SubResources["RectangleShape2D_x"].Type != "RectangleShape2D" {
+			t.Errorf("Format %d: unexpected child: %+v", format, shape)
+		}
+		if enemy.Path != "Player/Enemy" || enemy.Type != "" || enemy.Instance != `ExtResource("2")` || enemy.Index != 1 {
+			t.Errorf("Format %d: unexpected instance: %+v", format, enemy)
+		}
+		if len(scene.Connections) != 1 || scene.Connections[0].From != "Enemy" || scene.Connections[0].To != "." ||
+			scene.Connections[0].Method != "_on_body_entered" {
+			t.Errorf("Format %d: unexpected connections: %+v", format, scene.Connections)
+		}
+	}
+}
+
+func TestCorruptExportPack(t *testing.T) {
+	valid, err := os.ReadFile(writePck(t, 2, []string{"res://menu.tscn"}, map[string]string{"res://menu.tscn": packTextScene}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The directory of a format 2 pack starts after the 96 bytes of the header:
+	// file count, then path length, 16-byte path, offset and size of the file
+	const directory = 96
+	corrupt := func(offset int, value uint32) []byte {
+		data := bytes.Clone(valid)
+		binary.LittleEndian.PutUint32(data[offset:], value)
+		return data
+	}
+
+	tests := map[string][]byte{
+		"huge file count":   corrupt(directory, 0xFFFFFFFF),
+		"huge path length":  corrupt(directory+4, 0xFFFFFFF0),
+		"path past the end": corrupt(directory+4, 1000),
+		"file past the end": corrupt(directory+8+16+8, 1<<30),
+		"truncated":         valid[:directory+10],
+	}
+	for name, data := range tests {
+		path := filepath.Join(t.TempDir(), "corrupt.pck")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if pack, err := openExportPack(path); err == nil {
+			pack.Close()
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestExportPackZip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	entry, _ := w.Create("ui/menu.tscn")
+	entry.Write([]byte(packTextScene))
+	w.Close()
+	f.Close()
+
+	pack, err := openExportPack(path)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer pack.Close()
+
+	menu := loadPackScene(pack, "res://ui/menu.tscn")
+	if menu.Error != "" || menu.scene == nil || menu.scene.RootNode.Name != "Menu" {
+		t.Errorf("Expected the scene to be read from the zip: %+v", menu)
+	}
+
+	if _, err := parseBinaryResource([]byte("RSRC\x00")); err == nil {
+		t.Error("Expected an error for a truncated binary resource")
+	}
+	scene := []byte(binaryScene())
+	header, err := parseBinaryResource(scene[:len(scene)-20])
+	if err != nil {
+		t.Fatalf("Expected the header to be read: %v", err)
+	}
+	if _, err := binarySceneText(scene[:len(scene)-20], header); err == nil {
+		t.Error("Expected an error for a truncated node tree")
+	}
+
+	// A scene that cannot be decoded is reported on stderr, or in the JSON
+	// result, and fails the command
+	files := map[string]string{"res://menu.tscn": packTextScene, "res://broken.scn": string(scene[:len(scene)-20])}
+	pck := writePck(t, 2, []string{"res://menu.tscn", "res://broken.scn"}, files)
+	for _, format := range []string{"text", "json"} {
+		var stdout, stderr strings.Builder
+		if code := Run([]string{"pack", "-o", format, pck, "res://*"}, &stdout, &stderr); code == 0 {
+			t.Errorf("%s: expected a failure for the truncated node tree", format)
+		}
+		if !strings.Contains(stdout.String(), "Menu") {
+			t.Errorf("%s: expected the other scene to be displayed:\n%s", format, stdout.String())
+		}
+		if format == "text" && (!strings.Contains(stderr.String(), "Error: res://broken.scn: node tree not decoded") || strings.Contains(stdout.String(), "Error")) {
+			t.Errorf("Expected the error on stderr:\nstdout: %s\nstderr: %s", stdout.String(), stderr.String())
+		}
+		if format == "json" && !strings.Contains(stdout.String(), `"error": "node tree not decoded`) {
+			t.Errorf("Expected the error in the JSON result:\n%s", stdout.String())
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package gdquery
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Parser parses scenes with fixed options. It holds no per-parse state and is
+// safe for concurrent use; the root types of instanced scenes read with
+// FollowInstances are cached across calls.
+type Parser struct {
+	opts          ParseOptions
+	log           *slog.Logger
+	instanceTypes *instanceTypeCache
+}
+
+// NewParser returns a parser using opts for every scene
+func NewParser(opts ParseOptions) *Parser {
+	log := opts.Logger
+	if log == nil {
+		log = discardLogger
+	}
+	return &Parser{opts: opts, log: log, instanceTypes: newInstanceTypeCache()}
+}
+
+// instanceTypeCache holds the root types of instanced scenes by file
+type instanceTypeCache struct {
+	mu    sync.Mutex
+	types map[string]string
+}
+
+// newInstanceTypeCache returns an empty cache
+func newInstanceTypeCache() *instanceTypeCache {
+	return &instanceTypeCache{types: make(map[string]string)}
+}
+
+// get returns the cached root type of a scene file
+func (c *instanceTypeCache) get(file string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rootType, exists := c.types[file]
+	return rootType, exists
+}
+
+// set caches the root type of a scene file
+func (c *instanceTypeCache) set(file, rootType string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.types[file] = rootType
+}
@@ -0,0 +1,119 @@
+package gdquery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParserConcurrentUse(t *testing.T) {
+	files := map[string]string{
+		"player.tscn": "[gd_scene format=3]\n\n[node name=\"Player\" type=\"CharacterBody2D\"]\n",
+	}
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("levels/level_%02d.tscn", i)] = fmt.Sprintf(`[gd_scene load_steps=2 format=3]
+
+[ext_resource type="PackedScene" path="res://player.tscn" id="1_p"]
+
+[node name="Level%d" type="Node2D"]
+
+[node name="Player" parent="." instance=ExtResource("1_p")]
+`, i)
+	}
+	root := writeProjectFiles(t, files)
+
+	// One parser shared by all goroutines, including its instance type cache
+	parser := NewParser(ParseOptions{FollowInstances: true, ProjectRoot: root})
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			scene, err := parser.ParseFile(filepath.Join(root, "levels", fmt.Sprintf("level_%02d.tscn", i)))
+			switch {
+			case err != nil:
+				errs <- err
+			case scene.RootNode.Name != fmt.Sprintf("Level%d", i):
+				errs <- fmt.Errorf("level %d: unexpected root %s", i, scene.RootNode.Name)
+			case scene.AllNodes[1].InstanceType != "CharacterBody2D":
+				errs <- fmt.Errorf("level %d: unexpected instance type %q", i, scene.AllNodes[1].InstanceType)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Parallel project scans still report scenes in file order
+	var scanned []string
+	err := scanProjectFunc(root, root, ParseOptions{}, func(result *SceneScanResult) error {
+		scanned = append(scanned, result.File)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	if len(scanned) != 21 || scanned[0] != "res://levels/level_00.tscn" || scanned[19] != "res://levels/level_19.tscn" {
+		t.Errorf("Unexpected scan order: %v", scanned)
+	}
+}
+
+func TestParserLogger(t *testing.T) {
+	content := "[gd_scene format=3]\n\n[node name=\"Main\" type=\"Node\"]\n\n[node name=\"Lost\" type=\"Node\" parent=\"Missing\"]\n"
+
+	var logs bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if _, err := ParseReader(strings.NewReader(content), "main.tscn", ParseOptions{Forest: true, Logger: log}); err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	for _, expected := range []string{`msg="Parent not found, keeping a detached tree"`, `msg="Node start"`} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("Expected %s in the parser logs, got:\n%s", expected, logs.String())
+		}
+	}
+
+	// Without a logger nothing is logged, not even to the package logger
+	logs.Reset()
+	saved := logger
+	logger = log
+	defer func() { logger = saved }()
+	if _, err := ParseReader(strings.NewReader(content), "main.tscn", ParseOptions{Forest: true}); err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Expected no logs without a logger, got:\n%s", logs.String())
+	}
+}
+
+func TestScanStopsAfterParses(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		files[fmt.Sprintf("scene_%02d.tscn", i)] = "[gd_scene format=3]\n\n[node name=\"Main\" type=\"Node\"]\n"
+	}
+	root := writeProjectFiles(t, files)
+
+	stop := errors.New("stop")
+	var logs bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	err := scanProjectFunc(root, root, ParseOptions{Logger: log}, func(result *SceneScanResult) error {
+		return stop
+	})
+	if err != stop {
+		t.Fatalf("Expected the error of fn, got %v", err)
+	}
+	// No parse writes to the logs once the scan returned
+	written := logs.Len()
+	time.Sleep(10 * time.Millisecond)
+	if logs.Len() != written {
+		t.Errorf("Expected the started parses to be done when the scan returns")
+	}
+}
@@ -0,0 +1,169 @@
+package gdquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Patch command options
+var patchForce = false
+var patchDryRun = false
+
+// splitNodePath returns the parent path and name of a node path below the scene root
+func splitNodePath(path string) (string, string) {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return ".", path
+	}
+	return path[:i], path[i+1:]
+}
+
+// applySceneChange applies one change of a gdq diff list to the scene. Unless
+// force is set, the old value of a changed or removed property must match.
+func applySceneChange(editor *SceneEditor, change *SceneChange, force bool) error {
+	checkOld := func() error {
+		if force || change.Old == nil {
+			return nil
+		}
+		current, exists := editor.Property(change.Path, change.Property)
+		if !exists {
+			return fmt.Errorf("%s is not set, expected %s", change.Property, *change.Old)
+		}
+		if current != *change.Old {
+			return fmt.Errorf("%s is %s, expected %s", change.Property, current, *change.Old)
+		}
+		return nil
+	}
+
+	switch change.Op {
+	case opAddNode:
+		parent, name := splitNodePath(change.Path)
+		if change.Instance != "" && change.Type == "" {
+			return editor.AddInstance(parent, name, change.Instance)
+		}
+		return editor.AddChild(parent, name, change.Type)
+	case opRemoveNode:
+		return editor.Remove(change.Path)
+	case opSetType:
+		if change.New == nil {
+			return fmt.Errorf("missing new type")
+		}
+		return editor.SetType(change.Path, *change.New)
+	case opSetProperty:
+		if change.New == nil {
+			return fmt.Errorf("missing new value")
+		}
+		if editor.nodeSection(change.Path) == nil {
+			return fmt.Errorf("node not found: %s", change.Path)
+		}
+		if err := checkOld(); err != nil {
+			return err
+		}
+		return editor.SetProperty(change.Path, change.Property, *change.New)
+	case opRemoveProperty:
+		if err := checkOld(); err != nil {
+			return err
+		}
+		removed, err := editor.RemoveProperty(change.Path, change.Property)
+		if err == nil && !removed && !force {
+			err = fmt.Errorf("%s is not set", change.Property)
+		}
+		return err
+	case opAddConnection, opRemoveConnection:
+		c := change.Connection
+		if c == nil {
+			return fmt.Errorf("missing connection")
+		}
+		if change.Op == opAddConnection {
+			return editor.Connect(c.Signal, c.From, c.To, c.Method, c.Flags, c.Binds)
+		}
+		return editor.Disconnect(c.Signal, c.From, c.To, c.Method)
+	}
+	return fmt.Errorf("unknown op %q", change.Op)
+}
+
+// applySceneChanges applies a change list in order, stopping at the first
+// change that does not apply
+func applySceneChanges(editor *SceneEditor, changes []*SceneChange, force bool) error {
+	for i, change := range changes {
+		if err := applySceneChange(editor, change, force); err != nil {
+			target := change.Path
+			if change.Connection != nil {
+				target = change.Connection.String()
+			}
+			return fmt.Errorf("change %d (%s %s): %v", i+1, change.Op, target, err)
+		}
+	}
+	return nil
+}
+
+var patchCmd = &cobra.Command{
+	Use:   "patch <tscn file> <patch.json>",
+	Short: "Apply a JSON change list from gdq diff to a scene",
+	Long: `Apply the changes printed by gdq diff -o json to a scene, in order, and save it. Only the
+sections touched are rewritten. Node paths are relative to the scene root, so a patch recorded
+on one scene can be replayed on another branch or a similar scene.
+
+The old values of changed and removed properties must match the scene, and the changes stop at
+the first one that does not apply, leaving the file untouched. --force applies property changes
+whatever the current values. Use - to read the patch from stdin.`,
+	Example: `  gdq diff -o json old/player.tscn player.tscn > player.patch.json
+  gdq patch other/player.tscn player.patch.json
+  gdq diff -o json a.tscn b.tscn | gdq patch --dry-run c.tscn -`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		file, patchFile := args[0], args[1]
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return fmt.Errorf("file not found: %s", file)
+		}
+
+		var data []byte
+		var err error
+		if patchFile == "-" {
+			data, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			data, err = os.ReadFile(patchFile)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read patch: %v", err)
+		}
+		var changes []*SceneChange
+		if err := json.Unmarshal(data, &changes); err != nil {
+			return fmt.Errorf("invalid patch: %v", err)
+		}
+
+		editor, err := OpenSceneEditor(file)
+		if err != nil {
+			return fmt.Errorf("parse error: %v", err)
+		}
+		if err := applySceneChanges(editor, changes, patchForce); err != nil {
+			return err
+		}
+		if _, err := editor.Scene(); err != nil {
+			return fmt.Errorf("patched scene does not parse: %v", err)
+		}
+
+		if patchDryRun {
+			fmt.Fprint(out, editor.String())
+			return nil
+		}
+		if err := editor.Save(file); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Applied %d change(s) to %s\n", len(changes), file)
+		return nil
+	},
+}
+
+func init() {
+	patchCmd.Flags().BoolVar(&patchForce, "force", false, "Apply property changes without checking their old values")
+	patchCmd.Flags().BoolVar(&patchDryRun, "dry-run", false, "Print the patched scene instead of saving it")
+	rootCmd.AddCommand(patchCmd)
+}
@@ -0,0 +1,96 @@
+package gdquery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplySceneChanges(t *testing.T) {
+	oldContent := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_s"]
+
+[node name="Player" type="CharacterBody2D"]
+script = ExtResource("1_s")
+speed = 100
+visible = false
+
+[node name="Old" type="Node2D" parent="."]
+
+[node name="Child" type="Node2D" parent="Old"]
+
+[node name="Sprite" type="Sprite2D" parent="."]
+
+[connection signal="timeout" from="Old" to="." method="_on_timeout"]
+[connection signal="hit" from="Sprite" to="." method="_on_hit"]
+`
+	newContent := `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_s"]
+[ext_resource type="PackedScene" path="res://weapon.tscn" id="2_w"]
+
+[node name="Player" type="CharacterBody2D"]
+script = ExtResource("1_s")
+speed = 200
+health = 3
+
+[node name="Sprite" type="AnimatedSprite2D" parent="."]
+
+[node name="Label" type="Label" parent="Sprite"]
+text = "Hi"
+
+[node name="Weapon" parent="." instance=ExtResource("2_w")]
+damage = 5
+
+[connection signal="ready" from="." to="." method="_on_ready"]
+`
+	parse := func(content string) *GodotScene {
+		scene, err := ParseReader(strings.NewReader(content), "test.tscn", ParseOptions{KeepRawLines: true})
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		return scene
+	}
+	changes := diffScenes(parse(oldContent), parse(newContent))
+
+	editor, err := NewSceneEditor(oldContent)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if err := applySceneChanges(editor, changes, false); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	patched, err := editor.Scene()
+	if err != nil {
+		t.Fatalf("Patched scene does not parse: %v\n%s", err, editor.String())
+	}
+	if remaining := diffScenes(parse(editor.String()), parse(newContent)); len(remaining) != 0 {
+		t.Errorf("Unexpected changes after patching: %v\n%s", remaining, editor.String())
+	}
+	if weapon := findNodeByPath(patched, "Player/Weapon"); weapon == nil || resolveResourcePath(weapon.Instance, patched) != "res://weapon.tscn" {
+		t.Errorf("Weapon instance not added:\n%s", editor.String())
+	}
+
+	// Replaying the patch on the patched scene fails at the first change
+	err = applySceneChanges(editor, changes, false)
+	if err == nil || !strings.HasPrefix(err.Error(), "change 1 (remove-node Old)") {
+		t.Errorf("Expected an error on change 1, got %v", err)
+	}
+
+	// Old values must match unless forced
+	editor, _ = NewSceneEditor(strings.Replace(oldContent, "speed = 100", "speed = 150", 1))
+	speedChange := []*SceneChange{changes[2]}
+	if changes[2].Property != "speed" {
+		t.Fatalf("Unexpected change order: %v", changes)
+	}
+	err = applySceneChanges(editor, speedChange, false)
+	if err == nil || !strings.Contains(err.Error(), "speed is 150, expected 100") {
+		t.Errorf("Expected an old value mismatch, got %v", err)
+	}
+	if err := applySceneChanges(editor, speedChange, true); err != nil {
+		t.Errorf("Forced apply error: %v", err)
+	}
+	if value, _ := editor.Property(".", "speed"); value != "200" {
+		t.Errorf("Expected speed 200, got %q", value)
+	}
+}
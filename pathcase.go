@@ -0,0 +1,41 @@
+package gdquery
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "res-path-case",
+		Description: "res:// references whose case differs from the file on disk (breaks on case-sensitive filesystems such as Linux exports)",
+		Check:       checkResPathCase,
+	})
+}
+
+// checkResPathCase reports references that only resolve case-insensitively
+func checkResPathCase(ctx *LintContext) []LintFinding {
+	var findings []LintFinding
+	graph := ctx.Graph()
+
+	for _, file := range graph.Files {
+		if !ctx.inDir(file) {
+			continue
+		}
+		for _, edge := range graph.Edges[file] {
+			if !strings.HasPrefix(edge.To, "res://") {
+				continue
+			}
+			actual, exists := resolveResCase(ctx.Root, edge.To)
+			if exists && actual != edge.To {
+				findings = append(findings, LintFinding{
+					Severity: severityError,
+					File:     file,
+					Message:  fmt.Sprintf("%s does not match the case of %s", edge.To, actual),
+				})
+			}
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,129 @@
+package gdquery
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// physicsLayerCount is the number of physics layers Godot supports
+const physicsLayerCount = 32
+
+// PhysicsLayerNames holds the physics layer names of a project, by 1-based layer number
+type PhysicsLayerNames struct {
+	Names2D map[int]string
+	Names3D map[int]string
+}
+
+// physicsLayerNamesCache caches the layer names per project root
+var physicsLayerNamesCache = make(map[string]*PhysicsLayerNames)
+
+// loadPhysicsLayerNames reads the [layer_names] section of project.godot
+func loadPhysicsLayerNames(project *ConfigFile) *PhysicsLayerNames {
+	names := &PhysicsLayerNames{Names2D: make(map[int]string), Names3D: make(map[int]string)}
+	for layer := 1; layer <= physicsLayerCount; layer++ {
+		if name := project.GetString("layer_names", fmt.Sprintf("2d_physics/layer_%d", layer)); name != "" {
+			names.Names2D[layer] = name
+		}
+		if name := project.GetString("layer_names", fmt.Sprintf("3d_physics/layer_%d", layer)); name != "" {
+			names.Names3D[layer] = name
+		}
+	}
+	return names
+}
+
+// physicsLayerNamesFor returns the layer names of the project containing file
+func physicsLayerNamesFor(file string) *PhysicsLayerNames {
+	root := findProjectRoot(file)
+	if names, cached := physicsLayerNamesCache[root]; cached {
+		return names
+	}
+
+	project, err := parseConfigFile(filepath.Join(root, "project.godot"))
+	if err != nil {
+		project = &ConfigFile{}
+	}
+	names := loadPhysicsLayerNames(project)
+	physicsLayerNamesCache[root] = names
+	return names
+}
+
+// layerNamesForNode returns the 2D or 3D layer names matching the node type
+func (n *PhysicsLayerNames) layerNamesForNode(node *GodotNode) map[int]string {
+	if classInherits(node.Type, "Node3D") || classInherits(node.Type, "Spatial") {
+		return n.Names3D
+	}
+	return n.Names2D
+}
+
+// decodePhysicsLayers returns the names of the layers set in a bitmask,
+// using the layer number for unnamed layers
+func decodePhysicsLayers(mask uint64, names map[int]string) []string {
+	var layers []string
+	for layer := 1; layer <= physicsLayerCount; layer++ {
+		if mask&(1<<(layer-1)) == 0 {
+			continue
+		}
+		if name, exists := names[layer]; exists {
+			layers = append(layers, name)
+		} else {
+			layers = append(layers, strconv.Itoa(layer))
+		}
+	}
+	return layers
+}
+
+// isPhysicsLayerProperty reports whether prop holds a physics layer bitmask
+func isPhysicsLayerProperty(prop string) bool {
+	return prop == "collision_layer" || prop == "collision_mask"
+}
+
+// formatPhysicsLayers formats a collision_layer/collision_mask value as
+// "[player, world] (5)", or returns "" when the value is not a bitmask
+func formatPhysicsLayers(node *GodotNode, value string, scene *GodotScene) string {
+	mask, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return ""
+	}
+	names := physicsLayerNamesFor(scene.File).layerNamesForNode(node)
+	return fmt.Sprintf("[%s] (%d)", strings.Join(decodePhysicsLayers(mask, names), ", "), mask)
+}
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "physics-layer-zero",
+		Description: "Collision objects with collision_layer 0 (never detected) or collision_mask 0 (detect nothing)",
+		Check:       checkPhysicsLayerZero,
+	})
+}
+
+// checkPhysicsLayerZero reports collision objects with an empty layer or mask
+func checkPhysicsLayerZero(ctx *LintContext) []LintFinding {
+	var findings []LintFinding
+	for _, result := range ctx.Scenes() {
+		if result.Err != nil {
+			continue
+		}
+		for _, node := range result.Scene.AllNodes {
+			if !classInherits(node.Type, "CollisionObject2D") && !classInherits(node.Type, "CollisionObject3D") {
+				continue
+			}
+			if strings.TrimSpace(node.Properties["collision_layer"]) == "0" {
+				findings = append(findings, LintFinding{
+					File:    result.File,
+					Node:    node.Path,
+					Message: "collision_layer is 0: no other object can detect it",
+				})
+			}
+			if strings.TrimSpace(node.Properties["collision_mask"]) == "0" {
+				findings = append(findings, LintFinding{
+					File:    result.File,
+					Node:    node.Path,
+					Message: "collision_mask is 0: it does not detect any other object",
+				})
+			}
+		}
+	}
+	return findings
+}
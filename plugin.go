@@ -0,0 +1,219 @@
+package gdquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// pluginSection is the section of gdq.cfg that registers plugins, one key per
+// plugin: name = "short description"
+const pluginSection = "plugins"
+
+// projectPluginsEnv names the environment variable that, set to 1, lets gdq run
+// plugin executables found in the project root, and a Godot executable configured
+// relative to it. By default plugins are only looked up on the PATH, so that a
+// cloned repository cannot ship executables that gdq runs.
+const projectPluginsEnv = "GDQ_PROJECT_PLUGINS"
+
+// pluginProtocol is the version of the plugin request format
+const pluginProtocol = 1
+
+// Plugin is an external gdq-<name> executable exposed as a subcommand
+type Plugin struct {
+	Name        string
+	Description string
+	Root        string // project root holding the configuration
+}
+
+// PluginRequest is written as JSON to the standard input of a plugin
+type PluginRequest struct {
+	Protocol    int          `json:"protocol"`
+	Command     string       `json:"command"`
+	ProjectRoot string       `json:"project_root"`
+	Args        []string     `json:"args"` // arguments that are not scene files
+	Scenes      []*SceneJSON `json:"scenes"`
+}
+
+// PluginResponse is read as JSON from the standard output of a plugin
+type PluginResponse struct {
+	Output   string          `json:"output,omitempty"`
+	Findings []PluginFinding `json:"findings,omitempty"`
+}
+
+// PluginFinding is a problem reported by a plugin
+type PluginFinding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Node     string `json:"node,omitempty"`
+	Severity string `json:"severity,omitempty"` // "error" or "warning" (default)
+	Message  string `json:"message"`
+}
+
+// loadPlugins reads the plugins registered in the configuration of the
+// project containing dir
+func loadPlugins(dir string) []*Plugin {
+	root := findProjectRoot(dir)
+	config, err := loadToolConfig(root)
+	if err != nil {
+		logger.Warn("Skipping plugins", "error", err)
+		return nil
+	}
+	section := config.Section(pluginSection)
+	if section == nil {
+		return nil
+	}
+
+	var plugins []*Plugin
+	for _, name := range section.Keys {
+		plugins = append(plugins, &Plugin{Name: name, Description: unquoteValue(section.Values[name]), Root: root})
+	}
+	return plugins
+}
+
+// executable finds the gdq-<name> binary on the PATH, or first in the project
+// root when GDQ_PROJECT_PLUGINS=1
+func (p *Plugin) executable() (string, error) {
+	binary := "gdq-" + p.Name
+	if os.Getenv(projectPluginsEnv) == "1" {
+		for _, candidate := range []string{binary, binary + ".exe"} {
+			path := filepath.Join(p.Root, candidate)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return "", fmt.Errorf("plugin %s: %s not found in PATH", p.Name, binary)
+	}
+	return path, nil
+}
+
+// request builds the plugin request: arguments naming scene or resource files
+// are parsed and sent as scenes, the others are passed through
+func (p *Plugin) request(args []string) (*PluginRequest, error) {
+	request := &PluginRequest{Protocol: pluginProtocol, Command: p.Name, ProjectRoot: p.Root, Args: []string{}, Scenes: []*SceneJSON{}}
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil || info.IsDir() || !hasExtension(arg, sceneExtensions) {
+			request.Args = append(request.Args, arg)
+			continue
+		}
+		scene, err := parseSceneFile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("parse error: %s: %v", arg, err)
+		}
+		request.Scenes = append(request.Scenes, sceneToJSON(scene, nil))
+	}
+	return request, nil
+}
+
+// run executes the plugin with the request on stdin and its stderr going to
+// stderr. Output that is not a JSON response is returned as plain output.
+func (p *Plugin) run(request *PluginRequest, stderr io.Writer) (*PluginResponse, error) {
+	path, err := p.executable()
+	if err != nil {
+		return nil, err
+	}
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	command := exec.Command(path, request.Args...)
+	command.Dir = p.Root
+	command.Stdin = bytes.NewReader(input)
+	command.Stdout = &stdout
+	command.Stderr = stderr
+	logger.Debug("Running plugin", "name", p.Name, "path", path, "scenes", len(request.Scenes))
+	if err := command.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s failed: %v", p.Name, err)
+	}
+
+	response := &PluginResponse{}
+	if err := json.Unmarshal(stdout.Bytes(), response); err != nil {
+		return &PluginResponse{Output: stdout.String()}, nil
+	}
+	return response, nil
+}
+
+// lintFindings converts the findings of a plugin response, attributed to the plugin
+func (r *PluginResponse) lintFindings(plugin string) []LintFinding {
+	var findings []LintFinding
+	for _, finding := range r.Findings {
+		severity := severityWarning
+		if finding.Severity == severityError {
+			severity = severityError
+		}
+		findings = append(findings, LintFinding{
+			Rule:     plugin,
+			Severity: severity,
+			File:     finding.File,
+			Line:     finding.Line,
+			Node:     finding.Node,
+			Message:  finding.Message,
+		})
+	}
+	return findings
+}
+
+// command exposes the plugin as a subcommand. Flags are not parsed by gdq so
+// that they reach the plugin unchanged.
+func (p *Plugin) command() *cobra.Command {
+	short := p.Description
+	if short == "" {
+		short = fmt.Sprintf("Run the %s plugin", p.Name)
+	}
+	return &cobra.Command{
+		Use:                p.Name + " [scene files...] [plugin args...]",
+		Short:              short + " (plugin)",
+		Long:               fmt.Sprintf("%s\n\nRuns the gdq-%s plugin registered in %s.", short, p.Name, toolConfigFile),
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			request, err := p.request(args)
+			if err != nil {
+				return err
+			}
+			response, err := p.run(request, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(out, response.Output)
+			if response.Output != "" && !strings.HasSuffix(response.Output, "\n") {
+				fmt.Fprintln(out)
+			}
+			findings := response.lintFindings(p.Name)
+			printLintFindings(out, findings)
+			for _, finding := range findings {
+				if finding.Severity == severityError {
+					return fmt.Errorf("plugin %s reported errors", p.Name)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// registerPlugins adds the plugins of the current project as subcommands.
+// Plugins cannot replace built-in commands.
+func registerPlugins(cmd *cobra.Command, dir string) {
+	for _, plugin := range loadPlugins(dir) {
+		if existing, _, err := cmd.Find([]string{plugin.Name}); err == nil && existing != cmd {
+			logger.Warn("Plugin name conflicts with a command", "plugin", plugin.Name)
+			continue
+		}
+		cmd.AddCommand(plugin.command())
+	}
+}
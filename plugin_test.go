@@ -0,0 +1,99 @@
+package gdquery
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestPluginCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins need a POSIX shell")
+	}
+	root := writeProjectFiles(t, map[string]string{
+		"gdq.cfg":   "[plugins]\ncheck = \"Run team checks\"\nraw = \"\"\nlint = \"Shadows a command\"\n",
+		"main.tscn": "[gd_scene format=3]\n\n[node name=\"Main\" type=\"Node2D\"]\n",
+	})
+	bin := t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(projectPluginsEnv, "")
+	// The check plugin echoes its request back as a finding
+	script := `#!/bin/sh
+request=$(cat | tr -d '\n"')
+echo '{"output": "checked", "findings": [{"file": "res://main.tscn", "node": "Main", "message": "args '"$*"'"}]}'
+case "$request" in *name:Main*) exit 0 ;; *) exit 3 ;; esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "gdq-check"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "gdq-raw"), []byte("#!/bin/sh\necho plain text\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{Use: "gdq"}
+	cmd.AddCommand(&cobra.Command{Use: "lint"})
+	registerPlugins(cmd, root)
+
+	var names []string
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	if strings.Join(names, ",") != "check,lint,raw" {
+		t.Fatalf("Unexpected commands: %v", names)
+	}
+
+	var output bytes.Buffer
+	cmd.SetOut(&output)
+	check, _, _ := cmd.Find([]string{"check"})
+	if err := check.RunE(check, []string{filepath.Join(root, "main.tscn"), "--strict"}); err != nil {
+		t.Errorf("Plugin error: %v", err)
+	}
+	expected := "checked\nres://main.tscn:Main: warning: args --strict [check]\n"
+	if output.String() != expected {
+		t.Errorf("Unexpected output:\n%s", output.String())
+	}
+
+	output.Reset()
+	raw, _, _ := cmd.Find([]string{"raw"})
+	if err := raw.RunE(raw, nil); err != nil {
+		t.Errorf("Plugin error: %v", err)
+	}
+	if output.String() != "plain text\n" {
+		t.Errorf("Unexpected raw output: %q", output.String())
+	}
+
+	// A failing plugin is an error
+	if err := check.RunE(check, nil); err == nil {
+		t.Error("Expected an error when the plugin exits non-zero")
+	}
+}
+
+func TestPluginProjectExecutables(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"gdqlint.cfg":   "[plugins]\nlocal = \"\"\n",
+		"gdq-local":     "#!/bin/sh\necho local\n",
+		"gdq-local.exe": "",
+	})
+	t.Setenv("PATH", t.TempDir())
+
+	// Projects without gdq.cfg keep their plugins in gdqlint.cfg
+	plugins := loadPlugins(root)
+	if len(plugins) != 1 || plugins[0].Name != "local" {
+		t.Fatalf("Expected the local plugin from gdqlint.cfg, got %v", plugins)
+	}
+
+	// Executables shipped with the project only run after opting in
+	t.Setenv(projectPluginsEnv, "")
+	if path, err := plugins[0].executable(); err == nil {
+		t.Errorf("Expected the project executable to be ignored, got %s", path)
+	}
+	t.Setenv(projectPluginsEnv, "1")
+	if path, err := plugins[0].executable(); err != nil || path != filepath.Join(root, "gdq-local") {
+		t.Errorf("Expected the project executable with %s=1, got %s (%v)", projectPluginsEnv, path, err)
+	}
+}
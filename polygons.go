@@ -0,0 +1,241 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// polygonResourceTypes are the resource types the polygons command summarizes
+var polygonResourceTypes = map[string]bool{"NavigationPolygon": true, "OccluderPolygon2D": true}
+
+// packedArrayRe matches the packed arrays of polygon data: PackedVector2Array(...) and
+// PackedInt32Array(...) in Godot 4, PoolVector2Array( ... ) and PoolIntArray( ... ) in Godot 3
+var packedArrayRe = regexp.MustCompile(`(?:Packed|Pool)\w*Array\s*\(([^)]*)\)`)
+
+// PolygonResource is a NavigationPolygon or OccluderPolygon2D found in a scene or resource file
+type PolygonResource struct {
+	ID      string
+	Type    string
+	Line    int // line of the section header
+	Section *sceneSection
+}
+
+// PolygonSummary holds the counts and bounds of polygon data
+type PolygonSummary struct {
+	Vertices      int
+	Polygons      int // navigation polygons (indices into the vertices)
+	Outlines      int
+	OutlinePoints int
+	Closed        bool // occluders only
+	MinX, MinY    float64
+	MaxX, MaxY    float64
+}
+
+// findPolygonResources returns the polygon resources of a scene or resource file,
+// the [resource] section of a .tres file included under the id "resource"
+func findPolygonResources(text *sceneText) []*PolygonResource {
+	mainType := ""
+	var resources []*PolygonResource
+	line := len(text.Preamble) + 1
+	for _, section := range text.Sections {
+		sectionLine := line
+		line += 1 + len(section.Lines)
+
+		resourceType := ""
+		if matches := sectionTypeRe.FindStringSubmatch(section.Header); matches != nil {
+			resourceType = matches[1]
+		}
+
+		switch {
+		case strings.HasPrefix(section.Header, "[gd_resource"):
+			mainType = resourceType
+		case strings.HasPrefix(section.Header, "[resource]"):
+			if polygonResourceTypes[mainType] {
+				resources = append(resources, &PolygonResource{ID: mainResourceID, Type: mainType, Line: sectionLine, Section: section})
+			}
+		case strings.HasPrefix(section.Header, "[sub_resource"):
+			matches := sectionIDRe.FindStringSubmatch(section.Header)
+			if matches != nil && polygonResourceTypes[resourceType] {
+				resources = append(resources, &PolygonResource{ID: matches[1] + matches[2], Type: resourceType, Line: sectionLine, Section: section})
+			}
+		}
+	}
+	return resources
+}
+
+// packedArrays returns the numbers of every packed array in a value, which
+// is a single array or an array of them
+func packedArrays(value string) [][]float64 {
+	var arrays [][]float64
+	for _, matches := range packedArrayRe.FindAllStringSubmatch(value, -1) {
+		arrays = append(arrays, parseNumberList(matches[1]))
+	}
+	return arrays
+}
+
+// addPoints extends the bounds with the x, y pairs of numbers
+func (s *PolygonSummary) addPoints(numbers []float64) {
+	for i := 0; i+1 < len(numbers); i += 2 {
+		s.MinX, s.MaxX = math.Min(s.MinX, numbers[i]), math.Max(s.MaxX, numbers[i])
+		s.MinY, s.MaxY = math.Min(s.MinY, numbers[i+1]), math.Max(s.MaxY, numbers[i+1])
+	}
+}
+
+// summarize decodes the vertices, polygons and outlines of a polygon resource
+func (r *PolygonResource) summarize() *PolygonSummary {
+	s := &PolygonSummary{MinX: math.Inf(1), MinY: math.Inf(1), MaxX: math.Inf(-1), MaxY: math.Inf(-1)}
+
+	vertexProperty := "vertices"
+	if r.Type == "OccluderPolygon2D" {
+		vertexProperty = "polygon"
+		s.Closed = true
+		if closed, exists := r.Section.Property("closed"); exists {
+			s.Closed = strings.TrimSpace(closed) != "false"
+		}
+	}
+
+	if value, exists := r.Section.Property(vertexProperty); exists {
+		for _, numbers := range packedArrays(value) {
+			s.Vertices += len(numbers) / 2
+			s.addPoints(numbers)
+		}
+	}
+	if value, exists := r.Section.Property("polygons"); exists {
+		s.Polygons = len(packedArrays(value))
+	}
+	if value, exists := r.Section.Property("outlines"); exists {
+		for _, numbers := range packedArrays(value) {
+			s.Outlines++
+			s.OutlinePoints += len(numbers) / 2
+			s.addPoints(numbers)
+		}
+	}
+	return s
+}
+
+// emptyReason explains why a polygon resource does nothing, or returns "" when it is usable
+func (r *PolygonResource) emptyReason(s *PolygonSummary) string {
+	if r.Type == "OccluderPolygon2D" {
+		minimum := 2
+		if s.Closed {
+			minimum = 3
+		}
+		if s.Vertices < minimum {
+			return fmt.Sprintf("has %d point(s), needs at least %d", s.Vertices, minimum)
+		}
+		return ""
+	}
+	if s.Polygons == 0 || s.Vertices == 0 {
+		if s.Outlines > 0 {
+			return "has outlines but no polygons (never baked)"
+		}
+		return "has no polygons"
+	}
+	return ""
+}
+
+// String describes the summary in one line
+func (s *PolygonSummary) String(resourceType string) string {
+	var parts []string
+	parts = append(parts, fmt.Sprintf("%d vertices", s.Vertices))
+	if resourceType == "NavigationPolygon" {
+		parts = append(parts, fmt.Sprintf("%d polygon(s)", s.Polygons),
+			fmt.Sprintf("%d outline(s) (%d points)", s.Outlines, s.OutlinePoints))
+	} else if s.Closed {
+		parts = append(parts, "closed")
+	} else {
+		parts = append(parts, "open")
+	}
+	if s.MinX <= s.MaxX {
+		parts = append(parts, fmt.Sprintf("bounds (%s, %s)-(%s, %s)",
+			formatLayoutNumber(s.MinX), formatLayoutNumber(s.MinY), formatLayoutNumber(s.MaxX), formatLayoutNumber(s.MaxY)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printPolygonResources displays the summaries of the polygon resources of a file
+func printPolygonResources(out io.Writer, resources []*PolygonResource) {
+	if len(resources) == 0 {
+		fmt.Fprintln(out, "No navigation or occluder polygons")
+		return
+	}
+	for _, resource := range resources {
+		summary := resource.summarize()
+		fmt.Fprintf(out, "%s %s: %s", resource.Type, resource.ID, summary.String(resource.Type))
+		if reason := resource.emptyReason(summary); reason != "" {
+			fmt.Fprintf(out, " [EMPTY: %s]", reason)
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// checkEmptyPolygons reports polygon resources that do nothing
+func checkEmptyPolygons(ctx *LintContext) []LintFinding {
+	files, err := findProjectFiles(ctx.Dir, sceneExtensions)
+	if err != nil {
+		logger.Warn("Scene scan failed", "error", err)
+		return nil
+	}
+
+	var findings []LintFinding
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			logger.Warn("Skipping file", "path", file, "error", err)
+			continue
+		}
+		for _, resource := range findPolygonResources(splitSceneText(string(content))) {
+			if reason := resource.emptyReason(resource.summarize()); reason != "" {
+				findings = append(findings, LintFinding{
+					File:    fsToRes(ctx.Root, file),
+					Line:    resource.Line,
+					Message: fmt.Sprintf("%s %s %s", resource.Type, resource.ID, reason),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+var polygonsCmd = &cobra.Command{
+	Use:   "polygons <scene or resource file> [more files...]",
+	Short: "Summarize NavigationPolygon and OccluderPolygon2D resources",
+	Long: `Decode the NavigationPolygon and OccluderPolygon2D resources of scenes and .tres files and print
+their vertex, polygon and outline counts and bounding boxes. Polygons that do nothing (never
+baked, too few points) are marked EMPTY; the empty-polygon lint rule reports them project-wide.`,
+	Example:      `  gdq polygons levels/level_1.tscn`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		for i, file := range args {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("read error: %v", err)
+			}
+			if len(args) > 1 {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				fmt.Fprintf(out, "=== %s ===\n", file)
+			}
+			printPolygonResources(out, findPolygonResources(splitSceneText(string(content))))
+		}
+		return nil
+	},
+}
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "empty-polygon",
+		Description: "NavigationPolygon without polygons (never baked) and OccluderPolygon2D with too few points, which do nothing but are still processed",
+		Check:       checkEmptyPolygons,
+	})
+	rootCmd.AddCommand(polygonsCmd)
+}
@@ -0,0 +1,63 @@
+package gdquery
+
+import (
+	"testing"
+)
+
+const polygonScene = `[gd_scene load_steps=4 format=3]
+
+[sub_resource type="NavigationPolygon" id="NavigationPolygon_a"]
+vertices = PackedVector2Array(0, 0, 640, 0, 640, 480, 0, 480)
+polygons = [PackedInt32Array(0, 1, 2), PackedInt32Array(0, 2, 3)]
+outlines = [PackedVector2Array(-10, -10, 650, -10, 650, 490, -10, 490)]
+
+[sub_resource type="NavigationPolygon" id="NavigationPolygon_unbaked"]
+outlines = [PackedVector2Array(0, 0, 10, 0, 10, 10)]
+
+[sub_resource type="OccluderPolygon2D" id="OccluderPolygon2D_line"]
+closed = false
+polygon = PackedVector2Array(-5, 2, 5, 2)
+
+[node name="Level" type="Node2D"]
+`
+
+func TestPolygonSummaries(t *testing.T) {
+	resources := findPolygonResources(splitSceneText(polygonScene))
+	if len(resources) != 3 {
+		t.Fatalf("Expected 3 polygon resources, got %d", len(resources))
+	}
+
+	expected := []struct {
+		summary string
+		empty   string
+	}{
+		{"4 vertices, 2 polygon(s), 1 outline(s) (4 points), bounds (-10, -10)-(650, 490)", ""},
+		{"0 vertices, 0 polygon(s), 1 outline(s) (3 points), bounds (0, 0)-(10, 10)", "has outlines but no polygons (never baked)"},
+		{"2 vertices, open, bounds (-5, 2)-(5, 2)", ""},
+	}
+	for i, resource := range resources {
+		summary := resource.summarize()
+		if got := summary.String(resource.Type); got != expected[i].summary {
+			t.Errorf("%s: unexpected summary: %s", resource.ID, got)
+		}
+		if got := resource.emptyReason(summary); got != expected[i].empty {
+			t.Errorf("%s: unexpected empty reason: %q", resource.ID, got)
+		}
+	}
+	if resources[1].Line != 8 {
+		t.Errorf("Expected the unbaked polygon on line 8, got %d", resources[1].Line)
+	}
+
+	// Godot 3 occluder, closed by default
+	godot3 := findPolygonResources(splitSceneText(`[gd_resource type="OccluderPolygon2D" format=2]
+
+[resource]
+polygon = PoolVector2Array( 0, 0, 10, 0 )
+`))
+	if len(godot3) != 1 || godot3[0].ID != "resource" {
+		t.Fatalf("Expected the main resource, got %v", godot3)
+	}
+	if got := godot3[0].emptyReason(godot3[0].summarize()); got != "has 2 point(s), needs at least 3" {
+		t.Errorf("Unexpected empty reason: %q", got)
+	}
+}
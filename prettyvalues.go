@@ -0,0 +1,128 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// rawValues disables pretty printing of property values in the tree
+var rawValues = false
+
+// radianProperties are the properties stored in radians
+var radianProperties = map[string]bool{"rotation": true, "skew": true}
+
+// largeNumberMin is the magnitude from which numbers get thousands separators
+const largeNumberMin = 10000
+
+var (
+	plainNumberRe = regexp.MustCompile(`^-?\d+(?:\.\d+)?$`)
+	vectorValueRe = regexp.MustCompile(`^(Vector[23])\s*\(([^)]*)\)$`)
+	colorValueRe  = regexp.MustCompile(`^Color\s*\(([^)]*)\)$`)
+)
+
+// truecolorTerminal reports whether out is a terminal announcing 24-bit color
+// support (COLORTERM), for color swatches
+func truecolorTerminal(out io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if colorTerm := os.Getenv("COLORTERM"); colorTerm != "truecolor" && colorTerm != "24bit" {
+		return false
+	}
+	return isTerminal(out)
+}
+
+// formatDegrees converts radians to degrees rounded to two decimals
+func formatDegrees(radians float64) string {
+	degrees := math.Round(radians*180/math.Pi*100) / 100
+	if degrees == 0 {
+		degrees = 0 // no "-0"
+	}
+	return formatLayoutNumber(degrees) + "°"
+}
+
+// formatThousands inserts thousands separators into a plain decimal number
+func formatThousands(value string) string {
+	sign := ""
+	if strings.HasPrefix(value, "-") {
+		sign, value = "-", value[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(value, ".")
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if hasFraction {
+		b.WriteString("." + fraction)
+	}
+	return sign + b.String()
+}
+
+// colorValue formats Color(r, g, b[, a]) components as hex, prefixed with a
+// swatch on truecolor terminals. HDR colors (components above 1) have no hex form.
+func colorValue(components []float64, swatch bool) (string, bool) {
+	if len(components) < 3 || len(components) > 4 {
+		return "", false
+	}
+	for _, c := range components {
+		if c < 0 || c > 1 {
+			return "", false
+		}
+	}
+	alpha := 1.0
+	if len(components) == 4 {
+		alpha = components[3]
+	}
+	hex := formatColor(components[0], components[1], components[2], alpha)
+	if swatch {
+		channel := func(f float64) int { return int(math.Round(f * 255)) }
+		hex = fmt.Sprintf("\x1b[38;2;%d;%d;%dm██\x1b[0m %s", channel(components[0]), channel(components[1]), channel(components[2]), hex)
+	}
+	return hex, true
+}
+
+// prettyValue formats a property value for human output: rotations in
+// degrees, colors as hex (with a swatch when swatch is set, on truecolor
+// terminals) and large numbers with thousands separators. --raw keeps the
+// value as stored.
+func prettyValue(prop, value string, swatch bool) string {
+	if rawValues {
+		return value
+	}
+
+	if radianProperties[prop] {
+		if isVariantNumber(value) {
+			radians, _ := parseVariantFloat(value)
+			return formatDegrees(radians)
+		}
+		if matches := vectorValueRe.FindStringSubmatch(value); matches != nil {
+			var parts []string
+			for _, radians := range parseNumberList(matches[2]) {
+				parts = append(parts, formatDegrees(radians))
+			}
+			return fmt.Sprintf("%s(%s)", matches[1], strings.Join(parts, ", "))
+		}
+	}
+
+	if matches := colorValueRe.FindStringSubmatch(value); matches != nil {
+		if hex, ok := colorValue(parseNumberList(matches[1]), swatch); ok {
+			return hex
+		}
+		return value
+	}
+
+	if plainNumberRe.MatchString(value) {
+		if f, err := strconv.ParseFloat(value, 64); err == nil && math.Abs(f) >= largeNumberMin {
+			return formatThousands(value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,42 @@
+package gdquery
+
+import "testing"
+
+func TestPrettyValue(t *testing.T) {
+	tests := []struct {
+		prop     string
+		value    string
+		expected string
+	}{
+		{"rotation", "0.785398", "45°"},
+		{"rotation", "-1.5708", "-90°"},
+		{"rotation", "Vector3(0, 3.14159, -0)", "Vector3(0°, 180°, 0°)"},
+		{"skew", "0.0", "0°"},
+		{"rotation_degrees", "45.0", "45.0"},
+		{"modulate", "Color(0.2, 0.4, 0.6, 1)", "#336699"},
+		{"modulate", "Color( 1, 0, 0, 0.5 )", "#ff000080"},
+		{"modulate", "Color(2, 1, 1, 1)", "Color(2, 1, 1, 1)"},
+		{"max_value", "1000000", "1,000,000"},
+		{"offset", "-12345.5", "-12,345.5"},
+		{"z_index", "4096", "4096"},
+		{"text", "\"12345\"", "\"12345\""},
+	}
+	for _, test := range tests {
+		if got := prettyValue(test.prop, test.value, false); got != test.expected {
+			t.Errorf("prettyValue(%s, %s) = %q, expected %q", test.prop, test.value, got, test.expected)
+		}
+	}
+
+	rawValues = true
+	defer func() { rawValues = false }()
+	if got := prettyValue("rotation", "0.785398", false); got != "0.785398" {
+		t.Errorf("Expected the raw value with --raw, got %q", got)
+	}
+}
+
+func TestColorValueSwatch(t *testing.T) {
+	hex, ok := colorValue([]float64{1, 0.5, 0}, true)
+	if !ok || hex != "\x1b[38;2;255;128;0m██\x1b[0m #ff8000" {
+		t.Errorf("Unexpected swatch: %q", hex)
+	}
+}
@@ -0,0 +1,88 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Progress option
+var noProgress = false
+
+// progressOutput is the stderr of the running command, set with the logger
+var progressOutput io.Writer = os.Stderr
+
+// progressInterval is the minimum time between two redraws of a progress bar
+const progressInterval = 100 * time.Millisecond
+
+// progressBarWidth is the width of the bar between the brackets
+const progressBarWidth = 30
+
+// Progress draws the progress of a scan over many files on stderr, with the
+// rate and the estimated time left. It is only drawn when stderr is a terminal
+// and --no-progress is not set, and is not safe for concurrent use.
+type Progress struct {
+	out     io.Writer // nil when the progress is not drawn
+	label   string
+	total   int
+	done    int
+	start   time.Time
+	drawn   time.Time
+	visible bool
+	now     func() time.Time
+}
+
+// newProgress starts the progress of a scan of total files
+func newProgress(label string, total int) *Progress {
+	p := &Progress{label: label, total: total, start: time.Now(), now: time.Now}
+	if !noProgress && total > 1 && isTerminal(progressOutput) {
+		p.out = progressOutput
+	}
+	return p
+}
+
+// Step counts one more file and redraws the bar, at most every progressInterval
+func (p *Progress) Step() {
+	p.done++
+	if p.out == nil {
+		return
+	}
+	now := p.now()
+	if p.done < p.total && now.Sub(p.drawn) < progressInterval {
+		return
+	}
+	p.drawn = now
+	fmt.Fprint(p.out, "\r"+p.line(now)+"\x1b[K")
+	p.visible = true
+}
+
+// line formats the bar, the count, the rate and the estimated time left
+func (p *Progress) line(now time.Time) string {
+	filled := 0
+	if p.total > 0 {
+		filled = min(p.done, p.total) * progressBarWidth / p.total
+	}
+	line := fmt.Sprintf("%s [%s%s] %d/%d", p.label, strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled), p.done, p.total)
+
+	elapsed := now.Sub(p.start).Seconds()
+	if elapsed <= 0 || p.done == 0 {
+		return line
+	}
+	rate := float64(p.done) / elapsed
+	line += fmt.Sprintf("  %.1f files/s", rate)
+	if p.done < p.total {
+		left := time.Duration(float64(p.total-p.done) / rate * float64(time.Second))
+		line += "  ETA " + left.Round(time.Second).String()
+	}
+	return line
+}
+
+// Clear erases the bar, before printing to the terminal and when the scan is done
+func (p *Progress) Clear() {
+	if p.visible {
+		fmt.Fprint(p.out, "\r\x1b[K")
+		p.visible = false
+	}
+}
@@ -0,0 +1,48 @@
+package gdquery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	p := &Progress{out: &out, label: "Scanning", total: 100, start: start, now: func() time.Time { return now }}
+
+	// The first step draws, the next ones within the interval do not
+	now = start.Add(time.Second)
+	p.Step()
+	p.Step()
+	if got := strings.Count(out.String(), "\r"); got != 1 {
+		t.Errorf("Expected 1 redraw, got %d: %q", got, out.String())
+	}
+
+	for i := 0; i < 48; i++ {
+		p.Step()
+	}
+	now = start.Add(5 * time.Second)
+	p.Step()
+	want := "\rScanning [###############---------------] 51/100  10.2 files/s  ETA 5s\x1b[K"
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("Unexpected progress line: %q", out.String())
+	}
+
+	out.Reset()
+	p.Clear()
+	p.Clear()
+	if out.String() != "\r\x1b[K" {
+		t.Errorf("Expected one clear, got %q", out.String())
+	}
+
+	// Without a terminal nothing is drawn
+	p = newProgress("Scanning", 10)
+	p.Step()
+	p.Clear()
+	if p.visible || p.done != 1 {
+		t.Errorf("Expected a hidden progress counting steps")
+	}
+}
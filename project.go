@@ -0,0 +1,261 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sceneExtensions lists the file extensions treated as Godot text scenes/resources.
+// .escn is the text scene format written by the Blender exporter.
+var sceneExtensions = []string{".tscn", ".escn", ".tres"}
+
+// nodeSceneExtensions lists the scene formats that contain a node tree
+var nodeSceneExtensions = []string{".tscn", ".escn"}
+
+// findProjectRoot walks up from path looking for project.godot.
+// If none is found, the directory of path itself is used as root.
+func findProjectRoot(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+
+	dir := abs
+	if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+		dir = filepath.Dir(abs)
+	}
+
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, "project.godot")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	return dir
+}
+
+// resToFS converts a res:// path into a filesystem path under root
+func resToFS(root, resPath string) string {
+	rel := strings.TrimPrefix(resPath, "res://")
+	return filepath.Join(root, filepath.FromSlash(rel))
+}
+
+// fsToRes converts a filesystem path under root into a res:// path
+func fsToRes(root, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		rel = path
+	}
+	if rel == "." {
+		return "res://"
+	}
+	return "res://" + filepath.ToSlash(rel)
+}
+
+// resDir returns the res:// directory containing resPath
+func resDir(resPath string) string {
+	rel := strings.TrimPrefix(resPath, "res://")
+	if i := strings.LastIndex(rel, "/"); i >= 0 {
+		return "res://" + rel[:i]
+	}
+	return "res://"
+}
+
+// normalizeResPath turns a resource reference found in fromRes into an absolute res:// path.
+// Godot 3 allowed paths relative to the referencing file.
+func normalizeResPath(fromRes, ref string) string {
+	if strings.HasPrefix(ref, "res://") || strings.HasPrefix(ref, "uid://") {
+		return ref
+	}
+	dir := strings.TrimPrefix(fromRes, "res://")
+	if i := strings.LastIndex(dir, "/"); i >= 0 {
+		dir = dir[:i]
+	} else {
+		dir = ""
+	}
+	return "res://" + strings.TrimPrefix(filepath.ToSlash(filepath.Clean(filepath.Join(dir, ref))), "./")
+}
+
+// hasExtension reports whether path ends with one of the given extensions
+func hasExtension(path string, exts []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// findProjectFiles collects files with the given extensions under root,
+// skipping hidden directories such as .godot and .git.
+// Symlinked directories are followed once (Godot exports follow them too).
+func findProjectFiles(root string, exts []string) ([]string, error) {
+	var files []string
+	visited := make(map[string]bool)
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			if visited[real] {
+				return nil
+			}
+			visited[real] = true
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			isDir := entry.IsDir()
+			if entry.Type()&os.ModeSymlink != 0 {
+				info, err := os.Stat(path)
+				if err != nil {
+					logger.Debug("Skipping broken symlink", "path", path)
+					continue
+				}
+				isDir = info.IsDir()
+			}
+
+			if isDir {
+				if strings.HasPrefix(entry.Name(), ".") {
+					continue
+				}
+				if err := walk(path); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if hasExtension(path, exts) {
+				files = append(files, path)
+			}
+		}
+
+		return nil
+	}
+
+	err := walk(root)
+	sort.Strings(files)
+	return files, err
+}
+
+// resolveResCase checks resPath segment by segment against the actual file names.
+// It returns the path with the on-disk casing and whether the file exists at all;
+// the two paths differ when the reference only works on case-insensitive filesystems.
+func resolveResCase(root, resPath string) (string, bool) {
+	segments := strings.Split(strings.TrimPrefix(resPath, "res://"), "/")
+	dir := root
+	actual := make([]string, 0, len(segments))
+
+	for _, segment := range segments {
+		if segment == "" || segment == "." {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return resPath, false
+		}
+
+		match := ""
+		for _, entry := range entries {
+			if entry.Name() == segment {
+				match = segment
+				break
+			}
+			if match == "" && strings.EqualFold(entry.Name(), segment) {
+				match = entry.Name()
+			}
+		}
+		if match == "" {
+			return resPath, false
+		}
+
+		actual = append(actual, match)
+		dir = filepath.Join(dir, match)
+	}
+
+	// Follow symlinks to make sure the target exists
+	if _, err := os.Stat(dir); err != nil {
+		return resPath, false
+	}
+
+	return "res://" + strings.Join(actual, "/"), true
+}
+
+// globFiles expands a glob pattern supporting "**" (any number of directories)
+func globFiles(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+
+	// Walk from the longest prefix without wildcards
+	baseLen := 0
+	for baseLen < len(segments)-1 && !strings.ContainsAny(segments[baseLen], "*?[") {
+		baseLen++
+	}
+	base := strings.Join(segments[:baseLen], "/")
+	if base == "" {
+		base = "."
+		if strings.HasPrefix(pattern, "/") {
+			base = "/"
+		}
+	}
+	rest := segments[baseLen:]
+
+	var files []string
+	err := filepath.Walk(filepath.FromSlash(base), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(base), path)
+		if err != nil {
+			return nil
+		}
+		if globSegmentsMatch(rest, strings.Split(filepath.ToSlash(rel), "/")) {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	sort.Strings(files)
+	return files, err
+}
+
+// globSegmentsMatch matches path segments against pattern segments
+func globSegmentsMatch(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if globSegmentsMatch(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := filepath.Match(pattern[0], segments[0]); !matched {
+		return false
+	}
+	return globSegmentsMatch(pattern[1:], segments[1:])
+}
@@ -0,0 +1,184 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gdquery/query"
+	"github.com/spf13/cobra"
+)
+
+// Props command options
+var propsType = ""
+var propsFilter = ""
+
+// propsOutlierNodes is the largest number of nodes sharing a value that the
+// text report lists, so that the odd ones out can be found
+const propsOutlierNodes = 3
+
+// PropertyValueUse is one distinct value of a property and the nodes using it
+type PropertyValueUse struct {
+	Value string   `json:"value"`
+	Count int      `json:"count"`
+	Nodes []string `json:"nodes"` // "res://scene.tscn:Node/Path"
+}
+
+// PropertyPresence is a property overridden on nodes of the audited type
+type PropertyPresence struct {
+	Name   string              `json:"name"`
+	Nodes  int                 `json:"nodes"`
+	Values []*PropertyValueUse `json:"values"`
+}
+
+// PropertyReport is the property presence of all nodes of a type across a project
+type PropertyReport struct {
+	Type       string              `json:"type"`
+	Nodes      int                 `json:"nodes"`
+	Scenes     int                 `json:"scenes"`
+	Properties []*PropertyPresence `json:"properties"`
+}
+
+// buildPropertyReport collects the overridden properties of the nodes of class
+// name in the scanned scenes. Values equal to the class default and editor-only
+// metadata are left out; filter is a wildcard pattern on property names.
+func buildPropertyReport(results []*SceneScanResult, name, filter string) *PropertyReport {
+	report := &PropertyReport{Type: name, Properties: []*PropertyPresence{}}
+	properties := make(map[string]*PropertyPresence)
+	values := make(map[string]map[string]*PropertyValueUse)
+
+	for _, result := range results {
+		if result.Err != nil {
+			logger.Warn("Skipping scene", "path", result.File, "error", result.Err)
+			continue
+		}
+		resolveScriptClasses(result.Scene)
+		nodes := findNodesOfType(result.Scene.RootNode, name)
+		if len(nodes) == 0 {
+			continue
+		}
+		report.Scenes++
+		report.Nodes += len(nodes)
+
+		for _, node := range nodes {
+			for prop, value := range node.Properties {
+				if strings.HasPrefix(prop, "metadata/_edit_") || isDefaultValue(node.Type, prop, value) {
+					continue
+				}
+				if filter != "" && !query.Wildcard(filter, prop) {
+					continue
+				}
+				presence := properties[prop]
+				if presence == nil {
+					presence = &PropertyPresence{Name: prop}
+					properties[prop] = presence
+					values[prop] = make(map[string]*PropertyValueUse)
+					report.Properties = append(report.Properties, presence)
+				}
+				use := values[prop][value]
+				if use == nil {
+					use = &PropertyValueUse{Value: value}
+					values[prop][value] = use
+					presence.Values = append(presence.Values, use)
+				}
+				presence.Nodes++
+				use.Count++
+				use.Nodes = append(use.Nodes, result.File+":"+node.Path)
+			}
+		}
+	}
+
+	// Most used properties and values first
+	sort.Slice(report.Properties, func(i, j int) bool {
+		a, b := report.Properties[i], report.Properties[j]
+		if a.Nodes != b.Nodes {
+			return a.Nodes > b.Nodes
+		}
+		return a.Name < b.Name
+	})
+	for _, presence := range report.Properties {
+		sort.Slice(presence.Values, func(i, j int) bool {
+			a, b := presence.Values[i], presence.Values[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.Value < b.Value
+		})
+		for _, use := range presence.Values {
+			sort.Strings(use.Nodes)
+		}
+	}
+	return report
+}
+
+// printPropertyReport displays the properties with their distinct values. The
+// nodes of rare values are listed.
+func printPropertyReport(out io.Writer, report *PropertyReport) {
+	fmt.Fprintf(out, "%d %s node(s) in %d scene(s)\n", report.Nodes, report.Type, report.Scenes)
+	if len(report.Properties) == 0 {
+		fmt.Fprintln(out, "No overridden properties")
+		return
+	}
+	for _, presence := range report.Properties {
+		fmt.Fprintf(out, "\n%s: %d node(s), %d distinct value(s)\n", presence.Name, presence.Nodes, len(presence.Values))
+		for _, use := range presence.Values {
+			fmt.Fprintf(out, "  %s  x%d", strings.ReplaceAll(use.Value, "\n", `\n`), use.Count)
+			if use.Count <= propsOutlierNodes && len(presence.Values) > 1 {
+				fmt.Fprintf(out, "  %s", strings.Join(use.Nodes, ", "))
+			}
+			fmt.Fprintln(out)
+		}
+	}
+}
+
+var propsCmd = &cobra.Command{
+	Use:   "props --type <class> [dir]",
+	Short: "Report the properties overridden on all nodes of a type across a project",
+	Long: `For every node of a type (including subclasses and script classes) in the scenes under dir,
+collect the properties that differ from the class default and report how many nodes override
+each one and with which distinct values, most used first. Values used by few nodes list them,
+to find the odd ones out when standardizing e.g. the font sizes of every Button. --prop limits
+the report to matching property names (* and ? wildcards).`,
+	Example: `  gdq props --type Button .
+  gdq props --type Button --prop 'theme_override_font_sizes/*' ui/`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		if propsType == "" {
+			return fmt.Errorf("--type is required")
+		}
+
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		results, err := scanProject(findProjectRoot(dir), dir, ParseOptions{Logger: logger})
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+		report := buildPropertyReport(results, propsType, propsFilter)
+
+		if outputFormat == "json" {
+			return writeJSON(out, report)
+		}
+		printPropertyReport(out, report)
+		return nil
+	},
+}
+
+func init() {
+	propsCmd.Flags().StringVar(&propsType, "type", "", "Class of the audited nodes, including subclasses and script classes")
+	propsCmd.RegisterFlagCompletionFunc("type", completeClassName)
+	propsCmd.Flags().StringVar(&propsFilter, "prop", "", "Report only the properties matching this pattern (e.g. 'theme_override_*')")
+	rootCmd.AddCommand(propsCmd)
+}
@@ -0,0 +1,82 @@
+package gdquery
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPropertyReport(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"ui/menu.tscn": `[gd_scene format=3]
+
+[node name="Menu" type="Control"]
+
+[node name="Start" type="Button" parent="."]
+theme_override_font_sizes/font_size = 24
+text = "Start"
+metadata/_edit_lock_ = true
+
+[node name="Quit" type="Button" parent="."]
+theme_override_font_sizes/font_size = 24
+text = "Quit"
+flat = false
+
+[node name="Mute" type="CheckBox" parent="."]
+theme_override_font_sizes/font_size = 18
+
+[node name="Title" type="Label" parent="."]
+theme_override_font_sizes/font_size = 40
+`,
+		"ui/shop.tscn": `[gd_scene format=3]
+
+[node name="Shop" type="Control"]
+
+[node name="Buy" type="Button" parent="."]
+theme_override_font_sizes/font_size = 24
+`,
+	})
+
+	results, err := scanProject(root, root, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+
+	// CheckBox is a Button; flat = false is the class default
+	report := buildPropertyReport(results, "Button", "")
+	if report.Nodes != 4 || report.Scenes != 2 {
+		t.Errorf("Expected 4 nodes in 2 scenes, got %d in %d", report.Nodes, report.Scenes)
+	}
+	var got []string
+	for _, presence := range report.Properties {
+		for _, use := range presence.Values {
+			got = append(got, presence.Name+"="+use.Value+" "+strings.Join(use.Nodes, ","))
+		}
+	}
+	expected := []string{
+		"theme_override_font_sizes/font_size=24 res://ui/menu.tscn:Menu/Quit,res://ui/menu.tscn:Menu/Start,res://ui/shop.tscn:Shop/Buy",
+		"theme_override_font_sizes/font_size=18 res://ui/menu.tscn:Menu/Mute",
+		`text="Quit" res://ui/menu.tscn:Menu/Quit`,
+		`text="Start" res://ui/menu.tscn:Menu/Start`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected report:\n%s", strings.Join(got, "\n"))
+	}
+
+	report = buildPropertyReport(results, "Button", "theme_override_*")
+	if len(report.Properties) != 1 {
+		t.Errorf("Expected only the font size, got %d properties", len(report.Properties))
+	}
+
+	output := captureOutput(func(out io.Writer) { printPropertyReport(out, report) })
+	for _, want := range []string{
+		"4 Button node(s) in 2 scene(s)",
+		"theme_override_font_sizes/font_size: 4 node(s), 2 distinct value(s)",
+		"  24  x3  res://ui/menu.tscn:Menu/Quit, ",
+		"  18  x1  res://ui/menu.tscn:Menu/Mute\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package gdquery
+
+import (
+	"gdquery/query"
+)
+
+// queryNode is a GodotNode as seen by the terms of a query, bound to its
+// scene to resolve the resource references of script= and instance= terms
+type queryNode struct {
+	node  *GodotNode
+	scene *GodotScene
+}
+
+// QueryNode adapts node of scene to query.Node, for matching it against a
+// compiled --query expression
+func QueryNode(node *GodotNode, scene *GodotScene) query.Node {
+	return queryNode{node: node, scene: scene}
+}
+
+func (n queryNode) Path() string { return n.node.Path }
+
+func (n queryNode) Name() string { return n.node.OriginalName }
+
+func (n queryNode) Types() []string {
+	return append([]string{n.node.Type}, n.node.scriptClassChain...)
+}
+
+func (n queryNode) Script() string {
+	if n.node.Script == "" {
+		return ""
+	}
+	return resolveResourcePath(n.node.Script, n.scene)
+}
+
+func (n queryNode) Instance() string {
+	if n.node.Instance == "" {
+		return ""
+	}
+	return resolveResourcePath(n.node.Instance, n.scene)
+}
+
+func (n queryNode) Property(name string) ([]string, bool) {
+	value, exists := n.node.Properties[name]
+	if !exists {
+		return nil, false
+	}
+	return []string{value, unquoteValue(value)}, true
+}
+
+// findNodesByQuery returns all nodes of the scene matching the query, in file order
+func findNodesByQuery(scene *GodotScene, matcher query.Matcher) []*GodotNode {
+	var nodes []*GodotNode
+	for _, node := range scene.AllNodes {
+		if matcher.Match(QueryNode(node, scene)) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
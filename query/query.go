@@ -0,0 +1,149 @@
+// Package query compiles the node query expressions of the --query flags of
+// gdq, such as "type=Label,name=Title*", and matches scene nodes against them.
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node is a scene node as seen by query terms
+type Node interface {
+	// Path is the node path from the scene root, root name included ("Menu/Box/Title")
+	Path() string
+	// Name is the node name as written in the scene file
+	Name() string
+	// Types are the built-in type of the node, then its script class and the
+	// script classes it extends
+	Types() []string
+	// Script and Instance are the res:// paths of the attached script and of
+	// the instanced scene, "" when the node has none
+	Script() string
+	Instance() string
+	// Property returns the values a property term compares against: the value
+	// as written in the scene file and, for strings, the unquoted string.
+	// ok is false when the node does not set the property.
+	Property(name string) (values []string, ok bool)
+}
+
+// Matcher selects nodes with the semantics of the --query expressions of the CLI
+type Matcher interface {
+	Match(node Node) bool
+}
+
+// Term is a single key=value (or key!=value) condition of a query
+type Term struct {
+	Key    string
+	Value  string
+	Negate bool
+}
+
+// Query selects nodes with conditions such as "type=Label,name=Title*".
+// All terms must match. Values may contain "*" and "?" wildcards.
+// A term without "=" matches the node path like -q does (exact or suffix).
+type Query struct {
+	Terms []Term
+}
+
+// Compile parses a node query expression
+func Compile(expr string) (*Query, error) {
+	query := &Query{}
+
+	fields := strings.FieldsFunc(expr, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	for _, field := range fields {
+		term := Term{}
+		if i := strings.Index(field, "!="); i >= 0 {
+			term.Key, term.Value, term.Negate = field[:i], field[i+2:], true
+		} else if i := strings.Index(field, "="); i >= 0 {
+			term.Key, term.Value = field[:i], field[i+1:]
+		} else {
+			term.Key, term.Value = "", field
+		}
+		if term.Key == "" && strings.ContainsAny(field, "=") {
+			return nil, fmt.Errorf("invalid query term: %s", field)
+		}
+		query.Terms = append(query.Terms, term)
+	}
+
+	return query, nil
+}
+
+// Match reports whether node satisfies every term of the query
+func (q *Query) Match(node Node) bool {
+	for _, term := range q.Terms {
+		if term.Match(node) == term.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Match evaluates the condition of the term against node, ignoring Negate
+func (t Term) Match(node Node) bool {
+	switch t.Key {
+	case "":
+		path := node.Path()
+		return path == t.Value || node.Name() == t.Value ||
+			strings.HasSuffix(path, "/"+t.Value) || Wildcard(t.Value, path)
+	case "type":
+		// Script classes (class_name) count as types
+		return matchAny(t.Value, node.Types())
+	case "name":
+		return Wildcard(t.Value, node.Name())
+	case "path":
+		return Wildcard(t.Value, node.Path())
+	case "script":
+		script := node.Script()
+		return script != "" && Wildcard(t.Value, script)
+	case "instance":
+		instance := node.Instance()
+		return instance != "" && Wildcard(t.Value, instance)
+	default:
+		// Any other key is compared against the property value
+		values, ok := node.Property(t.Key)
+		return ok && matchAny(t.Value, values)
+	}
+}
+
+// matchAny reports whether one of values matches the wildcard pattern
+func matchAny(pattern string, values []string) bool {
+	for _, value := range values {
+		if Wildcard(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wildcard matches s against a Godot-style wildcard pattern, case-insensitively.
+// "*" matches any sequence (including "/") and "?" matches a single character.
+func Wildcard(pattern, s string) bool {
+	p := []rune(strings.ToLower(pattern))
+	r := []rune(strings.ToLower(s))
+
+	// matched[j] reports whether p[:i] matches r[:j]
+	matched := make([]bool, len(r)+1)
+	matched[0] = true
+	for i := 0; i < len(p); i++ {
+		next := make([]bool, len(r)+1)
+		if p[i] == '*' {
+			next[0] = matched[0]
+			for j := 1; j <= len(r); j++ {
+				next[j] = next[j-1] || matched[j]
+			}
+		} else {
+			for j := 1; j <= len(r); j++ {
+				next[j] = matched[j-1] && (p[i] == '?' || p[i] == r[j-1])
+			}
+		}
+		matched = next
+	}
+
+	return matched[len(r)]
+}
@@ -0,0 +1,90 @@
+package query
+
+import "testing"
+
+// testNode is a Node with fixed values
+type testNode struct {
+	path, name string
+	types      []string
+	script     string
+	properties map[string]string
+}
+
+func (n testNode) Path() string     { return n.path }
+func (n testNode) Name() string     { return n.name }
+func (n testNode) Types() []string  { return n.types }
+func (n testNode) Script() string   { return n.script }
+func (n testNode) Instance() string { return "" }
+
+func (n testNode) Property(name string) ([]string, bool) {
+	value, ok := n.properties[name]
+	return []string{value}, ok
+}
+
+func TestCompile(t *testing.T) {
+	node := testNode{
+		path:       "Menu/Box/Title",
+		name:       "Title",
+		types:      []string{"Label", "TitleLabel"},
+		script:     "res://ui/title.gd",
+		properties: map[string]string{"text": "Play"},
+	}
+
+	testCases := []struct {
+		expr  string
+		match bool
+	}{
+		{"type=Label", true},
+		{"type=TitleLabel", true},
+		{"type=Button", false},
+		{"type=Label,name=Tit*", true},
+		{"type=Label name=Tit*", true},
+		{"type!=Label", false},
+		{"type!=Button", true},
+		{"Box/Title", true},
+		{"Title", true},
+		{"Box", false},
+		{"path=Menu/*", true},
+		{"script=*.gd", true},
+		{"instance=*", false},
+		{"text=Pl?y", true},
+		{"font_size=16", false},
+	}
+
+	for _, tc := range testCases {
+		q, err := Compile(tc.expr)
+		if err != nil {
+			t.Fatalf("Compile error for %q: %v", tc.expr, err)
+		}
+		var matcher Matcher = q
+		if got := matcher.Match(node); got != tc.match {
+			t.Errorf("Query %q match = %v, expected %v", tc.expr, got, tc.match)
+		}
+	}
+
+	for _, expr := range []string{"", " , ", "=Label"} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Expected an error for %q", expr)
+		}
+	}
+}
+
+func TestWildcard(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"*.md", "docs/README.MD", true},
+		{"debug/*", "debug/a/b.tscn", true},
+		{"debug/*", "levels/debug.tscn", false},
+		{"level_?.tscn", "level_1.tscn", true},
+		{"level_?.tscn", "level_10.tscn", false},
+	}
+
+	for _, tc := range testCases {
+		if got := Wildcard(tc.pattern, tc.s); got != tc.match {
+			t.Errorf("Wildcard(%q, %q) = %v, expected %v", tc.pattern, tc.s, got, tc.match)
+		}
+	}
+}
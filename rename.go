@@ -0,0 +1,354 @@
+package gdquery
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Rename-all command options
+var renameMatch = ""
+var renameReplace = ""
+var renameDryRun = false
+
+// nodePathValueRe matches NodePath values in properties, including animation track paths
+var nodePathValueRe = regexp.MustCompile(`NodePath\("([^"]*)"\)`)
+
+// scriptNodeRefRe matches $Path, $"Path" and %Name node references in GDScript
+var scriptNodeRefRe = regexp.MustCompile(`[$%](?:"([^"]*)"|(\w[\w%/]*))`)
+
+// invalidNodeNameChars are the characters Godot does not allow in node names
+const invalidNodeNameChars = `.:@/"%`
+
+// NodeRenamer applies a regex rename to node names and the node paths naming them.
+// The regex must match the whole name.
+type NodeRenamer struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+// NodeRename describes one renamed node
+type NodeRename struct {
+	NodePath string
+	OldName  string
+	NewName  string
+}
+
+// ScriptReference is a node reference in a script that names a renamed node.
+// Scripts are reported, not rewritten.
+type ScriptReference struct {
+	File    string
+	Line    int
+	OldPath string
+	NewPath string
+}
+
+// RenameResult holds the changes of a bulk rename in one file
+type RenameResult struct {
+	Renames    []NodeRename
+	References int // rewritten parent, owner, connection, editable and NodePath references
+	Scripts    []ScriptReference
+}
+
+// compileNodeRenamer compiles the --match regex, anchored to whole names. The
+// replacement expands $1 and ${name} like regexp.Expand.
+func compileNodeRenamer(match, replace string) (*NodeRenamer, error) {
+	re, err := regexp.Compile(`^(?:` + match + `)$`)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeRenamer{re: re, replace: replace}, nil
+}
+
+// Name returns the new name of a node, or the name itself when it does not match
+func (r *NodeRenamer) Name(name string) string {
+	if !r.re.MatchString(name) {
+		return name
+	}
+	return r.re.ReplaceAllString(name, r.replace)
+}
+
+// Path renames every node name of a node path, keeping ".", "..", the % of
+// unique names and the :property subpath
+func (r *NodeRenamer) Path(path string) string {
+	nodePart, subname := path, ""
+	if i := strings.Index(path, ":"); i >= 0 {
+		nodePart, subname = path[:i], path[i:]
+	}
+	segments := strings.Split(nodePart, "/")
+	for i, segment := range segments {
+		switch {
+		case segment == "" || segment == "." || segment == "..":
+		case strings.HasPrefix(segment, "%"):
+			segments[i] = "%" + r.Name(segment[1:])
+		default:
+			segments[i] = r.Name(segment)
+		}
+	}
+	return strings.Join(segments, "/") + subname
+}
+
+// renameHeaderPath renames the node path held by an attribute of a section
+// header and reports whether it changed
+func (r *NodeRenamer) renameHeaderPath(section *sceneSection, key string) bool {
+	path, exists := headerAttr(section.Header, key)
+	if !exists {
+		return false
+	}
+	renamed := r.Path(path)
+	if renamed == path {
+		return false
+	}
+	section.Header = setHeaderAttr(section.Header, key, renamed)
+	return true
+}
+
+// checkRenamedSiblings reports new names that are invalid or collide with a
+// sibling. Siblings are the nodes with the same parent= attribute; only
+// collisions involving a renamed node are reported.
+func checkRenamedSiblings(scene *GodotScene, renamer *NodeRenamer) error {
+	type sibling struct {
+		node    *GodotNode
+		renamed bool
+	}
+	named := make(map[string]map[string]sibling)
+	for _, node := range scene.AllNodes {
+		name := renamer.Name(node.OriginalName)
+		renamed := name != node.OriginalName
+		if renamed && (name == "" || strings.ContainsAny(name, invalidNodeNameChars)) {
+			return fmt.Errorf("%s: invalid new name %q", headerNodePath(node), name)
+		}
+		if node.Parent == "" {
+			continue
+		}
+		siblings := named[node.Parent]
+		if siblings == nil {
+			siblings = make(map[string]sibling)
+			named[node.Parent] = siblings
+		}
+		if other, exists := siblings[name]; exists && (renamed || other.renamed) {
+			return fmt.Errorf("%s and %s would both be named %s", headerNodePath(other.node), headerNodePath(node), name)
+		}
+		siblings[name] = sibling{node: node, renamed: renamed}
+	}
+	return nil
+}
+
+// findScriptReferences returns the $ and % node references of the scripts
+// attached in scene that the renamer changes
+func findScriptReferences(scene *GodotScene, file, root string, renamer *NodeRenamer) []ScriptReference {
+	sceneRes := fsToRes(root, file)
+	checked := make(map[string]bool)
+	var references []ScriptReference
+	for _, node := range scene.AllNodes {
+		script := resolveResourcePath(node.Script, scene)
+		if script == "" || strings.HasPrefix(script, "SubResource(") || strings.HasPrefix(script, "uid://") {
+			continue
+		}
+		script = normalizeResPath(sceneRes, script)
+		if checked[script] {
+			continue
+		}
+		checked[script] = true
+
+		source, err := os.ReadFile(resToFS(root, script))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(strings.NewReader(string(source)))
+		line := 0
+		for scanner.Scan() {
+			line++
+			code := stripScriptComment(scanner.Text())
+			for _, matches := range scriptNodeRefRe.FindAllStringSubmatch(code, -1) {
+				path := matches[1] + matches[2]
+				if matches[0][0] == '%' {
+					path = "%" + path
+				}
+				if renamed := renamer.Path(path); renamed != path {
+					references = append(references, ScriptReference{File: script, Line: line, OldPath: path, NewPath: renamed})
+				}
+			}
+		}
+	}
+	return references
+}
+
+// renameNodesInFile renames the nodes of file matching the renamer and fixes the
+// node paths referencing them inside the scene. The file is only written when
+// write is true.
+func renameNodesInFile(file, root string, renamer *NodeRenamer, write bool) (*RenameResult, error) {
+	scene, err := ParseFile(file, ParseOptions{Logger: logger})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRenamedSiblings(scene, renamer); err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	text := splitSceneText(string(content))
+	nodeSections := text.nodeSections()
+	if len(nodeSections) != len(scene.AllNodes) {
+		return nil, fmt.Errorf("node sections do not match parsed nodes (%d != %d)", len(nodeSections), len(scene.AllNodes))
+	}
+
+	result := &RenameResult{}
+	for i, node := range scene.AllNodes {
+		name := renamer.Name(node.OriginalName)
+		if name == node.OriginalName {
+			continue
+		}
+		nodeSections[i].Header = setHeaderAttr(nodeSections[i].Header, "name", name)
+		result.Renames = append(result.Renames, NodeRename{NodePath: node.Path, OldName: node.OriginalName, NewName: name})
+	}
+
+	for _, section := range text.Sections {
+		switch {
+		case strings.HasPrefix(section.Header, "[node"):
+			for _, key := range []string{"parent", "owner"} {
+				if renamer.renameHeaderPath(section, key) {
+					result.References++
+				}
+			}
+		case strings.HasPrefix(section.Header, "[connection"):
+			for _, key := range []string{"from", "to"} {
+				if renamer.renameHeaderPath(section, key) {
+					result.References++
+				}
+			}
+		case strings.HasPrefix(section.Header, "[editable"):
+			if renamer.renameHeaderPath(section, "path") {
+				result.References++
+			}
+		}
+
+		for j, line := range section.Lines {
+			section.Lines[j] = nodePathValueRe.ReplaceAllStringFunc(line, func(value string) string {
+				path := nodePathValueRe.FindStringSubmatch(value)[1]
+				renamed := renamer.Path(path)
+				if renamed == path {
+					return value
+				}
+				result.References++
+				return `NodePath("` + renamed + `")`
+			})
+		}
+	}
+
+	if len(result.Renames) == 0 && result.References == 0 {
+		return result, nil
+	}
+	result.Scripts = findScriptReferences(scene, file, root, renamer)
+
+	if write {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(file, []byte(text.String()), info.Mode()); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+var renameAllCmd = &cobra.Command{
+	Use:   "rename-all <file or dir> [more files or dirs...] --match <regex> --replace <replacement>",
+	Short: "Rename nodes matching a regex across scenes and fix the references",
+	Long: `Rename every node whose whole name matches --match, using --replace with $1 or ${1}
+for the captured groups. Inside each scene the parent and owner paths, the signal
+connections, the editable children and the NodePath values (including animation tracks)
+are rewritten to the new names.
+
+Paths are rewritten by name, so nodes of instanced scenes referenced through an instance
+are only consistent when the instanced scenes are renamed in the same run. Node references
+in the attached scripts ($Name, %Name) are reported but not rewritten. Use --dry-run to
+preview the changes.`,
+	Example: `  gdq rename-all ui/ --match 'Btn(.*)' --replace 'Button$1' --dry-run
+  gdq rename-all ui/main_menu.tscn --match 'Btn(.*)' --replace 'Button$1'`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if renameMatch == "" {
+			return fmt.Errorf("--match is required")
+		}
+		renamer, err := compileNodeRenamer(renameMatch, renameReplace)
+		if err != nil {
+			return fmt.Errorf("invalid --match: %v", err)
+		}
+
+		var files []string
+		for _, arg := range args {
+			info, err := os.Stat(arg)
+			if err != nil {
+				return fmt.Errorf("file not found: %s", arg)
+			}
+			if !info.IsDir() {
+				files = append(files, arg)
+				continue
+			}
+			matches, err := findProjectFiles(arg, []string{".tscn", ".escn"})
+			if err != nil {
+				return fmt.Errorf("scan error: %v", err)
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no scenes found")
+		}
+
+		touchedFiles := 0
+		renamedNodes := 0
+		failed := 0
+		for _, file := range files {
+			result, err := renameNodesInFile(file, findProjectRoot(file), renamer, !renameDryRun)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", file, err)
+				failed++
+				continue
+			}
+			if len(result.Renames) == 0 && result.References == 0 {
+				continue
+			}
+
+			touchedFiles++
+			renamedNodes += len(result.Renames)
+			fmt.Fprintf(out, "%s: %d node(s), %d reference(s)\n", file, len(result.Renames), result.References)
+			for _, rename := range result.Renames {
+				fmt.Fprintf(out, "  %s -> %s\n", rename.NodePath, rename.NewName)
+			}
+			for _, reference := range result.Scripts {
+				fmt.Fprintf(out, "  check %s:%d: %s -> %s\n", reference.File, reference.Line, reference.OldPath, reference.NewPath)
+			}
+		}
+
+		if renameDryRun {
+			fmt.Fprintf(out, "\nWould rename %d node(s) in %d of %d file(s) (dry run)\n", renamedNodes, touchedFiles, len(files))
+		} else {
+			fmt.Fprintf(out, "\nRenamed %d node(s) in %d of %d file(s)\n", renamedNodes, touchedFiles, len(files))
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d file(s) could not be renamed", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	renameAllCmd.Flags().StringVar(&renameMatch, "match", "", "Regex matching whole node names (e.g. 'Btn(.*)')")
+	renameAllCmd.Flags().StringVar(&renameReplace, "replace", "", "Replacement name, $1 or ${1} for captured groups")
+	renameAllCmd.Flags().BoolVar(&renameDryRun, "dry-run", false, "Preview the renames without writing files")
+	rootCmd.AddCommand(renameAllCmd)
+}
@@ -0,0 +1,147 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameAllNodes(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"ui/menu.gd": `extends Control
+
+func _ready():
+	$BtnStart/Label.text = "Go" # $BtnQuit in a comment
+	%BtnQuit.grab_focus()
+`,
+		"ui/menu.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://ui/menu.gd" id="1_s"]
+
+[sub_resource type="Animation" id="Animation_1"]
+tracks/0/path = NodePath("BtnStart:modulate")
+
+[node name="Menu" type="Control"]
+script = ExtResource("1_s")
+focus_next = NodePath("BtnStart")
+
+[node name="BtnStart" type="Button" parent="."]
+focus_neighbor_bottom = NodePath("../BtnQuit")
+
+[node name="Label" type="Label" parent="BtnStart"]
+
+[node name="BtnQuit" type="Button" parent="."]
+unique_name_in_owner = true
+
+[node name="Btn" type="Button" parent="."]
+
+[connection signal="pressed" from="BtnStart" to="." method="_on_start"]
+[connection signal="pressed" from="BtnQuit" to="." method="_on_quit"]
+`,
+	})
+	menu := filepath.Join(root, "ui", "menu.tscn")
+	before, _ := os.ReadFile(menu)
+
+	renamer, err := compileNodeRenamer(`Btn(.+)`, `Button$1`)
+	if err != nil {
+		t.Fatalf("Compile error: %v", err)
+	}
+
+	// Dry run leaves the file untouched
+	result, err := renameNodesInFile(menu, root, renamer, false)
+	if err != nil {
+		t.Fatalf("Rename error: %v", err)
+	}
+	if after, _ := os.ReadFile(menu); string(after) != string(before) {
+		t.Error("Dry run modified the file")
+	}
+	// "Btn" does not match the whole regex
+	if len(result.Renames) != 2 || result.Renames[0].NodePath != "Menu/BtnStart" || result.Renames[0].NewName != "ButtonStart" {
+		t.Errorf("Unexpected renames: %+v", result.Renames)
+	}
+	// parent, 2 connections, 3 NodePaths
+	if result.References != 6 {
+		t.Errorf("Expected 6 references, got %d", result.References)
+	}
+	if len(result.Scripts) != 2 || result.Scripts[0].Line != 4 || result.Scripts[0].NewPath != "ButtonStart/Label" ||
+		result.Scripts[1].OldPath != "%BtnQuit" || result.Scripts[1].NewPath != "%ButtonQuit" {
+		t.Errorf("Unexpected script references: %+v", result.Scripts)
+	}
+
+	if _, err := renameNodesInFile(menu, root, renamer, true); err != nil {
+		t.Fatalf("Rename error: %v", err)
+	}
+	after, _ := os.ReadFile(menu)
+	for _, want := range []string{
+		`tracks/0/path = NodePath("ButtonStart:modulate")`,
+		`focus_next = NodePath("ButtonStart")`,
+		`[node name="ButtonStart" type="Button" parent="."]`,
+		`focus_neighbor_bottom = NodePath("../ButtonQuit")`,
+		`[node name="Label" type="Label" parent="ButtonStart"]`,
+		`[node name="Btn" type="Button" parent="."]`,
+		`[connection signal="pressed" from="ButtonQuit" to="." method="_on_quit"]`,
+	} {
+		if !strings.Contains(string(after), want) {
+			t.Errorf("Expected %q in:\n%s", want, after)
+		}
+	}
+	if strings.Contains(string(after), "BtnStart") {
+		t.Errorf("Old name left in:\n%s", after)
+	}
+}
+
+func TestRenameAllConflicts(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"menu.tscn": `[gd_scene format=3]
+
+[node name="Menu" type="Control"]
+
+[node name="BtnStart" type="Button" parent="."]
+
+[node name="ButtonStart" type="Button" parent="."]
+`,
+	})
+	menu := filepath.Join(root, "menu.tscn")
+
+	renamer, _ := compileNodeRenamer(`Btn(.*)`, `Button$1`)
+	if _, err := renameNodesInFile(menu, root, renamer, true); err == nil || !strings.Contains(err.Error(), "both be named ButtonStart") {
+		t.Errorf("Expected a sibling conflict, got: %v", err)
+	}
+
+	renamer, _ = compileNodeRenamer(`Btn(.*)`, `Btn.$1`)
+	if _, err := renameNodesInFile(menu, root, renamer, true); err == nil || !strings.Contains(err.Error(), "invalid new name") {
+		t.Errorf("Expected an invalid name error, got: %v", err)
+	}
+
+	// Siblings are grouped by parent= and only renamed nodes can collide: the
+	// sample repeats names at different depths
+	content, err := os.ReadFile(filepath.Join("test", "sample.tscn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := filepath.Join(root, "sample.tscn")
+	os.WriteFile(sample, content, 0644)
+	renamer, _ = compileNodeRenamer(`Btn(.*)`, `Button$1`)
+	if result, err := renameNodesInFile(sample, root, renamer, false); err != nil || len(result.Renames) != 0 {
+		t.Errorf("Expected no renames and no error, got %v (%v)", result, err)
+	}
+	renamer, _ = compileNodeRenamer(`TextureRect(\d)`, `Slot$1`)
+	if result, err := renameNodesInFile(sample, root, renamer, false); err != nil || len(result.Renames) != 13 {
+		t.Errorf("Expected 13 renames, got %v (%v)", result, err)
+	}
+	renamer, _ = compileNodeRenamer(`TextureRect2`, `TextureRect3`)
+	_, err = renameNodesInFile(sample, root, renamer, false)
+	if err == nil || err.Error() != "Control/partyScene/Control/TextureRect2 and Control/partyScene/Control/TextureRect3 would both be named TextureRect3" {
+		t.Errorf("Expected a nested sibling conflict, got: %v", err)
+	}
+
+	// A scene that cannot be renamed is reported on stderr and fails the command
+	var stdout, stderr strings.Builder
+	if code := Run([]string{"rename-all", menu, "--match", `Btn(.*)`, "--replace", `Button$1`}, &stdout, &stderr); code == 0 {
+		t.Error("Expected a failure for the sibling conflict")
+	}
+	if !strings.Contains(stderr.String(), "Error: "+menu) || strings.Contains(stdout.String(), "Error") {
+		t.Errorf("Expected the error on stderr:\nstdout: %s\nstderr: %s", stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,213 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// headerAttrKeyRe matches the key= right before an attribute value of a section header
+var headerAttrKeyRe = regexp.MustCompile(`(\w+)=$`)
+
+// ResourceReference is a place of a scene referencing a resource
+type ResourceReference struct {
+	Line     int    `json:"line"`
+	Section  string `json:"section"` // node path, SubResource("id") or [resource]
+	Property string `json:"property"`
+}
+
+// ResolvedResource is an ext_resource or sub_resource of a scene and its references
+type ResolvedResource struct {
+	Ref        string              `json:"ref"` // ExtResource("id") or SubResource("id")
+	ID         string              `json:"id"`
+	Type       string              `json:"type"`
+	Path       string              `json:"path,omitempty"`
+	UID        string              `json:"uid,omitempty"`
+	Line       int                 `json:"line"`
+	Properties []string            `json:"properties,omitempty"` // raw property lines of a sub_resource
+	References []ResourceReference `json:"references"`
+}
+
+// parseResourceRef splits ExtResource("id") or SubResource("id") into its kind
+// ("Ext" or "Sub") and id. A bare id has no kind and matches both.
+func parseResourceRef(ref string) (string, string) {
+	ref = strings.TrimSpace(ref)
+	if match := resourceRefRe.FindStringSubmatch(ref); match != nil && match[0] == ref {
+		return match[1], match[2]
+	}
+	return "", strings.Trim(ref, `"`)
+}
+
+// sectionLabel names the owner of a section in resolve output: the node path
+// of a node, SubResource("id") of a sub_resource, or the bare header tag
+func sectionLabel(header string) string {
+	switch {
+	case strings.HasPrefix(header, "[node "):
+		return sceneNodePath(header)
+	case strings.HasPrefix(header, "[sub_resource "):
+		id, _ := headerAttr(header, "id")
+		return `SubResource("` + id + `")`
+	}
+	return strings.Fields(strings.Trim(header, "[]"))[0]
+}
+
+// resolveResource finds the ext_resources and sub_resources of a scene with
+// the id of ref, with every header attribute and property referencing them
+func resolveResource(content, ref string) []*ResolvedResource {
+	kind, id := parseResourceRef(ref)
+	text := splitSceneText(content)
+
+	var resolved []*ResolvedResource
+	line := len(text.Preamble)
+	for _, section := range text.Sections {
+		line++
+		declared := line
+		line += len(section.Lines)
+
+		tag := strings.Fields(section.Header)[0]
+		var refKind string
+		switch tag {
+		case "[ext_resource":
+			refKind = "Ext"
+		case "[sub_resource":
+			refKind = "Sub"
+		default:
+			continue
+		}
+		if sectionID, _ := headerAttr(section.Header, "id"); sectionID != id || (kind != "" && kind != refKind) {
+			continue
+		}
+
+		resource := &ResolvedResource{Ref: refKind + `Resource("` + id + `")`, ID: id, Line: declared, References: []ResourceReference{}}
+		resource.Type, _ = headerAttr(section.Header, "type")
+		resource.Path, _ = headerAttr(section.Header, "path")
+		resource.UID, _ = headerAttr(section.Header, "uid")
+		if refKind == "Sub" {
+			for _, property := range section.Lines {
+				if strings.TrimSpace(property) != "" {
+					resource.Properties = append(resource.Properties, property)
+				}
+			}
+		}
+		resolved = append(resolved, resource)
+	}
+
+	// Collect the references in every section
+	line = len(text.Preamble)
+	for _, section := range text.Sections {
+		line++
+		label := sectionLabel(section.Header)
+		for _, match := range resourceRefRe.FindAllStringSubmatchIndex(section.Header, -1) {
+			attr := headerAttrKeyRe.FindStringSubmatch(section.Header[:match[0]])
+			property := ""
+			if attr != nil {
+				property = attr[1]
+			}
+			addResourceReference(resolved, section.Header[match[2]:match[3]], section.Header[match[4]:match[5]], ResourceReference{Line: line, Section: label, Property: property})
+		}
+
+		key := ""
+		pending := ""
+		for i, raw := range section.Lines {
+			if pending == "" {
+				if k, value, ok := splitPropertyLine(raw); ok && k != "" {
+					key, pending = k, value
+				}
+			} else {
+				pending += "\n" + raw
+			}
+			if pending != "" && valueComplete(pending) {
+				pending = ""
+			}
+			for _, match := range resourceRefRe.FindAllStringSubmatch(raw, -1) {
+				addResourceReference(resolved, match[1], match[2], ResourceReference{Line: line + 1 + i, Section: label, Property: key})
+			}
+		}
+		line += len(section.Lines)
+	}
+	return resolved
+}
+
+// addResourceReference records a reference to the resource of kind and id, if resolved
+func addResourceReference(resolved []*ResolvedResource, kind, id string, reference ResourceReference) {
+	for _, resource := range resolved {
+		if resource.ID == id && strings.HasPrefix(resource.Ref, kind+"Resource") {
+			resource.References = append(resource.References, reference)
+		}
+	}
+}
+
+// printResolvedResource displays a resource and its references
+func printResolvedResource(out io.Writer, resource *ResolvedResource) {
+	fmt.Fprintln(out, resource.Ref)
+	fmt.Fprintf(out, "  Type: %s\n", resource.Type)
+	if resource.Path != "" {
+		fmt.Fprintf(out, "  Path: %s\n", resource.Path)
+	}
+	if resource.UID != "" {
+		fmt.Fprintf(out, "  UID: %s\n", resource.UID)
+	}
+	fmt.Fprintf(out, "  Declared: line %d\n", resource.Line)
+	if len(resource.Properties) > 0 {
+		fmt.Fprintln(out, "  Properties:")
+		for _, property := range resource.Properties {
+			fmt.Fprintf(out, "    %s\n", property)
+		}
+	}
+
+	fmt.Fprintf(out, "\nReferences (%d):\n", len(resource.References))
+	for _, reference := range resource.References {
+		fmt.Fprintf(out, "  line %d: %s", reference.Line, reference.Section)
+		if reference.Property != "" {
+			fmt.Fprintf(out, " %s", reference.Property)
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve <scene or resource file> <ExtResource(\"id\") | SubResource(\"id\") | id>",
+	Short: "Show what an ExtResource or SubResource ID refers to and where it is used",
+	Long: `Look up an ext_resource or sub_resource of a scene by its ID, as seen in a diff, and print
+its type, path and uid (the properties for a sub_resource) with every node property, header
+attribute and sub_resource property of the scene referencing it. A bare ID matches both kinds.`,
+	Example: `  gdq resolve player.tscn 'ExtResource("3_h2k1a")'
+  gdq resolve player.tscn 'SubResource("RectangleShape2D_x7k2p")'
+  gdq resolve -o json player.tscn 3_h2k1a`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		content, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("file not found: %s", args[0])
+		}
+
+		resolved := resolveResource(string(content), args[1])
+		if len(resolved) == 0 {
+			return fmt.Errorf("resource not found: %s", args[1])
+		}
+
+		if outputFormat == "json" {
+			return writeJSON(out, resolved)
+		}
+		for i, resource := range resolved {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			printResolvedResource(out, resource)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resolveCmd)
+}
@@ -0,0 +1,66 @@
+package gdquery
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestResolveResource(t *testing.T) {
+	content := `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Texture2D" uid="uid://abc" path="res://a.png" id="1"]
+[ext_resource type="PackedScene" path="res://b.tscn" id="2_x"]
+
+[sub_resource type="StyleBoxTexture" id="1"]
+texture = ExtResource("1")
+
+[node name="Root" type="Node2D"]
+
+[node name="Sprite" type="Sprite2D" parent="."]
+texture = ExtResource("1")
+frames = [
+ExtResource("1"),
+SubResource("1")
+]
+
+[node name="B" parent="Sprite" instance=ExtResource("2_x")]
+`
+
+	resolved := resolveResource(content, `ExtResource("1")`)
+	if len(resolved) != 1 {
+		t.Fatalf("Expected 1 resource, got %d", len(resolved))
+	}
+	texture := resolved[0]
+	if texture.Type != "Texture2D" || texture.Path != "res://a.png" || texture.UID != "uid://abc" || texture.Line != 3 {
+		t.Errorf("Unexpected resource: %+v", texture)
+	}
+	var got []string
+	for _, reference := range texture.References {
+		got = append(got, fmt.Sprintf("%d %s %s", reference.Line, reference.Section, reference.Property))
+	}
+	expected := []string{`7 SubResource("1") texture`, "12 Sprite texture", "14 Sprite frames"}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected references:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	// A bare id matches both kinds
+	if resolved := resolveResource(content, "1"); len(resolved) != 2 || resolved[1].Ref != `SubResource("1")` {
+		t.Fatalf("Expected the ext and sub resources, got %+v", resolved)
+	} else if sub := resolved[1]; len(sub.Properties) != 1 || len(sub.References) != 1 || sub.References[0].Line != 15 {
+		t.Errorf("Unexpected sub resource: %+v", sub)
+	}
+
+	// Header attributes and Godot 3 references
+	resolved = resolveResource(content, `ExtResource( "2_x" )`)
+	if len(resolved) != 1 || len(resolved[0].References) != 1 {
+		t.Fatalf("Unexpected resolution: %+v", resolved)
+	}
+	if reference := resolved[0].References[0]; reference.Section != "Sprite/B" || reference.Property != "instance" || reference.Line != 18 {
+		t.Errorf("Unexpected reference: %+v", reference)
+	}
+
+	if resolved := resolveResource(content, `SubResource("2_x")`); len(resolved) != 0 {
+		t.Errorf("Expected no resource, got %+v", resolved)
+	}
+}
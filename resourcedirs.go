@@ -0,0 +1,116 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// resourceDirsBarWidth is the width of the bar of the most referenced directory
+const resourceDirsBarWidth = 30
+
+// resourceDirsTypes is the number of resource types listed per directory in text output
+const resourceDirsTypes = 3
+
+// DirectoryUsage counts the ext_resource references of the scanned scenes to
+// the resources of one directory
+type DirectoryUsage struct {
+	Dir    string         `json:"dir"`
+	Refs   int            `json:"refs"`
+	Scenes int            `json:"scenes"`
+	Types  map[string]int `json:"types"`
+}
+
+// resourceDirectory returns the directory of a res:// path, cut to its first
+// depth components when depth is positive (res://assets/ui/icons/a.png is
+// res://assets/ui/ with depth 2)
+func resourceDirectory(resPath string, depth int) string {
+	dir := path.Dir(strings.TrimPrefix(resPath, "res://"))
+	if dir == "." {
+		return "res://"
+	}
+	if parts := strings.Split(dir, "/"); depth > 0 && len(parts) > depth {
+		dir = strings.Join(parts[:depth], "/")
+	}
+	return "res://" + dir + "/"
+}
+
+// buildDirectoryUsage counts the references of the scanned scenes per resource
+// directory, most referenced first. Every ext_resource of a scene is one reference.
+func buildDirectoryUsage(results []*SceneScanResult, depth int) []*DirectoryUsage {
+	usages := make(map[string]*DirectoryUsage)
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, resource := range result.Scene.ExtResources {
+			resPath := normalizeResPath(result.File, resource.Path)
+			if !strings.HasPrefix(resPath, "res://") {
+				continue
+			}
+			dir := resourceDirectory(resPath, depth)
+			usage := usages[dir]
+			if usage == nil {
+				usage = &DirectoryUsage{Dir: dir, Types: make(map[string]int)}
+				usages[dir] = usage
+			}
+			usage.Refs++
+			usage.Types[resource.Type]++
+			if !seen[dir] {
+				seen[dir] = true
+				usage.Scenes++
+			}
+		}
+	}
+
+	list := make([]*DirectoryUsage, 0, len(usages))
+	for _, usage := range usages {
+		list = append(list, usage)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Refs != list[j].Refs {
+			return list[i].Refs > list[j].Refs
+		}
+		return list[i].Dir < list[j].Dir
+	})
+	return list
+}
+
+// writeDirectoryUsage writes one row per directory with a bar scaled to the
+// most referenced one and its most referenced resource types
+func writeDirectoryUsage(out io.Writer, usages []*DirectoryUsage) error {
+	if len(usages) == 0 {
+		_, err := fmt.Fprintln(out, "No resource references")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DIRECTORY\tREFS\tSCENES\t\tTYPES")
+	for _, usage := range usages {
+		types := make([]string, 0, len(usage.Types))
+		for name := range usage.Types {
+			types = append(types, name)
+		}
+		sort.Slice(types, func(i, j int) bool {
+			if usage.Types[types[i]] != usage.Types[types[j]] {
+				return usage.Types[types[i]] > usage.Types[types[j]]
+			}
+			return types[i] < types[j]
+		})
+		var labels []string
+		for _, name := range types[:min(len(types), resourceDirsTypes)] {
+			labels = append(labels, fmt.Sprintf("%s %d", name, usage.Types[name]))
+		}
+		if len(types) > resourceDirsTypes {
+			labels = append(labels, fmt.Sprintf("+%d more", len(types)-resourceDirsTypes))
+		}
+
+		bar := strings.Repeat("#", max(1, usage.Refs*resourceDirsBarWidth/usages[0].Refs))
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", usage.Dir, usage.Refs, usage.Scenes, bar, strings.Join(labels, ", "))
+	}
+	return w.Flush()
+}
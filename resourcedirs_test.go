@@ -0,0 +1,79 @@
+package gdquery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestResourceDirectory(t *testing.T) {
+	tests := []struct {
+		path     string
+		depth    int
+		expected string
+	}{
+		{"res://assets/ui/icons/a.png", 0, "res://assets/ui/icons/"},
+		{"res://assets/ui/icons/a.png", 2, "res://assets/ui/"},
+		{"res://assets/ui/icons/a.png", 5, "res://assets/ui/icons/"},
+		{"res://icon.png", 0, "res://"},
+		{"res://icon.png", 1, "res://"},
+	}
+	for _, tt := range tests {
+		if got := resourceDirectory(tt.path, tt.depth); got != tt.expected {
+			t.Errorf("resourceDirectory(%q, %d) = %q, expected %q", tt.path, tt.depth, got, tt.expected)
+		}
+	}
+}
+
+func TestBuildDirectoryUsage(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": "",
+		"levels/a.tscn": `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Texture2D" path="res://assets/ui/a.png" id="1"]
+[ext_resource type="Texture2D" path="res://assets/ui/b.png" id="2"]
+[ext_resource type="Script" path="a.gd" id="3"]
+
+[node name="A" type="Node"]
+`,
+		"levels/b.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Theme" path="res://assets/ui/theme.tres" id="1"]
+[ext_resource type="AudioStream" path="res://assets/sfx/hit.wav" id="2"]
+[ext_resource type="Texture2D" path="uid://b1" id="3"]
+
+[node name="B" type="Node"]
+`,
+	})
+
+	results, err := scanProject(root, root, ParseOptions{SkipProperties: true})
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+
+	usages := buildDirectoryUsage(results, 0)
+	var got []string
+	for _, usage := range usages {
+		got = append(got, usage.Dir)
+	}
+	if strings.Join(got, " ") != "res://assets/ui/ res://assets/sfx/ res://levels/" {
+		t.Fatalf("Unexpected directories: %v", got)
+	}
+	ui := usages[0]
+	if ui.Refs != 3 || ui.Scenes != 2 || ui.Types["Texture2D"] != 2 || ui.Types["Theme"] != 1 {
+		t.Errorf("Unexpected usage of res://assets/ui/: %+v", ui)
+	}
+
+	usages = buildDirectoryUsage(results, 1)
+	if len(usages) != 2 || usages[0].Dir != "res://assets/" || usages[0].Refs != 4 {
+		t.Errorf("Unexpected usage with depth 1: %+v", usages)
+	}
+
+	var out bytes.Buffer
+	if err := writeDirectoryUsage(&out, usages); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Texture2D 2, AudioStream 1, Theme 1") {
+		t.Errorf("Unexpected table:\n%s", out.String())
+	}
+}
@@ -0,0 +1,80 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// runMutex serializes Run: commands keep their options in package variables
+var runMutex sync.Mutex
+
+// pluginsOnce registers the plugins of the working directory on the first Run
+var pluginsOnce sync.Once
+
+// resetFlags puts every flag of cmd and its subcommands back to its default,
+// so that options of a previous Run do not leak into the next one
+func resetFlags(cmd *cobra.Command) {
+	reset := func(flag *pflag.Flag) {
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			var values []string
+			if def := strings.Trim(flag.DefValue, "[]"); def != "" {
+				values = strings.Split(def, ",")
+			}
+			slice.Replace(values)
+		} else {
+			flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+	cmd.PersistentFlags().VisitAll(reset)
+	cmd.Flags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// Run executes gdq in-process with args (without the program name), writing
+// its output to stdout and stderr, and returns the exit status: 0 on success,
+// 1 when the command failed. Flags are reset before each call and the classes
+// loaded with --class-db are dropped after it; calls are serialized since
+// commands share their options.
+func Run(args []string, stdout, stderr io.Writer) int {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+
+	savedLogger, savedProgressOutput := logger, progressOutput
+	defer func() { logger, progressOutput = savedLogger, savedProgressOutput }()
+	// --class-db replaces classes of the package-wide class database
+	savedClassDB := maps.Clone(classDB)
+	defer func() { classDB = savedClassDB }()
+
+	resetFlags(rootCmd)
+	// Plugin registration logs before the flags are parsed
+	if err := setupLogger(stderr); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	pluginsOnce.Do(func() { registerPlugins(rootCmd, ".") })
+	rootCmd.SetArgs(args)
+	rootCmd.SetOut(stdout)
+	rootCmd.SetErr(stderr)
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+	}()
+	err := rootCmd.Execute()
+	if closeErr := closeOutput(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package gdquery
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.tscn")
+	if err := os.WriteFile(file, []byte(testTscnContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-o", "scenetree", file}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), " ┖╴") {
+		t.Errorf("Expected a scenetree dump, got:\n%s", stdout.String())
+	}
+
+	// Flags of the previous call are reset
+	stdout.Reset()
+	if code := Run([]string{file}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "┖╴") {
+		t.Errorf("Expected the default text output, got:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := Run([]string{"missing.tscn"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit status 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "file not found: missing.tscn") {
+		t.Errorf("Expected the error on stderr, got: %q", stderr.String())
+	}
+}
+
+func TestRunLeavesProcessOutput(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": "[gd_scene format=3]\n\n[node name=\"Main\" type=\"Node\"]\n\n[node name=\"Lost\" type=\"Node\" parent=\"Missing\"]\n",
+	})
+	processOutput, err := os.Create(filepath.Join(t.TempDir(), "process.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer processOutput.Close()
+	savedStdout, savedStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = processOutput, processOutput
+	defer func() { os.Stdout, os.Stderr = savedStdout, savedStderr }()
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"--forest", filepath.Join(root, "main.tscn")}, &stdout, &stderr)
+	os.Stdout, os.Stderr = savedStdout, savedStderr
+	if code != 0 || !strings.HasPrefix(stdout.String(), "Main (Node)") {
+		t.Fatalf("Expected the tree, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Parent not found") {
+		t.Errorf("Expected the parent warning in stderr, got: %q", stderr.String())
+	}
+	if info, err := processOutput.Stat(); err != nil || info.Size() != 0 {
+		t.Errorf("Expected nothing written to the process stdout and stderr")
+	}
+}
+
+func TestRunRestoresClassDB(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"main.tscn": "[gd_scene format=3]\n\n[node name=\"Main\" type=\"Sprite2D\"]\ncentered = false\n",
+		"classes.json": `[{"name": "Sprite2D", "inherits": "Node2D", "properties": {"centered": "false"}},
+{"name": "CustomNode", "inherits": "Node"}]`,
+	})
+	scene := filepath.Join(root, "main.tscn")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"--class-db", filepath.Join(root, "classes.json"), scene}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+
+	// The next call sees the bundled classes again
+	stdout.Reset()
+	if code := Run([]string{scene}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	if lookupClass("CustomNode") != nil {
+		t.Error("Expected the loaded class to be dropped")
+	}
+	if !isDefaultValue("Sprite2D", "centered", "true") {
+		t.Error("Expected the bundled Sprite2D defaults")
+	}
+}
@@ -0,0 +1,238 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Runtime view option
+var showRuntime = false
+
+// Process modes, with the Godot 4 names. Godot 3 pause_mode STOP and PROCESS
+// are reported as pausable and always.
+const (
+	processInherit    = "inherit"
+	processPausable   = "pausable"
+	processWhenPaused = "when_paused"
+	processAlways     = "always"
+	processDisabled   = "disabled"
+)
+
+// processModes maps the process_mode (Godot 4) and pause_mode (Godot 3) values to process modes
+var processModes = map[string][]string{
+	"process_mode": {processInherit, processPausable, processWhenPaused, processAlways, processDisabled},
+	"pause_mode":   {processInherit, processPausable, processAlways},
+}
+
+// runtimeEnums are the runtime properties stored as enums, by value
+var runtimeEnums = map[string][]string{
+	"process_thread_group":       {"inherit", "main_thread", "sub_thread"},
+	"physics_interpolation_mode": {"inherit", "off", "on"},
+}
+
+// runtimeProperties are the process and physics settings the runtime view shows when set
+var runtimeProperties = []string{
+	"process_priority", "process_physics_priority", "process_thread_group", "physics_interpolation_mode",
+	"y_sort_enabled", "top_level", "disabled", "freeze", "freeze_mode", "sleeping", "can_sleep",
+	"lock_rotation", "custom_integrator", "monitoring", "monitorable", "sync_to_physics", "motion_mode",
+}
+
+// processCallbackRe matches the declaration of the callbacks that need a processing node
+var processCallbackRe = regexp.MustCompile(`(?m)^(?:static\s+)?func\s+(_process|_physics_process|_input|_unhandled_input|_unhandled_key_input)\s*\(`)
+
+// NodeRuntime is the processing behavior of a node at runtime
+type NodeRuntime struct {
+	Node     *GodotNode
+	Mode     string     // effective process mode
+	ModeFrom *GodotNode // node setting the mode, nil for the default of the scene root
+	Settings []string   // runtime properties set on the node, as "name = value"
+}
+
+// nodeProcessMode returns the process mode set on a node, or inherit
+func nodeProcessMode(node *GodotNode) string {
+	for prop, modes := range processModes {
+		value := strings.TrimSpace(node.Properties[prop])
+		if value == "" {
+			continue
+		}
+		if index := propertyInt(node, prop, -1); index >= 0 && index < len(modes) {
+			return modes[index]
+		}
+		return value
+	}
+	return processInherit
+}
+
+// runtimeSettings returns the runtime properties set on a node, enums by name
+func runtimeSettings(node *GodotNode) []string {
+	var settings []string
+	for _, prop := range runtimeProperties {
+		value, exists := node.Properties[prop]
+		if !exists {
+			continue
+		}
+		if names, isEnum := runtimeEnums[prop]; isEnum {
+			if index := propertyInt(node, prop, -1); index >= 0 && index < len(names) {
+				value = names[index]
+			}
+		}
+		settings = append(settings, prop+" = "+value)
+	}
+	return settings
+}
+
+// resolveRuntime computes the runtime behavior of node from that of its
+// parent, nil for the scene root
+func resolveRuntime(node *GodotNode, parent *NodeRuntime) *NodeRuntime {
+	runtime := &NodeRuntime{Node: node, Mode: processPausable, Settings: runtimeSettings(node)}
+	if mode := nodeProcessMode(node); mode != processInherit {
+		runtime.Mode, runtime.ModeFrom = mode, node
+	} else if parent != nil {
+		runtime.Mode, runtime.ModeFrom = parent.Mode, parent.ModeFrom
+	}
+	return runtime
+}
+
+// computeRuntime resolves the effective process mode of every node under root in tree
+// order. Nodes inherit the mode of their parent; the scene root defaults to pausable.
+func computeRuntime(root *GodotNode) []*NodeRuntime {
+	var result []*NodeRuntime
+
+	var walk func(node *GodotNode, parent *NodeRuntime)
+	walk = func(node *GodotNode, parent *NodeRuntime) {
+		runtime := resolveRuntime(node, parent)
+		result = append(result, runtime)
+
+		for _, child := range node.Children {
+			walk(child, runtime)
+		}
+	}
+	if root != nil {
+		walk(root, nil)
+	}
+
+	return result
+}
+
+// EffectiveProcessMode resolves the process mode of the node (pausable,
+// when_paused, always or disabled) by following inherit up its ancestors. It
+// also returns the node setting the mode, nil when the scene root defaults to
+// pausable. Within an instanced scene, the instancing scene is not known.
+func (n *GodotNode) EffectiveProcessMode() (string, *GodotNode) {
+	for node := n; node != nil; node = node.parent {
+		if mode := nodeProcessMode(node); mode != processInherit {
+			return mode, node
+		}
+	}
+	return processPausable, nil
+}
+
+// describeProcessMode formats the process mode of a node for display
+func describeProcessMode(runtime *NodeRuntime) string {
+	if runtime.ModeFrom == nil || runtime.ModeFrom == runtime.Node {
+		return runtime.Mode
+	}
+	return fmt.Sprintf("%s, from %s", runtime.Mode, runtime.ModeFrom.Path)
+}
+
+// printRuntime displays the process mode and runtime settings of the nodes under target
+func printRuntime(out io.Writer, scene *GodotScene, target *GodotNode) {
+	inTarget := false
+	depth := 0
+	for _, runtime := range computeRuntime(scene.RootNode) {
+		node := runtime.Node
+		nodeDepth := strings.Count(node.Path, "/")
+
+		// The results are in tree order: the subtree of target follows it
+		if node == target {
+			inTarget, depth = true, nodeDepth
+		} else if inTarget && nodeDepth <= depth {
+			break
+		}
+		if !inTarget {
+			continue
+		}
+
+		fmt.Fprintf(out, "%s%s (%s) [%s]", strings.Repeat("  ", nodeDepth-depth), node.OriginalName, typeLabel(node), describeProcessMode(runtime))
+		if len(runtime.Settings) > 0 {
+			fmt.Fprintf(out, " %s", strings.Join(runtime.Settings, ", "))
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// expectedProcessing returns why a node expects to be processed, or "" when
+// nothing suggests it: autostarting timers, autoplaying animations and sounds,
+// and scripts with process or input callbacks
+func expectedProcessing(ctx *LintContext, file string, scene *GodotScene, node *GodotNode, callbacks map[string]string) string {
+	class := nodeClass(node)
+	switch {
+	case classInherits(class, "Timer") && node.Properties["autostart"] == "true":
+		return "Timer with autostart"
+	case (classInherits(class, "AnimationPlayer") || strings.HasPrefix(class, "AnimatedSprite")) &&
+		strings.Trim(node.Properties["autoplay"], `"&`) != "":
+		return class + " with autoplay"
+	case strings.HasPrefix(class, "AudioStreamPlayer") && node.Properties["autoplay"] == "true":
+		return class + " with autoplay"
+	}
+
+	script := resolveResourcePath(node.Script, scene)
+	if !strings.HasSuffix(script, ".gd") {
+		return ""
+	}
+	script = normalizeResPath(file, script)
+	callback, checked := callbacks[script]
+	if !checked {
+		if content, err := os.ReadFile(resToFS(ctx.Root, script)); err == nil {
+			if matches := processCallbackRe.FindStringSubmatch(string(content)); matches != nil {
+				callback = matches[1]
+			}
+		}
+		callbacks[script] = callback
+	}
+	if callback == "" {
+		return ""
+	}
+	return fmt.Sprintf("script %s defines %s", script, callback)
+}
+
+// checkDisabledProcessing reports nodes that inherit a disabled process mode but
+// expect to be processed
+func checkDisabledProcessing(ctx *LintContext) []LintFinding {
+	callbacks := make(map[string]string)
+
+	var findings []LintFinding
+	for _, result := range ctx.Scenes() {
+		if result.Err != nil {
+			continue
+		}
+		scene := result.Scene
+		for _, runtime := range computeRuntime(scene.RootNode) {
+			if runtime.Mode != processDisabled || runtime.ModeFrom == runtime.Node {
+				continue
+			}
+			reason := expectedProcessing(ctx, result.File, scene, runtime.Node, callbacks)
+			if reason == "" {
+				continue
+			}
+			findings = append(findings, LintFinding{
+				File:    result.File,
+				Line:    runtime.Node.Span.StartLine,
+				Node:    runtime.Node.Path,
+				Message: fmt.Sprintf("%s but process_mode is disabled by %s", reason, runtime.ModeFrom.Path),
+			})
+		}
+	}
+	return findings
+}
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "disabled-processing",
+		Description: "Timers, autoplaying animations and sounds, and nodes with process or input callbacks under a node with process_mode disabled, which never run",
+		Check:       checkDisabledProcessing,
+	})
+}
@@ -0,0 +1,67 @@
+package gdquery
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRuntimeView(t *testing.T) {
+	content := `[gd_scene format=3]
+
+[node name="Main" type="Node2D"]
+
+[node name="World" type="Node2D" parent="."]
+y_sort_enabled = true
+process_priority = 5
+
+[node name="Body" type="RigidBody2D" parent="World"]
+freeze = true
+physics_interpolation_mode = 1
+
+[node name="PauseMenu" type="CanvasLayer" parent="."]
+process_mode = 2
+
+[node name="Timer" type="Timer" parent="PauseMenu"]
+
+[node name="Cutscene" type="Node" parent="."]
+process_mode = 4
+`
+	tempFile := "test_runtime.tscn"
+	if err := os.WriteFile(tempFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	output := captureOutput(func(out io.Writer) { printRuntime(out, scene, scene.RootNode) })
+	expected := []string{
+		"Main (Node2D) [pausable]",
+		"  World (Node2D) [pausable] process_priority = 5, y_sort_enabled = true",
+		"    Body (RigidBody2D) [pausable] physics_interpolation_mode = off, freeze = true",
+		"  PauseMenu (CanvasLayer) [when_paused]",
+		"    Timer (Timer) [when_paused, from Main/PauseMenu]",
+		"  Cutscene (Node) [disabled]",
+	}
+	if strings.TrimSpace(output) != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	if mode, from := findNodeByPath(scene, "PauseMenu/Timer").EffectiveProcessMode(); mode != processWhenPaused || from.Path != "Main/PauseMenu" {
+		t.Errorf("Expected when_paused from PauseMenu, got %s", mode)
+	}
+	if mode, from := findNodeByPath(scene, "World/Body").EffectiveProcessMode(); mode != processPausable || from != nil {
+		t.Errorf("Expected the pausable default, got %s", mode)
+	}
+
+	// Godot 3 pause_mode
+	scene.AllNodes[0].Properties["pause_mode"] = "2"
+	if runtime := computeRuntime(scene.RootNode); runtime[1].Mode != processAlways || runtime[1].ModeFrom != scene.RootNode {
+		t.Errorf("Expected always from the root, got %s", describeProcessMode(runtime[1]))
+	}
+}
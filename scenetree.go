@@ -0,0 +1,48 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+)
+
+// writeSceneTreePretty writes the tree under node the way Godot's
+// Node.print_tree_pretty() prints it at runtime, so that both dumps can be diffed
+func writeSceneTreePretty(w io.Writer, node *GodotNode, prefix string, last bool) {
+	branch, indent := " ┠╴", " ┃ "
+	if last {
+		branch, indent = " ┖╴", "   "
+	}
+	fmt.Fprintf(w, "%s%s%s\n", prefix, branch, node.OriginalName)
+	for i, child := range node.Children {
+		writeSceneTreePretty(w, child, prefix+indent, i == len(node.Children)-1)
+	}
+}
+
+// printScenesSceneTree writes the tree of each given file in print_tree_pretty()
+// format, separated by blank lines
+func printScenesSceneTree(out io.Writer, files []string) error {
+	if typeFilter != "" || relativeTo != "" {
+		return fmt.Errorf("--type and --relative-to are not supported with -o scenetree")
+	}
+	for i, file := range files {
+		scene, err := parseSceneFile(file)
+		if err != nil {
+			return fmt.Errorf("parse error: %v", err)
+		}
+		roots := []*GodotNode{scene.RootNode}
+		if nodePath != "" {
+			if roots, err = queryNodes(scene); err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+		}
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		for _, root := range roots {
+			if root != nil {
+				writeSceneTreePretty(out, root, "", true)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package gdquery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteSceneTreePretty(t *testing.T) {
+	content := `[gd_scene format=3]
+
+[node name="Main" type="Node2D"]
+
+[node name="Player" type="CharacterBody2D" parent="."]
+
+[node name="Sprite" type="Sprite2D" parent="Player"]
+
+[node name="Camera" type="Camera2D" parent="Player"]
+
+[node name="HUD" type="CanvasLayer" parent="."]
+
+[node name="Score" type="Label" parent="HUD"]
+`
+	scene, err := ParseReader(strings.NewReader(content), "main.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var b strings.Builder
+	writeSceneTreePretty(&b, scene.RootNode, "", true)
+	expected := ` ┖╴Main
+    ┠╴Player
+    ┃  ┠╴Sprite
+    ┃  ┖╴Camera
+    ┖╴HUD
+       ┖╴Score
+`
+	if b.String() != expected {
+		t.Errorf("Unexpected tree:\n%s", b.String())
+	}
+}
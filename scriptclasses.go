@@ -0,0 +1,263 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gdquery/query"
+)
+
+// Script class options
+var typeFilter = ""
+
+// scriptClassCacheFile is where Godot 4 lists the class_name declarations of a project
+const scriptClassCacheFile = ".godot/global_script_class_cache.cfg"
+
+// ScriptClass is a global class declared with class_name by a script
+type ScriptClass struct {
+	Name string
+	Base string // the class the script extends
+	Path string // res:// path of the script
+}
+
+// ScriptClasses are the script classes of a project, by script path and by name
+type ScriptClasses struct {
+	byPath map[string]*ScriptClass
+	byName map[string]*ScriptClass
+}
+
+// add registers a class, keeping the first declaration of a name
+func (c *ScriptClasses) add(class *ScriptClass) {
+	c.byPath[class.Path] = class
+	if _, exists := c.byName[class.Name]; !exists {
+		c.byName[class.Name] = class
+	}
+}
+
+// chain returns the script classes from class up through the classes it extends,
+// nearest first, followed by the built-in class at the end of the chain
+func (c *ScriptClasses) chain(class *ScriptClass) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for class != nil && !seen[class.Name] {
+		seen[class.Name] = true
+		names = append(names, class.Name)
+		if base, exists := c.byName[class.Base]; exists {
+			class = base
+			continue
+		}
+		if class.Base != "" {
+			names = append(names, class.Base)
+		}
+		break
+	}
+	return names
+}
+
+// scriptClassEntryRe matches the "key": value pairs of a script class entry;
+// values are Strings or StringNames (&"...")
+var scriptClassEntryRe = regexp.MustCompile(`"(\w+)"\s*:\s*&?"([^"]*)"`)
+
+// parseScriptClassList parses the dictionaries of global_script_class_cache.cfg
+// (list=Array[Dictionary]([...])) or of Godot 3 _global_script_classes
+func parseScriptClassList(value string, classes *ScriptClasses) {
+	for _, entry := range strings.Split(value, "}") {
+		class := &ScriptClass{}
+		for _, matches := range scriptClassEntryRe.FindAllStringSubmatch(entry, -1) {
+			switch matches[1] {
+			case "class":
+				class.Name = matches[2]
+			case "base":
+				class.Base = matches[2]
+			case "path":
+				class.Path = matches[2]
+			}
+		}
+		if class.Name != "" && class.Path != "" {
+			classes.add(class)
+		}
+	}
+}
+
+// gdscriptClassNameRe and gdscriptExtendsRe match the class_name and extends declarations of a GDScript
+var gdscriptClassNameRe = regexp.MustCompile(`(?m)^class_name\s+(\w+)`)
+var gdscriptExtendsRe = regexp.MustCompile(`(?m)^extends\s+(\w+)`)
+
+// scanScriptClasses reads the class_name declarations of the GDScript files
+// and the [GlobalClass] classes of the C# files under root
+func scanScriptClasses(root string, classes *ScriptClasses) {
+	files, err := findProjectFiles(root, append(csharpExtensions, scriptExtensions...))
+	if err != nil {
+		logger.Warn("Script scan failed", "error", err)
+		return
+	}
+	for _, file := range files {
+		if hasExtension(file, csharpExtensions) {
+			if script := readCSharpScript(root, fsToRes(root, file)); script != nil && script.Global && script.Class != "" {
+				classes.add(&ScriptClass{Name: script.Class, Base: script.Base, Path: fsToRes(root, file)})
+			}
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		name := gdscriptClassNameRe.FindSubmatch(content)
+		if name == nil {
+			continue
+		}
+		class := &ScriptClass{Name: string(name[1]), Path: fsToRes(root, file)}
+		if base := gdscriptExtendsRe.FindSubmatch(content); base != nil {
+			class.Base = string(base[1])
+		}
+		classes.add(class)
+	}
+}
+
+// loadScriptClasses reads the script classes of the project at root from the
+// Godot 4 class cache or the Godot 3 project settings, and scans class_name
+// declarations when neither is available (e.g. the project was never opened)
+func loadScriptClasses(root string) *ScriptClasses {
+	classes := &ScriptClasses{byPath: make(map[string]*ScriptClass), byName: make(map[string]*ScriptClass)}
+
+	if cache, err := parseConfigFile(filepath.Join(root, filepath.FromSlash(scriptClassCacheFile))); err == nil {
+		list, _ := cache.Get("", "list")
+		parseScriptClassList(list, classes)
+		return classes
+	}
+
+	project, err := parseConfigFile(filepath.Join(root, "project.godot"))
+	if err != nil {
+		// Not a project: nothing to scan
+		return classes
+	}
+	if list, exists := project.Get("", "_global_script_classes"); exists {
+		parseScriptClassList(list, classes)
+		return classes
+	}
+	scanScriptClasses(root, classes)
+	return classes
+}
+
+// scriptClassesCache holds the loaded script classes by project root
+var scriptClassesCache = make(map[string]*ScriptClasses)
+var scriptClassesMu sync.Mutex
+
+// projectScriptClasses returns the script classes of the project at root, loading them once
+func projectScriptClasses(root string) *ScriptClasses {
+	scriptClassesMu.Lock()
+	defer scriptClassesMu.Unlock()
+	classes, cached := scriptClassesCache[root]
+	if !cached {
+		classes = loadScriptClasses(root)
+		scriptClassesCache[root] = classes
+	}
+	return classes
+}
+
+// resolveScriptClasses sets the script class of the nodes of a scene whose
+// script declares a class_name, or is a C# script, whose class is always named
+func resolveScriptClasses(scene *GodotScene) {
+	root := findProjectRoot(scene.File)
+	classes := projectScriptClasses(root)
+
+	for _, node := range scene.AllNodes {
+		if node.Script == "" {
+			continue
+		}
+		script := resolveResourcePath(node.Script, scene)
+		if script == "" {
+			continue
+		}
+		script = normalizeResPath(fsToRes(root, scene.File), script)
+		if class, exists := classes.byPath[script]; exists {
+			node.ScriptClass = class.Name
+			node.scriptClassChain = classes.chain(class)
+		} else if hasExtension(script, csharpExtensions) {
+			if cs := readCSharpScript(root, script); cs != nil && cs.Class != "" {
+				node.ScriptClass = cs.Class
+				node.scriptClassChain = classes.chain(&ScriptClass{Name: cs.Class, Base: cs.Base, Path: script})
+			}
+		}
+	}
+}
+
+// matchesScriptClass reports whether the script class of node, or a class it extends, matches pattern
+func matchesScriptClass(node *GodotNode, pattern string) bool {
+	for _, class := range node.scriptClassChain {
+		if query.Wildcard(pattern, class) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOfType reports whether node is of the class name: its built-in type or a
+// base class of it, or its script class or a script class it extends
+func isOfType(node *GodotNode, name string) bool {
+	return matchesScriptClass(node, name) || query.Wildcard(name, node.Type) || classInherits(node.Type, name)
+}
+
+// typeLabel returns the type shown for a node: "Enemy: CharacterBody2D" for
+// nodes with a script class, the built-in type otherwise, and "CharacterBody2D,
+// instance of player.tscn" for instanced nodes without a type
+func typeLabel(node *GodotNode) string {
+	nodeType := node.Type
+	if nodeType == "" && node.InstanceOf != "" {
+		nodeType = instanceLabel(node)
+	}
+	if node.ScriptClass != "" {
+		return node.ScriptClass + ": " + nodeType
+	}
+	return nodeType
+}
+
+// findNodesOfType returns the nodes of the subtree of root that are of the class name, in tree order
+func findNodesOfType(root *GodotNode, name string) []*GodotNode {
+	var nodes []*GodotNode
+	if root == nil {
+		return nil
+	}
+	root.Walk(func(n *GodotNode, depth int) WalkAction {
+		if isOfType(n, name) {
+			nodes = append(nodes, n)
+		}
+		return WalkContinue
+	})
+	return nodes
+}
+
+// findQueriedNodesOfType returns the nodes matching --type in the subtrees of
+// the queried nodes, or in the whole tree when nodes is nil
+func findQueriedNodesOfType(scene *GodotScene, nodes []*GodotNode) []*GodotNode {
+	if nodes == nil {
+		return findNodesOfType(scene.RootNode, typeFilter)
+	}
+	var found []*GodotNode
+	seen := make(map[*GodotNode]bool)
+	for _, root := range nodes {
+		for _, node := range findNodesOfType(root, typeFilter) {
+			if !seen[node] {
+				seen[node] = true
+				found = append(found, node)
+			}
+		}
+	}
+	return found
+}
+
+// printNodesOfType lists the nodes of the subtree of root matching --type
+func printNodesOfType(out io.Writer, scene *GodotScene, root *GodotNode) {
+	for _, node := range findNodesOfType(root, typeFilter) {
+		fmt.Fprintf(out, "%s (%s)", node.Path, typeLabel(node))
+		if script := resolveResourcePath(node.Script, scene); script != "" {
+			fmt.Fprintf(out, " [Script: %s]", script)
+		}
+		fmt.Fprintln(out)
+	}
+}
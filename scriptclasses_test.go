@@ -0,0 +1,85 @@
+package gdquery
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gdquery/query"
+)
+
+const scriptClassScene = `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://enemies/goblin.gd" id="1_goblin"]
+[ext_resource type="Script" path="res://enemies/enemy.gd" id="2_enemy"]
+
+[node name="Level" type="Node2D"]
+
+[node name="Goblin" type="CharacterBody2D" parent="."]
+script = ExtResource("1_goblin")
+
+[node name="Bat" type="CharacterBody2D" parent="."]
+script = ExtResource("2_enemy")
+
+[node name="Player" type="CharacterBody2D" parent="."]
+
+[node name="HUD" type="Button" parent="."]
+`
+
+func TestScriptClassResolution(t *testing.T) {
+	// class_name declarations, and the Godot 4 class cache
+	scanned := writeProjectFiles(t, map[string]string{
+		"enemies/enemy.gd":  "extends CharacterBody2D\nclass_name Enemy\n",
+		"enemies/goblin.gd": "class_name Goblin\nextends Enemy\n",
+		"level.tscn":        scriptClassScene,
+	})
+
+	cached := writeProjectFiles(t, map[string]string{
+		"project.godot": "config_version=5\n",
+		".godot/global_script_class_cache.cfg": `list=Array[Dictionary]([{
+"base": &"CharacterBody2D",
+"class": &"Enemy",
+"icon": "",
+"language": &"GDScript",
+"path": "res://enemies/enemy.gd"
+}, {
+"base": &"Enemy",
+"class": &"Goblin",
+"icon": "",
+"language": &"GDScript",
+"path": "res://enemies/goblin.gd"
+}])
+`,
+		"level.tscn": scriptClassScene,
+	})
+
+	for _, root := range []string{cached, scanned} {
+		scene, err := ParseTscnFile(filepath.Join(root, "level.tscn"))
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		resolveScriptClasses(scene)
+
+		var labels []string
+		for _, node := range scene.AllNodes {
+			labels = append(labels, node.Name+" ("+typeLabel(node)+")")
+		}
+		expected := "Level (Node2D), Goblin (Goblin: CharacterBody2D), Bat (Enemy: CharacterBody2D), Player (CharacterBody2D), HUD (Button)"
+		if got := strings.Join(labels, ", "); got != expected {
+			t.Errorf("%s: unexpected types: %s", root, got)
+		}
+
+		var enemies []string
+		for _, node := range findNodesOfType(scene.RootNode, "Enemy") {
+			enemies = append(enemies, node.Name)
+		}
+		if got := strings.Join(enemies, " "); got != "Goblin Bat" {
+			t.Errorf("Expected Goblin (extends Enemy) and Bat for --type Enemy, got: %s", got)
+		}
+
+		nodeQuery, _ := query.Compile("type=Goblin")
+		if nodes := findNodesByQuery(scene, nodeQuery); len(nodes) != 1 || nodes[0].Name != "Goblin" {
+			t.Errorf("Expected type=Goblin to match the Goblin node, got %d nodes", len(nodes))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "script-node-type",
+		Description: "Scripts attached to nodes whose type does not derive from the class the script extends (Godot only reports this at runtime)",
+		Check:       checkScriptNodeType,
+	})
+}
+
+// gdscriptExtendsAnyRe matches an extends declaration naming a class or a script path
+var gdscriptExtendsAnyRe = regexp.MustCompile(`(?m)^extends\s+(?:"([^"]+)"|'([^']+)'|(\w+))`)
+
+// scriptNativeBase returns the built-in class a GDScript or C# script derives
+// from, following extends declarations naming script paths or script classes.
+// It returns "" when the chain cannot be followed (missing file, no extends, cycle).
+func scriptNativeBase(root, scriptRes string, classes *ScriptClasses, cache map[string]string) string {
+	if base, exists := cache[scriptRes]; exists {
+		return base
+	}
+	cache[scriptRes] = "" // guards against extends cycles
+
+	if hasExtension(scriptRes, csharpExtensions) {
+		cs := readCSharpScript(root, scriptRes)
+		if cs == nil || cs.Base == "" {
+			return ""
+		}
+		base := cs.Base
+		if class := classes.byName[base]; class != nil {
+			base = scriptNativeBase(root, class.Path, classes, cache)
+		}
+		cache[scriptRes] = base
+		return base
+	}
+
+	content, err := os.ReadFile(resToFS(root, scriptRes))
+	if err != nil {
+		return ""
+	}
+	matches := gdscriptExtendsAnyRe.FindStringSubmatch(string(content))
+	if matches == nil {
+		return ""
+	}
+
+	base := ""
+	switch path := matches[1] + matches[2]; {
+	case path != "":
+		base = scriptNativeBase(root, normalizeResPath(scriptRes, path), classes, cache)
+	case classes.byName[matches[3]] != nil:
+		base = scriptNativeBase(root, classes.byName[matches[3]].Path, classes, cache)
+	default:
+		base = matches[3]
+	}
+	cache[scriptRes] = base
+	return base
+}
+
+// classKnown reports whether the class database knows the whole inheritance chain of a class
+func classKnown(class string) bool {
+	for seen := 0; seen < 64; seen++ {
+		c := lookupClass(class)
+		if c == nil {
+			return false
+		}
+		if c.Inherits == "" {
+			return true
+		}
+		class = c.Inherits
+	}
+	return false
+}
+
+// checkScriptNodeType reports nodes whose script extends a class the node type does not derive from
+func checkScriptNodeType(ctx *LintContext) []LintFinding {
+	classes := projectScriptClasses(ctx.Root)
+	bases := make(map[string]string)
+
+	var findings []LintFinding
+	for _, result := range ctx.Scenes() {
+		if result.Err != nil {
+			continue
+		}
+		scene := result.Scene
+		for _, node := range scene.AllNodes {
+			if node.Script == "" || node.Type == "" || !classKnown(node.Type) {
+				continue
+			}
+			script := resolveResourcePath(node.Script, scene)
+			if !hasExtension(script, scriptExtensions) && !hasExtension(script, csharpExtensions) {
+				continue
+			}
+			script = normalizeResPath(result.File, script)
+			base := scriptNativeBase(ctx.Root, script, classes, bases)
+			if base == "" || !classKnown(base) || classInherits(node.Type, base) {
+				continue
+			}
+			findings = append(findings, LintFinding{
+				File:    result.File,
+				Node:    node.Path,
+				Message: fmt.Sprintf("%s extends %s but is attached to a %s node", script, base, node.Type),
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,247 @@
+package gdquery
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// loadStepsRe matches the load_steps attribute of a [gd_scene] or [gd_resource] header
+var loadStepsRe = regexp.MustCompile(`\s*load_steps=\d+`)
+
+// groupedSectionKinds are written without blank lines between consecutive sections
+var groupedSectionKinds = map[string]bool{"[ext_resource": true, "[connection": true, "[editable": true}
+
+// sceneSection is a [header] of a text scene and the raw lines that follow it.
+// Editing scenes through sections keeps the original formatting untouched.
+type sceneSection struct {
+	Header string
+	Lines  []string
+}
+
+// sceneText is a text scene split into sections
+type sceneText struct {
+	Preamble []string
+	Sections []*sceneSection
+}
+
+// splitSceneText splits text scene content into sections.
+// Lines inside multiline values (strings, arrays, dictionaries) never start a section.
+func splitSceneText(content string) *sceneText {
+	text := &sceneText{}
+	var current *sceneSection
+	var pending strings.Builder
+
+	for _, line := range strings.Split(content, "\n") {
+		inValue := pending.Len() > 0
+		if !inValue && strings.HasPrefix(line, "[") {
+			current = &sceneSection{Header: line}
+			text.Sections = append(text.Sections, current)
+			continue
+		}
+
+		if current == nil {
+			text.Preamble = append(text.Preamble, line)
+		} else {
+			current.Lines = append(current.Lines, line)
+		}
+
+		// Track values spanning several lines
+		if inValue {
+			pending.WriteString("\n" + line)
+		} else if key, value, ok := splitPropertyLine(line); ok && key != "" {
+			pending.WriteString(value)
+		}
+		if pending.Len() > 0 && valueComplete(pending.String()) {
+			pending.Reset()
+		}
+	}
+
+	return text
+}
+
+// String joins the sections back into file content
+func (t *sceneText) String() string {
+	var lines []string
+	lines = append(lines, t.Preamble...)
+	for _, section := range t.Sections {
+		lines = append(lines, section.Header)
+		lines = append(lines, section.Lines...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSceneSections writes sections the way Godot does: a blank line between
+// sections, except within runs of ext_resources and connections
+func formatSceneSections(sections []*sceneSection) string {
+	var content strings.Builder
+	previous := ""
+	for _, section := range sections {
+		kind := strings.Fields(section.Header)[0]
+		if content.Len() > 0 && (kind != previous || !groupedSectionKinds[kind]) {
+			content.WriteString("\n")
+		}
+		content.WriteString(section.Header + "\n")
+
+		lines := section.Lines
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for _, line := range lines {
+			content.WriteString(line + "\n")
+		}
+		previous = kind
+	}
+	return content.String()
+}
+
+// updateLoadSteps sets the load_steps of the [gd_scene] or [gd_resource] header,
+// when it has one, to the number of ext_resources and sub_resources plus one
+func updateLoadSteps(sections []*sceneSection) {
+	resources := 0
+	for _, section := range sections {
+		if strings.HasPrefix(section.Header, "[ext_resource") || strings.HasPrefix(section.Header, "[sub_resource") {
+			resources++
+		}
+	}
+	for _, section := range sections {
+		if strings.HasPrefix(section.Header, "[gd_scene") || strings.HasPrefix(section.Header, "[gd_resource") {
+			section.Header = loadStepsRe.ReplaceAllString(section.Header, " load_steps="+strconv.Itoa(resources+1))
+		}
+	}
+}
+
+// nodeSections returns the [node] sections in file order
+func (t *sceneText) nodeSections() []*sceneSection {
+	var sections []*sceneSection
+	for _, section := range t.Sections {
+		if strings.HasPrefix(section.Header, "[node") {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// resourceSection returns the [resource] section of a text resource, or nil
+func (t *sceneText) resourceSection() *sceneSection {
+	for _, section := range t.Sections {
+		if strings.HasPrefix(section.Header, "[resource]") {
+			return section
+		}
+	}
+	return nil
+}
+
+// subResourceSection returns the [sub_resource] section with the given id, or nil
+func (t *sceneText) subResourceSection(id string) *sceneSection {
+	idRe := regexp.MustCompile(`\bid=(?:"([^"]*)"|(\d+))`)
+	for _, section := range t.Sections {
+		if !strings.HasPrefix(section.Header, "[sub_resource") {
+			continue
+		}
+		if matches := idRe.FindStringSubmatch(section.Header); len(matches) > 2 && matches[1]+matches[2] == id {
+			return section
+		}
+	}
+	return nil
+}
+
+// splitPropertyLine splits "key = value" into its parts
+func splitPropertyLine(line string) (string, string, bool) {
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 || strings.HasPrefix(strings.TrimSpace(line), "\"") {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+// propertyRange returns the line range [start, end) of the property key,
+// including continuation lines, or -1 when the property is not present
+func (s *sceneSection) propertyRange(key string) (int, int) {
+	for i := 0; i < len(s.Lines); i++ {
+		k, value, ok := splitPropertyLine(s.Lines[i])
+		if !ok {
+			continue
+		}
+		end := i + 1
+		for !valueComplete(value) && end < len(s.Lines) {
+			value += "\n" + s.Lines[end]
+			end++
+		}
+		if k == key {
+			return i, end
+		}
+		i = end - 1
+	}
+	return -1, -1
+}
+
+// Property returns the raw value of key in the section
+func (s *sceneSection) Property(key string) (string, bool) {
+	start, end := s.propertyRange(key)
+	if start < 0 {
+		return "", false
+	}
+	_, value, _ := splitPropertyLine(strings.Join(s.Lines[start:end], "\n"))
+	return value, true
+}
+
+// PropertyValues returns the raw values of the properties of the section by
+// key, with the keys in the order they are written
+func (s *sceneSection) PropertyValues() ([]string, map[string]string) {
+	var keys []string
+	values := make(map[string]string)
+	for i := 0; i < len(s.Lines); i++ {
+		key, value, ok := splitPropertyLine(s.Lines[i])
+		if !ok || key == "" {
+			continue
+		}
+		for !valueComplete(value) && i+1 < len(s.Lines) {
+			i++
+			value += "\n" + s.Lines[i]
+		}
+		if _, exists := values[key]; !exists {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	return keys, values
+}
+
+// SetProperty replaces the value of key, or appends the property after the last one
+func (s *sceneSection) SetProperty(key, value string) {
+	line := key + " = " + value
+	if start, end := s.propertyRange(key); start >= 0 {
+		s.Lines = append(s.Lines[:start], append([]string{line}, s.Lines[end:]...)...)
+		return
+	}
+
+	// Insert before the trailing blank lines separating sections
+	insertAt := len(s.Lines)
+	for insertAt > 0 && strings.TrimSpace(s.Lines[insertAt-1]) == "" {
+		insertAt--
+	}
+	s.Lines = append(s.Lines[:insertAt], append([]string{line}, s.Lines[insertAt:]...)...)
+}
+
+// RenameProperty changes the key of a property, keeping its value and formatting,
+// and reports whether it existed
+func (s *sceneSection) RenameProperty(key, newKey string) bool {
+	start, _ := s.propertyRange(key)
+	if start < 0 {
+		return false
+	}
+	_, value, _ := strings.Cut(s.Lines[start], "=")
+	s.Lines[start] = newKey + " =" + value
+	return true
+}
+
+// RemoveProperty deletes key from the section and reports whether it existed
+func (s *sceneSection) RemoveProperty(key string) bool {
+	start, end := s.propertyRange(key)
+	if start < 0 {
+		return false
+	}
+	s.Lines = append(s.Lines[:start], s.Lines[end:]...)
+	return true
+}
@@ -0,0 +1,157 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+
+	"gdquery/query"
+	"github.com/spf13/cobra"
+)
+
+// Set command options
+var setGlobs []string
+var setQuery = ""
+var setDryRun = false
+
+// PropertyChange describes a property edit on one node
+type PropertyChange struct {
+	NodePath string
+	Property string
+	OldValue string
+	NewValue string
+	Existed  bool
+}
+
+// setPropertyInFile sets prop to value on every node of file selected by matcher.
+// The file is only written when write is true.
+func setPropertyInFile(file string, matcher query.Matcher, prop, value string, write bool) ([]PropertyChange, error) {
+	scene, err := ParseFile(file, ParseOptions{Logger: logger})
+	if err != nil {
+		return nil, err
+	}
+	resolveScriptClasses(scene)
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	text := splitSceneText(string(content))
+	sections := text.nodeSections()
+	if len(sections) != len(scene.AllNodes) {
+		return nil, fmt.Errorf("node sections do not match parsed nodes (%d != %d)", len(sections), len(scene.AllNodes))
+	}
+
+	var changes []PropertyChange
+	for i, node := range scene.AllNodes {
+		if !matcher.Match(QueryNode(node, scene)) {
+			continue
+		}
+
+		section := sections[i]
+		oldValue, existed := section.Property(prop)
+		if existed && oldValue == value {
+			continue
+		}
+
+		section.SetProperty(prop, value)
+		changes = append(changes, PropertyChange{
+			NodePath: node.Path,
+			Property: prop,
+			OldValue: oldValue,
+			NewValue: value,
+			Existed:  existed,
+		})
+	}
+
+	if write && len(changes) > 0 {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(file, []byte(text.String()), info.Mode()); err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set [flags] <property> <value> [tscn files...]",
+	Short: "Set a property on matching nodes across many scenes",
+	Long: `Set a property on every node matching --query in every file matching --glob.
+Values are written as raw Godot values, so strings need quotes (e.g. '"Hello"').`,
+	Example:      `  gdq set --glob 'scenes/**/*.tscn' --query 'type=Label' theme_override_font_sizes/font_size 24`,
+	Args:         cobra.MinimumNArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		prop, value := args[0], args[1]
+
+		if setQuery == "" {
+			return fmt.Errorf("--query is required")
+		}
+		nodeQuery, err := query.Compile(setQuery)
+		if err != nil {
+			return err
+		}
+
+		files := args[2:]
+		for _, pattern := range setGlobs {
+			matches, err := globFiles(pattern)
+			if err != nil {
+				return fmt.Errorf("glob error: %v", err)
+			}
+			files = append(files, matches...)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no files matched")
+		}
+
+		touchedFiles := 0
+		changedNodes := 0
+		failed := 0
+		for _, file := range files {
+			changes, err := setPropertyInFile(file, nodeQuery, prop, value, !setDryRun)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %s: %v\n", file, err)
+				failed++
+				continue
+			}
+			if len(changes) == 0 {
+				continue
+			}
+
+			touchedFiles++
+			changedNodes += len(changes)
+			fmt.Fprintf(out, "%s: %d node(s)\n", file, len(changes))
+			for _, change := range changes {
+				oldValue := change.OldValue
+				if !change.Existed {
+					oldValue = "(unset)"
+				}
+				fmt.Fprintf(out, "  %s: %s %s -> %s\n", change.NodePath, change.Property, oldValue, change.NewValue)
+			}
+		}
+
+		if setDryRun {
+			fmt.Fprintf(out, "\nWould update %d node(s) in %d of %d file(s) (dry run)\n", changedNodes, touchedFiles, len(files))
+		} else {
+			fmt.Fprintf(out, "\nUpdated %d node(s) in %d of %d file(s)\n", changedNodes, touchedFiles, len(files))
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d file(s) could not be updated", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	setCmd.Flags().StringArrayVar(&setGlobs, "glob", nil, "Glob pattern of files to edit (supports **, repeatable)")
+	setCmd.Flags().StringVar(&setQuery, "query", "", "Node query (e.g. \"type=Label\", \"name=Title*\", \"HUD/Score\")")
+	setCmd.RegisterFlagCompletionFunc("query", completeQueryType)
+	setCmd.Flags().BoolVar(&setDryRun, "dry-run", false, "Show the changes without writing files")
+	rootCmd.AddCommand(setCmd)
+}
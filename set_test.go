@@ -0,0 +1,137 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gdquery/query"
+)
+
+func TestSetPropertyAcrossScenes(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"scenes/ui/menu.tscn": `[gd_scene format=3]
+
+[node name="Menu" type="Control"]
+
+[node name="Title" type="Label" parent="."]
+text = "Multi
+line = not a property"
+font_size = 16
+
+[node name="Start" type="Button" parent="."]
+text = "Start"
+`,
+		"scenes/hud.tscn": `[gd_scene format=3]
+
+[node name="HUD" type="Control"]
+
+[node name="Score" type="Label" parent="."]
+text = "0"
+
+[connection signal="pressed" from="." to="." method="_on_pressed"]
+`,
+		"scenes/readme.txt": "not a scene",
+	})
+
+	files, err := globFiles(filepath.ToSlash(root) + "/scenes/**/*.tscn")
+	if err != nil {
+		t.Fatalf("Glob error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files from glob, got: %v", files)
+	}
+
+	nodeQuery, err := query.Compile("type=Label")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	menu := filepath.Join(root, "scenes", "ui", "menu.tscn")
+	before, _ := os.ReadFile(menu)
+
+	// Dry run leaves the file untouched
+	changes, err := setPropertyInFile(menu, nodeQuery, "font_size", "24", false)
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("Expected 1 change, got: %v (%v)", changes, err)
+	}
+	if after, _ := os.ReadFile(menu); string(after) != string(before) {
+		t.Error("Dry run modified the file")
+	}
+
+	for _, file := range files {
+		if _, err := setPropertyInFile(file, nodeQuery, "font_size", "24", true); err != nil {
+			t.Fatalf("Set error: %v", err)
+		}
+	}
+
+	menuContent, _ := os.ReadFile(menu)
+	if !strings.Contains(string(menuContent), "line = not a property\"\nfont_size = 24\n") {
+		t.Errorf("Existing property not replaced in place:\n%s", menuContent)
+	}
+
+	hudContent, _ := os.ReadFile(filepath.Join(root, "scenes", "hud.tscn"))
+	if !strings.Contains(string(hudContent), "text = \"0\"\nfont_size = 24\n\n[connection") {
+		t.Errorf("New property not appended to the node section:\n%s", hudContent)
+	}
+
+	// Setting the same value again changes nothing
+	if changes, _ := setPropertyInFile(menu, nodeQuery, "font_size", "24", true); len(changes) != 0 {
+		t.Errorf("Expected no changes, got: %v", changes)
+	}
+
+	// A file that cannot be edited is reported on stderr and fails the command
+	var stdout, stderr strings.Builder
+	missing := filepath.Join(root, "scenes", "missing.tscn")
+	if code := Run([]string{"set", "font_size", "32", "--query", "type=Label", menu, missing}, &stdout, &stderr); code == 0 {
+		t.Error("Expected a failure for a missing file")
+	}
+	if !strings.Contains(stderr.String(), "Error: "+missing) || strings.Contains(stdout.String(), "Error") {
+		t.Errorf("Expected the error on stderr:\nstdout: %s\nstderr: %s", stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Updated 1 node(s) in 1 of 2 file(s)") {
+		t.Errorf("Expected the other file to be updated:\n%s", stdout.String())
+	}
+}
+
+func TestQueryNode(t *testing.T) {
+	scene := &GodotScene{ExtResources: map[string]*GodotResource{
+		"1_s": {Path: "res://ui/title.gd"},
+	}}
+	node := &GodotNode{
+		OriginalName:     "Title",
+		Type:             "Label",
+		Path:             "Menu/Box/Title",
+		Script:           `ExtResource("1_s")`,
+		Properties:       map[string]string{"text": `"Play"`},
+		scriptClassChain: []string{"TitleLabel"},
+	}
+
+	testCases := []struct {
+		expr  string
+		match bool
+	}{
+		{"type=Label", true},
+		{"type=TitleLabel", true},
+		{"type=Button", false},
+		{"type=Label,name=Tit*", true},
+		{"type!=Label", false},
+		{"Box/Title", true},
+		{"script=res://ui/*.gd", true},
+		{"instance=*", false},
+		{"text=Play", true},
+		{"text=\"Play\"", true},
+		{"font_size=16", false},
+	}
+
+	for _, tc := range testCases {
+		nodeQuery, err := query.Compile(tc.expr)
+		if err != nil {
+			t.Fatalf("Query error for %q: %v", tc.expr, err)
+		}
+		if got := nodeQuery.Match(QueryNode(node, scene)); got != tc.match {
+			t.Errorf("Query %q match = %v, expected %v", tc.expr, got, tc.match)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// sexprVersion is bumped when the s-expression layout changes incompatibly
+const sexprVersion = 1
+
+// sexprString quotes a string for s-expressions: backslashes, quotes and
+// newlines are escaped
+func sexprString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// sexprAttrs formats keyword attributes, skipping empty values
+func sexprAttrs(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			fmt.Fprintf(&b, " :%s %s", pairs[i], sexprString(pairs[i+1]))
+		}
+	}
+	return b.String()
+}
+
+// sexprRef returns the path a resource reference resolves to, or the raw reference
+func sexprRef(ref string, scene *GodotScene) string {
+	if path := resolveResourcePath(ref, scene); path != "" {
+		return path
+	}
+	return ref
+}
+
+// writeSexprNode writes a node, its properties sorted by name and its children
+func writeSexprNode(w io.Writer, node *GodotNode, scene *GodotScene, depth int) {
+	indent := strings.Repeat("  ", depth)
+	attrs := sexprAttrs("name", node.OriginalName, "type", node.Type, "path", node.Path)
+	if node.Script != "" {
+		attrs += sexprAttrs("script", sexprRef(node.Script, scene))
+	}
+	if node.Instance != "" {
+		attrs += sexprAttrs("instance", sexprRef(node.Instance, scene))
+	}
+	fmt.Fprintf(w, "%s(node%s", indent, attrs)
+
+	props := make([]string, 0, len(node.Properties))
+	for prop := range node.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+	for _, prop := range props {
+		fmt.Fprintf(w, "\n%s  (prop %s %s)", indent, sexprString(prop), sexprString(node.Properties[prop]))
+	}
+	for _, child := range node.Children {
+		fmt.Fprintln(w)
+		writeSexprNode(w, child, scene, depth+1)
+	}
+	fmt.Fprint(w, ")")
+}
+
+// writeSceneSexpr writes a scene as one s-expression: resources in file order,
+// the node tree from root (the whole tree when nil) and the connections. Spans
+// are left out so that the output only changes with the structure.
+func writeSceneSexpr(w io.Writer, scene *GodotScene, root *GodotNode) {
+	fmt.Fprintf(w, "(scene :sexpr-version %d%s :format %d :load-steps %d",
+		sexprVersion, sexprAttrs("file", scene.File, "godot-version", scene.Version.String()), scene.Format, scene.LoadSteps)
+
+	for _, resource := range resourcesToJSON(scene.ExtResources) {
+		fmt.Fprintf(w, "\n  (ext-resource%s)", sexprAttrs("id", resource.ID, "type", resource.Type, "path", resource.Path, "uid", resource.UID))
+	}
+	for _, resource := range resourcesToJSON(scene.SubResources) {
+		fmt.Fprintf(w, "\n  (sub-resource%s)", sexprAttrs("id", resource.ID, "type", resource.Type))
+	}
+	if root == nil {
+		root = scene.RootNode
+	}
+	if root != nil {
+		fmt.Fprintln(w)
+		writeSexprNode(w, root, scene, 1)
+	}
+	for _, connection := range scene.Connections {
+		fmt.Fprintf(w, "\n  (connection%s)", sexprAttrs("signal", connection.Signal, "from", connection.From,
+			"to", connection.To, "method", connection.Method, "binds", connection.Binds))
+	}
+	fmt.Fprintln(w, ")")
+}
+
+// printScenesSexpr writes the given files as s-expressions, one form per scene
+// or per node matching --query
+func printScenesSexpr(out io.Writer, files []string) error {
+	if typeFilter != "" || relativeTo != "" {
+		return fmt.Errorf("--type and --relative-to are not supported with -o sexpr")
+	}
+	for _, file := range files {
+		scene, err := parseSceneFile(file)
+		if err != nil {
+			return fmt.Errorf("parse error: %v", err)
+		}
+		roots := []*GodotNode{nil}
+		if nodePath != "" {
+			if roots, err = queryNodes(scene); err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+		}
+		for _, root := range roots {
+			writeSceneSexpr(out, scene, root)
+		}
+	}
+	return nil
+}
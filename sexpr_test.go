@@ -0,0 +1,51 @@
+package gdquery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteSceneSexpr(t *testing.T) {
+	content := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://main.gd" id="1_s"]
+
+[node name="Main" type="Control"]
+script = ExtResource("1_s")
+
+[node name="Label" type="Label" parent="."]
+text = "A \"quoted\" word
+second line"
+position = Vector2(1, 2)
+
+[node name="Button" type="Button" parent="."]
+
+[connection signal="pressed" from="Button" to="." method="_on_pressed"]
+`
+	scene, err := ParseReader(strings.NewReader(content), "main.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var b strings.Builder
+	writeSceneSexpr(&b, scene, nil)
+	expected := `(scene :sexpr-version 1 :file "main.tscn" :godot-version "4.x" :format 3 :load-steps 2
+  (ext-resource :id "1_s" :type "Script" :path "res://main.gd")
+  (node :name "Main" :type "Control" :path "Main" :script "res://main.gd"
+    (prop "script" "ExtResource(\"1_s\")")
+    (node :name "Label" :type "Label" :path "Main/Label"
+      (prop "position" "Vector2(1, 2)")
+      (prop "text" "A \\\"quoted\\\" word\nsecond line"))
+    (node :name "Button" :type "Button" :path "Main/Button"))
+  (connection :signal "pressed" :from "Button" :to "." :method "_on_pressed"))
+`
+	if b.String() != expected {
+		t.Errorf("Unexpected s-expression:\n%s", b.String())
+	}
+
+	b.Reset()
+	writeSceneSexpr(&b, scene, findNodeByPath(scene, "Button"))
+	if !strings.Contains(b.String(), "\n  (node :name \"Button\" :type \"Button\" :path \"Main/Button\")\n") || strings.Contains(b.String(), "Label") {
+		t.Errorf("Expected only the queried subtree:\n%s", b.String())
+	}
+}
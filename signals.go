@@ -0,0 +1,87 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// connectionNodePath converts a node path relative to the scene root ("." or
+// "Child/Sub") into the path of the node in the scene tree
+func connectionNodePath(scene *GodotScene, path string) string {
+	if scene.RootNode == nil {
+		return path
+	}
+	if path == "." || path == "" {
+		return scene.RootNode.Path
+	}
+	return scene.RootNode.Path + "/" + path
+}
+
+// mermaidLabel escapes text for a quoted Mermaid label
+func mermaidLabel(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+// writeMermaidSignals writes the connections of the scenes as a Mermaid flowchart:
+// emitter node --signal--> receiver node with the method. Several scenes are
+// written as one subgraph each.
+func writeMermaidSignals(w io.Writer, files []string, scenes []*GodotScene) error {
+	fmt.Fprintln(w, "flowchart LR")
+	for i, scene := range scenes {
+		indent := "  "
+		if len(scenes) > 1 {
+			fmt.Fprintf(w, "  subgraph s%d[\"%s\"]\n", i, mermaidLabel(files[i]))
+			indent = "    "
+		}
+
+		// Declare each node once, in order of first appearance
+		ids := make(map[string]string)
+		nodeID := func(path string) string {
+			if id, exists := ids[path]; exists {
+				return id
+			}
+			id := fmt.Sprintf("s%dn%d", i, len(ids))
+			ids[path] = id
+
+			// Nodes inside instanced scenes are not in the tree and keep the bare path
+			label := path
+			for _, node := range scene.AllNodes {
+				if node.Path == path && node.Type != "" {
+					label = fmt.Sprintf("%s (%s)", path, node.Type)
+					break
+				}
+			}
+			fmt.Fprintf(w, "%s%s[\"%s\"]\n", indent, id, mermaidLabel(label))
+			return id
+		}
+
+		for _, connection := range scene.Connections {
+			from := nodeID(connectionNodePath(scene, connection.From))
+			to := nodeID(connectionNodePath(scene, connection.To))
+			label := connection.Signal + " → " + connection.Method + "()"
+			if connection.Binds != "" && connection.Binds != "[]" {
+				label += " binds " + connection.Binds
+			}
+			fmt.Fprintf(w, "%s%s -->|\"%s\"| %s\n", indent, from, mermaidLabel(label), to)
+		}
+
+		if len(scenes) > 1 {
+			fmt.Fprintln(w, "  end")
+		}
+	}
+	return nil
+}
+
+// printSceneSignals writes the signal connections of the given files as a Mermaid flowchart
+func printSceneSignals(out io.Writer, files []string) error {
+	var scenes []*GodotScene
+	for _, file := range files {
+		scene, err := parseSceneFile(file)
+		if err != nil {
+			return fmt.Errorf("parse error: %v", err)
+		}
+		scenes = append(scenes, scene)
+	}
+	return writeMermaidSignals(out, files, scenes)
+}
@@ -0,0 +1,62 @@
+package gdquery
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMermaidSignals(t *testing.T) {
+	content := `[gd_scene load_steps=1 format=3]
+
+[node name="Main" type="Node2D"]
+
+[node name="Button" type="Button" parent="."]
+
+[node name="Enemy" parent="." instance=ExtResource("1_e")]
+
+[connection signal="pressed" from="Button" to="." method="_on_button_pressed"]
+[connection signal="died" from="Enemy/Health" to="." method="_on_died" flags=3 binds=[[1, 2], "boss"]]
+`
+
+	tempFile := "test_signals.tscn"
+	if err := os.WriteFile(tempFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(scene.Connections) != 2 {
+		t.Fatalf("Expected 2 connections, got %d", len(scene.Connections))
+	}
+	died := scene.Connections[1]
+	if died.From != "Enemy/Health" || died.To != "." || died.Method != "_on_died" || died.Flags != 3 || died.Binds != `[[1, 2], "boss"]` {
+		t.Errorf("Unexpected connection: %+v", died)
+	}
+	if died.Span.StartLine != 10 {
+		t.Errorf("Expected the connection at line 10, got %d", died.Span.StartLine)
+	}
+
+	// Godot 3 writes spaces around the binds array
+	if binds := parseConnection(`[connection signal="timeout" from="Timer" to="." method="_on_timeout" binds= [ 1 ]]`).Binds; binds != "[ 1 ]" {
+		t.Errorf("Unexpected Godot 3 binds: %q", binds)
+	}
+
+	var b strings.Builder
+	if err := writeMermaidSignals(&b, []string{tempFile}, []*GodotScene{scene}); err != nil {
+		t.Fatalf("Mermaid error: %v", err)
+	}
+	expected := `flowchart LR
+  s0n0["Main/Button (Button)"]
+  s0n1["Main (Node2D)"]
+  s0n0 -->|"pressed → _on_button_pressed()"| s0n1
+  s0n2["Main/Enemy/Health"]
+  s0n2 -->|"died → _on_died() binds [[1, 2], #quot;boss#quot;]"| s0n1
+`
+	if b.String() != expected {
+		t.Errorf("Unexpected Mermaid output:\n%s", b.String())
+	}
+}
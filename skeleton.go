@@ -0,0 +1,380 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// skeletonClasses are the skeleton node types (Skeleton in Godot 3)
+var skeletonClasses = map[string]bool{"Skeleton3D": true, "Skeleton": true}
+
+// boneAttachmentClasses are the bone attachment node types (BoneAttachment in Godot 3)
+var boneAttachmentClasses = map[string]bool{"BoneAttachment3D": true, "BoneAttachment": true}
+
+// bonePropertyRe matches the per-bone properties of a skeleton, bones/<index>/<name>
+var bonePropertyRe = regexp.MustCompile(`^bones/(\d+)/(\w+)$`)
+
+// skinBindRe matches the per-bind properties of a Skin, bind/<index>/<name>
+var skinBindRe = regexp.MustCompile(`^bind/(\d+)/(\w+)$`)
+
+// SkeletonBone is a bone of a skeleton node
+type SkeletonBone struct {
+	Name   string
+	Parent int // index of the parent bone, -1 for a root
+}
+
+// skeletonBones returns the bones of a skeleton node by index, as written in the scene.
+// Skeletons of imported scenes have no bones in the scene that instances them.
+func skeletonBones(node *GodotNode) []*SkeletonBone {
+	bones := make(map[int]*SkeletonBone)
+	count := 0
+	for key, value := range node.Properties {
+		matches := bonePropertyRe.FindStringSubmatch(key)
+		if matches == nil {
+			continue
+		}
+		index, _ := strconv.Atoi(matches[1])
+		bone := bones[index]
+		if bone == nil {
+			bone = &SkeletonBone{Parent: -1}
+			bones[index] = bone
+			count = max(count, index+1)
+		}
+		switch matches[2] {
+		case "name":
+			bone.Name = unquoteName(value)
+		case "parent":
+			bone.Parent, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+
+	list := make([]*SkeletonBone, count)
+	for i := range list {
+		if list[i] = bones[i]; list[i] == nil {
+			list[i] = &SkeletonBone{Parent: -1}
+		}
+	}
+	return list
+}
+
+// describeBones summarizes the bones of a skeleton in place of their raw transforms
+func describeBones(bones []*SkeletonBone) string {
+	var roots []string
+	for _, bone := range bones {
+		if bone.Parent < 0 {
+			roots = append(roots, bone.Name)
+		}
+	}
+	description := fmt.Sprintf("%d bone(s)", len(bones))
+	if len(roots) > 0 {
+		description += fmt.Sprintf(", root %s", joinSummaryItems(roots))
+	}
+	return description
+}
+
+// findNodeByNodePath resolves a NodePath written on node, or returns nil.
+// Unique names and absolute paths are not resolved.
+func findNodeByNodePath(node *GodotNode, nodePath string) *GodotNode {
+	nodePath, _, _ = strings.Cut(nodePath, ":")
+	if nodePath == "" || strings.HasPrefix(nodePath, "/") || strings.HasPrefix(nodePath, "%") {
+		return nil
+	}
+	for _, segment := range strings.Split(nodePath, "/") {
+		switch segment {
+		case "", ".":
+		case "..":
+			node = node.ParentNode()
+		default:
+			var next *GodotNode
+			for _, child := range node.Children {
+				if child.Name == segment {
+					next = child
+					break
+				}
+			}
+			node = next
+		}
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// nodePathProperty returns the path of a NodePath property, or the class default
+func nodePathProperty(node *GodotNode, key string) string {
+	value, exists := node.Properties[key]
+	if !exists {
+		value, _ = propertyDefault(nodeClass(node), key)
+	}
+	if matches := nodePathValueRe.FindStringSubmatch(value); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
+
+// BoneAttachment is a bone attachment node with the bone it follows
+type BoneAttachment struct {
+	Node     *GodotNode
+	Skeleton *GodotNode // nil when the skeleton is not in the scene
+	Bone     string     // bone name, or #index when only bone_idx is set
+	// Missing is set when the skeleton has bones in the scene but not this one
+	Missing bool
+}
+
+// findBoneAttachments returns the bone attachments of a scene with their
+// skeleton: the parent, or external_skeleton with use_external_skeleton
+func findBoneAttachments(scene *GodotScene) []*BoneAttachment {
+	var attachments []*BoneAttachment
+	for _, node := range scene.AllNodes {
+		if !boneAttachmentClasses[node.Type] {
+			continue
+		}
+		attachment := &BoneAttachment{Node: node, Skeleton: node.ParentNode()}
+		if node.Properties["use_external_skeleton"] == "true" {
+			attachment.Skeleton = findNodeByNodePath(node, nodePathProperty(node, "external_skeleton"))
+		}
+		if attachment.Skeleton != nil && !skeletonClasses[attachment.Skeleton.Type] {
+			attachment.Skeleton = nil
+		}
+
+		var bones []*SkeletonBone
+		if attachment.Skeleton != nil {
+			bones = skeletonBones(attachment.Skeleton)
+		}
+		if name, exists := node.Properties["bone_name"]; exists && unquoteName(name) != "" {
+			attachment.Bone = unquoteName(name)
+			attachment.Missing = len(bones) > 0
+			for _, bone := range bones {
+				if bone.Name == attachment.Bone {
+					attachment.Missing = false
+				}
+			}
+		} else if index, exists := node.Properties["bone_idx"]; exists {
+			i, _ := strconv.Atoi(strings.TrimSpace(index))
+			attachment.Bone = fmt.Sprintf("#%d", i)
+			attachment.Missing = len(bones) > 0 && (i < 0 || i >= len(bones))
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments
+}
+
+// SkinResource is a Skin sub_resource with the meshes using it
+type SkinResource struct {
+	ID        string
+	Binds     []string // bone names of the binds by index
+	UsedBy    []*GodotNode
+	Skeletons []*GodotNode // skeleton of each mesh in UsedBy, nil when not found
+}
+
+// findSkinResources returns the Skin sub_resources of a scene with the mesh
+// instances using them
+func findSkinResources(text *sceneText, scene *GodotScene) []*SkinResource {
+	var skins []*SkinResource
+	byID := make(map[string]*SkinResource)
+	for _, section := range text.Sections {
+		if !strings.HasPrefix(section.Header, "[sub_resource") {
+			continue
+		}
+		resourceType, _ := headerAttr(section.Header, "type")
+		matches := sectionIDRe.FindStringSubmatch(section.Header)
+		if resourceType != "Skin" || matches == nil {
+			continue
+		}
+		skin := &SkinResource{ID: matches[1] + matches[2]}
+		binds := make(map[int]string)
+		count := 0
+		if value, exists := section.Property("bind_count"); exists {
+			count, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+		for _, line := range section.Lines {
+			key, value, ok := splitPropertyLine(line)
+			bind := skinBindRe.FindStringSubmatch(key)
+			if !ok || bind == nil {
+				continue
+			}
+			index, _ := strconv.Atoi(bind[1])
+			count = max(count, index+1)
+			if bind[2] == "name" {
+				binds[index] = unquoteName(value)
+			}
+		}
+		skin.Binds = make([]string, count)
+		for i := range skin.Binds {
+			skin.Binds[i] = binds[i]
+		}
+		skins = append(skins, skin)
+		byID[skin.ID] = skin
+	}
+
+	for _, node := range scene.AllNodes {
+		matches := subResourceRefRe.FindStringSubmatch(strings.TrimSpace(node.Properties["skin"]))
+		if matches == nil || byID[matches[1]+matches[2]] == nil {
+			continue
+		}
+		skin := byID[matches[1]+matches[2]]
+		skeleton := findNodeByNodePath(node, nodePathProperty(node, "skeleton"))
+		if skeleton != nil && !skeletonClasses[skeleton.Type] {
+			skeleton = nil
+		}
+		skin.UsedBy = append(skin.UsedBy, node)
+		skin.Skeletons = append(skin.Skeletons, skeleton)
+	}
+	return skins
+}
+
+// printSkeletons displays the skeletons of a scene with their attachments,
+// then the skins
+func printSkeletons(out io.Writer, scene *GodotScene, text *sceneText) {
+	attachments := findBoneAttachments(scene)
+	skins := findSkinResources(text, scene)
+	skeletons := 0
+	for _, node := range scene.AllNodes {
+		if skeletonClasses[node.Type] {
+			skeletons++
+		}
+	}
+	if skeletons == 0 && len(attachments) == 0 && len(skins) == 0 {
+		fmt.Fprintln(out, "No skeletons, bone attachments or skins")
+		return
+	}
+
+	printAttachment := func(attachment *BoneAttachment, indent string) {
+		fmt.Fprintf(out, "%s%s %s -> %s", indent, attachment.Node.Type, attachment.Node.Path, attachment.Bone)
+		if attachment.Missing {
+			fmt.Fprint(out, " [MISSING BONE]")
+		}
+		fmt.Fprintln(out)
+	}
+	for _, node := range scene.AllNodes {
+		if !skeletonClasses[node.Type] {
+			continue
+		}
+		bones := skeletonBones(node)
+		if len(bones) > 0 {
+			fmt.Fprintf(out, "%s %s: %s\n", node.Type, node.Path, describeBones(bones))
+		} else {
+			fmt.Fprintf(out, "%s %s: no bones in the scene (imported)\n", node.Type, node.Path)
+		}
+		for _, attachment := range attachments {
+			if attachment.Skeleton == node {
+				printAttachment(attachment, "  ")
+			}
+		}
+	}
+	for _, attachment := range attachments {
+		if attachment.Skeleton == nil {
+			printAttachment(attachment, "")
+		}
+	}
+
+	for _, skin := range skins {
+		fmt.Fprintf(out, "Skin %s: %d bind(s)", skin.ID, len(skin.Binds))
+		var users []string
+		for i, node := range skin.UsedBy {
+			user := node.Path
+			if skeleton := skin.Skeletons[i]; skeleton != nil {
+				known := make(map[string]bool)
+				for _, bone := range skeletonBones(skeleton) {
+					known[bone.Name] = true
+				}
+				unbound := 0
+				for _, name := range skin.Binds {
+					if name != "" && len(known) > 0 && !known[name] {
+						unbound++
+					}
+				}
+				user += " (skeleton " + skeleton.Path
+				if unbound > 0 {
+					user += fmt.Sprintf(", %d bind(s) without bone", unbound)
+				}
+				user += ")"
+			}
+			users = append(users, user)
+		}
+		sort.Strings(users)
+		if len(users) > 0 {
+			fmt.Fprintf(out, ", used by %s", strings.Join(users, ", "))
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// checkAttachmentBones reports bone attachments following a bone their
+// skeleton does not have
+func checkAttachmentBones(ctx *LintContext) []LintFinding {
+	var findings []LintFinding
+	for _, result := range ctx.Scenes() {
+		if result.Err != nil {
+			continue
+		}
+		for _, attachment := range findBoneAttachments(result.Scene) {
+			if !attachment.Missing {
+				continue
+			}
+			findings = append(findings, LintFinding{
+				Severity: severityError,
+				File:     result.File,
+				Line:     attachment.Node.Span.StartLine,
+				Message: fmt.Sprintf("%s %s follows bone %s, which %s %s does not have",
+					attachment.Node.Type, attachment.Node.Path, attachment.Bone, attachment.Skeleton.Type, attachment.Skeleton.Path),
+			})
+		}
+	}
+	return findings
+}
+
+var skeletonsCmd = &cobra.Command{
+	Use:   "skeletons <tscn file> [more files...]",
+	Short: "Summarize skeletons, bone attachments and skins",
+	Long: `Summarize the Skeleton3D nodes of 3D character scenes instead of dumping their bone transforms:
+bone count and root bones, the BoneAttachment3D nodes following each skeleton with their bone,
+and the Skin sub_resources with their bind count and the meshes using them. Attachments
+following a bone their skeleton does not have are marked MISSING BONE; the
+missing-attachment-bone lint rule reports them project-wide. Godot 3 Skeleton and
+BoneAttachment nodes are included.`,
+	Example:      `  gdq skeletons characters/player.tscn`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		for i, file := range args {
+			if _, err := os.Stat(file); os.IsNotExist(err) {
+				return fmt.Errorf("file not found: %s", file)
+			}
+			scene, err := parseSceneFile(file)
+			if err != nil {
+				return fmt.Errorf("parse error: %v", err)
+			}
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("read error: %v", err)
+			}
+			if len(args) > 1 {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				fmt.Fprintf(out, "=== %s ===\n", file)
+			}
+			printSkeletons(out, scene, splitSceneText(string(content)))
+		}
+		return nil
+	},
+}
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "missing-attachment-bone",
+		Description: "BoneAttachment3D nodes following a bone name their skeleton does not have, which Godot does not attach",
+		Check:       checkAttachmentBones,
+	})
+	rootCmd.AddCommand(skeletonsCmd)
+}
@@ -0,0 +1,94 @@
+package gdquery
+
+import (
+	"strings"
+	"testing"
+)
+
+const skeletonScene = `[gd_scene load_steps=2 format=3]
+
+[sub_resource type="Skin" id="Skin_body"]
+bind_count = 3
+bind/0/name = &"Hips"
+bind/0/bone = -1
+bind/1/name = &"Spine"
+bind/1/bone = -1
+bind/2/name = &"Tail"
+bind/2/bone = -1
+
+[node name="Player" type="Node3D"]
+
+[node name="Skeleton3D" type="Skeleton3D" parent="."]
+bones/0/name = "Hips"
+bones/0/parent = -1
+bones/0/rest = Transform3D(1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 1, 0)
+bones/1/name = "Spine"
+bones/1/parent = 0
+bones/1/rest = Transform3D(1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0.2, 0)
+bones/2/name = "Head"
+bones/2/parent = 1
+bones/2/rest = Transform3D(1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0.5, 0)
+
+[node name="Body" type="MeshInstance3D" parent="Skeleton3D"]
+skin = SubResource("Skin_body")
+
+[node name="HatSocket" type="BoneAttachment3D" parent="Skeleton3D"]
+bone_name = "Head"
+bone_idx = 2
+
+[node name="SwordSocket" type="BoneAttachment3D" parent="Skeleton3D"]
+bone_name = "Hand.R"
+bone_idx = 5
+
+[node name="Shadow" type="BoneAttachment3D" parent="."]
+bone_name = "Hips"
+use_external_skeleton = true
+external_skeleton = NodePath("../Skeleton3D")
+
+[node name="Imported" type="Skeleton3D" parent="."]
+
+[node name="Cape" type="BoneAttachment3D" parent="Imported"]
+bone_name = "Neck"
+`
+
+func TestSkeletonSummaries(t *testing.T) {
+	scene, err := ParseReader(strings.NewReader(skeletonScene), "player.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	skeleton := findNodeByExactPath(scene.RootNode, "Player/Skeleton3D")
+	if got := describeBones(skeletonBones(skeleton)); got != "3 bone(s), root Hips" {
+		t.Errorf("Unexpected bones: %s", got)
+	}
+
+	var got []string
+	for _, attachment := range findBoneAttachments(scene) {
+		skeletonPath := "-"
+		if attachment.Skeleton != nil {
+			skeletonPath = attachment.Skeleton.Path
+		}
+		got = append(got, strings.Join([]string{attachment.Node.Path, skeletonPath, attachment.Bone}, " ")+map[bool]string{true: " missing"}[attachment.Missing])
+	}
+	expected := []string{
+		"Player/Skeleton3D/HatSocket Player/Skeleton3D Head",
+		"Player/Skeleton3D/SwordSocket Player/Skeleton3D Hand.R missing",
+		"Player/Shadow Player/Skeleton3D Hips",
+		"Player/Imported/Cape Player/Imported Neck",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected attachments:\n%s", strings.Join(got, "\n"))
+	}
+
+	skins := findSkinResources(splitSceneText(skeletonScene), scene)
+	if len(skins) != 1 {
+		t.Fatalf("Expected 1 skin, got %d", len(skins))
+	}
+	skin := skins[0]
+	if skin.ID != "Skin_body" || strings.Join(skin.Binds, " ") != "Hips Spine Tail" {
+		t.Errorf("Unexpected skin: %s %v", skin.ID, skin.Binds)
+	}
+	if len(skin.UsedBy) != 1 || skin.UsedBy[0].Path != "Player/Skeleton3D/Body" || skin.Skeletons[0] != skeleton {
+		t.Errorf("Unexpected skin users: %v", skin.UsedBy)
+	}
+}
@@ -0,0 +1,306 @@
+package gdquery
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "stale-uid",
+		Description: "ext_resources whose uid and path disagree with the uids of the project files, which Godot warns about on load",
+		Check:       checkStaleUIDs,
+		Fix:         fixStaleUIDs,
+		Versions:    []int{4},
+	})
+}
+
+// uidCacheFile is the editor cache of the uids of the project, under the project root
+const uidCacheFile = ".godot/uid_cache.bin"
+
+// UIDIndex maps the uid:// of the project resources to their res:// path and back
+type UIDIndex struct {
+	Paths map[string]string // uid -> res:// path
+	UIDs  map[string]string // res:// path -> uid
+}
+
+// add records the uid of a resource unless the uid is already known
+func (u *UIDIndex) add(uid, resPath string) {
+	if !strings.HasPrefix(uid, "uid://") || uid == "uid://<invalid>" {
+		return
+	}
+	if _, exists := u.Paths[uid]; exists {
+		return
+	}
+	u.Paths[uid] = resPath
+	if _, exists := u.UIDs[resPath]; !exists {
+		u.UIDs[resPath] = uid
+	}
+}
+
+// uidText encodes a uid the way Godot writes it: base 34 (a-y, 0-8), most
+// significant digit first
+func uidText(id int64) string {
+	if id < 0 {
+		return "uid://<invalid>"
+	}
+	const letters = 'z' - 'a'
+	const base = letters + ('9' - '0')
+	var digits []byte
+	for {
+		c := byte(id % base)
+		if c < letters {
+			digits = append(digits, 'a'+c)
+		} else {
+			digits = append(digits, '0'+c-letters)
+		}
+		id /= base
+		if id == 0 {
+			break
+		}
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return "uid://" + string(digits)
+}
+
+// readUIDCache reads the uid -> res:// path entries of the editor uid cache:
+// an entry count, then for each entry the uid and the length-prefixed path
+func readUIDCache(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var count uint32
+	if err := binary.Read(f, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+	entries := make(map[string]string)
+	for i := uint32(0); i < count; i++ {
+		var id int64
+		var length uint32
+		if err := binary.Read(f, binary.LittleEndian, &id); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(f, binary.LittleEndian, &length); err != nil {
+			return nil, err
+		}
+		if length > 4096 {
+			return nil, fmt.Errorf("invalid path length %d", length)
+		}
+		buffer := make([]byte, length)
+		if _, err := io.ReadFull(f, buffer); err != nil {
+			return nil, err
+		}
+		entries[uidText(id)] = strings.TrimRight(string(buffer), "\x00")
+	}
+	return entries, nil
+}
+
+// buildUIDIndex collects the uids declared by the files of the project: the
+// header of text scenes and resources, .uid files next to scripts and shaders,
+// and the [remap] section of .import files. The editor uid cache completes
+// them for the resources whose file still exists.
+func buildUIDIndex(root string) (*UIDIndex, error) {
+	index := &UIDIndex{Paths: make(map[string]string), UIDs: make(map[string]string)}
+	files, err := findProjectFiles(root, append([]string{".uid", ".import"}, sceneExtensions...))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		switch filepath.Ext(file) {
+		case ".uid":
+			content, err := os.ReadFile(file)
+			if err != nil {
+				logger.Warn("Skipping file", "path", file, "error", err)
+				continue
+			}
+			index.add(strings.TrimSpace(string(content)), fsToRes(root, strings.TrimSuffix(file, ".uid")))
+		case ".import":
+			config, err := parseConfigFile(file)
+			if err != nil {
+				logger.Warn("Skipping file", "path", file, "error", err)
+				continue
+			}
+			index.add(config.GetString("remap", "uid"), fsToRes(root, strings.TrimSuffix(file, ".import")))
+		default:
+			content, err := os.ReadFile(file)
+			if err != nil {
+				logger.Warn("Skipping file", "path", file, "error", err)
+				continue
+			}
+			text := splitSceneText(string(content))
+			if len(text.Sections) > 0 {
+				uid, _ := headerAttr(text.Sections[0].Header, "uid")
+				index.add(uid, fsToRes(root, file))
+			}
+		}
+	}
+
+	cache, err := readUIDCache(filepath.Join(root, uidCacheFile))
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warn("Skipping uid cache", "path", uidCacheFile, "error", err)
+	}
+	for uid, resPath := range cache {
+		if _, err := os.Stat(resToFS(root, resPath)); err == nil {
+			index.add(uid, resPath)
+		}
+	}
+	return index, nil
+}
+
+// StaleUID is an ext_resource whose uid and path do not point to the same
+// resource. Fix names the attribute to rewrite ("path" or "uid") with Value,
+// an empty Value dropping it; stale pairs without a fix have an empty Fix.
+type StaleUID struct {
+	Line    int
+	Section *sceneSection
+	UID     string
+	Path    string // res:// path of the declaration
+	Target  string // res:// path of the resource with the uid, if any
+	Fix     string
+	Value   string
+}
+
+// findStaleUIDs lists the ext_resources of a text scene that declare a uid and
+// a path of different resources. Godot loads the resource of a known uid and
+// falls back to the path, with a warning, for unknown uids.
+func findStaleUIDs(text *sceneText, fileRes, root string, index *UIDIndex) []*StaleUID {
+	var stale []*StaleUID
+	line := len(text.Preamble)
+	for _, section := range text.Sections {
+		line++
+		headerLine := line
+		line += len(section.Lines)
+		header := section.Header
+		if !strings.HasPrefix(header, "[ext_resource") {
+			continue
+		}
+		uid, hasUID := headerAttr(header, "uid")
+		path, hasPath := headerAttr(header, "path")
+		if !hasUID || !hasPath {
+			continue
+		}
+		path = normalizeResPath(fileRes, path)
+		target, known := index.Paths[uid]
+		if known && target == path {
+			continue
+		}
+
+		entry := &StaleUID{Line: headerLine, Section: section, UID: uid, Path: path, Target: target}
+		_, err := os.Stat(resToFS(root, path))
+		switch {
+		case known:
+			entry.Fix, entry.Value = "path", target
+		case err != nil:
+			// Neither the uid nor the path leads to a resource
+		case index.UIDs[path] != "":
+			entry.Fix, entry.Value = "uid", index.UIDs[path]
+		default:
+			entry.Fix = "uid"
+		}
+		stale = append(stale, entry)
+	}
+	return stale
+}
+
+// message describes the stale pair for lint output
+func (s *StaleUID) message() string {
+	switch {
+	case s.Target != "":
+		return fmt.Sprintf("ext_resource %s points to %s, not to its path %s; fixable with --fix (path updated)", s.UID, s.Target, s.Path)
+	case s.Fix == "":
+		return fmt.Sprintf("ext_resource %s does not point to a valid resource and %s does not exist", s.UID, s.Path)
+	case s.Value != "":
+		return fmt.Sprintf("ext_resource %s does not point to a valid resource, %s has %s; fixable with --fix (uid updated)", s.UID, s.Path, s.Value)
+	default:
+		return fmt.Sprintf("ext_resource %s does not point to a valid resource and %s has no uid; fixable with --fix (uid removed)", s.UID, s.Path)
+	}
+}
+
+// checkStaleUIDs reports the ext_resources of the scenes and resources of the
+// linted directory whose uid and path disagree
+func checkStaleUIDs(ctx *LintContext) []LintFinding {
+	index, err := buildUIDIndex(ctx.Root)
+	if err != nil {
+		logger.Warn("UID scan failed", "error", err)
+		return nil
+	}
+	files, err := findProjectFiles(ctx.Dir, sceneExtensions)
+	if err != nil {
+		logger.Warn("Scene scan failed", "error", err)
+		return nil
+	}
+
+	var findings []LintFinding
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			logger.Warn("Skipping file", "path", file, "error", err)
+			continue
+		}
+		fileRes := fsToRes(ctx.Root, file)
+		for _, stale := range findStaleUIDs(splitSceneText(string(content)), fileRes, ctx.Root, index) {
+			severity := severityWarning
+			if stale.Fix == "" {
+				severity = severityError
+			}
+			findings = append(findings, LintFinding{
+				Severity: severity,
+				File:     fileRes,
+				Line:     stale.Line,
+				Message:  stale.message(),
+			})
+		}
+	}
+	return findings
+}
+
+// fixStaleUIDs points the stale ext_resources of the linted directory to the
+// resource Godot loads: the path of a known uid, or the uid of the path
+func fixStaleUIDs(ctx *LintContext) (int, error) {
+	index, err := buildUIDIndex(ctx.Root)
+	if err != nil {
+		return 0, err
+	}
+	files, err := findProjectFiles(ctx.Dir, sceneExtensions)
+	if err != nil {
+		return 0, err
+	}
+
+	fixed := 0
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fixed, err
+		}
+		text := splitSceneText(string(content))
+		changed := false
+		for _, stale := range findStaleUIDs(text, fsToRes(ctx.Root, file), ctx.Root, index) {
+			if stale.Fix != "" {
+				stale.Section.Header = setHeaderAttr(stale.Section.Header, stale.Fix, stale.Value)
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return fixed, err
+		}
+		if err := os.WriteFile(file, []byte(text.String()), info.Mode()); err != nil {
+			return fixed, err
+		}
+		fixed++
+	}
+	return fixed, nil
+}
@@ -0,0 +1,470 @@
+package gdquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// TreeMetrics holds the shape metrics of a scene tree
+type TreeMetrics struct {
+	NodeCount   int
+	MaxDepth    int // the root node is at depth 0
+	ParentCount int // nodes with at least one child
+	ChildCount  int // total number of parent-child links
+	MaxChildren int
+	WidestLevel int // depth of the level with the most nodes
+	WidestCount int
+	LongestPath string
+	DeepestPath string
+	levelWidths []int
+}
+
+// AvgChildren returns the average number of children of nodes that have children
+func (m *TreeMetrics) AvgChildren() float64 {
+	if m.ParentCount == 0 {
+		return 0
+	}
+	return float64(m.ChildCount) / float64(m.ParentCount)
+}
+
+// computeTreeMetrics measures depth and fan-out of the tree below node
+func computeTreeMetrics(node *GodotNode) *TreeMetrics {
+	metrics := &TreeMetrics{}
+	if node == nil {
+		return metrics
+	}
+
+	node.Walk(func(node *GodotNode, depth int) WalkAction {
+		metrics.NodeCount++
+		if depth >= len(metrics.levelWidths) {
+			metrics.levelWidths = append(metrics.levelWidths, 0)
+		}
+		metrics.levelWidths[depth]++
+
+		if depth > metrics.MaxDepth || metrics.DeepestPath == "" {
+			metrics.MaxDepth = depth
+			metrics.DeepestPath = node.Path
+		}
+		if len(node.Path) > len(metrics.LongestPath) {
+			metrics.LongestPath = node.Path
+		}
+
+		if len(node.Children) > 0 {
+			metrics.ParentCount++
+			metrics.ChildCount += len(node.Children)
+			metrics.MaxChildren = max(metrics.MaxChildren, len(node.Children))
+		}
+		return WalkContinue
+	})
+
+	for depth, width := range metrics.levelWidths {
+		if width > metrics.WidestCount {
+			metrics.WidestLevel = depth
+			metrics.WidestCount = width
+		}
+	}
+
+	return metrics
+}
+
+// printTreeMetrics displays the tree shape metrics
+func printTreeMetrics(out io.Writer, metrics *TreeMetrics) {
+	fmt.Fprintf(out, "Max Depth: %d (%s)\n", metrics.MaxDepth, metrics.DeepestPath)
+	fmt.Fprintf(out, "Average Children per Parent: %.2f\n", metrics.AvgChildren())
+	fmt.Fprintf(out, "Max Children: %d\n", metrics.MaxChildren)
+	fmt.Fprintf(out, "Widest Level: depth %d (%d nodes)\n", metrics.WidestLevel, metrics.WidestCount)
+	fmt.Fprintf(out, "Longest Node Path: %s (%d chars)\n", metrics.LongestPath, len(metrics.LongestPath))
+}
+
+// SubtreeStats summarizes the nodes below a queried node (--stat)
+type SubtreeStats struct {
+	Root          string         `json:"root"`
+	NodeCount     int            `json:"node_count"`
+	ScriptedNodes int            `json:"scripted_nodes"`
+	MaxDepth      int            `json:"max_depth"` // below the subtree root, at depth 0
+	DeepestPath   string         `json:"deepest_path"`
+	MaxChildren   int            `json:"max_children"`
+	Types         map[string]int `json:"types"`
+	Scripts       map[string]int `json:"scripts,omitempty"`   // nodes per script path
+	Instances     map[string]int `json:"instances,omitempty"` // nodes per instanced scene
+	metrics       *TreeMetrics
+}
+
+// computeSubtreeStats counts the nodes of the subtree of node by type, script
+// and instanced scene
+func computeSubtreeStats(scene *GodotScene, node *GodotNode) *SubtreeStats {
+	metrics := computeTreeMetrics(node)
+	stats := &SubtreeStats{
+		Root:        node.Path,
+		NodeCount:   metrics.NodeCount,
+		MaxDepth:    metrics.MaxDepth,
+		DeepestPath: metrics.DeepestPath,
+		MaxChildren: metrics.MaxChildren,
+		Types:       make(map[string]int),
+		Scripts:     make(map[string]int),
+		Instances:   make(map[string]int),
+		metrics:     metrics,
+	}
+	node.Walk(func(n *GodotNode, depth int) WalkAction {
+		nodeType := n.Type
+		if nodeType == "" {
+			nodeType = n.InstanceType
+		}
+		if nodeType == "" {
+			nodeType = "(unknown)"
+		}
+		stats.Types[nodeType]++
+		if n.Script != "" {
+			stats.ScriptedNodes++
+			script := resolveResourcePath(n.Script, scene)
+			if script == "" {
+				script = n.Script
+			}
+			stats.Scripts[script]++
+		}
+		if n.Instance != "" {
+			if instance := resolveResourcePath(n.Instance, scene); instance != "" {
+				stats.Instances[instance]++
+			}
+		}
+		return WalkContinue
+	})
+	return stats
+}
+
+// printCounts displays counts by decreasing count, then by name
+func printCounts(out io.Writer, title string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	fmt.Fprintf(out, "\n%s:\n", title)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %s: %d\n", name, counts[name])
+	}
+}
+
+// printSubtreeStats displays the statistics of a queried subtree
+func printSubtreeStats(out io.Writer, stats *SubtreeStats) {
+	fmt.Fprintf(out, "=== Subtree Statistics: %s ===\n", stats.Root)
+	fmt.Fprintf(out, "Total Nodes: %d\n", stats.NodeCount)
+	fmt.Fprintf(out, "Nodes with Scripts: %d\n", stats.ScriptedNodes)
+	printTreeMetrics(out, stats.metrics)
+	printCounts(out, "By Node Type", stats.Types)
+	printCounts(out, "Scripts", stats.Scripts)
+	printCounts(out, "Instanced Scenes", stats.Instances)
+}
+
+// SceneScanResult is the result of parsing one scene in a project scan
+type SceneScanResult struct {
+	File    string
+	Scene   *GodotScene
+	Metrics *TreeMetrics
+	Err     error
+}
+
+// ProjectStats aggregates the metrics of all scanned scenes
+type ProjectStats struct {
+	SceneCount  int
+	ErrorCount  int
+	NodeCount   int
+	ParentCount int
+	ChildCount  int
+	MaxDepth    int
+	DeepestFile string
+	DeepestPath string
+	WidestCount int
+	WidestFile  string
+	LongestPath string
+	LongestFile string
+}
+
+// add merges the metrics of one scene into the project statistics
+func (p *ProjectStats) add(result *SceneScanResult) {
+	if result.Err != nil {
+		p.ErrorCount++
+		return
+	}
+
+	m := result.Metrics
+	p.SceneCount++
+	p.NodeCount += m.NodeCount
+	p.ParentCount += m.ParentCount
+	p.ChildCount += m.ChildCount
+	if m.MaxDepth > p.MaxDepth || p.DeepestFile == "" {
+		p.MaxDepth, p.DeepestFile, p.DeepestPath = m.MaxDepth, result.File, m.DeepestPath
+	}
+	if m.WidestCount > p.WidestCount {
+		p.WidestCount, p.WidestFile = m.WidestCount, result.File
+	}
+	if len(m.LongestPath) > len(p.LongestPath) {
+		p.LongestPath, p.LongestFile = m.LongestPath, result.File
+	}
+}
+
+// scanProject parses every scene under dir, naming them by res:// path relative to root
+func scanProject(root, dir string, opts ParseOptions) ([]*SceneScanResult, error) {
+	var results []*SceneScanResult
+	err := scanProjectFunc(root, dir, opts, func(result *SceneScanResult) error {
+		results = append(results, result)
+		return nil
+	})
+	return results, err
+}
+
+// scanProjectFunc parses the scenes under dir in parallel and passes each
+// result to fn in file order as soon as it is available. At most one scene per
+// CPU is parsed ahead of fn. Scanning stops when fn fails, once the parses
+// already started are done.
+func scanProjectFunc(root, dir string, opts ParseOptions, fn func(result *SceneScanResult) error) error {
+	files, err := findProjectFiles(dir, nodeSceneExtensions)
+	if err != nil {
+		return err
+	}
+
+	parser := NewParser(opts)
+	pending := make([]chan *SceneScanResult, len(files))
+	for i := range pending {
+		pending[i] = make(chan *SceneScanResult, 1)
+	}
+	slots := make(chan struct{}, runtime.NumCPU())
+	done := make(chan struct{})
+	// The parses still running when fn fails are waited for before returning
+	var workers sync.WaitGroup
+	defer workers.Wait()
+	defer close(done)
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		for i, file := range files {
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			}
+			workers.Add(1)
+			go func(file string, out chan<- *SceneScanResult) {
+				defer workers.Done()
+				result := &SceneScanResult{File: fsToRes(root, file)}
+				result.Scene, result.Err = parser.ParseFile(file)
+				if result.Err == nil {
+					result.Metrics = computeTreeMetrics(result.Scene.RootNode)
+				}
+				out <- result
+			}(file, pending[i])
+		}
+	}()
+
+	progress := newProgress("Scanning", len(files))
+	defer progress.Clear()
+	for _, results := range pending {
+		result := <-results
+		<-slots
+		progress.Clear()
+		if err := fn(result); err != nil {
+			return err
+		}
+		progress.Step()
+	}
+
+	return nil
+}
+
+// writeScanTable writes one row of metrics per scene
+func writeScanTable(out io.Writer, results []*SceneScanResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCENE\tNODES\tDEPTH\tAVG CHILDREN\tWIDEST LEVEL\tLONGEST PATH")
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(w, "%s\terror: %v\t\t\t\t\n", result.File, result.Err)
+			continue
+		}
+		m := result.Metrics
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%d (depth %d)\t%d\n",
+			result.File, m.NodeCount, m.MaxDepth, m.AvgChildren(), m.WidestCount, m.WidestLevel, len(m.LongestPath))
+	}
+	return w.Flush()
+}
+
+// printScanResults displays one row of metrics per scene and the project totals
+func printScanResults(out io.Writer, results []*SceneScanResult, version GodotVersion) {
+	stats := &ProjectStats{}
+	for _, result := range results {
+		stats.add(result)
+	}
+	writeScanTable(out, results)
+
+	fmt.Fprintln(out, "\n=== Project Statistics ===")
+	if version.Known() {
+		fmt.Fprintf(out, "Godot Version: %s\n", version)
+	}
+	fmt.Fprintf(out, "Scenes: %d\n", stats.SceneCount)
+	if stats.ErrorCount > 0 {
+		fmt.Fprintf(out, "Parse Errors: %d\n", stats.ErrorCount)
+	}
+	fmt.Fprintf(out, "Total Nodes: %d\n", stats.NodeCount)
+	if stats.SceneCount == 0 {
+		return
+	}
+	avgChildren := 0.0
+	if stats.ParentCount > 0 {
+		avgChildren = float64(stats.ChildCount) / float64(stats.ParentCount)
+	}
+	fmt.Fprintf(out, "Average Nodes per Scene: %.2f\n", float64(stats.NodeCount)/float64(stats.SceneCount))
+	fmt.Fprintf(out, "Max Depth: %d (%s: %s)\n", stats.MaxDepth, stats.DeepestFile, stats.DeepestPath)
+	fmt.Fprintf(out, "Average Children per Parent: %.2f\n", avgChildren)
+	fmt.Fprintf(out, "Widest Level: %d nodes (%s)\n", stats.WidestCount, stats.WidestFile)
+	fmt.Fprintf(out, "Longest Node Path: %s (%s, %d chars)\n", stats.LongestPath, stats.LongestFile, len(stats.LongestPath))
+}
+
+// Scan command options
+var scanOutDir = ""
+var scanResourceDirs = false
+var scanDirDepth = 0
+
+// scanOutputExtensions are the extensions of the per-scene files written by --out-dir
+var scanOutputExtensions = map[string]string{"text": ".txt", "json": ".json", "jsonl": ".jsonl"}
+
+// writeScanResultFile writes the output for one scene to a file under dir
+// mirroring the scene path (res://levels/a.tscn -> dir/levels/a.tscn.json)
+func writeScanResultFile(dir string, result *SceneScanResult) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(result.File, "res://"))+scanOutputExtensions[outputFormat])
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	switch outputFormat {
+	case "json":
+		err = writeJSON(file, scanResultToJSON(result))
+	case "jsonl":
+		err = json.NewEncoder(file).Encode(scanResultToJSON(result))
+	default:
+		err = writeScanTable(file, []*SceneScanResult{result})
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return path, err
+}
+
+var scanCmd = &cobra.Command{
+	Use:          "scan [project dir]",
+	Short:        "Scan all scenes of a project and display tree statistics",
+	Long:         `Parse every scene under a directory and display per-scene tree metrics (node count, depth, fan-out) and project-wide aggregates.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json", "jsonl"); err != nil {
+			return err
+		}
+
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		root := findProjectRoot(dir)
+		// The metrics only need the hierarchy
+		opts := ParseOptions{SkipProperties: true, Logger: logger}
+
+		// Reference counts per resource directory instead of the tree metrics
+		if scanResourceDirs {
+			if err := validateOutputFormat("text", "json"); err != nil {
+				return err
+			}
+			results, err := scanProject(root, dir, opts)
+			if err != nil {
+				return fmt.Errorf("scan error: %v", err)
+			}
+			usages := buildDirectoryUsage(results, scanDirDepth)
+			if outputFormat == "json" {
+				return writeJSON(out, usages)
+			}
+			return writeDirectoryUsage(out, usages)
+		}
+
+		// Write one file per scene without keeping the parsed scenes
+		if scanOutDir != "" {
+			written := 0
+			err := scanProjectFunc(root, dir, opts, func(result *SceneScanResult) error {
+				if _, err := writeScanResultFile(scanOutDir, result); err != nil {
+					return err
+				}
+				written++
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("scan error: %v", err)
+			}
+			fmt.Fprintf(out, "Wrote %d file(s) to %s\n", written, scanOutDir)
+			return nil
+		}
+
+		// Stream one line per scene without keeping the parsed scenes
+		if outputFormat == "jsonl" {
+			err := scanProjectFunc(root, dir, opts, func(result *SceneScanResult) error {
+				return writeJSONLine(out, scanResultToJSON(result))
+			})
+			if err != nil {
+				return fmt.Errorf("scan error: %v", err)
+			}
+			return nil
+		}
+
+		results, err := scanProject(root, dir, opts)
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+
+		if outputFormat == "json" {
+			list := make([]*ScanResultJSON, 0, len(results))
+			for _, result := range results {
+				list = append(list, scanResultToJSON(result))
+			}
+			return writeJSON(out, list)
+		}
+
+		project, err := parseConfigFile(resToFS(root, "res://project.godot"))
+		if err != nil {
+			project = &ConfigFile{}
+		}
+		printScanResults(out, results, detectProjectVersion(project))
+		return nil
+	},
+}
+
+func init() {
+	scanCmd.Flags().StringVar(&scanOutDir, "out-dir", "", "Write one output file per scene to this directory (e.g. JSON sidecars with -o json)")
+	scanCmd.Flags().BoolVar(&scanResourceDirs, "resource-dirs", false, "Count the resource references of the scenes per resource directory instead")
+	scanCmd.Flags().IntVar(&scanDirDepth, "dir-depth", 0, "Group --resource-dirs by the first N directory levels (0 for the full directory)")
+	rootCmd.AddCommand(scanCmd)
+}
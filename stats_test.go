@@ -0,0 +1,168 @@
+package gdquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTreeMetrics(t *testing.T) {
+	tempFile := "test_metrics.tscn"
+	if err := os.WriteFile(tempFile, []byte(testTscnContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	metrics := computeTreeMetrics(scene.RootNode)
+
+	if metrics.NodeCount != 5 {
+		t.Errorf("Expected 5 nodes, got: %d", metrics.NodeCount)
+	}
+	if metrics.MaxDepth != 3 || metrics.DeepestPath != "Root/Child1/GrandChild/DeepChild" {
+		t.Errorf("Max depth is wrong: %d (%s)", metrics.MaxDepth, metrics.DeepestPath)
+	}
+	// Root has 2 children, Child1 and GrandChild have 1 each
+	if avg := metrics.AvgChildren(); avg < 1.33 || avg > 1.34 {
+		t.Errorf("Average children is wrong: %.2f", avg)
+	}
+	if metrics.WidestLevel != 1 || metrics.WidestCount != 2 {
+		t.Errorf("Widest level is wrong: depth %d (%d nodes)", metrics.WidestLevel, metrics.WidestCount)
+	}
+
+	stats := &ProjectStats{}
+	stats.add(&SceneScanResult{File: "res://a.tscn", Metrics: metrics})
+	stats.add(&SceneScanResult{File: "res://b.tscn", Metrics: &TreeMetrics{NodeCount: 1}})
+	if stats.SceneCount != 2 || stats.NodeCount != 6 || stats.DeepestFile != "res://a.tscn" {
+		t.Errorf("Project aggregation is wrong: %+v", stats)
+	}
+}
+
+func TestScanProjectFunc(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"a.tscn":     testTscnContent,
+		"b/b.tscn":   testTscnContent,
+		"c/c.tscn":   "[gd_scene format=3]\n\n[node name=\"C\" type=\"Node\"]\n",
+		"broken.gd":  "extends Node\n",
+		"d/res.tres": "[gd_resource type=\"Theme\" format=3]\n\n[resource]\n",
+	})
+
+	// Results are passed on one by one and scanning stops when the callback fails
+	stop := errors.New("stop")
+	var files []string
+	err := scanProjectFunc(root, root, ParseOptions{}, func(result *SceneScanResult) error {
+		files = append(files, result.File)
+		if result.Metrics == nil || result.Metrics.NodeCount == 0 {
+			t.Errorf("Missing metrics for %s", result.File)
+		}
+		if len(files) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("Expected the callback error, got %v", err)
+	}
+	if len(files) != 2 || files[0] != "res://a.tscn" || files[1] != "res://b/b.tscn" {
+		t.Errorf("Unexpected scanned files: %v", files)
+	}
+
+	results, err := scanProject(root, root, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("Expected 3 scenes, got %d", len(results))
+	}
+}
+
+func TestScanOutDir(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot":     "config_version=5\n",
+		"a.tscn":            testTscnContent,
+		"levels/b.tscn":     "[gd_scene format=3]\n\n[node name=\"B\" type=\"Node\"]\n",
+		"levels/readme.txt": "not a scene\n",
+	})
+	outDir := filepath.Join(t.TempDir(), "reports")
+	out := filepath.Join(t.TempDir(), "summary.txt")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"scan", "-o", "json", "--out-dir", outDir, "--out", out, root}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Scan error: %s", stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected the output in the --out file, got %q", stdout.String())
+	}
+
+	summary, err := os.ReadFile(out)
+	if err != nil || string(summary) != "Wrote 2 file(s) to "+outDir+"\n" {
+		t.Errorf("Unexpected --out file: %q (%v)", summary, err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, "levels", "b.tscn.json"))
+	if err != nil {
+		t.Fatalf("Sidecar error: %v", err)
+	}
+	var result ScanResultJSON
+	if err := json.Unmarshal(content, &result); err != nil {
+		t.Fatalf("Sidecar JSON error: %v", err)
+	}
+	if result.File != "res://levels/b.tscn" || result.Nodes != 1 {
+		t.Errorf("Unexpected sidecar: %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "a.tscn.json")); err != nil {
+		t.Errorf("Expected a sidecar for a.tscn: %v", err)
+	}
+}
+
+func TestSubtreeStats(t *testing.T) {
+	content := `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://enemy.gd" id="1_s"]
+[ext_resource type="PackedScene" path="res://coin.tscn" id="2_c"]
+
+[node name="Level" type="Node2D"]
+
+[node name="Enemies" type="Node2D" parent="."]
+
+[node name="Bat" type="CharacterBody2D" parent="Enemies"]
+script = ExtResource("1_s")
+
+[node name="Sprite" type="Sprite2D" parent="Enemies/Bat"]
+
+[node name="Rat" type="CharacterBody2D" parent="Enemies"]
+script = ExtResource("1_s")
+
+[node name="Coin" parent="Enemies/Rat" instance=ExtResource("2_c")]
+
+[node name="HUD" type="CanvasLayer" parent="."]
+`
+	scene, err := ParseReader(strings.NewReader(content), "level.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	stats := computeSubtreeStats(scene, findNodeByPath(scene, "Enemies"))
+	if stats.Root != "Level/Enemies" || stats.NodeCount != 5 || stats.ScriptedNodes != 2 || stats.MaxDepth != 2 || stats.MaxChildren != 2 {
+		t.Errorf("Unexpected subtree stats: %+v", stats)
+	}
+	if stats.Types["CharacterBody2D"] != 2 || stats.Types["Node2D"] != 1 || stats.Types["CanvasLayer"] != 0 {
+		t.Errorf("Unexpected type counts: %v", stats.Types)
+	}
+	if stats.Scripts["res://enemy.gd"] != 2 || stats.Instances["res://coin.tscn"] != 1 {
+		t.Errorf("Unexpected script or instance counts: %v %v", stats.Scripts, stats.Instances)
+	}
+
+	output := captureOutput(func(out io.Writer) { printSubtreeStats(out, stats) })
+	if !strings.Contains(output, "By Node Type:\n  CharacterBody2D: 2\n") {
+		t.Errorf("Expected types by decreasing count:\n%s", output)
+	}
+}
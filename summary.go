@@ -0,0 +1,281 @@
+package gdquery
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Summary command options
+var summaryBase = ""
+
+// summaryMaxItems is the number of items named on a summary line before "and N more"
+const summaryMaxItems = 3
+
+// SceneSummary is the human summary of the changes of a scene against a revision
+type SceneSummary struct {
+	File   string   `json:"file"`   // res:// path
+	Status string   `json:"status"` // changed, new or removed
+	Lines  []string `json:"lines"`
+}
+
+// summaryNodeName names the node at path below the root of scene for a summary
+func summaryNodeName(scene *GodotScene, path string) string {
+	if path == "." {
+		return scene.RootNode.Name
+	}
+	_, name := splitNodePath(path)
+	return name
+}
+
+// joinSummaryItems joins items as "a, b, c and 2 more"
+func joinSummaryItems(items []string) string {
+	if len(items) > summaryMaxItems {
+		return fmt.Sprintf("%s and %d more", strings.Join(items[:summaryMaxItems], ", "), len(items)-summaryMaxItems)
+	}
+	return strings.Join(items, ", ")
+}
+
+// countSubtrees groups the topmost of the given node paths by parent, with
+// the number of nodes below each: the nodes of a removed subtree count for
+// the subtree, whose nodes are in all
+func countSubtrees(paths []string, all map[string]*GodotNode) (map[string]int, []string) {
+	counts := make(map[string]int)
+	var parents []string
+	for _, path := range paths {
+		parent, _ := splitNodePath(path)
+		if _, exists := counts[parent]; !exists {
+			parents = append(parents, parent)
+		}
+		for nodePath := range all {
+			if inSubtree(nodePath, path) {
+				counts[parent]++
+			}
+		}
+	}
+	return counts, parents
+}
+
+// extDependencies returns the res:// paths of the ext_resources of a scene
+func extDependencies(scene *GodotScene) map[string]bool {
+	paths := make(map[string]bool)
+	for _, resource := range scene.ExtResources {
+		if resource.Path != "" {
+			paths[resource.Path] = true
+		}
+	}
+	return paths
+}
+
+// summarizeSceneChanges describes in a few lines the changes between two
+// versions of a scene: nodes added and removed by parent, types changed,
+// properties changed on existing nodes, connections and dependencies
+func summarizeSceneChanges(oldScene, newScene *GodotScene) []string {
+	if oldScene.RootNode == nil || newScene.RootNode == nil {
+		return nil
+	}
+	oldNodes, newNodes := diffNodes(oldScene), diffNodes(newScene)
+
+	var added, removed, types, properties []string
+	addedPaths := make(map[string]bool)
+	connections := map[string]int{}
+	for _, change := range diffScenes(oldScene, newScene) {
+		switch change.Op {
+		case opAddNode:
+			addedPaths[change.Path] = true
+			if parent, _ := splitNodePath(change.Path); !addedPaths[parent] {
+				added = append(added, change.Path)
+			}
+		case opRemoveNode:
+			removed = append(removed, change.Path)
+		case opSetType:
+			types = append(types, fmt.Sprintf("%s %s -> %s", summaryNodeName(newScene, change.Path), *change.Old, *change.New))
+		case opSetProperty, opRemoveProperty:
+			if !addedPaths[change.Path] {
+				item := fmt.Sprintf("%s of %s", change.Property, summaryNodeName(newScene, change.Path))
+				if len(properties) == 0 || properties[len(properties)-1] != item {
+					properties = append(properties, item)
+				}
+			}
+		case opAddConnection, opRemoveConnection:
+			connections[change.Op]++
+		}
+	}
+
+	var lines []string
+	var nodes []string
+	counts, parents := countSubtrees(added, newNodes)
+	for _, parent := range parents {
+		nodes = append(nodes, fmt.Sprintf("+%d node(s) under %s", counts[parent], summaryNodeName(newScene, parent)))
+	}
+	counts, parents = countSubtrees(removed, oldNodes)
+	for _, parent := range parents {
+		nodes = append(nodes, fmt.Sprintf("-%d node(s) under %s", counts[parent], summaryNodeName(oldScene, parent)))
+	}
+	if len(nodes) > 0 {
+		lines = append(lines, joinSummaryItems(nodes))
+	}
+	if len(types) > 0 {
+		lines = append(lines, "type of "+joinSummaryItems(types))
+	}
+	if len(properties) > 0 {
+		lines = append(lines, joinSummaryItems(properties)+" changed")
+	}
+	if connections[opAddConnection]+connections[opRemoveConnection] > 0 {
+		var items []string
+		if n := connections[opAddConnection]; n > 0 {
+			items = append(items, fmt.Sprintf("+%d connection(s)", n))
+		}
+		if n := connections[opRemoveConnection]; n > 0 {
+			items = append(items, fmt.Sprintf("-%d connection(s)", n))
+		}
+		lines = append(lines, strings.Join(items, ", "))
+	}
+
+	oldDeps, newDeps := extDependencies(oldScene), extDependencies(newScene)
+	var deps []string
+	for _, path := range sortedSetKeys(newDeps) {
+		if !oldDeps[path] {
+			deps = append(deps, "new dependency "+filepath.Base(path))
+		}
+	}
+	for _, path := range sortedSetKeys(oldDeps) {
+		if !newDeps[path] {
+			deps = append(deps, "dropped dependency "+filepath.Base(path))
+		}
+	}
+	if len(deps) > 0 {
+		lines = append(lines, joinSummaryItems(deps))
+	}
+	return lines
+}
+
+// sortedSetKeys returns the keys of a set in sorted order
+func sortedSetKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// summarizeChangedScenes summarizes every scene under root changed since base
+func summarizeChangedScenes(root, base string) ([]*SceneSummary, error) {
+	files, err := changedScenes(root, base)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []*SceneSummary
+	for _, file := range files {
+		summary := &SceneSummary{File: "res://" + filepath.ToSlash(file), Status: "changed"}
+		options := ParseOptions{KeepRawLines: true, Logger: logger}
+
+		var oldScene, newScene *GodotScene
+		// "./" makes the path relative to the working directory instead of the repository root
+		if content, err := gitOutput(root, "show", base+":./"+filepath.ToSlash(file)); err == nil {
+			if oldScene, err = ParseReader(bytes.NewReader(content), file, options); err != nil {
+				return nil, fmt.Errorf("%s at %s: %v", file, base, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(root, file)); err == nil {
+			if newScene, err = ParseFile(filepath.Join(root, file), options); err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+		}
+
+		switch {
+		case oldScene == nil && newScene == nil:
+			continue
+		case oldScene == nil:
+			summary.Status = "new"
+			line := fmt.Sprintf("new scene with %d node(s)", len(newScene.AllNodes))
+			if newScene.RootNode != nil {
+				line += fmt.Sprintf(", root %s (%s)", newScene.RootNode.Name, typeLabel(newScene.RootNode))
+			}
+			summary.Lines = []string{line}
+		case newScene == nil:
+			summary.Status = "removed"
+			summary.Lines = []string{fmt.Sprintf("scene removed (%d node(s))", len(oldScene.AllNodes))}
+		default:
+			summary.Lines = summarizeSceneChanges(oldScene, newScene)
+			if len(summary.Lines) == 0 {
+				summary.Lines = []string{"formatting only"}
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// printSceneSummaries displays the summaries as Markdown, ready for a PR comment
+func printSceneSummaries(out io.Writer, summaries []*SceneSummary) {
+	for i, summary := range summaries {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "**%s**\n", summary.File)
+		for _, line := range summary.Lines {
+			fmt.Fprintf(out, "- %s\n", line)
+		}
+	}
+}
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary --base <git ref> [project dir]",
+	Short: "Summarize the changes of every changed scene against a git revision for PR comments",
+	Long: `For every scene changed since the base git revision (committed, uncommitted and untracked
+changes), print a short human summary of what changed, in Markdown to post as a PR comment
+from CI: nodes added and removed by parent, type changes, properties changed on existing nodes,
+connections, and ext_resource dependencies added or dropped. Use gdq diff for the full list.`,
+	Example: `  gdq summary --base origin/main
+  gdq summary --base origin/main > summary.md && gh pr comment --body-file summary.md`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		if summaryBase == "" {
+			return fmt.Errorf("--base is required")
+		}
+
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		summaries, err := summarizeChangedScenes(findProjectRoot(dir), summaryBase)
+		if err != nil {
+			return fmt.Errorf("summary error: %v", err)
+		}
+		if outputFormat == "json" {
+			if summaries == nil {
+				summaries = []*SceneSummary{}
+			}
+			return writeJSON(out, summaries)
+		}
+		if len(summaries) == 0 {
+			fmt.Fprintf(out, "No scenes changed since %s\n", summaryBase)
+			return nil
+		}
+		printSceneSummaries(out, summaries)
+		return nil
+	},
+}
+
+func init() {
+	summaryCmd.Flags().StringVar(&summaryBase, "base", "", "Git revision to compare with (e.g. origin/main)")
+	rootCmd.AddCommand(summaryCmd)
+}
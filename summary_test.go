@@ -0,0 +1,107 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeSceneChanges(t *testing.T) {
+	oldContent := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Texture2D" path="res://old.png" id="1_o"]
+
+[node name="Main" type="Node2D"]
+
+[node name="HUD" type="CanvasLayer" parent="."]
+
+[node name="Score" type="Label" parent="HUD"]
+text = "0"
+
+[node name="Enemies" type="Node2D" parent="."]
+
+[node name="Bat" type="Node2D" parent="Enemies"]
+
+[node name="Wing" type="Sprite2D" parent="Enemies/Bat"]
+texture = ExtResource("1_o")
+`
+	newContent := `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Texture2D" path="res://art/coin.png" id="1_c"]
+
+[node name="Main" type="Node2D"]
+
+[node name="HUD" type="CanvasLayer" parent="."]
+
+[node name="Score" type="Label" parent="HUD"]
+text = "Score: 0"
+
+[node name="Coins" type="HBoxContainer" parent="HUD"]
+
+[node name="Coin" type="TextureRect" parent="HUD/Coins"]
+texture = ExtResource("1_c")
+
+[node name="Enemies" type="Node2D" parent="."]
+
+[connection signal="ready" from="." to="." method="_on_ready"]
+`
+	oldScene, err := ParseReader(strings.NewReader(oldContent), "old.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	newScene, err := ParseReader(strings.NewReader(newContent), "new.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	expected := []string{
+		"+2 node(s) under HUD, -2 node(s) under Enemies",
+		"text of Score changed",
+		"+1 connection(s)",
+		"new dependency coin.png, dropped dependency old.png",
+	}
+	lines := summarizeSceneChanges(oldScene, newScene)
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected summary:\n%s", strings.Join(lines, "\n"))
+	}
+
+	if lines := summarizeSceneChanges(oldScene, oldScene); len(lines) != 0 {
+		t.Errorf("Expected no summary for an unchanged scene: %v", lines)
+	}
+}
+
+func TestJoinSummaryItems(t *testing.T) {
+	if got := joinSummaryItems([]string{"a", "b", "c", "d", "e"}); got != "a, b, c and 2 more" {
+		t.Errorf("Unexpected join: %q", got)
+	}
+	if got := joinSummaryItems([]string{"a", "b"}); got != "a, b" {
+		t.Errorf("Unexpected join: %q", got)
+	}
+}
+
+func TestSummarizeSceneChangesRepeatedNames(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("test", "sample.tscn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the root's Control changes; Control/scrapScene/Control and the
+	// TextureRects below the other Controls do not
+	modified := strings.Replace(string(content), "offset_right = 40.0\n", "offset_right = 60.0\n", 1)
+	oldScene, err := ParseReader(strings.NewReader(string(content)), "old.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	newScene, err := ParseReader(strings.NewReader(modified), "new.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	lines := summarizeSceneChanges(oldScene, newScene)
+	if strings.Join(lines, "\n") != "offset_right of Control changed" {
+		t.Errorf("Unexpected summary:\n%s", strings.Join(lines, "\n"))
+	}
+	if lines := summarizeSceneChanges(oldScene, oldScene); len(lines) != 0 {
+		t.Errorf("Expected no summary for an unchanged scene: %v", lines)
+	}
+}
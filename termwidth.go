@@ -0,0 +1,123 @@
+package gdquery
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Property value display options
+var noTruncate = false
+var wrapValues = false
+
+// defaultValueWidth is the number of columns over which property values are
+// cut when the output is not a terminal
+const defaultValueWidth = 100
+
+// minValueWidth is the room left to values on narrow terminals or below deep nodes
+const minValueWidth = 20
+
+// runeWidth returns the number of terminal columns of r; CJK punctuation
+// (、。「」) is full-width too
+func runeWidth(r rune) int {
+	if isWideRune(r) || r >= 0x3000 && r <= 0x303F {
+		return 2
+	}
+	return 1
+}
+
+// displayWidth returns the number of terminal columns of s
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// isTerminal reports whether w is a file open on a terminal
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the width of the terminal out writes to, 0 when out is
+// not a terminal. COLUMNS sets the width, terminal or not.
+func terminalWidth(out io.Writer) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if !isTerminal(out) {
+		return 0
+	}
+	return fileTerminalWidth(out.(*os.File))
+}
+
+// valueWidth returns the number of columns left to a property value on a line
+// of out starting with prefix: the rest of the terminal line, or
+// defaultValueWidth when the terminal width is unknown
+func valueWidth(out io.Writer, prefix string) int {
+	columns := terminalWidth(out)
+	if columns == 0 {
+		return defaultValueWidth
+	}
+	return max(minValueWidth, columns-displayWidth(prefix))
+}
+
+// cutDisplay splits s after at most width columns, never inside a UTF-8
+// character; at least one character goes to the head so that cutting progresses
+func cutDisplay(s string, width int) (head, tail string) {
+	used := 0
+	for i, r := range s {
+		used += runeWidth(r)
+		if used > width && i > 0 {
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+// truncateDisplay cuts s to width columns, "..." included, when it is wider
+func truncateDisplay(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	head, _ := cutDisplay(s, max(1, width-3))
+	return head + "..."
+}
+
+// wrapDisplay splits s into lines of at most width columns, also breaking it
+// at its own newlines
+func wrapDisplay(s string, width int) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		for displayWidth(line) > width {
+			var head string
+			head, line = cutDisplay(line, width)
+			lines = append(lines, head)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// formatPropertyValue lays out a property value printed to out after prefix:
+// truncated to the room left on the line, wrapped onto lines aligned under the
+// value with --wrap, or whole with --no-truncate. Wrapped lines start with
+// treePrefix, the tree connectors at the start of prefix.
+func formatPropertyValue(out io.Writer, prefix, treePrefix, value string) string {
+	switch {
+	case noTruncate:
+		return value
+	case wrapValues:
+		indent := treePrefix + strings.Repeat(" ", displayWidth(prefix)-displayWidth(treePrefix))
+		return strings.Join(wrapDisplay(value, valueWidth(out, prefix)), "\n"+indent)
+	default:
+		return truncateDisplay(value, valueWidth(out, prefix))
+	}
+}
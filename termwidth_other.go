@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package gdquery
+
+import "os"
+
+// fileTerminalWidth returns 0: the terminal size is not queried on this
+// platform, set COLUMNS instead
+func fileTerminalWidth(file *os.File) int {
+	return 0
+}
@@ -0,0 +1,69 @@
+package gdquery
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateDisplay(t *testing.T) {
+	tests := []struct {
+		value    string
+		width    int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"abcdefghijkl", 10, "abcdefg..."},
+		// Full-width characters take two columns and are never split
+		{"こんにちは世界", 10, "こんに..."},
+		{"こんにちは世界", 14, "こんにちは世界"},
+		{"aこんにちは", 8, "aこん..."},
+	}
+	for _, test := range tests {
+		got := truncateDisplay(test.value, test.width)
+		if got != test.expected {
+			t.Errorf("truncateDisplay(%q, %d) = %q, expected %q", test.value, test.width, got, test.expected)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncateDisplay(%q, %d) is not valid UTF-8", test.value, test.width)
+		}
+	}
+}
+
+func TestWrapDisplay(t *testing.T) {
+	lines := wrapDisplay("こんにちは、世界。abc\nxyz", 6)
+	expected := "こんに|ちは、|世界。|abc|xyz"
+	if got := strings.Join(lines, "|"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestFormatPropertyValue(t *testing.T) {
+	t.Setenv("COLUMNS", "40")
+	value := strings.Repeat("x", 50)
+	prefix := "│     text: "
+
+	noTruncate, wrapValues = false, false
+	if got := formatPropertyValue(io.Discard, prefix, "│ ", value); got != strings.Repeat("x", 25)+"..." {
+		t.Errorf("Expected the value cut to the 28 remaining columns, got %q", got)
+	}
+
+	wrapValues = true
+	expected := strings.Repeat("x", 28) + "\n│           " + strings.Repeat("x", 22)
+	if got := formatPropertyValue(io.Discard, prefix, "│ ", value); got != expected {
+		t.Errorf("Expected the value wrapped under itself, got %q", got)
+	}
+
+	noTruncate = true
+	if got := formatPropertyValue(io.Discard, prefix, "│ ", value); got != value {
+		t.Errorf("Expected the whole value, got %q", got)
+	}
+	noTruncate, wrapValues = false, false
+
+	// Without a terminal, values are cut at 100 columns
+	t.Setenv("COLUMNS", "")
+	if got := formatPropertyValue(io.Discard, prefix, "│ ", strings.Repeat("x", 150)); got != strings.Repeat("x", 97)+"..." {
+		t.Errorf("Expected the value cut at 100 columns, got %d columns", displayWidth(got))
+	}
+}
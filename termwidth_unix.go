@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package gdquery
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// fileTerminalWidth asks the terminal of file for its number of columns, 0 when unknown
+func fileTerminalWidth(file *os.File) int {
+	var size struct {
+		Rows, Cols, XPixel, YPixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0
+	}
+	return int(size.Cols)
+}
@@ -0,0 +1,159 @@
+package gdquery
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultFontSize is the font size of Godot's default theme
+const defaultFontSize = 16
+
+// ThemeResolver looks up theme items of nodes the way Godot does: theme
+// overrides on the node, then the themes of the node and its ancestors, then
+// the project theme and project default font size
+type ThemeResolver struct {
+	Root    string
+	project *ConfigFile
+	texts   map[string]*sceneText // parsed text resources by filesystem path, nil when unreadable
+}
+
+// newThemeResolver creates a resolver for the project at root
+func newThemeResolver(root string, project *ConfigFile) *ThemeResolver {
+	if project == nil {
+		project = &ConfigFile{}
+	}
+	return &ThemeResolver{Root: root, project: project, texts: make(map[string]*sceneText)}
+}
+
+// loadText returns the sections of a text resource or scene, cached by path
+func (r *ThemeResolver) loadText(path string) *sceneText {
+	if text, cached := r.texts[path]; cached {
+		return text
+	}
+
+	var text *sceneText
+	content, err := os.ReadFile(path)
+	if err != nil {
+		logger.Debug("Cannot read resource", "path", path, "error", err)
+	} else {
+		text = splitSceneText(string(content))
+	}
+	r.texts[path] = text
+	return text
+}
+
+// resourceSection returns the section holding the properties of the resource
+// referenced by ref (ExtResource or SubResource) in scene, or nil
+func (r *ThemeResolver) resourceSection(scene *GodotScene, ref string) *sceneSection {
+	subResourceRe := regexp.MustCompile(`SubResource\(\s*"?([^")\s]*)"?\s*\)`)
+	if matches := subResourceRe.FindStringSubmatch(ref); len(matches) > 1 {
+		if text := r.loadText(scene.File); text != nil {
+			return text.subResourceSection(matches[1])
+		}
+		return nil
+	}
+
+	path := resolveResourcePath(ref, scene)
+	if path == "" {
+		return nil
+	}
+	return r.resourceFileSection(normalizeResPath(fsToRes(r.Root, scene.File), path))
+}
+
+// resourceFileSection returns the [resource] section of a res:// text resource, or nil
+func (r *ThemeResolver) resourceFileSection(resPath string) *sceneSection {
+	text := r.loadText(resToFS(r.Root, resPath))
+	if text == nil {
+		return nil
+	}
+	return text.resourceSection()
+}
+
+// intValue parses an integer property value
+func intValue(value string) (int, bool) {
+	f, err := parseVariantFloat(value)
+	if err != nil {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// themeTypes returns the theme types searched for a node: its type variation
+// followed by its class and base classes
+func themeTypes(node *GodotNode) []string {
+	var types []string
+	if variation := node.Properties["theme_type_variation"]; variation != "" {
+		types = append(types, unquoteValue(strings.TrimPrefix(variation, "&")))
+	}
+	for class, seen := node.Type, 0; class != "" && seen < 64; seen++ {
+		types = append(types, class)
+		info := lookupClass(class)
+		if info == nil {
+			break
+		}
+		class = info.Inherits
+	}
+	return types
+}
+
+// FontSize returns the font size a node uses for the given theme item
+// (font_size for Label, normal_font_size for RichTextLabel)
+func (r *ThemeResolver) FontSize(scene *GodotScene, node *GodotNode, item string) int {
+	if size, ok := intValue(node.Properties["theme_override_font_sizes/"+item]); ok {
+		return size
+	}
+
+	// LabelSettings replace the theme font size entirely
+	if ref, exists := node.Properties["label_settings"]; exists && node.Type == "Label" {
+		if section := r.resourceSection(scene, ref); section != nil {
+			if value, exists := section.Property("font_size"); exists {
+				if size, ok := intValue(value); ok {
+					return size
+				}
+			}
+		}
+		return defaultFontSize
+	}
+
+	// Themes of the node and its ancestors, nearest first, then the project theme
+	var themes []*sceneSection
+	ancestors := getPathToNode(scene, node)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if ref, exists := ancestors[i].Properties["theme"]; exists {
+			if section := r.resourceSection(scene, ref); section != nil {
+				themes = append(themes, section)
+			}
+		}
+	}
+	if custom := r.project.GetString("gui", "theme/custom"); custom != "" {
+		if section := r.resourceFileSection(custom); section != nil {
+			themes = append(themes, section)
+		}
+	}
+
+	types := themeTypes(node)
+	for _, theme := range themes {
+		for _, themeType := range types {
+			if value, exists := theme.Property(themeType + "/font_sizes/" + item); exists {
+				if size, ok := intValue(value); ok {
+					return size
+				}
+			}
+		}
+	}
+	for _, theme := range themes {
+		if value, exists := theme.Property("default_font_size"); exists {
+			if size, ok := intValue(value); ok {
+				return size
+			}
+		}
+	}
+
+	if value, exists := r.project.Get("gui", "theme/default_font_size"); exists {
+		if size, ok := intValue(value); ok {
+			return size
+		}
+	}
+	return defaultFontSize
+}
@@ -0,0 +1,143 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// gdscriptToolRe matches the @tool annotation (Godot 4) or the tool keyword
+// (Godot 3) at the top level of a GDScript
+var gdscriptToolRe = regexp.MustCompile(`(?m)^(@tool\b|tool[ \t]*(#.*)?\r?$)`)
+
+// ToolScriptNode is a node whose script runs in the editor
+type ToolScriptNode struct {
+	Path   string `json:"path"`
+	Type   string `json:"type"`
+	Script string `json:"script"` // res:// path
+}
+
+// ToolScriptScene lists the nodes of a scene with tool scripts
+type ToolScriptScene struct {
+	Scene string            `json:"scene"`
+	Nodes []*ToolScriptNode `json:"nodes"`
+}
+
+// isToolScript reports whether the GDScript at resPath runs in the editor.
+// Results are cached by path; unreadable and non-GDScript files are not tool scripts.
+func isToolScript(root, resPath string, cache map[string]bool) bool {
+	if tool, exists := cache[resPath]; exists {
+		return tool
+	}
+	tool := false
+	if strings.HasSuffix(resPath, ".gd") {
+		if content, err := os.ReadFile(resToFS(root, resPath)); err == nil {
+			tool = gdscriptToolRe.Match(content)
+		}
+	}
+	cache[resPath] = tool
+	return tool
+}
+
+// findToolScriptNodes lists, per scene, the nodes whose attached script
+// declares @tool. Built-in scripts are not read.
+func findToolScriptNodes(root string, results []*SceneScanResult) []*ToolScriptScene {
+	cache := make(map[string]bool)
+	var scenes []*ToolScriptScene
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		scene := &ToolScriptScene{Scene: result.File}
+		for _, node := range result.Scene.AllNodes {
+			script := resolveResourcePath(node.Script, result.Scene)
+			if script == "" || !strings.HasPrefix(script, "res://") {
+				continue
+			}
+			if isToolScript(root, script, cache) {
+				scene.Nodes = append(scene.Nodes, &ToolScriptNode{Path: node.Path, Type: typeLabel(node), Script: script})
+			}
+		}
+		if len(scene.Nodes) > 0 {
+			scenes = append(scenes, scene)
+		}
+	}
+	return scenes
+}
+
+// printToolScriptNodes displays the nodes with tool scripts grouped by scene
+func printToolScriptNodes(out io.Writer, scenes []*ToolScriptScene) {
+	scripts := make(map[string]bool)
+	nodes := 0
+	for _, scene := range scenes {
+		fmt.Fprintln(out, scene.Scene)
+		for _, node := range scene.Nodes {
+			fmt.Fprintf(out, "  %s (%s) [Script: %s]\n", node.Path, node.Type, node.Script)
+			scripts[node.Script] = true
+			nodes++
+		}
+	}
+	if len(scenes) > 0 {
+		fmt.Fprintln(out)
+	}
+
+	var names []string
+	for script := range scripts {
+		names = append(names, script)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(out, "%d node(s) in %d scene(s) use %d tool script(s)\n", nodes, len(scenes), len(names))
+	for _, name := range names {
+		fmt.Fprintf(out, "  %s\n", name)
+	}
+}
+
+var toolScriptsCmd = &cobra.Command{
+	Use:   "tool-scripts [project dir]",
+	Short: "List the nodes whose script runs in the editor (@tool)",
+	Long: `List, per scene, the nodes whose attached GDScript declares @tool (tool in Godot 3), followed
+by the tool scripts they use. Tool scripts run inside the editor as soon as a scene using them is
+opened, so teams keep an inventory of them for safety reviews. Built-in scripts are not read.`,
+	Example: `  gdq tool-scripts path/to/project
+  gdq tool-scripts -o json addons/`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json"); err != nil {
+			return err
+		}
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return fmt.Errorf("directory not found: %s", dir)
+		}
+
+		root := findProjectRoot(dir)
+		results, err := scanProject(root, dir, ParseOptions{SkipProperties: true, Logger: logger})
+		if err != nil {
+			return fmt.Errorf("scan error: %v", err)
+		}
+		scenes := findToolScriptNodes(root, results)
+
+		if outputFormat == "json" {
+			if scenes == nil {
+				scenes = []*ToolScriptScene{}
+			}
+			return writeJSON(out, scenes)
+		}
+		printToolScriptNodes(out, scenes)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(toolScriptsCmd)
+}
@@ -0,0 +1,55 @@
+package gdquery
+
+import "testing"
+
+func TestFindToolScriptNodes(t *testing.T) {
+	root := writeProjectFiles(t, map[string]string{
+		"project.godot": "config_version=5\n",
+		"spawner.gd":    "@tool\nextends Node2D\n",
+		"old_tool.gd":   "tool\nextends Node2D\n",
+		"player.gd":     "extends Node2D\n\n# @tool in a comment\nvar tool = 1\n",
+		"level.tscn": `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Script" path="res://spawner.gd" id="1_s"]
+[ext_resource type="Script" path="res://player.gd" id="2_p"]
+[ext_resource type="Script" path="res://old_tool.gd" id="3_o"]
+
+[node name="Level" type="Node2D"]
+
+[node name="Spawner" type="Node2D" parent="."]
+script = ExtResource("1_s")
+
+[node name="Player" type="Node2D" parent="."]
+script = ExtResource("2_p")
+
+[node name="Legacy" type="Node2D" parent="Player"]
+script = ExtResource("3_o")
+`,
+		"menu.tscn": `[gd_scene load_steps=2 format=3]
+
+[ext_resource type="Script" path="res://player.gd" id="1_p"]
+
+[node name="Menu" type="Node2D"]
+script = ExtResource("1_p")
+`,
+	})
+
+	results, err := scanProject(root, root, ParseOptions{SkipProperties: true})
+	if err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	scenes := findToolScriptNodes(root, results)
+	if len(scenes) != 1 || scenes[0].Scene != "res://level.tscn" {
+		t.Fatalf("Expected only res://level.tscn, got %+v", scenes)
+	}
+	nodes := scenes[0].Nodes
+	if len(nodes) != 2 {
+		t.Fatalf("Expected 2 nodes, got %d", len(nodes))
+	}
+	if nodes[0].Path != "Level/Spawner" || nodes[0].Script != "res://spawner.gd" || nodes[0].Type != "Node2D" {
+		t.Errorf("Unexpected node: %+v", nodes[0])
+	}
+	if nodes[1].Path != "Level/Player/Legacy" || nodes[1].Script != "res://old_tool.gd" {
+		t.Errorf("Unexpected node: %+v", nodes[1])
+	}
+}
@@ -0,0 +1,195 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Transform view option
+var showTransforms = false
+
+// transform3D is an affine 3D transform: a row-major basis and an origin, as
+// written in Transform3D(...) values (Transform(...) in Godot 3)
+type transform3D struct {
+	Basis  [3][3]float64
+	Origin [3]float64
+}
+
+// identityTransform3D is the transform of a Node3D without a transform property
+var identityTransform3D = transform3D{Basis: [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}}
+
+// parseTransform3D parses a Transform3D value
+func parseTransform3D(value string) (transform3D, bool) {
+	numbers := parseNumberList(value)
+	if len(numbers) != 12 {
+		return identityTransform3D, false
+	}
+	var t transform3D
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			t.Basis[i][j] = numbers[i*3+j]
+		}
+		t.Origin[i] = numbers[9+i]
+	}
+	return t, true
+}
+
+// multiply returns the transform applying other, then t
+func (t transform3D) multiply(other transform3D) transform3D {
+	var result transform3D
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				result.Basis[i][j] += t.Basis[i][k] * other.Basis[k][j]
+			}
+			result.Origin[i] += t.Basis[i][j] * other.Origin[j]
+		}
+		result.Origin[i] += t.Origin[i]
+	}
+	return result
+}
+
+// scale returns the lengths of the basis axes, negated when the basis is
+// mirrored, as Basis.get_scale does
+func (t transform3D) scale() [3]float64 {
+	b := t.Basis
+	det := b[0][0]*(b[1][1]*b[2][2]-b[1][2]*b[2][1]) -
+		b[0][1]*(b[1][0]*b[2][2]-b[1][2]*b[2][0]) +
+		b[0][2]*(b[1][0]*b[2][1]-b[1][1]*b[2][0])
+	sign := 1.0
+	if det < 0 {
+		sign = -1
+	}
+	var scale [3]float64
+	for axis := 0; axis < 3; axis++ {
+		scale[axis] = sign * math.Sqrt(b[0][axis]*b[0][axis]+b[1][axis]*b[1][axis]+b[2][axis]*b[2][axis])
+	}
+	return scale
+}
+
+// rotationDegrees returns the Euler angles of the basis in degrees, in the YXZ
+// order Node3D.rotation uses
+func (t transform3D) rotationDegrees() [3]float64 {
+	scale := t.scale()
+	// Normalize the axes, leaving no negative zeros that flip atan2 to -180
+	var m [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if scale[j] != 0 && t.Basis[i][j] != 0 {
+				m[i][j] = t.Basis[i][j] / scale[j]
+			}
+		}
+	}
+
+	var euler [3]float64
+	const epsilon = 1e-6
+	switch {
+	case m[1][2] >= 1-epsilon:
+		euler[0] = -math.Pi / 2
+		euler[1] = -math.Atan2(m[0][1], m[0][0])
+	case m[1][2] <= -(1 - epsilon):
+		euler[0] = math.Pi / 2
+		euler[1] = math.Atan2(m[0][1], m[0][0])
+	default:
+		euler[0] = math.Asin(-m[1][2])
+		euler[1] = math.Atan2(m[0][2], m[2][2])
+		euler[2] = math.Atan2(m[1][0], m[1][1])
+	}
+	for i := range euler {
+		euler[i] *= 180 / math.Pi
+	}
+	return euler
+}
+
+// NodeTransform is a Node3D with its transform decomposed
+type NodeTransform struct {
+	Node   *GodotNode
+	Local  transform3D
+	Global transform3D // relative to the nearest ancestor that is not a Node3D
+}
+
+// isNode3D reports whether a node is a Node3D, or a Spatial in Godot 3
+func isNode3D(node *GodotNode) bool {
+	class := nodeClass(node)
+	if rename, exists := legacyTypeRenames[class]; exists {
+		class = rename.New
+	}
+	return classInherits(class, "Node3D")
+}
+
+// computeNodeTransforms returns the Node3D nodes under root in tree order. The
+// global transform of a node combines those of its Node3D ancestors, up to a
+// node that is not a Node3D or has top_level set.
+func computeNodeTransforms(root *GodotNode) []*NodeTransform {
+	var transforms []*NodeTransform
+	var walk func(node *GodotNode, parent transform3D)
+	walk = func(node *GodotNode, parent transform3D) {
+		global := identityTransform3D
+		if isNode3D(node) {
+			item := &NodeTransform{Node: node, Local: identityTransform3D}
+			if value, exists := node.Properties["transform"]; exists {
+				item.Local, _ = parseTransform3D(value)
+			}
+			if node.Properties["top_level"] == "true" || node.Properties["set_as_toplevel"] == "true" {
+				parent = identityTransform3D
+			}
+			item.Global = parent.multiply(item.Local)
+			global = item.Global
+			transforms = append(transforms, item)
+		}
+		for _, child := range node.Children {
+			walk(child, global)
+		}
+	}
+	if root != nil {
+		walk(root, identityTransform3D)
+	}
+	return transforms
+}
+
+// formatTransformNumber rounds a component to 3 decimals, without negative zeros
+func formatTransformNumber(f float64) string {
+	f = math.Round(f*1000) / 1000
+	if f == 0 {
+		f = 0
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatVector3 formats the components of a vector
+func formatVector3(values [3]float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatTransformNumber(v)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// printTransforms displays the position, rotation and scale of the Node3D
+// nodes under target. Global positions are computed from the scene root.
+func printTransforms(out io.Writer, scene *GodotScene, target *GodotNode) {
+	var transforms []*NodeTransform
+	for _, item := range computeNodeTransforms(scene.RootNode) {
+		if item.Node == target || target.IsAncestorOf(item.Node) {
+			transforms = append(transforms, item)
+		}
+	}
+	if len(transforms) == 0 {
+		fmt.Fprintln(out, "No Node3D nodes found")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tTYPE\tPOSITION\tROTATION (DEG)\tSCALE\tGLOBAL POSITION")
+	for _, item := range transforms {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			item.Node.Path, typeLabel(item.Node),
+			formatVector3(item.Local.Origin), formatVector3(item.Local.rotationDegrees()),
+			formatVector3(item.Local.scale()), formatVector3(item.Global.Origin))
+	}
+	w.Flush()
+}
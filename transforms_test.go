@@ -0,0 +1,59 @@
+package gdquery
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNodeTransforms(t *testing.T) {
+	content := `[gd_scene format=3]
+
+[node name="World" type="Node3D"]
+
+[node name="Pivot" type="Node3D" parent="."]
+transform = Transform3D(-4.37114e-08, 0, 1, 0, 1, 0, -1, 0, -4.37114e-08, 10, 0, 0)
+
+[node name="Mesh" type="MeshInstance3D" parent="Pivot"]
+transform = Transform3D(2, 0, 0, 0, 2, 0, 0, 0, -2, 0, 1, 5)
+
+[node name="Free" type="Node3D" parent="Pivot"]
+transform = Transform3D(1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 7)
+top_level = true
+
+[node name="UI" type="Control" parent="."]
+
+[node name="Cam" type="Camera3D" parent="UI"]
+transform = Transform3D(1, 0, 0, 0, 0.707107, 0.707107, 0, -0.707107, 0.707107, 0, 3, 0)
+`
+	scene, err := ParseReader(strings.NewReader(content), "world.tscn", ParseOptions{})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var got []string
+	for _, item := range computeNodeTransforms(scene.RootNode) {
+		got = append(got, strings.Join([]string{
+			item.Node.Path,
+			formatVector3(item.Local.Origin),
+			formatVector3(item.Local.rotationDegrees()),
+			formatVector3(item.Local.scale()),
+			formatVector3(item.Global.Origin),
+		}, " "))
+	}
+	expected := []string{
+		"World (0, 0, 0) (0, 0, 0) (1, 1, 1) (0, 0, 0)",
+		"World/Pivot (10, 0, 0) (0, 90, 0) (1, 1, 1) (10, 0, 0)",
+		"World/Pivot/Mesh (0, 1, 5) (0, 0, 180) (-2, -2, -2) (15, 1, 0)",
+		"World/Pivot/Free (0, 0, 7) (0, 0, 0) (1, 1, 1) (0, 0, 7)",
+		"World/UI/Cam (0, 3, 0) (-45, 0, 0) (1, 1, 1) (0, 3, 0)",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected transforms:\n%s", strings.Join(got, "\n"))
+	}
+
+	output := captureOutput(func(out io.Writer) { printTransforms(out, scene, findNodeByPath(scene, "Pivot/Mesh")) })
+	if !strings.Contains(output, "World/Pivot/Mesh") || strings.Contains(output, "World/Pivot ") || !strings.Contains(output, "(15, 1, 0)") {
+		t.Errorf("Expected only the queried node with its global position:\n%s", output)
+	}
+}
@@ -0,0 +1,270 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// resourceProperty is a key and its raw Godot value
+type resourceProperty struct {
+	Key   string
+	Value string
+}
+
+// resourceProperties keeps properties in insertion order
+type resourceProperties []resourceProperty
+
+// set adds a property or replaces the value of an existing one
+func (p *resourceProperties) set(key, value string) {
+	for i := range *p {
+		if (*p)[i].Key == key {
+			(*p)[i].Value = value
+			return
+		}
+	}
+	*p = append(*p, resourceProperty{Key: key, Value: value})
+}
+
+// write writes the properties as "key = value" lines
+func (p resourceProperties) write(b *strings.Builder) {
+	for _, property := range p {
+		fmt.Fprintf(b, "%s = %s\n", property.Key, property.Value)
+	}
+}
+
+// SubResourceBuilder is a [sub_resource] being built inside a ResourceBuilder
+type SubResourceBuilder struct {
+	Type       string
+	ID         string
+	properties resourceProperties
+}
+
+// Set sets a property to a raw Godot value (see the Variant* helpers)
+func (s *SubResourceBuilder) Set(key, value string) *SubResourceBuilder {
+	s.properties.set(key, value)
+	return s
+}
+
+// Ref returns the SubResource("id") reference to use as a property value
+func (s *SubResourceBuilder) Ref() string {
+	return fmt.Sprintf("SubResource(%q)", s.ID)
+}
+
+// ResourceBuilder builds a gd_resource (.tres) file
+type ResourceBuilder struct {
+	Type         string
+	ScriptClass  string
+	UID          string
+	Format       int
+	extResources []*GodotResource
+	subResources []*SubResourceBuilder
+	properties   resourceProperties
+}
+
+// NewResourceBuilder creates a builder for a resource of the given class
+func NewResourceBuilder(resourceType string) *ResourceBuilder {
+	return &ResourceBuilder{Type: resourceType, Format: 3}
+}
+
+// AddExtResource declares an external resource and returns the
+// ExtResource("id") reference to use as a property value. Declaring the same
+// path twice returns the same reference.
+func (b *ResourceBuilder) AddExtResource(resourceType, path string) string {
+	for _, resource := range b.extResources {
+		if resource.Path == path {
+			return fmt.Sprintf("ExtResource(%q)", resource.ID)
+		}
+	}
+	resource := &GodotResource{
+		ID:   strconv.Itoa(len(b.extResources) + 1),
+		Type: resourceType,
+		Path: path,
+	}
+	b.extResources = append(b.extResources, resource)
+	return fmt.Sprintf("ExtResource(%q)", resource.ID)
+}
+
+// AddSubResource declares an embedded resource. Sub-resources are written in
+// the order they are added, so add dependencies before the resources using them.
+func (b *ResourceBuilder) AddSubResource(resourceType string) *SubResourceBuilder {
+	sub := &SubResourceBuilder{
+		Type: resourceType,
+		ID:   fmt.Sprintf("%s_%d", resourceType, len(b.subResources)+1),
+	}
+	b.subResources = append(b.subResources, sub)
+	return sub
+}
+
+// Set sets a property of the main [resource] section to a raw Godot value
+func (b *ResourceBuilder) Set(key, value string) *ResourceBuilder {
+	b.properties.set(key, value)
+	return b
+}
+
+// String serializes the resource in the text resource format
+func (b *ResourceBuilder) String() string {
+	var out strings.Builder
+
+	header := fmt.Sprintf("[gd_resource type=%q", b.Type)
+	if b.ScriptClass != "" {
+		header += fmt.Sprintf(" script_class=%q", b.ScriptClass)
+	}
+	if loadSteps := len(b.extResources) + len(b.subResources) + 1; loadSteps > 1 {
+		header += fmt.Sprintf(" load_steps=%d", loadSteps)
+	}
+	header += fmt.Sprintf(" format=%d", b.Format)
+	if b.UID != "" {
+		header += fmt.Sprintf(" uid=%q", b.UID)
+	}
+	out.WriteString(header + "]\n")
+
+	if len(b.extResources) > 0 {
+		out.WriteString("\n")
+		for _, resource := range b.extResources {
+			fmt.Fprintf(&out, "[ext_resource type=%q path=%q id=%q]\n", resource.Type, resource.Path, resource.ID)
+		}
+	}
+
+	for _, sub := range b.subResources {
+		fmt.Fprintf(&out, "\n[sub_resource type=%q id=%q]\n", sub.Type, sub.ID)
+		sub.properties.write(&out)
+	}
+
+	out.WriteString("\n[resource]\n")
+	b.properties.write(&out)
+
+	return out.String()
+}
+
+// WriteTo writes the serialized resource to w
+func (b *ResourceBuilder) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// WriteFile writes the serialized resource to a .tres file
+func (b *ResourceBuilder) WriteFile(path string) error {
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// VariantString formats a Go string as a Godot String value
+func VariantString(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + replacer.Replace(s) + `"`
+}
+
+// VariantStringName formats a Go string as a Godot StringName value
+func VariantStringName(s string) string {
+	return "&" + VariantString(s)
+}
+
+// VariantBool formats a Godot bool value
+func VariantBool(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+// VariantInt formats a Godot int value
+func VariantInt(i int) string {
+	return strconv.Itoa(i)
+}
+
+// VariantFloat formats a Godot float value. Whole numbers keep a ".0" so
+// they are read back as floats.
+func VariantFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// formatVariantNumber formats a single precision number inside a constructor like Vector2(...)
+func formatVariantNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 32)
+}
+
+// variantConstructor formats Name(a, b, ...) from numbers
+func variantConstructor(name string, values ...float64) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = formatVariantNumber(value)
+	}
+	return name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// VariantVector2 formats a Godot Vector2 value
+func VariantVector2(x, y float64) string {
+	return variantConstructor("Vector2", x, y)
+}
+
+// VariantVector3 formats a Godot Vector3 value
+func VariantVector3(x, y, z float64) string {
+	return variantConstructor("Vector3", x, y, z)
+}
+
+// VariantColor formats a Godot Color value
+func VariantColor(r, g, b, a float64) string {
+	return variantConstructor("Color", r, g, b, a)
+}
+
+// VariantPackedFloat32Array formats a Godot PackedFloat32Array value
+func VariantPackedFloat32Array(values ...float64) string {
+	return variantConstructor("PackedFloat32Array", values...)
+}
+
+// VariantPackedColorArray formats a Godot PackedColorArray from RGBA quadruples
+func VariantPackedColorArray(colors ...[4]float64) string {
+	var values []float64
+	for _, color := range colors {
+		values = append(values, color[:]...)
+	}
+	return variantConstructor("PackedColorArray", values...)
+}
+
+// VariantPackedStringArray formats a Godot PackedStringArray value
+func VariantPackedStringArray(values ...string) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = VariantString(value)
+	}
+	return "PackedStringArray(" + strings.Join(parts, ", ") + ")"
+}
+
+// VariantArray formats a Godot Array from raw values
+func VariantArray(values ...string) string {
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+// VariantDictionary formats a Godot Dictionary from raw keys and values,
+// sorted by key for stable output
+func VariantDictionary(entries map[string]string) string {
+	if len(entries) == 0 {
+		return "{}"
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + ": " + entries[key]
+	}
+	return "{\n" + strings.Join(lines, ",\n") + "\n}"
+}
@@ -0,0 +1,69 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResourceBuilder(t *testing.T) {
+	builder := NewResourceBuilder("Resource")
+	builder.ScriptClass = "LevelData"
+	script := builder.AddExtResource("Script", "res://level_data.gd")
+	if again := builder.AddExtResource("Script", "res://level_data.gd"); again != script {
+		t.Errorf("Expected the same reference for a duplicate ext_resource, got %s and %s", script, again)
+	}
+
+	gradient := builder.AddSubResource("Gradient").
+		Set("offsets", VariantPackedFloat32Array(0, 0.5, 1)).
+		Set("colors", VariantPackedColorArray([4]float64{1, 0, 0, 1}, [4]float64{0, 0, 1, 1}))
+
+	builder.Set("script", script).
+		Set("name", VariantString(`Level "1"`)).
+		Set("gravity", VariantFloat(980)).
+		Set("gradient", gradient.Ref()).
+		Set("remaps", VariantDictionary(map[string]string{
+			VariantString("res://a.png"): VariantPackedStringArray("res://a_ja.png:ja"),
+		})).
+		Set("name", VariantString("Level 1"))
+
+	expected := `[gd_resource type="Resource" script_class="LevelData" load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://level_data.gd" id="1"]
+
+[sub_resource type="Gradient" id="Gradient_1"]
+offsets = PackedFloat32Array(0, 0.5, 1)
+colors = PackedColorArray(1, 0, 0, 1, 0, 0, 1, 1)
+
+[resource]
+script = ExtResource("1")
+name = "Level 1"
+gravity = 980.0
+gradient = SubResource("Gradient_1")
+remaps = {
+"res://a.png": PackedStringArray("res://a_ja.png:ja")
+}
+`
+	if got := builder.String(); got != expected {
+		t.Fatalf("Unexpected resource output:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	// The written file can be read back by the parser
+	path := filepath.Join(t.TempDir(), "level.tres")
+	if err := builder.WriteFile(path); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	scene, err := ParseTscnFile(path)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if scene.ExtResources["1"] == nil || scene.SubResources["Gradient_1"] == nil {
+		t.Errorf("Resources not parsed back: %v %v", scene.ExtResources, scene.SubResources)
+	}
+
+	content, _ := os.ReadFile(path)
+	text := splitSceneText(string(content))
+	if len(text.Sections) != 4 {
+		t.Errorf("Expected 4 sections, got %d", len(text.Sections))
+	}
+}
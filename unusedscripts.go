@@ -0,0 +1,102 @@
+package gdquery
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// classNameRe matches a GDScript class_name declaration
+var classNameRe = regexp.MustCompile(`(?m)^class_name\s+\w+`)
+
+// csharpGodotClassRe matches a C# partial class; Godot 4 requires partial classes for scripts
+var csharpGodotClassRe = regexp.MustCompile(`\bpartial\s+class\b`)
+
+func init() {
+	registerLintRule(&LintRule{
+		Name:        "unused-script",
+		Description: "Scripts never referenced by a scene, resource, autoload, plugin or preload/load call (candidates for deletion)",
+		Check:       checkUnusedScripts,
+	})
+}
+
+// pluginScripts returns the scripts declared by the plugin.cfg files of the project
+func pluginScripts(root string) []string {
+	files, err := findProjectFiles(root, []string{".cfg"})
+	if err != nil {
+		return nil
+	}
+
+	var scripts []string
+	for _, file := range files {
+		if !strings.EqualFold(filepath.Base(file), "plugin.cfg") {
+			continue
+		}
+		config, err := parseConfigFile(file)
+		if err != nil {
+			continue
+		}
+		if script := config.GetString("plugin", "script"); script != "" {
+			scripts = append(scripts, normalizeResPath(fsToRes(root, file), script))
+		}
+	}
+	return scripts
+}
+
+// checkUnusedScripts reports scripts that nothing references. Scripts declaring a
+// class_name can be used by name anywhere and are not reported; for C# only
+// partial classes (Godot scripts) are considered.
+func checkUnusedScripts(ctx *LintContext) []LintFinding {
+	graph := ctx.Graph()
+
+	referenced := make(map[string]bool)
+	for from, edges := range graph.Edges {
+		for _, edge := range edges {
+			if edge.To != from {
+				referenced[edge.To] = true
+			}
+		}
+	}
+	if section := ctx.Project().Section("autoload"); section != nil {
+		for _, key := range section.Keys {
+			referenced[strings.TrimPrefix(unquoteValue(section.Values[key]), "*")] = true
+		}
+	}
+	for _, script := range pluginScripts(ctx.Root) {
+		referenced[script] = true
+	}
+
+	files, err := findProjectFiles(ctx.Dir, []string{".gd", ".cs"})
+	if err != nil {
+		logger.Warn("Script scan failed", "error", err)
+		return nil
+	}
+
+	var findings []LintFinding
+	for _, file := range files {
+		resPath := fsToRes(ctx.Root, file)
+		if referenced[resPath] {
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			logger.Warn("Skipping script", "path", file, "error", err)
+			continue
+		}
+		if hasExtension(file, []string{".cs"}) {
+			if !csharpGodotClassRe.Match(content) {
+				continue
+			}
+		} else if classNameRe.Match(content) {
+			continue
+		}
+
+		findings = append(findings, LintFinding{
+			File:    resPath,
+			Message: "script is not referenced by any scene, resource, autoload or script",
+		})
+	}
+	return findings
+}
@@ -0,0 +1,124 @@
+package gdquery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GodotVersion is a Godot engine version. Major is 0 when unknown, Minor is -1
+// when only the major version could be inferred.
+type GodotVersion struct {
+	Major int
+	Minor int
+}
+
+// unknownGodotVersion is the version of files that give no hint
+var unknownGodotVersion = GodotVersion{Minor: -1}
+
+// String formats the version as "4.2", "4.x", or "" when unknown
+func (v GodotVersion) String() string {
+	switch {
+	case v.Major == 0:
+		return ""
+	case v.Minor < 0:
+		return fmt.Sprintf("%d.x", v.Major)
+	}
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// MarshalText encodes the version in its String form (JSON and the index)
+func (v GodotVersion) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText decodes a version written by MarshalText
+func (v *GodotVersion) UnmarshalText(text []byte) error {
+	*v = parseGodotVersion(string(text))
+	return nil
+}
+
+// Known reports whether the major version is known
+func (v GodotVersion) Known() bool {
+	return v.Major != 0
+}
+
+// versionNumberRe matches a "major.minor" version such as the entries of config/features
+var versionNumberRe = regexp.MustCompile(`^(\d+)\.(x|\d+)`)
+
+// parseGodotVersion parses "4.2" or "4.x"
+func parseGodotVersion(s string) GodotVersion {
+	matches := versionNumberRe.FindStringSubmatch(strings.TrimSpace(s))
+	if matches == nil {
+		return unknownGodotVersion
+	}
+	version := GodotVersion{Minor: -1}
+	version.Major, _ = strconv.Atoi(matches[1])
+	if minor, err := strconv.Atoi(matches[2]); err == nil {
+		version.Minor = minor
+	}
+	return version
+}
+
+// sceneFormatMajors maps the format of [gd_scene]/[gd_resource] headers to the Godot major version
+var sceneFormatMajors = map[int]int{1: 2, 2: 3, 3: 4, 4: 4}
+
+// projectConfigMajors maps config_version of project.godot to the Godot major version
+var projectConfigMajors = map[int]int{3: 2, 4: 3, 5: 4}
+
+// godot4Types are node types that only exist in Godot 4
+var godot4Types = map[string]bool{
+	"Node3D": true, "CharacterBody2D": true, "CharacterBody3D": true, "MeshInstance3D": true,
+	"Camera3D": true, "GPUParticles2D": true, "GPUParticles3D": true, "Marker2D": true, "Marker3D": true,
+	"PointLight2D": true, "SubViewport": true, "TileMapLayer": true,
+}
+
+// godot3PropertyPrefixes are property names that Godot 4 renamed
+var godot3PropertyPrefixes = []string{"rect_", "margin_", "custom_fonts/", "custom_colors/", "custom_constants/", "custom_styles/"}
+
+// detectSceneVersion infers the Godot version that wrote a scene from its format,
+// falling back to node types and property names only one version uses
+// (Godot 3 types are the keys of legacyTypeRenames)
+func detectSceneVersion(scene *GodotScene) GodotVersion {
+	if major, exists := sceneFormatMajors[scene.Format]; exists {
+		return GodotVersion{Major: major, Minor: -1}
+	}
+
+	for _, node := range scene.AllNodes {
+		_, legacy := legacyTypeRenames[node.Type]
+		switch {
+		case legacy:
+			return GodotVersion{Major: 3, Minor: -1}
+		case godot4Types[node.Type]:
+			return GodotVersion{Major: 4, Minor: -1}
+		}
+		for prop := range node.Properties {
+			for _, prefix := range godot3PropertyPrefixes {
+				if strings.HasPrefix(prop, prefix) {
+					return GodotVersion{Major: 3, Minor: -1}
+				}
+			}
+		}
+	}
+	return unknownGodotVersion
+}
+
+// detectProjectVersion infers the Godot version of a project from
+// application/config/features (e.g. PackedStringArray("4.2", "Forward Plus"))
+// or, for older projects, from config_version
+func detectProjectVersion(project *ConfigFile) GodotVersion {
+	if features, exists := project.Get("application", "config/features"); exists {
+		for _, feature := range parseStringList(features) {
+			if version := parseGodotVersion(feature); version.Known() {
+				return version
+			}
+		}
+	}
+
+	configVersion, _ := strconv.Atoi(strings.TrimSpace(project.GetString("", "config_version")))
+	if major, exists := projectConfigMajors[configVersion]; exists {
+		return GodotVersion{Major: major, Minor: -1}
+	}
+	return unknownGodotVersion
+}
@@ -0,0 +1,48 @@
+package gdquery
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGodotVersionDetection(t *testing.T) {
+	tests := []struct {
+		project  string
+		expected string
+	}{
+		{"config_version=5\n\n[application]\n\nconfig/features=PackedStringArray(\"4.2\", \"Forward Plus\")\n", "4.2"},
+		{"config_version=5\n", "4.x"},
+		{"config_version=4\n\n[application]\n\nconfig/name=\"Old\"\n", "3.x"},
+		{"[application]\n", ""},
+	}
+	for _, test := range tests {
+		if got := detectProjectVersion(parseConfigText(test.project)).String(); got != test.expected {
+			t.Errorf("Expected project version %q, got %q for:\n%s", test.expected, got, test.project)
+		}
+	}
+
+	scene := &GodotScene{Format: 2}
+	if got := detectSceneVersion(scene).String(); got != "3.x" {
+		t.Errorf("Expected 3.x for format 2, got %q", got)
+	}
+	// Without a format, node types and renamed properties give the version away
+	scene = &GodotScene{AllNodes: []*GodotNode{{Type: "Control", Properties: map[string]string{"rect_size": "Vector2(1, 1)"}}}}
+	if got := detectSceneVersion(scene).String(); got != "3.x" {
+		t.Errorf("Expected 3.x from rect_size, got %q", got)
+	}
+	scene = &GodotScene{AllNodes: []*GodotNode{{Type: "CharacterBody2D", Properties: map[string]string{}}}}
+	if got := detectSceneVersion(scene).String(); got != "4.x" {
+		t.Errorf("Expected 4.x from CharacterBody2D, got %q", got)
+	}
+
+	// Versions round-trip through JSON (index)
+	var decoded struct{ Version GodotVersion }
+	if err := json.Unmarshal([]byte(`{"Version":"4.3"}`), &decoded); err != nil || decoded.Version != (GodotVersion{Major: 4, Minor: 3}) {
+		t.Errorf("Unexpected decoded version: %+v (%v)", decoded.Version, err)
+	}
+
+	rule := &LintRule{Versions: []int{4}}
+	if rule.appliesTo(GodotVersion{Major: 3, Minor: -1}) || !rule.appliesTo(GodotVersion{Major: 4, Minor: 1}) || !rule.appliesTo(unknownGodotVersion) {
+		t.Error("Unexpected version gating of a Godot 4 rule")
+	}
+}
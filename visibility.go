@@ -0,0 +1,172 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Visibility view options
+var showEffectiveVisibility = false
+var showHiddenOnly = false
+
+// NodeVisibility is the visibility of a node at load time
+type NodeVisibility struct {
+	Node     *GodotNode
+	Applies  bool       // the node type has a visibility (CanvasItem, Node3D, CanvasLayer)
+	Unknown  bool       // the node instances a scene whose root type is not known
+	Visible  bool       // own visibility combined with the inherited one
+	HiddenBy *GodotNode // node whose settings hide this node
+	Reason   string
+}
+
+// visibilityKind returns the visibility chain a node type belongs to:
+// "canvas" (CanvasItem), "layer" (CanvasLayer), "3d" (Node3D/Spatial) or ""
+func visibilityKind(nodeType string) string {
+	switch {
+	case classInherits(nodeType, "CanvasItem"):
+		return "canvas"
+	case classInherits(nodeType, "CanvasLayer"):
+		return "layer"
+	case classInherits(nodeType, "Node3D") || classInherits(nodeType, "Spatial"):
+		return "3d"
+	}
+	return ""
+}
+
+// inheritsVisibility reports whether a node of kind child inherits the visibility of
+// a parent of kind parent. A plain Node in between breaks the chain.
+func inheritsVisibility(child, parent string) bool {
+	switch child {
+	case "canvas":
+		return parent == "canvas" || parent == "layer"
+	case "3d":
+		return parent == "3d"
+	}
+	return false
+}
+
+// colorAlpha returns the alpha of a Color(r, g, b, a) value, or 1 when unknown
+func colorAlpha(value string) float64 {
+	if values := parseNumberList(value); len(values) == 4 {
+		return values[3]
+	}
+	return 1
+}
+
+// resolveVisibility computes the visibility of node from the visibility of its
+// parent, nil for the scene root
+func resolveVisibility(node *GodotNode, parent *NodeVisibility) *NodeVisibility {
+	kind := visibilityKind(nodeClass(node))
+	visibility := &NodeVisibility{Node: node, Applies: kind != "", Visible: true}
+	if nodeClass(node) == "" {
+		// An instance of an unresolved scene: only its own visible = false is known
+		visibility.Unknown = true
+		if node.Properties["visible"] == "false" {
+			visibility.Applies, visibility.Visible, visibility.HiddenBy, visibility.Reason = true, false, node, "visible = false"
+		}
+		return visibility
+	}
+
+	// self_modulate only hides the node itself
+	inherited := parent != nil && parent.Applies && inheritsVisibility(kind, visibilityKind(nodeClass(parent.Node))) &&
+		!parent.Visible && !(parent.HiddenBy == parent.Node && parent.Reason == "self_modulate alpha 0")
+	switch {
+	case !visibility.Applies:
+	case inherited:
+		visibility.Visible, visibility.HiddenBy, visibility.Reason = false, parent.HiddenBy, parent.Reason
+	case node.Properties["visible"] == "false":
+		visibility.Visible, visibility.HiddenBy, visibility.Reason = false, node, "visible = false"
+	case kind == "canvas" && colorAlpha(node.Properties["modulate"]) == 0:
+		visibility.Visible, visibility.HiddenBy, visibility.Reason = false, node, "modulate alpha 0"
+	case kind == "canvas" && colorAlpha(node.Properties["self_modulate"]) == 0:
+		visibility.Visible, visibility.HiddenBy, visibility.Reason = false, node, "self_modulate alpha 0"
+	}
+	return visibility
+}
+
+// computeEffectiveVisibility resolves the visibility of every node under root in tree order.
+// A node is hidden when it or an ancestor in its visibility chain has visible = false,
+// or when the modulate alpha of the chain (or its own self_modulate alpha) is 0.
+func computeEffectiveVisibility(root *GodotNode) []*NodeVisibility {
+	var result []*NodeVisibility
+
+	var walk func(node *GodotNode, parent *NodeVisibility)
+	walk = func(node *GodotNode, parent *NodeVisibility) {
+		visibility := resolveVisibility(node, parent)
+		result = append(result, visibility)
+
+		for _, child := range node.Children {
+			walk(child, visibility)
+		}
+	}
+	if root != nil {
+		walk(root, nil)
+	}
+
+	return result
+}
+
+// EffectiveVisibility resolves the visibility of the node at load time from its
+// own settings and those of its ancestors, as computeEffectiveVisibility does
+// for a whole tree
+func (n *GodotNode) EffectiveVisibility() *NodeVisibility {
+	var visibility *NodeVisibility
+	ancestors := n.Ancestors()
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		visibility = resolveVisibility(ancestors[i], visibility)
+	}
+	return resolveVisibility(n, visibility)
+}
+
+// EffectiveVisible reports whether the node is visible at load time. Nodes
+// without a visibility (a plain Node, a Timer) are visible.
+func (n *GodotNode) EffectiveVisible() bool {
+	return n.EffectiveVisibility().Visible
+}
+
+// describeVisibility formats the visibility of a node for display
+func describeVisibility(visibility *NodeVisibility) string {
+	switch {
+	case !visibility.Applies && visibility.Unknown:
+		return "unknown type"
+	case !visibility.Applies:
+		return "no visibility"
+	case visibility.Visible:
+		return "visible"
+	case visibility.HiddenBy == visibility.Node:
+		return fmt.Sprintf("hidden (%s)", visibility.Reason)
+	}
+	return fmt.Sprintf("hidden (%s on %s)", visibility.Reason, visibility.HiddenBy.Path)
+}
+
+// printEffectiveVisibility displays the effective visibility of the nodes under target.
+// With hiddenOnly, only the nodes hidden at load are listed by path.
+func printEffectiveVisibility(out io.Writer, scene *GodotScene, target *GodotNode, hiddenOnly bool) {
+	inTarget := false
+	depth := 0
+	for _, visibility := range computeEffectiveVisibility(scene.RootNode) {
+		node := visibility.Node
+		nodeDepth := strings.Count(node.Path, "/")
+
+		// The results are in tree order: the subtree of target follows it
+		if node == target {
+			inTarget, depth = true, nodeDepth
+		} else if inTarget && nodeDepth <= depth {
+			break
+		}
+		if !inTarget {
+			continue
+		}
+
+		if hiddenOnly {
+			if visibility.Applies && !visibility.Visible {
+				fmt.Fprintf(out, "%s (%s): %s\n", node.Path, typeLabel(node), describeVisibility(visibility))
+			}
+			continue
+		}
+
+		fmt.Fprintf(out, "%s%s (%s) [%s]\n", strings.Repeat("  ", nodeDepth-depth), node.OriginalName,
+			typeLabel(node), describeVisibility(visibility))
+	}
+}
@@ -0,0 +1,93 @@
+package gdquery
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveVisibility(t *testing.T) {
+	content := `[gd_scene format=3]
+
+[node name="Main" type="Control"]
+
+[node name="Debug" type="Label" parent="."]
+visible = false
+
+[node name="Child" type="Label" parent="Debug"]
+
+[node name="Island" type="Node" parent="Debug"]
+
+[node name="Free" type="Sprite2D" parent="Debug/Island"]
+
+[node name="Fade" type="Panel" parent="."]
+modulate = Color(1, 1, 1, 0)
+
+[node name="Ghost" type="Label" parent="Fade"]
+
+[node name="Self" type="Panel" parent="."]
+self_modulate = Color(1, 1, 1, 0)
+
+[node name="Shown" type="Label" parent="Self"]
+
+[node name="Hud" parent="Debug" instance=ExtResource("1")]
+
+[node name="Enemy" parent="." instance=ExtResource("2")]
+
+[node name="Boss" parent="." instance=ExtResource("2")]
+visible = false
+`
+	tempFile := "test_visibility.tscn"
+	if err := os.WriteFile(tempFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	// Instance types are resolved from the instanced scenes when displaying
+	hud := findNodeByPath(scene, "Debug/Hud")
+	hud.InstanceOf, hud.InstanceType = "res://ui/hud.tscn", "Control"
+
+	expected := map[string]string{
+		"Main":              "visible",
+		"Main/Debug":        "hidden (visible = false)",
+		"Main/Debug/Child":  "hidden (visible = false on Main/Debug)",
+		"Main/Debug/Island": "no visibility",
+		"Main/Debug/Hud":    "hidden (visible = false on Main/Debug)",
+		// A plain Node breaks the visibility chain
+		"Main/Debug/Island/Free": "visible",
+		"Main/Fade":              "hidden (modulate alpha 0)",
+		"Main/Fade/Ghost":        "hidden (modulate alpha 0 on Main/Fade)",
+		"Main/Self":              "hidden (self_modulate alpha 0)",
+		"Main/Self/Shown":        "visible",
+		"Main/Enemy":             "unknown type",
+		"Main/Boss":              "hidden (visible = false)",
+	}
+
+	results := computeEffectiveVisibility(scene.RootNode)
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d nodes, got %d", len(expected), len(results))
+	}
+	for _, visibility := range results {
+		if got := describeVisibility(visibility); got != expected[visibility.Node.Path] {
+			t.Errorf("%s: expected %q, got %q", visibility.Node.Path, expected[visibility.Node.Path], got)
+		}
+		// The per-node API resolves the same chain
+		if got := describeVisibility(visibility.Node.EffectiveVisibility()); got != expected[visibility.Node.Path] {
+			t.Errorf("%s: EffectiveVisibility gave %q", visibility.Node.Path, got)
+		}
+	}
+	if findNodeByPath(scene, "Debug/Child").EffectiveVisible() || !findNodeByPath(scene, "Debug/Island").EffectiveVisible() {
+		t.Error("Unexpected EffectiveVisible results")
+	}
+
+	var out strings.Builder
+	printEffectiveVisibility(&out, scene, hud, false)
+	if got := out.String(); got != "Hud (Control, instance of hud.tscn) [hidden (visible = false on Main/Debug)]\n" {
+		t.Errorf("Unexpected instance line: %q", got)
+	}
+}
@@ -0,0 +1,307 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// visualShaderModes are the names of VisualShader.mode values
+var visualShaderModes = []string{"spatial", "canvas_item", "particles", "sky", "fog"}
+
+// visualShaderValueSize is the length over which node property values are cut in text and DOT output
+const visualShaderValueSize = 60
+
+// VisualShaderNode is a node of a VisualShader graph
+type VisualShaderNode struct {
+	ID         int               `json:"id"`
+	Type       string            `json:"type"` // class without the VisualShaderNode prefix
+	Position   string            `json:"position,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	keys       []string          // property keys in file order
+}
+
+// VisualShaderConnection connects an output port of a node to an input port of another
+type VisualShaderConnection struct {
+	FromNode int `json:"from_node"`
+	FromPort int `json:"from_port"`
+	ToNode   int `json:"to_node"`
+	ToPort   int `json:"to_port"`
+}
+
+// VisualShaderFunction is the graph of one shader function (vertex, fragment, light...)
+type VisualShaderFunction struct {
+	Name        string                    `json:"name"`
+	Nodes       []*VisualShaderNode       `json:"nodes"`
+	Connections []*VisualShaderConnection `json:"connections"`
+}
+
+// VisualShader is a decoded VisualShader resource
+type VisualShader struct {
+	ID        string                  `json:"id"` // "resource" for the main resource of a .tres file
+	Mode      string                  `json:"mode"`
+	Functions []*VisualShaderFunction `json:"functions"`
+}
+
+// node returns the node with the given id, or nil
+func (f *VisualShaderFunction) node(id int) *VisualShaderNode {
+	for _, node := range f.Nodes {
+		if node.ID == id {
+			return node
+		}
+	}
+	return nil
+}
+
+// nodeType returns the type of the node with the given id, or "?" when it is not in the graph
+func (f *VisualShaderFunction) nodeType(id int) string {
+	if node := f.node(id); node != nil {
+		return node.Type
+	}
+	return "?"
+}
+
+// findVisualShaders decodes the VisualShader resources of a scene or resource
+// file, the [resource] section of a .tres file included under the id "resource"
+func findVisualShaders(text *sceneText) []*VisualShader {
+	mainType := ""
+	var shaders []*VisualShader
+	for _, section := range text.Sections {
+		resourceType := ""
+		if matches := sectionTypeRe.FindStringSubmatch(section.Header); matches != nil {
+			resourceType = matches[1]
+		}
+
+		switch {
+		case strings.HasPrefix(section.Header, "[gd_resource"):
+			mainType = resourceType
+		case strings.HasPrefix(section.Header, "[resource]"):
+			if mainType == "VisualShader" {
+				shaders = append(shaders, decodeVisualShader(text, mainResourceID, section))
+			}
+		case strings.HasPrefix(section.Header, "[sub_resource"):
+			matches := sectionIDRe.FindStringSubmatch(section.Header)
+			if matches != nil && resourceType == "VisualShader" {
+				shaders = append(shaders, decodeVisualShader(text, matches[1]+matches[2], section))
+			}
+		}
+	}
+	return shaders
+}
+
+// decodeVisualShader reads the nodes/<function>/<id>/node and position
+// properties and the nodes/<function>/connections arrays, which hold four
+// numbers per connection: from node, from port, to node, to port. Node 0 is
+// the output node of the function, which has no sub_resource.
+func decodeVisualShader(text *sceneText, id string, section *sceneSection) *VisualShader {
+	shader := &VisualShader{ID: id, Mode: visualShaderModes[0]}
+	keys, values := section.PropertyValues()
+	if mode, err := strconv.Atoi(strings.TrimSpace(values["mode"])); err == nil && mode >= 0 && mode < len(visualShaderModes) {
+		shader.Mode = visualShaderModes[mode]
+	}
+
+	functions := make(map[string]*VisualShaderFunction)
+	for _, key := range keys {
+		parts := strings.SplitN(key, "/", 4)
+		if len(parts) < 3 || parts[0] != "nodes" {
+			continue
+		}
+		function := functions[parts[1]]
+		if function == nil {
+			function = &VisualShaderFunction{Name: parts[1]}
+			functions[parts[1]] = function
+			shader.Functions = append(shader.Functions, function)
+		}
+
+		if parts[2] == "connections" {
+			numbers := parseNumberList(values[key])
+			for i := 0; i+4 <= len(numbers); i += 4 {
+				function.Connections = append(function.Connections, &VisualShaderConnection{
+					FromNode: int(numbers[i]), FromPort: int(numbers[i+1]), ToNode: int(numbers[i+2]), ToPort: int(numbers[i+3]),
+				})
+			}
+			continue
+		}
+		nodeID, err := strconv.Atoi(parts[2])
+		if err != nil || len(parts) < 4 {
+			continue
+		}
+		node := function.node(nodeID)
+		if node == nil {
+			node = &VisualShaderNode{ID: nodeID}
+			if nodeID == 0 {
+				node.Type = "Output"
+			}
+			function.Nodes = append(function.Nodes, node)
+		}
+		switch parts[3] {
+		case "position":
+			node.Position = values[key]
+		case "node":
+			node.Type = values[key]
+			ref := resourceRefName(values[key])
+			if sub := text.subResourceSection(ref); sub != nil {
+				if matches := sectionTypeRe.FindStringSubmatch(sub.Header); matches != nil {
+					node.Type = strings.TrimPrefix(matches[1], "VisualShaderNode")
+				}
+				var subValues map[string]string
+				node.keys, subValues = sub.PropertyValues()
+				if len(node.keys) > 0 {
+					node.Properties = subValues
+				}
+			}
+		}
+	}
+
+	for _, function := range shader.Functions {
+		sort.Slice(function.Nodes, func(i, j int) bool {
+			return function.Nodes[i].ID < function.Nodes[j].ID
+		})
+	}
+	return shader
+}
+
+// shortValue puts a property value on one line, cut to visualShaderValueSize
+func shortValue(value string) string {
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	if len(value) > visualShaderValueSize {
+		value = value[:visualShaderValueSize] + "..."
+	}
+	return value
+}
+
+// propertyList formats the properties of a node as key = value items in file order
+func (n *VisualShaderNode) propertyList() []string {
+	var items []string
+	for _, key := range n.keys {
+		items = append(items, key+" = "+shortValue(n.Properties[key]))
+	}
+	return items
+}
+
+// printVisualShaders displays the nodes and connections of each function
+func printVisualShaders(out io.Writer, shaders []*VisualShader) {
+	if len(shaders) == 0 {
+		fmt.Fprintln(out, "No visual shaders")
+		return
+	}
+	for i, shader := range shaders {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "VisualShader %s (%s)\n", shader.ID, shader.Mode)
+		for _, function := range shader.Functions {
+			fmt.Fprintf(out, "  %s: %d node(s), %d connection(s)\n", function.Name, len(function.Nodes), len(function.Connections))
+			for _, node := range function.Nodes {
+				fmt.Fprintf(out, "    #%d %s", node.ID, node.Type)
+				if properties := node.propertyList(); len(properties) > 0 {
+					fmt.Fprintf(out, "  %s", strings.Join(properties, ", "))
+				}
+				fmt.Fprintln(out)
+			}
+			for _, connection := range function.Connections {
+				fmt.Fprintf(out, "    #%d:%d %s -> #%d:%d %s\n",
+					connection.FromNode, connection.FromPort, function.nodeType(connection.FromNode),
+					connection.ToNode, connection.ToPort, function.nodeType(connection.ToNode))
+			}
+		}
+	}
+}
+
+// visualShaderGraphs converts each function of the shaders of a file into a
+// graph whose edges are labeled with their ports
+func visualShaderGraphs(file string, shaders []*VisualShader) []*ExportGraph {
+	var graphs []*ExportGraph
+	for _, shader := range shaders {
+		for _, function := range shader.Functions {
+			graph := &ExportGraph{Name: fmt.Sprintf("%s:%s/%s", file, shader.ID, function.Name)}
+			for _, node := range function.Nodes {
+				label := fmt.Sprintf("%s #%d", node.Type, node.ID)
+				if properties := node.propertyList(); len(properties) > 0 {
+					label += "\n" + strings.Join(properties, "\n")
+				}
+				graph.Nodes = append(graph.Nodes, &ExportGraphNode{ID: strconv.Itoa(node.ID), Label: label, Attrs: map[string]string{"type": node.Type}})
+			}
+			for _, connection := range function.Connections {
+				graph.Edges = append(graph.Edges, &ExportGraphEdge{
+					From: strconv.Itoa(connection.FromNode),
+					To:   strconv.Itoa(connection.ToNode),
+					Attrs: map[string]string{
+						"taillabel": strconv.Itoa(connection.FromPort),
+						"headlabel": strconv.Itoa(connection.ToPort),
+					},
+				})
+			}
+			graphs = append(graphs, graph)
+		}
+	}
+	return graphs
+}
+
+var visualShaderCmd = &cobra.Command{
+	Use:   "visual-shader <shader or scene file> [more files...]",
+	Short: "Print the node graph of VisualShader resources",
+	Long: `Decode the VisualShader resources of .tres files and scenes (embedded as sub_resources) and print,
+per shader function (vertex, fragment, light...), the nodes with the properties set on them and
+the connections between their ports, so that visual shaders can be reviewed in code review.
+Node 0 is the output node of each function. With -o dot or -o graphml, each function is written
+as a graph whose edges are labeled with the output and input ports.`,
+	Example: `  gdq visual-shader shaders/water.tres
+  gdq visual-shader -o dot shaders/water.tres | dot -Tsvg > water.svg`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if err := validateOutputFormat("text", "json", "dot", "graphml"); err != nil {
+			return err
+		}
+
+		var graphs []*ExportGraph
+		results := make(map[string][]*VisualShader)
+		for i, file := range args {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("read error: %v", err)
+			}
+			shaders := findVisualShaders(splitSceneText(string(content)))
+
+			switch outputFormat {
+			case "json":
+				if shaders == nil {
+					shaders = []*VisualShader{}
+				}
+				results[file] = shaders
+			case "dot", "graphml":
+				graphs = append(graphs, visualShaderGraphs(file, shaders)...)
+			default:
+				if len(args) > 1 {
+					if i > 0 {
+						fmt.Fprintln(out)
+					}
+					fmt.Fprintf(out, "=== %s ===\n", file)
+				}
+				printVisualShaders(out, shaders)
+			}
+		}
+
+		switch outputFormat {
+		case "json":
+			if len(args) == 1 {
+				return writeJSON(out, results[args[0]])
+			}
+			return writeJSON(out, results)
+		case "dot", "graphml":
+			return writeExportGraphs(out, graphs...)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(visualShaderCmd)
+}
@@ -0,0 +1,73 @@
+package gdquery
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+const visualShaderResource = `[gd_resource type="VisualShader" load_steps=4 format=3 uid="uid://bq1x3m5v7n2k4"]
+
+[sub_resource type="VisualShaderNodeInput" id="VisualShaderNodeInput_uv"]
+input_name = "uv"
+
+[sub_resource type="VisualShaderNodeTexture" id="VisualShaderNodeTexture_albedo"]
+
+[sub_resource type="VisualShaderNodeColorConstant" id="VisualShaderNodeColorConstant_tint"]
+constant = Color(1, 0.5, 0, 1)
+
+[resource]
+code = "shader_type canvas_item;
+"
+mode = 1
+graph_offset = Vector2(-120, 40)
+nodes/fragment/0/position = Vector2(600, 140)
+nodes/fragment/2/node = SubResource("VisualShaderNodeInput_uv")
+nodes/fragment/2/position = Vector2(-80, 120)
+nodes/fragment/3/node = SubResource("VisualShaderNodeTexture_albedo")
+nodes/fragment/3/position = Vector2(160, 100)
+nodes/fragment/4/node = SubResource("VisualShaderNodeColorConstant_tint")
+nodes/fragment/4/position = Vector2(160, 320)
+nodes/fragment/connections = PackedInt32Array(2, 0, 3, 0, 3, 0, 0, 0, 4, 1, 0, 1)
+`
+
+func TestFindVisualShaders(t *testing.T) {
+	shaders := findVisualShaders(splitSceneText(visualShaderResource))
+	if len(shaders) != 1 {
+		t.Fatalf("Expected 1 visual shader, got: %d", len(shaders))
+	}
+	shader := shaders[0]
+	if shader.ID != "resource" || shader.Mode != "canvas_item" || len(shader.Functions) != 1 {
+		t.Fatalf("Unexpected shader: %+v", shader)
+	}
+
+	fragment := shader.Functions[0]
+	var nodes []string
+	for _, node := range fragment.Nodes {
+		nodes = append(nodes, node.Type)
+	}
+	if got := strings.Join(nodes, ", "); got != "Output, Input, Texture, ColorConstant" {
+		t.Errorf("Unexpected nodes: %s", got)
+	}
+	if got := fragment.node(2).Properties["input_name"]; got != `"uv"` {
+		t.Errorf("Expected the input_name of node 2, got: %s", got)
+	}
+	if len(fragment.Connections) != 3 || *fragment.Connections[2] != (VisualShaderConnection{FromNode: 4, FromPort: 1, ToNode: 0, ToPort: 1}) {
+		t.Errorf("Unexpected connections: %v", fragment.Connections)
+	}
+
+	output := captureOutput(func(out io.Writer) { printVisualShaders(out, shaders) })
+	expected := `VisualShader resource (canvas_item)
+  fragment: 4 node(s), 3 connection(s)
+    #0 Output
+    #2 Input  input_name = "uv"
+    #3 Texture
+    #4 ColorConstant  constant = Color(1, 0.5, 0, 1)
+    #2:0 Input -> #3:0 Texture
+    #3:0 Texture -> #0:0 Output
+    #4:1 ColorConstant -> #0:1 Output
+`
+	if output != expected {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+}
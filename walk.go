@@ -0,0 +1,68 @@
+package gdquery
+
+// WalkAction tells Walk how to continue after visiting a node
+type WalkAction int
+
+const (
+	// WalkContinue visits the children of the node, then its later siblings
+	WalkContinue WalkAction = iota
+	// WalkSkipChildren does not visit the children of the node
+	WalkSkipChildren
+	// WalkStop ends the walk
+	WalkStop
+)
+
+// Walk visits the nodes of the scene tree depth-first in scene order, passing
+// each node and its depth (0 for the root) to fn. It returns false when fn stopped the walk.
+func (s *GodotScene) Walk(fn func(n *GodotNode, depth int) WalkAction) bool {
+	if s.RootNode == nil {
+		return true
+	}
+	return s.RootNode.Walk(fn)
+}
+
+// Walk visits n and its descendants depth-first, with depths relative to n.
+// It returns false when fn stopped the walk.
+func (n *GodotNode) Walk(fn func(n *GodotNode, depth int) WalkAction) bool {
+	return n.walk(fn, 0)
+}
+
+// walk visits n at the given depth and then its children
+func (n *GodotNode) walk(fn func(n *GodotNode, depth int) WalkAction, depth int) bool {
+	switch fn(n, depth) {
+	case WalkStop:
+		return false
+	case WalkSkipChildren:
+		return true
+	}
+	for _, child := range n.Children {
+		if !child.walk(fn, depth+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParentNode returns the parent of the node in the scene tree, or nil for the root
+func (n *GodotNode) ParentNode() *GodotNode {
+	return n.parent
+}
+
+// Ancestors returns the ancestors of the node, nearest first and the root last
+func (n *GodotNode) Ancestors() []*GodotNode {
+	var ancestors []*GodotNode
+	for parent := n.parent; parent != nil; parent = parent.parent {
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// IsAncestorOf reports whether n is a (direct or indirect) parent of node
+func (n *GodotNode) IsAncestorOf(node *GodotNode) bool {
+	for parent := node.parent; parent != nil; parent = parent.parent {
+		if parent == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package gdquery
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSceneWalk(t *testing.T) {
+	tempFile := "test_walk.tscn"
+	if err := os.WriteFile(tempFile, []byte(testTscnContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var visited []string
+	completed := scene.Walk(func(n *GodotNode, depth int) WalkAction {
+		visited = append(visited, fmt.Sprintf("%s:%d", n.Name, depth))
+		return WalkContinue
+	})
+	if got := strings.Join(visited, " "); !completed || got != "Root:0 Child1:1 GrandChild:2 DeepChild:3 Child2:1" {
+		t.Errorf("Unexpected walk order: %s", got)
+	}
+
+	visited = nil
+	scene.Walk(func(n *GodotNode, depth int) WalkAction {
+		visited = append(visited, n.Name)
+		if n.Name == "Child1" {
+			return WalkSkipChildren
+		}
+		return WalkContinue
+	})
+	if got := strings.Join(visited, " "); got != "Root Child1 Child2" {
+		t.Errorf("Expected the subtree of Child1 to be skipped, got: %s", got)
+	}
+
+	visited = nil
+	completed = scene.Walk(func(n *GodotNode, depth int) WalkAction {
+		visited = append(visited, n.Name)
+		if n.Name == "GrandChild" {
+			return WalkStop
+		}
+		return WalkContinue
+	})
+	if got := strings.Join(visited, " "); completed || got != "Root Child1 GrandChild" {
+		t.Errorf("Expected the walk to stop at GrandChild, got: %s (completed %t)", got, completed)
+	}
+
+	deep := findNodeByPath(scene, "Root/Child1/GrandChild/DeepChild")
+	if deep == nil {
+		t.Fatalf("DeepChild not found")
+	}
+	if parent := deep.ParentNode(); parent == nil || parent.Name != "GrandChild" {
+		t.Errorf("Unexpected parent: %v", parent)
+	}
+	var ancestors []string
+	for _, ancestor := range deep.Ancestors() {
+		ancestors = append(ancestors, ancestor.Name)
+	}
+	if got := strings.Join(ancestors, " "); got != "GrandChild Child1 Root" {
+		t.Errorf("Unexpected ancestors: %s", got)
+	}
+	if scene.RootNode.ParentNode() != nil || !scene.RootNode.IsAncestorOf(deep) || deep.IsAncestorOf(scene.RootNode) {
+		t.Errorf("Unexpected ancestry of the root node")
+	}
+}
@@ -0,0 +1,263 @@
+package gdquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a non-empty line of a YAML document without its comment
+type yamlLine struct {
+	Number int
+	Indent int
+	Text   string
+}
+
+// parseYAML parses the subset of YAML used by gdq spec files: block mappings and
+// sequences, flow sequences ([a, b]), plain and quoted scalars, and comments.
+// Mappings become map[string]any, sequences []any, and scalars string, bool,
+// int64, float64 or nil. Anchors, multi-line scalars and flow mappings are not
+// supported.
+func parseYAML(content string) (any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		text := stripYAMLComment(raw)
+		trimmed := strings.TrimLeft(text, " ")
+		if strings.TrimSpace(trimmed) == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", i+1)
+		}
+		lines = append(lines, yamlLine{Number: i + 1, Indent: len(text) - len(trimmed), Text: strings.TrimRight(trimmed, " \t")})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	value, next, err := parseYAMLBlock(lines, 0, lines[0].Indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].Number)
+	}
+	return value, nil
+}
+
+// stripYAMLComment removes a # comment, which starts a line or follows a space, outside of quotes
+func stripYAMLComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\'') && (i == 0 || strings.ContainsRune(" \t:-[,", rune(line[i-1]))):
+			quote = c
+		case quote == 0 && c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseYAMLBlock parses the mapping or sequence whose lines start at lines[i]
+// with the given indentation, and returns the index of the first line after it
+func parseYAMLBlock(lines []yamlLine, i, indent int) (any, int, error) {
+	if isYAMLSequenceItem(lines[i].Text) {
+		return parseYAMLSequence(lines, i, indent)
+	}
+	return parseYAMLMapping(lines, i, indent)
+}
+
+// isYAMLSequenceItem reports whether a line is a "- item" of a sequence
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseYAMLSequence parses the "- item" lines at indent
+func parseYAMLSequence(lines []yamlLine, i, indent int) (any, int, error) {
+	items := []any{}
+	for i < len(lines) && lines[i].Indent == indent && isYAMLSequenceItem(lines[i].Text) {
+		line := lines[i]
+		item := strings.TrimSpace(strings.TrimPrefix(line.Text, "-"))
+		switch {
+		case item == "":
+			// The item is the block on the following, deeper lines
+			if i+1 >= len(lines) || lines[i+1].Indent <= indent {
+				items = append(items, nil)
+				i++
+				continue
+			}
+			value, next, err := parseYAMLBlock(lines, i+1, lines[i+1].Indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			items, i = append(items, value), next
+		case yamlKeyEnd(item) >= 0 || isYAMLSequenceItem(item):
+			// "- key: value" starts a mapping (or "- - item" a sequence) indented
+			// at the item, continued by the following lines
+			itemIndent := indent + len(line.Text) - len(item)
+			lines[i] = yamlLine{Number: line.Number, Indent: itemIndent, Text: item}
+			value, next, err := parseYAMLBlock(lines, i, itemIndent)
+			if err != nil {
+				return nil, 0, err
+			}
+			items, i = append(items, value), next
+		default:
+			value, err := parseYAMLScalar(item, line.Number)
+			if err != nil {
+				return nil, 0, err
+			}
+			items, i = append(items, value), i+1
+		}
+	}
+	if i < len(lines) && lines[i].Indent > indent {
+		return nil, 0, fmt.Errorf("line %d: unexpected indentation", lines[i].Number)
+	}
+	return items, i, nil
+}
+
+// parseYAMLMapping parses the "key: value" lines at indent
+func parseYAMLMapping(lines []yamlLine, i, indent int) (any, int, error) {
+	mapping := make(map[string]any)
+	for i < len(lines) && lines[i].Indent == indent {
+		line := lines[i]
+		end := yamlKeyEnd(line.Text)
+		if end < 0 {
+			return nil, 0, fmt.Errorf("line %d: expected \"key: value\"", line.Number)
+		}
+		key, err := parseYAMLScalar(line.Text[:end], line.Number)
+		if err != nil {
+			return nil, 0, err
+		}
+		name := fmt.Sprint(key)
+		if _, exists := mapping[name]; exists {
+			return nil, 0, fmt.Errorf("line %d: duplicate key %q", line.Number, name)
+		}
+		rest := strings.TrimSpace(line.Text[end+1:])
+		i++
+
+		switch {
+		case rest != "":
+			if mapping[name], err = parseYAMLScalar(rest, line.Number); err != nil {
+				return nil, 0, err
+			}
+		case i < len(lines) && (lines[i].Indent > indent || lines[i].Indent == indent && isYAMLSequenceItem(lines[i].Text)):
+			// A nested block, or a sequence written at the indentation of its key
+			if mapping[name], i, err = parseYAMLBlock(lines, i, lines[i].Indent); err != nil {
+				return nil, 0, err
+			}
+		default:
+			mapping[name] = nil
+		}
+	}
+	if i < len(lines) && lines[i].Indent > indent {
+		return nil, 0, fmt.Errorf("line %d: unexpected indentation", lines[i].Number)
+	}
+	return mapping, i, nil
+}
+
+// yamlKeyEnd returns the index of the colon ending the key of a "key: value"
+// line, or -1 when the line is not a mapping entry
+func yamlKeyEnd(text string) int {
+	start := 0
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return -1
+		}
+		start = end + 2
+	}
+	for i := start; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return i
+		}
+		if start > 0 {
+			return -1
+		}
+	}
+	return -1
+}
+
+// parseYAMLScalar parses a scalar or a flow sequence
+func parseYAMLScalar(text string, number int) (any, error) {
+	switch {
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("line %d: unterminated flow sequence", number)
+		}
+		items := []any{}
+		for _, item := range splitYAMLFlow(text[1 : len(text)-1]) {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			value, err := parseYAMLScalar(item, number)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	case strings.HasPrefix(text, "{"):
+		if strings.TrimSpace(strings.Trim(text, "{}")) == "" {
+			return map[string]any{}, nil
+		}
+		return nil, fmt.Errorf("line %d: flow mappings are not supported", number)
+	case strings.HasPrefix(text, `"`):
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid quoted string: %s", number, text)
+		}
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("line %d: invalid quoted string: %s", number, text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+
+	switch text {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return value, nil
+	}
+	if value, err := strconv.ParseFloat(text, 64); err == nil {
+		return value, nil
+	}
+	return text, nil
+}
+
+// splitYAMLFlow splits the items of a flow sequence at the commas outside of quotes and brackets
+func splitYAMLFlow(text string) []string {
+	var items []string
+	quote := byte(0)
+	depth, start := 0, 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, text[start:])
+}
@@ -0,0 +1,50 @@
+package gdquery
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	content := `# spec
+name: "Level # 1"   # comment
+count: 3
+ratio: 0.5
+enabled: true
+empty:
+tags: [a, 'b c', "d"]
+root:
+  type: Node2D
+  children:
+  - name: Spawn
+    type: [Marker2D]
+  -
+    name: HUD
+    children:
+      - Score
+      - - nested
+`
+	value, err := parseYAML(content)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	data, _ := json.Marshal(value)
+	expected := `{"count":3,"empty":null,"enabled":true,"name":"Level # 1","ratio":0.5,` +
+		`"root":{"children":[{"name":"Spawn","type":["Marker2D"]},{"children":["Score",["nested"]],"name":"HUD"}],"type":"Node2D"},` +
+		`"tags":["a","b c","d"]}`
+	if string(data) != expected {
+		t.Errorf("Unexpected value:\n%s", data)
+	}
+
+	for _, invalid := range []string{
+		"a: 1\n  b: 2\n",
+		"a: 1\na: 2\n",
+		"just text\n",
+		"a: {b: 1}\n",
+		"a:\n\t- b\n",
+	} {
+		if _, err := parseYAML(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package gdquery
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Z order view option
+var showZOrder = false
+
+// Limits of CanvasItem.z_index
+const (
+	zIndexMin = -4096
+	zIndexMax = 4096
+)
+
+// DrawItem is a CanvasItem with its place in the draw order
+type DrawItem struct {
+	Node         *GodotNode
+	Layer        int        // canvas layer, 0 for the default canvas
+	LayerNode    *GodotNode // CanvasLayer drawing the item, nil for the default canvas
+	Z            int        // effective z_index
+	ZAbsolute    bool       // z_as_relative = false
+	BehindParent bool       // show_behind_parent = true
+	YSorted      bool       // sorted by y among its siblings
+	Y            float64
+}
+
+// nodeClass returns the type of a node, or the root type of the scene it instances
+func nodeClass(node *GodotNode) string {
+	if node.Type == "" {
+		return node.InstanceType
+	}
+	return node.Type
+}
+
+// propertyInt returns an integer property, or fallback when it is missing or not a number
+func propertyInt(node *GodotNode, prop string, fallback int) int {
+	if value, err := strconv.Atoi(strings.TrimSpace(node.Properties[prop])); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// ySortsChildren reports whether a node draws its children in y order:
+// y_sort_enabled in Godot 4, YSort nodes in Godot 3
+func ySortsChildren(node *GodotNode) bool {
+	if classInherits(nodeClass(node), "YSort") {
+		return node.Properties["sort_enabled"] != "false"
+	}
+	return node.Properties["y_sort_enabled"] == "true"
+}
+
+// nodeY returns the y of the position of a node
+func nodeY(node *GodotNode) float64 {
+	if values := parseNumberList(node.Properties["position"]); len(values) == 2 {
+		return values[1]
+	}
+	return 0
+}
+
+// computeDrawOrder returns the CanvasItems under root back to front. Items are
+// ordered by canvas layer, then effective z_index, then tree order: a parent
+// is drawn before its children except those with show_behind_parent, and the
+// children of a y-sorted node are drawn by increasing y.
+func computeDrawOrder(root *GodotNode) []*DrawItem {
+	var items []*DrawItem
+
+	var walk func(node *GodotNode, layer int, layerNode *GodotNode, parent *DrawItem, ySorted bool)
+	walk = func(node *GodotNode, layer int, layerNode *GodotNode, parent *DrawItem, ySorted bool) {
+		kind := visibilityKind(nodeClass(node))
+		if kind == "layer" {
+			layer, layerNode = propertyInt(node, "layer", 1), node
+		}
+		if kind != "canvas" {
+			// Items below a layer or a plain Node start a new z chain
+			for _, child := range node.Children {
+				walk(child, layer, layerNode, nil, false)
+			}
+			return
+		}
+
+		item := &DrawItem{
+			Node:         node,
+			Layer:        layer,
+			LayerNode:    layerNode,
+			Z:            propertyInt(node, "z_index", 0),
+			ZAbsolute:    node.Properties["z_as_relative"] == "false",
+			BehindParent: node.Properties["show_behind_parent"] == "true",
+			YSorted:      ySorted,
+			Y:            nodeY(node),
+		}
+		if parent != nil && !item.ZAbsolute {
+			item.Z += parent.Z
+		}
+		item.Z = max(zIndexMin, min(zIndexMax, item.Z))
+
+		children := append([]*GodotNode(nil), node.Children...)
+		sortsChildren := ySortsChildren(node)
+		if sortsChildren {
+			sort.SliceStable(children, func(i, j int) bool { return nodeY(children[i]) < nodeY(children[j]) })
+		}
+
+		drawChildren := func(behind bool) {
+			for _, child := range children {
+				if (child.Properties["show_behind_parent"] == "true") == behind {
+					walk(child, layer, layerNode, item, sortsChildren)
+				}
+			}
+		}
+		drawChildren(true)
+		items = append(items, item)
+		drawChildren(false)
+	}
+	if root != nil {
+		walk(root, 0, nil, nil, false)
+	}
+
+	// Tree order is kept within a layer and z_index
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Layer != items[j].Layer {
+			return items[i].Layer < items[j].Layer
+		}
+		return items[i].Z < items[j].Z
+	})
+	return items
+}
+
+// describeDrawItem lists what affects the place of an item in the draw order
+func describeDrawItem(item *DrawItem) string {
+	var notes []string
+	if item.ZAbsolute {
+		notes = append(notes, "z absolute")
+	}
+	if item.BehindParent {
+		notes = append(notes, "behind parent")
+	}
+	if item.YSorted {
+		notes = append(notes, "y-sort y="+formatLayoutNumber(item.Y))
+	}
+	return strings.Join(notes, ", ")
+}
+
+// printZOrder displays the CanvasItems under target in draw order, back to front
+func printZOrder(out io.Writer, scene *GodotScene, target *GodotNode) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	position := 0
+	for _, item := range computeDrawOrder(scene.RootNode) {
+		node := item.Node
+		if node != target && !strings.HasPrefix(node.Path, target.Path+"/") {
+			continue
+		}
+		position++
+		layer := strconv.Itoa(item.Layer)
+		if item.LayerNode != nil {
+			layer += " (" + item.LayerNode.OriginalName + ")"
+		}
+		fmt.Fprintf(w, "%d\tlayer %s\tz %d\t%s (%s)\t%s\n", position, layer, item.Z, node.Path, typeLabel(node), describeDrawItem(item))
+	}
+	w.Flush()
+	if position == 0 {
+		fmt.Fprintln(out, "No CanvasItem nodes")
+	}
+}
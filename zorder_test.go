@@ -0,0 +1,92 @@
+package gdquery
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDrawOrder(t *testing.T) {
+	content := `[gd_scene format=3]
+
+[node name="Main" type="Node2D"]
+
+[node name="HUD" type="CanvasLayer" parent="."]
+
+[node name="Score" type="Label" parent="HUD"]
+
+[node name="World" type="Node2D" parent="."]
+z_index = 1
+y_sort_enabled = true
+
+[node name="Tree" type="Sprite2D" parent="World"]
+position = Vector2(0, 300)
+
+[node name="Player" type="Sprite2D" parent="World"]
+position = Vector2(0, 200)
+
+[node name="Shadow" type="Sprite2D" parent="World/Player"]
+show_behind_parent = true
+
+[node name="Background" type="Sprite2D" parent="."]
+z_index = -1
+
+[node name="Overlay" type="Sprite2D" parent="World"]
+z_index = 1
+z_as_relative = false
+
+[node name="Logic" type="Node" parent="."]
+
+[node name="Marker" type="Sprite2D" parent="Logic"]
+`
+	tempFile := "test_zorder.tscn"
+	if err := os.WriteFile(tempFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tempFile)
+
+	scene, err := ParseTscnFile(tempFile)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var got []string
+	for _, item := range computeDrawOrder(scene.RootNode) {
+		got = append(got, item.Node.Path)
+	}
+	expected := []string{
+		"Main/Background",
+		"Main",
+		// A plain Node starts a new z chain
+		"Main/Logic/Marker",
+		"Main/World",
+		// y-sorted by position, Overlay at y=0 first
+		"Main/World/Overlay",
+		"Main/World/Player/Shadow",
+		"Main/World/Player",
+		"Main/World/Tree",
+		"Main/HUD/Score",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected draw order:\n%s", strings.Join(got, "\n"))
+	}
+
+	output := captureOutput(func(out io.Writer) { printZOrder(out, scene, findNodeByPath(scene, "Main/World/Player")) })
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	expectedLines := []string{
+		"1 layer 0 z 1 Main/World/Player/Shadow (Sprite2D) behind parent",
+		"2 layer 0 z 1 Main/World/Player (Sprite2D) y-sort y=200",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expectedLines, "\n") {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	output = captureOutput(func(out io.Writer) { printZOrder(out, scene, findNodeByPath(scene, "Main/HUD")) })
+	if !strings.Contains(output, "layer 1 (HUD)  z 0  Main/HUD/Score (Label)") {
+		t.Errorf("Unexpected layer output:\n%s", output)
+	}
+}